@@ -0,0 +1,122 @@
+package graw
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/turnage/graw/clock"
+	"github.com/turnage/graw/reddit"
+)
+
+// agingInterval is how often the ager checks for posts which reached an age.
+const agingInterval = time.Second
+
+// agingPost is a post waiting to reach its next age.
+type agingPost struct {
+	post *reddit.Post
+	// next is the index of the next age the post will reach.
+	next int
+}
+
+// agedPost is a post which reached an age.
+type agedPost struct {
+	post *reddit.Post
+	age  time.Duration
+}
+
+// ager tracks posts and refreshes them as they reach each configured age.
+type ager struct {
+	lurker reddit.Lurker
+	ages   []time.Duration
+	clock  clock.Clock
+	posts  []*agingPost
+	mu     *sync.Mutex
+}
+
+func newAger(
+	lurker reddit.Lurker,
+	ages []time.Duration,
+	clk clock.Clock,
+) *ager {
+	sorted := append([]time.Duration{}, ages...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	return &ager{
+		lurker: lurker,
+		ages:   sorted,
+		clock:  clk,
+		mu:     &sync.Mutex{},
+	}
+}
+
+// track starts tracking a post. Ages the post is already past are skipped,
+// since a late snapshot would not be comparable with others at that age.
+func (a *ager) track(p *reddit.Post, now time.Time) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	age := p.Age(now)
+	next := sort.Search(len(a.ages), func(i int) bool { return a.ages[i] > age })
+	if next < len(a.ages) {
+		a.posts = append(a.posts, &agingPost{post: p, next: next})
+	}
+}
+
+// due returns the posts which reached an age by now, and stops tracking posts
+// which have reached every age.
+func (a *ager) due(now time.Time) []agedPost {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	var due []agedPost
+	remaining := a.posts[:0]
+	for _, p := range a.posts {
+		created := p.post.Created()
+		for p.next < len(a.ages) && !created.Add(a.ages[p.next]).After(now) {
+			due = append(due, agedPost{post: p.post, age: a.ages[p.next]})
+			p.next++
+		}
+
+		if p.next < len(a.ages) {
+			remaining = append(remaining, p)
+		}
+	}
+	a.posts = remaining
+
+	return due
+}
+
+// run forwards posts with refreshed score and comment data as they reach each
+// age, until killed. Posts which no longer exist are dropped.
+func (a *ager) run(
+	kill <-chan bool,
+	errs chan<- error,
+	forward func(p *reddit.Post, age time.Duration),
+) {
+	ticker := a.clock.NewTicker(agingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-kill:
+			return
+		case now := <-ticker.C():
+			for _, aged := range a.due(now) {
+				post, err := a.lurker.ThreadWithOptions(
+					aged.post.Permalink,
+					reddit.ThreadOptions{Depth: 1, Limit: 1},
+				)
+				if err == reddit.ThreadDoesNotExistErr {
+					continue
+				} else if err != nil {
+					errs <- err
+					continue
+				}
+
+				post.Replies = nil
+				forward(post, aged.age)
+			}
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package graw
+
+import (
+	"testing"
+	"time"
+
+	"github.com/turnage/graw/clock"
+	"github.com/turnage/graw/reddit"
+)
+
+func TestAgerDue(t *testing.T) {
+	a := newAger(
+		nil,
+		[]time.Duration{time.Hour, 10 * time.Minute},
+		clock.Real,
+	)
+	created := time.Unix(1000000, 0)
+	at := func(d time.Duration) time.Time { return created.Add(d) }
+
+	a.track(&reddit.Post{Name: "t3_new", CreatedUTC: 1000000}, at(time.Minute))
+	// This post is past its first age when tracked, so only the second
+	// is reported.
+	a.track(
+		&reddit.Post{Name: "t3_late", CreatedUTC: 1000000},
+		at(20*time.Minute),
+	)
+
+	for _, test := range []struct {
+		now      time.Duration
+		expected []agedPost
+	}{
+		{5 * time.Minute, nil},
+		{
+			10 * time.Minute,
+			[]agedPost{{&reddit.Post{Name: "t3_new"}, 10 * time.Minute}},
+		},
+		{30 * time.Minute, nil},
+		{
+			2 * time.Hour,
+			[]agedPost{
+				{&reddit.Post{Name: "t3_new"}, time.Hour},
+				{&reddit.Post{Name: "t3_late"}, time.Hour},
+			},
+		},
+	} {
+		due := a.due(at(test.now))
+		if len(due) != len(test.expected) {
+			t.Errorf("at %v: got %d posts; wanted %d", test.now, len(due), len(test.expected))
+			continue
+		}
+
+		for i := range due {
+			if due[i].post.Name != test.expected[i].post.Name ||
+				due[i].age != test.expected[i].age {
+				t.Errorf(
+					"at %v: got %s at %v; wanted %s at %v",
+					test.now,
+					due[i].post.Name, due[i].age,
+					test.expected[i].post.Name, test.expected[i].age,
+				)
+			}
+		}
+	}
+
+	if len(a.posts) != 0 {
+		t.Errorf("posts which reached every age still tracked: %d", len(a.posts))
+	}
+}
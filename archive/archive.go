@@ -0,0 +1,95 @@
+// Package archive queries secondary sources of Reddit data, such as Pushshift,
+// which keep their own copies of posts and comments. They answer questions
+// Reddit's API cannot: searches over ranges of time, and what a post or
+// comment said before it was deleted or removed.
+//
+//	ps := archive.NewPushshift(archive.PushshiftConfig{Agent: agent})
+//	posts, err := ps.Posts(archive.Query{
+//		Subreddit: "golang",
+//		After:     time.Now().Add(-24 * time.Hour),
+//	})
+//
+// Archives are not Reddit. Their copies may be incomplete, late, or stale, so
+// graw never consults them on its own: bots query them explicitly, apart from
+// the reddit package's handles to the official API.
+package archive
+
+import (
+	"strings"
+	"time"
+
+	"github.com/turnage/graw/reddit"
+)
+
+// deletedKey is the text Reddit puts in place of deleted authors and bodies.
+const deletedKey = "[deleted]"
+
+// Query selects posts or comments from an archive. Unset fields select
+// everything.
+type Query struct {
+	// Subreddit and Author limit results to those in the subreddit, and
+	// by the author.
+	Subreddit string
+	Author    string
+	// Search limits results to those containing the text.
+	Search string
+	// After and Before limit results to those made in the range.
+	After  time.Time
+	Before time.Time
+	// Limit is the most results returned. The archive's default applies
+	// if it is zero.
+	Limit int
+}
+
+// Archive is a secondary source of Reddit data.
+type Archive interface {
+	// Posts returns the archived posts the query selects, oldest first.
+	Posts(q Query) ([]*reddit.Post, error)
+	// Comments returns the archived comments the query selects, oldest
+	// first.
+	Comments(q Query) ([]*reddit.Comment, error)
+	// Info returns the archived copies of the posts and comments named by
+	// fullname (e.g. t3_5du939). Things the archive does not have are
+	// left out.
+	Info(names ...string) (reddit.Harvest, error)
+}
+
+// Recover returns the post or comment with the fullname as Reddit has it,
+// unless Reddit has only the shell of a deleted or removed thing, in which case
+// it returns the archive's copy if there is one.
+func Recover(lurker reddit.Lurker, a Archive, name string) (reddit.Harvest, error) {
+	h, err := lurker.Info(name)
+	if err != nil {
+		return h, err
+	}
+
+	if !gone(h) {
+		return h, nil
+	}
+
+	archived, err := a.Info(name)
+	if err != nil || len(archived.Posts)+len(archived.Comments) == 0 {
+		return h, err
+	}
+	return archived, nil
+}
+
+// gone returns true if the harvest has no post or comment, or only one whose
+// content was deleted or removed.
+func gone(h reddit.Harvest) bool {
+	for _, p := range h.Posts {
+		return p.Deleted || p.Removed() || p.Author == deletedKey
+	}
+	for _, c := range h.Comments {
+		return c.Deleted || c.Removed() || c.Body == deletedKey
+	}
+	return true
+}
+
+// kindOf returns the kind prefix of a fullname, e.g. "t3" for "t3_5du939".
+func kindOf(name string) string {
+	if i := strings.Index(name, "_"); i > 0 {
+		return name[:i]
+	}
+	return ""
+}
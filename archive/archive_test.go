@@ -0,0 +1,115 @@
+package archive
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/turnage/graw/reddit"
+)
+
+// pushshiftServer answers searches with a fixed post and comment, and records
+// the queries it receives.
+func pushshiftServer(queries chan<- string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			queries <- r.URL.Path + "?" + r.URL.RawQuery
+			switch r.URL.Path {
+			case "/reddit/search/submission/":
+				w.Write([]byte(`{"data": [{
+					"id": "abc",
+					"title": "Fish &amp; chips",
+					"subreddit": "golang",
+					"created_utc": 1500000000,
+					"edited": false
+				}]}`))
+			case "/reddit/search/comment/":
+				w.Write([]byte(`{"data": [{
+					"id": "def",
+					"body": "what it said",
+					"link_id": "t3_abc",
+					"created_utc": 1500000060
+				}]}`))
+			}
+		},
+	))
+}
+
+func TestPushshift(t *testing.T) {
+	queries := make(chan string, 10)
+	serv := pushshiftServer(queries)
+	defer serv.Close()
+
+	p := NewPushshift(PushshiftConfig{URL: serv.URL, Rate: time.Millisecond})
+
+	posts, err := p.Posts(Query{
+		Subreddit: "golang",
+		After:     time.Unix(1500000000, 0),
+		Limit:     10,
+	})
+	if err != nil {
+		t.Fatalf("error searching posts: %v", err)
+	}
+	if q := <-queries; q != "/reddit/search/submission/"+
+		"?after=1500000000&size=10&sort=asc&subreddit=golang" {
+		t.Errorf("bad query %s", q)
+	}
+	if len(posts) != 1 || posts[0].Name != "t3_abc" ||
+		posts[0].Title != "Fish & chips" || posts[0].CreatedUTC != 1500000000 {
+		t.Errorf("posts decoded incorrectly: %+v", posts)
+	}
+
+	h, err := p.Info("t1_def")
+	if err != nil {
+		t.Fatalf("error looking up comment: %v", err)
+	}
+	if q := <-queries; q != "/reddit/search/comment/?ids=def" {
+		t.Errorf("bad query %s", q)
+	}
+	if len(h.Posts) != 0 || len(h.Comments) != 1 ||
+		h.Comments[0].Name != "t1_def" {
+		t.Errorf("comment looked up incorrectly: %+v", h)
+	}
+}
+
+// infoLurker answers info requests with a fixed harvest. Its other methods are
+// unimplemented.
+type infoLurker struct {
+	reddit.Lurker
+	h reddit.Harvest
+}
+
+func (i *infoLurker) Info(names ...string) (reddit.Harvest, error) {
+	return i.h, nil
+}
+
+func TestRecover(t *testing.T) {
+	queries := make(chan string, 10)
+	serv := pushshiftServer(queries)
+	defer serv.Close()
+	p := NewPushshift(PushshiftConfig{URL: serv.URL, Rate: time.Millisecond})
+
+	for i, test := range []struct {
+		onReddit *reddit.Comment
+		body     string
+	}{
+		{&reddit.Comment{Name: "t1_def", Body: "edited"}, "edited"},
+		{&reddit.Comment{Name: "t1_def", Body: "[deleted]"}, "what it said"},
+		{&reddit.Comment{Name: "t1_def", Body: "[removed]"}, "what it said"},
+	} {
+		h, err := Recover(
+			&infoLurker{h: reddit.Harvest{
+				Comments: []*reddit.Comment{test.onReddit},
+			}},
+			p,
+			"t1_def",
+		)
+		if err != nil {
+			t.Fatalf("%d: error recovering: %v", i, err)
+		}
+		if len(h.Comments) != 1 || h.Comments[0].Body != test.body {
+			t.Errorf("%d: got %+v; wanted body %q", i, h, test.body)
+		}
+	}
+}
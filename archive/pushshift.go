@@ -0,0 +1,252 @@
+package archive
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/turnage/graw/reddit"
+)
+
+const (
+	defaultPushshiftURL     = "https://api.pushshift.io"
+	defaultPushshiftRate    = time.Second
+	defaultPushshiftTimeout = 30 * time.Second
+)
+
+// PushshiftConfig configures a Pushshift client.
+type PushshiftConfig struct {
+	// URL is the root of the Pushshift API. The default is
+	// https://api.pushshift.io; point it at a mirror or self hosted
+	// instance to use one.
+	URL string
+	// Agent is the user-agent sent with requests.
+	Agent string
+	// Rate is the minimum time between requests. The default is a second.
+	Rate time.Duration
+	// Client makes the requests. The default is a client with a thirty
+	// second timeout.
+	Client *http.Client
+}
+
+// Pushshift is an Archive backed by a Pushshift-style search API. Its methods
+// are goroutine safe.
+type Pushshift struct {
+	url    string
+	agent  string
+	rate   time.Duration
+	client *http.Client
+	last   time.Time
+	mu     *sync.Mutex
+}
+
+// NewPushshift returns a client for the configured Pushshift API.
+func NewPushshift(c PushshiftConfig) *Pushshift {
+	p := &Pushshift{
+		url:    strings.TrimSuffix(c.URL, "/"),
+		agent:  c.Agent,
+		rate:   c.Rate,
+		client: c.Client,
+		mu:     &sync.Mutex{},
+	}
+
+	if p.url == "" {
+		p.url = defaultPushshiftURL
+	}
+	if p.rate <= 0 {
+		p.rate = defaultPushshiftRate
+	}
+	if p.client == nil {
+		p.client = &http.Client{Timeout: defaultPushshiftTimeout}
+	}
+	return p
+}
+
+func (p *Pushshift) Posts(q Query) ([]*reddit.Post, error) {
+	results, err := p.search("submission", q.values())
+	if err != nil {
+		return nil, err
+	}
+	return decodePosts(results)
+}
+
+func (p *Pushshift) Comments(q Query) ([]*reddit.Comment, error) {
+	results, err := p.search("comment", q.values())
+	if err != nil {
+		return nil, err
+	}
+	return decodeComments(results)
+}
+
+func (p *Pushshift) Info(names ...string) (reddit.Harvest, error) {
+	var posts, comments []string
+	for _, name := range names {
+		id := name[strings.Index(name, "_")+1:]
+		switch kindOf(name) {
+		case "t3":
+			posts = append(posts, id)
+		case "t1":
+			comments = append(comments, id)
+		}
+	}
+
+	var h reddit.Harvest
+	if len(posts) > 0 {
+		results, err := p.search(
+			"submission",
+			url.Values{"ids": {strings.Join(posts, ",")}},
+		)
+		if err != nil {
+			return h, err
+		}
+		if h.Posts, err = decodePosts(results); err != nil {
+			return h, err
+		}
+	}
+
+	if len(comments) > 0 {
+		results, err := p.search(
+			"comment",
+			url.Values{"ids": {strings.Join(comments, ",")}},
+		)
+		if err != nil {
+			return h, err
+		}
+		if h.Comments, err = decodeComments(results); err != nil {
+			return h, err
+		}
+	}
+	return h, nil
+}
+
+// values returns the query's search parameters.
+func (q Query) values() url.Values {
+	v := url.Values{"sort": {"asc"}}
+	if q.Subreddit != "" {
+		v.Set("subreddit", q.Subreddit)
+	}
+	if q.Author != "" {
+		v.Set("author", q.Author)
+	}
+	if q.Search != "" {
+		v.Set("q", q.Search)
+	}
+	if !q.After.IsZero() {
+		v.Set("after", strconv.FormatInt(q.After.Unix(), 10))
+	}
+	if !q.Before.IsZero() {
+		v.Set("before", strconv.FormatInt(q.Before.Unix(), 10))
+	}
+	if q.Limit > 0 {
+		v.Set("size", strconv.Itoa(q.Limit))
+	}
+	return v
+}
+
+// search queries the search endpoint for the kind ("submission" or
+// "comment") and returns the results.
+func (p *Pushshift) search(
+	kind string,
+	values url.Values,
+) ([]map[string]interface{}, error) {
+	p.rateBlock()
+
+	req, err := http.NewRequest(
+		"GET",
+		p.url+"/reddit/search/"+kind+"/?"+values.Encode(),
+		nil,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if p.agent != "" {
+		req.Header.Set("User-Agent", p.agent)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf(
+			"pushshift: bad response code: %d", resp.StatusCode,
+		)
+	}
+
+	var body struct {
+		Data []map[string]interface{} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, err
+	}
+	return body.Data, nil
+}
+
+func (p *Pushshift) rateBlock() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if time.Since(p.last) < p.rate {
+		<-time.After(p.last.Add(p.rate).Sub(time.Now()))
+	}
+	p.last = time.Now()
+}
+
+func decodePosts(results []map[string]interface{}) ([]*reddit.Post, error) {
+	posts := make([]*reddit.Post, 0, len(results))
+	for _, data := range results {
+		uneditedAsZero(data)
+		post := &reddit.Post{}
+		if err := mapstructure.Decode(data, post); err != nil {
+			return nil, err
+		}
+
+		if post.Name == "" {
+			post.Name = "t3_" + post.ID
+		}
+		post.Title = html.UnescapeString(post.Title)
+		post.SelfText = html.UnescapeString(post.SelfText)
+		post.URL = html.UnescapeString(post.URL)
+		post.Deleted = post.SelfText == deletedKey
+		posts = append(posts, post)
+	}
+	return posts, nil
+}
+
+func decodeComments(
+	results []map[string]interface{},
+) ([]*reddit.Comment, error) {
+	comments := make([]*reddit.Comment, 0, len(results))
+	for _, data := range results {
+		uneditedAsZero(data)
+		comment := &reddit.Comment{}
+		if err := mapstructure.Decode(data, comment); err != nil {
+			return nil, err
+		}
+
+		if comment.Name == "" {
+			comment.Name = "t1_" + comment.ID
+		}
+		comment.Body = html.UnescapeString(comment.Body)
+		comment.Deleted = comment.Body == deletedKey
+		comments = append(comments, comment)
+	}
+	return comments, nil
+}
+
+// uneditedAsZero drops the "edited" field of things which were never edited,
+// which is false rather than a timestamp.
+func uneditedAsZero(data map[string]interface{}) {
+	if _, ok := data["edited"].(bool); ok {
+		delete(data, "edited")
+	}
+}
@@ -0,0 +1,196 @@
+package graw
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/turnage/graw/botfaces"
+	"github.com/turnage/graw/clock"
+	"github.com/turnage/graw/reddit"
+)
+
+// defaultBatchWait is how long the first event in a batch waits for others if
+// a Batching does not say.
+const defaultBatchWait = 10 * time.Second
+
+var (
+	postBatchHandlerErr = fmt.Errorf(
+		"You must implement PostBatchHandler to receive batches of posts.",
+	)
+	commentBatchHandlerErr = fmt.Errorf(
+		"You must implement CommentBatchHandler to receive batches of " +
+			"comments.",
+	)
+	batchContextErr = fmt.Errorf(
+		"Batched comments cannot be handed over with their context; " +
+			"set Batching or CommentContext, not both.",
+	)
+)
+
+// Batching forwards the new posts in the Subreddits and comments in the
+// SubredditComments in batches, to the bot's PostBatchHandler and
+// CommentBatchHandler in place of its PostHandler and CommentHandler, for bots
+// which write what they see in bulk, e.g. to a database. A batch is forwarded
+// once it holds Size events, or once its first event has waited Wait,
+// whichever comes first; a busy feed's batches are full, and a quiet feed's
+// are forwarded on time.
+//
+// Events join a batch once the Filters, Cooldowns, and other policies admit
+// them, and are marked seen then. A batch's handler is paused by the Breaker
+// like any other, and a batch still filling when the bot stops is dropped.
+type Batching struct {
+	// Size is the most events in a batch. Zero disables batching.
+	Size int
+	// Wait is the longest an event waits for its batch to fill. If zero,
+	// it is ten seconds.
+	Wait time.Duration
+}
+
+// batcher gathers items into batches and forwards them.
+type batcher struct {
+	c       Batching
+	clock   clock.Clock
+	forward func([]interface{})
+	kill    <-chan bool
+	pending []interface{}
+	timer   clock.Timer
+	mu      *sync.Mutex
+}
+
+func newBatcher(
+	c Batching,
+	clk clock.Clock,
+	kill <-chan bool,
+	forward func([]interface{}),
+) *batcher {
+	if c.Wait <= 0 {
+		c.Wait = defaultBatchWait
+	}
+	return &batcher{
+		c:       c,
+		clock:   clk,
+		forward: forward,
+		kill:    kill,
+		mu:      &sync.Mutex{},
+	}
+}
+
+// add adds the item to the batch, forwarding it if it is full.
+func (b *batcher) add(item interface{}) {
+	b.mu.Lock()
+	b.pending = append(b.pending, item)
+	if len(b.pending) < b.c.Size {
+		if b.timer == nil {
+			b.timer = b.clock.AfterFunc(b.c.Wait, b.flush)
+		}
+		b.mu.Unlock()
+		return
+	}
+
+	batch := b.take()
+	b.mu.Unlock()
+	b.send(batch)
+}
+
+// flush forwards the batch however full it is.
+func (b *batcher) flush() {
+	b.mu.Lock()
+	batch := b.take()
+	b.mu.Unlock()
+	b.send(batch)
+}
+
+// take empties the batch and returns what it held. The caller holds the lock.
+func (b *batcher) take() []interface{} {
+	if b.timer != nil {
+		b.timer.Stop()
+		b.timer = nil
+	}
+	batch := b.pending
+	b.pending = nil
+	return batch
+}
+
+// send forwards the batch, unless it is empty or the bot has stopped.
+func (b *batcher) send(batch []interface{}) {
+	if len(batch) == 0 {
+		return
+	}
+
+	select {
+	case <-b.kill:
+	default:
+		b.forward(batch)
+	}
+}
+
+// postHandling returns the function which hands subreddit posts to the
+// handler, one at a time or in batches as the config says.
+func postHandling(
+	handler interface{},
+	c Config,
+	d *dispatcher,
+	kill <-chan bool,
+) (func(*reddit.Post) error, error) {
+	if c.Batching.Size <= 0 {
+		ph, ok := handler.(botfaces.PostHandler)
+		if !ok {
+			return nil, postHandlerErr
+		}
+		return ph.Post, nil
+	}
+
+	pbh, ok := handler.(botfaces.PostBatchHandler)
+	if !ok {
+		return nil, postBatchHandlerErr
+	}
+
+	b := newBatcher(c.Batching, d.clock, kill, func(batch []interface{}) {
+		posts := make([]*reddit.Post, len(batch))
+		for i, item := range batch {
+			posts[i] = item.(*reddit.Post)
+		}
+		d.dispatch(
+			event{kind: postBatchEvent, thing: posts},
+			func() error { return pbh.PostBatch(posts) },
+		)
+	})
+	return func(p *reddit.Post) error {
+		b.add(p)
+		return nil
+	}, nil
+}
+
+// batchedComments returns the function which hands subreddit comments to the
+// handler in batches.
+func batchedComments(
+	handler interface{},
+	c Config,
+	d *dispatcher,
+	kill <-chan bool,
+) (func(*reddit.Comment) error, error) {
+	if c.CommentContext > 0 {
+		return nil, batchContextErr
+	}
+
+	cbh, ok := handler.(botfaces.CommentBatchHandler)
+	if !ok {
+		return nil, commentBatchHandlerErr
+	}
+
+	b := newBatcher(c.Batching, d.clock, kill, func(batch []interface{}) {
+		comments := make([]*reddit.Comment, len(batch))
+		for i, item := range batch {
+			comments[i] = item.(*reddit.Comment)
+		}
+		d.dispatch(
+			event{kind: commentBatchEvent, thing: comments},
+			func() error { return cbh.CommentBatch(comments) },
+		)
+	})
+	return func(comment *reddit.Comment) error {
+		b.add(comment)
+		return nil
+	}, nil
+}
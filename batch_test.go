@@ -0,0 +1,124 @@
+package graw
+
+import (
+	"testing"
+	"time"
+
+	"github.com/turnage/graw/clock"
+	"github.com/turnage/graw/filter"
+	"github.com/turnage/graw/reddit"
+)
+
+type batchRecorder struct {
+	posts    chan []*reddit.Post
+	comments chan []*reddit.Comment
+}
+
+func (r *batchRecorder) PostBatch(posts []*reddit.Post) error {
+	r.posts <- posts
+	return nil
+}
+
+func (r *batchRecorder) CommentBatch(comments []*reddit.Comment) error {
+	r.comments <- comments
+	return nil
+}
+
+func TestPostBatches(t *testing.T) {
+	rec := &batchRecorder{posts: make(chan []*reddit.Post, 10)}
+	kill := make(chan bool)
+	defer close(kill)
+	errs := make(chan error, 10)
+
+	c := Config{
+		Batching: Batching{Size: 2, Wait: 50 * time.Millisecond},
+		Filters:  Filters{Post: filter.MustCompile("score > 1")},
+	}
+	d := newDispatcher(c, "", errs)
+	handle, err := postHandling(rec, c, d, kill)
+	if err != nil {
+		t.Fatalf("error making post handling: %v", err)
+	}
+
+	// Full batches are forwarded at once; the filtered post never joins
+	// one.
+	for _, p := range []*reddit.Post{
+		{Name: "t3_a", Score: 5},
+		{Name: "t3_b", Score: 0},
+		{Name: "t3_c", Score: 5},
+		{Name: "t3_d", Score: 5},
+	} {
+		p := p
+		d.dispatch(postEv(postEvent, p), func() error { return handle(p) })
+	}
+
+	select {
+	case posts := <-rec.posts:
+		if len(posts) != 2 || posts[0].Name != "t3_a" ||
+			posts[1].Name != "t3_c" {
+			t.Errorf("got batch %v; wanted t3_a and t3_c", posts)
+		}
+	default:
+		t.Fatalf("full batch was not forwarded at once")
+	}
+
+	// A batch which does not fill is forwarded once it has waited.
+	select {
+	case posts := <-rec.posts:
+		if len(posts) != 1 || posts[0].Name != "t3_d" {
+			t.Errorf("got batch %v; wanted t3_d", posts)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("partial batch was not forwarded")
+	}
+
+	_, err = postHandling(struct{}{}, c, d, kill)
+	if err != postBatchHandlerErr {
+		t.Errorf("got error %v; wanted %v", err, postBatchHandlerErr)
+	}
+}
+
+func TestCommentBatchesNeedNoContext(t *testing.T) {
+	d := newDispatcher(Config{}, "", nil)
+	c := Config{Batching: Batching{Size: 10}, CommentContext: 2}
+	if _, err := commentHandling(
+		&batchRecorder{}, nil, c, d, nil,
+	); err != batchContextErr {
+		t.Errorf("got error %v; wanted %v", err, batchContextErr)
+	}
+
+	c.CommentContext = 0
+	if _, err := commentHandling(
+		commentBot{}, nil, c, d, nil,
+	); err != commentBatchHandlerErr {
+		t.Errorf("got error %v; wanted %v", err, commentBatchHandlerErr)
+	}
+}
+
+func TestBatcherDropsBatchesAfterKill(t *testing.T) {
+	kill := make(chan bool)
+	forwarded := make(chan []interface{}, 1)
+	b := newBatcher(
+		Batching{Size: 5, Wait: time.Millisecond},
+		clock.Real,
+		kill,
+		func(batch []interface{}) { forwarded <- batch },
+	)
+
+	b.add("a")
+	close(kill)
+	select {
+	case batch := <-forwarded:
+		// The batch may have been forwarded before the kill.
+		if len(batch) != 1 {
+			t.Errorf("got batch %v; wanted [a]", batch)
+		}
+	case <-time.After(50 * time.Millisecond):
+	}
+	b.add("b")
+	select {
+	case batch := <-forwarded:
+		t.Errorf("batch %v was forwarded after the kill", batch)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
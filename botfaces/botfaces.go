@@ -3,7 +3,11 @@
 package botfaces
 
 import (
+	"log"
+	"time"
+
 	"github.com/turnage/graw/reddit"
+	"github.com/turnage/graw/unfurl"
 )
 
 // Loader defines methods for bots that use external resources or need to do
@@ -25,6 +29,14 @@ type Tearer interface {
 	TearDown()
 }
 
+// Logged defines methods for bots that log through the Logger in the
+// graw.Config they are run with.
+type Logged interface {
+	// SetLogger is called with the config's Logger before any event
+	// sources are connected. It is not called if the config has none.
+	SetLogger(logger *log.Logger)
+}
+
 // PostHandler defines methods for bots that handle new posts in
 // subreddits they monitor.
 type PostHandler interface {
@@ -41,6 +53,43 @@ type CommentHandler interface {
 	Comment(post *reddit.Comment) error
 }
 
+// CommentContextHandler defines methods for bots that handle new comments in
+// subreddits along with the comments they reply to, e.g. to explain a joke
+// made several comments up. Bots with a Config.CommentContext implement it in
+// place of CommentHandler.
+type CommentContextHandler interface {
+	// CommentContext is called when a comment is made in a monitored
+	// subreddit that the bot has not seen yet, with its nearest ancestors,
+	// parent first, and the post they are in. If the context could not
+	// all be fetched, ancestors has what was, and post may be nil.
+	// [Called as goroutine.]
+	CommentContext(
+		comment *reddit.Comment,
+		ancestors []*reddit.Comment,
+		post *reddit.Post,
+	) error
+}
+
+// PostBatchHandler defines methods for bots that handle new posts in subreddits
+// in batches. Bots with a Config.Batching implement it in place of
+// PostHandler.
+type PostBatchHandler interface {
+	// PostBatch is called with a batch of posts made in monitored
+	// subreddits that the bot has not seen yet, in the order they were
+	// found. [Called as goroutine.]
+	PostBatch(posts []*reddit.Post) error
+}
+
+// CommentBatchHandler defines methods for bots that handle new comments in
+// subreddits in batches. Bots with a Config.Batching implement it in place of
+// CommentHandler.
+type CommentBatchHandler interface {
+	// CommentBatch is called with a batch of comments made in monitored
+	// subreddits that the bot has not seen yet, in the order they were
+	// found. [Called as goroutine.]
+	CommentBatch(comments []*reddit.Comment) error
+}
+
 // MessageHandler defines methods for bots that handle new private messages to
 // their inbox.
 type MessageHandler interface {
@@ -104,3 +153,132 @@ type UserHandler interface {
 	// subreddit the bot can view. [Called as goroutine.]
 	UserComment(comment *reddit.Comment) error
 }
+
+// ThreadHandler defines methods for bots that handle new comments in threads
+// they watch. Bots watching threads must implement at least one of the thread
+// handlers.
+type ThreadHandler interface {
+	// ThreadComment is called when a comment is made in a watched thread
+	// that the bot has not seen yet. [Called as goroutine.]
+	ThreadComment(comment *reddit.Comment) error
+}
+
+// PostAgeHandler defines methods for bots that follow posts in subreddits they
+// monitor as the posts age.
+type PostAgeHandler interface {
+	// PostAge is called when a post in a monitored subreddit reaches one
+	// of the ages in the config, with its score and comment count
+	// refreshed. [Called as goroutine.]
+	PostAge(post *reddit.Post, age time.Duration) error
+}
+
+// RankHandler defines methods for bots that watch the top positions of ranked
+// listings, such as a subreddit's hot page.
+type RankHandler interface {
+	// Rank is called when a post enters, leaves, or moves within the top
+	// positions of a watched listing. Positions count from 1; rank is 0 if
+	// the post left the top positions, and previous is 0 if it entered
+	// them. [Called as goroutine.]
+	Rank(listing string, post *reddit.Post, rank, previous int) error
+}
+
+// DigestHandler defines methods for bots that post digests of the top posts of
+// listings, such as a subreddit's top posts of the day.
+type DigestHandler interface {
+	// Digest is called on each listing's schedule with its top posts, in
+	// listing order. [Called as goroutine.]
+	Digest(listing string, posts []*reddit.Post) error
+}
+
+// GildingHandler defines methods for bots that handle awards given in threads
+// they watch.
+type GildingHandler interface {
+	// PostGilded is called when a watched thread's post receives an
+	// award. [Called as goroutine.]
+	PostGilded(post *reddit.Post) error
+	// CommentGilded is called when a comment in a watched thread receives
+	// an award. [Called as goroutine.]
+	CommentGilded(comment *reddit.Comment) error
+}
+
+// FlairHandler defines methods for bots that follow the link flair of threads
+// they watch, e.g. to act when a question is flaired "Solved".
+type FlairHandler interface {
+	// FlairChanged is called when the link flair of a watched thread's
+	// post changes. The post has its new flair; old is the flair text it
+	// had before. [Called as goroutine.]
+	FlairChanged(post *reddit.Post, old string) error
+}
+
+// OPReplyHandler defines methods for bots that notify when the author of a
+// watched thread responds in it.
+type OPReplyHandler interface {
+	// OPReply is called when the author of a watched thread's post
+	// comments in the thread. [Called as goroutine.]
+	OPReply(post *reddit.Post, comment *reddit.Comment) error
+}
+
+// DeletionHandler defines methods for bots that follow posts and comments
+// taken down in threads they watch, e.g. to archive them or report on
+// moderation. Removal says whether the author deleted the thing or someone
+// removed it, and who, as far as Reddit says; see reddit.Removal.
+type DeletionHandler interface {
+	// PostDeleted is called when a watched thread's post is deleted or
+	// removed. [Called as goroutine.]
+	PostDeleted(post *reddit.Post, removal reddit.Removal) error
+	// CommentDeleted is called when a comment in a watched thread is
+	// deleted or removed. [Called as goroutine.]
+	CommentDeleted(comment *reddit.Comment, removal reddit.Removal) error
+}
+
+// SubmissionFilteredHandler defines methods for bots that want to know when
+// their own posts and comments cannot be seen by other users, e.g. because
+// Reddit's spam filter caught them (see graw.SpamCheck).
+type SubmissionFilteredHandler interface {
+	// PostFiltered is called when a post the bot made is not visible to
+	// logged out users. [Called as goroutine.]
+	PostFiltered(post *reddit.Post) error
+	// CommentFiltered is called when a comment the bot made is not
+	// visible to logged out users. [Called as goroutine.]
+	CommentFiltered(comment *reddit.Comment) error
+}
+
+// AlertHandler defines methods for bots that want to know when graw's breaker
+// (see graw.Breaker) pauses or resumes one of their handlers. Handlers are
+// named by the kind of event they handle, e.g. "comment" or "thread comment".
+type AlertHandler interface {
+	// HandlerPaused is called when a handler has failed too many times in
+	// a row and its events are being dropped. err is its last failure.
+	// [Called as goroutine.]
+	HandlerPaused(handler string, err error) error
+	// HandlerResumed is called when a paused handler succeeds again.
+	// [Called as goroutine.]
+	HandlerResumed(handler string) error
+}
+
+// SourceAlertHandler defines methods for bots that want to know when graw
+// degrades or suspends one of their feeds because its polls keep failing (see
+// graw.Degradation). Feeds are named by what they poll, e.g. /r/golang/new or
+// the permalink of a thread.
+type SourceAlertHandler interface {
+	// SourceDegraded is called when a feed has failed too many times in
+	// a row and is polled less often. err is its last failure. [Called
+	// as goroutine.]
+	SourceDegraded(source string, err error) error
+	// SourceSuspended is called when a feed has failed so many times in
+	// a row that it is no longer polled. err is its last failure.
+	// [Called as goroutine.]
+	SourceSuspended(source string, err error) error
+	// SourceRecovered is called when a degraded feed polls successfully
+	// again. [Called as goroutine.]
+	SourceRecovered(source string) error
+}
+
+// PageHandler defines methods for bots that want to know what the pages link
+// posts point to say about themselves (see graw.Config's Unfurler), e.g. to
+// catch posts whose titles misrepresent their links.
+type PageHandler interface {
+	// PostPage is called with a new post in a monitored subreddit once
+	// the page it links to has been fetched. [Called as goroutine.]
+	PostPage(post *reddit.Post, page *unfurl.Page) error
+}
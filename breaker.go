@@ -0,0 +1,140 @@
+package graw
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// defaultBreakerCooldown is how long a tripped handler is paused if a Breaker
+// does not specify a cooldown.
+const defaultBreakerCooldown = time.Minute
+
+// Breaker isolates the bot's handlers from each other's failures. Without a
+// breaker, an error returned by any handler stops the run. With one, a handler
+// which fails Threshold times in a row (by returning an error or panicking) is
+// paused instead: its events are dropped while the rest of the bot carries on.
+// After each Cooldown, one event is let through to it as a probe; if the probe
+// succeeds, the handler is resumed.
+//
+// Handlers are paused and resumed independently; a failing CommentHandler
+// does not pause the bot's PostHandler. If the bot implements
+// botfaces.AlertHandler, it is told when handlers are paused and resumed.
+type Breaker struct {
+	// Threshold is the number of consecutive failures which pause a
+	// handler. Zero disables the breaker.
+	Threshold int
+	// Cooldown is how long a paused handler waits between probes. If
+	// zero, it is a minute.
+	Cooldown time.Duration
+}
+
+// breakerState tracks the failures of one handler.
+type breakerState struct {
+	failures int
+	open     bool
+	probing  bool
+	// until is the time the next probe may be sent.
+	until time.Time
+}
+
+// breaker enforces a Breaker on each of the bot's handlers.
+type breaker struct {
+	threshold int
+	cooldown  time.Duration
+	states    map[eventKind]*breakerState
+	mu        *sync.Mutex
+}
+
+// newBreaker returns a breaker enforcing the config, or nil if it is disabled.
+func newBreaker(c Breaker) *breaker {
+	if c.Threshold <= 0 {
+		return nil
+	}
+
+	b := &breaker{
+		threshold: c.Threshold,
+		cooldown:  c.Cooldown,
+		states:    make(map[eventKind]*breakerState),
+		mu:        &sync.Mutex{},
+	}
+
+	if b.cooldown <= 0 {
+		b.cooldown = defaultBreakerCooldown
+	}
+
+	return b
+}
+
+func (b *breaker) state(kind eventKind) *breakerState {
+	s, ok := b.states[kind]
+	if !ok {
+		s = &breakerState{}
+		b.states[kind] = s
+	}
+	return s
+}
+
+// allow returns true if an event may be forwarded to the handler of the kind.
+// While the handler is paused, one event is allowed per cooldown as a probe.
+func (b *breaker) allow(kind eventKind, now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	s := b.state(kind)
+	if !s.open {
+		return true
+	}
+
+	if s.probing || now.Before(s.until) {
+		return false
+	}
+
+	s.probing = true
+	return true
+}
+
+// record records the result of forwarding an event to the handler of the kind.
+// It returns true in tripped if this failure paused the handler, and in
+// resumed if this success resumed it.
+func (b *breaker) record(
+	kind eventKind,
+	err error,
+	now time.Time,
+) (tripped, resumed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	s := b.state(kind)
+	if err == nil {
+		resumed = s.open
+		*s = breakerState{}
+		return false, resumed
+	}
+
+	s.failures++
+	s.probing = false
+	if s.open {
+		s.until = now.Add(b.cooldown)
+		return false, false
+	}
+
+	if s.failures >= b.threshold {
+		s.open = true
+		s.until = now.Add(b.cooldown)
+		return true, false
+	}
+	return false, false
+}
+
+// protect calls handle, converting a panic into an error so one handler's
+// panic does not take down the process.
+func protect(kind eventKind, handle func() error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%s handler panicked: %v", kind, r)
+		}
+	}()
+
+	return handle()
+}
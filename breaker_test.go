@@ -0,0 +1,112 @@
+package graw
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestBreaker(t *testing.T) {
+	if newBreaker(Breaker{}) != nil {
+		t.Errorf("breaker with no threshold is enabled")
+	}
+
+	b := newBreaker(Breaker{Threshold: 2, Cooldown: time.Minute})
+	start := time.Now()
+	failure := fmt.Errorf("failure")
+
+	for i, test := range []struct {
+		kind    eventKind
+		at      time.Duration
+		err     error
+		allow   bool
+		tripped bool
+		resumed bool
+	}{
+		{kind: commentEvent, err: failure, allow: true},
+		{kind: commentEvent, err: failure, allow: true, tripped: true},
+		// Paused handlers are dropped until the cooldown passes, while
+		// other handlers carry on.
+		{kind: commentEvent, at: time.Second, allow: false},
+		{kind: postEvent, at: time.Second, err: failure, allow: true},
+		// A failed probe pauses the handler for another cooldown.
+		{kind: commentEvent, at: time.Minute, err: failure, allow: true},
+		{kind: commentEvent, at: time.Minute + time.Second, allow: false},
+		// A successful probe resumes it.
+		{kind: commentEvent, at: 2 * time.Minute, allow: true, resumed: true},
+		{kind: commentEvent, at: 2 * time.Minute, err: failure, allow: true},
+	} {
+		now := start.Add(test.at)
+		if allow := b.allow(test.kind, now); allow != test.allow {
+			t.Errorf("%d: got allow %v; wanted %v", i, allow, test.allow)
+		}
+		if !test.allow {
+			continue
+		}
+
+		tripped, resumed := b.record(test.kind, test.err, now)
+		if tripped != test.tripped || resumed != test.resumed {
+			t.Errorf(
+				"%d: got tripped %v, resumed %v; wanted %v, %v",
+				i, tripped, resumed, test.tripped, test.resumed,
+			)
+		}
+	}
+}
+
+// alertBot records alerts it is handed.
+type alertBot struct {
+	alerts []string
+}
+
+func (a *alertBot) HandlerPaused(handler string, err error) error {
+	a.alerts = append(a.alerts, "paused "+handler)
+	return nil
+}
+
+func (a *alertBot) HandlerResumed(handler string) error {
+	a.alerts = append(a.alerts, "resumed "+handler)
+	return nil
+}
+
+func TestDispatcherBreaker(t *testing.T) {
+	errs := make(chan error, 10)
+	bot := &alertBot{}
+	d := newDispatcher(Config{Breaker: Breaker{Threshold: 1}}, "", errs)
+	d.alerts = bot
+
+	d.dispatch(
+		event{kind: commentEvent},
+		func() error { panic("oh no") },
+	)
+
+	calls := 0
+	d.dispatch(
+		event{kind: commentEvent},
+		func() error { calls++; return nil },
+	)
+
+	if calls != 0 {
+		t.Errorf("paused handler was called")
+	}
+	if len(errs) != 0 {
+		t.Errorf("handler failure was reported as a run error: %v", <-errs)
+	}
+	if len(bot.alerts) != 1 || bot.alerts[0] != "paused comment" {
+		t.Errorf("got alerts %v; wanted [paused comment]", bot.alerts)
+	}
+}
+
+func TestDispatcherRecoversPanics(t *testing.T) {
+	errs := make(chan error, 10)
+	d := newDispatcher(Config{}, "", errs)
+
+	d.dispatch(
+		event{kind: postEvent},
+		func() error { panic("oh no") },
+	)
+
+	if err := <-errs; err == nil {
+		t.Errorf("panic was not reported as an error")
+	}
+}
@@ -0,0 +1,190 @@
+package graw
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/turnage/graw/clock"
+	"github.com/turnage/graw/reddit"
+)
+
+const (
+	defaultClassifyBatch   = 16
+	defaultClassifyWait    = 50 * time.Millisecond
+	defaultClassifyTimeout = 5 * time.Second
+)
+
+var classifyTimeoutErr = fmt.Errorf("classifier timed out")
+
+// Classifier labels the text of posts, comments, and messages, e.g. with a
+// toxicity or topic model.
+type Classifier interface {
+	// Classify returns the labels of each of the texts, in order: scores
+	// by label name, e.g. {"toxic": 0.93}.
+	Classify(texts []string) ([]map[string]float64, error)
+}
+
+// Classification labels events with a Classifier so the Filters can select
+// them by their labels, e.g.
+//
+//	filter.MustCompile("labels.toxic < 0.5")
+//
+// Only events whose filter compares labels are classified. Events are sent to
+// the classifier in batches, as a model serving many texts at once is usually
+// much cheaper per text. Events which cannot be classified, because the
+// classifier failed or took too long, have no labels, and comparisons against
+// their labels are false; write filters so that is the safe outcome.
+type Classification struct {
+	// Classifier labels events. If nil, events are not classified.
+	Classifier Classifier
+	// BatchSize is the most texts sent to the classifier at once. The
+	// default is 16.
+	BatchSize int
+	// BatchWait is how long an event waits for others to share a batch
+	// with. The default is 50 milliseconds.
+	BatchWait time.Duration
+	// Timeout is how long an event waits for its labels before it is
+	// forwarded without them. The default is five seconds.
+	Timeout time.Duration
+}
+
+// classifyCall is one event's text, waiting on a batch.
+type classifyCall struct {
+	text   string
+	labels map[string]float64
+	err    error
+	done   chan struct{}
+}
+
+// classifyBatcher coalesces the texts of events dispatched around the same
+// time into batches for the classifier.
+type classifyBatcher struct {
+	c       Classification
+	clock   clock.Clock
+	logger  *log.Logger
+	pending []*classifyCall
+	mu      *sync.Mutex
+}
+
+// newClassifyBatcher returns a batcher for the classification, or nil if it
+// has no classifier.
+func newClassifyBatcher(
+	c Classification,
+	clk clock.Clock,
+	logger *log.Logger,
+) *classifyBatcher {
+	if c.Classifier == nil {
+		return nil
+	}
+
+	if c.BatchSize <= 0 {
+		c.BatchSize = defaultClassifyBatch
+	}
+	if c.BatchWait <= 0 {
+		c.BatchWait = defaultClassifyWait
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = defaultClassifyTimeout
+	}
+
+	return &classifyBatcher{
+		c:      c,
+		clock:  clk,
+		logger: logger,
+		mu:     &sync.Mutex{},
+	}
+}
+
+// labels returns the labels of the thing, or nil if it cannot be classified.
+func (b *classifyBatcher) labels(thing interface{}) map[string]float64 {
+	text := textOf(thing)
+	if text == "" {
+		return nil
+	}
+
+	call := &classifyCall{text: text, done: make(chan struct{})}
+
+	b.mu.Lock()
+	b.pending = append(b.pending, call)
+	switch len(b.pending) {
+	case b.c.BatchSize:
+		go b.flush()
+	case 1:
+		b.clock.AfterFunc(b.c.BatchWait, b.flush)
+	}
+	b.mu.Unlock()
+
+	select {
+	case <-call.done:
+		if call.err == nil {
+			return call.labels
+		}
+		b.logger.Printf("Could not classify event: %v", call.err)
+	case <-b.clock.After(b.c.Timeout):
+		b.logger.Printf("Could not classify event: %v", classifyTimeoutErr)
+	}
+	return nil
+}
+
+// flush classifies the pending texts and answers their calls.
+func (b *classifyBatcher) flush() {
+	b.mu.Lock()
+	calls := b.pending
+	b.pending = nil
+	b.mu.Unlock()
+
+	if len(calls) == 0 {
+		return
+	}
+
+	texts := make([]string, len(calls))
+	for i, call := range calls {
+		texts[i] = call.text
+	}
+
+	labels, err := b.classify(texts)
+	for i, call := range calls {
+		if err != nil {
+			call.err = err
+		} else {
+			call.labels = labels[i]
+		}
+		close(call.done)
+	}
+}
+
+// classify calls the classifier, guarding against it panicking or answering
+// for the wrong number of texts.
+func (b *classifyBatcher) classify(
+	texts []string,
+) (labels []map[string]float64, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("classifier panicked: %v", r)
+		}
+	}()
+
+	labels, err = b.c.Classifier.Classify(texts)
+	if err == nil && len(labels) != len(texts) {
+		err = fmt.Errorf(
+			"classifier returned %d labels for %d texts",
+			len(labels), len(texts),
+		)
+	}
+	return labels, err
+}
+
+// textOf returns the text of a post, comment, or message that is classified.
+func textOf(thing interface{}) string {
+	switch t := thing.(type) {
+	case *reddit.Post:
+		return t.Title + "\n" + t.SelfText
+	case *reddit.Comment:
+		return t.Body
+	case *reddit.Message:
+		return t.Subject + "\n" + t.Body
+	}
+	return ""
+}
@@ -0,0 +1,131 @@
+package graw
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/turnage/graw/clock"
+	"github.com/turnage/graw/filter"
+	"github.com/turnage/graw/reddit"
+)
+
+// toxicityModel labels texts containing "idiot" toxic, and records the size of
+// each batch it is asked to classify.
+type toxicityModel struct {
+	batches []int
+	err     error
+	mu      *sync.Mutex
+}
+
+func (m *toxicityModel) Classify(texts []string) ([]map[string]float64, error) {
+	m.mu.Lock()
+	m.batches = append(m.batches, len(texts))
+	m.mu.Unlock()
+
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	labels := make([]map[string]float64, len(texts))
+	for i, text := range texts {
+		labels[i] = map[string]float64{"toxic": 0.1}
+		if strings.Contains(text, "idiot") {
+			labels[i]["toxic"] = 0.9
+		}
+	}
+	return labels, nil
+}
+
+func TestClassification(t *testing.T) {
+	model := &toxicityModel{mu: &sync.Mutex{}}
+	errs := make(chan error, 10)
+	d := newDispatcher(
+		Config{
+			Filters: Filters{
+				Comment: filter.MustCompile("labels.toxic < 0.5"),
+			},
+			Classification: Classification{
+				Classifier: model,
+				BatchSize:  2,
+				BatchWait:  time.Hour,
+			},
+		},
+		"",
+		errs,
+	)
+
+	// A full batch is classified without waiting.
+	var wg sync.WaitGroup
+	admitted := make([]bool, 2)
+	for i, body := range []string{"thanks, this helped", "you idiot"} {
+		wg.Add(1)
+		go func(i int, body string) {
+			defer wg.Done()
+			admitted[i], _ = d.admit(commentEv(
+				commentEvent,
+				&reddit.Comment{Name: fmt.Sprintf("t1_%d", i), Body: body},
+			))
+		}(i, body)
+	}
+	wg.Wait()
+
+	if !admitted[0] || admitted[1] {
+		t.Errorf("got admitted %v; wanted only the polite comment", admitted)
+	}
+	if len(model.batches) != 1 || model.batches[0] != 2 {
+		t.Errorf("got batches %v; wanted one of 2", model.batches)
+	}
+
+	// Events of kinds whose filters do not compare labels are not
+	// classified.
+	if admit, _ := d.admit(postEv(postEvent, &reddit.Post{Name: "t3_a"})); !admit {
+		t.Errorf("unfiltered post was not admitted")
+	}
+	if len(model.batches) != 1 {
+		t.Errorf("post was classified: %v", model.batches)
+	}
+}
+
+func TestClassificationFailure(t *testing.T) {
+	model := &toxicityModel{mu: &sync.Mutex{}, err: fmt.Errorf("model down")}
+	d := newDispatcher(
+		Config{
+			Filters: Filters{
+				Comment: filter.MustCompile("!(labels.toxic > 0.5)"),
+				Message: filter.MustCompile("labels.toxic < 0.5"),
+			},
+			Classification: Classification{
+				Classifier: model,
+				BatchWait:  time.Millisecond,
+			},
+		},
+		"",
+		make(chan error, 10),
+	)
+
+	// Without labels, comparisons against them are false.
+	if admit, _ := d.admit(commentEv(commentEvent, &reddit.Comment{Body: "hi"})); !admit {
+		t.Errorf("comment was not admitted by a negated label filter")
+	}
+	if admit, _ := d.admit(messageEv(messageEvent, &reddit.Message{Body: "hi"})); admit {
+		t.Errorf("message was admitted without labels")
+	}
+}
+
+func TestClassificationTimeout(t *testing.T) {
+	b := newClassifyBatcher(
+		Classification{
+			Classifier: &toxicityModel{mu: &sync.Mutex{}},
+			BatchWait:  time.Hour,
+			Timeout:    time.Millisecond,
+		},
+		clock.Real,
+		logger(nil),
+	)
+	if labels := b.labels(&reddit.Comment{Body: "hi"}); labels != nil {
+		t.Errorf("got labels %v; wanted none after the timeout", labels)
+	}
+}
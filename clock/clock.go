@@ -0,0 +1,245 @@
+// Package clock abstracts the passage of time, so graw's timing logic (pacing,
+// backoffs, batching, cooldowns) can run on a virtual clock which tests and
+// replays advance at will, rather than on the wall clock, which they would
+// have to sleep through:
+//
+//	v := clock.NewVirtual(time.Unix(1486847000, 0))
+//	cfg := graw.Config{Clock: v, ...}
+//	...
+//	v.Advance(time.Minute)
+//
+// Real is the wall clock, which graw uses unless told otherwise.
+package clock
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Clock tells the time and waits for it to pass.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+	// After returns a channel which receives the time once d has passed.
+	After(d time.Duration) <-chan time.Time
+	// NewTicker returns a ticker which sends the time every d. Like a
+	// time.Ticker, it drops ticks a slow receiver is not ready for.
+	NewTicker(d time.Duration) Ticker
+	// AfterFunc calls f in its own goroutine once d has passed, unless
+	// the returned timer is stopped first.
+	AfterFunc(d time.Duration, f func()) Timer
+}
+
+// Ticker is a ticker of a Clock.
+type Ticker interface {
+	// C returns the channel the ticks are sent on.
+	C() <-chan time.Time
+	// Stop stops the ticker. No more ticks are sent.
+	Stop()
+}
+
+// Timer is a timer of a Clock.
+type Timer interface {
+	// Stop stops the timer, and returns false if it had already fired
+	// or been stopped.
+	Stop() bool
+}
+
+// Real is the wall clock.
+var Real Clock = realClock{}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time { return time.Now() }
+
+func (realClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+func (realClock) NewTicker(d time.Duration) Ticker {
+	return realTicker{time.NewTicker(d)}
+}
+
+func (realClock) AfterFunc(d time.Duration, f func()) Timer {
+	return time.AfterFunc(d, f)
+}
+
+type realTicker struct{ t *time.Ticker }
+
+func (r realTicker) C() <-chan time.Time { return r.t.C }
+func (r realTicker) Stop()               { r.t.Stop() }
+
+// Virtual is a clock whose time only moves when it is advanced. Waits on it
+// end as Advance or Set move its time past them, in the order they end. It is
+// goroutine safe.
+type Virtual struct {
+	now     time.Time
+	waiters []*waiter
+	// waiting is signaled whenever a wait starts, for BlockUntil.
+	waiting *sync.Cond
+	mu      *sync.Mutex
+}
+
+// waiter is a wait on a virtual clock: a channel to send to, or a function to
+// call, at a time, and every period after it if it is a ticker's.
+type waiter struct {
+	at     time.Time
+	period time.Duration
+	c      chan time.Time
+	f      func()
+}
+
+// NewVirtual returns a virtual clock set to start.
+func NewVirtual(start time.Time) *Virtual {
+	mu := &sync.Mutex{}
+	return &Virtual{now: start, waiting: sync.NewCond(mu), mu: mu}
+}
+
+// Now returns the clock's time.
+func (v *Virtual) Now() time.Time {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.now
+}
+
+// After returns a channel which receives the time once the clock has been
+// advanced by d.
+func (v *Virtual) After(d time.Duration) <-chan time.Time {
+	w := &waiter{c: make(chan time.Time, 1)}
+	v.wait(w, d)
+	return w.c
+}
+
+// NewTicker returns a ticker which sends the time each time the clock is
+// advanced past another d.
+func (v *Virtual) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("clock: non-positive interval for NewTicker")
+	}
+
+	w := &waiter{period: d, c: make(chan time.Time, 1)}
+	v.wait(w, d)
+	return &virtualTicker{v: v, w: w}
+}
+
+// AfterFunc calls f in its own goroutine once the clock has been advanced by
+// d.
+func (v *Virtual) AfterFunc(d time.Duration, f func()) Timer {
+	w := &waiter{f: f}
+	v.wait(w, d)
+	return &virtualTimer{v: v, w: w}
+}
+
+// Advance moves the clock forward by d, ending the waits it passes.
+func (v *Virtual) Advance(d time.Duration) {
+	v.Set(v.Now().Add(d))
+}
+
+// Set moves the clock to t, ending the waits it passes, e.g. to follow the
+// times of events being replayed. A clock is never moved backward; a t before
+// its time is ignored.
+func (v *Virtual) Set(t time.Time) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if t.Before(v.now) {
+		return
+	}
+
+	for {
+		sort.SliceStable(v.waiters, func(i, j int) bool {
+			return v.waiters[i].at.Before(v.waiters[j].at)
+		})
+		if len(v.waiters) == 0 || v.waiters[0].at.After(t) {
+			break
+		}
+
+		w := v.waiters[0]
+		v.waiters = v.waiters[1:]
+		v.now = w.at
+		w.fire()
+		if w.period > 0 {
+			w.at = w.at.Add(w.period)
+			v.waiters = append(v.waiters, w)
+		}
+	}
+	v.now = t
+}
+
+// Waiters returns the number of waits on the clock which have not ended.
+func (v *Virtual) Waiters() int {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return len(v.waiters)
+}
+
+// BlockUntil blocks until at least n waits are on the clock, e.g. so a test
+// advances the clock only once the goroutine it is testing is waiting.
+func (v *Virtual) BlockUntil(n int) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	for len(v.waiters) < n {
+		v.waiting.Wait()
+	}
+}
+
+// wait starts the wait, to end once the clock has been advanced by d.
+func (v *Virtual) wait(w *waiter, d time.Duration) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	w.at = v.now.Add(d)
+	if d <= 0 {
+		w.fire()
+		if w.period == 0 {
+			return
+		}
+		w.at = v.now.Add(w.period)
+	}
+	v.waiters = append(v.waiters, w)
+	v.waiting.Broadcast()
+}
+
+// stop ends the wait without firing it, and returns false if it had already
+// ended.
+func (v *Virtual) stop(w *waiter) bool {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	for i, waiting := range v.waiters {
+		if waiting == w {
+			v.waiters = append(v.waiters[:i], v.waiters[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// fire ends the wait at its time. The caller holds the clock's lock.
+func (w *waiter) fire() {
+	if w.f != nil {
+		go w.f()
+		return
+	}
+
+	select {
+	case w.c <- w.at:
+	default:
+	}
+}
+
+type virtualTicker struct {
+	v *Virtual
+	w *waiter
+}
+
+func (t *virtualTicker) C() <-chan time.Time { return t.w.c }
+func (t *virtualTicker) Stop()               { t.v.stop(t.w) }
+
+type virtualTimer struct {
+	v *Virtual
+	w *waiter
+}
+
+func (t *virtualTimer) Stop() bool { return t.v.stop(t.w) }
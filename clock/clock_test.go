@@ -0,0 +1,118 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+var start = time.Unix(1486847000, 0)
+
+func TestVirtualAfter(t *testing.T) {
+	v := NewVirtual(start)
+	c := v.After(time.Minute)
+
+	v.Advance(59 * time.Second)
+	select {
+	case <-c:
+		t.Fatalf("wait ended early")
+	default:
+	}
+
+	v.Advance(time.Second)
+	select {
+	case at := <-c:
+		if !at.Equal(start.Add(time.Minute)) {
+			t.Errorf("got time %v; wanted %v", at, start.Add(time.Minute))
+		}
+	default:
+		t.Fatalf("wait did not end")
+	}
+
+	if now := v.Now(); !now.Equal(start.Add(time.Minute)) {
+		t.Errorf("got now %v; wanted %v", now, start.Add(time.Minute))
+	}
+	if v.Waiters() != 0 {
+		t.Errorf("got %d waiters after the wait ended; wanted 0", v.Waiters())
+	}
+}
+
+func TestVirtualTicker(t *testing.T) {
+	v := NewVirtual(start)
+	ticker := v.NewTicker(time.Second)
+
+	v.Advance(time.Second)
+	if at := <-ticker.C(); !at.Equal(start.Add(time.Second)) {
+		t.Errorf("got tick at %v; wanted %v", at, start.Add(time.Second))
+	}
+
+	// Ticks a slow receiver misses are dropped, as with a time.Ticker.
+	v.Advance(5 * time.Second)
+	if at := <-ticker.C(); !at.Equal(start.Add(2 * time.Second)) {
+		t.Errorf("got tick at %v; wanted %v", at, start.Add(2*time.Second))
+	}
+	select {
+	case at := <-ticker.C():
+		t.Errorf("got extra tick at %v", at)
+	default:
+	}
+
+	ticker.Stop()
+	v.Advance(time.Minute)
+	select {
+	case at := <-ticker.C():
+		t.Errorf("got tick at %v after stopping", at)
+	default:
+	}
+}
+
+func TestVirtualAfterFunc(t *testing.T) {
+	v := NewVirtual(start)
+	called := make(chan bool, 2)
+	v.AfterFunc(time.Second, func() { called <- true })
+	stopped := v.AfterFunc(time.Second, func() { called <- false })
+	if !stopped.Stop() {
+		t.Errorf("stopping a pending timer returned false")
+	}
+
+	v.Advance(time.Second)
+	if ok := <-called; !ok {
+		t.Errorf("stopped timer called its function")
+	}
+	if stopped.Stop() {
+		t.Errorf("stopping a stopped timer returned true")
+	}
+}
+
+func TestVirtualSet(t *testing.T) {
+	v := NewVirtual(start)
+	c := v.After(time.Hour)
+
+	v.Set(start.Add(-time.Hour))
+	if now := v.Now(); !now.Equal(start) {
+		t.Errorf("clock moved backward to %v", now)
+	}
+
+	v.Set(start.Add(2 * time.Hour))
+	select {
+	case <-c:
+	default:
+		t.Errorf("wait did not end when the clock was set past it")
+	}
+}
+
+func TestVirtualBlockUntil(t *testing.T) {
+	v := NewVirtual(start)
+	done := make(chan bool)
+	go func() {
+		<-v.After(time.Minute)
+		close(done)
+	}()
+
+	v.BlockUntil(1)
+	v.Advance(time.Minute)
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("waiting goroutine was not woken")
+	}
+}
@@ -127,7 +127,7 @@ func main() {
 		}
 	} else {
 		if script, err := reddit.NewScript(
-			"graw:feed demo bot:0.5.1 by /u/roxven",
+			"graw:feed_demo_bot:0.5.1 (by /u/roxven)",
 			*rate,
 		); err != nil {
 			log.Fatalf("Failed to create reddit script: %v\n", err)
@@ -0,0 +1,197 @@
+// Package graw is a cli tool which streams Reddit events as JSON lines, one
+// event per line, for shell pipelines and for checking an agent file works
+// before writing a bot around it.
+//
+//	graw --subs golang --comments golang | jq .post.title
+//	graw --subs golang --filter "score > 100 && title ~= 'release'"
+//	graw --agent bot.agent --verify
+//	GRAW_AGENT_KEY=... graw --agent bot.agent --seal > bot.sealed
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"sync"
+
+	"github.com/turnage/graw"
+	"github.com/turnage/graw/filter"
+	"github.com/turnage/graw/reddit"
+	"github.com/turnage/graw/sink"
+	"gopkg.in/alecthomas/kingpin.v2"
+)
+
+var (
+	app            = kingpin.New("graw", "A cli tool for streaming Reddit events as JSON lines.")
+	agent          = app.Flag("agent", "Filename of the agent file to use.").String()
+	rate           = app.Flag("rate", "Update interval.").Duration()
+	subs           = app.Flag("subs", "Subreddits to stream posts from.").Strings()
+	comments       = app.Flag("comments", "Subreddits to stream comments from.").Strings()
+	users          = app.Flag("users", "Users to stream activity from.").Strings()
+	threads        = app.Flag("threads", "Permalinks of threads to stream comments from.").Strings()
+	threadSort     = app.Flag("thread-sort", "Sort threads are polled in (confidence, top, new, old, controversial, qa).").String()
+	threadDepth    = app.Flag("thread-depth", "Maximum depth of thread comments polled.").Int()
+	threadLimit    = app.Flag("thread-limit", "Maximum number of thread comments polled.").Int()
+	postreplies    = app.Flag("postreplies", "Stream replies to the bot's posts.").Bool()
+	commentreplies = app.Flag("commentreplies", "Stream replies to the bot's comments.").Bool()
+	mentions       = app.Flag("mentions", "Stream mentions of the bot's username.").Bool()
+	messages       = app.Flag("messages", "Stream messages sent to the bot.").Bool()
+	filterExpr     = app.Flag("filter", "Only stream events matching this filter expression.").String()
+	env            = app.Flag("env", "Log in with credentials from GRAW_* environment variables.").Bool()
+	verify         = app.Flag("verify", "Log in, print the account, and exit.").Bool()
+	seal           = app.Flag("seal", "Print the agent file sealed with the passphrase in GRAW_AGENT_KEY, and exit.").Bool()
+	health         = app.Flag("health", "Address to serve /healthz and /readyz on, e.g. :8080.").String()
+	debug          = app.Flag("debug", "Also serve /stats and pprof profiles on the --health address.").Bool()
+	verbose        = app.Flag("verbose", "Log graw's internal messages to stderr.").Bool()
+	trace          = app.Flag("trace", "Log every request and response to stderr.").Bool()
+	uncompressed   = app.Flag("uncompressed", "Request uncompressed responses from Reddit.").Bool()
+)
+
+// printer returns a handler which writes every event to stdout as a line of
+// JSON.
+func printer() *sink.Handler {
+	mu := &sync.Mutex{}
+	enc := json.NewEncoder(os.Stdout)
+	return sink.NewHandler(
+		func(ev sink.Event) error {
+			mu.Lock()
+			defer mu.Unlock()
+			return enc.Encode(ev)
+		},
+	)
+}
+
+func bot(agentfile string) reddit.Bot {
+	creds := reddit.CredentialsFromEnv()
+	if agentfile != "" {
+		var err error
+		if creds, err = reddit.LoadCredentials(agentfile); err != nil {
+			log.Fatalf("Failed to load agent file: %v\n", err)
+		}
+	}
+
+	if err := creds.Validate(); err != nil {
+		log.Fatalf("Bad credentials: %v\n", err)
+	}
+
+	cfg := creds.BotConfig(*rate)
+	cfg.Debug = *trace
+	cfg.Transport.DisableCompression = *uncompressed
+	cfg.Logger = log.New(os.Stderr, "", log.LstdFlags)
+
+	bot, err := reddit.NewBot(cfg)
+	if err != nil {
+		log.Fatalf("Failed to create api handle: %v\n", err)
+	}
+
+	return bot
+}
+
+// whoami prints the account the bot is logged in as, which fails if its
+// credentials are bad.
+func whoami(bot reddit.Bot) {
+	me, err := bot.Me()
+	if err != nil {
+		log.Fatalf("Failed to log in: %v\n", err)
+	}
+
+	if err := json.NewEncoder(os.Stdout).Encode(me); err != nil {
+		log.Fatalf("Failed to print account: %v\n", err)
+	}
+}
+
+// sealAgentFile prints the agent file sealed for storage at rest.
+func sealAgentFile(agentfile string) {
+	buf, err := ioutil.ReadFile(agentfile)
+	if err != nil {
+		log.Fatalf("Failed to read agent file: %v\n", err)
+	}
+
+	sealed, err := reddit.SealCredentials(buf, os.Getenv(reddit.EnvAgentKey))
+	if err != nil {
+		log.Fatalf("Failed to seal agent file: %v\n", err)
+	}
+
+	os.Stdout.Write(sealed)
+}
+
+func main() {
+	kingpin.MustParse(app.Parse(os.Args[1:]))
+
+	if *seal {
+		sealAgentFile(*agent)
+		return
+	}
+
+	loggedIn := *agent != "" || *env
+	inbox := *postreplies || *commentreplies || *mentions || *messages
+	if !loggedIn && (inbox || *verify) {
+		fmt.Fprintf(os.Stderr, "You must provide credentials to log in.\n")
+		os.Exit(-1)
+	}
+
+	cfg := graw.Config{
+		Subreddits:        *subs,
+		SubredditComments: *comments,
+		Users:             *users,
+		Threads:           *threads,
+		ThreadOptions: reddit.ThreadOptions{
+			Sort:  *threadSort,
+			Depth: *threadDepth,
+			Limit: *threadLimit,
+		},
+		PostReplies:    *postreplies,
+		CommentReplies: *commentreplies,
+		Messages:       *messages,
+		Mentions:       *mentions,
+		Health:         graw.Health{Addr: *health, Debug: *debug},
+	}
+	if *filterExpr != "" {
+		f, err := filter.Compile(*filterExpr)
+		if err != nil {
+			log.Fatalf("Bad filter: %v\n", err)
+		}
+		cfg.Filters = graw.Filters{
+			Post:         f,
+			Comment:      f,
+			User:         f,
+			PostReply:    f,
+			CommentReply: f,
+			Mention:      f,
+			Message:      f,
+			Thread:       f,
+		}
+	}
+	if *verbose {
+		cfg.Logger = log.New(os.Stderr, "", log.LstdFlags)
+	}
+
+	var err error
+	var wait func() error
+	if loggedIn {
+		b := bot(*agent)
+		if *verify {
+			whoami(b)
+			return
+		}
+
+		if _, wait, err = graw.Run(printer(), b, cfg); err != nil {
+			log.Fatalf("Failed to launch graw run: %v\n", err)
+		}
+	} else {
+		if script, err := reddit.NewScript(
+			"graw:cli:0.1.0 (by /u/roxven)",
+			*rate,
+		); err != nil {
+			log.Fatalf("Failed to create reddit script: %v\n", err)
+		} else if _, wait, err = graw.Scan(printer(), script, cfg); err != nil {
+			log.Fatalf("graw launch failed: %v\n", err)
+		}
+	}
+
+	if err := wait(); err != nil {
+		log.Fatalf("graw run failed: %v\n", err)
+	}
+}
@@ -0,0 +1,41 @@
+package graw
+
+import (
+	"log"
+
+	"github.com/turnage/graw/reddit"
+)
+
+// commentContexts fetches the context of comments: their nearest ancestors
+// and the post they are in.
+type commentContexts struct {
+	lurker reddit.Lurker
+	depth  int
+	logger *log.Logger
+}
+
+// fetch returns the comment's ancestors, parent first, and its post. Lookups
+// which fail are logged, and the context fetched until then returned.
+func (cc *commentContexts) fetch(
+	c *reddit.Comment,
+) ([]*reddit.Comment, *reddit.Post) {
+	chain, err := cc.lurker.ParentChain(c.Name, cc.depth)
+	if err != nil {
+		cc.logger.Printf("Could not fetch the context of %s: %v", c.Name, err)
+		return chain.Comments, nil
+	}
+	if len(chain.Posts) == 1 {
+		return chain.Comments, chain.Posts[0]
+	}
+
+	// The chain stopped short of the post, which is looked up on its own.
+	h, err := cc.lurker.Info(c.LinkID)
+	if err != nil {
+		cc.logger.Printf("Could not fetch the post of %s: %v", c.Name, err)
+		return chain.Comments, nil
+	}
+	if len(h.Posts) != 1 {
+		return chain.Comments, nil
+	}
+	return chain.Comments, h.Posts[0]
+}
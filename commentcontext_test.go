@@ -0,0 +1,107 @@
+package graw
+
+import (
+	"io/ioutil"
+	"log"
+	"testing"
+
+	"github.com/kylelemons/godebug/pretty"
+	"github.com/turnage/graw/reddit"
+)
+
+// chainLurker answers lookups from a thread of comments, each replying to the
+// one before it, under a post.
+type chainLurker struct {
+	reddit.Lurker
+	post     *reddit.Post
+	comments []*reddit.Comment
+	err      error
+}
+
+func (l *chainLurker) ParentChain(
+	fullname string,
+	depth int,
+) (reddit.Harvest, error) {
+	var chain reddit.Harvest
+	if l.err != nil {
+		return chain, l.err
+	}
+
+	for i := len(l.comments) - 1; i >= 0; i-- {
+		if l.comments[i].Name != fullname {
+			continue
+		}
+		for j := i - 1; j >= 0; j-- {
+			if len(chain.Comments) == depth {
+				return chain, nil
+			}
+			chain.Comments = append(chain.Comments, l.comments[j])
+		}
+	}
+	chain.Posts = []*reddit.Post{l.post}
+	return chain, nil
+}
+
+func (l *chainLurker) Info(fullnames ...string) (reddit.Harvest, error) {
+	if len(fullnames) == 1 && fullnames[0] == l.post.Name {
+		return reddit.Harvest{Posts: []*reddit.Post{l.post}}, nil
+	}
+	return reddit.Harvest{}, nil
+}
+
+func TestCommentContexts(t *testing.T) {
+	post := &reddit.Post{Name: "t3_p"}
+	comments := []*reddit.Comment{
+		{Name: "t1_a", LinkID: "t3_p"},
+		{Name: "t1_b", LinkID: "t3_p"},
+		{Name: "t1_c", LinkID: "t3_p"},
+	}
+	l := &chainLurker{post: post, comments: comments}
+
+	for i, test := range []struct {
+		depth     int
+		ancestors []*reddit.Comment
+	}{
+		{1, []*reddit.Comment{comments[1]}},
+		{2, []*reddit.Comment{comments[1], comments[0]}},
+		{5, []*reddit.Comment{comments[1], comments[0]}},
+	} {
+		cc := &commentContexts{
+			lurker: l,
+			depth:  test.depth,
+			logger: log.New(ioutil.Discard, "", 0),
+		}
+		ancestors, p := cc.fetch(comments[2])
+		if diff := pretty.Compare(ancestors, test.ancestors); diff != "" {
+			t.Errorf("%d: ancestors incorrect; diff: %s", i, diff)
+		}
+		if p != post {
+			t.Errorf("%d: got post %v; wanted %v", i, p, post)
+		}
+	}
+
+	l.err = reddit.BusyErr
+	cc := &commentContexts{
+		lurker: l,
+		depth:  2,
+		logger: log.New(ioutil.Discard, "", 0),
+	}
+	if ancestors, p := cc.fetch(comments[2]); ancestors != nil || p != nil {
+		t.Errorf("got context %v, %v after failing to fetch it", ancestors, p)
+	}
+}
+
+// commentBot handles comments without their context.
+type commentBot struct{}
+
+func (commentBot) Comment(c *reddit.Comment) error { return nil }
+
+func TestCommentHandlingNeedsContextHandler(t *testing.T) {
+	d := newDispatcher(Config{}, "", nil)
+	c := Config{CommentContext: 2}
+	if _, err := commentHandling(
+		commentBot{}, nil, c, d, nil,
+	); err != commentContextHandlerErr {
+		t.Errorf("got error %v; wanted %v", err, commentContextHandlerErr)
+	}
+}
@@ -2,6 +2,14 @@ package graw
 
 import (
 	"log"
+	"time"
+
+	"github.com/turnage/graw/clock"
+	"github.com/turnage/graw/reddit"
+	"github.com/turnage/graw/snapshot"
+	"github.com/turnage/graw/store"
+	"github.com/turnage/graw/trace"
+	"github.com/turnage/graw/unfurl"
 )
 
 // Config configures a graw run or scan by specifying event sources. Each event
@@ -9,16 +17,113 @@ import (
 // able to handle requested event types.
 type Config struct {
 	// New posts in all subreddits named here will be forwarded to the bot's
-	// PostHandler.
+	// PostHandler. Names may be given in any case, with or without an r/
+	// prefix (e.g. golang, R/Golang, or /r/golang/); each subreddit is
+	// polled once however many ways it is named.
 	Subreddits []string
 	// New comments in all subreddits named here will be forwarded to the
 	// bot's CommentHandler.
 	SubredditComments []string
+	// CommentContext, if set, is how many of the ancestors of each new
+	// comment in the SubredditComments (its parent, its parent's parent,
+	// and so on) are fetched with it, along with the post they are in,
+	// and handed to the bot's CommentContextHandler in place of its
+	// CommentHandler. Context is fetched only for comments the bot's
+	// filters and policies admit, and lookups made at once are shared.
+	CommentContext int
+	// Batching, if set, forwards the new posts in the Subreddits and
+	// comments in the SubredditComments in batches, to the bot's
+	// PostBatchHandler and CommentBatchHandler.
+	Batching Batching
+	// Subreddits named here are left out of r/all (and r/popular) when
+	// either is in the Subreddits or SubredditComments, e.g. to drop the
+	// noisiest communities from a firehose. Reddit leaves them out, so
+	// their posts and comments cost nothing to drop. The feed's listing
+	// is named with them, e.g. /r/all-pics-funny/new.
+	AllExcept []string
+	// Unfurler, if set, fetches the pages link posts in the Subreddits
+	// point to, and the bot's PageHandler is handed each post with what
+	// its page says about itself: its title and Open Graph metadata.
+	// Pages are fetched in the background, after the post is forwarded
+	// to the PostHandler; a page which cannot be fetched is logged and
+	// skipped.
+	Unfurler *unfurl.Fetcher
+	// Shard, if set, splits the Subreddits and SubredditComments among
+	// several processes, of which this is one.
+	Shard Shard
+	// New posts in the Subreddits are forwarded again to the bot's
+	// PostAgeHandler, with fresh scores and comment counts, when they
+	// reach each age named here (e.g. 10 minutes, 1 hour, and 1 day after
+	// they were posted).
+	PostAges []time.Duration
 	// New posts and comments made by all users named here will be forwarded
 	// to the bot's UserHandler. Note that since a separate monitor must be
 	// construced for every user, unlike subreddits, subscribing to the
 	// actions of many users can delay updates from other event sources.
 	Users []string
+	// New comments in all threads named here will be forwarded to the
+	// bot's ThreadHandler. Like users, each thread needs its own monitor.
+	// Threads may be named by permalink (e.g. /r/golang/comments/5du939),
+	// by any link to them or a comment in them (e.g.
+	// https://redd.it/5du939), by id (5du939), or by fullname
+	// (t3_5du939). Whichever way they are named, their feeds are named by
+	// their permalink, e.g. in Monitor.PauseSource.
+	Threads []string
+	// ThreadOptions select the slice of each watched thread which is
+	// polled. Threads are tailed from their newest comment, so by default
+	// they are polled sorted by new; set a Limit to make polls of large
+	// threads cheaper.
+	ThreadOptions reddit.ThreadOptions
+	// PriorityThreads are watched like Threads, but polled more often,
+	// e.g. an AMA in progress. Polls share the handle's rate, so priority
+	// threads take turns from the other threads rather than add
+	// requests: while they are waiting, up to PriorityWeight priority
+	// threads are polled for each poll of another thread. If the run is
+	// paced, priority threads are paced PriorityWeight times as fast. The
+	// default weight is 4.
+	PriorityThreads []string
+	PriorityWeight  int
+	// ThreadExpiry, if set, is how long each thread is watched for; once
+	// it passes, the thread is no longer polled. If Archiver is set too, a
+	// snapshot of each thread is saved when it expires.
+	ThreadExpiry time.Duration
+	Archiver     *snapshot.Archiver
+	// CheckSources, if set, makes Run and Scan look up the Subreddits,
+	// SubredditComments, Threads, and PriorityThreads before they start,
+	// and fail with SourceErrors naming those which do not exist or
+	// cannot be read, so a typo is caught at once rather than leaving a
+	// feed empty. Lookups are batched, a hundred names to a request.
+	CheckSources bool
+	// If set, the score, comment count, and upvote ratio of each watched
+	// thread are sampled into History every HistoryInterval, which is five
+	// minutes by default. Read the series back with History.Samples.
+	History         store.History
+	HistoryInterval time.Duration
+	// If set, each link post in the Subreddits is recorded here by the
+	// link it shares, so bots can ask where else a link was posted with
+	// Sightings.
+	Links store.Links
+	// If set, rolling statistics of the activity in the Subreddits and
+	// SubredditComments are kept here, and served by the health server's
+	// /stats endpoint. See NewSubredditStats.
+	SubredditStats *SubredditStats
+	// If set, rolling leaderboards of the authors in the Subreddits and
+	// SubredditComments (the top posters, top commenters, and most
+	// awarded) are kept here, e.g. for weekly recap posts. See
+	// NewLeaderboards.
+	Leaderboards *Leaderboards
+	// The top positions of the ranked listings named here are watched, and
+	// posts entering and leaving them are forwarded to the bot's
+	// RankHandler. Like users, each listing needs its own monitor.
+	Rankings []Ranking
+	// The top posts of the listings named here are forwarded to the
+	// bot's DigestHandler on a schedule, e.g. once a day for a bot which
+	// posts daily digests, or posted by graw itself (see Digest.PostTo).
+	// See DailyTop.
+	Digests []Digest
+	// The inbox feeds below may be taken in any combination. A bot which
+	// takes only one polls only that part of its inbox.
+	//
 	// When true, replies to posts made by the bot's account will be
 	// forwarded to the bot's PostReplyHandler.
 	PostReplies bool
@@ -31,7 +136,167 @@ type Config struct {
 	// When true, messages sent to the bot's inbox will be forwarded to the
 	// bot's MessageHandler.
 	Messages bool
+	// MarkRead, if true, marks inbox items read once they are handled:
+	// the bot's handler returned without error, or the config's policies
+	// turned them away. Items which failed, or which the bot did not get
+	// to before it stopped, stay unread and are forwarded again when the
+	// bot next starts; under AtMostOnce Delivery, failed items are marked
+	// read too. Marks are sent in batches every ten seconds.
+	MarkRead bool
+	// Ignore mutes users, subreddits, and domains; their events are not
+	// forwarded to any of the bot's handlers.
+	Ignore Ignore
+	// Cooldowns limit how often events by any one author are forwarded to
+	// each of the bot's handlers.
+	Cooldowns Cooldowns
+	// Filters select which events are forwarded to each of the bot's
+	// handlers.
+	Filters Filters
+	// Classification labels events with a model, such as a toxicity
+	// classifier, so the Filters can select them by their labels.
+	Classification Classification
+	// Breaker pauses handlers which keep failing, rather than stopping
+	// the run on their first error.
+	Breaker Breaker
+	// LoopGuard protects against the bot replying to itself or to other
+	// bots forever.
+	LoopGuard LoopGuard
+	// If set, events already in the seen set are not forwarded to the bot,
+	// and events which are handled are added to it (or, under AtMostOnce
+	// Delivery, events which are forwarded). With a durable set (see
+	// graw/store) this keeps a restarted bot from handling events twice.
+	Seen store.SeenSet
+	// If set, the place of each listing feed (subreddits, subreddit
+	// comments, users, and the inbox feeds) is saved here, and a
+	// restarted bot resumes its feeds from it. By default a place is only
+	// saved once the handlers of everything before it have returned
+	// without error, so events a stopped or failing bot did not finish
+	// are delivered again rather than lost; see Delivery. Thread and
+	// ranking feeds always start from the present.
+	Cursors store.Tips
+	// Delivery is whether events which were not handled, because their
+	// handlers failed or the bot stopped, are forwarded again or lost;
+	// see Delivery. FeedDelivery sets it for the feeds named, as
+	// Monitor.PauseSource names them (e.g. /r/golang/new), and Delivery
+	// for the rest.
+	Delivery     Delivery
+	FeedDelivery map[string]Delivery
+	// SpamCheck checks whether the bot's own submissions are visible to
+	// other users. Only logged in bots (see Run) can check.
+	SpamCheck SpamCheck
+	// Monitor, if set, pauses and resumes the run's polling while it runs.
+	Monitor *Monitor
+	// Pacing spreads the polls of the run's feeds across an interval,
+	// rather than polling each as often as the handle allows.
+	Pacing Pacing
+	// Degradation polls feeds which keep failing less often, and
+	// eventually not at all, rather than retrying them forever.
+	Degradation Degradation
+	// KeywordLists loads the keyword lists Filters match against (e.g.
+	// body ~= @spam) from a subreddit's wiki, and reloads them as the
+	// pages are edited.
+	KeywordLists KeywordLists
+	// Health configures an HTTP server for liveness and readiness probes.
+	Health Health
+	// Tracer, if set, records a span for each poll of a listing feed and
+	// for each event dispatched to the bot, with a child span for its
+	// handler. An event's span is a child of the span of the poll which
+	// fetched it, so its trace covers it from fetch to handler
+	// completion. Set reddit.BotConfig's Tracer too to trace the requests
+	// themselves. See graw/trace.
+	Tracer trace.Tracer
+	// Clock, if set, is the clock the run keeps time by: for pacing,
+	// backoffs, batches, cooldowns, and the like. Tests and replays can
+	// set a clock.Virtual to advance time at will. The default is the
+	// wall clock.
+	Clock clock.Clock
 	// If set, internal messages will be logged here. This is a spammy log
 	// used for debugging graw.
 	Logger *log.Logger
 }
+
+// Ranking names the top positions of a ranked listing to watch, e.g. the top
+// 10 of /r/golang/hot.
+type Ranking struct {
+	// Path of the listing, e.g. /r/golang/hot.
+	Path string
+	// Top is the number of positions watched; at most 1000. The default
+	// is 100. Each 100 positions cost a request per poll.
+	Top int
+	// If set, only changes for the posts with these fullnames (e.g. the
+	// bot's own) are forwarded.
+	Posts []string
+	// If true, posts moving within the top positions are forwarded too,
+	// not only posts entering and leaving them.
+	Moves bool
+	// Time is the range a top or controversial listing is ranked over,
+	// one of the reddit.Time constants, e.g. reddit.TimeWeek for
+	// /r/golang/top over the past week. The default is Reddit's, a day.
+	Time string
+}
+
+// defaultDigestEvery is the time between digests if a Digest does not say.
+const defaultDigestEvery = 24 * time.Hour
+
+// Digest names a listing whose top posts are forwarded to the bot together on
+// a schedule, e.g. the top 10 posts of /r/golang over each day.
+type Digest struct {
+	// Path of the listing, e.g. /r/golang/top.
+	Path string
+	// Top is the number of posts in each digest; at most 1000. The
+	// default is 10. Each 100 posts cost a request per digest.
+	Top int
+	// Time is the range a top or controversial listing is ranked over,
+	// one of the reddit.Time constants. The default is Reddit's, a day.
+	Time string
+	// Every is the time between digests. The default is a day. The first
+	// digest is forwarded one interval after the run starts.
+	Every time.Duration
+	// Comments is the number of top comments fetched into the Replies of
+	// each post in the digest, which costs a request per post. The
+	// default is none.
+	Comments int
+	// CommentSort is the order the comments are ranked in, one of the
+	// reddit.CommentSort constants. The default is reddit.CommentSortTop.
+	CommentSort string
+	// PostTo, if set, is the subreddit each digest is posted to as a self
+	// post (see DigestText), instead of being forwarded to the bot. This
+	// needs a logged in bot.
+	PostTo string
+	// Title is the title of posted digests, which is followed by the
+	// date. The default is "Top posts".
+	Title string
+}
+
+// DailyTop returns a Digest of the top posts of the subreddit over each day.
+func DailyTop(subreddit string, top int) Digest {
+	return Digest{
+		Path:  "/r/" + subreddit + "/top",
+		Top:   top,
+		Time:  reddit.TimeDay,
+		Every: defaultDigestEvery,
+	}
+}
+
+// allPageSize is the number of posts on a page of r/all.
+const allPageSize = 25
+
+// AllRanking returns a Ranking which watches the first pages of r/all, and
+// every post entering, leaving, and moving within them.
+func AllRanking(pages int) Ranking {
+	return Ranking{Path: "/r/all", Top: pages * allPageSize, Moves: true}
+}
+
+// wants returns true if changes for the post should be forwarded.
+func (r Ranking) wants(name string) bool {
+	if len(r.Posts) == 0 {
+		return true
+	}
+
+	for _, post := range r.Posts {
+		if post == name {
+			return true
+		}
+	}
+	return false
+}
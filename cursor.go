@@ -0,0 +1,83 @@
+package graw
+
+import (
+	"context"
+
+	"github.com/turnage/graw/reddit"
+	"github.com/turnage/graw/store"
+	"github.com/turnage/graw/streams"
+)
+
+// followListing streams the things in the listing at path to handle, one at a
+// time, which reports whether each is done with.
+//
+// With a cursor store, the listing resumes from the cursor saved for its path.
+// Under AtLeastOnce delivery, the cursor is only moved past things once they
+// and everything before them are done with; things a handler fails, or which
+// are held back from a paused handler, are sent again after the next poll, and
+// a bot which stops mid-listing is sent the things it did not finish again when
+// it restarts. Under AtMostOnce, things are done with before they are handled.
+func followListing(
+	sc reddit.Scanner,
+	cursors store.Tips,
+	path string,
+	delivery Delivery,
+	kill <-chan bool,
+	errs chan<- error,
+	handle func(streams.Event) bool,
+) error {
+	var tips []string
+	if cursors != nil {
+		var err error
+		if tips, err = cursors.Tips(path); err != nil {
+			return err
+		}
+	}
+
+	it, err := streams.ListingIterator(sc, path, tips)
+	if err != nil {
+		return err
+	}
+
+	if cursors != nil {
+		it.Checkpoint(func(tips []string) error {
+			return cursors.SetTips(path, tips)
+		})
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-kill
+		cancel()
+	}()
+
+	go func() {
+		for {
+			e, err := it.Next(ctx)
+			if ctx.Err() != nil {
+				return
+			} else if err != nil {
+				errs <- err
+				continue
+			}
+
+			if delivery == AtMostOnce {
+				if err := it.Ack(); err != nil {
+					errs <- err
+				}
+				handle(e)
+				continue
+			}
+
+			if !handle(e) {
+				continue
+			}
+
+			if err := it.Ack(); err != nil {
+				errs <- err
+			}
+		}
+	}()
+
+	return nil
+}
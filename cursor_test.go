@@ -0,0 +1,116 @@
+package graw
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/turnage/graw/reddit"
+	"github.com/turnage/graw/store"
+	"github.com/turnage/graw/streams"
+)
+
+// growingListing is a listing of posts which can be added to; it answers with
+// the posts newer than the reference post, newest first.
+type growingListing struct {
+	reddit.Scanner
+	posts []*reddit.Post
+	mu    sync.Mutex
+}
+
+func (g *growingListing) add(name string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.posts = append(g.posts, &reddit.Post{
+		Name:       name,
+		CreatedUTC: uint64(len(g.posts) + 1),
+	})
+}
+
+func (g *growingListing) Listing(path, before string) (reddit.Harvest, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	var h reddit.Harvest
+	for i := len(g.posts) - 1; i >= 0 && g.posts[i].Name != before; i-- {
+		h.Posts = append(h.Posts, g.posts[i])
+	}
+	time.Sleep(time.Millisecond)
+	return h, nil
+}
+
+func TestFollowListingRedelivers(t *testing.T) {
+	listing := &growingListing{}
+	listing.add("t3_a")
+	cursors := store.NewMemory()
+
+	handled := make(chan string)
+	follow := func(fail string) (chan bool, chan error) {
+		kill := make(chan bool)
+		errs := make(chan error, 10)
+		d := newDispatcher(Config{}, "", errs)
+		if err := followListing(
+			listing,
+			cursors,
+			"/r/golang/new",
+			AtLeastOnce,
+			kill,
+			errs,
+			func(e streams.Event) bool {
+				ok := d.dispatch(
+					postEv(postEvent, e.Post),
+					func() error {
+						if e.Post.Name == fail {
+							return fmt.Errorf("failed")
+						}
+						return nil
+					},
+				)
+				select {
+				case handled <- e.Post.Name:
+				case <-kill:
+				}
+				return ok
+			},
+		); err != nil {
+			t.Fatalf("error following listing: %v", err)
+		}
+		return kill, errs
+	}
+
+	kill, _ := follow("t3_c")
+	listing.add("t3_b")
+	if name := <-handled; name != "t3_b" {
+		t.Fatalf("got %s; wanted t3_b", name)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		tips, _ := cursors.Tips("/r/golang/new")
+		if len(tips) > 0 && tips[0] == "t3_b" {
+			break
+		} else if time.Now().After(deadline) {
+			t.Fatalf("cursor not saved after t3_b; got %v", tips)
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	listing.add("t3_c")
+	if name := <-handled; name != "t3_c" {
+		t.Fatalf("got %s; wanted t3_c", name)
+	}
+
+	// The failed post is tried again after the next poll.
+	if name := <-handled; name != "t3_c" {
+		t.Fatalf("got %s; wanted t3_c again", name)
+	}
+	close(kill)
+
+	// The restarted bot resumes after t3_b, so t3_c is sent again.
+	kill, _ = follow("")
+	defer close(kill)
+	if name := <-handled; name != "t3_c" {
+		t.Errorf("got %s after restart; wanted t3_c again", name)
+	}
+}
@@ -0,0 +1,218 @@
+package graw
+
+import (
+	"time"
+
+	"github.com/turnage/graw/reddit"
+)
+
+const (
+	// defaultDegradeBackoff is the wait before polling a degraded source
+	// again if a Degradation does not specify one.
+	defaultDegradeBackoff = time.Minute
+	// maxDegradeBackoff is the longest a degraded source waits between
+	// polls, however many times it has failed.
+	maxDegradeBackoff = time.Hour
+)
+
+// Degradation keeps one broken feed from spending the run's requests on
+// retries forever. Without it, a feed whose polls keep failing (e.g. a
+// subreddit which has gone private, or a thread whose permalink Reddit cannot
+// serve) is retried as often as the handle allows, as long as its errors do
+// not stop the run. With it, a feed which fails Threshold polls in a row is
+// degraded: it waits Backoff before polling again, doubling the wait with each
+// further failure. A feed which fails Suspend polls in a row is suspended, and
+// makes no more requests for the rest of the run. A degraded feed which polls
+// successfully goes back to its usual pace.
+//
+// Feeds are named as Monitor.PauseSource names them, and degraded
+// independently. If the bot implements botfaces.SourceAlertHandler, it is told
+// when feeds are degraded, suspended, and recovered.
+type Degradation struct {
+	// Threshold is the number of consecutive failures which degrade a
+	// feed. Zero disables degradation.
+	Threshold int
+	// Backoff is how long a degraded feed waits before its next poll, at
+	// first. If zero, it is a minute. The wait doubles with each failure,
+	// up to an hour.
+	Backoff time.Duration
+	// Suspend is the number of consecutive failures which suspend a feed.
+	// Zero never suspends feeds. It is at least Threshold.
+	Suspend int
+}
+
+// degrader enforces a Degradation on the polls of one feed. Each feed polls
+// from one goroutine, so it is not goroutine safe.
+type degrader struct {
+	c      Degradation
+	source string
+	d      *dispatcher
+	kill   <-chan bool
+	// failures is the number of polls in a row which failed, and last the
+	// latest of their errors.
+	failures int
+	last     error
+}
+
+// scanner returns the scanner with its polls degraded as the config says.
+func (c Degradation) scanner(
+	sc reddit.Scanner,
+	source string,
+	d *dispatcher,
+	kill <-chan bool,
+) reddit.Scanner {
+	if c.Threshold <= 0 {
+		return sc
+	}
+	return &degradedScanner{Scanner: sc, g: c.degrader(source, d, kill)}
+}
+
+// lurker returns the lurker with its thread polls degraded as the config says.
+func (c Degradation) lurker(
+	l reddit.Lurker,
+	source string,
+	d *dispatcher,
+	kill <-chan bool,
+) reddit.Lurker {
+	if c.Threshold <= 0 {
+		return l
+	}
+	return &degradedLurker{Lurker: l, g: c.degrader(source, d, kill)}
+}
+
+func (c Degradation) degrader(
+	source string,
+	d *dispatcher,
+	kill <-chan bool,
+) *degrader {
+	if c.Backoff <= 0 {
+		c.Backoff = defaultDegradeBackoff
+	}
+	if c.Suspend > 0 && c.Suspend < c.Threshold {
+		c.Suspend = c.Threshold
+	}
+	return &degrader{c: c, source: source, d: d, kill: kill}
+}
+
+// poll makes a poll of the feed, after waiting out its backoff if it is
+// degraded. A suspended feed does not poll; it blocks until the kill channel
+// closes, and returns its last error.
+func (g *degrader) poll(do func() error) error {
+	if g.suspended() {
+		<-g.kill
+		return g.last
+	}
+
+	if g.failures >= g.c.Threshold {
+		select {
+		case <-g.d.clock.After(g.backoff()):
+		case <-g.kill:
+			return g.last
+		}
+	}
+
+	err := do()
+	if err == nil {
+		if g.failures >= g.c.Threshold {
+			g.d.logger.Printf("Feed %s recovered.", g.source)
+			g.d.sourceAlert(func() error {
+				return g.d.sources.SourceRecovered(g.source)
+			})
+		}
+		g.failures, g.last = 0, nil
+		return nil
+	}
+
+	g.failures++
+	g.last = err
+	switch {
+	case g.suspended():
+		g.d.logger.Printf("Suspending feed %s: %v", g.source, err)
+		g.d.sourceAlert(func() error {
+			return g.d.sources.SourceSuspended(g.source, err)
+		})
+	case g.failures == g.c.Threshold:
+		g.d.logger.Printf("Degrading feed %s: %v", g.source, err)
+		g.d.sourceAlert(func() error {
+			return g.d.sources.SourceDegraded(g.source, err)
+		})
+	}
+	return err
+}
+
+func (g *degrader) suspended() bool {
+	return g.c.Suspend > 0 && g.failures >= g.c.Suspend
+}
+
+// backoff returns the wait before the next poll of the degraded feed.
+func (g *degrader) backoff() time.Duration {
+	wait := g.c.Backoff
+	for i := g.c.Threshold; i < g.failures && wait < maxDegradeBackoff; i++ {
+		wait *= 2
+	}
+	if wait > maxDegradeBackoff {
+		wait = maxDegradeBackoff
+	}
+	return wait
+}
+
+type degradedScanner struct {
+	reddit.Scanner
+	g *degrader
+}
+
+func (s *degradedScanner) Listing(
+	path, after string,
+) (h reddit.Harvest, err error) {
+	err = s.g.poll(func() error {
+		h, err = s.Scanner.Listing(path, after)
+		return err
+	})
+	return h, err
+}
+
+func (s *degradedScanner) ListingWithParams(
+	path string,
+	params map[string]string,
+) (h reddit.Harvest, err error) {
+	err = s.g.poll(func() error {
+		h, err = s.Scanner.ListingWithParams(path, params)
+		return err
+	})
+	return h, err
+}
+
+func (s *degradedScanner) ListingWithOptions(
+	path string,
+	opts reddit.ListingOptions,
+) (h reddit.Harvest, err error) {
+	err = s.g.poll(func() error {
+		h, err = s.Scanner.ListingWithOptions(path, opts)
+		return err
+	})
+	return h, err
+}
+
+type degradedLurker struct {
+	reddit.Lurker
+	g *degrader
+}
+
+func (l *degradedLurker) Thread(permalink string) (p *reddit.Post, err error) {
+	err = l.g.poll(func() error {
+		p, err = l.Lurker.Thread(permalink)
+		return err
+	})
+	return p, err
+}
+
+func (l *degradedLurker) ThreadWithOptions(
+	permalink string,
+	opts reddit.ThreadOptions,
+) (p *reddit.Post, err error) {
+	err = l.g.poll(func() error {
+		p, err = l.Lurker.ThreadWithOptions(permalink, opts)
+		return err
+	})
+	return p, err
+}
@@ -0,0 +1,122 @@
+package graw
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/kylelemons/godebug/pretty"
+	"github.com/turnage/graw/reddit"
+)
+
+// failingListing fails the polls it is told to, and counts them all.
+type failingListing struct {
+	reddit.Scanner
+	fail  []bool
+	polls int
+}
+
+func (f *failingListing) Listing(path, after string) (reddit.Harvest, error) {
+	f.polls++
+	if f.polls <= len(f.fail) && f.fail[f.polls-1] {
+		return reddit.Harvest{}, reddit.BusyErr
+	}
+	return reddit.Harvest{}, nil
+}
+
+// sourceAlertBot records source alerts it is handed.
+type sourceAlertBot struct {
+	alerts []string
+}
+
+func (a *sourceAlertBot) SourceDegraded(source string, err error) error {
+	a.alerts = append(a.alerts, "degraded "+source)
+	return nil
+}
+
+func (a *sourceAlertBot) SourceSuspended(source string, err error) error {
+	a.alerts = append(a.alerts, "suspended "+source)
+	return nil
+}
+
+func (a *sourceAlertBot) SourceRecovered(source string) error {
+	a.alerts = append(a.alerts, "recovered "+source)
+	return fmt.Errorf("alerts do not stop feeds")
+}
+
+func TestDegradation(t *testing.T) {
+	bot := &sourceAlertBot{}
+	d := newDispatcher(Config{}, "", nil)
+	d.sources = bot
+
+	listing := &failingListing{
+		fail: []bool{true, true, false, true, true, true, true},
+	}
+	kill := make(chan bool)
+	c := Degradation{Threshold: 2, Backoff: time.Millisecond, Suspend: 4}
+	sc := c.scanner(listing, "/r/golang/new", d, kill)
+
+	for i := 0; i < 7; i++ {
+		sc.Listing("/r/golang/new", "")
+	}
+	if listing.polls != 7 {
+		t.Fatalf("got %d polls; wanted 7", listing.polls)
+	}
+
+	// The suspended feed polls no more.
+	done := make(chan error)
+	go func() {
+		_, err := sc.Listing("/r/golang/new", "")
+		done <- err
+	}()
+	select {
+	case <-done:
+		t.Fatalf("suspended feed polled")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	close(kill)
+	if err := <-done; err != reddit.BusyErr {
+		t.Errorf("got error %v from suspended feed; wanted BusyErr", err)
+	}
+	if listing.polls != 7 {
+		t.Errorf("suspended feed made %d polls", listing.polls-7)
+	}
+
+	if diff := pretty.Compare(bot.alerts, []string{
+		"degraded /r/golang/new",
+		"recovered /r/golang/new",
+		"degraded /r/golang/new",
+		"suspended /r/golang/new",
+	}); diff != "" {
+		t.Errorf("alerts incorrect; diff: %s", diff)
+	}
+}
+
+func TestDegradationBackoff(t *testing.T) {
+	g := Degradation{Threshold: 2, Backoff: time.Minute}.degrader("", nil, nil)
+	for _, test := range []struct {
+		failures int
+		backoff  time.Duration
+	}{
+		{2, time.Minute},
+		{3, 2 * time.Minute},
+		{5, 8 * time.Minute},
+		{100, time.Hour},
+	} {
+		g.failures = test.failures
+		if backoff := g.backoff(); backoff != test.backoff {
+			t.Errorf(
+				"got backoff %v after %d failures; wanted %v",
+				backoff, test.failures, test.backoff,
+			)
+		}
+	}
+}
+
+func TestDegradationDisabled(t *testing.T) {
+	listing := &failingListing{}
+	if sc := (Degradation{}).scanner(listing, "", nil, nil); sc != listing {
+		t.Errorf("scanner without degradation was wrapped")
+	}
+}
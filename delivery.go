@@ -0,0 +1,50 @@
+package graw
+
+import (
+	"strings"
+)
+
+// Delivery is how often the events of a feed may be forwarded to the bot's
+// handlers when handlers fail or the bot stops partway through a feed: a
+// choice between handling some events twice and never handling some at all.
+//
+// The choice is kept by the Cursors and the Seen set. Under AtLeastOnce, a
+// feed's place is saved in the Cursors, and its events are added to the Seen
+// set, only once their handlers return without error; a restarted bot resumes
+// from before the events its handlers did not finish, and handles them again.
+// Under AtMostOnce, the place is saved, and the events added to the Seen set,
+// before their handlers run; a restarted bot resumes after them, and the Seen
+// set drops them if they come again. Without Cursors or a durable Seen set, a
+// restarted bot starts its feeds from the present under either.
+//
+// Events forwarded in batches (see Batching) are delivered at most once
+// whatever their feed's Delivery, since each is done with once it joins a
+// batch.
+type Delivery int
+
+const (
+	// AtLeastOnce forwards an event again if its handler failed, or the
+	// bot stopped before it returned. Handlers should be idempotent. It is
+	// the default.
+	AtLeastOnce Delivery = iota
+	// AtMostOnce never forwards an event twice; events whose handlers
+	// failed or did not finish are lost.
+	AtMostOnce
+)
+
+// deliveryOf returns the Delivery of the feed, named as Monitor.PauseSource
+// names feeds.
+func (c Config) deliveryOf(feed string) Delivery {
+	for name, delivery := range c.FeedDelivery {
+		if strings.EqualFold(name, feed) {
+			return delivery
+		}
+	}
+	return c.Delivery
+}
+
+// deliveredAs marks the event as delivered as the Delivery says.
+func deliveredAs(e event, delivery Delivery) event {
+	e.atMostOnce = delivery == AtMostOnce
+	return e
+}
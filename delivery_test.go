@@ -0,0 +1,93 @@
+package graw
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/turnage/graw/store"
+	"github.com/turnage/graw/streams"
+)
+
+func TestDeliveryOf(t *testing.T) {
+	c := Config{
+		Delivery: AtMostOnce,
+		FeedDelivery: map[string]Delivery{
+			"/r/golang/new": AtLeastOnce,
+		},
+	}
+	if d := c.deliveryOf("/r/GoLang/new"); d != AtLeastOnce {
+		t.Errorf("got %v for a named feed; wanted AtLeastOnce", d)
+	}
+	if d := c.deliveryOf("/message/inbox"); d != AtMostOnce {
+		t.Errorf("got %v for another feed; wanted AtMostOnce", d)
+	}
+	if d := (Config{}).deliveryOf("/message/inbox"); d != AtLeastOnce {
+		t.Errorf("got %v by default; wanted AtLeastOnce", d)
+	}
+}
+
+func TestDispatcherSeenDelivery(t *testing.T) {
+	errs := make(chan error, 10)
+	seen := store.NewMemory()
+	d := newDispatcher(Config{Seen: seen}, "", errs)
+	fail := func() error { return fmt.Errorf("failed") }
+
+	d.dispatch(
+		deliveredAs(event{kind: postEvent, name: "t3_a"}, AtLeastOnce),
+		fail,
+	)
+	if marked, _ := seen.Seen("t3_a"); marked {
+		t.Errorf("failed event delivered at least once was marked seen")
+	}
+
+	d.dispatch(
+		deliveredAs(event{kind: postEvent, name: "t3_b"}, AtMostOnce),
+		fail,
+	)
+	if marked, _ := seen.Seen("t3_b"); !marked {
+		t.Errorf("failed event delivered at most once was not marked seen")
+	}
+}
+
+func TestFollowListingAtMostOnce(t *testing.T) {
+	listing := &growingListing{}
+	listing.add("t3_a")
+	cursors := store.NewMemory()
+	kill := make(chan bool)
+	defer close(kill)
+	errs := make(chan error, 10)
+
+	handled := make(chan string, 10)
+	if err := followListing(
+		listing,
+		cursors,
+		"/r/golang/new",
+		AtMostOnce,
+		kill,
+		errs,
+		func(e streams.Event) bool {
+			handled <- e.Post.Name
+			return false
+		},
+	); err != nil {
+		t.Fatalf("error following listing: %v", err)
+	}
+
+	listing.add("t3_b")
+	if name := <-handled; name != "t3_b" {
+		t.Fatalf("got %s; wanted t3_b", name)
+	}
+
+	// The cursor moves past t3_b though it was not done with.
+	deadline := time.Now().Add(time.Second)
+	for {
+		tips, _ := cursors.Tips("/r/golang/new")
+		if len(tips) > 0 && tips[0] == "t3_b" {
+			break
+		} else if time.Now().After(deadline) {
+			t.Fatalf("cursor not saved after t3_b; got %v", tips)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
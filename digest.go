@@ -0,0 +1,240 @@
+package graw
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/turnage/graw/botfaces"
+	"github.com/turnage/graw/link"
+	"github.com/turnage/graw/reddit"
+	"github.com/turnage/graw/streams"
+)
+
+var (
+	digestHandlerErr = fmt.Errorf(
+		"You must implement DigestHandler to receive digests.",
+	)
+	digestPostErr = fmt.Errorf(
+		"You must be running as a logged in bot to post digests.",
+	)
+)
+
+const (
+	// defaultDigestTitle is the title of posted digests if a Digest does
+	// not say.
+	defaultDigestTitle = "Top posts"
+	// digestDateLayout is the layout of the date in posted digests'
+	// titles.
+	digestDateLayout = "January 2, 2006"
+	// digestExcerpt is the most characters of a comment quoted in a
+	// digest.
+	digestExcerpt = 300
+	// redditURL prefixes permalinks in digests.
+	redditURL = "https://www.reddit.com"
+)
+
+// connectDigests connects the digests in the config to the handler, or to the
+// subreddits they are posted to.
+func connectDigests(
+	handler interface{},
+	sc reddit.Script,
+	c Config,
+	d *dispatcher,
+	kill <-chan bool,
+	errs chan<- error,
+) error {
+	for _, digest := range c.Digests {
+		var handle func(posts []*reddit.Post) error
+		if digest.PostTo != "" {
+			acct, ok := sc.(reddit.Account)
+			if !ok {
+				return digestPostErr
+			}
+			handle = digestPoster(acct, digest, d)
+		} else {
+			dh, ok := handler.(botfaces.DigestHandler)
+			if !ok {
+				return digestHandlerErr
+			}
+			path := digest.Path
+			handle = func(posts []*reddit.Post) error {
+				return dh.Digest(path, posts)
+			}
+		}
+
+		every := digest.Every
+		if every <= 0 {
+			every = defaultDigestEvery
+		}
+
+		digests := streams.Digest(
+			c.Monitor.scanner(
+				c.Degradation.scanner(
+					d.tracing.scanner(sc),
+					digest.Path,
+					d,
+					kill,
+				),
+				digest.Path,
+				kill,
+			),
+			kill,
+			errs,
+			digest.Path,
+			digest.Time,
+			digest.Top,
+			every,
+		)
+		lurker := c.Monitor.lurker(
+			c.Degradation.lurker(sc, digest.Path, d, kill),
+			digest.Path,
+			kill,
+		)
+		go func(digest Digest) {
+			for posts := range digests {
+				posts := posts
+				topComments(lurker, digest, posts, d)
+				d.dispatch(
+					event{kind: digestEvent, thing: posts},
+					func() error { return handle(posts) },
+				)
+			}
+		}(digest)
+	}
+	return nil
+}
+
+// digestPoster returns a handler which posts digests to the digest's
+// subreddit. Empty digests are not posted.
+func digestPoster(
+	acct reddit.Account,
+	digest Digest,
+	d *dispatcher,
+) func(posts []*reddit.Post) error {
+	subreddit := link.SubredditName(digest.PostTo)
+	title := digest.Title
+	if title == "" {
+		title = defaultDigestTitle
+	}
+
+	return func(posts []*reddit.Post) error {
+		if len(posts) == 0 {
+			return nil
+		}
+		return acct.PostSelf(
+			subreddit,
+			fmt.Sprintf(
+				"%s, %s",
+				title,
+				d.clock.Now().Format(digestDateLayout),
+			),
+			DigestText(posts),
+		)
+	}
+}
+
+// topComments fetches the digest's number of top comments of each post into
+// its Replies. Stickied and removed comments are left out. Posts whose
+// comments cannot be fetched are left without them, and the failure logged.
+func topComments(
+	lurker reddit.Lurker,
+	digest Digest,
+	posts []*reddit.Post,
+	d *dispatcher,
+) {
+	if digest.Comments <= 0 {
+		return
+	}
+
+	sort := digest.CommentSort
+	if sort == "" {
+		sort = reddit.CommentSortTop
+	}
+
+	for _, post := range posts {
+		// Twice as many comments are asked for as are wanted, so the
+		// stickied and removed comments left out leave enough.
+		thread, err := lurker.ThreadWithOptions(
+			post.Permalink,
+			reddit.ThreadOptions{
+				Sort:  sort,
+				Depth: 1,
+				Limit: 2 * digest.Comments,
+			},
+		)
+		if err != nil {
+			d.logger.Printf(
+				"Could not fetch comments of %s for digest: %v",
+				post.Name, err,
+			)
+			continue
+		}
+
+		post.Replies = nil
+		for _, comment := range thread.Replies {
+			if len(post.Replies) == digest.Comments {
+				break
+			}
+			if comment.Stickied || comment.Removed() {
+				continue
+			}
+			comment.Replies = nil
+			post.Replies = append(post.Replies, comment)
+		}
+	}
+}
+
+// DigestText renders the posts of a digest as Markdown: a numbered list of
+// links to their threads, each with its score and number of comments, and the
+// comments in its Replies quoted beneath it, cut short if they are long.
+func DigestText(posts []*reddit.Post) string {
+	var b strings.Builder
+	for i, post := range posts {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(
+			&b,
+			"%d. [%s](%s%s) by /u/%s · %d points · %d comments\n",
+			i+1,
+			markdownEscaper.Replace(post.Title),
+			redditURL,
+			post.Permalink,
+			post.Author,
+			post.Score,
+			post.NumComments,
+		)
+		for _, comment := range post.Replies {
+			b.WriteString("\n")
+			b.WriteString(digestQuote(comment))
+		}
+	}
+	return b.String()
+}
+
+// markdownEscaper escapes the characters which would end a link's text.
+var markdownEscaper = strings.NewReplacer("[", `\[`, "]", `\]`)
+
+// digestQuote renders a comment quoted under its post in a digest's list.
+func digestQuote(c *reddit.Comment) string {
+	body := strings.TrimSpace(c.Body)
+	if runes := []rune(body); len(runes) > digestExcerpt {
+		body = strings.TrimSpace(string(runes[:digestExcerpt])) + "…"
+	}
+
+	var b strings.Builder
+	for _, line := range strings.Split(body, "\n") {
+		if line = strings.TrimSpace(line); line == "" {
+			b.WriteString("    >\n")
+		} else {
+			b.WriteString("    > " + line + "\n")
+		}
+	}
+	fmt.Fprintf(
+		&b,
+		"    >\n    > — /u/%s · %d points\n",
+		c.Author,
+		c.Ups-c.Downs,
+	)
+	return b.String()
+}
@@ -0,0 +1,233 @@
+package graw
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/turnage/graw/clock"
+	"github.com/turnage/graw/reddit"
+)
+
+// topListing answers every listing with its posts.
+type topListing struct {
+	reddit.Script
+	posts []*reddit.Post
+}
+
+func (l *topListing) ListingWithOptions(
+	path string,
+	opts reddit.ListingOptions,
+) (reddit.Harvest, error) {
+	return reddit.Harvest{Posts: l.posts}, nil
+}
+
+func (l *topListing) ThreadWithOptions(
+	permalink string,
+	opts reddit.ThreadOptions,
+) (*reddit.Post, error) {
+	if opts.Sort != reddit.CommentSortTop || opts.Depth != 1 {
+		return nil, fmt.Errorf("got thread options %+v", opts)
+	}
+	return &reddit.Post{
+		Replies: []*reddit.Comment{
+			{Name: "t1_sticky", Stickied: true},
+			{Name: "t1_gone", Body: "[removed]"},
+			{Name: "t1_a", Body: "first", Ups: 5},
+			{Name: "t1_b", Body: "second"},
+			{Name: "t1_c", Body: "third"},
+		},
+	}, nil
+}
+
+// digestAccount records the digests posted through it.
+type digestAccount struct {
+	*topListing
+	reddit.Account
+	posts chan string
+}
+
+func (a *digestAccount) PostSelf(subreddit, title, text string) error {
+	a.posts <- subreddit + "|" + title + "|" + text
+	return nil
+}
+
+type digestRecorder struct {
+	digests chan []*reddit.Post
+}
+
+func (r *digestRecorder) Digest(listing string, posts []*reddit.Post) error {
+	r.digests <- posts
+	return nil
+}
+
+func TestDigests(t *testing.T) {
+	digest := DailyTop("golang", 2)
+	if digest.Path != "/r/golang/top" || digest.Time != reddit.TimeDay {
+		t.Errorf("got daily top %+v", digest)
+	}
+	digest.Every = time.Millisecond
+
+	sc := &topListing{
+		posts: []*reddit.Post{{Name: "t3_a"}, {Name: "t3_b"}, {Name: "t3_c"}},
+	}
+	rec := &digestRecorder{digests: make(chan []*reddit.Post, 1)}
+	kill := make(chan bool)
+	defer close(kill)
+	errs := make(chan error, 10)
+
+	c := Config{Digests: []Digest{digest}}
+	if err := connectScanStreams(
+		rec,
+		sc,
+		c,
+		newDispatcher(c, "", errs),
+		kill,
+		errs,
+	); err != nil {
+		t.Fatalf("error connecting digests: %v", err)
+	}
+
+	select {
+	case posts := <-rec.digests:
+		if len(posts) != 2 || posts[0].Name != "t3_a" {
+			t.Errorf("got digest of %d posts; wanted t3_a and t3_b", len(posts))
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("no digest was forwarded")
+	}
+
+	if err := connectScanStreams(
+		struct{}{},
+		sc,
+		c,
+		newDispatcher(c, "", errs),
+		kill,
+		errs,
+	); err != digestHandlerErr {
+		t.Errorf("got %v for a bot without a DigestHandler; wanted %v",
+			err, digestHandlerErr)
+	}
+}
+
+func TestDigestComments(t *testing.T) {
+	digest := DailyTop("golang", 1)
+	digest.Every = time.Millisecond
+	digest.Comments = 2
+
+	sc := &topListing{posts: []*reddit.Post{{Name: "t3_a"}}}
+	rec := &digestRecorder{digests: make(chan []*reddit.Post, 1)}
+	kill := make(chan bool)
+	defer close(kill)
+	errs := make(chan error, 10)
+
+	c := Config{Digests: []Digest{digest}}
+	if err := connectScanStreams(
+		rec,
+		sc,
+		c,
+		newDispatcher(c, "", errs),
+		kill,
+		errs,
+	); err != nil {
+		t.Fatalf("error connecting digests: %v", err)
+	}
+
+	select {
+	case posts := <-rec.digests:
+		replies := posts[0].Replies
+		if len(replies) != 2 ||
+			replies[0].Name != "t1_a" ||
+			replies[1].Name != "t1_b" {
+			t.Errorf("got comments %+v; wanted t1_a and t1_b", replies)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("no digest was forwarded")
+	}
+}
+
+func TestDigestPosting(t *testing.T) {
+	digest := DailyTop("golang", 1)
+	digest.Every = time.Millisecond
+	digest.Comments = 1
+	digest.PostTo = "/r/golangdigest"
+
+	bot := &digestAccount{
+		topListing: &topListing{posts: []*reddit.Post{{
+			Name:        "t3_a",
+			Title:       "Go [1.8] is out",
+			Permalink:   "/r/golang/comments/a/go_18/",
+			Author:      "gopher",
+			Score:       100,
+			NumComments: 12,
+		}}},
+		posts: make(chan string, 1),
+	}
+	kill := make(chan bool)
+	defer close(kill)
+	errs := make(chan error, 10)
+
+	c := Config{
+		Digests: []Digest{digest},
+		Clock: clock.NewVirtual(
+			time.Date(2017, time.February, 16, 0, 0, 0, 0, time.UTC),
+		),
+	}
+	if err := connectScanStreams(
+		struct{}{},
+		bot,
+		c,
+		newDispatcher(c, "", errs),
+		kill,
+		errs,
+	); err != nil {
+		t.Fatalf("error connecting digests: %v", err)
+	}
+
+	select {
+	case post := <-bot.posts:
+		parts := strings.SplitN(post, "|", 3)
+		if parts[0] != "golangdigest" {
+			t.Errorf("digest posted to %q; wanted golangdigest", parts[0])
+		}
+		if parts[1] != "Top posts, February 16, 2017" {
+			t.Errorf("got title %q", parts[1])
+		}
+		want := "1. [Go \\[1.8\\] is out]" +
+			"(https://www.reddit.com/r/golang/comments/a/go_18/) " +
+			"by /u/gopher · 100 points · 12 comments\n" +
+			"\n" +
+			"    > first\n" +
+			"    >\n" +
+			"    > — /u/ · 5 points\n"
+		if parts[2] != want {
+			t.Errorf("got digest text %q; wanted %q", parts[2], want)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("no digest was posted")
+	}
+
+	if err := connectScanStreams(
+		struct{}{},
+		bot.topListing,
+		c,
+		newDispatcher(c, "", errs),
+		kill,
+		errs,
+	); err != digestPostErr {
+		t.Errorf("got %v for a logged out bot; wanted %v",
+			err, digestPostErr)
+	}
+}
+
+func TestDigestTextCutsLongComments(t *testing.T) {
+	text := DigestText([]*reddit.Post{{
+		Title:   "a",
+		Replies: []*reddit.Comment{{Body: strings.Repeat("é", 400)}},
+	}})
+	if !strings.Contains(text, strings.Repeat("é", digestExcerpt)+"…") ||
+		strings.Contains(text, strings.Repeat("é", digestExcerpt+1)) {
+		t.Errorf("long comment was not cut short: %q", text)
+	}
+}
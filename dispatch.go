@@ -0,0 +1,495 @@
+package graw
+
+import (
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/turnage/graw/botfaces"
+	"github.com/turnage/graw/clock"
+	"github.com/turnage/graw/filter"
+	"github.com/turnage/graw/link"
+	"github.com/turnage/graw/reddit"
+	"github.com/turnage/graw/store"
+	"github.com/turnage/graw/throttle"
+)
+
+// eventKind names the handler an event is dispatched to.
+type eventKind string
+
+const (
+	postEvent            eventKind = "post"
+	commentEvent         eventKind = "comment"
+	postBatchEvent       eventKind = "post batch"
+	commentBatchEvent    eventKind = "comment batch"
+	userPostEvent        eventKind = "user post"
+	userCommentEvent     eventKind = "user comment"
+	postReplyEvent       eventKind = "post reply"
+	commentReplyEvent    eventKind = "comment reply"
+	mentionEvent         eventKind = "mention"
+	messageEvent         eventKind = "message"
+	threadEvent          eventKind = "thread comment"
+	postAgeEvent         eventKind = "post age"
+	rankEvent            eventKind = "rank"
+	digestEvent          eventKind = "digest"
+	postPageEvent        eventKind = "post page"
+	postGildedEvent      eventKind = "post gilded"
+	commentGildedEvent   eventKind = "comment gilded"
+	flairEvent           eventKind = "flair"
+	opReplyEvent         eventKind = "op reply"
+	postDeletedEvent     eventKind = "post deleted"
+	commentDeletedEvent  eventKind = "comment deleted"
+	postFilteredEvent    eventKind = "post filtered"
+	commentFilteredEvent eventKind = "comment filtered"
+)
+
+// event is an event on its way to the bot's handlers, with the fields the
+// engine's policies inspect.
+type event struct {
+	kind   eventKind
+	name   string
+	author string
+	// parent is the name of the thing the event replies to, if any.
+	parent string
+	// thread is the name of the post the event belongs to, if any.
+	thread string
+	// thing is the post, comment, or message the event is about.
+	thing interface{}
+	// atMostOnce is set if the event is added to the seen set before it
+	// is handled, rather than once it is handled.
+	atMostOnce bool
+}
+
+func postEv(kind eventKind, p *reddit.Post) event {
+	return event{
+		kind:   kind,
+		name:   p.Name,
+		author: p.Author,
+		thread: p.Name,
+		thing:  p,
+	}
+}
+
+// repeatable strips the name from an event which can happen to the same thing
+// many times, such as a post being gilded, so it bypasses the seen set.
+func repeatable(e event) event {
+	e.name = ""
+	return e
+}
+
+// agedPostEv is an event for a post reaching an age. Each age is a distinct
+// event, so the seen set does not drop later ages of a post.
+func agedPostEv(p *reddit.Post, age time.Duration) event {
+	e := postEv(postAgeEvent, p)
+	e.name = p.Name + "@" + age.String()
+	return e
+}
+
+func commentEv(kind eventKind, c *reddit.Comment) event {
+	return event{
+		kind:   kind,
+		name:   c.Name,
+		author: c.Author,
+		parent: c.ParentID,
+		thread: c.LinkID,
+		thing:  c,
+	}
+}
+
+// opReplyEv is an event for a post's author commenting in its thread. The
+// comment is also a thread comment event, so the OP reply is named apart from
+// it in the seen set.
+func opReplyEv(c *reddit.Comment) event {
+	e := commentEv(opReplyEvent, c)
+	e.name = c.Name + "@op"
+	return e
+}
+
+// deletedEv marks an event as about a submission being taken down. It is named
+// apart from other events about the submission in the seen set.
+func deletedEv(e event) event {
+	e.name += "@deleted"
+	return e
+}
+
+// filteredEv marks an event as about the bot's own submission being filtered.
+// It is named apart from other events about the submission in the seen set,
+// and carries no author or parent, since the loop guard would otherwise drop
+// it as the bot's own.
+func filteredEv(e event) event {
+	e.name += "@filtered"
+	e.author = ""
+	e.parent = ""
+	return e
+}
+
+func messageEv(kind eventKind, m *reddit.Message) event {
+	return event{
+		kind:   kind,
+		name:   m.Name,
+		author: m.Author,
+		parent: m.ParentID,
+		thread: threadOfContext(m.Context),
+		thing:  m,
+	}
+}
+
+// threadOfContext returns the name of the post a context permalink (e.g.
+// /r/golang/comments/5du93939/title/d8s9dfa/?context=3) points into.
+func threadOfContext(context string) string {
+	l, err := link.Parse(context)
+	if err != nil {
+		return ""
+	}
+	return l.PostFullname()
+}
+
+// Cooldowns limit how often events by any one author are forwarded to each of
+// the bot's handlers. For example, a Comment cooldown of ten minutes means the
+// bot's CommentHandler will see at most one comment per author every ten
+// minutes; the rest are dropped. Zero values disable the cooldown.
+//
+// Cooldowns are a cheap guard against reply loops and users spamming a bot. If
+// you need finer control, see graw/throttle.
+type Cooldowns struct {
+	Post         time.Duration
+	Comment      time.Duration
+	User         time.Duration
+	PostReply    time.Duration
+	CommentReply time.Duration
+	Mention      time.Duration
+	Message      time.Duration
+	Thread       time.Duration
+}
+
+// Filters select which events are forwarded to each of the bot's handlers. For
+// example, a Post filter of
+//
+//	filter.MustCompile("score > 100 && title ~= 'release'")
+//
+// means the bot's PostHandler will only see posts matching it; the rest are
+// dropped. Nil filters forward everything. See graw/filter for the language.
+type Filters struct {
+	Post         *filter.Filter
+	Comment      *filter.Filter
+	User         *filter.Filter
+	PostReply    *filter.Filter
+	CommentReply *filter.Filter
+	Mention      *filter.Filter
+	Message      *filter.Filter
+	Thread       *filter.Filter
+
+	// Normalizer, if set, rewrites the text of events before every filter
+	// compares it, so lookalike letters, invisible characters, and markdown
+	// do not slip words past keyword filters. filter.DefaultNormalizer is a
+	// good choice; with it, write patterns in lowercase.
+	Normalizer filter.Normalizer
+}
+
+// dispatcher applies the event policies in a Config to events before they are
+// forwarded to the bot's handlers.
+type dispatcher struct {
+	loops     *loopGuard
+	ignore    *ignoreList
+	throttles map[eventKind]*throttle.Throttle
+	filters   map[eventKind]*filter.Filter
+	seen      store.SeenSet
+	breaker   *breaker
+	// lists, if set, are the keyword lists filters match against, which
+	// are loaded from a wiki.
+	lists *filter.Lists
+	// classifier labels events for filters which compare labels.
+	classifier *classifyBatcher
+	// tracing, if set, records spans for dispatches.
+	tracing *tracing
+	// subreddits, if set, counts the activity in monitored subreddits.
+	subreddits *SubredditStats
+	// leaderboards, if set, credits authors in monitored subreddits.
+	leaderboards *Leaderboards
+	// alerts is told when the breaker pauses and resumes handlers.
+	alerts botfaces.AlertHandler
+	// sources is told when feeds are degraded, suspended, and recovered.
+	sources botfaces.SourceAlertHandler
+	// clock is the time the dispatcher and the feeds keep.
+	clock  clock.Clock
+	logger *log.Logger
+	errs   chan<- error
+
+	// pending counts the events each handler is working through, counts
+	// the events each has handled, and rate meters them all.
+	pending map[eventKind]int
+	counts  map[eventKind]int
+	rate    *meter
+	mu      *sync.Mutex
+}
+
+// newDispatcher returns a dispatcher for the policies in the config, which
+// reports handler results and its own errors to errs. self is the name of the
+// bot's account, if it is logged in.
+func newDispatcher(c Config, self string, errs chan<- error) *dispatcher {
+	d := &dispatcher{
+		loops:     newLoopGuard(c.LoopGuard, self),
+		ignore:    newIgnoreList(c.Ignore),
+		throttles: make(map[eventKind]*throttle.Throttle),
+		filters:   make(map[eventKind]*filter.Filter),
+		seen:      c.Seen,
+		breaker:   newBreaker(c.Breaker),
+		clock:     c.Clock,
+		logger:    logger(c.Logger),
+		errs:      errs,
+		pending:   make(map[eventKind]int),
+		counts:    make(map[eventKind]int),
+		rate:      newMeter(),
+		mu:        &sync.Mutex{},
+	}
+	if d.clock == nil {
+		d.clock = clock.Real
+	}
+	d.classifier = newClassifyBatcher(c.Classification, d.clock, d.logger)
+	d.tracing = newTracing(c.Tracer)
+	d.subreddits = c.SubredditStats
+	d.subreddits.keepTime(d.clock)
+	d.leaderboards = c.Leaderboards
+	d.cooldown(c.Cooldowns.Post, postEvent)
+	d.cooldown(c.Cooldowns.Comment, commentEvent)
+	d.cooldown(c.Cooldowns.User, userPostEvent, userCommentEvent)
+	d.cooldown(c.Cooldowns.PostReply, postReplyEvent)
+	d.cooldown(c.Cooldowns.CommentReply, commentReplyEvent)
+	d.cooldown(c.Cooldowns.Mention, mentionEvent)
+	d.cooldown(c.Cooldowns.Message, messageEvent)
+	d.cooldown(c.Cooldowns.Thread, threadEvent)
+	if len(c.KeywordLists.Pages) > 0 {
+		d.lists = filter.NewLists()
+	}
+	fs := c.Filters
+	prepared := func(f *filter.Filter) *filter.Filter {
+		return f.Normalized(fs.Normalizer).WithLists(d.lists)
+	}
+	d.filter(prepared(fs.Post), postEvent)
+	d.filter(prepared(fs.Comment), commentEvent)
+	d.filter(prepared(fs.User), userPostEvent, userCommentEvent)
+	d.filter(prepared(fs.PostReply), postReplyEvent)
+	d.filter(prepared(fs.CommentReply), commentReplyEvent)
+	d.filter(prepared(fs.Mention), mentionEvent)
+	d.filter(prepared(fs.Message), messageEvent)
+	d.filter(prepared(fs.Thread), threadEvent)
+	return d
+}
+
+// cooldown throttles authors of events of the given kinds, which share one
+// cooldown.
+func (d *dispatcher) cooldown(cooldown time.Duration, kinds ...eventKind) {
+	if cooldown <= 0 {
+		return
+	}
+
+	t := throttle.New(cooldown)
+	for _, kind := range kinds {
+		d.throttles[kind] = t
+	}
+}
+
+// filter applies the filter to events of the given kinds.
+func (d *dispatcher) filter(f *filter.Filter, kinds ...eventKind) {
+	if f == nil {
+		return
+	}
+
+	for _, kind := range kinds {
+		d.filters[kind] = f
+	}
+}
+
+// dispatch calls handle, which forwards the event to the bot, if the event is
+// admitted by the dispatcher's policies. Without a breaker, the handler's
+// result is reported to errs; with one, it is recorded by the breaker.
+//
+// It returns whether the event is done with: handled without error, or turned
+// away by a policy. Events which failed, or were held back by a paused
+// handler, are not.
+func (d *dispatcher) dispatch(e event, handle func() error) bool {
+	if d.tracing == nil {
+		return d.forward(e, handle)
+	}
+
+	return d.tracing.dispatch(e, handle, func(handle func() error) bool {
+		return d.forward(e, handle)
+	})
+}
+
+// forward is dispatch, untraced.
+func (d *dispatcher) forward(e event, handle func() error) bool {
+	admit, err := d.admit(e)
+	if err != nil {
+		d.errs <- err
+		return false
+	} else if !admit {
+		return true
+	}
+
+	if d.breaker != nil && !d.breaker.allow(e.kind, d.clock.Now()) {
+		return false
+	}
+
+	if e.atMostOnce && !d.markSeen(e) {
+		return false
+	}
+
+	d.track(e.kind, 1)
+	err = protect(e.kind, handle)
+	d.track(e.kind, -1)
+	d.count(e.kind, d.clock.Now())
+	if err == nil && !e.atMostOnce && !d.markSeen(e) {
+		return false
+	}
+	if d.breaker == nil {
+		d.errs <- err
+		return err == nil
+	}
+
+	if err != nil {
+		d.logger.Printf("%s handler failed: %v", e.kind, err)
+	}
+
+	switch tripped, resumed := d.breaker.record(
+		e.kind, err, d.clock.Now(),
+	); {
+	case tripped:
+		d.logger.Printf("Pausing %s handler: %v", e.kind, err)
+		d.alert(func() error {
+			return d.alerts.HandlerPaused(string(e.kind), err)
+		})
+	case resumed:
+		d.logger.Printf("Resuming %s handler.", e.kind)
+		d.alert(func() error {
+			return d.alerts.HandlerResumed(string(e.kind))
+		})
+	}
+	return err == nil
+}
+
+// markSeen adds the event to the seen set, if there is one. It returns false if
+// the event could not be added.
+func (d *dispatcher) markSeen(e event) bool {
+	if d.seen == nil || e.name == "" {
+		return true
+	}
+
+	if err := d.seen.MarkSeen(e.name); err != nil {
+		d.errs <- err
+		return false
+	}
+	return true
+}
+
+// track adds delta to the number of events the handler of the kind is working
+// through.
+func (d *dispatcher) track(kind eventKind, delta int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.pending[kind] += delta
+}
+
+// count counts an event handled at now.
+func (d *dispatcher) count(kind eventKind, now time.Time) {
+	d.rate.mark(now)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.counts[kind]++
+}
+
+// handled returns the number of events each handler has handled.
+func (d *dispatcher) handled() map[string]int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	handled := make(map[string]int)
+	for kind, n := range d.counts {
+		handled[string(kind)] = n
+	}
+	return handled
+}
+
+// cooldowns returns how many seconds are left of the author's cooldown for
+// each handler with one.
+func (d *dispatcher) cooldowns(
+	author string,
+	now time.Time,
+) map[string]float64 {
+	cooldowns := make(map[string]float64)
+	for kind, t := range d.throttles {
+		cooldowns[string(kind)] = t.RemainingAt(author, now).Seconds()
+	}
+	return cooldowns
+}
+
+// backlog returns the number of events each handler is working through. Event
+// streams wait on their handlers, so this is the backlog of the streams.
+func (d *dispatcher) backlog() map[string]int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	backlog := make(map[string]int)
+	for kind, n := range d.pending {
+		backlog[string(kind)] = n
+	}
+	return backlog
+}
+
+// alert calls the bot's alert handler, if it has one. Alerts are best effort;
+// a failing alert handler is only logged, so it cannot stop the run it is
+// reporting on.
+func (d *dispatcher) alert(handle func() error) {
+	if d.alerts == nil {
+		return
+	}
+
+	if err := protect("alert", handle); err != nil {
+		d.logger.Printf("alert handler failed: %v", err)
+	}
+}
+
+// sourceAlert calls the bot's source alert handler, if it has one. Like
+// alert, it is best effort.
+func (d *dispatcher) sourceAlert(handle func() error) {
+	if d.sources == nil {
+		return
+	}
+
+	if err := protect("alert", handle); err != nil {
+		d.logger.Printf("alert handler failed: %v", err)
+	}
+}
+
+// admit returns true if the event should be forwarded to the bot.
+func (d *dispatcher) admit(e event) (bool, error) {
+	if d.seen != nil && e.name != "" {
+		if seen, err := d.seen.Seen(e.name); err != nil || seen {
+			return false, err
+		}
+	}
+
+	if !d.loops.admit(e, d.clock.Now()) || !d.ignore.admit(e) {
+		return false, nil
+	}
+
+	// Filtered events are dropped before they can start a cooldown.
+	if f, ok := d.filters[e.kind]; ok {
+		var labels map[string]float64
+		if d.classifier != nil && f.UsesLabels() {
+			labels = d.classifier.labels(e.thing)
+		}
+		if !f.MatchLabels(e.thing, labels) {
+			return false, nil
+		}
+	}
+
+	if t, ok := d.throttles[e.kind]; ok {
+		return t.AllowAt(strings.ToLower(e.author), d.clock.Now()), nil
+	}
+	return true, nil
+}
@@ -0,0 +1,101 @@
+package graw
+
+import (
+	"testing"
+	"time"
+
+	"github.com/turnage/graw/filter"
+	"github.com/turnage/graw/reddit"
+	"github.com/turnage/graw/store"
+)
+
+func TestDispatcherCooldowns(t *testing.T) {
+	d := newDispatcher(Config{
+		Cooldowns: Cooldowns{
+			Comment: time.Hour,
+			User:    time.Hour,
+		},
+	}, "", nil)
+
+	for i, test := range []struct {
+		kind   eventKind
+		author string
+		admit  bool
+	}{
+		{commentEvent, "roxven", true},
+		{commentEvent, "Roxven", false},
+		{commentEvent, "other", true},
+		{postEvent, "roxven", true},
+		{postEvent, "roxven", true},
+		{userPostEvent, "roxven", true},
+		{userCommentEvent, "roxven", false},
+	} {
+		e := event{kind: test.kind, author: test.author}
+		if admit, _ := d.admit(e); admit != test.admit {
+			t.Errorf("%d: got %v; wanted %v", i, admit, test.admit)
+		}
+	}
+}
+
+func TestThreadOfContext(t *testing.T) {
+	for _, test := range []struct {
+		context string
+		thread  string
+	}{
+		{"/r/golang/comments/5du939/title/d8s9dfa/?context=3", "t3_5du939"},
+		{"/r/golang/comments/5du939/", "t3_5du939"},
+		{"", ""},
+		{"/message/messages/6x8h2a", ""},
+	} {
+		if thread := threadOfContext(test.context); thread != test.thread {
+			t.Errorf("%s: got %s; wanted %s", test.context, thread, test.thread)
+		}
+	}
+}
+
+func TestDispatcherSeen(t *testing.T) {
+	errs := make(chan error, 10)
+	seen := store.NewMemory()
+	d := newDispatcher(Config{Seen: seen}, "", errs)
+
+	calls := 0
+	handle := func() error {
+		calls++
+		return nil
+	}
+
+	d.dispatch(event{kind: postEvent, name: "t3_a"}, handle)
+	d.dispatch(event{kind: postEvent, name: "t3_a"}, handle)
+	d.dispatch(event{kind: postEvent, name: "t3_b"}, handle)
+
+	if calls != 2 {
+		t.Errorf("handler called %d times; wanted 2", calls)
+	}
+
+	if marked, _ := seen.Seen("t3_b"); !marked {
+		t.Errorf("dispatched event was not marked seen")
+	}
+}
+
+func TestDispatcherFilters(t *testing.T) {
+	d := newDispatcher(Config{
+		Filters: Filters{
+			Post: filter.MustCompile("score > 100"),
+		},
+		Cooldowns: Cooldowns{Post: time.Hour},
+	}, "", nil)
+
+	for i, test := range []struct {
+		e     event
+		admit bool
+	}{
+		{postEv(postEvent, &reddit.Post{Author: "roxven", Score: 5}), false},
+		// The filtered post did not start a cooldown.
+		{postEv(postEvent, &reddit.Post{Author: "roxven", Score: 500}), true},
+		{postEv(userPostEvent, &reddit.Post{Author: "other", Score: 5}), true},
+	} {
+		if admit, _ := d.admit(test.e); admit != test.admit {
+			t.Errorf("%d: got %v; wanted %v", i, admit, test.admit)
+		}
+	}
+}
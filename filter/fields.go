@@ -0,0 +1,128 @@
+package filter
+
+import (
+	"github.com/turnage/graw/lang"
+	"github.com/turnage/graw/reddit"
+)
+
+type fieldKind int
+
+const (
+	numberField fieldKind = iota
+	stringField
+	boolField
+)
+
+// fieldKinds are the kinds of every field any thing has, so misspelled fields
+// are caught when a filter is compiled.
+var fieldKinds = map[string]fieldKind{
+	"name":          stringField,
+	"author":        stringField,
+	"subreddit":     stringField,
+	"title":         stringField,
+	"body":          stringField,
+	"subject":       stringField,
+	"url":           stringField,
+	"domain":        stringField,
+	"flair":         stringField,
+	"author_flair":  stringField,
+	"lang":          stringField,
+	"score":         numberField,
+	"comments":      numberField,
+	"upvote_ratio":  numberField,
+	"awards":        numberField,
+	"created":       numberField,
+	"nsfw":          boolField,
+	"self":          boolField,
+	"stickied":      boolField,
+	"locked":        boolField,
+	"top_level":     boolField,
+	"collapsed":     boolField,
+	"controversial": boolField,
+	"score_hidden":  boolField,
+	"new":           boolField,
+}
+
+func kindOf(value interface{}) fieldKind {
+	switch value.(type) {
+	case string:
+		return stringField
+	case bool:
+		return boolField
+	}
+	return numberField
+}
+
+// Fields returns the fields filters can select a thing by. Things which are
+// not a *reddit.Post, *reddit.Comment, or *reddit.Message have no fields.
+//
+// Posts have name, author, subreddit, title, body (self text), url, domain,
+// flair, author_flair, lang, score, comments, upvote_ratio, awards, created,
+// nsfw, self, stickied, and locked.
+//
+// Comments have name, author, subreddit, title (of their post), body,
+// author_flair, lang, score, awards, created, top_level, stickied, collapsed,
+// controversial, and score_hidden.
+//
+// Messages have name, author, subreddit, title (of the post replied in),
+// subject, body, lang, created, and new.
+//
+// lang is the ISO 639-1 code of the language of the thing's text as
+// graw/lang guesses it (e.g. "en"), or "" if it cannot tell.
+func Fields(thing interface{}) map[string]interface{} {
+	switch t := thing.(type) {
+	case *reddit.Post:
+		return map[string]interface{}{
+			"name":         t.Name,
+			"author":       t.Author,
+			"subreddit":    t.Subreddit,
+			"title":        t.Title,
+			"body":         t.SelfText,
+			"url":          t.URL,
+			"domain":       t.Domain,
+			"flair":        t.LinkFlairText,
+			"author_flair": t.AuthorFlairText,
+			"lang":         lang.Detect(t.Title + "\n" + t.SelfText),
+			"score":        float64(t.Score),
+			"comments":     float64(t.NumComments),
+			"upvote_ratio": t.UpvoteRatio,
+			"awards":       float64(t.Awards()),
+			"created":      float64(t.CreatedUTC),
+			"nsfw":         t.NSFW,
+			"self":         t.IsSelf,
+			"stickied":     t.Stickied,
+			"locked":       t.Locked,
+		}
+	case *reddit.Comment:
+		return map[string]interface{}{
+			"name":          t.Name,
+			"author":        t.Author,
+			"subreddit":     t.Subreddit,
+			"title":         t.LinkTitle,
+			"body":          t.Body,
+			"author_flair":  t.AuthorFlairText,
+			"lang":          lang.Detect(t.Body),
+			"score":         float64(t.Ups - t.Downs),
+			"awards":        float64(t.Awards()),
+			"created":       float64(t.CreatedUTC),
+			"top_level":     t.IsTopLevel(),
+			"stickied":      t.Stickied,
+			"collapsed":     t.Collapsed,
+			"controversial": t.IsControversial(),
+			"score_hidden":  t.ScoreHidden,
+		}
+	case *reddit.Message:
+		return map[string]interface{}{
+			"name":      t.Name,
+			"author":    t.Author,
+			"subreddit": t.Subreddit,
+			"title":     t.LinkTitle,
+			"subject":   t.Subject,
+			"body":      t.Body,
+			"lang":      lang.Detect(t.Subject + "\n" + t.Body),
+			"created":   float64(t.CreatedUTC),
+			"new":       t.New,
+		}
+	}
+	return nil
+}
@@ -0,0 +1,221 @@
+// Package filter compiles small expressions which select posts, comments, and
+// messages by their fields, so bots can route events without writing the
+// checks into their handlers:
+//
+//	f, err := filter.Compile(`score > 100 && subreddit == 'golang' && title ~= 'release'`)
+//
+// Expressions compare a field to a literal with ==, !=, <, <=, >, >=, or ~=,
+// which matches a string field against a regular expression. Comparisons are
+// combined with && and ||, negated with !, and grouped with parentheses.
+// Boolean fields such as nsfw can stand alone. Literals are numbers, strings
+// in single or double quotes, true, and false. See Fields for the fields of
+// each kind of thing.
+//
+// Things can also carry labels, scores given to them by something outside the
+// thing such as a toxicity or topic model. Labels are number fields named
+// labels.<label>, e.g. labels.toxic > 0.8; see MatchLabels.
+//
+// Text fields can also be matched against named keyword lists, which are kept
+// outside the expression so they can change while it is in use: body ~= @spam
+// matches bodies containing any keyword of the spam list; see Lists.
+//
+// graw applies filters itself if they are configured in graw.Config.
+package filter
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// labelPrefix prefixes the names of label fields.
+const labelPrefix = "labels."
+
+// Filter is a compiled expression. Its methods are goroutine safe.
+type Filter struct {
+	expr      string
+	root      node
+	labels    bool
+	normalize Normalizer
+	// listNames are the lists the filter matches against, whose patterns
+	// are looked up in lists.
+	listNames []string
+	lists     *Lists
+}
+
+// Compile parses an expression into a Filter.
+func Compile(expr string) (*Filter, error) {
+	toks, err := lex(expr)
+	if err != nil {
+		return nil, fmt.Errorf("filter %q: %v", expr, err)
+	}
+
+	p := &parser{toks: toks}
+	root, err := p.parse()
+	if err != nil {
+		return nil, fmt.Errorf("filter %q: %v", expr, err)
+	}
+
+	return &Filter{
+		expr:      expr,
+		root:      root,
+		labels:    p.labels,
+		listNames: p.lists,
+	}, nil
+}
+
+// MustCompile is like Compile but panics if the expression does not parse. It
+// is meant for expressions written into a bot's source.
+func MustCompile(expr string) *Filter {
+	f, err := Compile(expr)
+	if err != nil {
+		panic(err)
+	}
+	return f
+}
+
+// String returns the expression the filter was compiled from.
+func (f *Filter) String() string {
+	return f.expr
+}
+
+// Match returns true if the thing, a *reddit.Post, *reddit.Comment, or
+// *reddit.Message, satisfies the filter. Comparisons against fields the thing
+// does not have are false.
+func (f *Filter) Match(thing interface{}) bool {
+	return f.MatchLabels(thing, nil)
+}
+
+// MatchLabels is like Match for a thing with labels, which the filter's label
+// fields are compared against. Comparisons against labels the thing does not
+// have are false.
+func (f *Filter) MatchLabels(
+	thing interface{},
+	labels map[string]float64,
+) bool {
+	fields := Fields(thing)
+	if fields != nil {
+		f.normalizeFields(fields)
+		f.addLists(fields)
+		for label, score := range labels {
+			fields[labelPrefix+label] = score
+		}
+	}
+	return f.root.eval(fields)
+}
+
+// UsesLabels returns true if the filter compares any label fields, so callers
+// can skip labeling things for filters which would not look.
+func (f *Filter) UsesLabels() bool {
+	return f.labels
+}
+
+// isLabel returns true if the field name is a label field.
+func isLabel(field string) bool {
+	return strings.HasPrefix(field, labelPrefix) && len(field) > len(labelPrefix)
+}
+
+// node is a node of a compiled expression.
+type node interface {
+	eval(fields map[string]interface{}) bool
+}
+
+type andNode struct{ left, right node }
+
+func (n andNode) eval(fields map[string]interface{}) bool {
+	return n.left.eval(fields) && n.right.eval(fields)
+}
+
+type orNode struct{ left, right node }
+
+func (n orNode) eval(fields map[string]interface{}) bool {
+	return n.left.eval(fields) || n.right.eval(fields)
+}
+
+type notNode struct{ operand node }
+
+func (n notNode) eval(fields map[string]interface{}) bool {
+	return !n.operand.eval(fields)
+}
+
+// boolNode is a boolean field standing alone.
+type boolNode struct{ field string }
+
+func (n boolNode) eval(fields map[string]interface{}) bool {
+	b, ok := fields[n.field].(bool)
+	return ok && b
+}
+
+// compareNode compares a field to a literal.
+type compareNode struct {
+	field string
+	op    string
+	value interface{}
+	re    *regexp.Regexp
+}
+
+func (n compareNode) eval(fields map[string]interface{}) bool {
+	value, ok := fields[n.field]
+	if !ok {
+		return false
+	}
+
+	switch v := value.(type) {
+	case float64:
+		literal, ok := n.value.(float64)
+		return ok && compareNumbers(v, n.op, literal)
+	case string:
+		if n.op == "~=" {
+			return n.re.MatchString(v)
+		}
+		literal, ok := n.value.(string)
+		return ok && compareStrings(v, n.op, literal)
+	case bool:
+		literal, ok := n.value.(bool)
+		switch {
+		case !ok:
+			return false
+		case n.op == "==":
+			return v == literal
+		case n.op == "!=":
+			return v != literal
+		}
+	}
+	return false
+}
+
+func compareNumbers(a float64, op string, b float64) bool {
+	switch op {
+	case "==":
+		return a == b
+	case "!=":
+		return a != b
+	case "<":
+		return a < b
+	case "<=":
+		return a <= b
+	case ">":
+		return a > b
+	case ">=":
+		return a >= b
+	}
+	return false
+}
+
+func compareStrings(a string, op string, b string) bool {
+	switch op {
+	case "==":
+		return a == b
+	case "!=":
+		return a != b
+	case "<":
+		return a < b
+	case "<=":
+		return a <= b
+	case ">":
+		return a > b
+	case ">=":
+		return a >= b
+	}
+	return false
+}
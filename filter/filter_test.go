@@ -0,0 +1,121 @@
+package filter
+
+import (
+	"testing"
+
+	"github.com/turnage/graw/reddit"
+)
+
+func TestMatch(t *testing.T) {
+	post := &reddit.Post{
+		Subreddit:     "golang",
+		Title:         "Go 1.8 release notes",
+		Score:         150,
+		NSFW:          false,
+		LinkFlairText: "Solved",
+	}
+	comment := &reddit.Comment{
+		Subreddit:        "golang",
+		Body:             "hello",
+		Ups:              3,
+		ParentID:         "t3_abc",
+		Controversiality: 1,
+	}
+
+	for i, test := range []struct {
+		expr  string
+		thing interface{}
+		match bool
+	}{
+		{"score > 100", post, true},
+		{"score > 200", post, false},
+		{"score >= 150 && score <= 150", post, true},
+		{"subreddit == 'golang'", post, true},
+		{`subreddit != "golang"`, post, false},
+		{"title ~= 'release'", post, true},
+		{"title ~= '^release'", post, false},
+		{"score > 100 && subreddit == 'golang' && title ~= 'release'", post, true},
+		{"score > 200 || flair == 'Solved'", post, true},
+		{"!(score > 200 || flair == 'Solved')", post, false},
+		{"nsfw", post, false},
+		{"!nsfw", post, true},
+		{"nsfw == false", post, true},
+		{"score > -1", post, true},
+		{"top_level && body == 'hello'", comment, true},
+		{"score == 3", comment, true},
+		{"controversial && !collapsed", comment, true},
+		{"lang == 'en'", post, false},
+		{"lang == ''", post, true},
+		{"lang == 'de'", &reddit.Comment{Body: "Ich glaube, das ist nicht so einfach wie es aussieht."}, true},
+		// Comments have no flair, so comparisons against it are false.
+		{"flair == 'Solved'", comment, false},
+		{"flair != 'Solved'", comment, false},
+		{"score > 0", "not a thing", false},
+	} {
+		f, err := Compile(test.expr)
+		if err != nil {
+			t.Errorf("%d: error compiling %s: %v", i, test.expr, err)
+			continue
+		}
+
+		if match := f.Match(test.thing); match != test.match {
+			t.Errorf("%d: %s: got %v; wanted %v", i, test.expr, match, test.match)
+		}
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"scroe > 100",
+		"score",
+		"score >",
+		"score > 'many'",
+		"subreddit > 5",
+		"nsfw < true",
+		"score ~= '1'",
+		"labels. > 1",
+		"labels.toxic ~= 'x'",
+		"title ~= '('",
+		"(score > 1",
+		"score > 1)",
+		"title == 'unterminated",
+		"score > 1 &&",
+		"score # 1",
+	} {
+		if _, err := Compile(expr); err == nil {
+			t.Errorf("%q: compiled; wanted an error", expr)
+		}
+	}
+}
+
+func TestMatchLabels(t *testing.T) {
+	comment := &reddit.Comment{Body: "you are all wrong"}
+	labels := map[string]float64{"toxic": 0.93, "topic.politics": 0.1}
+
+	for i, test := range []struct {
+		expr  string
+		match bool
+	}{
+		{"labels.toxic > 0.8", true},
+		{"labels.toxic > 0.8 && body ~= 'wrong'", true},
+		{"labels.topic.politics >= 0.5", false},
+		// Comparisons against labels the thing does not have are false.
+		{"labels.spam < 0.5", false},
+	} {
+		f := MustCompile(test.expr)
+		if !f.UsesLabels() {
+			t.Errorf("%d: %s does not use labels", i, test.expr)
+		}
+		if match := f.MatchLabels(comment, labels); match != test.match {
+			t.Errorf("%d: %s: got %v; wanted %v", i, test.expr, match, test.match)
+		}
+	}
+
+	if MustCompile("score > 1").UsesLabels() {
+		t.Errorf("filter without label fields uses labels")
+	}
+	if MustCompile("labels.toxic > 0.8").Match(comment) {
+		t.Errorf("thing without labels matched a label comparison")
+	}
+}
@@ -0,0 +1,107 @@
+package filter
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// listPrefix prefixes the names of lists in expressions and fields.
+const listPrefix = "@"
+
+// Lists are named keyword lists which filters match text fields against with
+// ~=, e.g. body ~= @spam. A field matches a list if it contains any of the
+// list's keywords or phrases as whole words, in any case. Lists can be set
+// while filters use them, so keywords can be changed without compiling filters
+// again; see Filter.WithLists.
+type Lists struct {
+	mu    *sync.RWMutex
+	lists map[string]*regexp.Regexp
+}
+
+// NewLists returns an empty set of lists.
+func NewLists() *Lists {
+	return &Lists{
+		mu:    &sync.RWMutex{},
+		lists: make(map[string]*regexp.Regexp),
+	}
+}
+
+// Set replaces the keywords of the named list (e.g. spam for @spam). Blank
+// keywords are ignored; a list without keywords matches nothing.
+func (l *Lists) Set(name string, keywords []string) {
+	var alternatives []string
+	for _, keyword := range keywords {
+		words := strings.Fields(keyword)
+		if len(words) == 0 {
+			continue
+		}
+		for i, word := range words {
+			words[i] = regexp.QuoteMeta(word)
+		}
+		alternatives = append(alternatives, strings.Join(words, `\s+`))
+	}
+
+	var re *regexp.Regexp
+	if len(alternatives) > 0 {
+		re = regexp.MustCompile(
+			`(?i)(?:^|[^\pL\pN_])(?:` +
+				strings.Join(alternatives, "|") +
+				`)(?:$|[^\pL\pN_])`,
+		)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.lists[name] = re
+}
+
+// matcher returns the pattern of the named list, or nil if it has no keywords.
+func (l *Lists) matcher(name string) *regexp.Regexp {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.lists[name]
+}
+
+// WithLists returns a copy of the filter which matches against the lists.
+// Without lists, a filter's list comparisons match nothing. With nil lists, or
+// on a nil filter, the filter is returned as it is.
+func (f *Filter) WithLists(l *Lists) *Filter {
+	if f == nil || l == nil {
+		return f
+	}
+
+	withLists := *f
+	withLists.lists = l
+	return &withLists
+}
+
+// UsesLists returns true if the filter matches against any lists.
+func (f *Filter) UsesLists() bool {
+	return len(f.listNames) > 0
+}
+
+// addLists adds the patterns of the lists the filter uses to the fields.
+func (f *Filter) addLists(fields map[string]interface{}) {
+	if f.lists == nil {
+		return
+	}
+
+	for _, name := range f.listNames {
+		if re := f.lists.matcher(name); re != nil {
+			fields[listPrefix+name] = re
+		}
+	}
+}
+
+// listNode matches a string field against a list.
+type listNode struct {
+	field string
+	list  string
+}
+
+func (n listNode) eval(fields map[string]interface{}) bool {
+	v, ok := fields[n.field].(string)
+	re, _ := fields[listPrefix+n.list].(*regexp.Regexp)
+	return ok && re != nil && re.MatchString(v)
+}
@@ -0,0 +1,66 @@
+package filter
+
+import (
+	"testing"
+
+	"github.com/turnage/graw/reddit"
+)
+
+func TestLists(t *testing.T) {
+	lists := NewLists()
+	lists.Set("spam", []string{"free money", "Crypto", "c++", " "})
+
+	for i, test := range []struct {
+		body  string
+		match bool
+	}{
+		{"get FREE money now", true},
+		{"free\n money", true},
+		{"crypto!", true},
+		{"learning c++ today", true},
+		{"cryptography", false},
+		{"free monkeys", false},
+		{"nothing to see", false},
+	} {
+		f := MustCompile("body ~= @spam").WithLists(lists)
+		comment := &reddit.Comment{Body: test.body}
+		if match := f.Match(comment); match != test.match {
+			t.Errorf("%d: %q: got %v; wanted %v", i, test.body, match, test.match)
+		}
+	}
+
+	// Lists can change while filters use them.
+	f := MustCompile("title ~= @spam || title ~= @scam").WithLists(lists)
+	post := &reddit.Post{Title: "a scam"}
+	if f.Match(post) {
+		t.Errorf("matched a list which is not set")
+	}
+	lists.Set("scam", []string{"scam"})
+	if !f.Match(post) {
+		t.Errorf("did not match a list after it was set")
+	}
+	lists.Set("scam", nil)
+	if f.Match(post) {
+		t.Errorf("matched a list after it was emptied")
+	}
+
+	if !f.UsesLists() || MustCompile("score > 1").UsesLists() {
+		t.Errorf("filters do not report their use of lists")
+	}
+	if MustCompile("body ~= @spam").Match(&reddit.Comment{Body: "crypto"}) {
+		t.Errorf("filter without lists matched a list")
+	}
+}
+
+func TestListCompileErrors(t *testing.T) {
+	for _, expr := range []string{
+		"body == @spam",
+		"score ~= @spam",
+		"body ~= @",
+		"@spam",
+	} {
+		if _, err := Compile(expr); err == nil {
+			t.Errorf("%q: compiled; wanted an error", expr)
+		}
+	}
+}
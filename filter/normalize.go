@@ -0,0 +1,206 @@
+package filter
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// textFields are the fields holding text people write, which a filter's
+// normalizer rewrites. Names, links, and the like are compared as they are.
+var textFields = []string{"title", "body", "subject", "flair", "author_flair"}
+
+// Normalizer rewrites text before filters compare it, undoing the tricks
+// people use to slip words past keyword filters: "ʙᴀᴅ wоrd" with lookalike
+// letters, "b​ad" with invisible characters, or "**b**ad" with markdown.
+type Normalizer func(string) string
+
+// DefaultNormalizer removes invisible characters, strips markdown, folds
+// homoglyphs, and lowercases, in that order. Write patterns for it in
+// lowercase ASCII:
+//
+//	f := filter.MustCompile("body ~= 'free money'").
+//		Normalized(filter.DefaultNormalizer)
+var DefaultNormalizer = Chain(
+	RemoveInvisible,
+	StripMarkdown,
+	FoldHomoglyphs,
+	Lowercase,
+)
+
+// Chain returns a normalizer which applies the normalizers in order.
+func Chain(normalizers ...Normalizer) Normalizer {
+	return func(text string) string {
+		for _, n := range normalizers {
+			text = n(text)
+		}
+		return text
+	}
+}
+
+// Lowercase lowercases text.
+func Lowercase(text string) string {
+	return strings.ToLower(text)
+}
+
+// RemoveInvisible removes characters which take no space, such as zero width
+// spaces and joiners, soft hyphens, and direction marks.
+func RemoveInvisible(text string) string {
+	return strings.Map(func(r rune) rune {
+		if invisible(r) {
+			return -1
+		}
+		return r
+	}, text)
+}
+
+func invisible(r rune) bool {
+	switch {
+	case r == 0x00ad, r == 0x034f, r == 0x061c, r == 0x115f, r == 0x1160,
+		r == 0x180e, r == 0x3164, r == 0xfeff, r == 0xffa0:
+		return true
+	case r >= 0x200b && r <= 0x200f, r >= 0x202a && r <= 0x202e,
+		r >= 0x2060 && r <= 0x206f, r >= 0xfe00 && r <= 0xfe0f,
+		r >= 0xe0000 && r <= 0xe007f:
+		return true
+	}
+	return false
+}
+
+var (
+	// markdownLink matches links and images, [text](url).
+	markdownLink = regexp.MustCompile(`!?\[([^\]]*)\]\([^)]*\)`)
+	// markdownSpoiler matches spoilers, >!text!<.
+	markdownSpoiler = regexp.MustCompile(`>!(.*?)!<`)
+	// markdownLinePrefix matches the headings, quotes, and list markers
+	// which begin lines.
+	markdownLinePrefix = regexp.MustCompile(
+		`(?m)^[ \t]*(?:#{1,6}[ \t]+|(?:>[ \t]?)+|[*+-][ \t]+|\d+\.[ \t]+)`,
+	)
+	// markdownEscape matches backslash escapes, \*.
+	markdownEscape = regexp.MustCompile(`\\([\\*_~^` + "`" + `#>!\[\]()|-])`)
+	// markdownMarks are the characters of emphasis, strikethrough, code,
+	// and superscript, which are dropped wherever they are.
+	markdownMarks = strings.NewReplacer("*", "", "_", "", "~", "", "`", "",
+		"^", "")
+)
+
+// StripMarkdown reduces Reddit markdown to its text: links to their text,
+// spoilers to what they hide, and emphasis, strikethrough, code, superscript,
+// headings, quotes, and list markers removed. Escaped characters are kept.
+func StripMarkdown(text string) string {
+	text = markdownLink.ReplaceAllString(text, "$1")
+	text = markdownSpoiler.ReplaceAllString(text, "$1")
+	text = markdownLinePrefix.ReplaceAllString(text, "")
+
+	// Escaped characters are kept, so they are set aside while the marks
+	// are dropped.
+	var escaped []string
+	text = markdownEscape.ReplaceAllStringFunc(text, func(m string) string {
+		escaped = append(escaped, m[1:])
+		return "\x00"
+	})
+	text = markdownMarks.Replace(text)
+	for _, e := range escaped {
+		text = strings.Replace(text, "\x00", e, 1)
+	}
+	return text
+}
+
+// FoldHomoglyphs replaces letters which look like Latin letters with them:
+// Cyrillic and Greek lookalikes, fullwidth, small capital, and mathematical
+// letters, and letters with accents, whose accents are dropped.
+func FoldHomoglyphs(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+			// Combining marks, as in accents written separately
+			// from their letters, or stacked into "zalgo" text.
+			continue
+		case r >= 0xff01 && r <= 0xff5e:
+			// Fullwidth forms of ASCII.
+			r -= 0xfee0
+		case r >= 0x1d400 && r <= 0x1d6a3:
+			// Mathematical alphanumerics: bold, italic, script,
+			// and so on, each a run of A-Z and a-z.
+			if i := (r - 0x1d400) % 52; i < 26 {
+				r = 'A' + i
+			} else {
+				r = 'a' + i - 26
+			}
+		case r >= 0x1d7ce && r <= 0x1d7ff:
+			// Mathematical digits, each a run of 0-9.
+			r = '0' + (r-0x1d7ce)%10
+		default:
+			if folded, ok := homoglyphs[r]; ok {
+				r = folded
+			}
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// homoglyphs maps letters to the Latin letters they look like.
+var homoglyphs = map[rune]rune{
+	// Cyrillic.
+	'а': 'a', 'в': 'b', 'е': 'e', 'ё': 'e', 'к': 'k', 'м': 'm', 'н': 'h',
+	'о': 'o', 'р': 'p', 'с': 'c', 'т': 't', 'у': 'y', 'х': 'x', 'ѕ': 's',
+	'і': 'i', 'ї': 'i', 'ј': 'j', 'ԁ': 'd', 'ԛ': 'q', 'ԝ': 'w', 'һ': 'h',
+	'ɡ': 'g', 'А': 'A', 'В': 'B', 'Е': 'E', 'К': 'K', 'М': 'M', 'Н': 'H',
+	'О': 'O', 'Р': 'P', 'С': 'C', 'Т': 'T', 'У': 'Y', 'Х': 'X', 'Ѕ': 'S',
+	'І': 'I', 'Ј': 'J',
+	// Greek.
+	'α': 'a', 'β': 'b', 'ε': 'e', 'ι': 'i', 'κ': 'k', 'ν': 'v', 'ο': 'o',
+	'ρ': 'p', 'τ': 't', 'υ': 'u', 'χ': 'x', 'Α': 'A', 'Β': 'B', 'Ε': 'E',
+	'Ζ': 'Z', 'Η': 'H', 'Ι': 'I', 'Κ': 'K', 'Μ': 'M', 'Ν': 'N', 'Ο': 'O',
+	'Ρ': 'P', 'Τ': 'T', 'Υ': 'Y', 'Χ': 'X',
+	// Small capitals.
+	'ᴀ': 'a', 'ʙ': 'b', 'ᴄ': 'c', 'ᴅ': 'd', 'ᴇ': 'e', 'ꜰ': 'f', 'ɢ': 'g',
+	'ʜ': 'h', 'ɪ': 'i', 'ᴊ': 'j', 'ᴋ': 'k', 'ʟ': 'l', 'ᴍ': 'm', 'ɴ': 'n',
+	'ᴏ': 'o', 'ᴘ': 'p', 'ʀ': 'r', 'ꜱ': 's', 'ᴛ': 't', 'ᴜ': 'u', 'ᴠ': 'v',
+	'ᴡ': 'w', 'ʏ': 'y', 'ᴢ': 'z',
+	// Latin letters with accents.
+	'à': 'a', 'á': 'a', 'â': 'a', 'ã': 'a', 'ä': 'a', 'å': 'a', 'ā': 'a',
+	'ç': 'c', 'č': 'c', 'è': 'e', 'é': 'e', 'ê': 'e', 'ë': 'e', 'ē': 'e',
+	'ì': 'i', 'í': 'i', 'î': 'i', 'ï': 'i', 'ı': 'i', 'ñ': 'n', 'ò': 'o',
+	'ó': 'o', 'ô': 'o', 'õ': 'o', 'ö': 'o', 'ø': 'o', 'ō': 'o', 'š': 's',
+	'ù': 'u', 'ú': 'u', 'û': 'u', 'ü': 'u', 'ū': 'u', 'ý': 'y', 'ÿ': 'y',
+	'ž': 'z', 'À': 'A', 'Á': 'A', 'Â': 'A', 'Ã': 'A', 'Ä': 'A', 'Å': 'A',
+	'Ç': 'C', 'È': 'E', 'É': 'E', 'Ê': 'E', 'Ë': 'E', 'Ì': 'I', 'Í': 'I',
+	'Î': 'I', 'Ï': 'I', 'Ñ': 'N', 'Ò': 'O', 'Ó': 'O', 'Ô': 'O', 'Õ': 'O',
+	'Ö': 'O', 'Ø': 'O', 'Ù': 'U', 'Ú': 'U', 'Û': 'U', 'Ü': 'U', 'Ý': 'Y',
+	// Letterlike symbols.
+	'ℓ': 'l', 'ℯ': 'e', 'ℊ': 'g', 'ℴ': 'o', 'ℎ': 'h', 'ⅰ': 'i', 'ⅴ': 'v',
+	'ⅹ': 'x', 'ℂ': 'C', 'ℍ': 'H', 'ℕ': 'N', 'ℙ': 'P', 'ℚ': 'Q', 'ℝ': 'R',
+	'ℤ': 'Z',
+}
+
+// Normalized returns a copy of the filter which rewrites the text fields of
+// things (title, body, subject, flair, and author_flair) with the normalizer
+// before comparing them. Literals and patterns are not rewritten; write them
+// in the normalizer's terms, e.g. in lowercase for one which lowercases. With
+// a nil normalizer, or on a nil filter, the filter is returned as it is.
+func (f *Filter) Normalized(n Normalizer) *Filter {
+	if f == nil || n == nil {
+		return f
+	}
+
+	normalized := *f
+	normalized.normalize = n
+	return &normalized
+}
+
+// normalizeFields rewrites the text fields with the filter's normalizer.
+func (f *Filter) normalizeFields(fields map[string]interface{}) {
+	if f.normalize == nil {
+		return
+	}
+
+	for _, field := range textFields {
+		if text, ok := fields[field].(string); ok {
+			fields[field] = f.normalize(text)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package filter
+
+import (
+	"testing"
+
+	"github.com/turnage/graw/reddit"
+)
+
+func TestDefaultNormalizer(t *testing.T) {
+	for _, test := range []struct {
+		text string
+		want string
+	}{
+		{"Free Money", "free money"},
+		{"fr\u200bee mo\u00adney\ufeff", "free money"},
+		{"**free** _money_", "free money"},
+		{"[free money](https://example.com)", "free money"},
+		{">!free money!<", "free money"},
+		{"# free\n> money", "free\nmoney"},
+		{`free \*money\*`, "free *money*"},
+		{"fr\u0435\u0435 m\u043en\u0435y", "free money"},
+		{"ＦＲＥＥ money", "free money"},
+		{"\U0001d41f\U0001d42b\U0001d41e\U0001d41e money", "free money"},
+		{"fre\u0301e mon\u00e9y", "free money"},
+	} {
+		if got := DefaultNormalizer(test.text); got != test.want {
+			t.Errorf("%q: got %q; wanted %q", test.text, got, test.want)
+		}
+	}
+}
+
+func TestNormalized(t *testing.T) {
+	f := MustCompile("title ~= 'free money' && subreddit == 'golang'")
+	post := &reddit.Post{
+		Subreddit: "golang",
+		Title:     "FR\u0415\u0415 **money**",
+	}
+
+	if f.Match(post) {
+		t.Errorf("filter matched without normalizing")
+	}
+	if !f.Normalized(DefaultNormalizer).Match(post) {
+		t.Errorf("normalized filter did not match")
+	}
+	if f.Match(post) {
+		t.Errorf("normalizing changed the original filter")
+	}
+
+	// Only text fields are normalized.
+	f = MustCompile("subreddit == 'golang'").Normalized(DefaultNormalizer)
+	if f.Match(&reddit.Post{Subreddit: "GOLANG"}) {
+		t.Errorf("subreddit was normalized")
+	}
+
+	var none *Filter
+	if none.Normalized(DefaultNormalizer) != nil {
+		t.Errorf("normalized nil filter was not nil")
+	}
+}
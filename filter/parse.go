@@ -0,0 +1,305 @@
+package filter
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type tokenKind int
+
+const (
+	identToken tokenKind = iota
+	numberToken
+	stringToken
+	listToken
+	opToken
+	endToken
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+// operators are the operators of the language, longest first so the lexer
+// prefers "<=" to "<".
+var operators = []string{
+	"==", "!=", "<=", ">=", "~=", "&&", "||", "<", ">", "!", "(", ")",
+}
+
+// lex splits an expression into tokens.
+func lex(expr string) ([]token, error) {
+	var toks []token
+	for i := 0; i < len(expr); {
+		c := expr[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n':
+			i++
+		case c == '\'' || c == '"':
+			end := strings.IndexByte(expr[i+1:], c)
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated string at %d", i)
+			}
+			toks = append(
+				toks,
+				token{kind: stringToken, text: expr[i+1 : i+1+end], pos: i},
+			)
+			i += end + 2
+		case isDigit(c) || (c == '-' && i+1 < len(expr) && isDigit(expr[i+1])):
+			j := i + 1
+			for j < len(expr) && (isDigit(expr[j]) || expr[j] == '.') {
+				j++
+			}
+			toks = append(toks, token{kind: numberToken, text: expr[i:j], pos: i})
+			i = j
+		case c == listPrefix[0] && i+1 < len(expr) && isIdentStart(expr[i+1]):
+			j := i + 2
+			for j < len(expr) && isIdentPart(expr[j]) {
+				j++
+			}
+			toks = append(toks, token{kind: listToken, text: expr[i+1 : j], pos: i})
+			i = j
+		case isIdentStart(c):
+			j := i + 1
+			for j < len(expr) && isIdentPart(expr[j]) {
+				j++
+			}
+			toks = append(toks, token{kind: identToken, text: expr[i:j], pos: i})
+			i = j
+		default:
+			op := ""
+			for _, candidate := range operators {
+				if strings.HasPrefix(expr[i:], candidate) {
+					op = candidate
+					break
+				}
+			}
+			if op == "" {
+				return nil, fmt.Errorf("unexpected %q at %d", c, i)
+			}
+			toks = append(toks, token{kind: opToken, text: op, pos: i})
+			i += len(op)
+		}
+	}
+	return append(toks, token{kind: endToken, pos: len(expr)}), nil
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// isIdentPart reports whether the byte can continue a field name; dots join
+// the parts of label fields, e.g. labels.toxic.
+func isIdentPart(c byte) bool {
+	return isIdentStart(c) || isDigit(c) || c == '.'
+}
+
+// parser is a recursive descent parser for the grammar
+//
+//	or         = and { "||" and }
+//	and        = unary { "&&" unary }
+//	unary      = "!" unary | "(" or ")" | comparison
+//	comparison = field [ op literal | "~=" list ]
+type parser struct {
+	toks []token
+	pos  int
+	// labels is set if the expression compares any label fields.
+	labels bool
+	// lists are the lists the expression matches against.
+	lists []string
+}
+
+func (p *parser) parse() (node, error) {
+	n, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+
+	if t := p.peek(); t.kind != endToken {
+		return nil, fmt.Errorf("unexpected %q at %d", t.text, t.pos)
+	}
+	return n, nil
+}
+
+func (p *parser) peek() token {
+	return p.toks[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.toks[p.pos]
+	if t.kind != endToken {
+		p.pos++
+	}
+	return t
+}
+
+// accept consumes the next token if it is the operator.
+func (p *parser) accept(op string) bool {
+	if t := p.peek(); t.kind == opToken && t.text == op {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) or() (node, error) {
+	left, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.accept("||") {
+		right, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		left = orNode{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) and() (node, error) {
+	left, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.accept("&&") {
+		right, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		left = andNode{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) unary() (node, error) {
+	if p.accept("!") {
+		operand, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{operand}, nil
+	}
+
+	if p.accept("(") {
+		n, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if !p.accept(")") {
+			t := p.peek()
+			return nil, fmt.Errorf("expected ) at %d", t.pos)
+		}
+		return n, nil
+	}
+
+	return p.comparison()
+}
+
+func (p *parser) comparison() (node, error) {
+	t := p.next()
+	if t.kind != identToken {
+		return nil, fmt.Errorf("expected a field at %d", t.pos)
+	}
+
+	kind, ok := fieldKinds[t.text]
+	if isLabel(t.text) {
+		kind, ok = numberField, true
+		p.labels = true
+	}
+	if !ok {
+		return nil, fmt.Errorf("unknown field %q", t.text)
+	}
+
+	op := p.peek()
+	if op.kind != opToken || !isComparison(op.text) {
+		if kind != boolField {
+			return nil, fmt.Errorf(
+				"field %q must be compared to a value", t.text,
+			)
+		}
+		return boolNode{t.text}, nil
+	}
+	p.next()
+
+	if l := p.peek(); l.kind == listToken {
+		p.next()
+		if op.text != "~=" || kind != stringField {
+			return nil, fmt.Errorf(
+				"lists can only be matched with ~= against string fields",
+			)
+		}
+		p.addList(l.text)
+		return listNode{field: t.text, list: l.text}, nil
+	}
+
+	value, err := p.literal()
+	if err != nil {
+		return nil, err
+	}
+
+	n := compareNode{field: t.text, op: op.text, value: value}
+	switch {
+	case op.text == "~=":
+		pattern, ok := value.(string)
+		if !ok || kind != stringField {
+			return nil, fmt.Errorf(
+				"~= matches string fields against string patterns",
+			)
+		}
+		if n.re, err = regexp.Compile(pattern); err != nil {
+			return nil, err
+		}
+	case kind != kindOf(value):
+		return nil, fmt.Errorf(
+			"field %q cannot be compared to %v", t.text, value,
+		)
+	case kind == boolField && op.text != "==" && op.text != "!=":
+		return nil, fmt.Errorf(
+			"field %q can only be compared with == or !=", t.text,
+		)
+	}
+	return n, nil
+}
+
+// addList records that the expression matches against the list.
+func (p *parser) addList(name string) {
+	for _, list := range p.lists {
+		if list == name {
+			return
+		}
+	}
+	p.lists = append(p.lists, name)
+}
+
+func (p *parser) literal() (interface{}, error) {
+	t := p.next()
+	switch {
+	case t.kind == stringToken:
+		return t.text, nil
+	case t.kind == numberToken:
+		return strconv.ParseFloat(t.text, 64)
+	case t.kind == identToken && t.text == "true":
+		return true, nil
+	case t.kind == identToken && t.text == "false":
+		return false, nil
+	}
+	return nil, fmt.Errorf("expected a value at %d", t.pos)
+}
+
+func isComparison(op string) bool {
+	switch op {
+	case "==", "!=", "<", "<=", ">", ">=", "~=":
+		return true
+	}
+	return false
+}
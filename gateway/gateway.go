@@ -0,0 +1,213 @@
+// Package gateway serves graw's event streams over gRPC, so other processes,
+// in any language, can share a single graw "Reddit gateway" and its rate limit
+// budget instead of each polling Reddit themselves.
+//
+// A gateway is a bot. Run it with graw like any other, subscribed to every
+// event the gateway's clients may want, and serve it:
+//
+//	gw := gateway.New()
+//	stop, wait, err := graw.Run(gw, bot, cfg)
+//	...
+//	lis, err := net.Listen("tcp", ":7777")
+//	go gw.Serve(lis)
+//
+// The service is defined in gateway.proto in this package. Clients which
+// cannot keep up with the event stream have events dropped rather than slowing
+// down the gateway or other clients.
+package gateway
+
+import (
+	"net"
+	"sync"
+
+	"google.golang.org/grpc"
+
+	"github.com/turnage/graw/link"
+	"github.com/turnage/graw/sink"
+)
+
+// subscriberBuffer is the number of events buffered for each client before
+// events are dropped for it.
+const subscriberBuffer = 256
+
+// stream identifies one of the gateway's event streams.
+type stream int
+
+const (
+	postStream stream = iota
+	commentStream
+	inboxStream
+)
+
+// streamOf maps event kinds to the stream which carries them.
+var streamOf = map[string]stream{
+	sink.PostKind:            postStream,
+	sink.UserPostKind:        postStream,
+	sink.PostAgeKind:         postStream,
+	sink.RankKind:            postStream,
+	sink.PostGildedKind:      postStream,
+	sink.FlairChangedKind:    postStream,
+	sink.PostPageKind:        postStream,
+	sink.PostFilteredKind:    postStream,
+	sink.PostDeletedKind:     postStream,
+	sink.CommentDeletedKind:  commentStream,
+	sink.CommentFilteredKind: commentStream,
+	sink.OPReplyKind:         commentStream,
+	sink.CommentGildedKind:   commentStream,
+	sink.CommentKind:         commentStream,
+	sink.UserCommentKind:     commentStream,
+	sink.ThreadKind:          commentStream,
+	sink.CommentContextKind:  commentStream,
+	sink.PostReplyKind:       inboxStream,
+	sink.CommentReplyKind:    inboxStream,
+	sink.MentionKind:         inboxStream,
+	sink.MessageKind:         inboxStream,
+}
+
+// Server is a bot which serves the events it receives to gRPC clients.
+type Server struct {
+	*sink.Handler
+
+	grpc *grpc.Server
+	subs map[*subscriber]bool
+	mu   *sync.Mutex
+	// subscribed, if set, is called with each subscriber once it is
+	// subscribed.
+	subscribed func(*subscriber)
+}
+
+type subscriber struct {
+	stream     stream
+	subreddits map[string]bool
+	events     chan []byte
+}
+
+// New returns a gateway server.
+func New() *Server {
+	s := &Server{
+		grpc: grpc.NewServer(grpc.ForceServerCodec(codec{})),
+		subs: make(map[*subscriber]bool),
+		mu:   &sync.Mutex{},
+	}
+	s.Handler = sink.NewHandler(s.publish)
+	s.grpc.RegisterService(&serviceDesc, s)
+	return s
+}
+
+// Serve serves gRPC clients on the listener until Stop is called.
+func (s *Server) Serve(lis net.Listener) error {
+	return s.grpc.Serve(lis)
+}
+
+// Stop disconnects all clients and stops serving.
+func (s *Server) Stop() {
+	s.grpc.Stop()
+}
+
+// publish sends the event to every subscriber interested in it.
+func (s *Server) publish(ev sink.Event) error {
+	// Batches are served one post or comment at a time, as they would be
+	// without batching.
+	switch ev.Kind {
+	case sink.PostBatchKind:
+		for _, p := range ev.Posts {
+			err := s.publish(sink.Event{Kind: sink.PostKind, Post: p})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	case sink.CommentBatchKind:
+		for _, c := range ev.Comments {
+			err := s.publish(sink.Event{Kind: sink.CommentKind, Comment: c})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	// Alerts about the gateway's own handlers are not served.
+	st, ok := streamOf[ev.Kind]
+	if !ok {
+		return nil
+	}
+
+	payload, err := sink.Proto.Encode(ev)
+	if err != nil || payload == nil {
+		return err
+	}
+	subreddit := ev.Subreddit()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for sub := range s.subs {
+		if sub.stream != st || !sub.wants(subreddit) {
+			continue
+		}
+
+		select {
+		case sub.events <- payload:
+		default:
+		}
+	}
+	return nil
+}
+
+func (s *Server) subscribe(st stream, req *subscribeRequest) *subscriber {
+	sub := &subscriber{
+		stream: st,
+		events: make(chan []byte, subscriberBuffer),
+	}
+	if len(req.Subreddits) > 0 {
+		sub.subreddits = make(map[string]bool)
+		for _, sr := range req.Subreddits {
+			sub.subreddits[link.SubredditName(sr)] = true
+		}
+	}
+
+	s.mu.Lock()
+	s.subs[sub] = true
+	s.mu.Unlock()
+
+	if s.subscribed != nil {
+		s.subscribed(sub)
+	}
+	return sub
+}
+
+func (s *Server) unsubscribe(sub *subscriber) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.subs, sub)
+}
+
+// serve streams events to a client until it disconnects.
+func (s *Server) serve(st stream, ss grpc.ServerStream) error {
+	req := &subscribeRequest{}
+	if err := ss.RecvMsg(req); err != nil {
+		return err
+	}
+
+	sub := s.subscribe(st, req)
+	defer s.unsubscribe(sub)
+
+	for {
+		select {
+		case <-ss.Context().Done():
+			return nil
+		case payload := <-sub.events:
+			if err := ss.SendMsg(rawMessage(payload)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// wants returns true if the subscriber wants events from the subreddit.
+func (sub *subscriber) wants(subreddit string) bool {
+	return sub.subreddits == nil || subreddit == "" ||
+		sub.subreddits[link.SubredditName(subreddit)]
+}
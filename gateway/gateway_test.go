@@ -0,0 +1,90 @@
+package gateway
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/turnage/graw/reddit"
+	"github.com/turnage/redditproto"
+	"google.golang.org/grpc"
+)
+
+// fakeStream is a client's stream. The methods of grpc.ServerStream the
+// gateway does not use are left to the embedded nil interface.
+type fakeStream struct {
+	grpc.ServerStream
+	ctx  context.Context
+	req  []byte
+	sent chan []byte
+}
+
+func (f *fakeStream) Context() context.Context { return f.ctx }
+
+func (f *fakeStream) RecvMsg(m interface{}) error {
+	return codec{}.Unmarshal(f.req, m)
+}
+
+func (f *fakeStream) SendMsg(m interface{}) error {
+	payload, err := codec{}.Marshal(m)
+	f.sent <- payload
+	return err
+}
+
+func TestSubscribeFiltersStreamsAndSubreddits(t *testing.T) {
+	s := New()
+	subscribed := make(chan bool, 1)
+	s.subscribed = func(*subscriber) { subscribed <- true }
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	req, err := proto.Marshal(
+		&subscribeRequest{Subreddits: []string{"golang"}},
+	)
+	if err != nil {
+		t.Fatalf("error encoding request: %v", err)
+	}
+	ss := &fakeStream{ctx: ctx, req: req, sent: make(chan []byte)}
+
+	done := make(chan error)
+	go func() { done <- s.serve(postStream, ss) }()
+
+	// Wait for the subscription before publishing.
+	select {
+	case <-subscribed:
+	case <-time.After(time.Second):
+		t.Fatalf("client was not subscribed")
+	}
+
+	s.Comment(&reddit.Comment{Subreddit: "golang"})
+	s.Post(&reddit.Post{Subreddit: "rust", Title: "rust"})
+	s.Post(&reddit.Post{Subreddit: "GoLang", Title: "go"})
+
+	select {
+	case payload := <-ss.sent:
+		link := &redditproto.Link{}
+		if err := proto.Unmarshal(payload, link); err != nil {
+			t.Fatalf("error decoding post: %v", err)
+		}
+		if link.GetTitle() != "go" {
+			t.Errorf("streamed post %q; wanted go", link.GetTitle())
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("no event streamed")
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("stream did not end with client context")
+	}
+
+	s.mu.Lock()
+	subs := len(s.subs)
+	s.mu.Unlock()
+	if subs != 0 {
+		t.Errorf("subscriber not removed after disconnect")
+	}
+}
@@ -0,0 +1,76 @@
+package gateway
+
+import (
+	"fmt"
+
+	"github.com/golang/protobuf/proto"
+	"google.golang.org/grpc"
+)
+
+// gatewayServer is the interface registered gateway implementations satisfy.
+type gatewayServer interface {
+	serve(st stream, ss grpc.ServerStream) error
+}
+
+// serviceDesc describes the service in gateway.proto.
+var serviceDesc = grpc.ServiceDesc{
+	ServiceName: "graw.gateway.Gateway",
+	HandlerType: (*gatewayServer)(nil),
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "SubscribePosts",
+			Handler:       handler(postStream),
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "SubscribeComments",
+			Handler:       handler(commentStream),
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "SubscribeInbox",
+			Handler:       handler(inboxStream),
+			ServerStreams: true,
+		},
+	},
+	Metadata: "gateway/gateway.proto",
+}
+
+func handler(st stream) grpc.StreamHandler {
+	return func(srv interface{}, ss grpc.ServerStream) error {
+		return srv.(gatewayServer).serve(st, ss)
+	}
+}
+
+// subscribeRequest is the SubscribeRequest message in gateway.proto.
+type subscribeRequest struct {
+	Subreddits []string `protobuf:"bytes,1,rep,name=subreddits" json:"subreddits,omitempty"`
+}
+
+func (m *subscribeRequest) Reset()         { *m = subscribeRequest{} }
+func (m *subscribeRequest) String() string { return proto.MarshalTextString(m) }
+func (*subscribeRequest) ProtoMessage()    {}
+
+// rawMessage is an already encoded message.
+type rawMessage []byte
+
+// codec encodes the gateway's messages. Events are already encoded by the time
+// they are sent, so it passes them through as they are.
+type codec struct{}
+
+func (codec) Name() string { return "proto" }
+
+func (codec) Marshal(v interface{}) ([]byte, error) {
+	if raw, ok := v.(rawMessage); ok {
+		return raw, nil
+	}
+	return nil, fmt.Errorf("gateway cannot marshal %T", v)
+}
+
+func (codec) Unmarshal(data []byte, v interface{}) error {
+	req, ok := v.(*subscribeRequest)
+	if !ok {
+		return fmt.Errorf("gateway cannot unmarshal into %T", v)
+	}
+	return proto.Unmarshal(data, req)
+}
@@ -19,7 +19,7 @@
 //   // Get an api handle to reddit for a logged out (script) program,
 //   // which forwards this user agent on all requests and issues a request at
 //   // most every 5 seconds.
-//   apiHandle := reddit.NewScript("your user agent", 5 * time.Second)
+//   apiHandle := reddit.NewScript("linux:yourbot:1.0.0 (by /u/you)", 5 * time.Second)
 //
 //   // Create a configuration specifying what event sources on Reddit graw
 //   // should connect to the bot.
@@ -42,4 +42,8 @@
 // is for logged-out bots (what Reddit calls "scripts"). Run() handles logged in
 // bots, which can subscribe to logged-in event sources in the bot's account
 // inbox like mentions and private messages.
+//
+// Scan() also accepts the handles reddit.NewFeedScript returns, which read
+// public subreddits' .json or .rss feeds at a gentler pace, for tools which
+// run before they have credentials.
 package graw
@@ -0,0 +1,156 @@
+package graw
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/turnage/graw/reddit"
+)
+
+// defaultStale is how long after its last successful request a bot is not
+// ready if Health does not specify.
+const defaultStale = 10 * time.Minute
+
+// Health configures an HTTP server for container liveness and readiness
+// probes. It serves:
+//
+//	/healthz, which succeeds as long as the run is up.
+//	/readyz, which succeeds if a request to Reddit succeeded recently.
+//
+// Both respond with a JSON report of the last successful and failed requests
+// to Reddit, the rate limit remaining, the requests waiting to be sent and how
+// long they will take, and the number of events each of the bot's handlers is
+// working through.
+//
+// With Debug set, it also serves the runtime profiles of net/http/pprof under
+// /debug/pprof/, and /stats, a JSON report of goroutines, heap, handler
+// backlogs, and events handled, for debugging stuck monitors without a
+// restart. /stats?author=name also reports how long the author's Cooldowns
+// have left.
+type Health struct {
+	// Addr is the address the server listens on, e.g. ":8080". If empty,
+	// no server is started.
+	Addr string
+	// Stale is how long after its last successful request to Reddit the
+	// bot is reported not ready. If zero, it is ten minutes.
+	Stale time.Duration
+	// Debug adds the /stats and /debug/pprof/ endpoints. Profiles can
+	// reveal details of the bot's process, so only enable this on a
+	// private address.
+	Debug bool
+}
+
+// healthReport is the body of health responses.
+type healthReport struct {
+	Ready              bool           `json:"ready"`
+	LastSuccess        time.Time      `json:"last_success"`
+	LastFailure        time.Time      `json:"last_failure"`
+	LastError          string         `json:"last_error,omitempty"`
+	RateLimitRemaining float64        `json:"rate_limit_remaining"`
+	RateLimitReset     time.Time      `json:"rate_limit_reset"`
+	Queue              queueReport    `json:"queue"`
+	Backlog            map[string]int `json:"backlog"`
+}
+
+// queueReport is the requests waiting to be sent, in health responses.
+type queueReport struct {
+	Reads        int            `json:"reads"`
+	Writes       int            `json:"writes"`
+	Endpoints    map[string]int `json:"endpoints"`
+	DrainSeconds float64        `json:"drain_seconds"`
+}
+
+// healthServer serves health checks for a run.
+type healthServer struct {
+	monitor reddit.Monitor
+	d       *dispatcher
+	stale   time.Duration
+	debug   bool
+}
+
+// serveHealth starts the health server the config asks for, if any, until the
+// kill channel closes.
+func serveHealth(
+	c Health,
+	monitor reddit.Monitor,
+	d *dispatcher,
+	kill <-chan bool,
+) error {
+	if c.Addr == "" {
+		return nil
+	}
+
+	lis, err := net.Listen("tcp", c.Addr)
+	if err != nil {
+		return err
+	}
+
+	h := &healthServer{monitor: monitor, d: d, stale: c.Stale, debug: c.Debug}
+	if h.stale <= 0 {
+		h.stale = defaultStale
+	}
+
+	srv := &http.Server{Handler: h.mux()}
+	go srv.Serve(lis)
+	go func() {
+		<-kill
+		srv.Close()
+	}()
+	return nil
+}
+
+func (h *healthServer) mux() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc(
+		"/healthz",
+		func(w http.ResponseWriter, r *http.Request) {
+			h.respond(w, h.report(h.d.clock.Now()), true)
+		},
+	)
+	mux.HandleFunc(
+		"/readyz",
+		func(w http.ResponseWriter, r *http.Request) {
+			report := h.report(h.d.clock.Now())
+			h.respond(w, report, report.Ready)
+		},
+	)
+	if h.debug {
+		h.serveDebug(mux)
+	}
+	return mux
+}
+
+func (h *healthServer) report(now time.Time) healthReport {
+	status := h.monitor.Status()
+	return healthReport{
+		Ready: !status.LastSuccess.IsZero() &&
+			now.Sub(status.LastSuccess) < h.stale,
+		LastSuccess:        status.LastSuccess,
+		LastFailure:        status.LastFailure,
+		LastError:          status.LastError,
+		RateLimitRemaining: status.RateLimitRemaining,
+		RateLimitReset:     status.RateLimitReset,
+		Queue: queueReport{
+			Reads:        status.Queue.Reads,
+			Writes:       status.Queue.Writes,
+			Endpoints:    status.Queue.Endpoints,
+			DrainSeconds: status.Queue.Drain.Seconds(),
+		},
+		Backlog: h.d.backlog(),
+	}
+}
+
+// respond writes the body as JSON, with a failing status code if not ok.
+func (h *healthServer) respond(
+	w http.ResponseWriter,
+	body interface{},
+	ok bool,
+) {
+	w.Header().Set("Content-Type", "application/json")
+	if !ok {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(body)
+}
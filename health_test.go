@@ -0,0 +1,64 @@
+package graw
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/turnage/graw/reddit"
+)
+
+type fakeMonitor struct {
+	status reddit.Status
+}
+
+func (f *fakeMonitor) Status() reddit.Status { return f.status }
+
+func TestHealthServer(t *testing.T) {
+	monitor := &fakeMonitor{
+		status: reddit.Status{
+			Queue: reddit.Queue{Reads: 3, Drain: 3 * time.Second},
+		},
+	}
+	d := newDispatcher(Config{}, "", nil)
+	d.track(commentEvent, 2)
+	h := &healthServer{monitor: monitor, d: d, stale: time.Minute}
+	serv := httptest.NewServer(h.mux())
+	defer serv.Close()
+
+	for i, test := range []struct {
+		path        string
+		lastSuccess time.Time
+		code        int
+	}{
+		{"/healthz", time.Time{}, http.StatusOK},
+		{"/readyz", time.Time{}, http.StatusServiceUnavailable},
+		{"/readyz", time.Now().Add(-time.Hour), http.StatusServiceUnavailable},
+		{"/readyz", time.Now(), http.StatusOK},
+	} {
+		monitor.status.LastSuccess = test.lastSuccess
+		resp, err := http.Get(serv.URL + test.path)
+		if err != nil {
+			t.Fatalf("%d: request failed: %v", i, err)
+		}
+
+		var report healthReport
+		err = json.NewDecoder(resp.Body).Decode(&report)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatalf("%d: error decoding report: %v", i, err)
+		}
+
+		if resp.StatusCode != test.code {
+			t.Errorf("%d: got code %d; wanted %d", i, resp.StatusCode, test.code)
+		}
+		if report.Backlog["comment"] != 2 {
+			t.Errorf("%d: got backlog %v; wanted 2 comments", i, report.Backlog)
+		}
+		if report.Queue.Reads != 3 || report.Queue.DrainSeconds != 3 {
+			t.Errorf("%d: got queue %+v; wanted 3 reads", i, report.Queue)
+		}
+	}
+}
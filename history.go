@@ -0,0 +1,61 @@
+package graw
+
+import (
+	"time"
+
+	"github.com/turnage/graw/clock"
+	"github.com/turnage/graw/reddit"
+	"github.com/turnage/graw/store"
+)
+
+// defaultHistoryInterval is how often watched threads are sampled if the
+// config does not say.
+const defaultHistoryInterval = 5 * time.Minute
+
+// sampleOf returns a sample of the post's standing at a time.
+func sampleOf(p *reddit.Post, at time.Time) store.Sample {
+	return store.Sample{
+		Time:        at,
+		Score:       p.Score,
+		Comments:    p.NumComments,
+		UpvoteRatio: p.UpvoteRatio,
+	}
+}
+
+// sampleHistory records a sample of a thread's standing every interval until
+// killed.
+func sampleHistory(
+	lurker reddit.Lurker,
+	permalink string,
+	history store.History,
+	interval time.Duration,
+	clk clock.Clock,
+	kill <-chan bool,
+	errs chan<- error,
+) {
+	if interval <= 0 {
+		interval = defaultHistoryInterval
+	}
+
+	ticker := clk.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		post, err := lurker.ThreadWithOptions(
+			permalink,
+			reddit.ThreadOptions{Depth: 1, Limit: 1},
+		)
+		if err == nil {
+			err = history.AddSample(post.Name, sampleOf(post, clk.Now()))
+		}
+		if err != nil {
+			errs <- err
+		}
+
+		select {
+		case <-kill:
+			return
+		case <-ticker.C():
+		}
+	}
+}
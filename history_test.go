@@ -0,0 +1,98 @@
+package graw
+
+import (
+	"testing"
+	"time"
+
+	"github.com/turnage/graw/clock"
+	"github.com/turnage/graw/reddit"
+	"github.com/turnage/graw/store"
+)
+
+// threadLurker returns the same post for every thread. Its other methods are
+// unimplemented.
+type threadLurker struct {
+	reddit.Lurker
+	post *reddit.Post
+}
+
+func (t *threadLurker) Thread(permalink string) (*reddit.Post, error) {
+	return t.post, nil
+}
+
+func (t *threadLurker) ThreadWithOptions(
+	permalink string,
+	opts reddit.ThreadOptions,
+) (*reddit.Post, error) {
+	return t.post, nil
+}
+
+// polledLurker is a threadLurker which signals each poll.
+type polledLurker struct {
+	threadLurker
+	polls chan bool
+}
+
+func (p *polledLurker) ThreadWithOptions(
+	permalink string,
+	opts reddit.ThreadOptions,
+) (*reddit.Post, error) {
+	p.polls <- true
+	return p.post, nil
+}
+
+func TestSampleHistory(t *testing.T) {
+	history := store.NewMemory()
+	start := time.Unix(1500000000, 0)
+	v := clock.NewVirtual(start)
+	lurker := &polledLurker{
+		threadLurker: threadLurker{
+			post: &reddit.Post{
+				Name:        "t3_a",
+				Score:       10,
+				NumComments: 2,
+				UpvoteRatio: 0.9,
+			},
+		},
+		polls: make(chan bool),
+	}
+	kill := make(chan bool)
+	errs := make(chan error, 1)
+	done := make(chan bool)
+
+	go func() {
+		sampleHistory(
+			lurker,
+			"/r/golang/comments/a",
+			history,
+			time.Hour,
+			v,
+			kill,
+			errs,
+		)
+		close(done)
+	}()
+
+	// The thread is sampled at once, and again each time the interval
+	// passes on the clock.
+	<-lurker.polls
+	v.Advance(time.Hour)
+	<-lurker.polls
+	close(kill)
+	<-done
+
+	samples, err := history.Samples("t3_a")
+	if err != nil {
+		t.Fatalf("error reading samples: %v", err)
+	}
+	if len(samples) != 2 ||
+		!samples[0].Time.Equal(start) ||
+		!samples[1].Time.Equal(start.Add(time.Hour)) {
+		t.Fatalf("got samples %+v; wanted one at start and an hour on", samples)
+	}
+	if samples[0].Score != 10 ||
+		samples[0].Comments != 2 ||
+		samples[0].UpvoteRatio != 0.9 {
+		t.Errorf("sample incorrect: %+v", samples[0])
+	}
+}
@@ -0,0 +1,93 @@
+package graw
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/turnage/graw/link"
+	"github.com/turnage/graw/reddit"
+)
+
+// Ignore mutes sources of events. Events from them are dropped for every one of
+// the bot's handlers, so harassment or a noisy source can be dealt with in one
+// place. Names are not case sensitive.
+type Ignore struct {
+	// Users lists authors whose posts, comments, and messages are dropped.
+	Users []string
+	// Subreddits lists subreddits whose posts, comments, and messages are
+	// dropped.
+	Subreddits []string
+	// Domains lists domains whose link posts, and comments on them, are
+	// dropped. A domain covers its subdomains, so "youtube.com" covers
+	// "m.youtube.com".
+	Domains []string
+}
+
+// ignoreList enforces an Ignore.
+type ignoreList struct {
+	users      map[string]bool
+	subreddits map[string]bool
+	domains    []string
+}
+
+func newIgnoreList(c Ignore) *ignoreList {
+	l := &ignoreList{
+		users:      make(map[string]bool),
+		subreddits: make(map[string]bool),
+	}
+
+	for _, user := range c.Users {
+		l.users[strings.ToLower(user)] = true
+	}
+	for _, sub := range c.Subreddits {
+		l.subreddits[link.SubredditName(sub)] = true
+	}
+	for _, domain := range c.Domains {
+		l.domains = append(l.domains, strings.ToLower(domain))
+	}
+
+	return l
+}
+
+// admit returns true if the event is from no ignored source.
+func (l *ignoreList) admit(e event) bool {
+	if l.users[strings.ToLower(e.author)] {
+		return false
+	}
+
+	subreddit, domain := "", ""
+	switch thing := e.thing.(type) {
+	case *reddit.Post:
+		subreddit = thing.Subreddit
+		domain = thing.Domain
+		if domain == "" {
+			domain = hostOf(thing.URL)
+		}
+	case *reddit.Comment:
+		subreddit = thing.Subreddit
+		domain = hostOf(thing.LinkURL)
+	case *reddit.Message:
+		subreddit = thing.Subreddit
+	}
+
+	if l.subreddits[link.SubredditName(subreddit)] {
+		return false
+	}
+
+	domain = strings.ToLower(domain)
+	for _, ignored := range l.domains {
+		if domain == ignored || strings.HasSuffix(domain, "."+ignored) {
+			return false
+		}
+	}
+	return true
+}
+
+// hostOf returns the host of a url, or "" if it has none.
+func hostOf(link string) string {
+	u, err := url.Parse(link)
+	if err != nil {
+		return ""
+	}
+	return u.Hostname()
+}
@@ -0,0 +1,47 @@
+package graw
+
+import (
+	"testing"
+
+	"github.com/turnage/graw/reddit"
+)
+
+func TestIgnoreList(t *testing.T) {
+	l := newIgnoreList(Ignore{
+		Users:      []string{"Troll"},
+		Subreddits: []string{"Noisy"},
+		Domains:    []string{"spam.com"},
+	})
+
+	for i, test := range []struct {
+		e     event
+		admit bool
+	}{
+		{postEv(postEvent, &reddit.Post{Author: "troll"}), false},
+		{postEv(postEvent, &reddit.Post{Subreddit: "noisy"}), false},
+		{postEv(postEvent, &reddit.Post{Domain: "spam.com"}), false},
+		{postEv(postEvent, &reddit.Post{Domain: "www.spam.com"}), false},
+		{postEv(postEvent, &reddit.Post{Domain: "notspam.com"}), true},
+		{
+			commentEv(commentEvent, &reddit.Comment{
+				LinkURL: "https://m.spam.com/buy",
+			}),
+			false,
+		},
+		{
+			messageEv(messageEvent, &reddit.Message{Author: "TROLL"}),
+			false,
+		},
+		{
+			messageEv(messageEvent, &reddit.Message{
+				Author:    "roxven",
+				Subreddit: "golang",
+			}),
+			true,
+		},
+	} {
+		if admit := l.admit(test.e); admit != test.admit {
+			t.Errorf("%d: got %v; wanted %v", i, admit, test.admit)
+		}
+	}
+}
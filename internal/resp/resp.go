@@ -0,0 +1,260 @@
+// Package resp is a minimal client for the Redis serialization protocol,
+// covering what graw needs to share state through Redis.
+package resp
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Nil is returned by Do for Redis nil replies, such as GET of a missing key.
+var Nil = fmt.Errorf("redis: nil reply")
+
+// Error is an error reply from Redis.
+type Error string
+
+func (e Error) Error() string { return "redis: " + string(e) }
+
+// Config configures a connection to Redis.
+type Config struct {
+	// Addr is the host:port of the Redis server.
+	Addr string
+	// Password, if set, is sent with AUTH when connecting.
+	Password string
+	// DB, if set, is selected when connecting.
+	DB int
+	// Timeout bounds dialing and each command. The default is 5 seconds.
+	Timeout time.Duration
+}
+
+// Client executes commands over a single connection, reconnecting after
+// network errors. Its methods are goroutine safe; commands are serialized.
+type Client struct {
+	cfg  Config
+	conn net.Conn
+	rd   *bufio.Reader
+	mu   *sync.Mutex
+}
+
+// New returns a client for the configured server. It does not connect until
+// the first command.
+func New(c Config) *Client {
+	if c.Timeout <= 0 {
+		c.Timeout = 5 * time.Second
+	}
+	return &Client{cfg: c, mu: &sync.Mutex{}}
+}
+
+// Do executes a command and returns its reply, which is one of string (simple
+// and bulk strings), int64, or []interface{} of those. Nil replies are returned
+// as the Nil error, and error replies as Error.
+func (c *Client) Do(args ...string) (interface{}, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.conn == nil {
+		if err := c.connect(); err != nil {
+			return nil, err
+		}
+	}
+
+	reply, err := c.roundTrip(args)
+	if _, ok := err.(Error); err != nil && !ok && err != Nil {
+		c.conn.Close()
+		c.conn = nil
+	}
+	return reply, err
+}
+
+// Close closes the connection to Redis.
+func (c *Client) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.conn == nil {
+		return nil
+	}
+
+	err := c.conn.Close()
+	c.conn = nil
+	return err
+}
+
+func (c *Client) connect() error {
+	conn, err := net.DialTimeout("tcp", c.cfg.Addr, c.cfg.Timeout)
+	if err != nil {
+		return err
+	}
+
+	c.conn = conn
+	c.rd = bufio.NewReader(conn)
+
+	if c.cfg.Password != "" {
+		if _, err := c.roundTrip([]string{"AUTH", c.cfg.Password}); err != nil {
+			c.conn.Close()
+			c.conn = nil
+			return err
+		}
+	}
+
+	if c.cfg.DB != 0 {
+		if _, err := c.roundTrip(
+			[]string{"SELECT", strconv.Itoa(c.cfg.DB)},
+		); err != nil {
+			c.conn.Close()
+			c.conn = nil
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *Client) roundTrip(args []string) (interface{}, error) {
+	c.conn.SetDeadline(time.Now().Add(c.cfg.Timeout))
+	if _, err := c.conn.Write(Encode(args)); err != nil {
+		return nil, err
+	}
+	return Read(c.rd)
+}
+
+// Encode encodes a command as an array of bulk strings.
+func Encode(args []string) []byte {
+	buf := []byte("*" + strconv.Itoa(len(args)) + "\r\n")
+	for _, arg := range args {
+		buf = append(buf, "$"+strconv.Itoa(len(arg))+"\r\n"...)
+		buf = append(buf, arg...)
+		buf = append(buf, "\r\n"...)
+	}
+	return buf
+}
+
+// Read reads one reply.
+func Read(rd *bufio.Reader) (interface{}, error) {
+	line, err := readLine(rd)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(line) == 0 {
+		return nil, fmt.Errorf("redis: empty reply")
+	}
+
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, Error(line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, err
+		} else if n < 0 {
+			return nil, Nil
+		}
+
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(rd, buf); err != nil {
+			return nil, err
+		}
+		return string(buf[:n]), nil
+	case '*':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, err
+		} else if n < 0 {
+			return nil, Nil
+		}
+
+		// An error reply inside the array (e.g. from a transaction) is
+		// returned once the rest of the array is read, so the reply
+		// after it is not read from the middle of this one.
+		items := make([]interface{}, n)
+		var first error
+		for i := range items {
+			item, err := Read(rd)
+			if _, ok := err.(Error); ok {
+				if first == nil {
+					first = err
+				}
+				continue
+			} else if err != nil && err != Nil {
+				return nil, err
+			}
+			items[i] = item
+		}
+		if first != nil {
+			return nil, first
+		}
+		return items, nil
+	}
+
+	return nil, fmt.Errorf("redis: unknown reply type %q", line[0])
+}
+
+func readLine(rd *bufio.Reader) (string, error) {
+	line, err := rd.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+
+	if len(line) < 2 || line[len(line)-2] != '\r' {
+		return "", fmt.Errorf("redis: malformed line %q", line)
+	}
+	return line[:len(line)-2], nil
+}
+
+// String converts a reply to a string.
+func String(reply interface{}, err error) (string, error) {
+	if err != nil {
+		return "", err
+	}
+
+	s, ok := reply.(string)
+	if !ok {
+		return "", fmt.Errorf("redis: unexpected reply %v", reply)
+	}
+	return s, nil
+}
+
+// Int converts a reply to an integer.
+func Int(reply interface{}, err error) (int64, error) {
+	if err != nil {
+		return 0, err
+	}
+
+	switch r := reply.(type) {
+	case int64:
+		return r, nil
+	case string:
+		return strconv.ParseInt(r, 10, 64)
+	}
+	return 0, fmt.Errorf("redis: unexpected reply %v", reply)
+}
+
+// Strings converts an array reply to strings. Nil items are empty strings.
+func Strings(reply interface{}, err error) ([]string, error) {
+	if err != nil {
+		return nil, err
+	}
+
+	items, ok := reply.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("redis: unexpected reply %v", reply)
+	}
+
+	strs := make([]string, len(items))
+	for i, item := range items {
+		if item != nil {
+			strs[i] = fmt.Sprint(item)
+		}
+	}
+	return strs, nil
+}
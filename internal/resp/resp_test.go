@@ -0,0 +1,79 @@
+package resp
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+
+	"github.com/turnage/graw/internal/resp/resptest"
+)
+
+func TestEncode(t *testing.T) {
+	expected := "*2\r\n$3\r\nGET\r\n$3\r\nkey\r\n"
+	if actual := string(Encode([]string{"GET", "key"})); actual != expected {
+		t.Errorf("got %q; wanted %q", actual, expected)
+	}
+}
+
+func TestRead(t *testing.T) {
+	for i, test := range []struct {
+		input string
+		reply interface{}
+		err   error
+	}{
+		{"+OK\r\n", "OK", nil},
+		{":42\r\n", int64(42), nil},
+		{"$5\r\nhello\r\n", "hello", nil},
+		{"$-1\r\n", nil, Nil},
+		{"-ERR bad\r\n", nil, Error("ERR bad")},
+	} {
+		reply, err := Read(bufio.NewReader(strings.NewReader(test.input)))
+		if err != test.err || reply != test.reply {
+			t.Errorf(
+				"%d: got %v, %v; wanted %v, %v",
+				i, reply, err, test.reply, test.err,
+			)
+		}
+	}
+
+	reply, err := Strings(Read(bufio.NewReader(
+		strings.NewReader("*3\r\n$1\r\na\r\n:2\r\n$-1\r\n"),
+	)))
+	if err != nil || strings.Join(reply, ",") != "a,2," {
+		t.Errorf("array read incorrectly: %v, %v", reply, err)
+	}
+
+	// An error inside an array is returned after the whole array is read.
+	rd := bufio.NewReader(
+		strings.NewReader("*3\r\n:1\r\n-ERR bad\r\n:3\r\n+OK\r\n"),
+	)
+	if _, err := Read(rd); err != Error("ERR bad") {
+		t.Errorf("got %v reading an array with an error; wanted it", err)
+	}
+	if reply, err := Read(rd); err != nil || reply != "OK" {
+		t.Errorf("got %v, %v after the array; wanted OK", reply, err)
+	}
+}
+
+func TestClient(t *testing.T) {
+	s, err := resptest.NewServer()
+	if err != nil {
+		t.Fatalf("failed to start server: %v", err)
+	}
+	defer s.Close()
+
+	c := New(Config{Addr: s.Addr, Password: "secret", DB: 2})
+	defer c.Close()
+
+	if _, err := c.Do("SET", "key", "value"); err != nil {
+		t.Fatalf("error setting key: %v", err)
+	}
+
+	if v, err := String(c.Do("GET", "key")); err != nil || v != "value" {
+		t.Errorf("got %s, %v; wanted value", v, err)
+	}
+
+	if _, err := c.Do("GET", "missing"); err != Nil {
+		t.Errorf("got %v for missing key; wanted Nil", err)
+	}
+}
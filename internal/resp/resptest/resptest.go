@@ -0,0 +1,362 @@
+// Package resptest provides an in memory Redis server speaking the subset of
+// commands graw uses, for tests.
+package resptest
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Server is an in memory Redis server.
+type Server struct {
+	// Addr is the address the server listens on.
+	Addr string
+
+	ln      net.Listener
+	strings map[string]string
+	sets    map[string]map[string]bool
+	hashes  map[string]map[string]string
+	lists   map[string][]string
+	zsets   map[string]map[string]float64
+	mu      *sync.Mutex
+}
+
+// NewServer starts a server on a local port.
+func NewServer() (*Server, error) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Server{
+		Addr:    ln.Addr().String(),
+		ln:      ln,
+		strings: make(map[string]string),
+		sets:    make(map[string]map[string]bool),
+		hashes:  make(map[string]map[string]string),
+		lists:   make(map[string][]string),
+		zsets:   make(map[string]map[string]float64),
+		mu:      &sync.Mutex{},
+	}
+	go s.serve()
+	return s, nil
+}
+
+// Close stops the server.
+func (s *Server) Close() error {
+	return s.ln.Close()
+}
+
+func (s *Server) serve() {
+	for {
+		conn, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *Server) handle(conn net.Conn) {
+	defer conn.Close()
+	rd := bufio.NewReader(conn)
+	for {
+		args, err := readCommand(rd)
+		if err != nil {
+			return
+		}
+
+		s.mu.Lock()
+		reply := s.exec(args)
+		s.mu.Unlock()
+
+		if _, err := conn.Write([]byte(reply)); err != nil {
+			return
+		}
+	}
+}
+
+func readCommand(rd *bufio.Reader) ([]string, error) {
+	line, err := rd.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+
+	n, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+	if err != nil {
+		return nil, err
+	}
+
+	args := make([]string, n)
+	for i := range args {
+		if _, err := rd.ReadString('\n'); err != nil {
+			return nil, err
+		}
+		arg, err := rd.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		args[i] = strings.TrimSuffix(arg, "\r\n")
+	}
+	return args, nil
+}
+
+func (s *Server) exec(args []string) string {
+	if len(args) == 0 {
+		return "-ERR empty command\r\n"
+	}
+
+	switch cmd := strings.ToUpper(args[0]); {
+	case cmd == "PING":
+		return "+PONG\r\n"
+	case cmd == "AUTH" || cmd == "SELECT":
+		return "+OK\r\n"
+	case cmd == "GET" && len(args) == 2:
+		v, ok := s.strings[args[1]]
+		if !ok {
+			return "$-1\r\n"
+		}
+		return bulk(v)
+	case cmd == "SET" && len(args) >= 3:
+		nx := len(args) > 3 && strings.ToUpper(args[3]) == "NX"
+		if _, ok := s.strings[args[1]]; ok && nx {
+			return "$-1\r\n"
+		}
+		s.strings[args[1]] = args[2]
+		return "+OK\r\n"
+	case cmd == "DEL" && len(args) >= 2:
+		n := 0
+		for _, key := range args[1:] {
+			if _, ok := s.strings[key]; ok {
+				n++
+			}
+			delete(s.strings, key)
+			delete(s.sets, key)
+			delete(s.hashes, key)
+			delete(s.lists, key)
+			delete(s.zsets, key)
+		}
+		return integer(n)
+	case cmd == "INCR" && len(args) == 2:
+		n, _ := strconv.Atoi(s.strings[args[1]])
+		n++
+		s.strings[args[1]] = strconv.Itoa(n)
+		return integer(n)
+	case cmd == "SADD" && len(args) >= 3:
+		if s.sets[args[1]] == nil {
+			s.sets[args[1]] = make(map[string]bool)
+		}
+		n := 0
+		for _, member := range args[2:] {
+			if !s.sets[args[1]][member] {
+				n++
+			}
+			s.sets[args[1]][member] = true
+		}
+		return integer(n)
+	case cmd == "SMEMBERS" && len(args) == 2:
+		var members []string
+		for member := range s.sets[args[1]] {
+			members = append(members, member)
+		}
+		sort.Strings(members)
+		return array(members)
+	case cmd == "SISMEMBER" && len(args) == 3:
+		if s.sets[args[1]][args[2]] {
+			return integer(1)
+		}
+		return integer(0)
+	case cmd == "HSET" && len(args) == 4:
+		if s.hashes[args[1]] == nil {
+			s.hashes[args[1]] = make(map[string]string)
+		}
+		_, existed := s.hashes[args[1]][args[2]]
+		s.hashes[args[1]][args[2]] = args[3]
+		if existed {
+			return integer(0)
+		}
+		return integer(1)
+	case cmd == "HGET" && len(args) == 3:
+		v, ok := s.hashes[args[1]][args[2]]
+		if !ok {
+			return "$-1\r\n"
+		}
+		return bulk(v)
+	case cmd == "HGETALL" && len(args) == 2:
+		var fields []string
+		for field := range s.hashes[args[1]] {
+			fields = append(fields, field)
+		}
+		sort.Strings(fields)
+		reply := fmt.Sprintf("*%d\r\n", 2*len(fields))
+		for _, field := range fields {
+			reply += bulk(field) + bulk(s.hashes[args[1]][field])
+		}
+		return reply
+	case cmd == "HMGET" && len(args) >= 3:
+		reply := fmt.Sprintf("*%d\r\n", len(args)-2)
+		for _, field := range args[2:] {
+			v, ok := s.hashes[args[1]][field]
+			if !ok {
+				reply += "$-1\r\n"
+				continue
+			}
+			reply += bulk(v)
+		}
+		return reply
+	case cmd == "HKEYS" && len(args) == 2:
+		var fields []string
+		for field := range s.hashes[args[1]] {
+			fields = append(fields, field)
+		}
+		sort.Strings(fields)
+		return array(fields)
+	case cmd == "HDEL" && len(args) >= 3:
+		n := 0
+		for _, field := range args[2:] {
+			if _, ok := s.hashes[args[1]][field]; ok {
+				n++
+			}
+			delete(s.hashes[args[1]], field)
+		}
+		return integer(n)
+	case cmd == "RPUSH" && len(args) >= 3:
+		s.lists[args[1]] = append(s.lists[args[1]], args[2:]...)
+		return integer(len(s.lists[args[1]]))
+	case cmd == "LRANGE" && len(args) == 4:
+		list := s.lists[args[1]]
+		start, _ := strconv.Atoi(args[2])
+		stop, _ := strconv.Atoi(args[3])
+		start, stop = index(start, len(list)), index(stop, len(list))+1
+		if stop > len(list) {
+			stop = len(list)
+		}
+		if start >= stop {
+			return "*0\r\n"
+		}
+		reply := fmt.Sprintf("*%d\r\n", stop-start)
+		for _, v := range list[start:stop] {
+			reply += bulk(v)
+		}
+		return reply
+	case cmd == "ZADD" && (len(args) == 4 ||
+		len(args) == 5 && strings.ToUpper(args[2]) == "NX"):
+		nx := len(args) == 5
+		if nx {
+			args = append(args[:2], args[3:]...)
+		}
+		score, err := strconv.ParseFloat(args[2], 64)
+		if err != nil {
+			return "-ERR value is not a valid float\r\n"
+		}
+		if s.zsets[args[1]] == nil {
+			s.zsets[args[1]] = make(map[string]float64)
+		}
+		_, existed := s.zsets[args[1]][args[3]]
+		if existed && nx {
+			return integer(0)
+		}
+		s.zsets[args[1]][args[3]] = score
+		if existed {
+			return integer(0)
+		}
+		return integer(1)
+	case cmd == "ZSCORE" && len(args) == 3:
+		score, ok := s.zsets[args[1]][args[2]]
+		if !ok {
+			return "$-1\r\n"
+		}
+		return bulk(strconv.FormatFloat(score, 'f', -1, 64))
+	case cmd == "ZRANGEBYSCORE" && len(args) == 4:
+		members, err := s.rangeByScore(args[1], args[2], args[3])
+		if err != nil {
+			return fmt.Sprintf("-ERR %v\r\n", err)
+		}
+		return array(members)
+	case cmd == "ZREMRANGEBYSCORE" && len(args) == 4:
+		members, err := s.rangeByScore(args[1], args[2], args[3])
+		if err != nil {
+			return fmt.Sprintf("-ERR %v\r\n", err)
+		}
+		for _, member := range members {
+			delete(s.zsets[args[1]], member)
+		}
+		return integer(len(members))
+	}
+
+	return fmt.Sprintf("-ERR unsupported command %q\r\n", args)
+}
+
+// rangeByScore returns the members of the sorted set whose scores are within
+// the bounds, by score and then by member.
+func (s *Server) rangeByScore(key, min, max string) ([]string, error) {
+	lo, loOpen, err := bound(min)
+	if err != nil {
+		return nil, err
+	}
+	hi, hiOpen, err := bound(max)
+	if err != nil {
+		return nil, err
+	}
+
+	zset := s.zsets[key]
+	var members []string
+	for member, score := range zset {
+		if score < lo || loOpen && score == lo ||
+			score > hi || hiOpen && score == hi {
+			continue
+		}
+		members = append(members, member)
+	}
+	sort.Slice(members, func(i, j int) bool {
+		if zset[members[i]] != zset[members[j]] {
+			return zset[members[i]] < zset[members[j]]
+		}
+		return members[i] < members[j]
+	})
+	return members, nil
+}
+
+// bound parses a sorted set score bound, which is exclusive if it starts
+// with "(".
+func bound(arg string) (float64, bool, error) {
+	open := strings.HasPrefix(arg, "(")
+	score, err := strconv.ParseFloat(strings.TrimPrefix(arg, "("), 64)
+	if err != nil {
+		return 0, false, fmt.Errorf("min or max is not a float")
+	}
+	return score, open, nil
+}
+
+func bulk(s string) string {
+	return fmt.Sprintf("$%d\r\n%s\r\n", len(s), s)
+}
+
+func integer(n int) string {
+	return fmt.Sprintf(":%d\r\n", n)
+}
+
+func array(values []string) string {
+	reply := fmt.Sprintf("*%d\r\n", len(values))
+	for _, v := range values {
+		reply += bulk(v)
+	}
+	return reply
+}
+
+// index resolves a Redis list index, which may count from the end, into the
+// list's bounds.
+func index(i, length int) int {
+	if i < 0 {
+		i += length
+	}
+	if i < 0 {
+		return 0
+	}
+	return i
+}
@@ -0,0 +1,162 @@
+package graw
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/turnage/graw/clock"
+	"github.com/turnage/graw/filter"
+	"github.com/turnage/graw/link"
+	"github.com/turnage/graw/reddit"
+)
+
+// defaultKeywordInterval is how often keyword list pages are checked for edits
+// if KeywordLists does not say.
+const defaultKeywordInterval = 5 * time.Minute
+
+var keywordSubredditErr = fmt.Errorf(
+	"KeywordLists.Subreddit must name the subreddit whose wiki holds the lists",
+)
+
+// KeywordLists loads the keyword lists filters match against from pages of a
+// subreddit's wiki, so its moderators can tune what the bot reacts to without
+// redeploying it. For example, with
+//
+//	KeywordLists{
+//		Subreddit: "golang",
+//		Pages:     map[string]string{"spam": "graw/spam"},
+//	}
+//
+// a filter of body ~= @spam matches bodies containing any keyword listed on
+// r/golang/wiki/graw/spam. Each line of a page is a keyword or phrase; list
+// markers (* or -) before them are ignored, as are blank lines and headings
+// (lines beginning with #), so pages can be organized for their readers.
+//
+// The pages are loaded before the run starts, which fails if any cannot be.
+// They are checked for edits every Interval afterward, and lists are reloaded
+// when their pages change; if a page cannot be checked, its list is kept as it
+// was and the failure logged. Logged in bots need the wikiread scope.
+type KeywordLists struct {
+	// Subreddit is the subreddit whose wiki holds the lists.
+	Subreddit string
+	// Pages maps the names of lists, as filters name them (spam for
+	// @spam), to the wiki pages holding them.
+	Pages map[string]string
+	// Interval is how often pages are checked for edits. If zero, it is
+	// five minutes.
+	Interval time.Duration
+}
+
+// keywordLoader loads keyword lists from a wiki and keeps them current.
+type keywordLoader struct {
+	c      KeywordLists
+	lurker reddit.Lurker
+	lists  *filter.Lists
+	clock  clock.Clock
+	logger *log.Logger
+	// revisions are the revisions of each list's page last loaded.
+	revisions map[string]string
+}
+
+func newKeywordLoader(
+	c KeywordLists,
+	lurker reddit.Lurker,
+	lists *filter.Lists,
+	clk clock.Clock,
+	logger *log.Logger,
+) (*keywordLoader, error) {
+	c.Subreddit = link.SubredditName(c.Subreddit)
+	if c.Subreddit == "" {
+		return nil, keywordSubredditErr
+	}
+	if c.Interval <= 0 {
+		c.Interval = defaultKeywordInterval
+	}
+
+	return &keywordLoader{
+		c:         c,
+		lurker:    lurker,
+		lists:     lists,
+		clock:     clk,
+		logger:    logger,
+		revisions: make(map[string]string),
+	}, nil
+}
+
+// load loads every list, failing if any cannot be loaded.
+func (k *keywordLoader) load() error {
+	for name, page := range k.c.Pages {
+		if err := k.loadList(name, page); err != nil {
+			return fmt.Errorf(
+				"keyword list %s (r/%s/wiki/%s): %v",
+				name, k.c.Subreddit, page, err,
+			)
+		}
+	}
+	return nil
+}
+
+// loadList loads the list from its page if the page changed since the list
+// was last loaded.
+func (k *keywordLoader) loadList(name, page string) error {
+	p, err := k.lurker.WikiPage(k.c.Subreddit, page)
+	if err != nil {
+		return err
+	}
+
+	last, loaded := k.revisions[name]
+	if loaded && p.RevisionID == last {
+		return nil
+	}
+
+	keywords := parseKeywords(p.Content)
+	k.lists.Set(name, keywords)
+	k.revisions[name] = p.RevisionID
+	if loaded {
+		k.logger.Printf(
+			"Reloaded keyword list %s (%d keywords) edited by %s",
+			name, len(keywords), p.RevisionBy,
+		)
+	}
+	return nil
+}
+
+// run checks the lists' pages for edits until the kill channel closes.
+func (k *keywordLoader) run(kill <-chan bool) {
+	ticker := k.clock.NewTicker(k.c.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-kill:
+			return
+		case <-ticker.C():
+			for name, page := range k.c.Pages {
+				if err := k.loadList(name, page); err != nil {
+					k.logger.Printf(
+						"Could not reload keyword list %s: %v",
+						name, err,
+					)
+				}
+			}
+		}
+	}
+}
+
+// parseKeywords returns the keywords listed in a wiki page's markdown.
+func parseKeywords(content string) []string {
+	var keywords []string
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimLeft(line, "*- \t")
+		if line != "" {
+			keywords = append(keywords, line)
+		}
+	}
+	return keywords
+}
@@ -0,0 +1,119 @@
+package graw
+
+import (
+	"io/ioutil"
+	"log"
+	"testing"
+
+	"github.com/kylelemons/godebug/pretty"
+	"github.com/turnage/graw/filter"
+	"github.com/turnage/graw/reddit"
+)
+
+// wikiLurker serves wiki pages from a map of page names to pages.
+type wikiLurker struct {
+	reddit.Lurker
+	pages map[string]*reddit.WikiPage
+}
+
+func (w *wikiLurker) WikiPage(
+	subreddit, page string,
+) (*reddit.WikiPage, error) {
+	p, ok := w.pages[subreddit+"/"+page]
+	if !ok {
+		return nil, reddit.PermissionDeniedErr
+	}
+	return p, nil
+}
+
+func TestKeywordLoader(t *testing.T) {
+	l := &wikiLurker{pages: map[string]*reddit.WikiPage{
+		"golang/graw/spam": {Content: "free money", RevisionID: "1"},
+	}}
+	c := Config{
+		Filters: Filters{
+			Comment:    filter.MustCompile("body ~= @spam"),
+			Normalizer: filter.DefaultNormalizer,
+		},
+		KeywordLists: KeywordLists{
+			Subreddit: "/r/GoLang",
+			Pages:     map[string]string{"spam": "graw/spam"},
+		},
+		Logger: log.New(ioutil.Discard, "", 0),
+	}
+	d := newDispatcher(c, "", nil)
+
+	k, err := newKeywordLoader(
+		c.KeywordLists,
+		l,
+		d.lists,
+		d.clock,
+		d.logger,
+	)
+	if err != nil {
+		t.Fatalf("error making loader: %v", err)
+	}
+	if err := k.load(); err != nil {
+		t.Fatalf("error loading lists: %v", err)
+	}
+
+	admits := func(body string) bool {
+		admit, _ := d.admit(commentEv(
+			commentEvent,
+			&reddit.Comment{Body: body},
+		))
+		return admit
+	}
+	if !admits("FREE **money**") || admits("crypto") {
+		t.Errorf("filter did not match against the loaded list")
+	}
+
+	// Lists are only reloaded when their pages are edited.
+	l.pages["golang/graw/spam"] = &reddit.WikiPage{
+		Content:    "crypto",
+		RevisionID: "1",
+	}
+	if err := k.load(); err != nil {
+		t.Fatalf("error reloading lists: %v", err)
+	}
+	if admits("crypto") {
+		t.Errorf("list was reloaded though its page was not edited")
+	}
+
+	l.pages["golang/graw/spam"].RevisionID = "2"
+	if err := k.load(); err != nil {
+		t.Fatalf("error reloading lists: %v", err)
+	}
+	if !admits("crypto") || admits("free money") {
+		t.Errorf("list was not reloaded after its page was edited")
+	}
+
+	delete(l.pages, "golang/graw/spam")
+	if err := k.load(); err == nil {
+		t.Errorf("loaded a list whose page could not be read")
+	}
+
+	if _, err := newKeywordLoader(
+		KeywordLists{Pages: c.KeywordLists.Pages},
+		l, d.lists, d.clock, d.logger,
+	); err != keywordSubredditErr {
+		t.Errorf("got error %v without a subreddit", err)
+	}
+}
+
+func TestParseKeywords(t *testing.T) {
+	keywords := parseKeywords(`# Spam
+
+* free money
+- crypto	
+*  get rich quick
+
+`)
+	if diff := pretty.Compare(keywords, []string{
+		"free money",
+		"crypto",
+		"get rich quick",
+	}); diff != "" {
+		t.Errorf("keywords incorrect; diff: %s", diff)
+	}
+}
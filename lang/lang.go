@@ -0,0 +1,203 @@
+// Package lang guesses the language of short texts like posts and comments,
+// so multilingual communities can route each to a responder who speaks it:
+//
+//	switch lang.Detect(c.Body) {
+//	case "es":
+//	  return b.Reply(c.Name, "¡hola!")
+//	case "en":
+//	  return b.Reply(c.Name, "hello!")
+//	}
+//
+// The detector is small and has no models to load. Texts in scripts used by
+// one language (e.g. Greek, Hangul) are recognized by their script; texts in
+// Latin script by their most common words. Its guesses are good for a few
+// sentences, and it declines to guess for a few words.
+//
+// graw's filters can select things by language with the lang field (see
+// graw/filter), and graw/router can route events by their language.
+package lang
+
+import (
+	"strings"
+	"unicode"
+)
+
+const (
+	// maxWords is the number of words of a text examined; the language of
+	// a long text is clear from its start.
+	maxWords = 200
+	// minHits is the fewest common words a text in Latin script must
+	// have for its language to be guessed.
+	minHits = 2
+)
+
+// commonWords are frequent words of languages written in Latin script. Words
+// common to several languages (e.g. "a") are left out, since they tell the
+// languages apart poorly.
+var commonWords = map[string][]string{
+	"en": {
+		"the", "and", "is", "of", "to", "in", "that", "it", "for", "you",
+		"was", "with", "this", "have", "are", "not", "but", "what",
+		"they", "be", "on", "my", "would", "just", "from", "there",
+	},
+	"es": {
+		"el", "la", "los", "las", "que", "y", "en", "es", "por", "con",
+		"para", "una", "pero", "como", "del", "muy", "más", "está",
+		"yo", "lo", "se", "su", "también", "cuando", "porque",
+	},
+	"pt": {
+		"o", "os", "que", "e", "é", "do", "da", "em", "um", "uma", "não",
+		"para", "com", "mais", "mas", "como", "isso", "muito", "você",
+		"ele", "ela", "foi", "são", "também", "então",
+	},
+	"fr": {
+		"le", "la", "les", "et", "est", "des", "une", "que", "pas",
+		"pour", "dans", "je", "ce", "qui", "sur", "avec", "mais", "vous",
+		"il", "sont", "au", "du", "très", "c'est", "aussi",
+	},
+	"it": {
+		"il", "la", "che", "e", "di", "non", "per", "una", "sono", "gli",
+		"con", "ma", "anche", "della", "questo", "molto", "ho", "mi",
+		"lo", "del", "alla", "perché", "come", "io", "ci",
+	},
+	"de": {
+		"der", "die", "und", "das", "ist", "nicht", "ich", "mit", "sie",
+		"ein", "eine", "es", "zu", "auf", "den", "auch", "sich", "wie",
+		"aber", "wenn", "noch", "nur", "dass", "oder", "sehr",
+	},
+	"nl": {
+		"de", "het", "een", "en", "van", "ik", "niet", "dat", "is", "je",
+		"op", "te", "zijn", "met", "voor", "maar", "ook", "wel", "als",
+		"dit", "naar", "nog", "hij", "heb", "kan",
+	},
+	"sv": {
+		"och", "att", "det", "som", "är", "på", "för", "inte", "med",
+		"jag", "har", "en", "av", "till", "den", "om", "men", "var",
+		"så", "kan", "du", "vi", "också", "eller", "mycket",
+	},
+	"pl": {
+		"nie", "się", "i", "w", "na", "jest", "to", "że", "z", "do",
+		"jak", "ale", "co", "tak", "już", "tylko", "jestem", "być",
+		"czy", "mnie", "dla", "bardzo", "może", "ten", "tego",
+	},
+	"tr": {
+		"ve", "bir", "bu", "da", "de", "için", "çok", "ne", "ben",
+		"değil", "ama", "gibi", "var", "daha", "olarak", "sen", "mi",
+		"ile", "her", "şey", "kadar", "yok", "olan", "benim", "bunu",
+	},
+}
+
+// wordLanguages maps each common word to the languages it is common in.
+var wordLanguages = func() map[string][]string {
+	m := make(map[string][]string)
+	for lang, words := range commonWords {
+		for _, w := range words {
+			m[w] = append(m[w], lang)
+		}
+	}
+	return m
+}()
+
+// Detect returns the ISO 639-1 code of the language the text is most likely
+// written in (e.g. "en", "es", "ja"), or "" if it cannot tell.
+func Detect(text string) string {
+	if lang := byScript(text); lang != "" {
+		return lang
+	}
+	return byWords(text)
+}
+
+// byScript returns the language of text mostly written in a script which
+// identifies it, or "" if the text is not.
+func byScript(text string) string {
+	counts := make(map[string]int)
+	letters := 0
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+
+		switch {
+		case unicode.In(r, unicode.Hiragana, unicode.Katakana):
+			counts["ja"]++
+		case unicode.Is(unicode.Han, r):
+			counts["han"]++
+		case unicode.Is(unicode.Hangul, r):
+			counts["ko"]++
+		case unicode.Is(unicode.Greek, r):
+			counts["el"]++
+		case unicode.Is(unicode.Arabic, r):
+			counts["ar"]++
+		case unicode.Is(unicode.Hebrew, r):
+			counts["he"]++
+		case unicode.Is(unicode.Devanagari, r):
+			counts["hi"]++
+		case unicode.Is(unicode.Thai, r):
+			counts["th"]++
+		case strings.ContainsRune("іїєґІЇЄҐ", r):
+			counts["uk"]++
+			counts["cyrillic"]++
+		case unicode.Is(unicode.Cyrillic, r):
+			counts["cyrillic"]++
+		}
+	}
+	if letters == 0 {
+		return ""
+	}
+
+	// Japanese mixes kana with Han characters; Chinese uses Han alone.
+	if counts["ja"] > 0 && counts["ja"]+counts["han"] > letters/2 {
+		return "ja"
+	}
+	if counts["han"] > letters/2 {
+		return "zh"
+	}
+	if counts["cyrillic"] > letters/2 {
+		if counts["uk"] > 0 {
+			return "uk"
+		}
+		return "ru"
+	}
+	for _, lang := range []string{"ko", "el", "ar", "he", "hi", "th"} {
+		if counts[lang] > letters/2 {
+			return lang
+		}
+	}
+	return ""
+}
+
+// byWords returns the language whose common words the text uses most, or ""
+// if it uses too few of any language's.
+func byWords(text string) string {
+	words := strings.FieldsFunc(
+		strings.ToLower(text),
+		func(r rune) bool {
+			return !unicode.IsLetter(r) && r != '\''
+		},
+	)
+	if len(words) > maxWords {
+		words = words[:maxWords]
+	}
+
+	hits := make(map[string]int)
+	for _, w := range words {
+		for _, lang := range wordLanguages[w] {
+			hits[lang]++
+		}
+	}
+
+	best, bestHits, tied := "", 0, false
+	for lang, n := range hits {
+		switch {
+		case n > bestHits:
+			best, bestHits, tied = lang, n, false
+		case n == bestHits:
+			tied = true
+		}
+	}
+	if bestHits < minHits || tied {
+		return ""
+	}
+	return best
+}
@@ -0,0 +1,39 @@
+package lang
+
+import (
+	"testing"
+)
+
+func TestDetect(t *testing.T) {
+	for _, test := range []struct {
+		text string
+		lang string
+	}{
+		{"I think the new release is great, and it was worth the wait for you.", "en"},
+		{"Creo que la nueva versión es muy buena, pero todavía tiene errores con los módulos.", "es"},
+		{"Eu acho que a nova versão é muito boa, mas você ainda não pode usar isso.", "pt"},
+		{"Je pense que la nouvelle version est très bien, mais il y a des problèmes avec les modules.", "fr"},
+		{"Penso che la nuova versione sia molto buona, ma non ho ancora provato questo.", "it"},
+		{"Ich denke, die neue Version ist sehr gut, aber es gibt noch Probleme mit den Modulen.", "de"},
+		{"Ik denk dat de nieuwe versie heel goed is, maar het werkt nog niet met mijn code.", "nl"},
+		{"Jag tycker att den nya versionen är bra, men det finns fortfarande problem med moduler.", "sv"},
+		{"Myślę, że nowa wersja jest bardzo dobra, ale nie działa jeszcze z moim kodem.", "pl"},
+		{"Bence yeni sürüm çok iyi ama bu kadar yavaş olması bir sorun değil mi?", "tr"},
+		{"Я думаю, что новая версия очень хорошая.", "ru"},
+		{"Я думаю, що нова версія дуже гарна, і вона працює.", "uk"},
+		{"新しいバージョンはとても良いと思います。", "ja"},
+		{"我认为新版本非常好。", "zh"},
+		{"새 버전이 아주 좋다고 생각합니다.", "ko"},
+		{"Νομίζω ότι η νέα έκδοση είναι πολύ καλή.", "el"},
+		{"أعتقد أن الإصدار الجديد جيد جدا.", "ar"},
+		{"אני חושב שהגרסה החדשה טובה מאוד.", "he"},
+		{"मुझे लगता है कि नया संस्करण बहुत अच्छा है।", "hi"},
+		{"lol", ""},
+		{"", ""},
+		{"12345 !!!", ""},
+	} {
+		if lang := Detect(test.text); lang != test.lang {
+			t.Errorf("Detect(%q) = %q; wanted %q", test.text, lang, test.lang)
+		}
+	}
+}
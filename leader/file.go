@@ -0,0 +1,74 @@
+//go:build unix
+
+package leader
+
+import (
+	"os"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// FileLock is a Lock shared through a lock file, for instances on one host.
+// The operating system releases it when its holder exits, however it exits,
+// so a standby takes over as soon as the leader dies. Locks on network file
+// systems are not reliable; use a coordinator such as Redis across hosts.
+type FileLock struct {
+	path string
+	f    *os.File
+	mu   *sync.Mutex
+}
+
+// NewFileLock returns a lock on the file at the path, which is created if it
+// does not exist.
+func NewFileLock(path string) *FileLock {
+	return &FileLock{path: path, mu: &sync.Mutex{}}
+}
+
+// Acquire takes the lock if it is free. A held file lock does not run out, so
+// the time returned for a held lock is far in the future.
+func (l *FileLock) Acquire() (time.Time, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.f != nil {
+		return forever(), nil
+	}
+
+	f, err := os.OpenFile(l.path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	err = syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+	if err == syscall.EWOULDBLOCK {
+		f.Close()
+		return time.Time{}, nil
+	} else if err != nil {
+		f.Close()
+		return time.Time{}, err
+	}
+
+	l.f = f
+	return forever(), nil
+}
+
+// Release releases the lock if it is held.
+func (l *FileLock) Release() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.f == nil {
+		return nil
+	}
+
+	f := l.f
+	l.f = nil
+	syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+	return f.Close()
+}
+
+// forever returns a time a held lock will not run out before.
+func forever() time.Time {
+	return time.Now().Add(24 * time.Hour)
+}
@@ -0,0 +1,29 @@
+//go:build !unix
+
+package leader
+
+import (
+	"fmt"
+	"time"
+)
+
+var fileLockErr = fmt.Errorf("leader: file locks are not supported here")
+
+// FileLock is a Lock shared through a lock file. It is only supported on
+// Unix systems; elsewhere acquiring it fails.
+type FileLock struct{}
+
+// NewFileLock returns a lock on the file at the path.
+func NewFileLock(path string) *FileLock {
+	return &FileLock{}
+}
+
+// Acquire fails.
+func (l *FileLock) Acquire() (time.Time, error) {
+	return time.Time{}, fileLockErr
+}
+
+// Release does nothing.
+func (l *FileLock) Release() error {
+	return nil
+}
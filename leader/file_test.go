@@ -0,0 +1,36 @@
+//go:build unix
+
+package leader
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFileLock(t *testing.T) {
+	dir, err := os.MkdirTemp("", "leader")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "bot.lock")
+	a, b := NewFileLock(path), NewFileLock(path)
+
+	if until, err := a.Acquire(); err != nil || !until.After(time.Now()) {
+		t.Fatalf("first lock not acquired: %v, %v", until, err)
+	}
+	if until, err := b.Acquire(); err != nil || until.After(time.Now()) {
+		t.Errorf("second lock acquired while the first is held: %v, %v", until, err)
+	}
+
+	if err := a.Release(); err != nil {
+		t.Fatalf("error releasing lock: %v", err)
+	}
+	if until, err := b.Acquire(); err != nil || !until.After(time.Now()) {
+		t.Errorf("lock not acquired after release: %v, %v", until, err)
+	}
+	b.Release()
+}
@@ -0,0 +1,161 @@
+// Package leader elects one of several instances of a bot to be active, so a
+// standby can take over when the active instance dies without the two ever
+// both replying to the same events.
+//
+// Instances contend for a Lock; the one holding it leads. Pair an Elector with
+// a graw.Monitor so only the leader polls, and so only it handles events and
+// replies to them:
+//
+//	m := graw.NewMonitor()
+//	m.Pause()
+//	e := leader.New(leader.Config{
+//	  Lock:      redis.NewLock(redis.Config{Addr: "localhost:6379"}, "mybot", 0),
+//	  OnElected: m.Resume,
+//	  OnDeposed: m.Pause,
+//	})
+//	defer e.Close()
+//
+//	stop, wait, err := graw.Run(handler, bot, graw.Config{Monitor: m, ...})
+//
+// Work a bot does outside its handlers, such as sending the replies queued in
+// graw/outbox, should check Leading first.
+//
+// graw/store/redis provides a Lock shared through Redis, and NewFileLock one
+// shared through a file on one host. Other coordinators, such as etcd or
+// ZooKeeper, can be used by implementing Lock.
+package leader
+
+import (
+	"io/ioutil"
+	"log"
+	"sync"
+	"time"
+)
+
+const defaultInterval = 5 * time.Second
+
+// Lock is leadership contended for by instances of a bot.
+type Lock interface {
+	// Acquire takes the lock, or extends it if this instance holds it,
+	// and returns until when this instance holds it. It returns a time in
+	// the past if another instance holds it.
+	Acquire() (time.Time, error)
+	// Release gives up the lock if this instance holds it.
+	Release() error
+}
+
+// Config configures an Elector.
+type Config struct {
+	// Lock is the lock the instances contend for.
+	Lock Lock
+	// Interval is how often the lock is acquired or extended. It must be
+	// well under the time the lock is held for, so the leader extends its
+	// hold before it runs out. The default is five seconds.
+	Interval time.Duration
+	// OnElected is called when this instance becomes the leader, and
+	// OnDeposed when it stops being the leader: it lost the lock, or the
+	// elector was closed.
+	OnElected func()
+	OnDeposed func()
+	// Logger, if set, receives failures to acquire the lock.
+	Logger *log.Logger
+}
+
+// Elector keeps contending for leadership until it is closed. Its methods are
+// goroutine safe.
+type Elector struct {
+	c Config
+	// until is when this instance's hold on the lock runs out.
+	until   time.Time
+	leading bool
+	mu      *sync.Mutex
+	kill    chan bool
+	done    chan bool
+}
+
+// New returns an elector contending for the lock, which makes its first attempt
+// at once.
+func New(c Config) *Elector {
+	if c.Interval <= 0 {
+		c.Interval = defaultInterval
+	}
+	if c.Logger == nil {
+		c.Logger = log.New(ioutil.Discard, "", 0)
+	}
+
+	e := &Elector{
+		c:    c,
+		mu:   &sync.Mutex{},
+		kill: make(chan bool),
+		done: make(chan bool),
+	}
+	go e.run()
+	return e
+}
+
+// Leading returns true if this instance is the leader.
+func (e *Elector) Leading() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.leading && time.Now().Before(e.until)
+}
+
+// Close stops contending for leadership, and releases the lock if this
+// instance holds it.
+func (e *Elector) Close() error {
+	close(e.kill)
+	<-e.done
+
+	e.update(time.Time{})
+	return e.c.Lock.Release()
+}
+
+// run acquires the lock every interval, and deposes this instance as soon as
+// its hold runs out if it could not be extended.
+func (e *Elector) run() {
+	defer close(e.done)
+
+	for {
+		until, err := e.c.Lock.Acquire()
+		if err != nil {
+			// The hold already granted still stands.
+			e.c.Logger.Printf("Could not acquire leader lock: %v", err)
+			e.mu.Lock()
+			until = e.until
+			e.mu.Unlock()
+		}
+		e.update(until)
+
+		wait := e.c.Interval
+		if left := time.Until(until); left > 0 && left < wait {
+			wait = left
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-e.kill:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// update records this instance's hold on the lock, and calls the callbacks if
+// that makes it the leader or ends its lead.
+func (e *Elector) update(until time.Time) {
+	e.mu.Lock()
+	e.until = until
+	leading := time.Now().Before(until)
+	changed := leading != e.leading
+	e.leading = leading
+	e.mu.Unlock()
+
+	switch {
+	case changed && leading && e.c.OnElected != nil:
+		e.c.OnElected()
+	case changed && !leading && e.c.OnDeposed != nil:
+		e.c.OnDeposed()
+	}
+}
@@ -0,0 +1,120 @@
+package leader
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeLock is held until the time it is set to.
+type fakeLock struct {
+	until    time.Time
+	err      error
+	released bool
+	mu       sync.Mutex
+}
+
+func (l *fakeLock) set(until time.Time, err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.until, l.err = until, err
+}
+
+func (l *fakeLock) Acquire() (time.Time, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.until, l.err
+}
+
+func (l *fakeLock) Release() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.released = true
+	return nil
+}
+
+// eventually fails the test if cond is not true within a second.
+func eventually(t *testing.T, cond func() bool, what string) {
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestElector(t *testing.T) {
+	lock := &fakeLock{}
+	var events []string
+	var mu sync.Mutex
+	record := func(event string) func() {
+		return func() {
+			mu.Lock()
+			defer mu.Unlock()
+			events = append(events, event)
+		}
+	}
+	count := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(events)
+	}
+
+	e := New(Config{
+		Lock:      lock,
+		Interval:  5 * time.Millisecond,
+		OnElected: record("elected"),
+		OnDeposed: record("deposed"),
+		Logger:    log.New(ioutil.Discard, "", 0),
+	})
+
+	time.Sleep(20 * time.Millisecond)
+	if e.Leading() || count() != 0 {
+		t.Errorf("elected without the lock")
+	}
+
+	lock.set(time.Now().Add(time.Hour), nil)
+	eventually(t, e.Leading, "election")
+
+	// Failures to reach the lock do not end a hold already granted...
+	lock.set(time.Time{}, fmt.Errorf("connection refused"))
+	time.Sleep(20 * time.Millisecond)
+	if !e.Leading() {
+		t.Errorf("deposed by a failure to renew within the hold")
+	}
+
+	// ...but losing the lock does.
+	lock.set(time.Time{}, nil)
+	eventually(t, func() bool { return !e.Leading() }, "deposition")
+
+	lock.set(time.Now().Add(time.Hour), nil)
+	eventually(t, e.Leading, "reelection")
+	if err := e.Close(); err != nil {
+		t.Fatalf("error closing elector: %v", err)
+	}
+	if e.Leading() || !lock.released {
+		t.Errorf("closed elector still leads")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	want := []string{"elected", "deposed", "elected", "deposed"}
+	if fmt.Sprint(events) != fmt.Sprint(want) {
+		t.Errorf("got events %v; wanted %v", events, want)
+	}
+}
+
+func TestElectorDeposesWhenHoldRunsOut(t *testing.T) {
+	lock := &fakeLock{}
+	lock.set(time.Now().Add(30*time.Millisecond), nil)
+	e := New(Config{Lock: lock, Interval: time.Hour})
+	defer e.Close()
+
+	eventually(t, e.Leading, "election")
+	lock.set(time.Time{}, nil)
+	eventually(t, func() bool { return !e.Leading() }, "deposition")
+}
@@ -0,0 +1,270 @@
+package graw
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/turnage/graw/link"
+	"github.com/turnage/graw/reddit"
+	"github.com/turnage/graw/store"
+	"github.com/turnage/graw/streams"
+)
+
+// The boards Leaderboards keeps.
+const (
+	// TopPosters ranks authors by their posts.
+	TopPosters = "posters"
+	// TopCommenters ranks authors by their comments.
+	TopCommenters = "commenters"
+	// MostAwarded ranks authors by the awards their posts and comments
+	// received.
+	MostAwarded = "awarded"
+)
+
+const (
+	// defaultLeaderboardWindow is a week, for weekly recaps.
+	defaultLeaderboardWindow = 7 * 24 * time.Hour
+	// leaderboardPruneInterval is the least time between forgetting the
+	// credits which have left the window.
+	leaderboardPruneInterval = time.Hour
+	// defaultRecapTop is how many authors recaps rank on each board if
+	// they are not told.
+	defaultRecapTop = 10
+)
+
+// Leaderboards keeps rolling leaderboards of the authors in the subreddits a
+// bot monitors (see Config's Subreddits and SubredditComments): the top
+// posters, the top commenters, and the most awarded. The boards are kept in a
+// store, so they survive restarts. Read them with Leaders, or render them for
+// a recap post with Recap. Its methods are goroutine safe.
+//
+// Every post and comment the feeds deliver is counted, before the config's
+// policies choose which are forwarded to the bot. Awards are counted as they
+// are seen: on new posts and comments, on posts refreshed at their PostAges,
+// and on awards given in watched Threads.
+type Leaderboards struct {
+	store  store.Leaderboards
+	window time.Duration
+	// pruned is when the credits which left the window were last
+	// forgotten.
+	pruned time.Time
+	mu     *sync.Mutex
+}
+
+// NewLeaderboards returns leaderboards kept in the store over a rolling
+// window, e.g. the last week, which is the default. Posts and comments made
+// before the window are forgotten.
+func NewLeaderboards(s store.Leaderboards, window time.Duration) *Leaderboards {
+	if window <= 0 {
+		window = defaultLeaderboardWindow
+	}
+
+	return &Leaderboards{store: s, window: window, mu: &sync.Mutex{}}
+}
+
+// Leaders returns the authors on the board (one of TopPosters, TopCommenters,
+// and MostAwarded) by their points for posts and comments in the subreddit
+// made at or after since, most first.
+func (l *Leaderboards) Leaders(
+	board, subreddit string,
+	since time.Time,
+) ([]Tally, error) {
+	credits, err := l.store.Credits(
+		board,
+		link.SubredditName(subreddit),
+		since,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	points := make(map[string]int)
+	for _, c := range credits {
+		points[c.Author] += c.Points
+	}
+	return ranked(points), nil
+}
+
+// recapBoards are the boards in recaps, with their titles and what their
+// points count.
+var recapBoards = []struct{ board, title, points string }{
+	{TopPosters, "Top posters", "Posts"},
+	{TopCommenters, "Top commenters", "Comments"},
+	{MostAwarded, "Most awarded", "Awards"},
+}
+
+// Recap renders the top authors on each board for the subreddit since the
+// time as Markdown tables, e.g. for a weekly recap post. Boards with no
+// authors are left out. If top is not positive, the top 10 are rendered.
+func (l *Leaderboards) Recap(
+	subreddit string,
+	since time.Time,
+	top int,
+) (string, error) {
+	if top <= 0 {
+		top = defaultRecapTop
+	}
+
+	var b strings.Builder
+	for _, board := range recapBoards {
+		leaders, err := l.Leaders(board.board, subreddit, since)
+		if err != nil {
+			return "", err
+		}
+		if len(leaders) == 0 {
+			continue
+		}
+		if len(leaders) > top {
+			leaders = leaders[:top]
+		}
+
+		if b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(
+			&b,
+			"**%s**\n\n| | Author | %s |\n|-:|:-|-:|\n",
+			board.title,
+			board.points,
+		)
+		for i, leader := range leaders {
+			fmt.Fprintf(
+				&b,
+				"| %d | /u/%s | %d |\n",
+				i+1,
+				leader.Name,
+				leader.Count,
+			)
+		}
+	}
+	return b.String(), nil
+}
+
+// post credits a post to its author, and its awards if it has any.
+func (l *Leaderboards) post(p *reddit.Post, now time.Time) error {
+	c := store.Credit{
+		Thing:     p.Name,
+		Author:    p.Author,
+		Subreddit: p.Subreddit,
+		Time:      p.Created(),
+		Points:    1,
+	}
+	if err := l.add(TopPosters, c, now); err != nil {
+		return err
+	}
+	return l.awards(c, int(p.Awards()), now)
+}
+
+// comment credits a comment to its author, and its awards if it has any.
+func (l *Leaderboards) comment(comment *reddit.Comment, now time.Time) error {
+	c := store.Credit{
+		Thing:     comment.Name,
+		Author:    comment.Author,
+		Subreddit: comment.Subreddit,
+		Time:      comment.Created(),
+		Points:    1,
+	}
+	if err := l.add(TopCommenters, c, now); err != nil {
+		return err
+	}
+	return l.awards(c, int(comment.Awards()), now)
+}
+
+// postAwards credits a post's awards to its author, e.g. when it is seen
+// again with more.
+func (l *Leaderboards) postAwards(p *reddit.Post, now time.Time) error {
+	return l.awards(
+		store.Credit{
+			Thing:     p.Name,
+			Author:    p.Author,
+			Subreddit: p.Subreddit,
+			Time:      p.Created(),
+		},
+		int(p.Awards()),
+		now,
+	)
+}
+
+// commentAwards credits a comment's awards to its author.
+func (l *Leaderboards) commentAwards(
+	comment *reddit.Comment,
+	now time.Time,
+) error {
+	return l.awards(
+		store.Credit{
+			Thing:     comment.Name,
+			Author:    comment.Author,
+			Subreddit: comment.Subreddit,
+			Time:      comment.Created(),
+		},
+		int(comment.Awards()),
+		now,
+	)
+}
+
+// leaderboardAwards credits the awards given in a watched thread to their
+// recipients.
+func leaderboardAwards(d *dispatcher, e streams.ThreadEvent) {
+	if d.leaderboards == nil {
+		return
+	}
+
+	switch e.Kind {
+	case streams.ThreadPostGilded:
+		logCredit(d, d.leaderboards.postAwards(e.Post, d.clock.Now()))
+	case streams.ThreadCommentGilded:
+		logCredit(
+			d,
+			d.leaderboards.commentAwards(e.Comment, d.clock.Now()),
+		)
+	}
+}
+
+// logCredit logs an error crediting the leaderboards. A missed credit costs
+// a board a point, which does not warrant stopping the bot.
+func logCredit(d *dispatcher, err error) {
+	if err != nil {
+		d.logger.Printf("Could not credit the leaderboards: %v", err)
+	}
+}
+
+func (l *Leaderboards) awards(c store.Credit, awards int, now time.Time) error {
+	if awards <= 0 {
+		return nil
+	}
+	c.Points = awards
+	return l.add(MostAwarded, c, now)
+}
+
+// add records the credit, unless its author is deleted or it is older than
+// the window, and forgets the credits which have left the window if it has
+// been a while.
+func (l *Leaderboards) add(board string, c store.Credit, now time.Time) error {
+	if l == nil || c.Author == "" || c.Author == "[deleted]" {
+		return nil
+	}
+
+	start := now.Add(-l.window)
+	if c.Time.Before(start) {
+		return nil
+	}
+
+	c.Subreddit = link.SubredditName(c.Subreddit)
+	if err := l.store.AddCredit(board, c); err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	prune := now.Sub(l.pruned) >= leaderboardPruneInterval
+	if prune {
+		l.pruned = now
+	}
+	l.mu.Unlock()
+
+	if prune {
+		return l.store.ForgetCredits(start)
+	}
+	return nil
+}
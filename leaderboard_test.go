@@ -0,0 +1,211 @@
+package graw
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/turnage/graw/reddit"
+	"github.com/turnage/graw/store"
+	"github.com/turnage/graw/streams"
+)
+
+func TestLeaderboards(t *testing.T) {
+	l := NewLeaderboards(store.NewMemory(), 24*time.Hour)
+	start := time.Unix(1500000000, 0)
+	created := uint64(start.Unix())
+
+	for i, author := range []string{"gopher", "gopher", "crab", "[deleted]"} {
+		if err := l.post(&reddit.Post{
+			Name:       "t3_" + string(rune('a'+i)),
+			Author:     author,
+			Subreddit:  "GoLang",
+			CreatedUTC: created,
+		}, start); err != nil {
+			t.Fatalf("error crediting post: %v", err)
+		}
+	}
+	if err := l.comment(&reddit.Comment{
+		Name:                "t1_a",
+		Author:              "crab",
+		Subreddit:           "golang",
+		CreatedUTC:          created,
+		TotalAwardsReceived: 2,
+	}, start); err != nil {
+		t.Fatalf("error crediting comment: %v", err)
+	}
+	// Posts from before the window are not credited.
+	if err := l.post(&reddit.Post{
+		Name:       "t3_old",
+		Author:     "crab",
+		Subreddit:  "golang",
+		CreatedUTC: created - 2*24*60*60,
+	}, start); err != nil {
+		t.Fatalf("error crediting post: %v", err)
+	}
+
+	// Awards seen again replace those seen before.
+	for _, awards := range []int32{1, 3} {
+		if err := l.postAwards(&reddit.Post{
+			Name:                "t3_a",
+			Author:              "gopher",
+			Subreddit:           "golang",
+			CreatedUTC:          created,
+			TotalAwardsReceived: awards,
+		}, start); err != nil {
+			t.Fatalf("error crediting awards: %v", err)
+		}
+	}
+
+	for _, test := range []struct {
+		board string
+		want  []Tally
+	}{
+		{TopPosters, []Tally{{"gopher", 2}, {"crab", 1}}},
+		{TopCommenters, []Tally{{"crab", 1}}},
+		{MostAwarded, []Tally{{"gopher", 3}, {"crab", 2}}},
+	} {
+		leaders, err := l.Leaders(test.board, "/r/golang", start)
+		if err != nil {
+			t.Fatalf("error getting leaders: %v", err)
+		}
+		if !reflect.DeepEqual(leaders, test.want) {
+			t.Errorf(
+				"got %s leaders %v; wanted %v",
+				test.board, leaders, test.want,
+			)
+		}
+	}
+
+	recap, err := l.Recap("golang", start, 1)
+	if err != nil {
+		t.Fatalf("error rendering recap: %v", err)
+	}
+	want := "**Top posters**\n\n" +
+		"| | Author | Posts |\n|-:|:-|-:|\n" +
+		"| 1 | /u/gopher | 2 |\n" +
+		"\n**Top commenters**\n\n" +
+		"| | Author | Comments |\n|-:|:-|-:|\n" +
+		"| 1 | /u/crab | 1 |\n" +
+		"\n**Most awarded**\n\n" +
+		"| | Author | Awards |\n|-:|:-|-:|\n" +
+		"| 1 | /u/gopher | 3 |\n"
+	if recap != want {
+		t.Errorf("got recap %q; wanted %q", recap, want)
+	}
+
+	if recap, err := l.Recap("rust", start, 10); err != nil || recap != "" {
+		t.Errorf("got recap %q, %v for a quiet subreddit", recap, err)
+	}
+
+	// Recaps which do not say how many authors to rank rank the default.
+	recap, err = l.Recap("golang", start, 0)
+	if err != nil {
+		t.Fatalf("error rendering recap: %v", err)
+	}
+	if !strings.Contains(recap, "| 2 | /u/crab | 1 |") {
+		t.Errorf("got recap %q; wanted both posters ranked", recap)
+	}
+}
+
+func TestLeaderboardsForget(t *testing.T) {
+	st := store.NewMemory()
+	l := NewLeaderboards(st, time.Hour)
+	start := time.Unix(1500000000, 0)
+
+	post := func(name string, at time.Time) {
+		if err := l.post(&reddit.Post{
+			Name:       name,
+			Author:     "gopher",
+			Subreddit:  "golang",
+			CreatedUTC: uint64(at.Unix()),
+		}, at); err != nil {
+			t.Fatalf("error crediting post: %v", err)
+		}
+	}
+	post("t3_a", start)
+	post("t3_b", start.Add(2*time.Hour))
+
+	credits, err := st.Credits(TopPosters, "golang", time.Time{})
+	if err != nil {
+		t.Fatalf("error getting credits: %v", err)
+	}
+	if len(credits) != 1 || credits[0].Thing != "t3_b" {
+		t.Errorf("got credits %+v; wanted only t3_b", credits)
+	}
+}
+
+func TestLeaderboardAwards(t *testing.T) {
+	l := NewLeaderboards(store.NewMemory(), 0)
+	c := Config{Leaderboards: l}
+	d := newDispatcher(c, "", nil)
+	now := d.clock.Now()
+
+	leaderboardAwards(d, streams.ThreadEvent{
+		Kind: streams.ThreadCommentGilded,
+		Comment: &reddit.Comment{
+			Name:       "t1_a",
+			Author:     "gopher",
+			Subreddit:  "golang",
+			CreatedUTC: uint64(now.Unix()),
+			Gilded:     1,
+		},
+	})
+
+	leaders, err := l.Leaders(MostAwarded, "golang", now.Add(-time.Minute))
+	if err != nil {
+		t.Fatalf("error getting leaders: %v", err)
+	}
+	if !reflect.DeepEqual(leaders, []Tally{{"gopher", 1}}) {
+		t.Errorf("got leaders %v; wanted gopher with 1 award", leaders)
+	}
+
+	// Runs without leaderboards credit nothing.
+	leaderboardAwards(
+		newDispatcher(Config{}, "", nil),
+		streams.ThreadEvent{Kind: streams.ThreadPostGilded},
+	)
+
+	// Credits the store fails to record are logged, not fatal.
+	var buf bytes.Buffer
+	d = newDispatcher(
+		Config{
+			Leaderboards: NewLeaderboards(brokenCredits{}, 0),
+			Logger:       log.New(&buf, "", 0),
+		},
+		"",
+		nil,
+	)
+	leaderboardAwards(d, streams.ThreadEvent{
+		Kind: streams.ThreadPostGilded,
+		Post: &reddit.Post{
+			Name:                "t3_a",
+			Author:              "gopher",
+			CreatedUTC:          uint64(now.Unix()),
+			TotalAwardsReceived: 1,
+		},
+	})
+	if !strings.Contains(buf.String(), "credit") {
+		t.Errorf("got log %q; wanted the store's error", buf.String())
+	}
+}
+
+// brokenCredits is a leaderboard store which fails to record credits.
+type brokenCredits struct{}
+
+func (brokenCredits) AddCredit(board string, c store.Credit) error {
+	return fmt.Errorf("store is down")
+}
+
+func (brokenCredits) Credits(
+	board, subreddit string,
+	since time.Time,
+) ([]store.Credit, error) {
+	return nil, nil
+}
+
+func (brokenCredits) ForgetCredits(before time.Time) error { return nil }
@@ -0,0 +1,148 @@
+// Package link converts between the ways Reddit identifies things: ids
+// (5du939), fullnames (t3_5du939), permalinks
+// (/r/golang/comments/5du939/title/d8s9dfa/), and short links
+// (https://redd.it/5du939).
+//
+//	l, err := link.Parse("https://old.reddit.com/r/golang/comments/5du939/title/d8s9dfa/?context=3")
+//	// l.Subreddit == "golang", l.Post == "5du939", l.Comment == "d8s9dfa"
+//	bot.Reply(l.CommentFullname(), "hello!")
+package link
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Type prefixes of fullnames.
+const (
+	CommentType   = "t1"
+	AccountType   = "t2"
+	PostType      = "t3"
+	MessageType   = "t4"
+	SubredditType = "t5"
+	AwardType     = "t6"
+)
+
+// shortHost serves short links to posts.
+const shortHost = "redd.it"
+
+var errNotALink = fmt.Errorf("not a link to a Reddit post or comment")
+
+// Fullname returns the fullname of the thing of the type with the id, e.g.
+// Fullname(PostType, "5du939") is "t3_5du939".
+func Fullname(typ, id string) string {
+	return typ + "_" + id
+}
+
+// Split returns the type prefix and id of a fullname. Both are empty if it is
+// not a fullname.
+func Split(fullname string) (typ, id string) {
+	parts := strings.SplitN(fullname, "_", 2)
+	if len(parts) != 2 || len(parts[0]) != 2 || parts[0][0] != 't' {
+		return "", ""
+	}
+	return parts[0], parts[1]
+}
+
+// ID returns the id of the thing with the fullname, or the argument if it is
+// already an id.
+func ID(fullname string) string {
+	if _, id := Split(fullname); id != "" {
+		return id
+	}
+	return fullname
+}
+
+// SubredditName returns a subreddit's name in the form Reddit matches names
+// in: lowercase, and without the r/ prefix or slashes. "/R/Golang/",
+// "r/golang", and "golang" are all "golang".
+func SubredditName(name string) string {
+	name = strings.ToLower(strings.Trim(strings.TrimSpace(name), "/"))
+	return strings.TrimPrefix(name, "r/")
+}
+
+// ShortLink returns the short link to the post with the id or fullname.
+func ShortLink(post string) string {
+	return "https://" + shortHost + "/" + ID(post)
+}
+
+// Link locates a post or a comment in it.
+type Link struct {
+	// Subreddit is the name of the subreddit the post is in, without the
+	// /r/ prefix. It is empty if the link did not say, as short links do
+	// not.
+	Subreddit string
+	// Post is the id of the post.
+	Post string
+	// Comment is the id of the comment, or empty if the link is to the
+	// post.
+	Comment string
+}
+
+// Parse parses a url or path linking to a post or comment on Reddit. It
+// understands links on any Reddit host (www., old., np., etc.), site-relative
+// permalinks, and short links.
+func Parse(rawurl string) (Link, error) {
+	u, err := url.Parse(strings.TrimSpace(rawurl))
+	if err != nil {
+		return Link{}, err
+	}
+
+	host := strings.ToLower(u.Host)
+	parts := strings.FieldsFunc(u.Path, func(r rune) bool { return r == '/' })
+	if host == shortHost {
+		if len(parts) != 1 {
+			return Link{}, errNotALink
+		}
+		return Link{Post: parts[0]}, nil
+	}
+
+	if host != "" && host != "reddit.com" && !strings.HasSuffix(host, ".reddit.com") {
+		return Link{}, errNotALink
+	}
+
+	var l Link
+	if len(parts) >= 2 && parts[0] == "r" {
+		l.Subreddit = parts[1]
+		parts = parts[2:]
+	}
+
+	// The rest is comments/<post>[/<title slug>[/<comment>]].
+	if len(parts) < 2 || parts[0] != "comments" {
+		return Link{}, errNotALink
+	}
+
+	l.Post = parts[1]
+	if len(parts) >= 4 {
+		l.Comment = parts[3]
+	}
+	return l, nil
+}
+
+// PostFullname returns the fullname of the post.
+func (l Link) PostFullname() string {
+	return Fullname(PostType, l.Post)
+}
+
+// CommentFullname returns the fullname of the comment, or an empty string if
+// the link is to the post.
+func (l Link) CommentFullname() string {
+	if l.Comment == "" {
+		return ""
+	}
+	return Fullname(CommentType, l.Comment)
+}
+
+// Permalink returns the site-relative permalink of the post or comment. Reddit
+// ignores the title slug of permalinks, so "_" stands in for it.
+func (l Link) Permalink() string {
+	path := "/comments/" + l.Post + "/"
+	if l.Subreddit != "" {
+		path = "/r/" + l.Subreddit + path
+	}
+	if l.Comment != "" {
+		path += "_/" + l.Comment + "/"
+	}
+	return path
+}
@@ -0,0 +1,103 @@
+package link
+
+import (
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	for _, test := range []struct {
+		url  string
+		link Link
+		err  bool
+	}{
+		{
+			url:  "https://www.reddit.com/r/golang/comments/5du939/title/",
+			link: Link{Subreddit: "golang", Post: "5du939"},
+		},
+		{
+			url: "https://old.reddit.com/r/golang/comments/5du939/title/d8s9dfa/?context=3",
+			link: Link{
+				Subreddit: "golang",
+				Post:      "5du939",
+				Comment:   "d8s9dfa",
+			},
+		},
+		{
+			url:  "/r/golang/comments/5du939",
+			link: Link{Subreddit: "golang", Post: "5du939"},
+		},
+		{
+			url:  "https://reddit.com/comments/5du939",
+			link: Link{Post: "5du939"},
+		},
+		{
+			url:  "https://redd.it/5du939",
+			link: Link{Post: "5du939"},
+		},
+		{url: "https://www.reddit.com/r/golang/", err: true},
+		{url: "https://example.com/r/golang/comments/5du939", err: true},
+		{url: "https://redd.it/", err: true},
+	} {
+		l, err := Parse(test.url)
+		if test.err {
+			if err == nil {
+				t.Errorf("%s: parsed as %+v; wanted an error", test.url, l)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%s: error parsing: %v", test.url, err)
+		} else if l != test.link {
+			t.Errorf("%s: got %+v; wanted %+v", test.url, l, test.link)
+		}
+	}
+}
+
+func TestConversions(t *testing.T) {
+	if name := Fullname(PostType, "5du939"); name != "t3_5du939" {
+		t.Errorf("got fullname %s; wanted t3_5du939", name)
+	}
+
+	if typ, id := Split("t1_d8s9dfa"); typ != CommentType || id != "d8s9dfa" {
+		t.Errorf("got %s, %s; wanted t1, d8s9dfa", typ, id)
+	}
+
+	if typ, id := Split("5du939"); typ != "" || id != "" {
+		t.Errorf("got %s, %s for an id; wanted nothing", typ, id)
+	}
+
+	for _, arg := range []string{"t3_5du939", "5du939"} {
+		if id := ID(arg); id != "5du939" {
+			t.Errorf("%s: got id %s; wanted 5du939", arg, id)
+		}
+	}
+
+	if short := ShortLink("t3_5du939"); short != "https://redd.it/5du939" {
+		t.Errorf("got short link %s", short)
+	}
+
+	l := Link{Subreddit: "golang", Post: "5du939", Comment: "d8s9dfa"}
+	if p := l.Permalink(); p != "/r/golang/comments/5du939/_/d8s9dfa/" {
+		t.Errorf("got permalink %s", p)
+	}
+	if name := l.CommentFullname(); name != "t1_d8s9dfa" {
+		t.Errorf("got comment fullname %s", name)
+	}
+
+	reparsed, err := Parse(l.Permalink())
+	if err != nil || reparsed != l {
+		t.Errorf("permalink reparsed as %+v, %v; wanted %+v", reparsed, err, l)
+	}
+}
+
+func TestSubredditName(t *testing.T) {
+	for _, name := range []string{
+		"golang", "Golang", "r/golang", "R/Golang", "/r/golang/",
+		" golang/ ",
+	} {
+		if got := SubredditName(name); got != "golang" {
+			t.Errorf("%q: got %q; wanted golang", name, got)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package graw
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/turnage/graw/reddit"
+	"github.com/turnage/graw/store"
+)
+
+// hostAliases are the prefixes of hosts which serve the same pages as the host
+// without them.
+var hostAliases = []string{"www.", "m.", "mobile.", "old.", "amp."}
+
+// trackingParams are query parameters which say how a link was shared, not
+// what it links to.
+var trackingParams = map[string]bool{
+	"fbclid": true,
+	"gclid":  true,
+	"ref":    true,
+	"si":     true,
+}
+
+// linkKey returns the key a link is indexed under: the link with what varies
+// between shares of the same page removed, so that
+// https://www.example.com/a/?utm_source=x and http://example.com/a are the
+// same link. It returns "" for links which cannot be parsed.
+func linkKey(link string) string {
+	u, err := url.Parse(strings.TrimSpace(link))
+	if err != nil || u.Host == "" {
+		return ""
+	}
+
+	host := strings.ToLower(u.Hostname())
+	for _, alias := range hostAliases {
+		host = strings.TrimPrefix(host, alias)
+	}
+
+	query := u.Query()
+	path := strings.TrimSuffix(u.EscapedPath(), "/")
+	if host == "youtu.be" && path != "" {
+		host, path = "youtube.com", "/watch"
+		query.Set("v", strings.TrimPrefix(u.Path, "/"))
+	}
+
+	for key := range query {
+		if trackingParams[key] || strings.HasPrefix(key, "utm_") {
+			query.Del(key)
+		}
+	}
+
+	key := host + path
+	if len(query) > 0 {
+		// Encode sorts the parameters by key.
+		key += "?" + query.Encode()
+	}
+	return key
+}
+
+// indexLink records a link post in the index. Self posts link to themselves,
+// so they are not recorded.
+func indexLink(links store.Links, p *reddit.Post) error {
+	if p.IsSelf {
+		return nil
+	}
+
+	key := linkKey(p.URL)
+	if key == "" {
+		return nil
+	}
+
+	return links.AddSighting(
+		key,
+		store.Sighting{
+			Post:      p.Name,
+			Subreddit: p.Subreddit,
+			Time:      p.Created(),
+		},
+	)
+}
+
+// Sightings returns the posts of a link recorded in the index (see Config's
+// Links), oldest first: where else the link was posted in the subreddits the
+// bot monitors. Links which differ only in how they were shared, such as in
+// tracking parameters, are the same link.
+func Sightings(links store.Links, link string) ([]store.Sighting, error) {
+	key := linkKey(link)
+	if key == "" {
+		return nil, nil
+	}
+
+	return links.Sightings(key)
+}
@@ -0,0 +1,54 @@
+package graw
+
+import (
+	"testing"
+
+	"github.com/turnage/graw/reddit"
+	"github.com/turnage/graw/store"
+)
+
+func TestLinkKey(t *testing.T) {
+	for i, test := range []struct {
+		link string
+		key  string
+	}{
+		{"https://www.example.com/a/", "example.com/a"},
+		{"http://m.Example.com/a?utm_source=x&fbclid=y", "example.com/a"},
+		{"https://example.com/a?b=2&a=1#top", "example.com/a?a=1&b=2"},
+		{"https://youtu.be/abc?si=x", "youtube.com/watch?v=abc"},
+		{"https://www.youtube.com/watch?v=abc&utm_medium=y", "youtube.com/watch?v=abc"},
+		{"not a link", ""},
+	} {
+		if key := linkKey(test.link); key != test.key {
+			t.Errorf("%d: got %q; wanted %q", i, key, test.key)
+		}
+	}
+}
+
+func TestSightings(t *testing.T) {
+	links := store.NewMemory()
+	for _, p := range []*reddit.Post{
+		{Name: "t3_a", Subreddit: "golang", URL: "https://blog.golang.org/go2", CreatedUTC: 2},
+		{Name: "t3_b", Subreddit: "programming", URL: "http://www.blog.golang.org/go2/", CreatedUTC: 1},
+		{Name: "t3_c", Subreddit: "golang", URL: "https://reddit.com/r/golang/c", IsSelf: true},
+	} {
+		if err := indexLink(links, p); err != nil {
+			t.Fatalf("error indexing %s: %v", p.Name, err)
+		}
+	}
+
+	sightings, err := Sightings(links, "https://blog.golang.org/go2?utm_source=tw")
+	if err != nil {
+		t.Fatalf("error getting sightings: %v", err)
+	}
+	if len(sightings) != 2 ||
+		sightings[0].Post != "t3_b" ||
+		sightings[0].Subreddit != "programming" ||
+		sightings[1].Post != "t3_a" {
+		t.Errorf("got sightings %+v; wanted t3_b then t3_a", sightings)
+	}
+
+	if sightings, err := Sightings(links, "https://reddit.com/r/golang/c"); err != nil || len(sightings) != 0 {
+		t.Errorf("self posts should not be indexed; got %v, %v", sightings, err)
+	}
+}
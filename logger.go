@@ -3,6 +3,8 @@ package graw
 import (
 	"io/ioutil"
 	"log"
+
+	"github.com/turnage/graw/botfaces"
 )
 
 func logger(l *log.Logger) *log.Logger {
@@ -12,3 +14,14 @@ func logger(l *log.Logger) *log.Logger {
 
 	return l
 }
+
+// lendLogger gives the config's logger to the handler, if it takes one.
+func lendLogger(handler interface{}, l *log.Logger) {
+	if l == nil {
+		return
+	}
+
+	if logged, ok := handler.(botfaces.Logged); ok {
+		logged.SetLogger(l)
+	}
+}
@@ -0,0 +1,163 @@
+package graw
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultExchangeWindow is the window over which exchanges are
+	// counted if a LoopGuard does not specify one.
+	defaultExchangeWindow = time.Hour
+	// maxRemembered is the number of the bot's own recent posts and
+	// comments the loop guard remembers, to recognize replies to them.
+	maxRemembered = 1000
+)
+
+// LoopGuard protects against the classic failure mode of two bots replying to
+// each other forever, and bots responding to themselves.
+type LoopGuard struct {
+	// IgnoreSelf drops events authored by the bot's own account. Only
+	// logged in bots (see Run) know their own account.
+	IgnoreSelf bool
+	// IgnoreBots lists other known bots whose events are always dropped.
+	IgnoreBots []string
+	// MaxExchanges is the number of replies any one author may make to
+	// the bot in a single thread within the Window. Further replies from
+	// them in that thread are dropped until the window passes. Zero
+	// disables this check.
+	//
+	// Replies are recognized from the inbox, and in comment streams by
+	// their parent being something the bot wrote (this requires
+	// IgnoreSelf so the bot's own comments are recognized).
+	MaxExchanges int
+	// Window is the period over which exchanges are counted. If zero, it
+	// is an hour.
+	Window time.Duration
+}
+
+// loopGuard enforces a LoopGuard.
+type loopGuard struct {
+	self   string
+	bots   map[string]bool
+	max    int
+	window time.Duration
+
+	// mine is the set of names of things recently authored by the bot.
+	// order is a ring of the same names, the oldest at next, for
+	// eviction.
+	mine  map[string]bool
+	order []string
+	next  int
+	// exchanges holds the times of recent replies to the bot, by author
+	// and thread, and swept is when the pairs with no recent replies were
+	// last forgotten.
+	exchanges map[string][]time.Time
+	swept     time.Time
+	mu        *sync.Mutex
+}
+
+func newLoopGuard(c LoopGuard, self string) *loopGuard {
+	l := &loopGuard{
+		bots:      make(map[string]bool),
+		max:       c.MaxExchanges,
+		window:    c.Window,
+		mine:      make(map[string]bool),
+		exchanges: make(map[string][]time.Time),
+		mu:        &sync.Mutex{},
+	}
+
+	if c.IgnoreSelf {
+		l.self = strings.ToLower(self)
+	}
+
+	for _, b := range c.IgnoreBots {
+		l.bots[strings.ToLower(b)] = true
+	}
+
+	if l.window <= 0 {
+		l.window = defaultExchangeWindow
+	}
+
+	return l
+}
+
+// admit returns true if the event should be forwarded to the bot.
+func (l *loopGuard) admit(e event, now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	author := strings.ToLower(e.author)
+	if l.self != "" && author == l.self {
+		l.remember(e.name)
+		return false
+	}
+
+	if l.bots[author] {
+		return false
+	}
+
+	if l.max <= 0 || !l.repliesToBot(e) {
+		return true
+	}
+
+	if now.Sub(l.swept) >= l.window {
+		l.sweep(now)
+	}
+
+	key := author + " " + e.thread
+	recent := l.exchanges[key][:0]
+	for _, t := range l.exchanges[key] {
+		if now.Sub(t) < l.window {
+			recent = append(recent, t)
+		}
+	}
+
+	if len(recent) >= l.max {
+		l.exchanges[key] = recent
+		return false
+	}
+
+	l.exchanges[key] = append(recent, now)
+	return true
+}
+
+// sweep forgets the authors and threads with no replies in the window, which
+// are otherwise only trimmed when the same author replies in the same thread
+// again.
+func (l *loopGuard) sweep(now time.Time) {
+	l.swept = now
+	for key, times := range l.exchanges {
+		if len(times) == 0 || now.Sub(times[len(times)-1]) >= l.window {
+			delete(l.exchanges, key)
+		}
+	}
+}
+
+// repliesToBot returns true if the event is a reply to something the bot
+// wrote.
+func (l *loopGuard) repliesToBot(e event) bool {
+	switch e.kind {
+	case postReplyEvent, commentReplyEvent:
+		return true
+	}
+	return e.parent != "" && l.mine[e.parent]
+}
+
+// remember records the name of something the bot wrote.
+func (l *loopGuard) remember(name string) {
+	if name == "" || l.mine[name] {
+		return
+	}
+
+	l.mine[name] = true
+	if len(l.order) < maxRemembered {
+		l.order = append(l.order, name)
+		return
+	}
+
+	delete(l.mine, l.order[l.next])
+	l.order[l.next] = name
+	l.next = (l.next + 1) % maxRemembered
+}
@@ -0,0 +1,107 @@
+package graw
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestLoopGuardIgnoresSelfAndBots(t *testing.T) {
+	l := newLoopGuard(
+		LoopGuard{IgnoreSelf: true, IgnoreBots: []string{"OtherBot"}},
+		"MyBot",
+	)
+	now := time.Now()
+
+	for i, test := range []struct {
+		author string
+		admit  bool
+	}{
+		{"mybot", false},
+		{"otherbot", false},
+		{"roxven", true},
+	} {
+		e := event{kind: commentEvent, author: test.author}
+		if admit := l.admit(e, now); admit != test.admit {
+			t.Errorf("%d: got %v; wanted %v", i, admit, test.admit)
+		}
+	}
+}
+
+func TestLoopGuardPingPong(t *testing.T) {
+	l := newLoopGuard(
+		LoopGuard{IgnoreSelf: true, MaxExchanges: 2, Window: time.Minute},
+		"mybot",
+	)
+	start := time.Now()
+
+	// The bot's own comment is seen in the stream, so replies to it in
+	// the comment stream count as exchanges.
+	l.admit(event{kind: commentEvent, name: "t1_mine", author: "mybot"}, start)
+	reply := event{
+		kind:   commentEvent,
+		author: "pingbot",
+		parent: "t1_mine",
+		thread: "t3_thread",
+	}
+	inbox := event{kind: commentReplyEvent, author: "pingbot", thread: "t3_thread"}
+
+	for i, test := range []struct {
+		e     event
+		at    time.Duration
+		admit bool
+	}{
+		{reply, 0, true},
+		{inbox, time.Second, true},
+		{inbox, 2 * time.Second, false},
+		{event{kind: commentEvent, author: "pingbot"}, 3 * time.Second, true},
+		{inbox, time.Minute + time.Second, true},
+	} {
+		if admit := l.admit(test.e, start.Add(test.at)); admit != test.admit {
+			t.Errorf("%d: got %v; wanted %v", i, admit, test.admit)
+		}
+	}
+}
+
+func TestLoopGuardForgets(t *testing.T) {
+	l := newLoopGuard(
+		LoopGuard{IgnoreSelf: true, MaxExchanges: 1, Window: time.Minute},
+		"mybot",
+	)
+	start := time.Now()
+
+	for i := 0; i < maxRemembered+10; i++ {
+		l.admit(event{
+			kind:   commentEvent,
+			name:   fmt.Sprintf("t1_%d", i),
+			author: "mybot",
+		}, start)
+	}
+	if len(l.mine) != maxRemembered || len(l.order) != maxRemembered {
+		t.Errorf(
+			"remembered %d names in a ring of %d; wanted %d",
+			len(l.mine), len(l.order), maxRemembered,
+		)
+	}
+	if l.mine["t1_0"] || !l.mine[fmt.Sprintf("t1_%d", maxRemembered+9)] {
+		t.Errorf("oldest names were not the ones forgotten")
+	}
+
+	for i := 0; i < 100; i++ {
+		l.admit(event{
+			kind:   commentReplyEvent,
+			author: fmt.Sprintf("user%d", i),
+			thread: "t3_thread",
+		}, start)
+	}
+
+	// Once the window passes, the next reply sweeps out the pairs with
+	// no recent replies.
+	l.admit(
+		event{kind: commentReplyEvent, author: "user0", thread: "t3_other"},
+		start.Add(2*time.Minute),
+	)
+	if len(l.exchanges) != 1 {
+		t.Errorf("got %d exchanges after the window; wanted 1", len(l.exchanges))
+	}
+}
@@ -0,0 +1,248 @@
+// Package media fetches the images posts link to and fingerprints them with
+// perceptual hashes, for bots which catch reposts and stolen content on image
+// subreddits:
+//
+//	f := media.NewFetcher(media.FetcherConfig{Agent: agent})
+//
+//	func (b *bot) Post(p *reddit.Post) error {
+//	  hash, err := f.HashPost(p)
+//	  if err == media.NoImageErr {
+//	    return nil
+//	  } else if err != nil {
+//	    return err
+//	  }
+//	  for _, seen := range b.recent {
+//	    if media.Distance(hash, seen.hash) <= 5 {
+//	      return b.flagRepost(p, seen.post)
+//	    }
+//	  }
+//	  ...
+//	}
+//
+// Perceptual hashes of the same picture stay close when it is scaled,
+// recompressed, or lightly edited, unlike checksums of its bytes. JPEG, PNG,
+// and GIF images can be fetched.
+package media
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	// Decoders for the formats images are fetched in.
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"io/ioutil"
+	"math/bits"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/turnage/graw/reddit"
+)
+
+const (
+	defaultMaxBytes = 10 << 20
+	defaultTimeout  = 30 * time.Second
+	// hashSize is the side of the grid images are shrunk to before they
+	// are hashed; the hash compares horizontally adjacent cells of a grid
+	// one wider.
+	hashSize = 8
+)
+
+var (
+	// NoImageErr is returned for posts which do not link to an image.
+	NoImageErr   = fmt.Errorf("post does not link to an image")
+	tooLargeErr  = fmt.Errorf("image is larger than the size limit")
+	imageHosts   = []string{"i.redd.it", "i.imgur.com", "preview.redd.it"}
+	imageFormats = []string{".jpg", ".jpeg", ".png", ".gif"}
+)
+
+// FetcherConfig configures a Fetcher.
+type FetcherConfig struct {
+	// Agent is the user agent images are requested with. Reddit's image
+	// hosts throttle generic agents like Reddit's API does; build one with
+	// reddit.UserAgent.
+	Agent string
+	// MaxBytes is the largest image fetched. Larger images are not
+	// downloaded past the limit. The default is 10 MiB.
+	MaxBytes int64
+	// Client makes the requests. The default is a client with a thirty
+	// second timeout.
+	Client *http.Client
+}
+
+// Fetcher downloads images.
+type Fetcher struct {
+	c FetcherConfig
+}
+
+// NewFetcher returns a Fetcher for the config.
+func NewFetcher(c FetcherConfig) *Fetcher {
+	if c.MaxBytes <= 0 {
+		c.MaxBytes = defaultMaxBytes
+	}
+	if c.Client == nil {
+		c.Client = &http.Client{Timeout: defaultTimeout}
+	}
+	return &Fetcher{c: c}
+}
+
+// Fetch downloads and decodes the image at the link.
+func (f *Fetcher) Fetch(link string) (image.Image, error) {
+	req, err := http.NewRequest("GET", link, nil)
+	if err != nil {
+		return nil, err
+	}
+	if f.c.Agent != "" {
+		req.Header.Set("User-Agent", f.c.Agent)
+	}
+
+	resp, err := f.c.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching %s: %s", link, resp.Status)
+	} else if resp.ContentLength > f.c.MaxBytes {
+		return nil, tooLargeErr
+	}
+
+	// Read one byte past the limit to tell a body which is exactly the
+	// limit from one which is larger.
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, f.c.MaxBytes+1))
+	if err != nil {
+		return nil, err
+	} else if int64(len(body)) > f.c.MaxBytes {
+		return nil, tooLargeErr
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(body))
+	return img, err
+}
+
+// HashPost fetches the image the post links to and returns its perceptual
+// hash. It returns NoImageErr if the post does not link to an image.
+func (f *Fetcher) HashPost(p *reddit.Post) (uint64, error) {
+	link := ImageURL(p)
+	if link == "" {
+		return 0, NoImageErr
+	}
+
+	img, err := f.Fetch(link)
+	if err != nil {
+		return 0, err
+	}
+	return Hash(img), nil
+}
+
+// ImageURL returns the link to the image a post shows: the image it links to,
+// the first image of a gallery, or the preview Reddit made of its link. It
+// returns "" if the post shows no image.
+func ImageURL(p *reddit.Post) string {
+	if isImage(p.URL) {
+		return p.URL
+	}
+
+	for _, m := range p.Gallery() {
+		if m.Source.URL != "" {
+			return m.Source.URL
+		}
+	}
+
+	for _, preview := range p.Preview.Images {
+		if preview.Source.URL != "" {
+			return preview.Source.URL
+		}
+	}
+	return ""
+}
+
+// isImage returns true if the link is to an image file.
+func isImage(link string) bool {
+	u, err := url.Parse(link)
+	if err != nil {
+		return false
+	}
+
+	ext := strings.ToLower(path.Ext(u.Path))
+	for _, format := range imageFormats {
+		if ext == format {
+			return true
+		}
+	}
+	for _, host := range imageHosts {
+		if strings.EqualFold(u.Hostname(), host) &&
+			ext != ".gifv" && ext != ".mp4" {
+			return true
+		}
+	}
+	return false
+}
+
+// Hash returns the difference hash of the image: it is shrunk to a grayscale
+// grid, and each bit of the hash says whether a cell is brighter than the cell
+// to its right. Scaling, recompressing, and small edits change few bits.
+func Hash(img image.Image) uint64 {
+	grid := shrink(img, hashSize+1, hashSize)
+
+	var hash uint64
+	for y := 0; y < hashSize; y++ {
+		for x := 0; x < hashSize; x++ {
+			hash <<= 1
+			if grid[y][x] > grid[y][x+1] {
+				hash |= 1
+			}
+		}
+	}
+	return hash
+}
+
+// Distance returns the number of bits two hashes differ in. Hashes of the same
+// picture usually differ in fewer than ten.
+func Distance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+// shrink returns the mean luminance of each cell of a w by h grid laid over
+// the image.
+func shrink(img image.Image, w, h int) [][]float64 {
+	bounds := img.Bounds()
+	grid := make([][]float64, h)
+	for y := range grid {
+		grid[y] = make([]float64, w)
+	}
+	if bounds.Empty() {
+		return grid
+	}
+
+	counts := make([][]int, h)
+	for y := range counts {
+		counts[y] = make([]int, w)
+	}
+
+	for py := bounds.Min.Y; py < bounds.Max.Y; py++ {
+		gy := (py - bounds.Min.Y) * h / bounds.Dy()
+		for px := bounds.Min.X; px < bounds.Max.X; px++ {
+			gx := (px - bounds.Min.X) * w / bounds.Dx()
+			r, g, b, _ := img.At(px, py).RGBA()
+			grid[gy][gx] += 0.299*float64(r) + 0.587*float64(g) +
+				0.114*float64(b)
+			counts[gy][gx]++
+		}
+	}
+
+	for y := range grid {
+		for x := range grid[y] {
+			if counts[y][x] > 0 {
+				grid[y][x] /= float64(counts[y][x])
+			}
+		}
+	}
+	return grid
+}
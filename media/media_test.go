@@ -0,0 +1,139 @@
+package media
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/turnage/graw/reddit"
+)
+
+// gradient returns an image which brightens from left to right, with a dark
+// square at the given offset.
+func gradient(w, h, offset int) image.Image {
+	img := image.NewGray(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			v := uint8(x * 255 / w)
+			if x >= offset && x < offset+w/4 && y < h/4 {
+				v = 0
+			}
+			img.SetGray(x, y, color.Gray{Y: v})
+		}
+	}
+	return img
+}
+
+func encode(t *testing.T, img image.Image) []byte {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestFetch(t *testing.T) {
+	blob := encode(t, gradient(64, 48, 0))
+	var agent string
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			agent = r.UserAgent()
+			if r.URL.Path == "/missing.png" {
+				http.NotFound(w, r)
+				return
+			}
+			w.Write(blob)
+		},
+	))
+	defer server.Close()
+
+	f := NewFetcher(FetcherConfig{Agent: "graw-test"})
+	img, err := f.Fetch(server.URL + "/cat.png")
+	if err != nil {
+		t.Fatalf("error fetching image: %v", err)
+	}
+	if img.Bounds().Dx() != 64 || img.Bounds().Dy() != 48 {
+		t.Errorf("got image bounds %v; wanted 64x48", img.Bounds())
+	}
+	if agent != "graw-test" {
+		t.Errorf("got user agent %q; wanted graw-test", agent)
+	}
+
+	if _, err := f.Fetch(server.URL + "/missing.png"); err == nil {
+		t.Errorf("wanted error fetching missing image")
+	}
+
+	small := NewFetcher(FetcherConfig{MaxBytes: int64(len(blob) - 1)})
+	if _, err := small.Fetch(server.URL + "/cat.png"); err != tooLargeErr {
+		t.Errorf("got error %v; wanted %v", err, tooLargeErr)
+	}
+
+	exact := NewFetcher(FetcherConfig{MaxBytes: int64(len(blob))})
+	if _, err := exact.Fetch(server.URL + "/cat.png"); err != nil {
+		t.Errorf("error fetching image at the size limit: %v", err)
+	}
+}
+
+func TestHash(t *testing.T) {
+	original := Hash(gradient(64, 48, 0))
+	if d := Distance(original, Hash(gradient(64, 48, 0))); d != 0 {
+		t.Errorf("got distance %d between the same image; wanted 0", d)
+	}
+	if d := Distance(original, Hash(gradient(256, 192, 0))); d > 5 {
+		t.Errorf("got distance %d to a scaled copy; wanted at most 5", d)
+	}
+
+	flipped := image.NewGray(image.Rect(0, 0, 64, 48))
+	src := gradient(64, 48, 0)
+	for y := 0; y < 48; y++ {
+		for x := 0; x < 64; x++ {
+			flipped.Set(63-x, y, src.At(x, y))
+		}
+	}
+	if d := Distance(original, Hash(flipped)); d < 20 {
+		t.Errorf("got distance %d to a different image; wanted >= 20", d)
+	}
+}
+
+func TestImageURL(t *testing.T) {
+	for i, test := range []struct {
+		post *reddit.Post
+		url  string
+	}{
+		{&reddit.Post{URL: "https://example.com/cat.JPG"}, "https://example.com/cat.JPG"},
+		{&reddit.Post{URL: "https://i.redd.it/abc"}, "https://i.redd.it/abc"},
+		{&reddit.Post{URL: "https://i.imgur.com/abc.gifv"}, ""},
+		{
+			&reddit.Post{
+				URL: "https://www.reddit.com/gallery/abc",
+				GalleryData: reddit.GalleryData{
+					Items: []reddit.GalleryItem{{MediaID: "m1"}},
+				},
+				MediaMetadata: map[string]reddit.MediaMetadata{
+					"m1": {Source: reddit.MediaImage{URL: "https://preview.redd.it/m1.jpg"}},
+				},
+			},
+			"https://preview.redd.it/m1.jpg",
+		},
+		{
+			&reddit.Post{
+				URL: "https://example.com/article",
+				Preview: reddit.Preview{
+					Images: []reddit.PreviewImage{
+						{Source: reddit.Image{URL: "https://preview.redd.it/p.jpg"}},
+					},
+				},
+			},
+			"https://preview.redd.it/p.jpg",
+		},
+		{&reddit.Post{URL: "https://example.com/article"}, ""},
+	} {
+		if url := ImageURL(test.post); url != test.url {
+			t.Errorf("%d: got %q; wanted %q", i, url, test.url)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package graw
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/turnage/graw/reddit"
+)
+
+// Monitor pauses and resumes a run's polling while it runs, e.g. during a
+// deploy, or while a system the bot feeds is down for maintenance. Paused
+// feeds keep their place: they make no requests, and when resumed they pick up
+// where they left off, with the events posted meanwhile. Its methods are
+// goroutine safe.
+//
+//	m := graw.NewMonitor()
+//	cfg := graw.Config{Subreddits: []string{"golang"}, Monitor: m}
+//	...
+//	m.Pause()
+//	defer m.Resume()
+//
+// A poll in flight when a feed is paused finishes, and its events are still
+// forwarded to the bot.
+type Monitor struct {
+	paused bool
+	// sources are the feeds paused one by one.
+	sources map[string]bool
+	// changed is closed, and replaced, whenever a pause begins or ends.
+	changed chan struct{}
+	mu      *sync.Mutex
+}
+
+// NewMonitor returns a monitor with nothing paused.
+func NewMonitor() *Monitor {
+	return &Monitor{
+		sources: make(map[string]bool),
+		changed: make(chan struct{}),
+		mu:      &sync.Mutex{},
+	}
+}
+
+// Pause pauses every feed, and following posts as they age.
+func (m *Monitor) Pause() {
+	m.set(func() { m.paused = true })
+}
+
+// Resume ends a Pause. Feeds paused with PauseSource stay paused.
+func (m *Monitor) Resume() {
+	m.set(func() { m.paused = false })
+}
+
+// PauseSource pauses one feed, named by what it polls: the listing of a
+// subreddit or user feed (e.g. /r/golang+rust/new, /r/golang/comments, or
+// /u/spez), the inbox listing (e.g. /message/inbox), the path of a Ranking or
+// Digest, or the permalink of a thread.
+func (m *Monitor) PauseSource(source string) {
+	m.set(func() { m.sources[strings.ToLower(source)] = true })
+}
+
+// ResumeSource ends a PauseSource.
+func (m *Monitor) ResumeSource(source string) {
+	m.set(func() { delete(m.sources, strings.ToLower(source)) })
+}
+
+// Paused returns true if the feed is paused, by Pause or PauseSource.
+func (m *Monitor) Paused(source string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.pausedLocked(source)
+}
+
+// set makes a change to what is paused, and wakes the feeds waiting on it.
+func (m *Monitor) set(change func()) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	change()
+	close(m.changed)
+	m.changed = make(chan struct{})
+}
+
+func (m *Monitor) pausedLocked(source string) bool {
+	return m.paused || (source != "" && m.sources[strings.ToLower(source)])
+}
+
+// wait blocks while the source is paused, or until the kill channel closes. A
+// source of "" is paused only by Pause.
+func (m *Monitor) wait(source string, kill <-chan bool) {
+	for {
+		m.mu.Lock()
+		if !m.pausedLocked(source) {
+			m.mu.Unlock()
+			return
+		}
+		changed := m.changed
+		m.mu.Unlock()
+
+		select {
+		case <-changed:
+		case <-kill:
+			return
+		}
+	}
+}
+
+// scanner returns the scanner with its polls held while the source is paused.
+func (m *Monitor) scanner(
+	sc reddit.Scanner,
+	source string,
+	kill <-chan bool,
+) reddit.Scanner {
+	if m == nil {
+		return sc
+	}
+	return &gatedScanner{Scanner: sc, m: m, source: source, kill: kill}
+}
+
+// lurker returns the lurker with its thread polls held while the source is
+// paused.
+func (m *Monitor) lurker(
+	l reddit.Lurker,
+	source string,
+	kill <-chan bool,
+) reddit.Lurker {
+	if m == nil {
+		return l
+	}
+	return &gatedLurker{Lurker: l, m: m, source: source, kill: kill}
+}
+
+type gatedScanner struct {
+	reddit.Scanner
+	m      *Monitor
+	source string
+	kill   <-chan bool
+}
+
+func (s *gatedScanner) Listing(path, after string) (reddit.Harvest, error) {
+	s.m.wait(s.source, s.kill)
+	return s.Scanner.Listing(path, after)
+}
+
+func (s *gatedScanner) ListingWithParams(
+	path string,
+	params map[string]string,
+) (reddit.Harvest, error) {
+	s.m.wait(s.source, s.kill)
+	return s.Scanner.ListingWithParams(path, params)
+}
+
+func (s *gatedScanner) ListingWithOptions(
+	path string,
+	opts reddit.ListingOptions,
+) (reddit.Harvest, error) {
+	s.m.wait(s.source, s.kill)
+	return s.Scanner.ListingWithOptions(path, opts)
+}
+
+type gatedLurker struct {
+	reddit.Lurker
+	m      *Monitor
+	source string
+	kill   <-chan bool
+}
+
+func (l *gatedLurker) Thread(permalink string) (*reddit.Post, error) {
+	l.m.wait(l.source, l.kill)
+	return l.Lurker.Thread(permalink)
+}
+
+func (l *gatedLurker) ThreadWithOptions(
+	permalink string,
+	opts reddit.ThreadOptions,
+) (*reddit.Post, error) {
+	l.m.wait(l.source, l.kill)
+	return l.Lurker.ThreadWithOptions(permalink, opts)
+}
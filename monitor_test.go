@@ -0,0 +1,82 @@
+package graw
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMonitorPausesPolls(t *testing.T) {
+	m := NewMonitor()
+	listing := &growingListing{}
+	kill := make(chan bool)
+	sc := m.scanner(listing, "/r/golang/new", kill)
+
+	// polled starts a poll, and returns a channel closed when it is done.
+	polled := func() chan bool {
+		done := make(chan bool)
+		go func() {
+			sc.Listing("/r/golang/new", "")
+			close(done)
+		}()
+		return done
+	}
+
+	for _, test := range []struct {
+		pause, resume func()
+	}{
+		{m.Pause, m.Resume},
+		{
+			func() { m.PauseSource("/R/Golang/new") },
+			func() { m.ResumeSource("/r/golang/new") },
+		},
+	} {
+		test.pause()
+		if !m.Paused("/r/golang/new") {
+			t.Errorf("source is not paused")
+		}
+
+		done := polled()
+		select {
+		case <-done:
+			t.Errorf("paused source polled")
+		case <-time.After(20 * time.Millisecond):
+		}
+
+		test.resume()
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatalf("resumed source did not poll")
+		}
+	}
+
+	m.PauseSource("/r/rust/new")
+	select {
+	case <-polled():
+	case <-time.After(time.Second):
+		t.Errorf("source was held by another source's pause")
+	}
+
+	// Resuming everything does not end a pause of one source.
+	m.PauseSource("/r/golang/new")
+	m.Resume()
+	if !m.Paused("/r/golang/new") {
+		t.Errorf("Resume ended a source's pause")
+	}
+
+	done := polled()
+	close(kill)
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Errorf("paused source was not released when killed")
+	}
+}
+
+func TestMonitorDisabled(t *testing.T) {
+	var m *Monitor
+	listing := &growingListing{}
+	if sc := m.scanner(listing, "/r/golang/new", nil); sc != listing {
+		t.Errorf("scanner without a monitor was wrapped")
+	}
+}
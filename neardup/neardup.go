@@ -0,0 +1,165 @@
+// Package neardup detects texts which are nearly the same as texts seen
+// before, such as the copy-pasted posts and comments of spam rings, which vary
+// a word or two to slip past exact matching.
+//
+//	d := neardup.New(neardup.Config{})
+//
+//	func (b *bot) Comment(c *reddit.Comment) error {
+//	  if dupes := d.Add(c.Name, c.Body); len(dupes) > 0 {
+//	    return b.Report(c.Name, dupes)
+//	  }
+//	  return nil
+//	}
+//
+// Texts are compared by their simhashes: 64 bit fingerprints which differ in
+// few bits for texts which share most of their phrases.
+package neardup
+
+import (
+	"hash/fnv"
+	"math/bits"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+const (
+	// shingleSize is the number of words in each phrase hashed.
+	shingleSize = 3
+
+	defaultDistance  = 3
+	defaultWindow    = 10000
+	defaultMinLength = 10
+)
+
+// Config configures a detector.
+type Config struct {
+	// Distance is the most bits the fingerprints of two texts can differ
+	// in for them to be near duplicates. Larger distances catch looser
+	// copies, and more texts which merely look alike. The default is 3.
+	Distance int
+	// Window is the number of recent texts new texts are compared with.
+	// Each comparison is cheap, but every text added is compared with the
+	// whole window. The default is 10000.
+	Window int
+	// MinLength is the fewest words a text needs to be compared. Short
+	// texts like "thanks!" are duplicated innocently all the time. The
+	// default is 10.
+	MinLength int
+}
+
+// entry is a text in the window.
+type entry struct {
+	name string
+	hash uint64
+}
+
+// Detector remembers the fingerprints of recent texts and finds which of them
+// new texts nearly duplicate. Its methods are goroutine safe.
+type Detector struct {
+	c Config
+	// window is a ring of the most recent texts; next is where the next
+	// text added goes.
+	window []entry
+	next   int
+	mu     *sync.Mutex
+}
+
+// New returns a detector which has seen no texts.
+func New(c Config) *Detector {
+	if c.Distance <= 0 {
+		c.Distance = defaultDistance
+	}
+	if c.Window <= 0 {
+		c.Window = defaultWindow
+	}
+	if c.MinLength <= 0 {
+		c.MinLength = defaultMinLength
+	}
+
+	return &Detector{c: c, mu: &sync.Mutex{}}
+}
+
+// Add compares the text with the recent texts, and returns the names (e.g. the
+// fullnames of posts or comments) of those it nearly duplicates, oldest first.
+// The text then joins the recent texts under its name. Texts shorter than the
+// minimum length are neither compared nor remembered.
+func (d *Detector) Add(name, text string) []string {
+	words := wordsOf(text)
+	if len(words) < d.c.MinLength {
+		return nil
+	}
+	hash := hashWords(words)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var dupes []string
+	for i := range d.window {
+		// Walk the ring from its oldest entry.
+		e := d.window[(d.next+i)%len(d.window)]
+		if Distance(hash, e.hash) <= d.c.Distance {
+			dupes = append(dupes, e.name)
+		}
+	}
+
+	if len(d.window) < d.c.Window {
+		d.window = append(d.window, entry{name: name, hash: hash})
+	} else {
+		d.window[d.next] = entry{name: name, hash: hash}
+		d.next = (d.next + 1) % len(d.window)
+	}
+	return dupes
+}
+
+// Hash returns the simhash of the text. Case, punctuation, and spacing do not
+// change it.
+func Hash(text string) uint64 {
+	return hashWords(wordsOf(text))
+}
+
+// Distance returns the number of bits two hashes differ in.
+func Distance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+// wordsOf splits text into lowercase words.
+func wordsOf(text string) []string {
+	return strings.FieldsFunc(
+		strings.ToLower(text),
+		func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsNumber(r) },
+	)
+}
+
+// hashWords returns the simhash of the phrases of consecutive words: each bit
+// is set if more phrases' hashes set it than do not.
+func hashWords(words []string) uint64 {
+	var votes [64]int
+	vote := func(phrase []string) {
+		h := fnv.New64a()
+		h.Write([]byte(strings.Join(phrase, " ")))
+		sum := h.Sum64()
+		for bit := range votes {
+			if sum&(1<<uint(bit)) != 0 {
+				votes[bit]++
+			} else {
+				votes[bit]--
+			}
+		}
+	}
+
+	if len(words) < shingleSize {
+		vote(words)
+	}
+	for i := 0; i+shingleSize <= len(words); i++ {
+		vote(words[i : i+shingleSize])
+	}
+
+	var hash uint64
+	for bit, v := range votes {
+		if v > 0 {
+			hash |= 1 << uint(bit)
+		}
+	}
+	return hash
+}
@@ -0,0 +1,53 @@
+package neardup
+
+import (
+	"testing"
+)
+
+const spam = "Amazing deal on sunglasses today only, visit cheap-shades " +
+	"dot example for ninety percent off every pair in the store, " +
+	"limited stock so hurry before they are gone forever"
+
+func TestHash(t *testing.T) {
+	if Hash("Hello, WORLD!") != Hash("hello   world") {
+		t.Errorf("case and punctuation changed the hash")
+	}
+
+	copied := Hash(spam + " friends")
+	if d := Distance(Hash(spam), copied); d > defaultDistance {
+		t.Errorf("copy with one word added is %d bits away", d)
+	}
+
+	other := Hash("I have been learning Go for a few weeks and the " +
+		"way interfaces are satisfied implicitly still surprises me " +
+		"every time I read someone else's code")
+	if d := Distance(Hash(spam), other); d <= defaultDistance {
+		t.Errorf("unrelated texts are only %d bits apart", d)
+	}
+}
+
+func TestDetector(t *testing.T) {
+	d := New(Config{Window: 2})
+
+	if dupes := d.Add("t1_a", spam); len(dupes) != 0 {
+		t.Errorf("first text has duplicates %v", dupes)
+	}
+	if dupes := d.Add("t1_b", "thanks!"); len(dupes) != 0 {
+		t.Errorf("short text was compared: %v", dupes)
+	}
+	if dupes := d.Add("t1_c", spam+" friends"); len(dupes) != 1 || dupes[0] != "t1_a" {
+		t.Errorf("got duplicates %v; wanted t1_a", dupes)
+	}
+	if dupes := d.Add("t1_d", spam); len(dupes) != 2 ||
+		dupes[0] != "t1_a" ||
+		dupes[1] != "t1_c" {
+		t.Errorf("got duplicates %v; wanted t1_a, t1_c", dupes)
+	}
+
+	// The window holds two texts, so t1_a has been forgotten.
+	if dupes := d.Add("t1_e", spam); len(dupes) != 2 ||
+		dupes[0] != "t1_c" ||
+		dupes[1] != "t1_d" {
+		t.Errorf("got duplicates %v; wanted t1_c, t1_d", dupes)
+	}
+}
@@ -0,0 +1,251 @@
+// Package outbox queues a bot's replies and sends them at a steady pace, at
+// most a configured number per minute in each subreddit. Communities often
+// frown on bots which reply in bursts, and Reddit's spam filter does too.
+//
+//	o := outbox.New(bot, outbox.Config{
+//	  Store:      st, // e.g. from graw/store/sqlite
+//	  PerMinute:  2,
+//	  Subreddits: map[string]int{"AskReddit": 1},
+//	})
+//	defer o.Close()
+//
+//	func (b *bot) Comment(c *reddit.Comment) error {
+//	  return o.Reply(c.Subreddit, c.Name, "hello!")
+//	}
+//
+// Queued replies are kept in the store until they are sent, so with a durable
+// store a restarted bot sends the replies its last run did not get to.
+package outbox
+
+import (
+	"io/ioutil"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/turnage/graw/link"
+	"github.com/turnage/graw/reddit"
+	"github.com/turnage/graw/store"
+)
+
+const (
+	// replyPath is the path queued replies are recorded under.
+	replyPath = "/api/comment"
+	// idleWait is how long the outbox sleeps when nothing is queued and
+	// it is not woken by a new reply.
+	idleWait = time.Minute
+	// defaultMaxAttempts is how many times a reply is tried before it is
+	// given up on.
+	defaultMaxAttempts = 3
+)
+
+// Config configures an outbox.
+type Config struct {
+	// Store keeps queued replies until they are sent. If nil, they are
+	// kept in memory and lost when the program exits.
+	Store store.Outbox
+	// PerMinute is the most replies sent per minute in any one subreddit.
+	// Replies are spaced evenly across the minute rather than sent in a
+	// burst. Zero means no limit.
+	PerMinute int
+	// Subreddits overrides PerMinute for the subreddits named, e.g.
+	// {"AskReddit": 1}. Names are not case sensitive.
+	Subreddits map[string]int
+	// Cooldown is the least time between replies in any one subreddit,
+	// whatever the per minute limits. Young and low karma accounts should
+	// set it from their reddit.Limits:
+	//
+	//	limits, err := bot.Limits()
+	//	...
+	//	outbox.Config{Cooldown: limits.Cooldown()}
+	Cooldown time.Duration
+	// MaxAttempts is how many times sending a reply is tried before it is
+	// dropped. The default is 3.
+	MaxAttempts int
+	// Logger, if set, receives failures to send replies.
+	Logger *log.Logger
+}
+
+// Outbox queues replies and sends them in the background. Its methods are
+// goroutine safe.
+type Outbox struct {
+	acct        reddit.Account
+	store       store.Outbox
+	perMinute   int
+	subreddits  map[string]int
+	cooldown    time.Duration
+	maxAttempts int
+	logger      *log.Logger
+
+	// next is when each subreddit may next be replied in.
+	next map[string]time.Time
+	// attempts counts failed sends of each queued reply.
+	attempts map[int64]int
+
+	wake chan bool
+	kill chan bool
+	mu   *sync.Mutex
+}
+
+// New returns an outbox sending replies with the account, and starts sending
+// any replies already queued in the store.
+func New(acct reddit.Account, c Config) *Outbox {
+	o := newOutbox(acct, c)
+	go o.run()
+	return o
+}
+
+func newOutbox(acct reddit.Account, c Config) *Outbox {
+	o := &Outbox{
+		acct:        acct,
+		store:       c.Store,
+		perMinute:   c.PerMinute,
+		subreddits:  make(map[string]int),
+		cooldown:    c.Cooldown,
+		maxAttempts: c.MaxAttempts,
+		logger:      c.Logger,
+		next:        make(map[string]time.Time),
+		attempts:    make(map[int64]int),
+		wake:        make(chan bool, 1),
+		kill:        make(chan bool),
+		mu:          &sync.Mutex{},
+	}
+
+	if o.store == nil {
+		o.store = store.NewMemory()
+	}
+	if o.maxAttempts <= 0 {
+		o.maxAttempts = defaultMaxAttempts
+	}
+	if o.logger == nil {
+		o.logger = log.New(ioutil.Discard, "", 0)
+	}
+	for name, limit := range c.Subreddits {
+		o.subreddits[link.SubredditName(name)] = limit
+	}
+	return o
+}
+
+// Reply queues a reply to the parent, which is in the subreddit. Replies to
+// private messages have no subreddit and are limited by PerMinute.
+func (o *Outbox) Reply(subreddit, parentName, text string) error {
+	if _, err := o.store.Push(
+		replyPath,
+		map[string]string{
+			"subreddit": subreddit,
+			"thing_id":  parentName,
+			"text":      text,
+		},
+	); err != nil {
+		return err
+	}
+
+	select {
+	case o.wake <- true:
+	default:
+	}
+	return nil
+}
+
+// Close stops sending replies. Replies still queued stay in the store.
+func (o *Outbox) Close() {
+	close(o.kill)
+}
+
+func (o *Outbox) run() {
+	for {
+		wait := o.flush(time.Now())
+		select {
+		case <-o.kill:
+			return
+		case <-o.wake:
+		case <-time.After(wait):
+		}
+	}
+}
+
+// flush sends the oldest queued reply in each subreddit which may be replied
+// in at now, and returns how long until another may be sent.
+func (o *Outbox) flush(now time.Time) time.Duration {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	items, err := o.store.Pending()
+	if err != nil {
+		o.logger.Printf("Failed to read queued replies: %v", err)
+		return idleWait
+	}
+
+	wait := idleWait
+	sent := make(map[string]bool)
+	for _, item := range items {
+		subreddit := link.SubredditName(item.Values["subreddit"])
+		if item.Path != replyPath || sent[subreddit] {
+			continue
+		}
+
+		if next := o.next[subreddit]; now.Before(next) {
+			if next.Sub(now) < wait {
+				wait = next.Sub(now)
+			}
+			continue
+		}
+
+		sent[subreddit] = true
+		o.send(item)
+		if spacing := o.spacing(subreddit); spacing > 0 {
+			o.next[subreddit] = now.Add(spacing)
+			if spacing < wait {
+				wait = spacing
+			}
+		} else {
+			// More replies to this subreddit may be waiting.
+			wait = 0
+		}
+	}
+
+	return wait
+}
+
+// send sends a queued reply and removes it from the queue, unless it failed
+// and has attempts left.
+func (o *Outbox) send(item store.Item) {
+	err := o.acct.Reply(item.Values["thing_id"], item.Values["text"])
+	if err != nil {
+		o.attempts[item.ID]++
+		if o.attempts[item.ID] < o.maxAttempts {
+			o.logger.Printf(
+				"Failed to reply to %s; will retry: %v",
+				item.Values["thing_id"], err,
+			)
+			return
+		}
+
+		o.logger.Printf(
+			"Failed to reply to %s; giving up: %v",
+			item.Values["thing_id"], err,
+		)
+	}
+
+	delete(o.attempts, item.ID)
+	if err := o.store.Ack(item.ID); err != nil {
+		o.logger.Printf("Failed to remove sent reply from queue: %v", err)
+	}
+}
+
+// spacing returns the time between replies in the subreddit.
+func (o *Outbox) spacing(subreddit string) time.Duration {
+	limit := o.perMinute
+	if l, ok := o.subreddits[subreddit]; ok {
+		limit = l
+	}
+
+	spacing := time.Duration(0)
+	if limit > 0 {
+		spacing = time.Minute / time.Duration(limit)
+	}
+	if spacing < o.cooldown {
+		spacing = o.cooldown
+	}
+	return spacing
+}
@@ -0,0 +1,108 @@
+package outbox
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/turnage/graw/reddit"
+)
+
+type replyRecorder struct {
+	reddit.Account
+	replies []string
+	err     error
+}
+
+func (r *replyRecorder) Reply(parentName, text string) error {
+	if r.err != nil {
+		return r.err
+	}
+	r.replies = append(r.replies, parentName)
+	return nil
+}
+
+func TestFlushSpacesReplies(t *testing.T) {
+	acct := &replyRecorder{}
+	o := newOutbox(acct, Config{
+		PerMinute:  2,
+		Subreddits: map[string]int{"AskReddit": 1},
+	})
+
+	for _, reply := range [][2]string{
+		{"golang", "t1_a"},
+		{"golang", "t1_b"},
+		{"askreddit", "t1_c"},
+		{"askreddit", "t1_d"},
+		{"golang", "t1_e"},
+	} {
+		if err := o.Reply(reply[0], reply[1], "hi"); err != nil {
+			t.Fatalf("error queueing: %v", err)
+		}
+	}
+
+	start := time.Now()
+	for _, test := range []struct {
+		at      time.Duration
+		replies int
+		wait    time.Duration
+	}{
+		// One reply per subreddit is sent at once.
+		{0, 2, 30 * time.Second},
+		// golang allows another after 30s, AskReddit after a minute.
+		{30 * time.Second, 3, 30 * time.Second},
+		{45 * time.Second, 3, 15 * time.Second},
+		{time.Minute, 5, 30 * time.Second},
+	} {
+		wait := o.flush(start.Add(test.at))
+		if len(acct.replies) != test.replies {
+			t.Errorf(
+				"at %v: sent %v; wanted %d replies",
+				test.at, acct.replies, test.replies,
+			)
+		}
+		if wait != test.wait {
+			t.Errorf("at %v: got wait %v; wanted %v", test.at, wait, test.wait)
+		}
+	}
+
+	want := []string{"t1_a", "t1_c", "t1_b", "t1_d", "t1_e"}
+	if fmt.Sprint(acct.replies) != fmt.Sprint(want) {
+		t.Errorf("sent %v; wanted %v", acct.replies, want)
+	}
+}
+
+func TestFlushGivesUp(t *testing.T) {
+	acct := &replyRecorder{err: fmt.Errorf("deleted")}
+	o := newOutbox(acct, Config{MaxAttempts: 2})
+	o.Reply("golang", "t1_a", "hi")
+
+	now := time.Now()
+	for i := 0; i < 2; i++ {
+		o.flush(now)
+	}
+
+	if pending, _ := o.store.Pending(); len(pending) != 0 {
+		t.Errorf("reply still queued after its attempts: %v", pending)
+	}
+}
+
+func TestFlushCooldown(t *testing.T) {
+	acct := &replyRecorder{}
+	o := newOutbox(acct, Config{PerMinute: 2, Cooldown: 10 * time.Minute})
+	o.Reply("golang", "t1_a", "hi")
+	o.Reply("golang", "t1_b", "hi")
+
+	start := time.Now()
+	if wait := o.flush(start); wait != idleWait {
+		t.Errorf("got wait %v; wanted %v", wait, idleWait)
+	}
+	o.flush(start.Add(5 * time.Minute))
+	if len(acct.replies) != 1 {
+		t.Errorf("sent %v within the cooldown; wanted one reply", acct.replies)
+	}
+	o.flush(start.Add(10 * time.Minute))
+	if len(acct.replies) != 2 {
+		t.Errorf("sent %v after the cooldown; wanted two replies", acct.replies)
+	}
+}
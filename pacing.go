@@ -0,0 +1,161 @@
+package graw
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/turnage/graw/clock"
+	"github.com/turnage/graw/reddit"
+)
+
+const defaultPacingJitter = 0.1
+
+// Pacing spreads the polls of a run's feeds across an interval. Unpaced, every
+// feed polls as often as the handle's rate allows, so the feeds queue on the
+// handle together and the bot's own requests, such as its replies, wait behind
+// a poll of each of them. Paced, each feed polls once per interval, at an
+// offset of its own, leaving the handle idle between polls for the bot.
+//
+// The listing feeds (subreddits, subreddit comments, users, and the inbox),
+// rankings, and threads are paced.
+type Pacing struct {
+	// Interval is how often each feed is polled, e.g. 30 seconds. If
+	// zero, feeds are not paced.
+	Interval time.Duration
+	// Jitter is the fraction of the interval each poll moves by at random,
+	// so feeds which were started together drift apart. The default is
+	// 0.1.
+	Jitter float64
+}
+
+// pacer holds a feed to its pace.
+type pacer struct {
+	p     Pacing
+	clock clock.Clock
+	kill  <-chan bool
+	next  time.Time
+	mu    *sync.Mutex
+}
+
+// newPacer returns a pacer for one feed, which stops holding it back once the
+// kill channel closes, or nil if feeds are not paced.
+func (p Pacing) newPacer(clk clock.Clock, kill <-chan bool) *pacer {
+	if p.Interval <= 0 {
+		return nil
+	}
+	if p.Jitter <= 0 {
+		p.Jitter = defaultPacingJitter
+	}
+
+	return &pacer{p: p, clock: clk, kill: kill, mu: &sync.Mutex{}}
+}
+
+// scanner returns the scanner with its polls paced.
+func (p Pacing) scanner(
+	sc reddit.Scanner,
+	clk clock.Clock,
+	kill <-chan bool,
+) reddit.Scanner {
+	pc := p.newPacer(clk, kill)
+	if pc == nil {
+		return sc
+	}
+	return &pacedScanner{Scanner: sc, pc: pc}
+}
+
+// lurker returns the lurker with its thread polls paced.
+func (p Pacing) lurker(
+	l reddit.Lurker,
+	clk clock.Clock,
+	kill <-chan bool,
+) reddit.Lurker {
+	pc := p.newPacer(clk, kill)
+	if pc == nil {
+		return l
+	}
+	return &pacedLurker{Lurker: l, pc: pc}
+}
+
+// wait blocks until the feed's next poll is due. A feed's first poll is made
+// at once, and its second at a random point in the interval after, so feeds
+// started together are spread across the interval from then on.
+func (pc *pacer) wait() {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+
+	if pc.next.IsZero() {
+		pc.next = pc.clock.Now().Add(
+			time.Duration(rand.Int63n(int64(pc.p.Interval))),
+		)
+		return
+	}
+
+	if delay := pc.next.Sub(pc.clock.Now()); delay > 0 {
+		due := make(chan struct{})
+		timer := pc.clock.AfterFunc(delay, func() { close(due) })
+		select {
+		case <-due:
+		case <-pc.kill:
+			timer.Stop()
+		}
+	}
+
+	// A feed which fell behind, e.g. because the handle was busy, is not
+	// polled in a burst to catch up.
+	now := pc.clock.Now()
+	if pc.next.Before(now) {
+		pc.next = now
+	}
+	pc.next = pc.next.Add(pc.interval())
+}
+
+// interval returns the pacing interval, moved by up to the jitter.
+func (pc *pacer) interval() time.Duration {
+	shift := pc.p.Jitter * (2*rand.Float64() - 1)
+	return time.Duration(float64(pc.p.Interval) * (1 + shift))
+}
+
+type pacedScanner struct {
+	reddit.Scanner
+	pc *pacer
+}
+
+func (s *pacedScanner) Listing(path, after string) (reddit.Harvest, error) {
+	s.pc.wait()
+	return s.Scanner.Listing(path, after)
+}
+
+func (s *pacedScanner) ListingWithParams(
+	path string,
+	params map[string]string,
+) (reddit.Harvest, error) {
+	s.pc.wait()
+	return s.Scanner.ListingWithParams(path, params)
+}
+
+func (s *pacedScanner) ListingWithOptions(
+	path string,
+	opts reddit.ListingOptions,
+) (reddit.Harvest, error) {
+	s.pc.wait()
+	return s.Scanner.ListingWithOptions(path, opts)
+}
+
+type pacedLurker struct {
+	reddit.Lurker
+	pc *pacer
+}
+
+func (l *pacedLurker) Thread(permalink string) (*reddit.Post, error) {
+	l.pc.wait()
+	return l.Lurker.Thread(permalink)
+}
+
+func (l *pacedLurker) ThreadWithOptions(
+	permalink string,
+	opts reddit.ThreadOptions,
+) (*reddit.Post, error) {
+	l.pc.wait()
+	return l.Lurker.ThreadWithOptions(permalink, opts)
+}
@@ -0,0 +1,104 @@
+package graw
+
+import (
+	"testing"
+	"time"
+
+	"github.com/turnage/graw/clock"
+)
+
+func TestPacerSpreadsPolls(t *testing.T) {
+	interval := 40 * time.Millisecond
+	kill := make(chan bool)
+	pc := Pacing{Interval: interval, Jitter: 0.25}.newPacer(
+		clock.Real,
+		kill,
+	)
+
+	start := time.Now()
+	pc.wait()
+	if elapsed := time.Since(start); elapsed > 10*time.Millisecond {
+		t.Errorf("first poll waited %v; wanted none", elapsed)
+	}
+
+	// The second poll is somewhere in the interval after the first, and
+	// later polls an interval, give or take the jitter, apart.
+	pc.wait()
+	second := time.Now()
+	if elapsed := second.Sub(start); elapsed > interval+10*time.Millisecond {
+		t.Errorf("second poll waited %v; wanted at most %v", elapsed, interval)
+	}
+
+	pc.wait()
+	gap := time.Since(second)
+	if gap < 30*time.Millisecond || gap > 60*time.Millisecond {
+		t.Errorf("got %v between polls; wanted 30ms to 50ms", gap)
+	}
+
+	close(kill)
+	start = time.Now()
+	pc.wait()
+	pc.wait()
+	if elapsed := time.Since(start); elapsed > 10*time.Millisecond {
+		t.Errorf("polls after kill waited %v; wanted none", elapsed)
+	}
+}
+
+func TestPacerDoesNotCatchUp(t *testing.T) {
+	interval := 20 * time.Millisecond
+	pc := Pacing{Interval: interval}.newPacer(clock.Real, make(chan bool))
+	pc.wait()
+
+	// A feed held up for several intervals polls once at once, then
+	// resumes its pace rather than bursting.
+	time.Sleep(5 * interval)
+	start := time.Now()
+	pc.wait()
+	pc.wait()
+	if elapsed := time.Since(start); elapsed < interval*8/10 {
+		t.Errorf("got %v for two polls after a delay; wanted a pause", elapsed)
+	}
+}
+
+func TestPacingDisabled(t *testing.T) {
+	listing := &growingListing{}
+	if sc := (Pacing{}).scanner(listing, clock.Real, nil); sc != listing {
+		t.Errorf("unpaced scanner was wrapped")
+	}
+}
+
+func TestPacerOnVirtualClock(t *testing.T) {
+	interval := time.Minute
+	v := clock.NewVirtual(time.Unix(1486847000, 0))
+	pc := Pacing{Interval: interval}.newPacer(v, make(chan bool))
+	pc.wait()
+
+	polled := make(chan bool)
+	poll := func() {
+		go func() {
+			pc.wait()
+			polled <- true
+		}()
+		v.BlockUntil(1)
+	}
+
+	// The second poll is within an interval of the first.
+	poll()
+	v.Advance(interval)
+	<-polled
+
+	// Later polls are an interval apart, give or take the jitter.
+	poll()
+	v.Advance(interval * 85 / 100)
+	select {
+	case <-polled:
+		t.Fatalf("poll was made early")
+	default:
+	}
+	v.Advance(interval * 30 / 100)
+	select {
+	case <-polled:
+	case <-time.After(time.Second):
+		t.Fatalf("poll was not made after an interval")
+	}
+}
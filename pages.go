@@ -0,0 +1,73 @@
+package graw
+
+import (
+	"log"
+
+	"github.com/turnage/graw/reddit"
+	"github.com/turnage/graw/unfurl"
+)
+
+const (
+	// pageWorkers is the number of pages fetched at once.
+	pageWorkers = 4
+	// pageBacklog is the most posts waiting for their pages to be fetched;
+	// past it, the pages of new posts are not fetched.
+	pageBacklog = 100
+)
+
+// pageFetcher fetches the pages link posts point to in the background, so slow
+// sites do not hold up the feed the posts came from.
+type pageFetcher struct {
+	f      *unfurl.Fetcher
+	posts  chan *reddit.Post
+	logger *log.Logger
+}
+
+func newPageFetcher(f *unfurl.Fetcher, logger *log.Logger) *pageFetcher {
+	return &pageFetcher{
+		f:      f,
+		posts:  make(chan *reddit.Post, pageBacklog),
+		logger: logger,
+	}
+}
+
+// fetch queues the post for its page to be fetched. Its page is not fetched if
+// too many posts are waiting.
+func (p *pageFetcher) fetch(post *reddit.Post) {
+	select {
+	case p.posts <- post:
+	default:
+		p.logger.Printf("Too many pages waiting; not fetching %s", post.URL)
+	}
+}
+
+// run fetches the pages of queued posts and hands them to found until the kill
+// channel closes. Pages which cannot be fetched are logged and skipped; they
+// are not the bot's failures.
+func (p *pageFetcher) run(
+	kill <-chan bool,
+	found func(*reddit.Post, *unfurl.Page),
+) {
+	for i := 0; i < pageWorkers; i++ {
+		go func() {
+			for {
+				select {
+				case <-kill:
+					return
+				case post := <-p.posts:
+					page, err := p.f.FetchPost(post)
+					switch err {
+					case nil:
+						found(post, page)
+					case unfurl.NoLinkErr:
+					default:
+						p.logger.Printf(
+							"Could not fetch %s: %v",
+							post.URL, err,
+						)
+					}
+				}
+			}
+		}()
+	}
+}
@@ -0,0 +1,56 @@
+package graw
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/turnage/graw/reddit"
+	"github.com/turnage/graw/unfurl"
+)
+
+func TestPageFetcher(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/html")
+			fmt.Fprintf(w, "<title>page %s</title>", r.URL.Path)
+		},
+	))
+	defer srv.Close()
+
+	f := unfurl.NewFetcher(unfurl.FetcherConfig{
+		HostInterval: time.Millisecond,
+		IgnoreRobots: true,
+		Client:       srv.Client(),
+	})
+	pages := newPageFetcher(f, log.New(ioutil.Discard, "", 0))
+
+	kill := make(chan bool)
+	defer close(kill)
+	found := make(chan string)
+	pages.run(kill, func(p *reddit.Post, page *unfurl.Page) {
+		found <- p.Name + ": " + page.Title
+	})
+
+	pages.fetch(&reddit.Post{Name: "t3_self", IsSelf: true})
+	pages.fetch(&reddit.Post{Name: "t3_link", URL: srv.URL + "/a"})
+
+	select {
+	case got := <-found:
+		if got != "t3_link: page /a" {
+			t.Errorf("got %q; wanted t3_link: page /a", got)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("page was not fetched")
+	}
+
+	select {
+	case got := <-found:
+		t.Errorf("got unexpected page %q", got)
+	case <-time.After(20 * time.Millisecond):
+	}
+}
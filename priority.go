@@ -0,0 +1,123 @@
+package graw
+
+import (
+	"sync"
+	"time"
+
+	"github.com/turnage/graw/reddit"
+)
+
+const defaultPriorityWeight = 4
+
+// threadTurns orders the polls of watched threads, so priority threads are
+// polled more often than the rest within the handle's rate. Threads poll one
+// at a time; while priority threads are waiting, up to weight of them poll in
+// a row before another thread gets a turn.
+type threadTurns struct {
+	weight int
+	// waiting counts the threads waiting for a turn, other threads first
+	// and priority threads second.
+	waiting [2]int
+	// streak is the number of priority polls made in a row.
+	streak int
+	busy   bool
+	cond   *sync.Cond
+}
+
+// newThreadTurns returns turns for a run's threads, or nil if they do not need
+// ordering because none, or all, are priority threads.
+func newThreadTurns(c Config) *threadTurns {
+	if len(c.Threads) == 0 || len(c.PriorityThreads) == 0 {
+		return nil
+	}
+
+	weight := c.PriorityWeight
+	if weight <= 0 {
+		weight = defaultPriorityWeight
+	}
+	return &threadTurns{weight: weight, cond: sync.NewCond(&sync.Mutex{})}
+}
+
+// take blocks until it is the thread's turn to poll. Callers must call done
+// when the poll is over.
+func (t *threadTurns) take(priority bool) {
+	class := 0
+	if priority {
+		class = 1
+	}
+
+	t.cond.L.Lock()
+	defer t.cond.L.Unlock()
+
+	t.waiting[class]++
+	for t.busy || !t.turn(priority) {
+		t.cond.Wait()
+	}
+	t.waiting[class]--
+
+	t.busy = true
+	if priority {
+		t.streak++
+	} else {
+		t.streak = 0
+	}
+}
+
+// turn returns true if a thread of the priority may poll next. Callers hold
+// the lock.
+func (t *threadTurns) turn(priority bool) bool {
+	if priority {
+		return t.waiting[0] == 0 || t.streak < t.weight
+	}
+	return t.waiting[1] == 0 || t.streak >= t.weight
+}
+
+// done ends the poll which holds the turn.
+func (t *threadTurns) done() {
+	t.cond.L.Lock()
+	defer t.cond.L.Unlock()
+
+	t.busy = false
+	t.cond.Broadcast()
+}
+
+// lurker returns the lurker with its thread polls taking turns.
+func (t *threadTurns) lurker(l reddit.Lurker, priority bool) reddit.Lurker {
+	if t == nil {
+		return l
+	}
+	return &turnLurker{Lurker: l, turns: t, priority: priority}
+}
+
+type turnLurker struct {
+	reddit.Lurker
+	turns    *threadTurns
+	priority bool
+}
+
+func (l *turnLurker) Thread(permalink string) (*reddit.Post, error) {
+	l.turns.take(l.priority)
+	defer l.turns.done()
+	return l.Lurker.Thread(permalink)
+}
+
+func (l *turnLurker) ThreadWithOptions(
+	permalink string,
+	opts reddit.ThreadOptions,
+) (*reddit.Post, error) {
+	l.turns.take(l.priority)
+	defer l.turns.done()
+	return l.Lurker.ThreadWithOptions(permalink, opts)
+}
+
+// priorityPacing returns the pacing of priority threads, which are polled
+// weight times as often as other feeds.
+func priorityPacing(c Config) Pacing {
+	p := c.Pacing
+	weight := c.PriorityWeight
+	if weight <= 0 {
+		weight = defaultPriorityWeight
+	}
+	p.Interval /= time.Duration(weight)
+	return p
+}
@@ -0,0 +1,91 @@
+package graw
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/turnage/graw/reddit"
+)
+
+// pollLog is a lurker which logs which threads it polls.
+type pollLog struct {
+	reddit.Lurker
+	polls []string
+	mu    sync.Mutex
+}
+
+func (l *pollLog) ThreadWithOptions(
+	permalink string,
+	opts reddit.ThreadOptions,
+) (*reddit.Post, error) {
+	l.mu.Lock()
+	l.polls = append(l.polls, permalink)
+	l.mu.Unlock()
+	time.Sleep(time.Millisecond)
+	return &reddit.Post{}, nil
+}
+
+func TestThreadTurnsFavorPriorityThreads(t *testing.T) {
+	turns := newThreadTurns(Config{
+		Threads:         []string{"normal"},
+		PriorityThreads: []string{"ama"},
+		PriorityWeight:  3,
+	})
+
+	log := &pollLog{}
+	stop := make(chan bool)
+	var wg sync.WaitGroup
+	for _, thread := range []string{"normal", "ama"} {
+		l := turns.lurker(log, thread == "ama")
+		wg.Add(1)
+		go func(thread string) {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+					l.ThreadWithOptions(thread, reddit.ThreadOptions{})
+				}
+			}
+		}(thread)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	close(stop)
+	wg.Wait()
+
+	counts := make(map[string]int)
+	for _, poll := range log.polls {
+		counts[poll]++
+	}
+	if counts["normal"] == 0 {
+		t.Fatalf("normal thread was starved: %v", counts)
+	}
+	ratio := float64(counts["ama"]) / float64(counts["normal"])
+	if ratio < 2.5 || ratio > 3.5 {
+		t.Errorf("got %v polls; wanted about 3 ama polls per normal", counts)
+	}
+}
+
+func TestThreadTurnsUnneeded(t *testing.T) {
+	for _, c := range []Config{
+		{Threads: []string{"a"}},
+		{PriorityThreads: []string{"a"}},
+	} {
+		if turns := newThreadTurns(c); turns != nil {
+			t.Errorf("got turns for %+v; wanted none", c)
+		}
+	}
+}
+
+func TestPriorityPacing(t *testing.T) {
+	p := priorityPacing(Config{
+		Pacing:         Pacing{Interval: time.Minute},
+		PriorityWeight: 6,
+	})
+	if p.Interval != 10*time.Second {
+		t.Errorf("got interval %v; wanted 10s", p.Interval)
+	}
+}
@@ -0,0 +1,79 @@
+package graw
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/turnage/graw/clock"
+	"github.com/turnage/graw/reddit"
+)
+
+// readInterval is how often handled inbox items are marked read.
+const readInterval = 10 * time.Second
+
+// readMarker marks inbox items read in batches, once the bot has handled them.
+type readMarker struct {
+	acct   reddit.Account
+	clock  clock.Clock
+	logger *log.Logger
+	// names are the handled items not yet marked read.
+	names []string
+	mu    *sync.Mutex
+}
+
+func newReadMarker(
+	acct reddit.Account,
+	clk clock.Clock,
+	logger *log.Logger,
+) *readMarker {
+	return &readMarker{
+		acct:   acct,
+		clock:  clk,
+		logger: logger,
+		mu:     &sync.Mutex{},
+	}
+}
+
+// add queues the item to be marked read.
+func (r *readMarker) add(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.names = append(r.names, name)
+}
+
+// run marks queued items read every interval until it is killed, and once
+// more as it stops.
+func (r *readMarker) run(kill <-chan bool, interval time.Duration) {
+	for {
+		select {
+		case <-kill:
+			r.flush()
+			return
+		case <-r.clock.After(interval):
+			r.flush()
+		}
+	}
+}
+
+// flush marks the queued items read. If that fails, they stay queued for the
+// next flush.
+func (r *readMarker) flush() {
+	r.mu.Lock()
+	names := r.names
+	r.names = nil
+	r.mu.Unlock()
+
+	if len(names) == 0 {
+		return
+	}
+
+	if err := r.acct.MarkRead(names...); err != nil {
+		r.logger.Printf("Failed to mark inbox items read: %v", err)
+
+		r.mu.Lock()
+		r.names = append(names, r.names...)
+		r.mu.Unlock()
+	}
+}
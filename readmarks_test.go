@@ -0,0 +1,42 @@
+package graw
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"testing"
+
+	"github.com/turnage/graw/clock"
+	"github.com/turnage/graw/reddit"
+)
+
+type readRecorder struct {
+	reddit.Account
+	marked [][]string
+	err    error
+}
+
+func (r *readRecorder) MarkRead(names ...string) error {
+	if r.err != nil {
+		return r.err
+	}
+	r.marked = append(r.marked, names)
+	return nil
+}
+
+func TestReadMarkerBatches(t *testing.T) {
+	acct := &readRecorder{err: fmt.Errorf("busy")}
+	r := newReadMarker(acct, clock.Real, log.New(ioutil.Discard, "", 0))
+
+	r.add("t4_a")
+	r.flush()
+	r.add("t1_b")
+
+	acct.err = nil
+	r.flush()
+	r.flush()
+
+	if fmt.Sprint(acct.marked) != "[[t4_a t1_b]]" {
+		t.Errorf("marked %v; wanted one batch of t4_a and t1_b", acct.marked)
+	}
+}
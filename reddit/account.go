@@ -1,5 +1,13 @@
 package reddit
 
+import (
+	"strconv"
+	"strings"
+)
+
+// readBatch is the most inbox items marked read in one request.
+const readBatch = 100
+
 // Account defines behaviors only an account can perform on Reddit.
 type Account interface {
 	// Reply posts a reply to something on reddit. The behavior depends on
@@ -11,38 +19,122 @@ type Account interface {
 	//
 	// Use .Name on the parent post, message, or comment to find its
 	// name.
+	//
+	// If the text is too long for one comment, it is split into a chain
+	// of comments as configured by the BotConfig's Split field. Each part
+	// after the first replies to the part before it.
 	Reply(parentName, text string) error
 
 	// SendMessage sends a private message to a user.
 	SendMessage(user, subject, text string) error
 
+	// MarkRead marks items in the bot's inbox read. Any number of names
+	// may be given; they are marked in as few requests as possible.
+	MarkRead(names ...string) error
+
+	// MarkUnread marks items in the bot's inbox unread.
+	MarkUnread(names ...string) error
+
+	// Delete deletes a post or comment the bot made.
+	Delete(name string) error
+
+	// Approve approves a post or comment in a subreddit the bot
+	// moderates, restoring it if it was removed.
+	Approve(name string) error
+
+	// Remove removes a post or comment from a subreddit the bot
+	// moderates. If spam is set, it also trains the subreddit's spam
+	// filter on it.
+	Remove(name string, spam bool) error
+
+	// Ban bans a user from a subreddit the bot moderates (e.g. golang)
+	// for a number of days, or permanently if days is 0, with a reason
+	// shown to the other moderators.
+	Ban(subreddit, user string, days int, reason string) error
+
+	// Unban lifts a user's ban from a subreddit the bot moderates.
+	Unban(subreddit, user string) error
+
 	// PostSelf makes a text (self) post to a subreddit.
 	PostSelf(subreddit, title, text string) error
 
 	// PostLink makes a link post to a subreddit.
 	PostLink(subreddit, title, url string) error
+
+	// Me returns the account the bot is logged in as.
+	Me() (*Redditor, error)
+
+	// Limits probes the restrictions Reddit places on the account's
+	// writes, such as captchas and cooldowns for young accounts, so the
+	// bot can pace itself; see Limits.Cooldown. Probe once on startup.
+	Limits() (Limits, error)
+
+	// LinkFlairTemplates and UserFlairTemplates return the flairs the
+	// subreddit (e.g. golang) offers for posts and users.
+	LinkFlairTemplates(subreddit string) ([]FlairTemplate, error)
+	UserFlairTemplates(subreddit string) ([]FlairTemplate, error)
+
+	// Emojis returns the emojis usable in the subreddit's flair: its own,
+	// then Reddit's.
+	Emojis(subreddit string) ([]Emoji, error)
+
+	// Scopes returns the OAuth scopes the bot was granted. Operations
+	// which need a scope the bot lacks fail with a *ScopeError before
+	// any request is made.
+	Scopes() []string
+
+	// Triggered returns the account, with the writes made through it
+	// recorded in the BotConfig's Audit log as prompted by the event
+	// about the named thing, e.g. the mention a handler is answering:
+	//
+	//	bot.Triggered(mention.Name).Reply(mention.Name, "hello!")
+	//
+	// Handlers run concurrently, so each passes the name of the thing it
+	// is handling rather than the account guessing it.
+	Triggered(name string) Account
 }
 
 type account struct {
 	// r is used to execute requests to Reddit.
 	r reaper
+	// split configures how replies too long for one comment are split.
+	split SplitConfig
 }
 
 // newAccount returns a new Account using the given reaper to make requests
 // to Reddit.
-func newAccount(r reaper) Account {
+func newAccount(r reaper, split SplitConfig) Account {
 	return &account{
-		r: r,
+		r:     r,
+		split: split,
 	}
 }
 
 func (a *account) Reply(parentName, text string) error {
-	return a.r.sow(
-		"/api/comment", map[string]string{
-			"thing_id": parentName,
-			"text":     text,
-		},
-	)
+	parts := a.split.split(text)
+	if len(parts) == 1 {
+		return a.r.sow(
+			"/api/comment", map[string]string{
+				"thing_id": parentName,
+				"text":     text,
+			},
+		)
+	}
+
+	for _, part := range parts {
+		reply, err := a.r.plant(
+			"/api/comment", map[string]string{
+				"thing_id": parentName,
+				"text":     part,
+			},
+		)
+		if err != nil {
+			return err
+		}
+		parentName = reply.name
+	}
+
+	return nil
 }
 
 func (a *account) SendMessage(user, subject, text string) error {
@@ -55,6 +147,75 @@ func (a *account) SendMessage(user, subject, text string) error {
 	)
 }
 
+func (a *account) MarkRead(names ...string) error {
+	return a.mark("/api/read_message", names)
+}
+
+func (a *account) MarkUnread(names ...string) error {
+	return a.mark("/api/unread_message", names)
+}
+
+// mark marks inbox items read or unread, in batches.
+func (a *account) mark(path string, names []string) error {
+	for start := 0; start < len(names); start += readBatch {
+		end := start + readBatch
+		if end > len(names) {
+			end = len(names)
+		}
+
+		if err := a.r.sow(
+			path, map[string]string{
+				"id": strings.Join(names[start:end], ","),
+			},
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (a *account) Delete(name string) error {
+	return a.r.sow("/api/del", map[string]string{"id": name})
+}
+
+func (a *account) Approve(name string) error {
+	return a.r.sow("/api/approve", map[string]string{"id": name})
+}
+
+func (a *account) Remove(name string, spam bool) error {
+	return a.r.sow(
+		"/api/remove", map[string]string{
+			"id":   name,
+			"spam": strconv.FormatBool(spam),
+		},
+	)
+}
+
+func (a *account) Ban(subreddit, user string, days int, reason string) error {
+	values := map[string]string{
+		"type":       "banned",
+		"name":       user,
+		"ban_reason": reason,
+	}
+	if days > 0 {
+		values["duration"] = strconv.Itoa(days)
+	}
+	return a.r.sow("/r/"+subreddit+"/api/friend", values)
+}
+
+func (a *account) Unban(subreddit, user string) error {
+	return a.r.sow(
+		"/r/"+subreddit+"/api/unfriend", map[string]string{
+			"type": "banned",
+			"name": user,
+		},
+	)
+}
+
+func (a *account) Triggered(name string) Account {
+	return &account{r: triggered(a.r, name), split: a.split}
+}
+
 func (a *account) PostSelf(subreddit, title, text string) error {
 	return a.r.sow(
 		"/api/submit", map[string]string{
@@ -76,3 +237,16 @@ func (a *account) PostLink(subreddit, title, url string) error {
 		},
 	)
 }
+
+func (a *account) Me() (*Redditor, error) {
+	blob, err := a.r.reapRaw("/api/v1/me", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseRedditor(blob)
+}
+
+func (a *account) Scopes() []string {
+	return a.r.granted()
+}
@@ -6,17 +6,19 @@ import (
 
 // agentForward forwards a user agent in all requests made by the Transport.
 type agentForwarder struct {
-	http.Transport
+	next  http.RoundTripper
 	agent string
 }
 
 // RoundTrip sets a predefined agent in the request and then forwards it to the
-// default RountTrip implementation.
+// transport.
 func (a *agentForwarder) RoundTrip(r *http.Request) (*http.Response, error) {
 	r.Header.Add("User-Agent", a.agent)
-	return a.Transport.RoundTrip(r)
+	return a.next.RoundTrip(r)
 }
 
-func clientWithAgent(agent string) *http.Client {
-	return &http.Client{Transport: &agentForwarder{agent: agent}}
+func clientWithAgent(agent string, t TransportConfig) *http.Client {
+	return &http.Client{
+		Transport: &agentForwarder{next: t.roundTripper(), agent: agent},
+	}
 }
@@ -2,6 +2,7 @@ package reddit
 
 import (
 	"net/http"
+	"time"
 
 	"golang.org/x/net/context"
 	"golang.org/x/oauth2"
@@ -16,14 +17,34 @@ var oauthScopes = []string{
 	"history",
 }
 
+func (c clientConfig) requestedScopes() []string {
+	if len(c.scopes) == 0 {
+		return oauthScopes
+	}
+	return c.scopes
+}
+
 type appClient struct {
 	baseClient
 	cfg clientConfig
 	cli *http.Client
+	// src, if set, is the source of the bot's password grant tokens.
+	src *refreshingSource
+	// granted are the OAuth scopes Reddit granted the bot.
+	granted []string
 }
 
 func (a *appClient) Do(req *http.Request) ([]byte, error) {
-	return a.baseClient.Do(req)
+	start := time.Now()
+	resp, err := a.baseClient.Do(req)
+	if err == UnauthorizedErr && a.src != nil {
+		// The token lapsed early or was revoked; get a new one and try
+		// once more. Requests reach Reddit with their parameters in the
+		// url, so they are safe to send again.
+		a.src.invalidate(start)
+		return a.baseClient.Do(req)
+	}
+	return resp, err
 }
 
 func (a *appClient) authorize() error {
@@ -38,26 +59,47 @@ func (a *appClient) authorize() error {
 		ClientID:     a.cfg.app.ID,
 		ClientSecret: a.cfg.app.Secret,
 		Endpoint:     oauth2.Endpoint{TokenURL: a.cfg.app.tokenURL},
-		Scopes:       oauthScopes,
+		Scopes:       a.cfg.requestedScopes(),
+	}
+
+	if a.cfg.app.Token != nil {
+		a.granted = grantedScopes(a.cfg.app.Token, cfg.Scopes)
+		a.baseClient.cli = cfg.Client(ctx, a.cfg.app.Token)
+		return nil
+	}
+
+	var src oauth2.TokenSource = &passwordSource{
+		ctx:      ctx,
+		cfg:      cfg,
+		username: a.cfg.app.Username,
+		password: a.cfg.app.Password,
+		otp:      a.cfg.otp,
 	}
 
 	var token *oauth2.Token
-	var err error
+	if a.cfg.tokens != nil {
+		key := tokenKey(a.cfg.app)
+		stored, err := loadToken(a.cfg.tokens, key)
+		if err != nil {
+			return err
+		}
 
-	if a.cfg.app.Token != nil {
-		token = a.cfg.app.Token
-		err = nil
-	} else {
-		token, err = cfg.PasswordCredentialsToken(
-			ctx,
-			a.cfg.app.Username,
-			a.cfg.app.Password,
-		)
+		token = stored
+		src = newStoredSource(src, a.cfg.tokens, key)
 	}
 
-	a.baseClient.cli = cfg.Client(ctx, token)
+	if !token.Valid() {
+		fresh, err := src.Token()
+		if err != nil {
+			return err
+		}
+		token = fresh
+	}
 
-	return err
+	a.granted = grantedScopes(token, cfg.Scopes)
+	a.src = newRefreshingSource(token, src, a.cfg.refreshEarly)
+	a.baseClient.cli = oauth2.NewClient(ctx, a.src)
+	return nil
 }
 
 func (a *appClient) clientCredentialsClient(ctx context.Context) *http.Client {
@@ -65,7 +107,7 @@ func (a *appClient) clientCredentialsClient(ctx context.Context) *http.Client {
 		ClientID:     a.cfg.app.ID,
 		ClientSecret: a.cfg.app.Secret,
 		TokenURL:     a.cfg.app.tokenURL,
-		Scopes:       oauthScopes,
+		Scopes:       a.cfg.requestedScopes(),
 	}
 
 	return cfg.Client(ctx)
@@ -73,7 +115,7 @@ func (a *appClient) clientCredentialsClient(ctx context.Context) *http.Client {
 
 func newAppClient(c clientConfig) (*appClient, error) {
 	a := &appClient{
-		cli: clientWithAgent(c.agent),
+		cli: c.httpClient(),
 		cfg: c,
 	}
 	return a, a.authorize()
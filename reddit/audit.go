@@ -0,0 +1,106 @@
+package reddit
+
+import (
+	"log"
+	"time"
+
+	"github.com/turnage/graw/store"
+)
+
+// auditReaper records every write made through it to an audit log.
+type auditReaper struct {
+	reaper
+	audit  store.Audit
+	logger *log.Logger
+	// trigger is the name of the thing whose event prompted the writes,
+	// if it is known.
+	trigger string
+}
+
+func newAuditReaper(r reaper, audit store.Audit, logger *log.Logger) reaper {
+	return &auditReaper{reaper: r, audit: audit, logger: logger}
+}
+
+// triggerer is a reaper which can record the writes made through it as
+// prompted by an event.
+type triggerer interface {
+	triggered(name string) reaper
+}
+
+// triggered returns the reaper, recording the writes made through it as
+// prompted by the event about the named thing, if it records writes at all.
+func triggered(r reaper, name string) reaper {
+	if t, ok := r.(triggerer); ok {
+		return t.triggered(name)
+	}
+	return r
+}
+
+func (a *auditReaper) triggered(name string) reaper {
+	t := *a
+	t.trigger = name
+	return &t
+}
+
+// creates are the endpoints whose writes create things. They are made through
+// plant, so the name of what they created is recorded and they can be undone.
+var creates = map[string]bool{
+	"/api/comment": true,
+	"/api/submit":  true,
+}
+
+func (a *auditReaper) sow(path string, values map[string]string) error {
+	if creates[path] {
+		_, err := a.plant(path, values)
+		return err
+	}
+
+	err := a.reaper.sow(path, values)
+	a.record(path, values, "", err)
+	return err
+}
+
+func (a *auditReaper) plant(
+	path string,
+	values map[string]string,
+) (submission, error) {
+	s, err := a.reaper.plant(path, values)
+	a.record(path, values, s.name, err)
+	return s, err
+}
+
+// record records a write. A write which cannot be recorded has already been
+// made, so the failure is logged rather than returned; returning it would
+// invite the bot to make the write again.
+func (a *auditReaper) record(
+	path string,
+	values map[string]string,
+	result string,
+	err error,
+) {
+	action := store.Action{
+		Time:    time.Now(),
+		Path:    path,
+		Target:  targetOf(values),
+		Values:  values,
+		Trigger: a.trigger,
+		Result:  result,
+	}
+	if err != nil {
+		action.Err = err.Error()
+	}
+
+	if _, err := a.audit.Record(action); err != nil {
+		a.logger.Printf("Failed to record %s in audit log: %v", path, err)
+	}
+}
+
+// targetOf returns what a write with the parameters acts on.
+func targetOf(values map[string]string) string {
+	for _, key := range []string{"thing_id", "id", "to", "sr", "name"} {
+		if target, ok := values[key]; ok {
+			return target
+		}
+	}
+	return ""
+}
@@ -0,0 +1,70 @@
+package reddit
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/turnage/graw/store"
+)
+
+func TestAuditReaper(t *testing.T) {
+	r := reaperWhich(Harvest{}, nil)
+	audit := store.NewMemory()
+	a := newAccount(
+		newAuditReaper(r, audit, log.New(ioutil.Discard, "", 0)),
+		SplitConfig{},
+	)
+
+	if err := a.Triggered("t4_m").Reply("t1_a", "hi"); err != nil {
+		t.Fatalf("error replying: %v", err)
+	}
+	r.err = fmt.Errorf("forbidden")
+	a.SendMessage("spez", "hello", "hi")
+
+	actions, err := audit.Actions(time.Time{})
+	if err != nil {
+		t.Fatalf("error reading audit log: %v", err)
+	}
+	if len(actions) != 2 {
+		t.Fatalf("got actions %+v; wanted two", actions)
+	}
+
+	reply, message := actions[0], actions[1]
+	if reply.Path != "/api/comment" || reply.Target != "t1_a" ||
+		reply.Trigger != "t4_m" || reply.Values["text"] != "hi" ||
+		reply.Err != "" {
+		t.Errorf("reply recorded incorrectly: %+v", reply)
+	}
+	if message.Path != "/api/compose" || message.Target != "spez" ||
+		message.Trigger != "" || message.Err != "forbidden" {
+		t.Errorf("failed message recorded incorrectly: %+v", message)
+	}
+}
+
+func TestAuditTriggerThroughGuards(t *testing.T) {
+	audit := store.NewMemory()
+	book := newLockBook()
+	r := &lockReaper{
+		reaper: newAuditReaper(
+			reaperWhich(Harvest{}, nil),
+			audit,
+			log.New(ioutil.Discard, "", 0),
+		),
+		book: book,
+	}
+	g := &lockGuard{Account: newAccount(r, SplitConfig{}), book: book}
+
+	if err := g.Triggered("t4_m").Reply("t1_a", "hi"); err != nil {
+		t.Fatalf("error replying: %v", err)
+	}
+	actions, err := audit.Actions(time.Time{})
+	if err != nil {
+		t.Fatalf("error reading audit log: %v", err)
+	}
+	if len(actions) != 1 || actions[0].Trigger != "t4_m" {
+		t.Errorf("got actions %+v; wanted one triggered by t4_m", actions)
+	}
+}
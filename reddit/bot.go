@@ -1,13 +1,26 @@
 package reddit
 
 import (
+	"io/ioutil"
+	"log"
+	"os"
 	"time"
+
+	"github.com/turnage/graw/store"
+	"github.com/turnage/graw/trace"
 )
 
+// RateLimiter spaces requests to Reddit. Reddit's limits are per account, so
+// processes sharing an account must share the limit; see BotConfig.Limiter.
+type RateLimiter interface {
+	// Wait blocks until a request may be made.
+	Wait() error
+}
+
 // BotConfig configures a Reddit bot's behavior with the Reddit package.
 type BotConfig struct {
 	// Agent is the user-agent sent in all requests the bot makes through
-	// this package.
+	// this package. It is required; build it with UserAgent.
 	Agent string
 	// App is the information for your registration on Reddit.
 	// If you are not familiar with this, read:
@@ -18,6 +31,63 @@ type BotConfig struct {
 	// rules cap OAuth2 clients at 60 requests per minute. See package
 	// overview for rate limit information.
 	Rate time.Duration
+	// Limiter, if set, is waited on before every request, in addition to
+	// Rate. Processes which share an account should share a limiter, such
+	// as the one in graw/store/redis, so together they stay within
+	// Reddit's limits.
+	Limiter RateLimiter
+	// Split configures how replies too long for a single comment are
+	// split into a chain of comments. The zero value splits on paragraphs,
+	// lines, sentences, and words, in that order of preference.
+	Split SplitConfig
+	// Scopes are the OAuth scopes the bot requests when it logs in. If
+	// empty, graw requests identity, read, privatemessages, submit, and
+	// history.
+	Scopes []string
+	// Tokens, if set, stores the bot's OAuth token so it is reused across
+	// runs instead of requested anew each start.
+	Tokens TokenStore
+	// Replies, if set, records the things the bot has replied to, and
+	// Reply does nothing when asked to reply to one of them again, e.g.
+	// because an event was redelivered after a restart. The SeenSet in
+	// graw/store satisfies it.
+	Replies ReplyLedger
+	// CheckReplies, if true, has Reply look through the bot's recent
+	// comments (or sent messages) on Reddit for a reply to the parent
+	// before replying. This costs a request per reply, but catches
+	// replies made before they could be recorded, e.g. just before a
+	// crash.
+	CheckReplies bool
+	// RefuseLocked, if true, has Reply fail with ThreadLockedErr or
+	// ThreadArchivedErr, without making a request, for parents which were
+	// locked or archived, or in a thread which was, when the bot last read
+	// them. Reddit rejects such replies anyway.
+	RefuseLocked bool
+	// Audit, if set, records every write the bot makes to Reddit: its
+	// endpoint, target, parameters, and outcome. The stores in graw/store
+	// satisfy it. Recorded actions can be reverted with Undo.
+	Audit store.Audit
+	// OTP, if set, provides two-factor authentication codes for accounts
+	// which have it enabled. See NewTOTP.
+	OTP OTP
+	// RefreshEarly is how long before it expires the bot's token is
+	// replaced, to allow for requests in flight and clock skew. The
+	// default is a minute.
+	RefreshEarly time.Duration
+	// Transport tunes the connections the bot makes requests over.
+	Transport TransportConfig
+	// Logger, if set, receives warnings about the bot's configuration,
+	// and failures to record writes to the Audit log.
+	Logger *log.Logger
+	// Debug, if true, logs every request's url and parameters, with
+	// credentials redacted, and the response code and rate limit headers
+	// of its response to Logger. This is spammy, but makes the API's
+	// behavior diagnosable.
+	Debug bool
+	// Tracer, if set, records a span for every request the bot makes,
+	// with its method, url (credentials redacted), status code, and the
+	// rate limit remaining. See graw/trace.
+	Tracer trace.Tracer
 }
 
 // Bot defines the behaviors of a logged in Reddit bot.
@@ -25,17 +95,50 @@ type Bot interface {
 	Account
 	Lurker
 	Scanner
+	Monitor
 }
 
 type bot struct {
 	Account
 	Lurker
 	Scanner
+	Monitor
 }
 
 // NewBot returns a logged in handle to the Reddit API.
 func NewBot(c BotConfig) (Bot, error) {
-	cli, err := newClient(clientConfig{agent: c.Agent, app: c.App})
+	if err := checkUserAgent(c.Agent, c.Logger); err != nil {
+		return nil, err
+	}
+
+	cc := clientConfig{
+		agent:  c.Agent,
+		app:    c.App,
+		scopes: c.Scopes,
+		tokens: c.Tokens,
+		otp:    c.OTP,
+		status: newStatusRecorder(),
+
+		refreshEarly: c.RefreshEarly,
+		transport:    c.Transport,
+		spans:        c.Tracer,
+	}
+	if c.Debug {
+		cc.trace = c.Logger
+		if cc.trace == nil {
+			cc.trace = log.New(os.Stderr, "graw: ", log.LstdFlags)
+		}
+	}
+	cli, err := newClient(cc)
+
+	var scopes []string
+	if !c.App.unauthenticated() {
+		scopes = cc.requestedScopes()
+		if app, ok := cli.(*appClient); ok && app.granted != nil {
+			scopes = app.granted
+		}
+	}
+
 	r := newReaper(
 		reaperConfig{
 			client:   cli,
@@ -43,29 +146,76 @@ func NewBot(c BotConfig) (Bot, error) {
 			hostname: "oauth.reddit.com",
 			tls:      true,
 			rate:     maxOf(c.Rate, time.Second),
+			limiter:  c.Limiter,
+			scopes:   scopes,
+			queue:    cc.status.queue,
 		},
 	)
+	if c.Audit != nil {
+		logger := c.Logger
+		if logger == nil {
+			logger = log.New(ioutil.Discard, "", 0)
+		}
+		r = newAuditReaper(r, c.Audit, logger)
+	}
+
+	var locks *lockBook
+	if c.RefuseLocked {
+		locks = newLockBook()
+		r = &lockReaper{reaper: r, book: locks}
+	}
+
+	acct := newAccount(r, c.Split)
+	if c.Replies != nil || c.CheckReplies {
+		acct = newReplyGuard(
+			acct,
+			r,
+			c.App.Username,
+			c.Replies,
+			c.CheckReplies,
+		)
+	}
+	if locks != nil {
+		acct = &lockGuard{Account: acct, book: locks}
+	}
+
 	return &bot{
-		Account: newAccount(r),
+		Account: acct,
 		Lurker:  newLurker(r),
 		Scanner: newScanner(r),
+		Monitor: cc.status,
 	}, err
 }
 
 // NewBotFromAgentFile calls NewBot with a config built from an agent file. An
 // agent file is a convenient way to store your bot's account information. See
 // https://github.com/turnage/graw/wiki/agent-files
+//
+// Agent files may also be JSON or sealed; see LoadCredentials. Those are
+// checked with Credentials.Validate first. Original agent files are not, so
+// the files bots already run with load as they always have.
 func NewBotFromAgentFile(filename string, rate time.Duration) (Bot, error) {
-	agent, app, err := load(filename)
+	creds, original, err := loadCredentials(filename)
 	if err != nil {
 		return nil, err
 	}
+	if original {
+		return NewBot(creds.BotConfig(rate))
+	}
 
-	return NewBot(
-		BotConfig{
-			Agent: agent,
-			App:   app,
-			Rate:  rate,
-		},
-	)
+	return newBotFromCredentials(creds, rate)
+}
+
+// NewBotFromEnv calls NewBot with a config built from the GRAW_* environment
+// variables; see CredentialsFromEnv.
+func NewBotFromEnv(rate time.Duration) (Bot, error) {
+	return newBotFromCredentials(CredentialsFromEnv(), rate)
+}
+
+func newBotFromCredentials(c Credentials, rate time.Duration) (Bot, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+
+	return NewBot(c.BotConfig(rate))
 }
@@ -3,7 +3,11 @@ package reddit
 import (
 	"bytes"
 	"fmt"
+	"log"
 	"net/http"
+	"time"
+
+	"github.com/turnage/graw/trace"
 )
 
 // tokenURL is the url of reddit's oauth2 authorization service.
@@ -18,6 +22,46 @@ type clientConfig struct {
 	// If all fields in App are set, this client will attempt to identify as
 	// a registered Reddit app using the credentials.
 	app App
+
+	// scopes are the OAuth scopes requested; oauthScopes if empty.
+	scopes []string
+
+	// tokens, if set, persists the OAuth token between runs.
+	tokens TokenStore
+
+	// otp, if set, provides two-factor codes for password grants.
+	otp OTP
+
+	// refreshEarly is how long before they expire tokens are refreshed.
+	refreshEarly time.Duration
+
+	// trace, if set, receives a log line for every request and response.
+	trace *log.Logger
+
+	// spans, if set, records a span for every request.
+	spans trace.Tracer
+
+	// status, if set, records the results of requests.
+	status *statusRecorder
+
+	// transport tunes the connections requests are made over.
+	transport TransportConfig
+}
+
+// httpClient returns the http client requests are made with, before any
+// authorization.
+func (c clientConfig) httpClient() *http.Client {
+	cli := clientWithAgent(c.agent, c.transport)
+	if c.trace != nil {
+		cli = traced(cli, c.trace)
+	}
+	if c.spans != nil {
+		cli = spanned(cli, c.spans)
+	}
+	if c.status != nil {
+		cli = recorded(cli, c.status)
+	}
+	return cli
 }
 
 // client executes http Requests and invisibly handles OAuth2 authorization.
@@ -40,6 +84,8 @@ func (b *baseClient) Do(req *http.Request) ([]byte, error) {
 
 	switch resp.StatusCode {
 	case http.StatusOK:
+	case http.StatusUnauthorized:
+		return nil, UnauthorizedErr
 	case http.StatusForbidden:
 		return nil, PermissionDeniedErr
 	case http.StatusServiceUnavailable:
@@ -69,7 +115,7 @@ func newClient(c clientConfig) (client, error) {
 	}
 
 	if c.app.unauthenticated() {
-		return &baseClient{clientWithAgent(c.agent)}, nil
+		return &baseClient{c.httpClient()}, nil
 	}
 
 	if err := c.app.validateAuth(); err != nil {
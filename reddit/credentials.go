@@ -0,0 +1,323 @@
+package reddit
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+)
+
+// Environment variables read by CredentialsFromEnv.
+const (
+	EnvUserAgent    = "GRAW_USER_AGENT"
+	EnvClientID     = "GRAW_CLIENT_ID"
+	EnvClientSecret = "GRAW_CLIENT_SECRET"
+	EnvUsername     = "GRAW_USERNAME"
+	EnvPassword     = "GRAW_PASSWORD"
+	// EnvTOTPSecret is the base32 two-factor secret of the account.
+	EnvTOTPSecret = "GRAW_TOTP_SECRET"
+	// EnvScopes is a comma or space separated list of OAuth scopes.
+	EnvScopes = "GRAW_SCOPES"
+	// EnvAgentKey is the passphrase used to open sealed agent files.
+	EnvAgentKey = "GRAW_AGENT_KEY"
+)
+
+// sealedHeader begins every sealed agent file.
+const sealedHeader = "graw-sealed-v1\n"
+
+const (
+	saltSize         = 16
+	keySize          = 32
+	keyIterations    = 100000
+	sealedKeyMinimum = 8
+)
+
+var (
+	errNoAgentKey = fmt.Errorf(
+		"agent file is sealed; set %s to its passphrase", EnvAgentKey,
+	)
+	errBadAgentKey   = fmt.Errorf("agent file could not be opened with the passphrase")
+	errShortAgentKey = fmt.Errorf(
+		"passphrases must be at least %d characters", sealedKeyMinimum,
+	)
+	errMalformedSealedFile = fmt.Errorf("sealed agent file is malformed")
+)
+
+// Scopes Reddit grants OAuth apps. See https://www.reddit.com/api/v1/scopes.
+var knownScopes = map[string]bool{
+	"account":          true,
+	"creddits":         true,
+	"edit":             true,
+	"flair":            true,
+	"history":          true,
+	"identity":         true,
+	"livemanage":       true,
+	"modconfig":        true,
+	"modcontributors":  true,
+	"modflair":         true,
+	"modlog":           true,
+	"modmail":          true,
+	"modothers":        true,
+	"modposts":         true,
+	"modself":          true,
+	"modtraffic":       true,
+	"modwiki":          true,
+	"mysubreddits":     true,
+	"privatemessages":  true,
+	"read":             true,
+	"report":           true,
+	"save":             true,
+	"structuredstyles": true,
+	"submit":           true,
+	"subscribe":        true,
+	"vote":             true,
+	"wikiedit":         true,
+	"wikiread":         true,
+}
+
+// Credentials are the account information a bot logs in with. They can be
+// loaded from an agent file in any of the formats LoadCredentials reads, or
+// from the environment with CredentialsFromEnv.
+type Credentials struct {
+	// UserAgent identifies the bot to Reddit.
+	UserAgent string `json:"user_agent"`
+	// ClientID and ClientSecret identify the bot's app registration.
+	ClientID     string `json:"client_id"`
+	ClientSecret string `json:"client_secret"`
+	// Username and Password log in to the bot's account. Without them,
+	// the bot has app-only access, which cannot act as a user.
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+	// TOTPSecret is the base32 two-factor authentication secret of the
+	// account, if it has two-factor authentication enabled.
+	TOTPSecret string `json:"totp_secret,omitempty"`
+	// Scopes are the OAuth scopes to request. If empty, graw's defaults
+	// are requested.
+	Scopes []string `json:"scopes,omitempty"`
+}
+
+// Validate reports every problem with the credentials in one error, so a bad
+// agent file can be fixed in one pass.
+func (c Credentials) Validate() error {
+	var problems []string
+	if c.UserAgent == "" {
+		problems = append(problems, "missing user_agent")
+	}
+	if c.ClientID == "" {
+		problems = append(problems, "missing client_id")
+	}
+	if c.ClientSecret == "" {
+		problems = append(problems, "missing client_secret")
+	}
+	if c.Username != "" && c.Password == "" {
+		problems = append(problems, "username is set but password is missing")
+	}
+	if c.Password != "" && c.Username == "" {
+		problems = append(problems, "password is set but username is missing")
+	}
+	if c.TOTPSecret != "" {
+		if _, err := NewTOTP(c.TOTPSecret); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+	for _, scope := range c.Scopes {
+		if !knownScopes[scope] {
+			problems = append(
+				problems,
+				fmt.Sprintf("unknown oauth scope %q", scope),
+			)
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf(
+			"invalid credentials: %s", strings.Join(problems, "; "),
+		)
+	}
+	return nil
+}
+
+// BotConfig returns a config for a bot logging in with these credentials. The
+// credentials should be valid.
+func (c Credentials) BotConfig(rate time.Duration) BotConfig {
+	var otp OTP
+	if totp, err := NewTOTP(c.TOTPSecret); c.TOTPSecret != "" && err == nil {
+		otp = totp
+	}
+
+	return BotConfig{
+		Agent: c.UserAgent,
+		App: App{
+			ID:       c.ClientID,
+			Secret:   c.ClientSecret,
+			Username: c.Username,
+			Password: c.Password,
+		},
+		Rate:   rate,
+		Scopes: c.Scopes,
+		OTP:    otp,
+	}
+}
+
+// LoadCredentials reads credentials from an agent file. The file may be in the
+// protobuf text format of the original agent files, JSON with the same field
+// names, or either of those sealed with SealCredentials. Sealed files are
+// opened with the passphrase in the GRAW_AGENT_KEY environment variable.
+func LoadCredentials(filename string) (Credentials, error) {
+	c, _, err := loadCredentials(filename)
+	return c, err
+}
+
+// loadCredentials reads credentials from an agent file, and reports whether
+// it is an original agent file: protobuf text, not sealed.
+func loadCredentials(filename string) (Credentials, bool, error) {
+	buf, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return Credentials{}, false, err
+	}
+
+	sealed := bytes.HasPrefix(buf, []byte(sealedHeader))
+	if sealed {
+		passphrase := os.Getenv(EnvAgentKey)
+		if passphrase == "" {
+			return Credentials{}, false, errNoAgentKey
+		}
+
+		if buf, err = openSealed(buf, passphrase); err != nil {
+			return Credentials{}, false, err
+		}
+	}
+
+	c, isJSON, err := parseCredentials(buf)
+	if err != nil {
+		return c, false, fmt.Errorf("agent file %s: %v", filename, err)
+	}
+	return c, !sealed && !isJSON, nil
+}
+
+// parseCredentials parses credentials in JSON or protobuf text format, and
+// reports whether they were JSON.
+func parseCredentials(buf []byte) (Credentials, bool, error) {
+	if trimmed := bytes.TrimSpace(buf); len(trimmed) > 0 && trimmed[0] == '{' {
+		var c Credentials
+		return c, true, json.Unmarshal(trimmed, &c)
+	}
+
+	agent, err := unmarshalAgent(buf)
+	return Credentials{
+		UserAgent:    agent.GetUserAgent(),
+		ClientID:     agent.GetClientId(),
+		ClientSecret: agent.GetClientSecret(),
+		Username:     agent.GetUsername(),
+		Password:     agent.GetPassword(),
+	}, false, err
+}
+
+// CredentialsFromEnv reads credentials from the GRAW_* environment variables,
+// for deployments which inject secrets through the environment rather than
+// files.
+func CredentialsFromEnv() Credentials {
+	return Credentials{
+		UserAgent:    os.Getenv(EnvUserAgent),
+		ClientID:     os.Getenv(EnvClientID),
+		ClientSecret: os.Getenv(EnvClientSecret),
+		Username:     os.Getenv(EnvUsername),
+		Password:     os.Getenv(EnvPassword),
+		TOTPSecret:   os.Getenv(EnvTOTPSecret),
+		Scopes: strings.FieldsFunc(
+			os.Getenv(EnvScopes),
+			func(r rune) bool { return r == ',' || r == ' ' },
+		),
+	}
+}
+
+// SealCredentials encrypts the contents of an agent file with a passphrase, so
+// it can be kept at rest without exposing the bot's password. The result can
+// be written to a file and loaded with LoadCredentials.
+func SealCredentials(agentFile []byte, passphrase string) ([]byte, error) {
+	if len(passphrase) < sealedKeyMinimum {
+		return nil, errShortAgentKey
+	}
+
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	aead, err := sealer(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	blob := append(salt, nonce...)
+	blob = aead.Seal(blob, nonce, agentFile, []byte(sealedHeader))
+	return []byte(
+		sealedHeader + base64.StdEncoding.EncodeToString(blob) + "\n",
+	), nil
+}
+
+// openSealed decrypts a sealed agent file.
+func openSealed(sealed []byte, passphrase string) ([]byte, error) {
+	blob, err := base64.StdEncoding.DecodeString(
+		strings.TrimSpace(string(sealed[len(sealedHeader):])),
+	)
+	if err != nil || len(blob) < saltSize {
+		return nil, errMalformedSealedFile
+	}
+
+	aead, err := sealer(passphrase, blob[:saltSize])
+	if err != nil {
+		return nil, err
+	}
+
+	blob = blob[saltSize:]
+	if len(blob) < aead.NonceSize() {
+		return nil, errMalformedSealedFile
+	}
+
+	plain, err := aead.Open(
+		nil,
+		blob[:aead.NonceSize()],
+		blob[aead.NonceSize():],
+		[]byte(sealedHeader),
+	)
+	if err != nil {
+		return nil, errBadAgentKey
+	}
+	return plain, nil
+}
+
+// sealer returns the AES-GCM cipher for a passphrase and salt, keyed with
+// PBKDF2-SHA256.
+func sealer(passphrase string, salt []byte) (cipher.AEAD, error) {
+	key, err := pbkdf2.Key(
+		sha256.New,
+		passphrase,
+		salt,
+		keyIterations,
+		keySize,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
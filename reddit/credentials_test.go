@@ -0,0 +1,152 @@
+package reddit
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/kylelemons/godebug/pretty"
+)
+
+var testCredentials = Credentials{
+	UserAgent:    "test",
+	ClientID:     "id",
+	ClientSecret: "secret",
+	Username:     "user",
+	Password:     "pass",
+}
+
+func writeTemp(t *testing.T, contents []byte) string {
+	f, err := ioutil.TempFile("", "agent")
+	if err != nil {
+		t.Fatalf("failed to make test input file: %v", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(contents); err != nil {
+		t.Fatalf("failed to write test input file: %v", err)
+	}
+	return f.Name()
+}
+
+func TestLoadCredentials(t *testing.T) {
+	sealed, err := SealCredentials(
+		[]byte(`{"user_agent": "test", "client_id": "id",
+			"client_secret": "secret", "username": "user",
+			"password": "pass"}`),
+		"correct horse",
+	)
+	if err != nil {
+		t.Fatalf("failed to seal credentials: %v", err)
+	}
+
+	for i, test := range []struct {
+		contents string
+		key      string
+		original bool
+	}{
+		{`
+			user_agent: "test"
+			client_id: "id"
+			client_secret: "secret"
+			username: "user"
+			password: "pass"
+		`, "", true},
+		{`{"user_agent": "test", "client_id": "id",
+			"client_secret": "secret", "username": "user",
+			"password": "pass"}`, "", false},
+		{string(sealed), "correct horse", false},
+	} {
+		os.Setenv(EnvAgentKey, test.key)
+		filename := writeTemp(t, []byte(test.contents))
+		defer os.Remove(filename)
+
+		creds, original, err := loadCredentials(filename)
+		if err != nil {
+			t.Errorf("%d: failed: %v", i, err)
+		}
+		if original != test.original {
+			t.Errorf(
+				"%d: reported original agent file %v; wanted %v",
+				i, original, test.original,
+			)
+		}
+
+		if diff := pretty.Compare(creds, testCredentials); diff != "" {
+			t.Errorf("%d: credentials incorrect; diff: %s", i, diff)
+		}
+	}
+	os.Unsetenv(EnvAgentKey)
+}
+
+func TestLoadSealedCredentialsErrors(t *testing.T) {
+	sealed, err := SealCredentials([]byte(`{}`), "correct horse")
+	if err != nil {
+		t.Fatalf("failed to seal credentials: %v", err)
+	}
+
+	filename := writeTemp(t, sealed)
+	defer os.Remove(filename)
+
+	os.Unsetenv(EnvAgentKey)
+	if _, err := LoadCredentials(filename); err != errNoAgentKey {
+		t.Errorf("got %v; wanted %v", err, errNoAgentKey)
+	}
+
+	os.Setenv(EnvAgentKey, "battery staple")
+	defer os.Unsetenv(EnvAgentKey)
+	if _, err := LoadCredentials(filename); err != errBadAgentKey {
+		t.Errorf("got %v; wanted %v", err, errBadAgentKey)
+	}
+
+	if _, err := SealCredentials(nil, "short"); err != errShortAgentKey {
+		t.Errorf("got %v; wanted %v", err, errShortAgentKey)
+	}
+}
+
+func TestCredentialsFromEnv(t *testing.T) {
+	for k, v := range map[string]string{
+		EnvUserAgent:    "test",
+		EnvClientID:     "id",
+		EnvClientSecret: "secret",
+		EnvUsername:     "user",
+		EnvPassword:     "pass",
+		EnvScopes:       "read, submit",
+	} {
+		os.Setenv(k, v)
+		defer os.Unsetenv(k)
+	}
+
+	expected := testCredentials
+	expected.Scopes = []string{"read", "submit"}
+	if diff := pretty.Compare(CredentialsFromEnv(), expected); diff != "" {
+		t.Errorf("credentials incorrect; diff: %s", diff)
+	}
+}
+
+func TestValidateCredentials(t *testing.T) {
+	if err := testCredentials.Validate(); err != nil {
+		t.Errorf("valid credentials rejected: %v", err)
+	}
+
+	err := Credentials{
+		UserAgent: "test",
+		Username:  "user",
+		Scopes:    []string{"read", "launchmissiles"},
+	}.Validate()
+	if err == nil {
+		t.Fatalf("invalid credentials accepted")
+	}
+
+	for _, problem := range []string{
+		"client_id",
+		"client_secret",
+		"password is missing",
+		`"launchmissiles"`,
+	} {
+		if !strings.Contains(err.Error(), problem) {
+			t.Errorf("error %q does not mention %s", err, problem)
+		}
+	}
+}
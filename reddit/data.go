@@ -1,40 +1,107 @@
 package reddit
 
-import "strings"
+import (
+	"strings"
+	"time"
+)
 
 // Comment represents a comment on Reddit (Reddit type t1_).
 // https://github.com/reddit/reddit/wiki/JSON#comment-implements-votable--created
 type Comment struct {
-	ID        string `mapstructure:"id"`
-	Name      string `mapstructure:"name"`
-	Permalink string `mapstructure:"permalink"`
+	ID        string `mapstructure:"id" json:"id"`
+	Name      string `mapstructure:"name" json:"name"`
+	Permalink string `mapstructure:"permalink" json:"permalink"`
 
-	CreatedUTC uint64 `mapstructure:"created_utc"`
-	Deleted    bool   `mapstructure:"deleted"`
+	CreatedUTC uint64 `mapstructure:"created_utc" json:"created_utc"`
+	EditedUTC  uint64 `mapstructure:"edited" json:"edited_utc"`
+	Deleted    bool   `mapstructure:"deleted" json:"deleted"`
 
-	Ups   int32 `mapstructure:"ups"`
-	Downs int32 `mapstructure:"downs"`
-	Likes bool  `mapstructure:"likes"`
+	Ups   int32 `mapstructure:"ups" json:"ups"`
+	Downs int32 `mapstructure:"downs" json:"downs"`
+	Likes bool  `mapstructure:"likes" json:"likes"`
 
-	Author              string `mapstructure:"author"`
-	AuthorFlairCSSClass string `mapstructure:"author_flair_css_class"`
-	AuthorFlairText     string `mapstructure:"author_flair_text"`
+	// Controversiality is 1 when the comment has many votes both ways,
+	// and 0 otherwise. ScoreHidden is set while the subreddit hides the
+	// comment's score, during which Ups and Downs are not meaningful.
+	Controversiality int32 `mapstructure:"controversiality" json:"controversiality"`
+	ScoreHidden      bool  `mapstructure:"score_hidden" json:"score_hidden"`
 
-	LinkAuthor string `mapstructure:"link_author"`
-	LinkURL    string `mapstructure:"link_url"`
-	LinkTitle  string `mapstructure:"link_title"`
+	// Collapsed is set when Reddit shows the comment collapsed, e.g.
+	// because its score is low or its author is blocked;
+	// CollapsedReason says why, when Reddit says.
+	Collapsed       bool   `mapstructure:"collapsed" json:"collapsed"`
+	CollapsedReason string `mapstructure:"collapsed_reason" json:"collapsed_reason"`
+	Stickied        bool   `mapstructure:"stickied" json:"stickied"`
 
-	Subreddit   string `mapstructure:"subreddit"`
-	SubredditID string `mapstructure:"subreddit_id"`
+	// Locked comments and Archived comments, those in archived threads,
+	// accept no replies.
+	Locked   bool `mapstructure:"locked" json:"locked"`
+	Archived bool `mapstructure:"archived" json:"archived"`
 
-	Body     string `mapstructure:"body"`
-	BodyHTML string `mapstructure:"body_html"`
+	Author              string `mapstructure:"author" json:"author"`
+	AuthorFlairCSSClass string `mapstructure:"author_flair_css_class" json:"author_flair_css_class"`
+	AuthorFlairText     string `mapstructure:"author_flair_text" json:"author_flair_text"`
 
-	ParentID string     `mapstructure:"parent_id"`
-	Replies  []*Comment `mapstructure:"reply_tree"`
+	LinkAuthor string `mapstructure:"link_author" json:"link_author"`
+	LinkURL    string `mapstructure:"link_url" json:"link_url"`
+	LinkTitle  string `mapstructure:"link_title" json:"link_title"`
 
-	Gilded        int32  `mapstructure:"gilded"`
-	Distinguished string `mapstructure:"distinguished"`
+	Subreddit   string `mapstructure:"subreddit" json:"subreddit"`
+	SubredditID string `mapstructure:"subreddit_id" json:"subreddit_id"`
+
+	Body     string `mapstructure:"body" json:"body"`
+	BodyHTML string `mapstructure:"body_html" json:"body_html"`
+
+	LinkID   string     `mapstructure:"link_id" json:"link_id"`
+	ParentID string     `mapstructure:"parent_id" json:"parent_id"`
+	Replies  []*Comment `mapstructure:"reply_tree" json:"replies"`
+
+	Gilded              int32      `mapstructure:"gilded" json:"gilded"`
+	TotalAwardsReceived int32      `mapstructure:"total_awards_received" json:"total_awards_received"`
+	AllAwardings        []Awarding `mapstructure:"all_awardings" json:"all_awardings"`
+	Distinguished       string     `mapstructure:"distinguished" json:"distinguished"`
+
+	// BannedBy is the moderator who removed the comment, which only
+	// moderators see; see Removal.
+	BannedBy string `mapstructure:"banned_by" json:"banned_by"`
+}
+
+// Awards returns the number of awards the comment has received, counting
+// gildings from before Reddit had other awards.
+func (c *Comment) Awards() int32 {
+	return maxInt32(c.Gilded, c.TotalAwardsReceived)
+}
+
+// AwardCoins returns the number of coins given in awards to the comment.
+func (c *Comment) AwardCoins() int64 {
+	return coins(c.AllAwardings)
+}
+
+// Created returns when the comment was created.
+func (c *Comment) Created() time.Time {
+	return utc(c.CreatedUTC)
+}
+
+// Edited returns when the comment was last edited, or the zero time if it has
+// not been.
+func (c *Comment) Edited() time.Time {
+	return utc(c.EditedUTC)
+}
+
+// Age returns how long before now the comment was created.
+func (c *Comment) Age(now time.Time) time.Duration {
+	return now.Sub(c.Created())
+}
+
+// Removed is true when the comment was removed by a moderator or Reddit's
+// spam filter, rather than deleted by its author.
+func (c *Comment) Removed() bool {
+	return c.Removal().Removed()
+}
+
+// IsControversial is true when the comment has many votes both ways.
+func (c *Comment) IsControversial() bool {
+	return c.Controversiality > 0
 }
 
 // IsTopLevel is true when the comment is a top level comment.
@@ -43,115 +110,391 @@ func (c *Comment) IsTopLevel() bool {
 	return parentType == postKind
 }
 
+// Awarding is one kind of award given to a post or comment, and how many
+// times it was given.
+type Awarding struct {
+	ID          string `mapstructure:"id" json:"id"`
+	Name        string `mapstructure:"name" json:"name"`
+	Description string `mapstructure:"description" json:"description"`
+	// AwardType is "global" for awards anyone can give, and "community"
+	// for awards a subreddit made.
+	AwardType string `mapstructure:"award_type" json:"award_type"`
+	IconURL   string `mapstructure:"icon_url" json:"icon_url"`
+	Count     int32  `mapstructure:"count" json:"count"`
+	// CoinPrice is the price in coins of one of the award.
+	CoinPrice int32 `mapstructure:"coin_price" json:"coin_price"`
+}
+
+func coins(awardings []Awarding) int64 {
+	total := int64(0)
+	for _, a := range awardings {
+		total += int64(a.Count) * int64(a.CoinPrice)
+	}
+	return total
+}
+
 // Media represents a subfield in the response about posts
 type Media struct {
-	Type   string `mapstructure:"type"`
+	Type   string `mapstructure:"type" json:"type"`
 	OEmbed struct {
-		ProviderURL     string `mapstructure:"provider_url"`
-		Description     string `mapstructure:"description"`
-		Title           string `mapstructure:"title"`
-		ThumbnailWidth  int    `mapstructure:"thumbnail_width"`
-		Height          int    `mapstructure:"height"`
-		Width           int    `mapstructure:"width"`
-		HTML            string `mapstructure:"html"`
-		Version         string `mapstructure:"version"`
-		ProviderName    string `mapstructure:"provider_name"`
-		ThumbnailURL    string `mapstructure:"thumbnail_url"`
-		Type            string `mapstructure:"type"`
-		ThumbnailHeight int    `mapstructure:"thumbnail_height"`
-	} `mapstructure:"oembed"`
+		ProviderURL     string `mapstructure:"provider_url" json:"provider_url"`
+		Description     string `mapstructure:"description" json:"description"`
+		Title           string `mapstructure:"title" json:"title"`
+		ThumbnailWidth  int    `mapstructure:"thumbnail_width" json:"thumbnail_width"`
+		Height          int    `mapstructure:"height" json:"height"`
+		Width           int    `mapstructure:"width" json:"width"`
+		HTML            string `mapstructure:"html" json:"html"`
+		Version         string `mapstructure:"version" json:"version"`
+		ProviderName    string `mapstructure:"provider_name" json:"provider_name"`
+		ThumbnailURL    string `mapstructure:"thumbnail_url" json:"thumbnail_url"`
+		Type            string `mapstructure:"type" json:"type"`
+		ThumbnailHeight int    `mapstructure:"thumbnail_height" json:"thumbnail_height"`
+	} `mapstructure:"oembed" json:"oembed"`
 	RedditVideo struct {
-		FallbackURL       string `mapstructure:"fallback_url"`
-		Height            int    `mapstructure:"height"`
-		Width             int    `mapstructure:"width"`
-		ScrubberMediaURL  string `mapstructure:"scrubber_media_url"`
-		DashURL           string `mapstructure:"dash_url"`
-		Duration          int    `mapstructure:"duration"`
-		HLSURL            string `mapstructure:"hls_url"`
-		IsGIF             bool   `mapstructure:"is_gif"`
-		TranscodingStatus string `mapstructure:"transcoding_status"`
-	} `mapstructure:"reddit_video"`
+		FallbackURL       string `mapstructure:"fallback_url" json:"fallback_url"`
+		Height            int    `mapstructure:"height" json:"height"`
+		Width             int    `mapstructure:"width" json:"width"`
+		ScrubberMediaURL  string `mapstructure:"scrubber_media_url" json:"scrubber_media_url"`
+		DashURL           string `mapstructure:"dash_url" json:"dash_url"`
+		Duration          int    `mapstructure:"duration" json:"duration"`
+		HLSURL            string `mapstructure:"hls_url" json:"hls_url"`
+		IsGIF             bool   `mapstructure:"is_gif" json:"is_gif"`
+		TranscodingStatus string `mapstructure:"transcoding_status" json:"transcoding_status"`
+	} `mapstructure:"reddit_video" json:"reddit_video"`
 }
 
 // Post represents posts on Reddit (Reddit type t3_).
 // https://github.com/reddit/reddit/wiki/JSON#link-implements-votable--created
 type Post struct {
-	ID        string `mapstructure:"id"`
-	Name      string `mapstructure:"name"`
-	Permalink string `mapstructure:"permalink"`
+	ID        string `mapstructure:"id" json:"id"`
+	Name      string `mapstructure:"name" json:"name"`
+	Permalink string `mapstructure:"permalink" json:"permalink"`
+
+	CreatedUTC uint64 `mapstructure:"created_utc" json:"created_utc"`
+	EditedUTC  uint64 `mapstructure:"edited" json:"edited_utc"`
+	Deleted    bool   `mapstructure:"deleted" json:"deleted"`
+
+	Ups   int32 `mapstructure:"ups" json:"ups"`
+	Downs int32 `mapstructure:"downs" json:"downs"`
+	Likes bool  `mapstructure:"likes" json:"likes"`
+
+	Author              string `mapstructure:"author" json:"author"`
+	AuthorFlairCSSClass string `mapstructure:"author_flair_css_class" json:"author_flair_css_class"`
+	AuthorFlairText     string `mapstructure:"author_flair_text" json:"author_flair_text"`
+
+	Title       string  `mapstructure:"title" json:"title"`
+	Score       int32   `mapstructure:"score" json:"score"`
+	UpvoteRatio float64 `mapstructure:"upvote_ratio" json:"upvote_ratio"`
+	URL         string  `mapstructure:"url" json:"url"`
+	Domain      string  `mapstructure:"domain" json:"domain"`
+	NSFW        bool    `mapstructure:"over_18" json:"over_18"`
+
+	Subreddit   string `mapstructure:"subreddit" json:"subreddit"`
+	SubredditID string `mapstructure:"subreddit_id" json:"subreddit_id"`
+
+	IsSelf       bool   `mapstructure:"is_self" json:"is_self"`
+	SelfText     string `mapstructure:"selftext" json:"selftext"`
+	SelfTextHTML string `mapstructure:"selftext_html" json:"selftext_html"`
+
+	Replies []*Comment `mapstructure:"reply_tree" json:"replies"`
+
+	Hidden            bool   `mapstructure:"hidden" json:"hidden"`
+	LinkFlairCSSClass string `mapstructure:"link_flair_css_class" json:"link_flair_css_class"`
+	LinkFlairText     string `mapstructure:"link_flair_text" json:"link_flair_text"`
+
+	// Locked posts and Archived posts, which Reddit archives after six
+	// months, accept no new comments.
+	NumComments int32  `mapstructure:"num_comments" json:"num_comments"`
+	Locked      bool   `mapstructure:"locked" json:"locked"`
+	Archived    bool   `mapstructure:"archived" json:"archived"`
+	Thumbnail   string `mapstructure:"thumbnail" json:"thumbnail"`
+
+	Gilded              int32      `mapstructure:"gilded" json:"gilded"`
+	TotalAwardsReceived int32      `mapstructure:"total_awards_received" json:"total_awards_received"`
+	AllAwardings        []Awarding `mapstructure:"all_awardings" json:"all_awardings"`
+	Distinguished       string     `mapstructure:"distinguished" json:"distinguished"`
+	Stickied            bool       `mapstructure:"stickied" json:"stickied"`
+
+	IsRedditMediaDomain bool  `mapstructure:"is_reddit_media_domain" json:"is_reddit_media_domain"`
+	Media               Media `mapstructure:"media" json:"media"`
+	SecureMedia         Media `mapstructure:"secure_media" json:"secure_media"`
+
+	Preview       Preview                  `mapstructure:"preview" json:"preview"`
+	IsGallery     bool                     `mapstructure:"is_gallery" json:"is_gallery"`
+	GalleryData   GalleryData              `mapstructure:"gallery_data" json:"gallery_data"`
+	MediaMetadata map[string]MediaMetadata `mapstructure:"media_metadata" json:"media_metadata"`
+
+	// CrosspostParent is the fullname of the post this post crossposts,
+	// if it is a crosspost. CrosspostParentList holds that post.
+	CrosspostParent     string  `mapstructure:"crosspost_parent" json:"crosspost_parent"`
+	CrosspostParentList []*Post `mapstructure:"-" json:"crosspost_parent_list"`
+
+	// RemovedByCategory says who removed the post, e.g. "moderator" or
+	// "reddit" (the spam filter), if it was removed, or "deleted" if its
+	// author deleted it. BannedBy is the moderator who removed it, which
+	// only moderators see; see Removal.
+	RemovedByCategory string `mapstructure:"removed_by_category" json:"removed_by_category"`
+	BannedBy          string `mapstructure:"banned_by" json:"banned_by"`
+
+	// PollData is set for poll and prediction posts.
+	PollData *Poll `mapstructure:"poll_data" json:"poll_data"`
+}
+
+// PollOption is an option voters can choose in a poll. VoteCount is only
+// known once the bot has voted or the poll has ended.
+type PollOption struct {
+	ID        string `mapstructure:"id" json:"id"`
+	Text      string `mapstructure:"text" json:"text"`
+	VoteCount int32  `mapstructure:"vote_count" json:"vote_count"`
+}
+
+// Poll describes the options and votes of a poll post. Predictions are polls
+// whose result is decided by moderators rather than votes; ResolvedOptionID
+// is the option they chose, once they have.
+type Poll struct {
+	Options []PollOption `mapstructure:"options" json:"options"`
+	// TotalVoteCount is the number of votes cast across all options.
+	TotalVoteCount int32 `mapstructure:"total_vote_count" json:"total_vote_count"`
+	// VotingEndTimestamp is when voting ends, in milliseconds since the
+	// epoch.
+	VotingEndTimestamp uint64 `mapstructure:"voting_end_timestamp" json:"voting_end_timestamp"`
+	// UserSelection is the id of the option the bot voted for, if any.
+	UserSelection string `mapstructure:"user_selection" json:"user_selection"`
 
-	CreatedUTC uint64 `mapstructure:"created_utc"`
-	Deleted    bool   `mapstructure:"deleted"`
+	IsPrediction     bool   `mapstructure:"is_prediction" json:"is_prediction"`
+	PredictionStatus string `mapstructure:"prediction_status" json:"prediction_status"`
+	ResolvedOptionID string `mapstructure:"resolved_option_id" json:"resolved_option_id"`
+	TotalStakeAmount int32  `mapstructure:"total_stake_amount" json:"total_stake_amount"`
+}
 
-	Ups   int32 `mapstructure:"ups"`
-	Downs int32 `mapstructure:"downs"`
-	Likes bool  `mapstructure:"likes"`
+// Ends returns when voting in the poll ends.
+func (p *Poll) Ends() time.Time {
+	if p.VotingEndTimestamp == 0 {
+		return time.Time{}
+	}
+	ms := int64(p.VotingEndTimestamp)
+	return time.Unix(ms/1000, (ms%1000)*int64(time.Millisecond)).UTC()
+}
 
-	Author              string `mapstructure:"author"`
-	AuthorFlairCSSClass string `mapstructure:"author_flair_css_class"`
-	AuthorFlairText     string `mapstructure:"author_flair_text"`
+// Open returns whether voting in the poll is still open at now.
+func (p *Poll) Open(now time.Time) bool {
+	return now.Before(p.Ends())
+}
 
-	Title  string `mapstructure:"title"`
-	Score  int32  `mapstructure:"score"`
-	URL    string `mapstructure:"url"`
-	Domain string `mapstructure:"domain"`
-	NSFW   bool   `mapstructure:"over_18"`
+// Leaders returns the options with the most votes; more than one if they are
+// tied. It returns nothing if no votes are known.
+func (p *Poll) Leaders() []PollOption {
+	var leaders []PollOption
+	most := int32(0)
+	for _, option := range p.Options {
+		switch {
+		case option.VoteCount > most:
+			most = option.VoteCount
+			leaders = []PollOption{option}
+		case option.VoteCount == most && most > 0:
+			leaders = append(leaders, option)
+		}
+	}
+	return leaders
+}
 
-	Subreddit   string `mapstructure:"subreddit"`
-	SubredditID string `mapstructure:"subreddit_id"`
+// Image is one size of an image Reddit hosts.
+type Image struct {
+	URL    string `mapstructure:"url" json:"url"`
+	Width  int    `mapstructure:"width" json:"width"`
+	Height int    `mapstructure:"height" json:"height"`
+}
 
-	IsSelf       bool   `mapstructure:"is_self"`
-	SelfText     string `mapstructure:"selftext"`
-	SelfTextHTML string `mapstructure:"selftext_html"`
+// PreviewImage is an image Reddit made from a post's link, in its original
+// size and the smaller sizes Reddit scaled it to.
+type PreviewImage struct {
+	ID          string  `mapstructure:"id" json:"id"`
+	Source      Image   `mapstructure:"source" json:"source"`
+	Resolutions []Image `mapstructure:"resolutions" json:"resolutions"`
+}
 
-	Replies []*Comment `mapstructure:"reply_tree"`
+// Preview holds the preview images of a post.
+type Preview struct {
+	Enabled bool           `mapstructure:"enabled" json:"enabled"`
+	Images  []PreviewImage `mapstructure:"images" json:"images"`
+}
 
-	Hidden            bool   `mapstructure:"hidden"`
-	LinkFlairCSSClass string `mapstructure:"link_flair_css_class"`
-	LinkFlairText     string `mapstructure:"link_flair_text"`
+// GalleryItem is one image in a gallery post. Its media is described by the
+// post's MediaMetadata under its MediaID.
+type GalleryItem struct {
+	ID          int64  `mapstructure:"id" json:"id"`
+	MediaID     string `mapstructure:"media_id" json:"media_id"`
+	Caption     string `mapstructure:"caption" json:"caption"`
+	OutboundURL string `mapstructure:"outbound_url" json:"outbound_url"`
+}
 
-	NumComments int32  `mapstructure:"num_comments"`
-	Locked      bool   `mapstructure:"locked"`
-	Thumbnail   string `mapstructure:"thumbnail"`
+// GalleryData holds the images of a gallery post, in order.
+type GalleryData struct {
+	Items []GalleryItem `mapstructure:"items" json:"items"`
+}
 
-	Gilded        int32  `mapstructure:"gilded"`
-	Distinguished string `mapstructure:"distinguished"`
-	Stickied      bool   `mapstructure:"stickied"`
+// MediaImage is one size of an image or animation uploaded to Reddit.
+// Animations have GIF and MP4 links instead of a URL.
+type MediaImage struct {
+	URL    string `mapstructure:"u" json:"url"`
+	GIF    string `mapstructure:"gif" json:"gif"`
+	MP4    string `mapstructure:"mp4" json:"mp4"`
+	Width  int    `mapstructure:"x" json:"width"`
+	Height int    `mapstructure:"y" json:"height"`
+}
 
-	IsRedditMediaDomain bool  `mapstructure:"is_reddit_media_domain"`
-	Media               Media `mapstructure:"media"`
-	SecureMedia         Media `mapstructure:"secure_media"`
+// MediaMetadata describes media uploaded to Reddit for a gallery post or
+// embedded in text. Source is the original size and Previews the smaller
+// sizes Reddit scaled it to.
+type MediaMetadata struct {
+	ID       string       `mapstructure:"id" json:"id"`
+	Status   string       `mapstructure:"status" json:"status"`
+	Kind     string       `mapstructure:"e" json:"kind"`
+	MIMEType string       `mapstructure:"m" json:"mime_type"`
+	Source   MediaImage   `mapstructure:"s" json:"source"`
+	Previews []MediaImage `mapstructure:"p" json:"previews"`
+}
+
+// Gallery returns the media of a gallery post, in gallery order. Items whose
+// media Reddit did not describe, such as ones still processing, are left
+// out.
+func (p *Post) Gallery() []MediaMetadata {
+	var media []MediaMetadata
+	for _, item := range p.GalleryData.Items {
+		if m, ok := p.MediaMetadata[item.MediaID]; ok {
+			media = append(media, m)
+		}
+	}
+	return media
+}
+
+// Awards returns the number of awards the post has received, counting
+// gildings from before Reddit had other awards.
+func (p *Post) Awards() int32 {
+	return maxInt32(p.Gilded, p.TotalAwardsReceived)
+}
+
+// AwardCoins returns the number of coins given in awards to the post.
+func (p *Post) AwardCoins() int64 {
+	return coins(p.AllAwardings)
+}
+
+// Removed is true when the post was removed by a moderator or Reddit's spam
+// filter, rather than deleted by its author.
+func (p *Post) Removed() bool {
+	return p.Removal().Removed()
+}
+
+// Created returns when the post was created.
+func (p *Post) Created() time.Time {
+	return utc(p.CreatedUTC)
+}
+
+// Edited returns when the post was last edited, or the zero time if it has not
+// been.
+func (p *Post) Edited() time.Time {
+	return utc(p.EditedUTC)
+}
+
+// Age returns how long before now the post was created.
+func (p *Post) Age(now time.Time) time.Duration {
+	return now.Sub(p.Created())
+}
+
+func maxInt32(a, b int32) int32 {
+	if a > b {
+		return a
+	}
+	return b
 }
 
 // Message represents messages on Reddit (Reddit type t4_).
 // https://github.com/reddit/reddit/wiki/JSON#message-implements-created
 type Message struct {
-	ID   string `mapstructure:"id"`
-	Name string `mapstructure:"name"`
+	ID   string `mapstructure:"id" json:"id"`
+	Name string `mapstructure:"name" json:"name"`
+
+	CreatedUTC uint64 `mapstructure:"created_utc" json:"created_utc"`
+
+	Author   string `mapstructure:"author" json:"author"`
+	Subject  string `mapstructure:"subject" json:"subject"`
+	Body     string `mapstructure:"body" json:"body"`
+	BodyHTML string `mapstructure:"body_html" json:"body_html"`
+
+	Context          string `mapstructure:"context" json:"context"`
+	FirstMessageName string `mapstructure:"first_message_name" json:"first_message_name"`
+	Likes            bool   `mapstructure:"likes" json:"likes"`
+	LinkTitle        string `mapstructure:"link_title" json:"link_title"`
+
+	New      bool   `mapstructure:"new" json:"new"`
+	ParentID string `mapstructure:"parent_id" json:"parent_id"`
+
+	Subreddit  string `mapstructure:"subreddit" json:"subreddit"`
+	WasComment bool   `mapstructure:"was_comment" json:"was_comment"`
+	// Type is the kind of inbox item a comment is: "post_reply",
+	// "comment_reply", or "username_mention".
+	Type string `mapstructure:"type" json:"type"`
+}
+
+// Created returns when the message was sent.
+func (m *Message) Created() time.Time {
+	return utc(m.CreatedUTC)
+}
+
+// Age returns how long before now the message was sent.
+func (m *Message) Age(now time.Time) time.Duration {
+	return now.Sub(m.Created())
+}
 
-	CreatedUTC uint64 `mapstructure:"created_utc"`
+// Redditor represents a user account on Reddit (Reddit type t2_).
+// https://github.com/reddit/reddit/wiki/JSON#account
+type Redditor struct {
+	ID   string `mapstructure:"id" json:"id"`
+	Name string `mapstructure:"name" json:"name"`
 
-	Author   string `mapstructure:"author"`
-	Subject  string `mapstructure:"subject"`
-	Body     string `mapstructure:"body"`
-	BodyHTML string `mapstructure:"body_html"`
+	CreatedUTC uint64 `mapstructure:"created_utc" json:"created_utc"`
 
-	Context          string `mapstructure:"context"`
-	FirstMessageName string `mapstructure:"first_message_name"`
-	Likes            bool   `mapstructure:"likes"`
-	LinkTitle        string `mapstructure:"link_title"`
+	LinkKarma    int32 `mapstructure:"link_karma" json:"link_karma"`
+	CommentKarma int32 `mapstructure:"comment_karma" json:"comment_karma"`
 
-	New      bool   `mapstructure:"new"`
-	ParentID string `mapstructure:"parent_id"`
+	HasVerifiedEmail bool `mapstructure:"has_verified_email" json:"has_verified_email"`
+	IsGold           bool `mapstructure:"is_gold" json:"is_gold"`
+	IsMod            bool `mapstructure:"is_mod" json:"is_mod"`
+}
+
+// Created returns when the account was created.
+func (r *Redditor) Created() time.Time {
+	return utc(r.CreatedUTC)
+}
 
-	Subreddit  string `mapstructure:"subreddit"`
-	WasComment bool   `mapstructure:"was_comment"`
+// utc returns the time of a Reddit timestamp, in seconds since the epoch, in
+// UTC. Zero timestamps, which Reddit uses for things that never happened, are
+// the zero time.
+func utc(epoch uint64) time.Time {
+	if epoch == 0 {
+		return time.Time{}
+	}
+	return time.Unix(int64(epoch), 0).UTC()
 }
 
 // Harvest is a set of all possible elements that Reddit could return in a
 // listing.
 type Harvest struct {
-	Comments []*Comment
-	Posts    []*Post
-	Messages []*Message
+	Comments []*Comment `json:"comments"`
+	Posts    []*Post    `json:"posts"`
+	Messages []*Message `json:"messages"`
+	// Warnings are the problems with the response which did not stop the
+	// rest of it from being parsed; see ParseWarning.
+	Warnings []ParseWarning `json:"-"`
+	// Unknown are the fields of the things in the response which are not
+	// read into their structs, by kind (e.g. "t3"), sorted. Fields Reddit
+	// adds show up here.
+	Unknown map[string][]string `json:"-"`
+}
+
+// submission identifies something created on Reddit by a POST request.
+type submission struct {
+	id   string
+	name string
+	url  string
 }
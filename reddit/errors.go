@@ -6,9 +6,15 @@ import (
 
 var (
 	PermissionDeniedErr   = fmt.Errorf("unauthorized access to endpoint")
+	UnauthorizedErr       = fmt.Errorf("401 unauthorized; Reddit rejected the bot's token")
 	BusyErr               = fmt.Errorf("Reddit is busy right now")
 	RateLimitErr          = fmt.Errorf("Reddit is rate limiting requests")
 	GatewayErr            = fmt.Errorf("502 bad gateway code from Reddit")
 	GatewayTimeoutErr     = fmt.Errorf("504 gateway timeout from Reddit")
 	ThreadDoesNotExistErr = fmt.Errorf("The requested post does not exist.")
+	ThingDoesNotExistErr  = fmt.Errorf("The requested thing does not exist.")
+	// ThreadLockedErr and ThreadArchivedErr are returned by Reply, with
+	// BotConfig.RefuseLocked, for parents which cannot be replied to.
+	ThreadLockedErr   = fmt.Errorf("The thread is locked; it cannot be replied to.")
+	ThreadArchivedErr = fmt.Errorf("The thread is archived; it cannot be replied to.")
 )
@@ -0,0 +1,88 @@
+package reddit
+
+import "html"
+
+// Reddit encodes &, <, and > in text fields as HTML entities unless a request
+// asks for raw_json. graw asks for it, but for requests which do not, text
+// fields are unescaped after parsing so they always hold the markdown the
+// author wrote. Rendered HTML fields (BodyHTML, SelfTextHTML) are always
+// entity-encoded by Reddit and are left as they were sent; their HTML methods
+// unescape them.
+//
+// escaped reports whether Reddit encodes the text of responses to a request
+// with the values.
+func escaped(values map[string]string) bool {
+	return values["raw_json"] != "1"
+}
+
+// unescape decodes the text fields of everything in the harvest.
+func (h Harvest) unescape() {
+	for _, p := range h.Posts {
+		p.unescape()
+	}
+	unescapeComments(h.Comments)
+	for _, m := range h.Messages {
+		m.unescape()
+	}
+}
+
+// HTML returns the rendered HTML of the comment's body.
+func (c *Comment) HTML() string {
+	return html.UnescapeString(c.BodyHTML)
+}
+
+func (c *Comment) unescape() {
+	c.Body = html.UnescapeString(c.Body)
+	c.LinkTitle = html.UnescapeString(c.LinkTitle)
+	c.AuthorFlairText = html.UnescapeString(c.AuthorFlairText)
+	unescapeComments(c.Replies)
+}
+
+func unescapeComments(comments []*Comment) {
+	for _, c := range comments {
+		c.unescape()
+	}
+}
+
+// HTML returns the rendered HTML of the post's self text.
+func (p *Post) HTML() string {
+	return html.UnescapeString(p.SelfTextHTML)
+}
+
+func (p *Post) unescape() {
+	p.Title = html.UnescapeString(p.Title)
+	p.SelfText = html.UnescapeString(p.SelfText)
+	p.URL = html.UnescapeString(p.URL)
+	p.LinkFlairText = html.UnescapeString(p.LinkFlairText)
+	p.AuthorFlairText = html.UnescapeString(p.AuthorFlairText)
+	for i := range p.Preview.Images {
+		image := &p.Preview.Images[i]
+		image.Source.URL = html.UnescapeString(image.Source.URL)
+		for j := range image.Resolutions {
+			size := &image.Resolutions[j]
+			size.URL = html.UnescapeString(size.URL)
+		}
+	}
+	for id, m := range p.MediaMetadata {
+		m.Source.URL = html.UnescapeString(m.Source.URL)
+		p.MediaMetadata[id] = m
+	}
+	if p.PollData != nil {
+		for i := range p.PollData.Options {
+			option := &p.PollData.Options[i]
+			option.Text = html.UnescapeString(option.Text)
+		}
+	}
+	unescapeComments(p.Replies)
+}
+
+// HTML returns the rendered HTML of the message's body.
+func (m *Message) HTML() string {
+	return html.UnescapeString(m.BodyHTML)
+}
+
+func (m *Message) unescape() {
+	m.Subject = html.UnescapeString(m.Subject)
+	m.Body = html.UnescapeString(m.Body)
+	m.LinkTitle = html.UnescapeString(m.LinkTitle)
+}
@@ -0,0 +1,163 @@
+package reddit
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"html"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// minFeedRate is the least time between a feed script's requests. Reddit
+// allows clients which are not logged in about ten requests per minute.
+const minFeedRate = 6 * time.Second
+
+// FeedFormat selects the listing endpoints a feed script reads.
+type FeedFormat int
+
+const (
+	// JSONFeed reads the .json listings, which carry every field Reddit
+	// knows about each post and comment.
+	JSONFeed FeedFormat = iota
+	// RSSFeed reads the .rss listings, which Reddit serves more readily
+	// to clients without credentials, but which carry less: see
+	// NewFeedScript.
+	RSSFeed
+)
+
+// NewFeedScript returns a Script handle which monitors public listings through
+// their .json or .rss endpoints, without OAuth credentials, so read-only tools
+// can run before an app is registered for them. It makes requests no more
+// often than rate, and never more than once every six seconds.
+//
+// Posts and comments read from RSS feeds have only their name, author,
+// subreddit, title, body (text and HTML), permalink, and creation time, and
+// comments their post's name; the URL of a post is its permalink.
+func NewFeedScript(agent string, format FeedFormat, rate time.Duration) (
+	Script,
+	error,
+) {
+	if err := checkUserAgent(agent, nil); err != nil {
+		return nil, err
+	}
+
+	cfg := reaperConfig{
+		parser:     newParser(),
+		hostname:   "www.reddit.com",
+		reapSuffix: ".json",
+		tls:        true,
+		rate:       maxOf(rate, minFeedRate),
+	}
+	if format == RSSFeed {
+		cfg.parser = &feedParser{}
+		cfg.reapSuffix = ".rss"
+	}
+
+	status := newStatusRecorder()
+	c, err := newClient(clientConfig{agent: agent, status: status})
+	cfg.client = c
+	cfg.queue = status.queue
+	r := newReaper(cfg)
+	return &script{
+		Lurker:  newLurker(r),
+		Scanner: newScanner(r),
+		Monitor: status,
+	}, err
+}
+
+// atomFeed is the structure of Reddit's .rss listings, which are Atom feeds.
+type atomFeed struct {
+	Entries []struct {
+		ID     string `xml:"id"`
+		Title  string `xml:"title"`
+		Author struct {
+			Name string `xml:"name"`
+		} `xml:"author"`
+		Category struct {
+			Term string `xml:"term,attr"`
+		} `xml:"category"`
+		Content string `xml:"content"`
+		Link    struct {
+			Href string `xml:"href,attr"`
+		} `xml:"link"`
+		Published string `xml:"published"`
+	} `xml:"entry"`
+}
+
+// feedParser parses .rss listings.
+type feedParser struct{}
+
+func (f *feedParser) parse(blob json.RawMessage) (Harvest, error) {
+	var feed atomFeed
+	if err := xml.Unmarshal(blob, &feed); err != nil {
+		return Harvest{}, err
+	}
+
+	var comments []*Comment
+	var posts []*Post
+	for _, entry := range feed.Entries {
+		var created uint64
+		if t, err := time.Parse(time.RFC3339, entry.Published); err == nil {
+			created = uint64(t.Unix())
+		}
+
+		permalink := entry.Link.Href
+		if u, err := url.Parse(entry.Link.Href); err == nil {
+			permalink = u.Path
+		}
+
+		id := entry.ID[strings.Index(entry.ID, "_")+1:]
+		author := strings.TrimPrefix(entry.Author.Name, "/u/")
+		switch {
+		case strings.HasPrefix(entry.ID, postKind+"_"):
+			posts = append(posts, &Post{
+				ID:           id,
+				Name:         entry.ID,
+				Permalink:    permalink,
+				CreatedUTC:   created,
+				Author:       author,
+				Title:        entry.Title,
+				URL:          entry.Link.Href,
+				Subreddit:    entry.Category.Term,
+				SelfText:     textOf(entry.Content),
+				SelfTextHTML: entry.Content,
+			})
+		case strings.HasPrefix(entry.ID, commentKind+"_"):
+			comments = append(comments, &Comment{
+				ID:         id,
+				Name:       entry.ID,
+				Permalink:  permalink,
+				CreatedUTC: created,
+				Author:     author,
+				Subreddit:  entry.Category.Term,
+				Body:       textOf(entry.Content),
+				BodyHTML:   entry.Content,
+				LinkID:     postOfPermalink(permalink),
+			})
+		}
+	}
+	return Harvest{Comments: comments, Posts: posts}, nil
+}
+
+// tags matches HTML tags.
+var tags = regexp.MustCompile(`<[^>]*>`)
+
+// textOf returns the text of an HTML fragment.
+func textOf(fragment string) string {
+	text := html.UnescapeString(tags.ReplaceAllString(fragment, ""))
+	return strings.TrimSpace(text)
+}
+
+// postOfPermalink returns the name of the post a permalink (e.g.
+// /r/golang/comments/5du939/title/d8s9dfa/) points into, or "".
+func postOfPermalink(permalink string) string {
+	parts := strings.Split(strings.Trim(permalink, "/"), "/")
+	for i := 0; i+1 < len(parts); i++ {
+		if parts[i] == "comments" {
+			return postKind + "_" + parts[i+1]
+		}
+	}
+	return ""
+}
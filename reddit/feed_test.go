@@ -0,0 +1,57 @@
+package reddit
+
+import (
+	"testing"
+)
+
+const testFeed = `<?xml version="1.0" encoding="UTF-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+  <category term="golang" label="r/golang"/>
+  <title>newest submissions : golang</title>
+  <entry>
+    <author><name>/u/roxven</name></author>
+    <category term="golang" label="r/golang"/>
+    <content type="html">&lt;div class=&quot;md&quot;&gt;&lt;p&gt;Fish &amp;amp; chips&lt;/p&gt;&lt;/div&gt;</content>
+    <id>t3_5du939</id>
+    <link href="https://www.reddit.com/r/golang/comments/5du939/hello/"/>
+    <published>2016-11-19T18:20:00+00:00</published>
+    <title>hello</title>
+  </entry>
+  <entry>
+    <author><name>/u/spez</name></author>
+    <category term="golang" label="r/golang"/>
+    <content type="html">&lt;p&gt;hi&lt;/p&gt;</content>
+    <id>t1_d8s9dfa</id>
+    <link href="https://www.reddit.com/r/golang/comments/5du939/hello/d8s9dfa/"/>
+    <published>2016-11-19T18:21:00+00:00</published>
+    <title>/u/spez on hello</title>
+  </entry>
+</feed>`
+
+func TestFeedParser(t *testing.T) {
+	h, err := (&feedParser{}).parse([]byte(testFeed))
+	if err != nil {
+		t.Fatalf("error parsing feed: %v", err)
+	}
+	comments, posts, messages := h.Comments, h.Posts, h.Messages
+
+	if len(posts) != 1 || len(comments) != 1 || len(messages) != 0 {
+		t.Fatalf("got %v, %v, %v; wanted a post and a comment",
+			posts, comments, messages)
+	}
+
+	p := posts[0]
+	if p.Name != "t3_5du939" || p.ID != "5du939" || p.Author != "roxven" ||
+		p.Subreddit != "golang" || p.Title != "hello" ||
+		p.SelfText != "Fish & chips" ||
+		p.Permalink != "/r/golang/comments/5du939/hello/" ||
+		p.CreatedUTC != 1479579600 {
+		t.Errorf("post parsed incorrectly: %+v", p)
+	}
+
+	c := comments[0]
+	if c.Name != "t1_d8s9dfa" || c.Author != "spez" || c.Body != "hi" ||
+		c.LinkID != "t3_5du939" || c.CreatedUTC != 1479579660 {
+		t.Errorf("comment parsed incorrectly: %+v", c)
+	}
+}
@@ -0,0 +1,119 @@
+package reddit
+
+import (
+	"encoding/json"
+	"sort"
+)
+
+// FlairTemplate is a flair a subreddit offers for its posts or users. Select
+// one by its ID.
+type FlairTemplate struct {
+	// ID identifies the template, e.g. when assigning it.
+	ID string `json:"id"`
+	// Text is the flair's text, which may contain emojis as :name:.
+	Text string `json:"text"`
+	// TextEditable is whether the text may be changed when the template
+	// is assigned.
+	TextEditable bool   `json:"text_editable"`
+	CSSClass     string `json:"css_class"`
+	// BackgroundColor is the flair's color, e.g. #ff4500, and TextColor
+	// is dark or light.
+	BackgroundColor string `json:"background_color"`
+	TextColor       string `json:"text_color"`
+	// ModOnly is whether only moderators may assign the template.
+	ModOnly bool `json:"mod_only"`
+	// AllowableContent is what the text may hold: all, text, or emoji.
+	AllowableContent string `json:"allowable_content"`
+	// MaxEmojis is the most emojis the text may hold.
+	MaxEmojis int `json:"max_emojis"`
+}
+
+// Emoji is a custom emoji usable in a subreddit's flair, as :name:.
+type Emoji struct {
+	Name string
+	// URL is the address of the emoji's image.
+	URL string
+	// UserFlairAllowed and PostFlairAllowed are whether the emoji may be
+	// used in user and post flair, and ModOnly whether only moderators
+	// may use it.
+	UserFlairAllowed bool
+	PostFlairAllowed bool
+	ModOnly          bool
+	// Snoomoji is whether the emoji is one of Reddit's, available in
+	// every subreddit, rather than the subreddit's own.
+	Snoomoji bool
+}
+
+// snoomojis is the key of Reddit's own emojis in emoji listings.
+const snoomojis = "snoomojis"
+
+func (a *account) LinkFlairTemplates(subreddit string) (
+	[]FlairTemplate,
+	error,
+) {
+	return a.flairTemplates("/r/" + subreddit + "/api/link_flair_v2")
+}
+
+func (a *account) UserFlairTemplates(subreddit string) (
+	[]FlairTemplate,
+	error,
+) {
+	return a.flairTemplates("/r/" + subreddit + "/api/user_flair_v2")
+}
+
+func (a *account) flairTemplates(path string) ([]FlairTemplate, error) {
+	blob, err := a.r.reapRaw(path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var templates []FlairTemplate
+	if err := json.Unmarshal(blob, &templates); err != nil {
+		return nil, err
+	}
+	return templates, nil
+}
+
+func (a *account) Emojis(subreddit string) ([]Emoji, error) {
+	blob, err := a.r.reapRaw("/api/v1/"+subreddit+"/emojis/all", nil)
+	if err != nil {
+		return nil, err
+	}
+	return parseEmojis(blob)
+}
+
+// parseEmojis parses an emoji listing, which groups emojis by the fullname of
+// the subreddit they belong to, or snoomojis for Reddit's own.
+func parseEmojis(blob []byte) ([]Emoji, error) {
+	var groups map[string]map[string]struct {
+		URL              string `json:"url"`
+		UserFlairAllowed bool   `json:"user_flair_allowed"`
+		PostFlairAllowed bool   `json:"post_flair_allowed"`
+		ModFlairOnly     bool   `json:"mod_flair_only"`
+	}
+	if err := json.Unmarshal(blob, &groups); err != nil {
+		return nil, err
+	}
+
+	var emojis []Emoji
+	for group, byName := range groups {
+		for name, e := range byName {
+			emojis = append(emojis, Emoji{
+				Name:             name,
+				URL:              e.URL,
+				UserFlairAllowed: e.UserFlairAllowed,
+				PostFlairAllowed: e.PostFlairAllowed,
+				ModOnly:          e.ModFlairOnly,
+				Snoomoji:         group == snoomojis,
+			})
+		}
+	}
+	// Order the subreddit's own emojis first, then by name.
+	sort.Slice(emojis, func(i, j int) bool {
+		if emojis[i].Snoomoji != emojis[j].Snoomoji {
+			return !emojis[i].Snoomoji
+		}
+		return emojis[i].Name < emojis[j].Name
+	})
+	return emojis, nil
+}
@@ -0,0 +1,97 @@
+package reddit
+
+import (
+	"testing"
+
+	"github.com/kylelemons/godebug/pretty"
+)
+
+func TestFlairTemplates(t *testing.T) {
+	r := &mockReaper{
+		raw: []byte(`[{
+			"id": "a1b2",
+			"text": ":go: Gopher",
+			"text_editable": true,
+			"css_class": "gopher",
+			"background_color": "#00add8",
+			"text_color": "light",
+			"mod_only": false,
+			"allowable_content": "all",
+			"max_emojis": 10
+		}]`),
+	}
+	a := newAccount(r, SplitConfig{})
+
+	templates, err := a.LinkFlairTemplates("golang")
+	if err != nil {
+		t.Fatalf("error listing templates: %v", err)
+	}
+
+	expected := []FlairTemplate{{
+		ID:               "a1b2",
+		Text:             ":go: Gopher",
+		TextEditable:     true,
+		CSSClass:         "gopher",
+		BackgroundColor:  "#00add8",
+		TextColor:        "light",
+		AllowableContent: "all",
+		MaxEmojis:        10,
+	}}
+	if diff := pretty.Compare(templates, expected); diff != "" {
+		t.Errorf("templates incorrect; diff: %s", diff)
+	}
+	if r.path != "/r/golang/api/link_flair_v2" {
+		t.Errorf("got path %s", r.path)
+	}
+
+	if _, err := a.UserFlairTemplates("golang"); err != nil {
+		t.Fatalf("error listing templates: %v", err)
+	}
+	if r.path != "/r/golang/api/user_flair_v2" {
+		t.Errorf("got path %s", r.path)
+	}
+}
+
+func TestEmojis(t *testing.T) {
+	emojis, err := parseEmojis([]byte(`{
+		"snoomojis": {
+			"cake": {"url": "https://e/cake.png", "post_flair_allowed": true}
+		},
+		"t5_2rc7j": {
+			"gopher": {"url": "https://e/gopher.png", "user_flair_allowed": true},
+			"badge": {"url": "https://e/badge.png", "mod_flair_only": true}
+		}
+	}`))
+	if err != nil {
+		t.Fatalf("error parsing emojis: %v", err)
+	}
+
+	expected := []Emoji{
+		{Name: "badge", URL: "https://e/badge.png", ModOnly: true},
+		{Name: "gopher", URL: "https://e/gopher.png", UserFlairAllowed: true},
+		{
+			Name:             "cake",
+			URL:              "https://e/cake.png",
+			PostFlairAllowed: true,
+			Snoomoji:         true,
+		},
+	}
+	if diff := pretty.Compare(emojis, expected); diff != "" {
+		t.Errorf("emojis incorrect; diff: %s", diff)
+	}
+}
+
+func TestFlairScopes(t *testing.T) {
+	for _, path := range []string{
+		"/r/golang/api/link_flair_v2",
+		"/r/golang/api/user_flair_v2",
+		"/r/golang/api/selectflair",
+	} {
+		if scope := scopeOf(path); scope != "flair" {
+			t.Errorf("got scope %s for %s; wanted flair", scope, path)
+		}
+	}
+	if scope := scopeOf("/r/golang/new"); scope != "read" {
+		t.Errorf("got scope %s for a listing; wanted read", scope)
+	}
+}
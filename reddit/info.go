@@ -0,0 +1,164 @@
+package reddit
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// infoWindow is how long lookups wait for others to share a request
+	// with.
+	infoWindow = 50 * time.Millisecond
+	// infoBatch is the most things Reddit looks up in one request.
+	infoBatch = 100
+)
+
+// infoCall is one caller's lookup, waiting on a batch.
+type infoCall struct {
+	names   []string
+	harvest Harvest
+	err     error
+	done    chan struct{}
+}
+
+// infoBatcher coalesces lookups by fullname. Lookups made within a short
+// window of each other, e.g. by many handlers resolving the parents of the
+// comments they were handed, share /api/info requests, so looking up many
+// things costs one request per hundred rather than one each.
+type infoBatcher struct {
+	r       reaper
+	window  time.Duration
+	pending []*infoCall
+	mu      *sync.Mutex
+}
+
+func newInfoBatcher(r reaper, window time.Duration) *infoBatcher {
+	return &infoBatcher{r: r, window: window, mu: &sync.Mutex{}}
+}
+
+// info returns the things with the fullnames, in the order they were named.
+// Things which do not exist are left out. If a request for some of them failed,
+// its error is returned with the things which were found.
+func (b *infoBatcher) info(names []string) (Harvest, error) {
+	if len(names) == 0 {
+		return Harvest{}, nil
+	}
+
+	call := &infoCall{names: names, done: make(chan struct{})}
+
+	b.mu.Lock()
+	b.pending = append(b.pending, call)
+	if len(b.pending) == 1 {
+		time.AfterFunc(b.window, b.flush)
+	}
+	b.mu.Unlock()
+
+	<-call.done
+	return call.harvest, call.err
+}
+
+// flush looks up everything the pending calls want and answers them.
+func (b *infoBatcher) flush() {
+	b.mu.Lock()
+	calls := b.pending
+	b.pending = nil
+	b.mu.Unlock()
+
+	var names []string
+	wanted := make(map[string]bool)
+	for _, call := range calls {
+		for _, name := range call.names {
+			if !wanted[name] {
+				wanted[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+
+	found, failed := b.lookup(names)
+	for _, call := range calls {
+		call.harvest = found.subset(call.names)
+		for _, name := range call.names {
+			if err, ok := failed[name]; ok {
+				call.err = err
+				break
+			}
+		}
+		close(call.done)
+	}
+}
+
+// lookup fetches the things with the fullnames, a batch at a time. A batch
+// which fails does not stop the rest; the error is returned for each name it
+// held, so only the lookups which wanted them fail.
+func (b *infoBatcher) lookup(names []string) (things, map[string]error) {
+	found := newThings()
+	failed := make(map[string]error)
+	for start := 0; start < len(names); start += infoBatch {
+		end := start + infoBatch
+		if end > len(names) {
+			end = len(names)
+		}
+
+		harvest, err := b.r.reap(
+			"/api/info",
+			map[string]string{
+				"id":       strings.Join(names[start:end], ","),
+				"raw_json": "1",
+			},
+		)
+		if err != nil {
+			for _, name := range names[start:end] {
+				failed[name] = err
+			}
+			continue
+		}
+		found.add(harvest)
+	}
+	return found, failed
+}
+
+// things indexes looked up things by fullname.
+type things struct {
+	posts    map[string]*Post
+	comments map[string]*Comment
+	messages map[string]*Message
+}
+
+func newThings() things {
+	return things{
+		posts:    make(map[string]*Post),
+		comments: make(map[string]*Comment),
+		messages: make(map[string]*Message),
+	}
+}
+
+func (t things) add(h Harvest) {
+	for _, p := range h.Posts {
+		t.posts[p.Name] = p
+	}
+	for _, c := range h.Comments {
+		t.comments[c.Name] = c
+	}
+	for _, m := range h.Messages {
+		t.messages[m.Name] = m
+	}
+}
+
+// subset returns the things with the fullnames, in order.
+func (t things) subset(names []string) Harvest {
+	var h Harvest
+	for _, name := range names {
+		if p, ok := t.posts[name]; ok {
+			h.Posts = append(h.Posts, p)
+		}
+		if c, ok := t.comments[name]; ok {
+			h.Comments = append(h.Comments, c)
+		}
+		if m, ok := t.messages[name]; ok {
+			h.Messages = append(h.Messages, m)
+		}
+	}
+	return h
+}
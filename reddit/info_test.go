@@ -0,0 +1,125 @@
+package reddit
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// infoReaper answers info lookups with a comment for every fullname asked
+// about except "t1_missing", and counts the requests it receives. Requests
+// asking about "t1_broken" fail.
+type infoReaper struct {
+	mockReaper
+	requests [][]string
+	mu       sync.Mutex
+}
+
+var infoBrokenErr = fmt.Errorf("broken")
+
+func (i *infoReaper) reap(path string, values map[string]string) (Harvest, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	names := strings.Split(values["id"], ",")
+	i.requests = append(i.requests, names)
+
+	var h Harvest
+	for _, name := range names {
+		if name == "t1_broken" {
+			return Harvest{}, infoBrokenErr
+		}
+		if name != "t1_missing" {
+			h.Comments = append(h.Comments, &Comment{Name: name})
+		}
+	}
+	return h, nil
+}
+
+func TestInfoCoalescesLookups(t *testing.T) {
+	r := &infoReaper{}
+	b := newInfoBatcher(r, 20*time.Millisecond)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			name := fmt.Sprintf("t1_%d", i)
+			h, err := b.info([]string{name, "t1_shared", "t1_missing"})
+			if err != nil {
+				t.Errorf("error looking up %s: %v", name, err)
+				return
+			}
+			if len(h.Comments) != 2 ||
+				h.Comments[0].Name != name ||
+				h.Comments[1].Name != "t1_shared" {
+				t.Errorf("got %v for %s; wanted it and t1_shared", h.Comments, name)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if len(r.requests) != 1 {
+		t.Fatalf("got %d requests; wanted 1", len(r.requests))
+	}
+	if len(r.requests[0]) != 12 {
+		t.Errorf("got %d names in request; wanted 12 unique", len(r.requests[0]))
+	}
+}
+
+func TestInfoBatchesLargeLookups(t *testing.T) {
+	r := &infoReaper{}
+	b := newInfoBatcher(r, time.Millisecond)
+
+	var names []string
+	for i := 0; i < 250; i++ {
+		names = append(names, fmt.Sprintf("t1_%d", i))
+	}
+
+	h, err := b.info(names)
+	if err != nil {
+		t.Fatalf("error looking up: %v", err)
+	}
+
+	if len(h.Comments) != 250 || len(r.requests) != 3 {
+		t.Errorf(
+			"got %d comments in %d requests; wanted 250 in 3",
+			len(h.Comments), len(r.requests),
+		)
+	}
+}
+
+func TestInfoBatchErrors(t *testing.T) {
+	r := &infoReaper{}
+	b := newInfoBatcher(r, time.Hour)
+
+	// The first call fills the first batch, so only it holds the failure.
+	var names []string
+	for i := 0; i < infoBatch-1; i++ {
+		names = append(names, fmt.Sprintf("t1_%d", i))
+	}
+	broken := &infoCall{
+		names: append(names, "t1_broken"),
+		done:  make(chan struct{}),
+	}
+	fine := &infoCall{names: []string{"t1_fine"}, done: make(chan struct{})}
+	b.pending = []*infoCall{broken, fine}
+	b.flush()
+
+	if broken.err != infoBrokenErr {
+		t.Errorf("got error %v for the broken batch; wanted it", broken.err)
+	}
+	if fine.err != nil {
+		t.Errorf("got error %v for the fine batch", fine.err)
+	}
+	if len(fine.harvest.Comments) != 1 ||
+		fine.harvest.Comments[0].Name != "t1_fine" {
+		t.Errorf("got %v; wanted t1_fine", fine.harvest.Comments)
+	}
+	if len(r.requests) != 2 {
+		t.Errorf("got %d requests; wanted 2", len(r.requests))
+	}
+}
@@ -0,0 +1,78 @@
+package reddit
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	// youngAccountAge and lowKarma are the age and karma below which
+	// Reddit is known to restrict how often an account may submit. Reddit
+	// does not publish its thresholds; these are conservative.
+	youngAccountAge = 30 * 24 * time.Hour
+	lowKarma        = 100
+	// restrictedCooldown is the time Reddit makes restricted accounts
+	// wait between submissions in a subreddit.
+	restrictedCooldown = 10 * time.Minute
+)
+
+// Limits describes the restrictions Reddit places on an account's writes.
+// Young and low karma accounts are throttled by Reddit ("you are doing that too
+// much"); a bot which paces itself to Cooldown avoids having its writes
+// rejected.
+type Limits struct {
+	// NeedsCaptcha is whether Reddit requires the account to solve a
+	// captcha to submit. Captchas cannot be solved through the API, so
+	// such accounts may be unable to post at all.
+	NeedsCaptcha bool
+	// Age is how long ago the account was created.
+	Age time.Duration
+	// Karma is the account's combined link and comment karma.
+	Karma int
+	// HasVerifiedEmail is whether the account has a verified email.
+	HasVerifiedEmail bool
+}
+
+// Restricted returns whether Reddit is likely to throttle the account's
+// submissions.
+func (l Limits) Restricted() bool {
+	return l.NeedsCaptcha || l.Age < youngAccountAge || l.Karma < lowKarma
+}
+
+// Cooldown returns how long the account should wait between submissions in
+// any one subreddit, or zero if it need not wait.
+func (l Limits) Cooldown() time.Duration {
+	if l.Restricted() {
+		return restrictedCooldown
+	}
+	return 0
+}
+
+func (a *account) Limits() (Limits, error) {
+	blob, err := a.r.reapRaw("/api/needs_captcha", nil)
+	if err != nil {
+		return Limits{}, err
+	}
+
+	var needsCaptcha bool
+	if err := json.Unmarshal(blob, &needsCaptcha); err != nil {
+		return Limits{}, err
+	}
+
+	me, err := a.Me()
+	if err != nil {
+		return Limits{}, err
+	}
+
+	return limitsOf(me, needsCaptcha, time.Now()), nil
+}
+
+// limitsOf returns the limits of the account as of now.
+func limitsOf(me *Redditor, needsCaptcha bool, now time.Time) Limits {
+	return Limits{
+		NeedsCaptcha:     needsCaptcha,
+		Age:              now.Sub(me.Created()),
+		Karma:            int(me.LinkKarma) + int(me.CommentKarma),
+		HasVerifiedEmail: me.HasVerifiedEmail,
+	}
+}
@@ -0,0 +1,44 @@
+package reddit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLimits(t *testing.T) {
+	now := time.Unix(1500000000, 0)
+	for i, test := range []struct {
+		me           *Redditor
+		needsCaptcha bool
+		cooldown     time.Duration
+	}{
+		{
+			&Redditor{CreatedUTC: 1400000000, LinkKarma: 90, CommentKarma: 20},
+			false,
+			0,
+		},
+		{
+			&Redditor{CreatedUTC: 1400000000, LinkKarma: 90, CommentKarma: 20},
+			true,
+			restrictedCooldown,
+		},
+		{
+			&Redditor{CreatedUTC: 1400000000, LinkKarma: 50},
+			false,
+			restrictedCooldown,
+		},
+		{
+			&Redditor{CreatedUTC: 1499990000, CommentKarma: 5000},
+			false,
+			restrictedCooldown,
+		},
+	} {
+		limits := limitsOf(test.me, test.needsCaptcha, now)
+		if cooldown := limits.Cooldown(); cooldown != test.cooldown {
+			t.Errorf(
+				"%d: got cooldown %v for %+v; wanted %v",
+				i, cooldown, limits, test.cooldown,
+			)
+		}
+	}
+}
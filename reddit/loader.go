@@ -8,18 +8,6 @@ import (
 	"github.com/turnage/redditproto"
 )
 
-// load loads the user agent and App config from an AgentFile (legacy graw 0.3.0
-// file format).
-func load(filename string) (string, App, error) {
-	agentPB, err := loadAgentFile(filename)
-	return agentPB.GetUserAgent(), App{
-		ID:       agentPB.GetClientId(),
-		Secret:   agentPB.GetClientSecret(),
-		Username: agentPB.GetUsername(),
-		Password: agentPB.GetPassword(),
-	}, err
-}
-
 // loadAgentFile reads a user agent from a protobuffer file and returns it.
 func loadAgentFile(filename string) (*redditproto.UserAgent, error) {
 	buf, err := ioutil.ReadFile(filename)
@@ -27,6 +15,12 @@ func loadAgentFile(filename string) (*redditproto.UserAgent, error) {
 		return nil, err
 	}
 
+	return unmarshalAgent(buf)
+}
+
+// unmarshalAgent parses a user agent in protobuffer text format (legacy graw
+// 0.3.0 file format).
+func unmarshalAgent(buf []byte) (*redditproto.UserAgent, error) {
 	agent := &redditproto.UserAgent{}
 	return agent, proto.UnmarshalText(bytes.NewBuffer(buf).String(), agent)
 }
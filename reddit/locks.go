@@ -0,0 +1,123 @@
+package reddit
+
+import (
+	"sync"
+)
+
+// maxLocks is the number of locked and archived things the lock book
+// remembers.
+const maxLocks = 10000
+
+// lockBook records the posts and comments the bot has seen locked or archived,
+// as it reads them, so replies to them can be refused without a request
+// Reddit is certain to reject.
+type lockBook struct {
+	// refusals are the errors replies to locked and archived things are
+	// refused with, by name, and order is the order they were recorded
+	// in, for eviction.
+	refusals map[string]error
+	order    []string
+	mu       *sync.Mutex
+}
+
+func newLockBook() *lockBook {
+	return &lockBook{
+		refusals: make(map[string]error),
+		mu:       &sync.Mutex{},
+	}
+}
+
+// record records the state of the posts and comments in the harvest, and of
+// the comments under them. Comments in threads recorded locked or archived are
+// recorded so too, since Reddit does not mark them. Things seen open again are
+// forgotten, e.g. when a moderator unlocks a thread.
+func (b *lockBook) record(h Harvest) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, p := range h.Posts {
+		b.set(p.Name, "", p.Locked, p.Archived)
+		b.recordComments(p.Replies)
+	}
+	b.recordComments(h.Comments)
+}
+
+func (b *lockBook) recordComments(comments []*Comment) {
+	for _, c := range comments {
+		b.set(c.Name, c.LinkID, c.Locked, c.Archived)
+		b.recordComments(c.Replies)
+	}
+}
+
+func (b *lockBook) set(name, thread string, locked, archived bool) {
+	var refusal error
+	switch {
+	case archived:
+		refusal = ThreadArchivedErr
+	case locked:
+		refusal = ThreadLockedErr
+	case thread != "":
+		refusal = b.refusals[thread]
+	}
+
+	if refusal == nil {
+		delete(b.refusals, name)
+		return
+	}
+
+	if _, ok := b.refusals[name]; !ok {
+		b.order = append(b.order, name)
+		if len(b.order) > maxLocks {
+			delete(b.refusals, b.order[0])
+			b.order = b.order[1:]
+		}
+	}
+	b.refusals[name] = refusal
+}
+
+// refusal returns the error a reply to the parent is refused with, or nil if
+// the parent is not known to be locked or archived.
+func (b *lockBook) refusal(parentName string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.refusals[parentName]
+}
+
+// lockReaper records the state of the things it reaps in a lock book.
+type lockReaper struct {
+	reaper
+	book *lockBook
+}
+
+func (r *lockReaper) reap(
+	path string,
+	values map[string]string,
+) (Harvest, error) {
+	h, err := r.reaper.reap(path, values)
+	r.book.record(h)
+	return h, err
+}
+
+func (r *lockReaper) triggered(name string) reaper {
+	return &lockReaper{reaper: triggered(r.reaper, name), book: r.book}
+}
+
+// lockGuard refuses replies to things the bot has seen locked or archived.
+type lockGuard struct {
+	Account
+	book *lockBook
+}
+
+func (g *lockGuard) Triggered(name string) Account {
+	return &lockGuard{Account: g.Account.Triggered(name), book: g.book}
+}
+
+// Reply replies to the parent, unless it, or the thread it is in, was locked
+// or archived when the bot last read it.
+func (g *lockGuard) Reply(parentName, text string) error {
+	if err := g.book.refusal(parentName); err != nil {
+		return err
+	}
+	return g.Account.Reply(parentName, text)
+}
@@ -0,0 +1,71 @@
+package reddit
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestLockGuard(t *testing.T) {
+	r := reaperWhich(
+		Harvest{
+			Posts: []*Post{{
+				Name:   "t3_locked",
+				Locked: true,
+				Replies: []*Comment{
+					{Name: "t1_under", LinkID: "t3_locked"},
+				},
+			}},
+			Comments: []*Comment{
+				{Name: "t1_old", Archived: true},
+				{Name: "t1_open", LinkID: "t3_open"},
+			},
+		},
+		nil,
+	)
+	book := newLockBook()
+	lr := &lockReaper{reaper: r, book: book}
+	g := &lockGuard{Account: newAccount(lr, SplitConfig{}), book: book}
+
+	if _, err := lr.reap("/r/golang/comments/locked", nil); err != nil {
+		t.Fatalf("error reaping: %v", err)
+	}
+
+	for parent, want := range map[string]error{
+		"t3_locked": ThreadLockedErr,
+		"t1_under":  ThreadLockedErr,
+		"t1_old":    ThreadArchivedErr,
+		"t1_open":   nil,
+		"t1_unseen": nil,
+	} {
+		r.path = ""
+		if err := g.Reply(parent, "hi"); err != want {
+			t.Errorf("%s: got error %v; wanted %v", parent, err, want)
+		}
+		if sent := r.path == "/api/comment"; sent != (want == nil) {
+			t.Errorf("%s: sent %v; wanted %v", parent, sent, want == nil)
+		}
+	}
+
+	// A thread seen unlocked again is forgotten.
+	r.h = Harvest{Posts: []*Post{{Name: "t3_locked"}}}
+	if _, err := lr.reap("/r/golang/comments/locked", nil); err != nil {
+		t.Fatalf("error reaping: %v", err)
+	}
+	if err := g.Reply("t3_locked", "hi"); err != nil {
+		t.Errorf("got error %v for an unlocked thread", err)
+	}
+}
+
+func TestLockBookEviction(t *testing.T) {
+	book := newLockBook()
+	for i := 0; i <= maxLocks; i++ {
+		book.set("t3_"+strconv.Itoa(i), "", true, false)
+	}
+
+	if len(book.refusals) != maxLocks || len(book.order) != maxLocks {
+		t.Errorf(
+			"got %d refusals in order %d; wanted %d",
+			len(book.refusals), len(book.order), maxLocks,
+		)
+	}
+}
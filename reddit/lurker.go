@@ -1,28 +1,176 @@
 package reddit
 
+import (
+	"strconv"
+)
+
+// Comment sorts Reddit supports for threads.
+const (
+	CommentSortBest          = "confidence"
+	CommentSortTop           = "top"
+	CommentSortNew           = "new"
+	CommentSortOld           = "old"
+	CommentSortControversial = "controversial"
+	CommentSortQA            = "qa"
+)
+
+// Subreddits Reddit picks at random, for Random: any subreddit, or any NSFW
+// subreddit.
+const (
+	RandomSubreddit     = "random"
+	RandomNSFWSubreddit = "randnsfw"
+)
+
+// maxStickies is the most posts a subreddit can sticky.
+const maxStickies = 2
+
+// ThreadOptions select which slice of a thread's discussion is fetched. Large
+// threads are expensive to fetch whole; limiting the depth and number of
+// comments makes each fetch cheaper.
+type ThreadOptions struct {
+	// Sort is the order comments are fetched in, one of the CommentSort
+	// constants. The default is the subreddit's suggested sort.
+	Sort string
+	// Depth is the maximum depth of the comment tree fetched. Zero fetches
+	// as deep as Reddit allows.
+	Depth int
+	// Limit is the maximum number of comments fetched. Zero fetches as
+	// many as Reddit allows.
+	Limit int
+}
+
+func (t ThreadOptions) values() map[string]string {
+	values := map[string]string{"raw_json": "1"}
+	if t.Sort != "" {
+		values["sort"] = t.Sort
+	}
+	if t.Depth > 0 {
+		values["depth"] = strconv.Itoa(t.Depth)
+	}
+	if t.Limit > 0 {
+		values["limit"] = strconv.Itoa(t.Limit)
+	}
+	return values
+}
+
 // Lurker defines browsing behavior.
 type Lurker interface {
 	// Thread returns a Reddit post with a fully parsed comment tree.
 	Thread(permalink string) (*Post, error)
+	// ThreadWithOptions returns a Reddit post with the slice of its
+	// comment tree selected by the options.
+	ThreadWithOptions(permalink string, opts ThreadOptions) (*Post, error)
+	// Info returns the posts and comments with the given fullnames (e.g.
+	// t3_5du939), in the order they were named; things which do not
+	// exist are left out. Lookups made concurrently, such as by several
+	// handlers at once, are coalesced into shared requests.
+	Info(fullnames ...string) (Harvest, error)
+	// ParentChain returns the ancestry of the comment with the given
+	// fullname: its parent comments, nearest first, in Comments, and the
+	// post they are in in Posts. At most depth ancestors are returned;
+	// zero walks all the way to the post. Each step up the chain costs a
+	// request, shared with concurrent lookups as in Info.
+	ParentChain(fullname string, depth int) (Harvest, error)
+	// Random returns a random post from the subreddit (e.g. golang),
+	// with its comment tree. Pass RandomSubreddit or RandomNSFWSubreddit
+	// to pick the subreddit at random too.
+	Random(subreddit string) (*Post, error)
+	// Stickies returns the posts stickied in the subreddit (e.g. golang),
+	// at most two, in the order they are pinned, without their comments.
+	Stickies(subreddit string) ([]*Post, error)
+	// Subreddits describes the subreddits with the given names (e.g.
+	// golang), in no particular order; subreddits which do not exist, or
+	// are banned, are left out. Names are looked up a hundred to a
+	// request.
+	Subreddits(names ...string) ([]*Subreddit, error)
+	// WikiPage returns a page of the subreddit's wiki (e.g. config/rules
+	// of golang). It needs the wikiread scope.
+	WikiPage(subreddit, page string) (*WikiPage, error)
 }
 
 type lurker struct {
-	r reaper
+	r    reaper
+	info *infoBatcher
 }
 
 func newLurker(r reaper) Lurker {
-	return &lurker{r: r}
+	return &lurker{r: r, info: newInfoBatcher(r, infoWindow)}
 }
 
-func (s *lurker) Thread(permalink string) (*Post, error) {
+func (s *lurker) Info(fullnames ...string) (Harvest, error) {
+	return s.info.info(fullnames)
+}
+
+func (s *lurker) ParentChain(fullname string, depth int) (Harvest, error) {
+	var chain Harvest
+	for name := fullname; depth <= 0 || len(chain.Comments) < depth; {
+		h, err := s.Info(name)
+		if err != nil {
+			return chain, err
+		}
+
+		if len(h.Posts) == 1 {
+			chain.Posts = h.Posts
+			return chain, nil
+		}
+
+		if len(h.Comments) != 1 {
+			return chain, ThingDoesNotExistErr
+		}
+
+		if name != fullname {
+			chain.Comments = append(chain.Comments, h.Comments[0])
+		}
+		name = h.Comments[0].ParentID
+	}
+	return chain, nil
+}
+
+func (s *lurker) Random(subreddit string) (*Post, error) {
+	// Reddit redirects the random listing to the permalink of the post
+	// it picked.
+	return s.ThreadWithOptions(
+		"/r/"+subreddit+"/random",
+		ThreadOptions{},
+	)
+}
+
+func (s *lurker) Stickies(subreddit string) ([]*Post, error) {
+	// Stickied posts are pinned to the top of the hot listing, so the
+	// first page holds them all.
 	harvest, err := s.r.reap(
-		permalink+".json",
-		map[string]string{"raw_json": "1"},
+		"/r/"+subreddit+"/hot",
+		map[string]string{
+			"raw_json": "1",
+			"limit":    strconv.Itoa(maxStickies),
+		},
 	)
 	if err != nil {
 		return nil, err
 	}
 
+	var stickies []*Post
+	for _, p := range harvest.Posts {
+		if p.Stickied {
+			stickies = append(stickies, p)
+		}
+	}
+	return stickies, nil
+}
+
+func (s *lurker) Thread(permalink string) (*Post, error) {
+	return s.ThreadWithOptions(permalink, ThreadOptions{})
+}
+
+func (s *lurker) ThreadWithOptions(
+	permalink string,
+	opts ThreadOptions,
+) (*Post, error) {
+	harvest, err := s.r.reap(permalink+".json", opts.values())
+	if err != nil {
+		return nil, err
+	}
+
 	if len(harvest.Posts) != 1 {
 		return nil, ThreadDoesNotExistErr
 	}
@@ -2,6 +2,7 @@ package reddit
 
 import (
 	"testing"
+	"time"
 
 	"github.com/kylelemons/godebug/pretty"
 )
@@ -33,3 +34,189 @@ func TestThreadReturnsEmpty(t *testing.T) {
 		t.Errorf("err unexpected; wanted DoesNotExistErr; got %v", err)
 	}
 }
+
+func TestThreadWithOptions(t *testing.T) {
+	r := reaperWhich(Harvest{Posts: []*Post{&Post{}}}, nil)
+	s := newLurker(r)
+
+	if _, err := s.ThreadWithOptions(
+		"/r/golang/comments/abc",
+		ThreadOptions{Sort: CommentSortNew, Depth: 1, Limit: 50},
+	); err != nil {
+		t.Errorf("error pulling thread: %v", err)
+	}
+
+	expected := map[string]string{
+		"raw_json": "1",
+		"sort":     "new",
+		"depth":    "1",
+		"limit":    "50",
+	}
+	if diff := pretty.Compare(r.values, expected); diff != "" {
+		t.Errorf("values incorrect; diff: %s", diff)
+	}
+}
+
+func TestRandom(t *testing.T) {
+	post := &Post{Name: "t3_abc"}
+	r := reaperWhich(Harvest{Posts: []*Post{post}}, nil)
+	s := newLurker(r)
+
+	got, err := s.Random(RandomNSFWSubreddit)
+	if err != nil {
+		t.Fatalf("error pulling random post: %v", err)
+	}
+
+	if got != post {
+		t.Errorf("got post %+v; wanted %+v", got, post)
+	}
+	if r.path != "/r/randnsfw/random.json" {
+		t.Errorf("got path %s; wanted /r/randnsfw/random.json", r.path)
+	}
+}
+
+func TestStickies(t *testing.T) {
+	h := Harvest{
+		Posts: []*Post{
+			&Post{Name: "t3_a", Stickied: true},
+			&Post{Name: "t3_b"},
+		},
+	}
+	r := reaperWhich(h, nil)
+	s := newLurker(r)
+
+	stickies, err := s.Stickies("golang")
+	if err != nil {
+		t.Fatalf("error pulling stickies: %v", err)
+	}
+
+	if len(stickies) != 1 || stickies[0].Name != "t3_a" {
+		t.Errorf("got stickies %+v; wanted t3_a", stickies)
+	}
+	if r.path != "/r/golang/hot" || r.values["limit"] != "2" {
+		t.Errorf("got path %s and values %v", r.path, r.values)
+	}
+}
+
+func TestSubreddits(t *testing.T) {
+	r := &mockReaper{
+		raw: []byte(`{"kind": "Listing", "data": {"children": [
+			{"kind": "t5", "data": {
+				"name": "t5_2rc7j",
+				"display_name": "golang",
+				"subreddit_type": "public",
+				"subscribers": 200000,
+				"user_is_contributor": null
+			}},
+			{"kind": "t5", "data": {
+				"name": "t5_x",
+				"display_name": "secret",
+				"subreddit_type": "private"
+			}}
+		]}}`),
+	}
+	s := newLurker(r)
+
+	subreddits, err := s.Subreddits("golang", "secret", "gloang")
+	if err != nil {
+		t.Fatalf("error looking up subreddits: %v", err)
+	}
+
+	if len(subreddits) != 2 {
+		t.Fatalf("got %d subreddits; wanted 2", len(subreddits))
+	}
+	if sr := subreddits[0]; sr.DisplayName != "golang" ||
+		sr.Subscribers != 200000 || !sr.Readable() {
+		t.Errorf("got subreddit %+v; wanted readable golang", sr)
+	}
+	if sr := subreddits[1]; sr.Type != SubredditPrivate || sr.Readable() {
+		t.Errorf("got subreddit %+v; wanted unreadable private", sr)
+	}
+	if r.path != "/api/info" {
+		t.Errorf("got path %s; wanted /api/info", r.path)
+	}
+}
+
+func TestWikiPage(t *testing.T) {
+	r := &mockReaper{
+		raw: []byte(`{"kind": "wikipage", "data": {
+			"content_md": "* spam\n* scam",
+			"may_revise": true,
+			"revision_id": "6fd6e4b4-f1e2-11e6-a1a1-0e4a5e2b3c01",
+			"revision_date": 1486847000,
+			"revision_by": {"kind": "t2", "data": {"name": "roxven"}}
+		}}`),
+	}
+	s := newLurker(r)
+
+	page, err := s.WikiPage("golang", "graw/spam")
+	if err != nil {
+		t.Fatalf("error fetching wiki page: %v", err)
+	}
+	if diff := pretty.Compare(page, &WikiPage{
+		Content:      "* spam\n* scam",
+		RevisionID:   "6fd6e4b4-f1e2-11e6-a1a1-0e4a5e2b3c01",
+		RevisionDate: 1486847000,
+		RevisionBy:   "roxven",
+		MayRevise:    true,
+	}); diff != "" {
+		t.Errorf("wiki page incorrect; diff: %s", diff)
+	}
+	if r.path != "/r/golang/wiki/graw/spam" {
+		t.Errorf("got path %s; wanted /r/golang/wiki/graw/spam", r.path)
+	}
+	if scope := scopeOf(r.path); scope != "wikiread" {
+		t.Errorf("got scope %s for a wiki page; wanted wikiread", scope)
+	}
+}
+
+// chainReaper answers info lookups from a set of comments and posts.
+type chainReaper struct {
+	mockReaper
+	comments map[string]*Comment
+	posts    map[string]*Post
+}
+
+func (c *chainReaper) reap(path string, values map[string]string) (Harvest, error) {
+	var h Harvest
+	if comment, ok := c.comments[values["id"]]; ok {
+		h.Comments = append(h.Comments, comment)
+	}
+	if post, ok := c.posts[values["id"]]; ok {
+		h.Posts = append(h.Posts, post)
+	}
+	return h, nil
+}
+
+func TestParentChain(t *testing.T) {
+	r := &chainReaper{
+		comments: map[string]*Comment{
+			"t1_c": &Comment{Name: "t1_c", ParentID: "t1_b"},
+			"t1_b": &Comment{Name: "t1_b", ParentID: "t1_a"},
+			"t1_a": &Comment{Name: "t1_a", ParentID: "t3_post"},
+			"t1_z": &Comment{Name: "t1_z", ParentID: "t1_gone"},
+		},
+		posts: map[string]*Post{"t3_post": &Post{Name: "t3_post"}},
+	}
+	s := &lurker{r: r, info: newInfoBatcher(r, time.Millisecond)}
+
+	chain, err := s.ParentChain("t1_c", 0)
+	if err != nil {
+		t.Fatalf("error walking chain: %v", err)
+	}
+	if len(chain.Comments) != 2 ||
+		chain.Comments[0].Name != "t1_b" ||
+		chain.Comments[1].Name != "t1_a" ||
+		len(chain.Posts) != 1 {
+		t.Errorf("got %+v; wanted t1_b, t1_a, and the post", chain)
+	}
+
+	chain, err = s.ParentChain("t1_c", 1)
+	if err != nil || len(chain.Comments) != 1 || len(chain.Posts) != 0 {
+		t.Errorf("got %+v, %v; wanted only t1_b", chain, err)
+	}
+
+	if _, err := s.ParentChain("t1_z", 0); err != ThingDoesNotExistErr {
+		t.Errorf("got %v; wanted %v", err, ThingDoesNotExistErr)
+	}
+}
@@ -5,21 +5,13 @@ import (
 )
 
 type mockParser struct {
-	comments []*Comment
-	posts    []*Post
-	messages []*Message
+	h Harvest
 }
 
-func (m *mockParser) parse(
-	blob json.RawMessage,
-) ([]*Comment, []*Post, []*Message, error) {
-	return m.comments, m.posts, m.messages, nil
+func (m *mockParser) parse(blob json.RawMessage) (Harvest, error) {
+	return m.h, nil
 }
 
 func parserWhich(h Harvest) parser {
-	return &mockParser{
-		comments: h.Comments,
-		posts:    h.Posts,
-		messages: h.Messages,
-	}
+	return &mockParser{h: h}
 }
@@ -4,21 +4,45 @@ package reddit
 type mockReaper struct {
 	// path is the path received by the most recent Reap or Sow call.
 	path string
+	// values are the values received by the most recent reap call.
+	values map[string]string
+	// planted is the values of each plant call received, in order.
+	planted []map[string]string
 
 	h   Harvest
+	raw []byte
 	err error
 }
 
-func (m *mockReaper) reap(path string, _ map[string]string) (Harvest, error) {
+func (m *mockReaper) reap(path string, values map[string]string) (Harvest, error) {
 	m.path = path
+	m.values = values
 	return m.h, m.err
 }
 
+func (m *mockReaper) reapRaw(path string, _ map[string]string) ([]byte, error) {
+	m.path = path
+	return m.raw, m.err
+}
+
 func (m *mockReaper) sow(path string, _ map[string]string) error {
 	m.path = path
 	return m.err
 }
 
+func (m *mockReaper) plant(
+	path string,
+	values map[string]string,
+) (submission, error) {
+	m.path = path
+	m.planted = append(m.planted, values)
+	return submission{name: "t1_" + string('a'+rune(len(m.planted)))}, m.err
+}
+
+func (m *mockReaper) granted() []string {
+	return nil
+}
+
 func reaperWhich(h Harvest, err error) *mockReaper {
 	return &mockReaper{
 		h:   h,
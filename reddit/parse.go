@@ -3,6 +3,9 @@ package reddit
 import (
 	"encoding/json"
 	"fmt"
+	"reflect"
+	"sort"
+	"strings"
 
 	"github.com/mitchellh/mapstructure"
 )
@@ -12,22 +15,25 @@ const (
 	postKind    = "t3"
 	commentKind = "t1"
 	messageKind = "t4"
+	// subredditKind is only found in lookups of subreddits; see
+	// Lurker.Subreddits.
+	subredditKind = "t5"
 )
 
 // author fields and body fields are set to the deletedKey if the user deletes
 // their post.
 const deletedKey = "[deleted]"
 
+// body fields are set to the removedKey for users other than the author and
+// moderators if a moderator or Reddit's spam filter removes the thing.
+const removedKey = "[removed]"
+
 // thing is a Reddit type that holds all of their subtypes.
 type thing struct {
 	Kind string                 `json:"kind"`
 	Data map[string]interface{} `json:"data"`
 }
 
-type listing struct {
-	Children []thing `json:"children,omitempty"`
-}
-
 // comment wraps the user facing Comment type with a Replies field for
 // intermediate parsing.
 type comment struct {
@@ -35,10 +41,46 @@ type comment struct {
 	Replies thing `mapstructure:"replies"`
 }
 
+var (
+	// commentFields, postFields, and messageFields are the fields of
+	// things which are read, lowercased. The rest are unknown.
+	commentFields = fieldsOf(reflect.TypeOf(comment{}))
+	postFields    = fieldsOf(reflect.TypeOf(Post{}), "crosspost_parent_list")
+	messageFields = fieldsOf(reflect.TypeOf(Message{}), "was_comment")
+)
+
+// ParseWarning is a problem with part of a response from Reddit which did not
+// stop the rest of it from being parsed. Reddit changes its responses without
+// notice; rather than failing a whole listing because one thing in it is not
+// as expected, the parser leaves out what it cannot read and warns about it.
+type ParseWarning struct {
+	// Kind is the kind of the thing the problem is in, e.g. "t3" for a
+	// post, or "Listing".
+	Kind string
+	// Name is the full name of the thing, if it could be read.
+	Name string
+	// Field is the field which could not be read, which is left zero. If
+	// it is empty, the whole thing could not be read, and is left out.
+	Field string
+	// Err is the problem.
+	Err error
+}
+
+func (w ParseWarning) Error() string {
+	what := w.Kind
+	if w.Name != "" {
+		what = w.Name
+	}
+	if w.Field != "" {
+		return fmt.Sprintf("%s field %q: %v", what, w.Field, w.Err)
+	}
+	return fmt.Sprintf("%s left out: %v", what, w.Err)
+}
+
 // parser parses Reddit responses..
 type parser interface {
 	// parse parses any Reddit response and provides the elements in it.
-	parse(blob json.RawMessage) ([]*Comment, []*Post, []*Message, error)
+	parse(blob json.RawMessage) (Harvest, error)
 }
 
 type parserImpl struct{}
@@ -48,89 +90,137 @@ func newParser() parser {
 }
 
 // parse parses any Reddit response and provides the elements in it.
-func (p *parserImpl) parse(
-	blob json.RawMessage,
-) ([]*Comment, []*Post, []*Message, error) {
-	comments, posts, msgs, listingErr := parseRawListing(blob)
+func (p *parserImpl) parse(blob json.RawMessage) (Harvest, error) {
+	h, listingErr := parseRawListing(blob)
 	if listingErr == nil {
-		return comments, posts, msgs, nil
+		return h, nil
 	}
 
-	post, threadErr := parseThread(blob)
+	h, threadErr := parseThread(blob)
 	if threadErr == nil {
-		return nil, []*Post{post}, nil, nil
+		return h, nil
 	}
 
-	return nil, nil, nil, fmt.Errorf(
+	return Harvest{}, fmt.Errorf(
 		"failed to parse as listing [%v] or thread [%v]",
 		listingErr, threadErr,
 	)
 }
 
 // parseRawListing parses a listing json blob and returns the elements in it.
-func parseRawListing(
-	blob json.RawMessage,
-) ([]*Comment, []*Post, []*Message, error) {
+func parseRawListing(blob json.RawMessage) (Harvest, error) {
 	var activityListing thing
 	if err := json.Unmarshal(blob, &activityListing); err != nil {
-		return nil, nil, nil, err
+		return Harvest{}, err
 	}
 
-	return parseListing(&activityListing)
+	p := &parsing{}
+	comments, posts, msgs, err := p.listing(&activityListing)
+	if err != nil {
+		return Harvest{}, err
+	}
+	return p.harvest(comments, posts, msgs), nil
 }
 
 // parseThread parses a post from a thread json blob returned by Reddit.
 //
 // Reddit structures this as two things in an array, the first thing being a
 // listing with only the post and the second thing being a listing of comments.
-func parseThread(blob json.RawMessage) (*Post, error) {
+func parseThread(blob json.RawMessage) (Harvest, error) {
 	var listings [2]thing
 	if err := json.Unmarshal(blob, &listings); err != nil {
-		return nil, err
+		return Harvest{}, err
 	}
 
-	_, posts, _, err := parseListing(&listings[0])
+	p := &parsing{}
+	_, posts, _, err := p.listing(&listings[0])
 	if err != nil {
-		return nil, err
+		return Harvest{}, err
 	}
 
 	if len(posts) != 1 {
-		return nil, fmt.Errorf("expected 1 post; found %d", len(posts))
+		return Harvest{}, fmt.Errorf("expected 1 post; found %d", len(posts))
 	}
 
-	comments, _, _, err := parseListing(&listings[1])
+	// The post is worth having without its comments.
+	comments, _, _, err := p.listing(&listings[1])
 	if err != nil {
-		return nil, err
+		p.warn(ParseWarning{Kind: listingKind, Err: err})
 	}
 
 	posts[0].Replies = comments
-	return posts[0], nil
+	return p.harvest(nil, posts, nil), nil
 }
 
-// parseListing parses a Reddit listing type and returns the elements inside it.
-func parseListing(t *thing) ([]*Comment, []*Post, []*Message, error) {
+// parsing holds what is learned about a response while it is parsed: the
+// problems with it, and the fields of its things which are not read.
+type parsing struct {
+	warnings []ParseWarning
+	// unknown is the set of unknown fields of each kind of thing.
+	unknown map[string]map[string]bool
+}
+
+// harvest returns a harvest of the elements with what was learned about the
+// response.
+func (p *parsing) harvest(
+	comments []*Comment,
+	posts []*Post,
+	msgs []*Message,
+) Harvest {
+	h := Harvest{
+		Comments: comments,
+		Posts:    posts,
+		Messages: msgs,
+		Warnings: p.warnings,
+	}
+	for kind, fields := range p.unknown {
+		if h.Unknown == nil {
+			h.Unknown = make(map[string][]string)
+		}
+		for field := range fields {
+			h.Unknown[kind] = append(h.Unknown[kind], field)
+		}
+		sort.Strings(h.Unknown[kind])
+	}
+	return h
+}
+
+func (p *parsing) warn(w ParseWarning) {
+	p.warnings = append(p.warnings, w)
+}
+
+// listing parses a Reddit listing type and returns the elements inside it.
+// Things in it which cannot be read are left out and warned about.
+func (p *parsing) listing(t *thing) ([]*Comment, []*Post, []*Message, error) {
 	if t.Kind != listingKind {
 		return nil, nil, nil, fmt.Errorf("thing is not listing")
 	}
 
-	l := &listing{}
-	if err := mapstructure.Decode(t.Data, l); err != nil {
-		return nil, nil, nil, mapDecodeError(err, t.Data)
+	children, ok := t.Data["children"].([]interface{})
+	if !ok && t.Data["children"] != nil {
+		return nil, nil, nil, fmt.Errorf(
+			"listing children are %T, not a list",
+			t.Data["children"],
+		)
 	}
 
 	comments := []*Comment{}
 	posts := []*Post{}
 	msgs := []*Message{}
-	err := error(nil)
 
-	for _, c := range l.Children {
-		if err != nil {
-			break
+	for _, child := range children {
+		c := thing{}
+		if err := mapstructure.Decode(child, &c); err != nil {
+			p.warn(ParseWarning{Kind: listingKind, Err: err})
+			continue
+		}
+		if c.Data == nil {
+			p.warn(ParseWarning{
+				Kind: c.Kind,
+				Err:  fmt.Errorf("thing has no data"),
+			})
+			continue
 		}
-
-		var comment *Comment
-		var post *Post
-		var msg *Message
 
 		// Reddit sets the "Kind" field of comments in the inbox, which
 		// have only Message and not Comment fields, to commentKind. The
@@ -138,22 +228,25 @@ func parseListing(t *thing) ([]*Comment, []*Post, []*Message, error) {
 		// a field called "was_comment". Reddit does this because they
 		// hate programmers.
 		if c.Kind == messageKind || c.Data["was_comment"] != nil {
-			msg, err = parseMessage(&c)
-			msgs = append(msgs, msg)
+			if msg, err := p.message(&c); err == nil {
+				msgs = append(msgs, msg)
+			}
 		} else if c.Kind == commentKind {
-			comment, err = parseComment(&c)
-			comments = append(comments, comment)
+			if comment, err := p.comment(&c); err == nil {
+				comments = append(comments, comment)
+			}
 		} else if c.Kind == postKind {
-			post, err = parsePost(&c)
-			posts = append(posts, post)
+			if post, err := p.post(&c); err == nil {
+				posts = append(posts, post)
+			}
 		}
 	}
 
-	return comments, posts, msgs, err
+	return comments, posts, msgs, nil
 }
 
-// parseComment parses a comment into the user facing Comment struct.
-func parseComment(t *thing) (*Comment, error) {
+// comment parses a comment into the user facing Comment struct.
+func (p *parsing) comment(t *thing) (*Comment, error) {
 	// Reddit makes the replies field a string if it is empty, just to make
 	// it harder for programmers who like static type systems.
 	value, present := t.Data["replies"]
@@ -163,35 +256,180 @@ func parseComment(t *thing) (*Comment, error) {
 		}
 	}
 
+	uneditedAsZero(t)
+	spamFilterAsBanner(t)
+
 	c := &comment{}
-	if err := mapstructure.Decode(t.Data, c); err != nil {
-		return nil, mapDecodeError(err, t.Data)
+	if err := p.decode(commentKind, t, c, commentFields); err != nil {
+		return nil, err
 	}
 
-	var err error
 	if c.Replies.Kind == listingKind {
-		c.Comment.Replies, _, _, err = parseListing(&c.Replies)
+		replies, _, _, err := p.listing(&c.Replies)
+		if err != nil {
+			p.warn(ParseWarning{
+				Kind:  commentKind,
+				Name:  c.Comment.Name,
+				Field: "replies",
+				Err:   err,
+			})
+		}
+		c.Comment.Replies = replies
 	}
 
 	c.Comment.Deleted = c.Comment.Body == deletedKey
-	return &c.Comment, err
+	return &c.Comment, nil
 }
 
-// parsePost parses a post into the user facing Post struct.
-func parsePost(t *thing) (*Post, error) {
-	p := &Post{}
-	if err := mapstructure.Decode(t.Data, p); err != nil {
-		return nil, mapDecodeError(err, t.Data)
+// post parses a post into the user facing Post struct.
+func (p *parsing) post(t *thing) (*Post, error) {
+	uneditedAsZero(t)
+	spamFilterAsBanner(t)
+
+	post := &Post{}
+	if err := p.decode(postKind, t, post, postFields); err != nil {
+		return nil, err
+	}
+
+	// Crossposts carry the post they crosspost whole, which is parsed as
+	// a post of its own.
+	if parents, ok := t.Data["crosspost_parent_list"].([]interface{}); ok {
+		for _, parent := range parents {
+			data, ok := parent.(map[string]interface{})
+			if !ok {
+				continue
+			}
+
+			parentPost, err := p.post(&thing{Kind: postKind, Data: data})
+			if err != nil {
+				continue
+			}
+			post.CrosspostParentList = append(
+				post.CrosspostParentList,
+				parentPost,
+			)
+		}
 	}
 
-	p.Deleted = p.SelfText == deletedKey
-	return p, nil
+	post.Deleted = post.SelfText == deletedKey
+	return post, nil
 }
 
-// parseMessage parses a message into the user facing Message struct.
-func parseMessage(t *thing) (*Message, error) {
+// message parses a message into the user facing Message struct.
+func (p *parsing) message(t *thing) (*Message, error) {
 	m := &Message{}
-	return m, mapstructure.Decode(t.Data, m)
+	return m, p.decode(messageKind, t, m, messageFields)
+}
+
+// decode decodes the data of a thing into v, and records the fields of it not
+// in known. Fields which cannot be decoded, e.g. because Reddit changed their
+// type, are left zero and warned about, so one field does not cost the whole
+// thing. If the thing still cannot be decoded, it is warned about and the
+// error returned.
+func (p *parsing) decode(
+	kind string,
+	t *thing,
+	v interface{},
+	known map[string]bool,
+) error {
+	name, _ := t.Data["name"].(string)
+	for field := range t.Data {
+		field = strings.ToLower(field)
+		if known[field] {
+			continue
+		}
+		if p.unknown == nil {
+			p.unknown = make(map[string]map[string]bool)
+		}
+		if p.unknown[kind] == nil {
+			p.unknown[kind] = make(map[string]bool)
+		}
+		p.unknown[kind][field] = true
+	}
+
+	err := mapstructure.Decode(t.Data, v)
+	if err == nil {
+		return nil
+	}
+
+	// Find the fields at fault by decoding them one at a time, in order so
+	// the warnings are.
+	fields := make([]string, 0, len(t.Data))
+	for field := range t.Data {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	typ := reflect.TypeOf(v).Elem()
+	for _, field := range fields {
+		alone := map[string]interface{}{field: t.Data[field]}
+		fieldErr := mapstructure.Decode(alone, reflect.New(typ).Interface())
+		if fieldErr == nil {
+			continue
+		}
+
+		p.warn(ParseWarning{
+			Kind:  kind,
+			Name:  name,
+			Field: field,
+			Err:   fieldErr,
+		})
+		delete(t.Data, field)
+	}
+
+	reflect.ValueOf(v).Elem().Set(reflect.Zero(typ))
+	if err := mapstructure.Decode(t.Data, v); err != nil {
+		p.warn(ParseWarning{Kind: kind, Name: name, Err: err})
+		return err
+	}
+	return nil
+}
+
+// fieldsOf returns the lowercased names of the fields which are decoded into
+// the struct type, along with the extra fields, which are read by hand.
+func fieldsOf(typ reflect.Type, extra ...string) map[string]bool {
+	fields := make(map[string]bool)
+	for _, field := range extra {
+		fields[field] = true
+	}
+
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		tag := strings.Split(f.Tag.Get("mapstructure"), ",")
+		switch {
+		case len(tag) > 1 && tag[1] == "squash":
+			for field := range fieldsOf(f.Type) {
+				fields[field] = true
+			}
+		case tag[0] == "-" || f.PkgPath != "":
+		case tag[0] == "":
+			fields[strings.ToLower(f.Name)] = true
+		default:
+			fields[strings.ToLower(tag[0])] = true
+		}
+	}
+	return fields
+}
+
+// uneditedAsZero drops the edited field of things which have not been edited.
+// Reddit sets it to false for them, and to the time of the last edit for the
+// rest.
+func uneditedAsZero(t *thing) {
+	if _, ok := t.Data["edited"].(bool); ok {
+		delete(t.Data, "edited")
+	}
+}
+
+// spamFilterAsBanner names the spam filter in the banned_by field of things it
+// removed, which Reddit sets to true rather than a moderator's name. Reddit
+// sets it to null for things which are up, or to users who are not moderators.
+func spamFilterAsBanner(t *thing) {
+	switch t.Data["banned_by"] {
+	case true:
+		t.Data["banned_by"] = SpamFilter
+	case false:
+		delete(t.Data, "banned_by")
+	}
 }
 
 func mapDecodeError(err error, val interface{}) error {
@@ -200,3 +438,56 @@ func mapDecodeError(err error, val interface{}) error {
 		err, val,
 	)
 }
+
+// parseRedditor parses an account description from Reddit, such as the one
+// at /api/v1/me.
+func parseRedditor(blob json.RawMessage) (*Redditor, error) {
+	var data map[string]interface{}
+	if err := json.Unmarshal(blob, &data); err != nil {
+		return nil, err
+	}
+
+	r := &Redditor{}
+	if err := mapstructure.Decode(data, r); err != nil {
+		return nil, mapDecodeError(err, data)
+	}
+	return r, nil
+}
+
+// submissionResponse is the structure of Reddit's response to POST requests
+// made with api_type=json.
+type submissionResponse struct {
+	JSON struct {
+		Errors [][]interface{} `json:"errors"`
+		Data   struct {
+			ID     string  `json:"id"`
+			Name   string  `json:"name"`
+			URL    string  `json:"url"`
+			Things []thing `json:"things"`
+		} `json:"data"`
+	} `json:"json"`
+}
+
+// parseSubmission parses Reddit's response to a POST request which created
+// something, such as a comment or a post.
+func parseSubmission(blob json.RawMessage) (submission, error) {
+	resp := &submissionResponse{}
+	if err := json.Unmarshal(blob, resp); err != nil {
+		return submission{}, err
+	}
+
+	if len(resp.JSON.Errors) > 0 {
+		return submission{}, fmt.Errorf(
+			"Reddit rejected the request: %v", resp.JSON.Errors,
+		)
+	}
+
+	data := resp.JSON.Data
+	if len(data.Things) > 0 {
+		name, _ := data.Things[0].Data["name"].(string)
+		id, _ := data.Things[0].Data["id"].(string)
+		return submission{id: id, name: name, url: data.URL}, nil
+	}
+
+	return submission{id: data.ID, name: data.Name, url: data.URL}, nil
+}
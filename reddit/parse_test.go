@@ -10,6 +10,7 @@ package reddit
 import (
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/turnage/graw/reddit/internal/testdata"
 )
@@ -22,21 +23,22 @@ func TestParse(t *testing.T) {
 		testdata.MustAsset("subreddit.json"),
 		testdata.MustAsset("inbox.json"),
 	} {
-		if _, _, _, err := p.parse(input); err != nil {
+		if _, err := p.parse(input); err != nil {
 			t.Errorf("failed to parse input %d: %v", i, err)
 		}
 	}
 }
 
 func TestParseThread(t *testing.T) {
-	post, err := parseThread(testdata.MustAsset("thread.json"))
+	h, err := parseThread(testdata.MustAsset("thread.json"))
 	if err != nil {
 		t.Fatalf("failed to parse: %v", err)
 	}
 
-	if post == nil {
-		t.Fatalf("post is nil")
+	if len(h.Posts) != 1 {
+		t.Fatalf("got %d posts; wanted 1", len(h.Posts))
 	}
+	post := h.Posts[0]
 
 	if !strings.HasPrefix(post.Title, "my wife passed away") {
 		t.Errorf("post title incorrect: %s", post.Title)
@@ -74,12 +76,11 @@ func TestParseThread(t *testing.T) {
 }
 
 func TestParseUserFeed(t *testing.T) {
-	comments, posts, _, err := parseRawListing(
-		testdata.MustAsset("user.json"),
-	)
+	h, err := parseRawListing(testdata.MustAsset("user.json"))
 	if err != nil {
 		t.Fatalf("failed to parse user feed: %v", err)
 	}
+	comments, posts := h.Comments, h.Posts
 
 	if len(comments) < 1 {
 		t.Fatalf("found no comments in user feed")
@@ -105,10 +106,11 @@ func TestParseUserFeed(t *testing.T) {
 }
 
 func TestParseSubredditFeed(t *testing.T) {
-	_, posts, _, err := parseRawListing(testdata.MustAsset("subreddit.json"))
+	h, err := parseRawListing(testdata.MustAsset("subreddit.json"))
 	if err != nil {
 		t.Fatalf("failed to parse subreddit feed: %v", err)
 	}
+	posts := h.Posts
 
 	if len(posts) != 27 {
 		t.Fatalf(
@@ -130,10 +132,11 @@ func TestParseSubredditFeed(t *testing.T) {
 }
 
 func TestParseInboxFeed(t *testing.T) {
-	_, _, msgs, err := parseRawListing(testdata.MustAsset("inbox.json"))
+	h, err := parseRawListing(testdata.MustAsset("inbox.json"))
 	if err != nil {
 		t.Fatalf("failed to parse inbox feed: %v", err)
 	}
+	msgs := h.Messages
 
 	if len(msgs) != 5 {
 		t.Fatalf("found unexpected number of messages: %v", len(msgs))
@@ -143,3 +146,316 @@ func TestParseInboxFeed(t *testing.T) {
 		t.Errorf("first message had unexpected name: %s", msgs[0].Name)
 	}
 }
+
+func TestParseSubmission(t *testing.T) {
+	s, err := parseSubmission([]byte(`{"json": {"errors": [], "data": {
+		"things": [{"kind": "t1", "data": {"id": "abc", "name": "t1_abc"}}]
+	}}}`))
+	if err != nil {
+		t.Fatalf("failed to parse: %v", err)
+	}
+
+	if s.name != "t1_abc" || s.id != "abc" {
+		t.Errorf("got %+v; wanted id abc and name t1_abc", s)
+	}
+
+	if _, err := parseSubmission([]byte(`{"json": {"errors": [
+		["TOO_LONG", "this is too long", "text"]
+	]}}`)); err == nil {
+		t.Errorf("wanted error for rejected submission")
+	}
+}
+
+func TestParseTimes(t *testing.T) {
+	for _, test := range []struct {
+		edited interface{}
+		want   time.Time
+	}{
+		{false, time.Time{}},
+		{float64(1478000100), time.Unix(1478000100, 0).UTC()},
+	} {
+		c, err := (&parsing{}).comment(&thing{
+			Kind: commentKind,
+			Data: map[string]interface{}{
+				"created_utc": float64(1478000000),
+				"edited":      test.edited,
+			},
+		})
+		if err != nil {
+			t.Fatalf("edited %v: failed to parse: %v", test.edited, err)
+		}
+
+		created := c.Created()
+		if created.Unix() != 1478000000 || created.Location() != time.UTC {
+			t.Errorf("edited %v: got created time %v", test.edited, created)
+		}
+		if !c.Edited().Equal(test.want) {
+			t.Errorf(
+				"edited %v: got edit time %v; wanted %v",
+				test.edited, c.Edited(), test.want,
+			)
+		}
+		if age := c.Age(created.Add(time.Minute)); age != time.Minute {
+			t.Errorf("edited %v: got age %v; wanted 1m", test.edited, age)
+		}
+	}
+}
+
+func TestParseRichMedia(t *testing.T) {
+	h, err := parseRawListing([]byte(`{
+		"kind": "Listing",
+		"data": {"children": [{"kind": "t3", "data": {
+			"name": "t3_crosspost",
+			"edited": false,
+			"crosspost_parent": "t3_gallery",
+			"preview": {"enabled": true, "images": [{
+				"id": "img",
+				"source": {"url": "https://i.redd.it/a.jpg", "width": 640, "height": 480},
+				"resolutions": [{"url": "https://i.redd.it/a-s.jpg", "width": 108, "height": 81}]
+			}]},
+			"crosspost_parent_list": [{
+				"name": "t3_gallery",
+				"edited": false,
+				"is_gallery": true,
+				"gallery_data": {"items": [
+					{"media_id": "two", "id": 2, "caption": "second"},
+					{"media_id": "one", "id": 1},
+					{"media_id": "processing", "id": 3}
+				]},
+				"media_metadata": {
+					"one": {"status": "valid", "e": "Image", "m": "image/png", "s": {"u": "https://i.redd.it/one.png", "x": 10, "y": 20}},
+					"two": {"status": "valid", "e": "AnimatedImage", "m": "image/gif", "s": {"gif": "https://i.redd.it/two.gif", "mp4": "https://i.redd.it/two.mp4", "x": 30, "y": 40}}
+				}
+			}]
+		}}]}
+	}`))
+	if err != nil {
+		t.Fatalf("failed to parse: %v", err)
+	}
+
+	post := h.Posts[0]
+	if len(post.Preview.Images) != 1 ||
+		post.Preview.Images[0].Source.Width != 640 ||
+		len(post.Preview.Images[0].Resolutions) != 1 {
+		t.Errorf("got preview %+v", post.Preview)
+	}
+
+	if post.CrosspostParent != "t3_gallery" || len(post.CrosspostParentList) != 1 {
+		t.Fatalf(
+			"got crosspost parent %s, %v",
+			post.CrosspostParent, post.CrosspostParentList,
+		)
+	}
+
+	gallery := post.CrosspostParentList[0].Gallery()
+	if len(gallery) != 2 ||
+		gallery[0].Source.MP4 != "https://i.redd.it/two.mp4" ||
+		gallery[1].Source.URL != "https://i.redd.it/one.png" {
+		t.Errorf("got gallery %+v; wanted two then one", gallery)
+	}
+}
+
+func TestParsePoll(t *testing.T) {
+	h, err := parseRawListing([]byte(`{
+		"kind": "Listing",
+		"data": {"children": [
+			{"kind": "t3", "data": {"name": "t3_plain", "poll_data": null}},
+			{"kind": "t3", "data": {"name": "t3_poll", "poll_data": {
+				"options": [
+					{"id": "1", "text": "tabs", "vote_count": 40},
+					{"id": "2", "text": "spaces", "vote_count": 55},
+					{"id": "3", "text": "both", "vote_count": 55}
+				],
+				"total_vote_count": 150,
+				"voting_end_timestamp": 1600000000500,
+				"is_prediction": false,
+				"resolved_option_id": null,
+				"user_selection": null
+			}}}
+		]}
+	}`))
+	if err != nil {
+		t.Fatalf("failed to parse: %v", err)
+	}
+	posts := h.Posts
+
+	if posts[0].PollData != nil {
+		t.Errorf("got poll %+v for a post without one", posts[0].PollData)
+	}
+
+	poll := posts[1].PollData
+	if poll == nil || len(poll.Options) != 3 || poll.TotalVoteCount != 150 {
+		t.Fatalf("got poll %+v", poll)
+	}
+
+	ends := time.Unix(1600000000, 500*int64(time.Millisecond))
+	if !poll.Ends().Equal(ends) {
+		t.Errorf("got end %v; wanted %v", poll.Ends(), ends)
+	}
+	if !poll.Open(ends.Add(-time.Second)) || poll.Open(ends) {
+		t.Errorf("poll open at the wrong times")
+	}
+
+	leaders := poll.Leaders()
+	if len(leaders) != 2 || leaders[0].Text != "spaces" || leaders[1].Text != "both" {
+		t.Errorf("got leaders %+v; wanted spaces and both", leaders)
+	}
+}
+
+func TestParseAwardings(t *testing.T) {
+	c, err := (&parsing{}).comment(&thing{
+		Kind: commentKind,
+		Data: map[string]interface{}{
+			"gilded":                float64(1),
+			"total_awards_received": float64(4),
+			"all_awardings": []interface{}{
+				map[string]interface{}{
+					"id":         "gid_2",
+					"name":       "Gold",
+					"award_type": "global",
+					"count":      float64(1),
+					"coin_price": float64(500),
+				},
+				map[string]interface{}{
+					"id":         "award_5f123e3d",
+					"name":       "Wholesome",
+					"award_type": "global",
+					"count":      float64(3),
+					"coin_price": float64(125),
+				},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to parse: %v", err)
+	}
+
+	if len(c.AllAwardings) != 2 || c.AllAwardings[1].Name != "Wholesome" {
+		t.Errorf("got awardings %+v", c.AllAwardings)
+	}
+	if c.Awards() != 4 {
+		t.Errorf("got %d awards; wanted 4", c.Awards())
+	}
+	if c.AwardCoins() != 875 {
+		t.Errorf("got %d coins; wanted 875", c.AwardCoins())
+	}
+}
+
+func TestParseSchemaDrift(t *testing.T) {
+	h, err := parseRawListing([]byte(`{
+		"kind": "Listing",
+		"data": {"after": null, "children": [
+			{"kind": "t3", "data": {
+				"name": "t3_drifted",
+				"title": "still here",
+				"score": "many",
+				"author": null,
+				"new_field": {"nested": true}
+			}},
+			{"kind": "t3", "data": null},
+			"not a thing",
+			{"kind": "t1", "data": {
+				"name": "t1_replies",
+				"body": "hi",
+				"replies": 7
+			}},
+			{"kind": "t3", "data": {"name": "t3_fine", "title": "fine"}}
+		]}
+	}`))
+	if err != nil {
+		t.Fatalf("failed to parse: %v", err)
+	}
+
+	if len(h.Posts) != 2 || len(h.Comments) != 1 {
+		t.Fatalf(
+			"got %d posts and %d comments; wanted 2 and 1",
+			len(h.Posts), len(h.Comments),
+		)
+	}
+	if h.Posts[0].Title != "still here" || h.Posts[0].Score != 0 {
+		t.Errorf("got drifted post %+v", h.Posts[0])
+	}
+	if h.Comments[0].Body != "hi" || len(h.Comments[0].Replies) != 0 {
+		t.Errorf("got comment %+v", h.Comments[0])
+	}
+
+	var problems []string
+	for _, w := range h.Warnings {
+		problems = append(problems, w.Name+"/"+w.Field)
+	}
+	want := []string{"t3_drifted/score", "/", "/", "t1_replies/replies"}
+	if strings.Join(problems, " ") != strings.Join(want, " ") {
+		t.Errorf("got warnings %v; wanted %v", h.Warnings, want)
+	}
+
+	if unknown := h.Unknown[postKind]; len(unknown) != 1 ||
+		unknown[0] != "new_field" {
+		t.Errorf("got unknown post fields %v; wanted new_field", unknown)
+	}
+}
+
+func TestParseThreadWithBadComments(t *testing.T) {
+	h, err := parseThread([]byte(`[
+		{"kind": "Listing", "data": {"children": [
+			{"kind": "t3", "data": {"name": "t3_post"}}
+		]}},
+		{"kind": "Listing", "data": {"children": {"broken": true}}}
+	]`))
+	if err != nil {
+		t.Fatalf("failed to parse: %v", err)
+	}
+
+	if len(h.Posts) != 1 || h.Posts[0].Name != "t3_post" {
+		t.Fatalf("got posts %v; wanted t3_post", h.Posts)
+	}
+	if len(h.Warnings) != 1 || h.Warnings[0].Kind != listingKind {
+		t.Errorf("got warnings %v; wanted one for the comments", h.Warnings)
+	}
+}
+
+func FuzzParse(f *testing.F) {
+	for _, name := range []string{
+		"thread.json",
+		"user.json",
+		"subreddit.json",
+		"inbox.json",
+	} {
+		f.Add(testdata.MustAsset(name))
+	}
+	f.Add([]byte(`{"kind": "Listing", "data": {"children": [null, 1]}}`))
+	f.Add([]byte(`{"kind": "Listing", "data": {"children": [
+		{"kind": "t1", "data": {"replies": {"kind": "Listing", "data": {
+			"children": [{"kind": "t1", "data": {"score": "1"}}]
+		}}}},
+		{"kind": "t3", "data": {"crosspost_parent_list": [1, {}]}}
+	]}}`))
+	f.Add([]byte(`[{"kind": "Listing"}, null]`))
+
+	f.Fuzz(func(t *testing.T, blob []byte) {
+		h, err := newParser().parse(blob)
+		if err != nil {
+			return
+		}
+
+		for _, c := range h.Comments {
+			if c == nil {
+				t.Fatalf("nil comment in %q", blob)
+			}
+		}
+		for _, p := range h.Posts {
+			if p == nil {
+				t.Fatalf("nil post in %q", blob)
+			}
+		}
+		for _, m := range h.Messages {
+			if m == nil {
+				t.Fatalf("nil message in %q", blob)
+			}
+		}
+		for _, w := range h.Warnings {
+			if w.Err == nil {
+				t.Fatalf("warning %+v without an error", w)
+			}
+		}
+	})
+}
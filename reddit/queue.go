@@ -0,0 +1,103 @@
+package reddit
+
+import (
+	"sync"
+	"time"
+)
+
+// Queue reports the requests a handle has waiting for their turn under its
+// rate limit, so a bot falling behind can tell, and e.g. put off work which
+// is not urgent until it catches up.
+type Queue struct {
+	// Reads and Writes are the number of GET and POST requests waiting.
+	Reads  int
+	Writes int
+	// Endpoints are the number of requests waiting by path, e.g.
+	// /r/golang/new.
+	Endpoints map[string]int
+	// Drain is about how long the waiting requests will take to be sent,
+	// at the handle's rate. It does not include waits on a Limiter.
+	Drain time.Duration
+}
+
+// requestQueue counts the requests waiting on a reaper's rate limit.
+type requestQueue struct {
+	reads     int
+	writes    int
+	endpoints map[string]int
+	// next is when the next request may be sent, and rate the time
+	// between requests.
+	next time.Time
+	rate time.Duration
+	mu   *sync.Mutex
+}
+
+func newRequestQueue() *requestQueue {
+	return &requestQueue{endpoints: make(map[string]int), mu: &sync.Mutex{}}
+}
+
+// wait records a request joining the queue.
+func (q *requestQueue) wait(method, path string) {
+	q.add(method, path, 1)
+}
+
+// leave records a request leaving the queue to be sent.
+func (q *requestQueue) leave(method, path string) {
+	q.add(method, path, -1)
+}
+
+func (q *requestQueue) add(method, path string, n int) {
+	if q == nil {
+		return
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if method == "POST" {
+		q.writes += n
+	} else {
+		q.reads += n
+	}
+	if q.endpoints[path] += n; q.endpoints[path] <= 0 {
+		delete(q.endpoints, path)
+	}
+}
+
+// sent records a request sent at the given time, and the rate the next
+// must wait for.
+func (q *requestQueue) sent(at time.Time, rate time.Duration) {
+	if q == nil {
+		return
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.next = at.Add(rate)
+	q.rate = rate
+}
+
+// queue returns the state of the queue at the given time.
+func (q *requestQueue) queue(now time.Time) Queue {
+	if q == nil {
+		return Queue{}
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	endpoints := make(map[string]int, len(q.endpoints))
+	for path, n := range q.endpoints {
+		endpoints[path] = n
+	}
+
+	queue := Queue{Reads: q.reads, Writes: q.writes, Endpoints: endpoints}
+	if pending := q.reads + q.writes; pending > 0 {
+		last := q.next.Add(time.Duration(pending-1) * q.rate)
+		if drain := last.Sub(now); drain > 0 {
+			queue.Drain = drain
+		}
+	}
+	return queue
+}
@@ -0,0 +1,59 @@
+package reddit
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRequestQueue(t *testing.T) {
+	queue := newRequestQueue()
+	r := &reaperImpl{
+		rate:  50 * time.Millisecond,
+		last:  time.Now(),
+		queue: queue,
+		mu:    &sync.Mutex{},
+	}
+	queue.sent(r.last, r.rate)
+
+	var wg sync.WaitGroup
+	for _, req := range []struct {
+		method string
+		path   string
+	}{
+		{"GET", "/r/golang/new"},
+		{"GET", "/r/golang/new"},
+		{"POST", "/api/comment"},
+	} {
+		wg.Add(1)
+		go func(method, path string) {
+			defer wg.Done()
+			r.rateBlock(method, path)
+		}(req.method, req.path)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	q := queue.queue(time.Now())
+	for ; q.Reads+q.Writes < 3; q = queue.queue(time.Now()) {
+		if time.Now().After(deadline) {
+			t.Fatalf("got queue %+v; wanted three requests waiting", q)
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	if q.Reads != 2 || q.Writes != 1 {
+		t.Errorf("got %d reads and %d writes; wanted 2 and 1", q.Reads, q.Writes)
+	}
+	if q.Endpoints["/r/golang/new"] != 2 || q.Endpoints["/api/comment"] != 1 {
+		t.Errorf("got endpoints %v", q.Endpoints)
+	}
+	if q.Drain <= 0 || q.Drain > 3*r.rate {
+		t.Errorf("got drain %v; wanted up to %v", q.Drain, 3*r.rate)
+	}
+
+	wg.Wait()
+	if q := queue.queue(time.Now()); q.Reads+q.Writes != 0 ||
+		len(q.Endpoints) != 0 || q.Drain != 0 {
+		t.Errorf("got queue %+v after the requests were sent; wanted empty", q)
+	}
+}
@@ -26,6 +26,13 @@ type reaperConfig struct {
 	reapSuffix string
 	tls        bool
 	rate       time.Duration
+	// limiter, if set, is waited on before each request, after the rate.
+	limiter RateLimiter
+	// scopes are the OAuth scopes the handle was granted. If nil, requests
+	// are not checked against scopes.
+	scopes []string
+	// queue, if set, counts the requests waiting on the rate.
+	queue *requestQueue
 }
 
 // reaper is a high level api for Reddit HTTP requests.
@@ -33,8 +40,17 @@ type reaper interface {
 	// reap executes a GET request to Reddit and returns the elements from
 	// the endpoint.
 	reap(path string, values map[string]string) (Harvest, error)
+	// reapRaw executes a GET request to Reddit and returns the response
+	// body, for endpoints which do not return listings.
+	reapRaw(path string, values map[string]string) ([]byte, error)
 	// sow executes a POST request to Reddit.
 	sow(path string, values map[string]string) error
+	// plant executes a POST request to Reddit which creates something,
+	// and returns the created thing.
+	plant(path string, values map[string]string) (submission, error)
+	// granted returns the OAuth scopes requests are checked against, or
+	// nil if they are not checked.
+	granted() []string
 }
 
 type reaperImpl struct {
@@ -45,10 +61,18 @@ type reaperImpl struct {
 	scheme     string
 	rate       time.Duration
 	last       time.Time
+	limiter    RateLimiter
+	scopes     scopeSet
+	queue      *requestQueue
 	mu         *sync.Mutex
 }
 
 func newReaper(c reaperConfig) reaper {
+	var scopes scopeSet
+	if c.scopes != nil {
+		scopes = newScopeSet(c.scopes)
+	}
+
 	return &reaperImpl{
 		cli:        c.client,
 		parser:     c.parser,
@@ -56,12 +80,21 @@ func newReaper(c reaperConfig) reaper {
 		reapSuffix: c.reapSuffix,
 		scheme:     scheme[c.tls],
 		rate:       c.rate,
+		limiter:    c.limiter,
+		scopes:     scopes,
+		queue:      c.queue,
 		mu:         &sync.Mutex{},
 	}
 }
 
 func (r *reaperImpl) reap(path string, values map[string]string) (Harvest, error) {
-	r.rateBlock()
+	if err := r.scopes.permit(path); err != nil {
+		return Harvest{}, err
+	}
+
+	if err := r.rateBlock("GET", path); err != nil {
+		return Harvest{}, err
+	}
 	resp, err := r.cli.Do(
 		&http.Request{
 			Method: "GET",
@@ -73,16 +106,41 @@ func (r *reaperImpl) reap(path string, values map[string]string) (Harvest, error
 		return Harvest{}, err
 	}
 
-	comments, posts, messages, err := r.parser.parse(resp)
-	return Harvest{
-		Comments: comments,
-		Posts:    posts,
-		Messages: messages,
-	}, err
+	h, err := r.parser.parse(resp)
+	if escaped(values) {
+		h.unescape()
+	}
+	return h, err
+}
+
+func (r *reaperImpl) reapRaw(
+	path string,
+	values map[string]string,
+) ([]byte, error) {
+	if err := r.scopes.permit(path); err != nil {
+		return nil, err
+	}
+
+	if err := r.rateBlock("GET", path); err != nil {
+		return nil, err
+	}
+	return r.cli.Do(
+		&http.Request{
+			Method: "GET",
+			URL:    r.url(r.path(path, r.reapSuffix), values),
+			Host:   r.hostname,
+		},
+	)
 }
 
 func (r *reaperImpl) sow(path string, values map[string]string) error {
-	r.rateBlock()
+	if err := r.scopes.permit(path); err != nil {
+		return err
+	}
+
+	if err := r.rateBlock("POST", path); err != nil {
+		return err
+	}
 	_, err := r.cli.Do(
 		&http.Request{
 			Method: "POST",
@@ -95,7 +153,45 @@ func (r *reaperImpl) sow(path string, values map[string]string) error {
 	return err
 }
 
-func (r *reaperImpl) rateBlock() {
+func (r *reaperImpl) plant(
+	path string,
+	values map[string]string,
+) (submission, error) {
+	if err := r.scopes.permit(path); err != nil {
+		return submission{}, err
+	}
+
+	if err := r.rateBlock("POST", path); err != nil {
+		return submission{}, err
+	}
+	resp, err := r.cli.Do(
+		&http.Request{
+			Method: "POST",
+			Header: formEncoding,
+			Host:   r.hostname,
+			URL:    r.url(path, withAPIType(values)),
+		},
+	)
+	if err != nil {
+		return submission{}, err
+	}
+
+	return parseSubmission(resp)
+}
+
+func (r *reaperImpl) granted() []string {
+	if r.scopes == nil {
+		return nil
+	}
+	return r.scopes.list()
+}
+
+// rateBlock blocks until the request may be sent under the rate, counting it
+// in the queue while it waits.
+func (r *reaperImpl) rateBlock(method, path string) error {
+	r.queue.wait(method, path)
+	defer r.queue.leave(method, path)
+
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
@@ -103,6 +199,12 @@ func (r *reaperImpl) rateBlock() {
 		<-time.After(r.last.Add(r.rate).Sub(time.Now()))
 	}
 	r.last = time.Now()
+	r.queue.sent(r.last, r.rate)
+
+	if r.limiter != nil {
+		return r.limiter.Wait()
+	}
+	return nil
 }
 
 func (r *reaperImpl) url(path string, values map[string]string) *url.URL {
@@ -122,6 +224,16 @@ func (r *reaperImpl) path(p string, suff string) string {
 	return p + suff
 }
 
+// withAPIType returns a copy of values which asks Reddit to respond in json,
+// which it otherwise doesn't do for POST requests.
+func withAPIType(values map[string]string) map[string]string {
+	jsonValues := map[string]string{"api_type": "json"}
+	for key, value := range values {
+		jsonValues[key] = value
+	}
+	return jsonValues
+}
+
 func (r *reaperImpl) formatValues(values map[string]string) url.Values {
 	formattedValues := url.Values{}
 
@@ -196,3 +196,65 @@ func testRateBlock(f func(reaper), t *testing.T) {
 		t.Errorf("wanted updated timestamp; found same timestamp")
 	}
 }
+
+func TestReaperScopes(t *testing.T) {
+	c := &mockClient{}
+	r := newReaper(
+		reaperConfig{
+			client: c,
+			parser: &mockParser{},
+			scopes: []string{"read"},
+		},
+	)
+
+	if _, err := r.reap("/r/golang", nil); err != nil {
+		t.Errorf("read denied: %v", err)
+	}
+
+	err := r.sow("/api/comment", nil)
+	if se, ok := err.(*ScopeError); !ok || se.Scope != "submit" {
+		t.Errorf("got %v; wanted a ScopeError for submit", err)
+	}
+}
+
+func TestReapUnescapes(t *testing.T) {
+	for _, test := range []struct {
+		values map[string]string
+		body   string
+	}{
+		{nil, "a < b && c > d"},
+		{map[string]string{"raw_json": "1"}, "a &lt; b &amp;&amp; c &gt; d"},
+	} {
+		r := &reaperImpl{
+			cli: &mockClient{},
+			parser: parserWhich(Harvest{
+				Posts: []*Post{
+					&Post{
+						Replies: []*Comment{
+							&Comment{Body: "a &lt; b &amp;&amp; c &gt; d"},
+						},
+					},
+				},
+			}),
+			hostname: "com",
+			scheme:   "http",
+			mu:       &sync.Mutex{},
+		}
+
+		h, err := r.reap("", test.values)
+		if err != nil {
+			t.Fatalf("error reaping: %v", err)
+		}
+
+		if body := h.Posts[0].Replies[0].Body; body != test.body {
+			t.Errorf("%v: got body %q; wanted %q", test.values, body, test.body)
+		}
+	}
+
+	c := &Comment{
+		BodyHTML: "&lt;div class=&quot;md&quot;&gt;&lt;p&gt;hi&lt;/p&gt;&lt;/div&gt;",
+	}
+	if html := c.HTML(); html != `<div class="md"><p>hi</p></div>` {
+		t.Errorf("got html %q", html)
+	}
+}
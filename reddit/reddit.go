@@ -4,7 +4,7 @@
 // Script handle.
 //
 //   rate := 5 * time.Second
-//   script, _ := NewScript("graw:doc_script:0.3.1 by /u/yourusername", rate)
+//   script, _ := NewScript("graw:doc_script:0.3.1 (by /u/yourusername)", rate)
 //   post, _ := script.Thread("r/programming/comments/5du93939")
 //   fmt.Printf("%s posted \"%s\"!", post.Author, post.Title)
 //
@@ -12,7 +12,7 @@
 // features.
 //
 //   cfg := BotConfig{
-//     Agent: "graw:doc_demo_bot:0.3.1 by /u/yourusername"
+//     Agent: "graw:doc_demo_bot:0.3.1 (by /u/yourusername)"
 //     // Your registered app info from following:
 //     // https://github.com/reddit/reddit/wiki/OAuth2
 //     App: App{
@@ -55,6 +55,23 @@ func TestAccount(t *testing.T) {
 					Header: formEncoding,
 				},
 			},
+			testCase{
+				name: "MarkRead",
+				f: func(b Bot) error {
+					return b.MarkRead("t4_a", "t1_b")
+				},
+				correct: http.Request{
+					Method: "POST",
+					URL: &url.URL{
+						Scheme:   "https",
+						Host:     "reddit.com",
+						Path:     "/api/read_message",
+						RawQuery: "id=t4_a%2Ct1_b",
+					},
+					Host:   "reddit.com",
+					Header: formEncoding,
+				},
+			},
 			testCase{
 				name: "PostSelf",
 				f: func(b Bot) error {
@@ -153,7 +170,7 @@ func testRequests(cases []testCase, t *testing.T) {
 		mu:         &sync.Mutex{},
 	}
 	b := &bot{
-		Account: newAccount(r),
+		Account: newAccount(r, SplitConfig{}),
 		Lurker:  newLurker(r),
 		Scanner: newScanner(r),
 	}
@@ -171,3 +188,21 @@ func testRequests(cases []testCase, t *testing.T) {
 		}
 	}
 }
+
+func TestMe(t *testing.T) {
+	r := reaperWhich(Harvest{}, nil)
+	r.raw = []byte(`{"name": "mybot", "link_karma": 5, "created_utc": 1477145440.0}`)
+
+	me, err := newAccount(r, SplitConfig{}).Me()
+	if err != nil {
+		t.Fatalf("error fetching account: %v", err)
+	}
+
+	if r.path != "/api/v1/me" {
+		t.Errorf("requested %s; wanted /api/v1/me", r.path)
+	}
+
+	if me.Name != "mybot" || me.LinkKarma != 5 || me.CreatedUTC != 1477145440 {
+		t.Errorf("account parsed incorrectly: %+v", me)
+	}
+}
@@ -0,0 +1,92 @@
+package reddit
+
+import (
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// defaultRefreshEarly is how long before they expire tokens are refreshed if
+// the BotConfig does not specify.
+const defaultRefreshEarly = time.Minute
+
+// refreshingSource caches a token and replaces it from its source shortly
+// before it expires. Expiry is tracked on the local clock from when the token
+// was granted, so refreshing early covers requests in flight and the time the
+// grant spent in transit, and Reddit's clock disagreeing with ours.
+//
+// Calls are serialized, so when the token lapses in the middle of a burst of
+// requests only one of them asks Reddit for a new token; the rest wait for it.
+type refreshingSource struct {
+	src   oauth2.TokenSource
+	early time.Duration
+	token *oauth2.Token
+	// issued is when the token was granted.
+	issued time.Time
+	mu     *sync.Mutex
+}
+
+func newRefreshingSource(
+	token *oauth2.Token,
+	src oauth2.TokenSource,
+	early time.Duration,
+) *refreshingSource {
+	if early <= 0 {
+		early = defaultRefreshEarly
+	}
+
+	return &refreshingSource{
+		src:   src,
+		early: early,
+		token: token,
+		mu:    &sync.Mutex{},
+	}
+}
+
+// Token returns the cached token, refreshing it first if it is about to
+// expire. If the refresh fails but the cached token has not expired yet, the
+// cached token is returned, so a brief outage of Reddit's token endpoint does
+// not fail requests.
+func (r *refreshingSource) Token() (*oauth2.Token, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	if r.fresh(now) {
+		return r.token, nil
+	}
+
+	token, err := r.src.Token()
+	if err != nil {
+		if r.token.Valid() {
+			return r.token, nil
+		}
+		return nil, err
+	}
+
+	r.token = token
+	r.issued = now
+	return token, nil
+}
+
+// fresh returns true if the token will not expire soon.
+func (r *refreshingSource) fresh(now time.Time) bool {
+	if r.token == nil || r.token.AccessToken == "" {
+		return false
+	}
+
+	return r.token.Expiry.IsZero() || now.Add(r.early).Before(r.token.Expiry)
+}
+
+// invalidate drops the token if it was granted before a request started at
+// start was rejected, so the next call gets a new one. Requests rejected at
+// the same time drop the token once, rather than once each.
+func (r *refreshingSource) invalidate(start time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.issued.Before(start) {
+		r.token = nil
+	}
+}
@@ -0,0 +1,95 @@
+package reddit
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// countingSource grants numbered tokens which expire after a lifetime.
+type countingSource struct {
+	grants   int
+	lifetime time.Duration
+	err      error
+	mu       sync.Mutex
+}
+
+func (c *countingSource) Token() (*oauth2.Token, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.err != nil {
+		return nil, c.err
+	}
+
+	c.grants++
+	return &oauth2.Token{
+		AccessToken: fmt.Sprintf("token-%d", c.grants),
+		Expiry:      time.Now().Add(c.lifetime),
+	}, nil
+}
+
+func TestRefreshingSourceSerializesGrants(t *testing.T) {
+	src := &countingSource{lifetime: time.Hour}
+	r := newRefreshingSource(nil, src, time.Minute)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := r.Token(); err != nil {
+				t.Errorf("error getting token: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if src.grants != 1 {
+		t.Errorf("got %d grants for a burst; wanted 1", src.grants)
+	}
+}
+
+func TestRefreshingSourceRefreshesEarly(t *testing.T) {
+	src := &countingSource{lifetime: time.Hour}
+	expiring := &oauth2.Token{
+		AccessToken: "expiring",
+		Expiry:      time.Now().Add(30 * time.Second),
+	}
+	r := newRefreshingSource(expiring, src, time.Minute)
+
+	token, err := r.Token()
+	if err != nil {
+		t.Fatalf("error getting token: %v", err)
+	}
+	if token.AccessToken != "token-1" {
+		t.Errorf("got %s; wanted the token refreshed early", token.AccessToken)
+	}
+
+	// If the refresh fails, the old token is used until it expires.
+	src.err = fmt.Errorf("token endpoint down")
+	r = newRefreshingSource(expiring, src, time.Minute)
+	if token, err := r.Token(); err != nil || token != expiring {
+		t.Errorf("got %v, %v; wanted the expiring token", token, err)
+	}
+}
+
+func TestRefreshingSourceInvalidate(t *testing.T) {
+	src := &countingSource{lifetime: time.Hour}
+	r := newRefreshingSource(nil, src, time.Minute)
+	r.Token()
+
+	start := time.Now().Add(time.Second)
+	r.invalidate(start)
+	r.Token()
+	// A second rejection from before the new grant is ignored.
+	r.invalidate(start.Add(-2 * time.Second))
+	r.Token()
+
+	if src.grants != 2 {
+		t.Errorf("got %d grants; wanted 2", src.grants)
+	}
+}
@@ -0,0 +1,78 @@
+package reddit
+
+// Removal is how a post or comment was taken down, as far as Reddit says.
+// Reddit says more to some users than others: moderators of a subreddit see
+// who removed things in it, while other users may see only that they were
+// removed, not by whom.
+type Removal string
+
+const (
+	// NotRemoved is the removal of things which are up.
+	NotRemoved Removal = ""
+	// DeletedByAuthor is the removal of things their author deleted.
+	DeletedByAuthor Removal = "deleted"
+	// RemovedByModerator is the removal of things a moderator of their
+	// subreddit, or its AutoModerator, removed.
+	RemovedByModerator Removal = "moderator"
+	// RemovedByReddit is the removal of things Reddit's spam filter or its
+	// admins removed.
+	RemovedByReddit Removal = "reddit"
+	// RemovedByUnknown is the removal of things which were removed by
+	// someone other than their author, when Reddit does not say who.
+	RemovedByUnknown Removal = "removed"
+)
+
+// SpamFilter is the BannedBy of things Reddit's spam filter removed, which
+// Reddit marks with no moderator's name.
+const SpamFilter = "[spam filter]"
+
+// removedByCategories are the removals of the removed_by_category values Reddit
+// sets on posts. Values not here are RemovedByUnknown.
+var removedByCategories = map[string]Removal{
+	"deleted":            DeletedByAuthor,
+	"author":             DeletedByAuthor,
+	"moderator":          RemovedByModerator,
+	"automod_filtered":   RemovedByModerator,
+	"reddit":             RemovedByReddit,
+	"anti_evil_ops":      RemovedByReddit,
+	"community_ops":      RemovedByReddit,
+	"copyright_takedown": RemovedByReddit,
+	"content_takedown":   RemovedByReddit,
+}
+
+// Removed returns true for removals by someone other than the author.
+func (r Removal) Removed() bool {
+	return r != NotRemoved && r != DeletedByAuthor
+}
+
+// Removal returns how the post was taken down, or NotRemoved if it is up.
+func (p *Post) Removal() Removal {
+	if p.RemovedByCategory != "" {
+		if r, ok := removedByCategories[p.RemovedByCategory]; ok {
+			return r
+		}
+		return RemovedByUnknown
+	}
+	return removal(p.BannedBy, p.SelfText)
+}
+
+// Removal returns how the comment was taken down, or NotRemoved if it is up.
+func (c *Comment) Removal() Removal {
+	return removal(c.BannedBy, c.Body)
+}
+
+// removal returns the removal of a thing by who banned it and its text, which
+// Reddit replaces when the thing is taken down.
+func removal(bannedBy, text string) Removal {
+	switch {
+	case bannedBy == SpamFilter:
+		return RemovedByReddit
+	case bannedBy != "":
+		return RemovedByModerator
+	case text == deletedKey:
+		return DeletedByAuthor
+	case text == removedKey:
+		return RemovedByUnknown
+	}
+	return NotRemoved
+}
@@ -0,0 +1,71 @@
+package reddit
+
+import (
+	"testing"
+)
+
+func TestRemoval(t *testing.T) {
+	for i, test := range []struct {
+		post *Post
+		want Removal
+	}{
+		{&Post{SelfText: "hello"}, NotRemoved},
+		{&Post{RemovedByCategory: "deleted"}, DeletedByAuthor},
+		{&Post{RemovedByCategory: "moderator"}, RemovedByModerator},
+		{&Post{RemovedByCategory: "automod_filtered"}, RemovedByModerator},
+		{&Post{RemovedByCategory: "anti_evil_ops"}, RemovedByReddit},
+		{&Post{RemovedByCategory: "something_new"}, RemovedByUnknown},
+		{&Post{BannedBy: SpamFilter}, RemovedByReddit},
+		{&Post{SelfText: "[deleted]"}, DeletedByAuthor},
+		{&Post{SelfText: "[removed]"}, RemovedByUnknown},
+	} {
+		if r := test.post.Removal(); r != test.want {
+			t.Errorf("%d: got removal %q; wanted %q", i, r, test.want)
+		}
+		if test.post.Removed() != test.want.Removed() {
+			t.Errorf("%d: Removed() disagrees with %q", i, test.want)
+		}
+	}
+
+	for i, test := range []struct {
+		comment *Comment
+		want    Removal
+	}{
+		{&Comment{Body: "hello"}, NotRemoved},
+		{&Comment{Body: "[deleted]", Author: "[deleted]"}, DeletedByAuthor},
+		{&Comment{Body: "[removed]"}, RemovedByUnknown},
+		{&Comment{Body: "hello", BannedBy: "AutoModerator"}, RemovedByModerator},
+		{&Comment{Body: "hello", BannedBy: SpamFilter}, RemovedByReddit},
+	} {
+		if r := test.comment.Removal(); r != test.want {
+			t.Errorf("%d: got removal %q; wanted %q", i, r, test.want)
+		}
+	}
+}
+
+func TestParseBannedBy(t *testing.T) {
+	p := &parsing{}
+	for banned, want := range map[interface{}]Removal{
+		"a_mod": RemovedByModerator,
+		true:    RemovedByReddit,
+		false:   NotRemoved,
+		nil:     NotRemoved,
+	} {
+		c, err := p.comment(&thing{
+			Kind: commentKind,
+			Data: map[string]interface{}{"body": "hi", "banned_by": banned},
+		})
+		if err != nil {
+			t.Fatalf("banned by %v: failed to parse: %v", banned, err)
+		}
+		if c.Removal() != want {
+			t.Errorf(
+				"banned by %v: got removal %q; wanted %q",
+				banned, c.Removal(), want,
+			)
+		}
+	}
+	if len(p.warnings) != 0 {
+		t.Errorf("got warnings %v", p.warnings)
+	}
+}
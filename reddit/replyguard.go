@@ -0,0 +1,118 @@
+package reddit
+
+import (
+	"strings"
+	"sync"
+)
+
+// ReplyLedger records which things a bot has replied to. The SeenSet in
+// graw/store satisfies this interface; use a durable one so the record
+// survives restarts.
+type ReplyLedger interface {
+	Seen(name string) (bool, error)
+	MarkSeen(name string) error
+}
+
+// replyGuard makes replies exactly-once per parent: a bot which handles an
+// event twice, e.g. because it was redelivered after a restart, does not
+// answer it twice.
+type replyGuard struct {
+	Account
+	r        reaper
+	username string
+	// ledger, if set, records the parents replied to.
+	ledger ReplyLedger
+	// check, if set, looks for an earlier reply on Reddit before each
+	// reply, for replies made before the ledger recorded them.
+	check bool
+	// mu makes checking for, sending, and recording a reply atomic, so
+	// concurrent handlers cannot both reply to one parent.
+	mu *sync.Mutex
+}
+
+func newReplyGuard(
+	a Account,
+	r reaper,
+	username string,
+	ledger ReplyLedger,
+	check bool,
+) Account {
+	return &replyGuard{
+		Account:  a,
+		r:        r,
+		username: username,
+		ledger:   ledger,
+		check:    check,
+		mu:       &sync.Mutex{},
+	}
+}
+
+// Reply replies to the parent unless the bot already has, in which case it
+// does nothing.
+func (g *replyGuard) Triggered(name string) Account {
+	t := *g
+	t.Account = g.Account.Triggered(name)
+	t.r = triggered(g.r, name)
+	return &t
+}
+
+func (g *replyGuard) Reply(parentName, text string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	key := replyKey(g.username, parentName)
+	if g.ledger != nil {
+		if replied, err := g.ledger.Seen(key); err != nil || replied {
+			return err
+		}
+	}
+
+	if g.check {
+		if replied, err := g.replied(parentName); err != nil || replied {
+			if err == nil && g.ledger != nil {
+				err = g.ledger.MarkSeen(key)
+			}
+			return err
+		}
+	}
+
+	if err := g.Account.Reply(parentName, text); err != nil {
+		return err
+	}
+
+	if g.ledger != nil {
+		return g.ledger.MarkSeen(key)
+	}
+	return nil
+}
+
+// replied looks through the bot's most recent comments, or sent messages if
+// the parent is a message, for a reply to the parent.
+func (g *replyGuard) replied(parentName string) (bool, error) {
+	path := "/user/" + g.username + "/comments"
+	if strings.HasPrefix(parentName, messageKind+"_") {
+		path = "/message/sent"
+	}
+
+	h, err := g.r.reap(path, map[string]string{"limit": "100", "raw_json": "1"})
+	if err != nil {
+		return false, err
+	}
+
+	for _, c := range h.Comments {
+		if c.ParentID == parentName {
+			return true, nil
+		}
+	}
+	for _, m := range h.Messages {
+		if m.ParentID == parentName {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// replyKey is the key replies by the bot to the parent are recorded under.
+func replyKey(username, parentName string) string {
+	return "reply:" + username + ":" + parentName
+}
@@ -0,0 +1,70 @@
+package reddit
+
+import (
+	"testing"
+)
+
+type mapLedger map[string]bool
+
+func (m mapLedger) Seen(name string) (bool, error) { return m[name], nil }
+
+func (m mapLedger) MarkSeen(name string) error {
+	m[name] = true
+	return nil
+}
+
+func TestReplyGuardLedger(t *testing.T) {
+	r := reaperWhich(Harvest{}, nil)
+	ledger := mapLedger{}
+	g := newReplyGuard(newAccount(r, SplitConfig{}), r, "bot", ledger, false)
+
+	for i := 0; i < 2; i++ {
+		r.path = ""
+		if err := g.Reply("t1_parent", "hi"); err != nil {
+			t.Fatalf("error replying: %v", err)
+		}
+
+		sent := r.path == "/api/comment"
+		if sent != (i == 0) {
+			t.Errorf("reply %d: sent %v; wanted only the first sent", i, sent)
+		}
+	}
+
+	if !ledger["reply:bot:t1_parent"] {
+		t.Errorf("reply was not recorded; ledger is %v", ledger)
+	}
+}
+
+func TestReplyGuardCheck(t *testing.T) {
+	r := reaperWhich(
+		Harvest{Comments: []*Comment{{ParentID: "t1_answered"}}},
+		nil,
+	)
+	ledger := mapLedger{}
+	g := newReplyGuard(newAccount(r, SplitConfig{}), r, "bot", ledger, true)
+
+	if err := g.Reply("t1_answered", "hi"); err != nil {
+		t.Fatalf("error replying: %v", err)
+	}
+	if r.path != "/user/bot/comments" {
+		t.Errorf("got path %s; wanted only the check of comments", r.path)
+	}
+	if !ledger["reply:bot:t1_answered"] {
+		t.Errorf("earlier reply was not recorded")
+	}
+
+	if err := g.Reply("t1_unanswered", "hi"); err != nil {
+		t.Fatalf("error replying: %v", err)
+	}
+	if r.path != "/api/comment" {
+		t.Errorf("reply to an unanswered comment was not sent")
+	}
+
+	r.values = nil
+	if err := g.Reply("t4_message", "hi"); err != nil {
+		t.Fatalf("error replying: %v", err)
+	}
+	if r.values["limit"] != "100" {
+		t.Errorf("sent messages were not checked")
+	}
+}
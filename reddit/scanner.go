@@ -1,8 +1,66 @@
 package reddit
 
+import (
+	"strconv"
+)
+
 // deletedAuthor is the author field of deleted posts on Reddit.
 const deletedAuthor = "[deleted]"
 
+// Time ranges Reddit supports for top and controversial listings.
+const (
+	TimeHour  = "hour"
+	TimeDay   = "day"
+	TimeWeek  = "week"
+	TimeMonth = "month"
+	TimeYear  = "year"
+	TimeAll   = "all"
+)
+
+// ListingOptions select the page of a listing which is fetched. Text in the
+// harvest is always unescaped, as Reddit is asked for raw JSON.
+type ListingOptions struct {
+	// After and Before are the fullnames of elements the page is fetched
+	// after or before in the listing. If both are empty, the page starts
+	// at the top of the listing.
+	After  string
+	Before string
+	// Limit is the number of elements fetched. The default is 100, the
+	// most Reddit allows.
+	Limit int
+	// Time is the range a top or controversial listing covers, one of the
+	// Time constants. The default is Reddit's, a day.
+	Time string
+	// ShowAll includes elements the account's preferences would hide,
+	// e.g. posts it has hidden or voted on.
+	ShowAll bool
+	// SubredditDetail includes details of each post's subreddit.
+	SubredditDetail bool
+}
+
+func (l ListingOptions) values() map[string]string {
+	values := map[string]string{"raw_json": "1", "limit": "100"}
+	if l.After != "" {
+		values["after"] = l.After
+	}
+	if l.Before != "" {
+		values["before"] = l.Before
+	}
+	if l.Limit > 0 {
+		values["limit"] = strconv.Itoa(l.Limit)
+	}
+	if l.Time != "" {
+		values["t"] = l.Time
+	}
+	if l.ShowAll {
+		values["show"] = "all"
+	}
+	if l.SubredditDetail {
+		values["sr_detail"] = "true"
+	}
+	return values
+}
+
 // Scanner defines a low level interface for fetching reading Reddit listings.
 type Scanner interface {
 	// Listing returns a harvest from a listing endpoint at Reddit.
@@ -28,6 +86,9 @@ type Scanner interface {
 	// or graw/streams.
 	Listing(path, after string) (Harvest, error)
 	ListingWithParams(path string, params map[string]string) (Harvest, error)
+	// ListingWithOptions returns the page of a listing selected by the
+	// options.
+	ListingWithOptions(path string, opts ListingOptions) (Harvest, error)
 }
 
 type scanner struct {
@@ -60,4 +121,11 @@ func (s *scanner) ListingWithParams(path string, params map[string]string) (
 		reaperParams[key] = value
 	}
 	return s.r.reap(path, reaperParams)
-}
\ No newline at end of file
+}
+
+func (s *scanner) ListingWithOptions(
+	path string,
+	opts ListingOptions,
+) (Harvest, error) {
+	return s.r.reap(path, opts.values())
+}
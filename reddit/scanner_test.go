@@ -0,0 +1,37 @@
+package reddit
+
+import (
+	"testing"
+
+	"github.com/kylelemons/godebug/pretty"
+)
+
+func TestListingWithOptions(t *testing.T) {
+	r := reaperWhich(Harvest{}, nil)
+	s := newScanner(r)
+
+	if _, err := s.ListingWithOptions(
+		"/r/golang/top",
+		ListingOptions{
+			After:           "t3_abc",
+			Limit:           25,
+			Time:            TimeWeek,
+			ShowAll:         true,
+			SubredditDetail: true,
+		},
+	); err != nil {
+		t.Errorf("error pulling listing: %v", err)
+	}
+
+	expected := map[string]string{
+		"raw_json":  "1",
+		"after":     "t3_abc",
+		"limit":     "25",
+		"t":         "week",
+		"show":      "all",
+		"sr_detail": "true",
+	}
+	if diff := pretty.Compare(r.values, expected); diff != "" {
+		t.Errorf("values incorrect; diff: %s", diff)
+	}
+}
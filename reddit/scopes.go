@@ -0,0 +1,192 @@
+package reddit
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"golang.org/x/oauth2"
+)
+
+// ScopeError is returned when the bot attempts an operation which needs an
+// OAuth scope it was not granted. Add the scope to BotConfig.Scopes (or the
+// agent file's scopes) to fix it.
+type ScopeError struct {
+	// Scope is the scope the operation needs.
+	Scope string
+	// Path is the endpoint of the operation.
+	Path string
+}
+
+func (s *ScopeError) Error() string {
+	return fmt.Sprintf(
+		"%s needs the %q oauth scope, which the bot was not granted",
+		s.Path, s.Scope,
+	)
+}
+
+// scopePrefix maps an endpoint prefix to the scope it needs.
+type scopePrefix struct {
+	prefix string
+	scope  string
+}
+
+// scopePrefixes maps endpoint prefixes to the scope they need, longest first
+// so the most specific prefix matching an endpoint wins. Endpoints which match
+// none need "read". Endpoints under a user's page are matched with the user's
+// name as {user}.
+var scopePrefixes = longestFirst([]scopePrefix{
+	{"/api/v1/me", "identity"},
+	{"/api/v1/me/karma", "mysubreddits"},
+	{"/api/v1/me/prefs", "account"},
+	{"/api/comment", "submit"},
+	{"/api/submit", "submit"},
+	{"/api/compose", "privatemessages"},
+	{"/api/read_message", "privatemessages"},
+	{"/api/unread_message", "privatemessages"},
+	{"/message/", "privatemessages"},
+	{"/api/del", "edit"},
+	{"/api/editusertext", "edit"},
+	{"/api/vote", "vote"},
+	{"/api/approve", "modposts"},
+	{"/api/remove", "modposts"},
+	{"/api/lock", "modposts"},
+	{"/api/unlock", "modposts"},
+	{"/api/distinguish", "modposts"},
+	{"/api/marknsfw", "modposts"},
+	{"/api/set_subreddit_sticky", "modposts"},
+	{"/api/friend", "modcontributors"},
+	{"/api/unfriend", "modcontributors"},
+	{"/api/selectflair", "flair"},
+	{"/api/link_flair", "flair"},
+	{"/api/user_flair", "flair"},
+	{"/user/{user}", "history"},
+	{"/user/{user}/about", "read"},
+	{"/wiki/", "wikiread"},
+})
+
+func longestFirst(prefixes []scopePrefix) []scopePrefix {
+	sort.SliceStable(prefixes, func(i, j int) bool {
+		return len(prefixes[i].prefix) > len(prefixes[j].prefix)
+	})
+	return prefixes
+}
+
+// scopeOf returns the scope needed to use an endpoint. Endpoints scoped to a
+// subreddit (e.g. /r/golang/api/link_flair_v2 or /r/golang/wiki/index) need
+// the same scope as the endpoint without it.
+func scopeOf(path string) string {
+	if strings.HasPrefix(path, "/r/") {
+		if i := strings.Index(path, "/api/"); i >= 0 {
+			path = path[i:]
+		} else if i := strings.Index(path, "/wiki/"); i >= 0 {
+			path = path[i:]
+		}
+	}
+	path = userPath(path)
+
+	for _, s := range scopePrefixes {
+		if strings.HasPrefix(path, s.prefix) {
+			return s.scope
+		}
+	}
+	return "read"
+}
+
+// userPath returns the endpoint with the user's name in it replaced by
+// {user}, e.g. /user/{user}/about for /u/spez/about. Other endpoints are
+// returned as they are.
+func userPath(path string) string {
+	var rest string
+	switch {
+	case strings.HasPrefix(path, "/user/"):
+		rest = strings.TrimPrefix(path, "/user/")
+	case strings.HasPrefix(path, "/u/"):
+		rest = strings.TrimPrefix(path, "/u/")
+	default:
+		return path
+	}
+
+	if i := strings.Index(rest, "/"); i >= 0 {
+		return "/user/{user}" + rest[i:]
+	}
+	return "/user/{user}"
+}
+
+// scopeSet is a set of granted scopes. A nil set permits everything; it is
+// used by logged out handles, whose access Reddit limits on its own.
+type scopeSet map[string]bool
+
+func newScopeSet(scopes []string) scopeSet {
+	set := make(scopeSet)
+	for _, scope := range scopes {
+		set[scope] = true
+	}
+	return set
+}
+
+// permit returns a ScopeError if the set does not include the scope needed
+// to use the endpoint.
+func (s scopeSet) permit(path string) error {
+	if s == nil {
+		return nil
+	}
+
+	if scope := scopeOf(path); !s[scope] && !s["*"] {
+		return &ScopeError{Scope: scope, Path: path}
+	}
+	return nil
+}
+
+func (s scopeSet) list() []string {
+	var scopes []string
+	for scope := range s {
+		scopes = append(scopes, scope)
+	}
+	return scopes
+}
+
+// grantedScopes returns the scopes the token carries, or the requested scopes
+// if it does not report them (e.g. tokens kept from an earlier run).
+func grantedScopes(token *oauth2.Token, requested []string) []string {
+	if token != nil {
+		if grant, ok := token.Extra("scope").(string); ok && grant != "" {
+			return strings.Fields(grant)
+		}
+	}
+	return requested
+}
+
+// checkGrant returns an error naming every requested scope Reddit did not
+// grant, if the token reports which scopes it carries.
+func checkGrant(token *oauth2.Token, requested []string) error {
+	if token == nil {
+		return nil
+	}
+
+	grant, ok := token.Extra("scope").(string)
+	if !ok || grant == "" {
+		return nil
+	}
+
+	granted := newScopeSet(strings.Fields(grant))
+	if granted["*"] {
+		return nil
+	}
+
+	var missing []string
+	for _, scope := range requested {
+		if !granted[scope] {
+			missing = append(missing, scope)
+		}
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf(
+			"reddit did not grant the requested oauth scopes %s; "+
+				"check the app's registration",
+			strings.Join(missing, ", "),
+		)
+	}
+	return nil
+}
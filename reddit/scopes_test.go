@@ -0,0 +1,70 @@
+package reddit
+
+import (
+	"reflect"
+	"testing"
+
+	"golang.org/x/oauth2"
+)
+
+func TestScopeOf(t *testing.T) {
+	for _, test := range []struct {
+		path  string
+		scope string
+	}{
+		{"/api/v1/me", "identity"},
+		{"/api/v1/me/karma", "mysubreddits"},
+		{"/api/v1/me/prefs", "account"},
+		{"/api/comment", "submit"},
+		{"/message/unread", "privatemessages"},
+		{"/api/vote", "vote"},
+		{"/r/golang/api/link_flair_v2", "flair"},
+		{"/r/golang/api/friend", "modcontributors"},
+		{"/r/golang/wiki/index", "wikiread"},
+		{"/r/golang/new", "read"},
+		{"/user/spez/comments", "history"},
+		{"/u/spez/submitted", "history"},
+		{"/user/spez", "history"},
+		{"/user/spez/about", "read"},
+		{"/u/spez/about", "read"},
+		{"/api/info", "read"},
+	} {
+		if scope := scopeOf(test.path); scope != test.scope {
+			t.Errorf(
+				"%s needs %q; wanted %q",
+				test.path, scope, test.scope,
+			)
+		}
+	}
+}
+
+func TestGrantedScopes(t *testing.T) {
+	requested := []string{"identity", "read", "submit"}
+
+	for _, test := range []struct {
+		token *oauth2.Token
+		want  []string
+	}{
+		{nil, requested},
+		{&oauth2.Token{AccessToken: "a"}, requested},
+		{
+			(&oauth2.Token{AccessToken: "a"}).WithExtra(
+				map[string]interface{}{"scope": "identity read"},
+			),
+			[]string{"identity", "read"},
+		},
+		{
+			(&oauth2.Token{AccessToken: "a"}).WithExtra(
+				map[string]interface{}{"scope": "*"},
+			),
+			[]string{"*"},
+		},
+	} {
+		if got := grantedScopes(test.token, requested); !reflect.DeepEqual(
+			got,
+			test.want,
+		) {
+			t.Errorf("got granted scopes %v; wanted %v", got, test.want)
+		}
+	}
+}
@@ -8,11 +8,13 @@ import (
 type Script interface {
 	Lurker
 	Scanner
+	Monitor
 }
 
 type script struct {
 	Lurker
 	Scanner
+	Monitor
 }
 
 // NewScript returns a Script handle to Reddit's API which always sends the
@@ -20,8 +22,16 @@ type script struct {
 // no less time between them than rate. The minimum respected value of rate is 2
 // seconds, because Reddit's API rules cap logged out non-OAuth clients at 30
 // requests per minute.
+//
+// The agent is required, and non-compliant agents are warned about on the
+// standard logger; see UserAgent.
 func NewScript(agent string, rate time.Duration) (Script, error) {
-	c, err := newClient(clientConfig{agent: agent})
+	if err := checkUserAgent(agent, nil); err != nil {
+		return nil, err
+	}
+
+	status := newStatusRecorder()
+	c, err := newClient(clientConfig{agent: agent, status: status})
 	r := newReaper(
 		reaperConfig{
 			client:     c,
@@ -30,10 +40,12 @@ func NewScript(agent string, rate time.Duration) (Script, error) {
 			reapSuffix: ".json",
 			tls:        true,
 			rate:       maxOf(rate, 2*time.Second),
+			queue:      status.queue,
 		},
 	)
 	return &script{
 		Lurker:  newLurker(r),
 		Scanner: newScanner(r),
+		Monitor: status,
 	}, err
 }
@@ -0,0 +1,115 @@
+package reddit
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// maxCommentLength is the maximum number of characters Reddit accepts in the
+// body of a comment.
+const maxCommentLength = 10000
+
+var (
+	// defaultSeparators are the split points tried, in order, when a reply
+	// is too long for one comment: paragraphs, then lines, then sentences,
+	// then words.
+	defaultSeparators = []string{"\n\n", "\n", ". ", " "}
+	// defaultContinuation is appended to every comment in a split reply
+	// but the last one.
+	defaultContinuation = "\n\n*(continued below)*"
+)
+
+// SplitConfig configures how replies longer than Reddit's comment length limit
+// are broken into a chain of comments, each replying to the one before it.
+type SplitConfig struct {
+	// Separators are the points at which a long reply may be split, in
+	// order of preference. A reply is split at the last occurrence of the
+	// most preferred separator which fits in a comment. If none fit, the
+	// reply is split at the length limit. The default is paragraphs, then
+	// lines, then sentences, then words.
+	Separators []string
+	// Continuation is appended to every comment in the chain but the last,
+	// to tell readers the reply continues below. The default is
+	// "(continued below)" in italics.
+	Continuation string
+	// MaxLength is the maximum number of characters in each comment of
+	// the chain. If unset or greater than Reddit's limit of 10,000, the
+	// limit is used.
+	MaxLength int
+}
+
+func (s SplitConfig) separators() []string {
+	if len(s.Separators) == 0 {
+		return defaultSeparators
+	}
+	return s.Separators
+}
+
+func (s SplitConfig) continuation() string {
+	if s.Continuation == "" {
+		return defaultContinuation
+	}
+	return s.Continuation
+}
+
+func (s SplitConfig) maxLength() int {
+	if s.MaxLength <= 0 || s.MaxLength > maxCommentLength {
+		return maxCommentLength
+	}
+	return s.MaxLength
+}
+
+// split breaks text into parts which each fit in a comment once the
+// continuation is appended. Text which already fits is returned as is.
+func (s SplitConfig) split(text string) []string {
+	max := s.maxLength()
+	if utf8.RuneCountInString(text) <= max {
+		return []string{text}
+	}
+
+	continuation := s.continuation()
+	room := max - utf8.RuneCountInString(continuation)
+	if room <= 0 {
+		continuation = ""
+		room = max
+	}
+
+	var parts []string
+	for utf8.RuneCountInString(text) > max {
+		head, tail := s.cut(text, room)
+		parts = append(parts, head+continuation)
+		text = tail
+	}
+
+	return append(parts, text)
+}
+
+// cut splits text into a head of at most room characters and the tail after
+// it, preferring to cut at the configured separators.
+func (s SplitConfig) cut(text string, room int) (string, string) {
+	window := prefix(text, room)
+	for _, sep := range s.separators() {
+		// Separators at the very front would produce an empty comment.
+		i := strings.LastIndex(window, sep)
+		if i <= 0 {
+			continue
+		}
+
+		end := i + len(sep)
+		if head := strings.TrimRight(text[:end], " \n"); head != "" {
+			return head, text[end:]
+		}
+	}
+
+	return window, text[len(window):]
+}
+
+// prefix returns the first n characters of text.
+func prefix(text string, n int) string {
+	i := 0
+	for ; n > 0 && i < len(text); n-- {
+		_, size := utf8.DecodeRuneInString(text[i:])
+		i += size
+	}
+	return text[:i]
+}
@@ -0,0 +1,70 @@
+package reddit
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestSplitShortText(t *testing.T) {
+	parts := SplitConfig{}.split("hello")
+	if len(parts) != 1 || parts[0] != "hello" {
+		t.Errorf("got %v; wanted text unchanged", parts)
+	}
+}
+
+func TestSplitPrefersSeparators(t *testing.T) {
+	cfg := SplitConfig{MaxLength: 20, Continuation: "+"}
+	for i, test := range []struct {
+		input  string
+		output []string
+	}{
+		{
+			"one two.\n\nthree four five",
+			[]string{"one two.+", "three four five"},
+		},
+		{
+			"one two three four five six",
+			[]string{"one two three four+", "five six"},
+		},
+		{
+			strings.Repeat("x", 25),
+			[]string{strings.Repeat("x", 19) + "+", "xxxxxx"},
+		},
+	} {
+		parts := cfg.split(test.input)
+		if strings.Join(parts, "|") != strings.Join(test.output, "|") {
+			t.Errorf("%d: got %q; wanted %q", i, parts, test.output)
+		}
+	}
+}
+
+func TestSplitRespectsLimit(t *testing.T) {
+	text := strings.Repeat("ünïcödé wörds ", 2000)
+	for i, part := range (SplitConfig{}).split(text) {
+		if n := utf8.RuneCountInString(part); n > maxCommentLength {
+			t.Errorf("part %d has %d characters", i, n)
+		}
+	}
+}
+
+func TestReplyChainsSplitText(t *testing.T) {
+	r := reaperWhich(Harvest{}, nil)
+	a := newAccount(r, SplitConfig{MaxLength: 10, Continuation: "+"})
+
+	if err := a.Reply("t3_parent", "aaaa bbbb cccc"); err != nil {
+		t.Fatalf("error replying: %v", err)
+	}
+
+	if len(r.planted) != 2 {
+		t.Fatalf("got %d comments; wanted 2", len(r.planted))
+	}
+
+	if parent := r.planted[0]["thing_id"]; parent != "t3_parent" {
+		t.Errorf("first part replied to %s; wanted t3_parent", parent)
+	}
+
+	if parent := r.planted[1]["thing_id"]; parent != "t1_b" {
+		t.Errorf("second part replied to %s; wanted t1_b", parent)
+	}
+}
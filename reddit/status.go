@@ -0,0 +1,118 @@
+package reddit
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Status reports how a handle's requests to Reddit have been going, for health
+// checks.
+type Status struct {
+	// LastSuccess is when a request to Reddit last succeeded.
+	LastSuccess time.Time
+	// LastFailure is when a request to Reddit last failed, and LastError
+	// is why.
+	LastFailure time.Time
+	LastError   string
+	// RateLimitRemaining is the number of requests Reddit last reported
+	// remain in the current rate limit period, or -1 if it has not
+	// reported one. RateLimitReset is when the period ends.
+	RateLimitRemaining float64
+	RateLimitReset     time.Time
+	// Queue is the requests waiting to be sent under the handle's rate.
+	Queue Queue
+}
+
+// Monitor defines behaviors for reporting on a handle's requests.
+type Monitor interface {
+	// Status returns the status of the handle's requests so far.
+	Status() Status
+}
+
+// statusRecorder records the status of requests made through it. It is a
+// Monitor.
+type statusRecorder struct {
+	status Status
+	// queue counts the requests waiting on the handle's rate.
+	queue *requestQueue
+	mu    *sync.Mutex
+}
+
+func newStatusRecorder() *statusRecorder {
+	return &statusRecorder{
+		status: Status{RateLimitRemaining: -1},
+		queue:  newRequestQueue(),
+		mu:     &sync.Mutex{},
+	}
+}
+
+func (s *statusRecorder) Status() Status {
+	s.mu.Lock()
+	status := s.status
+	s.mu.Unlock()
+
+	status.Queue = s.queue.queue(time.Now())
+	return status
+}
+
+// record records the result of a request made at now.
+func (s *statusRecorder) record(resp *http.Response, err error, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err == nil && (resp.StatusCode < 200 || resp.StatusCode > 299) {
+		err = fmt.Errorf("bad response code: %d", resp.StatusCode)
+	}
+
+	if err != nil {
+		s.status.LastFailure = now
+		s.status.LastError = err.Error()
+	} else {
+		s.status.LastSuccess = now
+	}
+
+	if resp == nil {
+		return
+	}
+
+	if remaining, err := strconv.ParseFloat(
+		resp.Header.Get("X-Ratelimit-Remaining"),
+		64,
+	); err == nil {
+		s.status.RateLimitRemaining = remaining
+	}
+
+	if reset, err := strconv.Atoi(
+		resp.Header.Get("X-Ratelimit-Reset"),
+	); err == nil {
+		s.status.RateLimitReset = now.Add(time.Duration(reset) * time.Second)
+	}
+}
+
+// recorder records the result of every request made through it.
+type recorder struct {
+	next   http.RoundTripper
+	status *statusRecorder
+}
+
+func (r *recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := r.next.RoundTrip(req)
+	r.status.record(resp, err, time.Now())
+	return resp, err
+}
+
+// recorded returns the client with the results of its requests recorded.
+func recorded(cli *http.Client, status *statusRecorder) *http.Client {
+	next := cli.Transport
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	return &http.Client{
+		Transport: &recorder{next: next, status: status},
+		Timeout:   cli.Timeout,
+	}
+}
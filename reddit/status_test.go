@@ -0,0 +1,52 @@
+package reddit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestStatusRecorder(t *testing.T) {
+	code := http.StatusOK
+	serv := httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("X-Ratelimit-Remaining", "598.0")
+				w.Header().Set("X-Ratelimit-Reset", "120")
+				w.WriteHeader(code)
+			},
+		),
+	)
+	defer serv.Close()
+
+	status := newStatusRecorder()
+	if s := status.Status(); s.RateLimitRemaining != -1 {
+		t.Errorf("got rate limit %v before requests; wanted -1", s.RateLimitRemaining)
+	}
+
+	cli := recorded(&http.Client{}, status)
+	if _, err := cli.Get(serv.URL); err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+
+	s := status.Status()
+	if s.LastSuccess.IsZero() || !s.LastFailure.IsZero() {
+		t.Errorf("got %+v; wanted a success and no failure", s)
+	}
+	if s.RateLimitRemaining != 598 {
+		t.Errorf("got rate limit %v; wanted 598", s.RateLimitRemaining)
+	}
+	if left := time.Until(s.RateLimitReset); left < time.Minute {
+		t.Errorf("got reset in %v; wanted about two minutes", left)
+	}
+
+	code = http.StatusServiceUnavailable
+	if _, err := cli.Get(serv.URL); err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+
+	if s := status.Status(); s.LastFailure.IsZero() || s.LastError == "" {
+		t.Errorf("got %+v; wanted a failure", s)
+	}
+}
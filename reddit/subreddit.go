@@ -0,0 +1,94 @@
+package reddit
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// Types of subreddits, which say who can read them.
+const (
+	SubredditPublic     = "public"
+	SubredditRestricted = "restricted"
+	SubredditPrivate    = "private"
+	SubredditArchived   = "archived"
+	SubredditEmployees  = "employees_only"
+)
+
+// Subreddit describes a subreddit.
+type Subreddit struct {
+	// Name is the subreddit's fullname, e.g. t5_2rc7j, and DisplayName
+	// the name it goes by, e.g. golang.
+	Name        string `json:"name"`
+	DisplayName string `json:"display_name"`
+	Title       string `json:"title"`
+	// Type is one of the Subreddit type constants. Only its approved
+	// users can read a private subreddit.
+	Type        string `json:"subreddit_type"`
+	NSFW        bool   `json:"over18"`
+	Subscribers int    `json:"subscribers"`
+	// UserIsContributor and UserIsModerator are whether the bot is an
+	// approved user and a moderator of the subreddit. Logged out bots
+	// are neither.
+	UserIsContributor bool `json:"user_is_contributor"`
+	UserIsModerator   bool `json:"user_is_moderator"`
+}
+
+// Readable returns true if the bot can read the subreddit's posts.
+func (s *Subreddit) Readable() bool {
+	switch s.Type {
+	case SubredditPrivate, SubredditEmployees:
+		return s.UserIsContributor || s.UserIsModerator
+	}
+	return true
+}
+
+func (s *lurker) Subreddits(names ...string) ([]*Subreddit, error) {
+	var subreddits []*Subreddit
+	for start := 0; start < len(names); start += infoBatch {
+		end := start + infoBatch
+		if end > len(names) {
+			end = len(names)
+		}
+
+		blob, err := s.r.reapRaw(
+			"/api/info",
+			map[string]string{
+				"sr_name":  strings.Join(names[start:end], ","),
+				"raw_json": "1",
+			},
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		batch, err := parseSubreddits(blob)
+		if err != nil {
+			return nil, err
+		}
+		subreddits = append(subreddits, batch...)
+	}
+	return subreddits, nil
+}
+
+// parseSubreddits parses the subreddits in a listing.
+func parseSubreddits(blob []byte) ([]*Subreddit, error) {
+	var listing struct {
+		Data struct {
+			Children []struct {
+				Kind string     `json:"kind"`
+				Data *Subreddit `json:"data"`
+			} `json:"children"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(blob, &listing); err != nil {
+		return nil, err
+	}
+
+	var subreddits []*Subreddit
+	for _, child := range listing.Data.Children {
+		if child.Kind == subredditKind && child.Data != nil {
+			subreddits = append(subreddits, child.Data)
+		}
+	}
+	return subreddits, nil
+}
@@ -0,0 +1,114 @@
+package reddit
+
+import (
+	"encoding/json"
+	"sync"
+
+	"golang.org/x/net/context"
+	"golang.org/x/oauth2"
+)
+
+// TokenStore persists a bot's OAuth token between runs, so bots which run
+// briefly (e.g. from cron) reuse their token rather than requesting a new one
+// every start, and can start while Reddit's token endpoint is having trouble.
+// The Sessions in graw/store satisfy this interface.
+//
+// Tokens grant access to the bot's account; keep the store as private as the
+// bot's password.
+type TokenStore interface {
+	Session(key string) ([]byte, error)
+	SetSession(key string, data []byte) error
+}
+
+// tokenKey is the key a bot's token is stored under.
+func tokenKey(app App) string {
+	return "reddit-token:" + app.ID + ":" + app.Username
+}
+
+// loadToken returns the token in the store, or nil if there is none.
+func loadToken(tokens TokenStore, key string) (*oauth2.Token, error) {
+	blob, err := tokens.Session(key)
+	if err != nil || blob == nil {
+		return nil, err
+	}
+
+	token := &oauth2.Token{}
+	return token, json.Unmarshal(blob, token)
+}
+
+// passwordSource grants tokens with the account's username and password.
+// Reddit does not issue refresh tokens for password grants, so this is how
+// expired tokens are replaced.
+type passwordSource struct {
+	ctx      context.Context
+	cfg      *oauth2.Config
+	username string
+	password string
+	otp      OTP
+}
+
+func (p *passwordSource) Token() (*oauth2.Token, error) {
+	password, err := withOTP(p.password, p.otp)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := p.cfg.PasswordCredentialsToken(
+		p.ctx,
+		p.username,
+		password,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return token, checkGrant(token, p.cfg.Scopes)
+}
+
+// storedSource saves each new token from its source to a store.
+type storedSource struct {
+	src    oauth2.TokenSource
+	tokens TokenStore
+	key    string
+	last   string
+	mu     *sync.Mutex
+}
+
+func newStoredSource(
+	src oauth2.TokenSource,
+	tokens TokenStore,
+	key string,
+) *storedSource {
+	return &storedSource{
+		src:    src,
+		tokens: tokens,
+		key:    key,
+		mu:     &sync.Mutex{},
+	}
+}
+
+func (s *storedSource) Token() (*oauth2.Token, error) {
+	token, err := s.src.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if token.AccessToken == s.last {
+		return token, nil
+	}
+
+	blob, err := json.Marshal(token)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.tokens.SetSession(s.key, blob); err != nil {
+		return nil, err
+	}
+
+	s.last = token.AccessToken
+	return token, nil
+}
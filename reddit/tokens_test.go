@@ -0,0 +1,76 @@
+package reddit
+
+import (
+	"testing"
+
+	"golang.org/x/oauth2"
+)
+
+// mockTokenStore is an in-memory TokenStore which counts writes.
+type mockTokenStore struct {
+	data   map[string][]byte
+	writes int
+}
+
+func (m *mockTokenStore) Session(key string) ([]byte, error) {
+	return m.data[key], nil
+}
+
+func (m *mockTokenStore) SetSession(key string, data []byte) error {
+	m.data[key] = data
+	m.writes++
+	return nil
+}
+
+// sequenceSource returns its tokens in order, repeating the last.
+type sequenceSource struct {
+	tokens []*oauth2.Token
+}
+
+func (s *sequenceSource) Token() (*oauth2.Token, error) {
+	token := s.tokens[0]
+	if len(s.tokens) > 1 {
+		s.tokens = s.tokens[1:]
+	}
+	return token, nil
+}
+
+func TestStoredSource(t *testing.T) {
+	store := &mockTokenStore{data: make(map[string][]byte)}
+	key := tokenKey(App{ID: "id", Username: "user"})
+
+	if token, err := loadToken(store, key); err != nil || token != nil {
+		t.Errorf("got %v, %v from empty store; wanted nil, nil", token, err)
+	}
+
+	src := newStoredSource(
+		&sequenceSource{
+			tokens: []*oauth2.Token{
+				{AccessToken: "a"},
+				{AccessToken: "a"},
+				{AccessToken: "b"},
+			},
+		},
+		store,
+		key,
+	)
+
+	for i := 0; i < 3; i++ {
+		if _, err := src.Token(); err != nil {
+			t.Fatalf("error getting token: %v", err)
+		}
+	}
+
+	if store.writes != 2 {
+		t.Errorf("store written %d times; wanted once per new token", store.writes)
+	}
+
+	token, err := loadToken(store, key)
+	if err != nil {
+		t.Fatalf("error loading token: %v", err)
+	}
+
+	if token.AccessToken != "b" {
+		t.Errorf("loaded token %q; wanted the latest, b", token.AccessToken)
+	}
+}
@@ -0,0 +1,90 @@
+package reddit
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// totpPeriod and totpDigits are the parameters authenticator apps use, and
+// the ones Reddit expects.
+const (
+	totpPeriod = 30 * time.Second
+	totpDigits = 6
+)
+
+// OTP provides one-time codes for accounts with two-factor authentication
+// enabled. Each time the bot logs in, a code is appended to its password
+// ("password:code"), as Reddit requires.
+type OTP interface {
+	// Code returns the current one-time code.
+	Code() (string, error)
+}
+
+// OTPFunc adapts a function to the OTP interface, e.g. to prompt an operator
+// for a code.
+type OTPFunc func() (string, error)
+
+func (f OTPFunc) Code() (string, error) {
+	return f()
+}
+
+// TOTP generates codes from the base32 secret shown when two-factor
+// authentication is set up (the one encoded in the QR code).
+type TOTP struct {
+	key []byte
+	now func() time.Time
+}
+
+// NewTOTP returns a TOTP generating codes from the base32 secret. Spaces and
+// letter case in the secret are ignored.
+func NewTOTP(secret string) (*TOTP, error) {
+	secret = strings.ToUpper(strings.Replace(secret, " ", "", -1))
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(
+		strings.TrimRight(secret, "="),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("totp secret is not valid base32: %v", err)
+	}
+
+	return &TOTP{key: key, now: time.Now}, nil
+}
+
+func (t *TOTP) Code() (string, error) {
+	return totpCode(t.key, t.now()), nil
+}
+
+// totpCode computes the RFC 6238 code for a key at a time.
+func totpCode(key []byte, at time.Time) string {
+	counter := make([]byte, 8)
+	binary.BigEndian.PutUint64(
+		counter,
+		uint64(at.Unix()/int64(totpPeriod/time.Second)),
+	)
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(counter)
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0xf
+	code := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%0*d", totpDigits, code%1000000)
+}
+
+// withOTP appends a one-time code to a password, if a provider is set.
+func withOTP(password string, otp OTP) (string, error) {
+	if otp == nil {
+		return password, nil
+	}
+
+	code, err := otp.Code()
+	if err != nil {
+		return "", err
+	}
+
+	return password + ":" + code, nil
+}
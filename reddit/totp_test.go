@@ -0,0 +1,51 @@
+package reddit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTOTP(t *testing.T) {
+	// Test vectors from RFC 6238, truncated to six digits.
+	key := []byte("12345678901234567890")
+	for _, test := range []struct {
+		at   int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	} {
+		if code := totpCode(key, time.Unix(test.at, 0)); code != test.code {
+			t.Errorf("at %d: got %s; wanted %s", test.at, code, test.code)
+		}
+	}
+}
+
+func TestNewTOTP(t *testing.T) {
+	totp, err := NewTOTP("gezd gnbv gy3t qojq gezd gnbv gy3t qojq")
+	if err != nil {
+		t.Fatalf("error parsing secret: %v", err)
+	}
+
+	totp.now = func() time.Time { return time.Unix(59, 0) }
+	if code, _ := totp.Code(); code != "287082" {
+		t.Errorf("got %s; wanted 287082", code)
+	}
+
+	if _, err := NewTOTP("not base32!"); err == nil {
+		t.Errorf("invalid secret accepted")
+	}
+}
+
+func TestWithOTP(t *testing.T) {
+	if password, _ := withOTP("hunter2", nil); password != "hunter2" {
+		t.Errorf("got %s; wanted the password unchanged", password)
+	}
+
+	otp := OTPFunc(func() (string, error) { return "123456", nil })
+	if password, _ := withOTP("hunter2", otp); password != "hunter2:123456" {
+		t.Errorf("got %s; wanted hunter2:123456", password)
+	}
+}
@@ -0,0 +1,135 @@
+package reddit
+
+import (
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/turnage/graw/trace"
+)
+
+// redacted are parameters whose values are never traced.
+var redacted = map[string]bool{
+	"password":      true,
+	"passwd":        true,
+	"client_secret": true,
+	"access_token":  true,
+	"refresh_token": true,
+	"code":          true,
+	"otp":           true,
+}
+
+// rateLimitHeaders are the response headers Reddit reports its rate limit in.
+var rateLimitHeaders = []string{
+	"X-Ratelimit-Used",
+	"X-Ratelimit-Remaining",
+	"X-Ratelimit-Reset",
+}
+
+// tracer logs every request made through it and the response to it.
+type tracer struct {
+	next   http.RoundTripper
+	logger *log.Logger
+}
+
+func (t *tracer) RoundTrip(r *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.next.RoundTrip(r)
+	elapsed := time.Since(start)
+
+	if err != nil {
+		t.logger.Printf(
+			"%s %s failed after %v: %v",
+			r.Method, redact(r.URL), elapsed, err,
+		)
+		return resp, err
+	}
+
+	limits := ""
+	for _, header := range rateLimitHeaders {
+		if value := resp.Header.Get(header); value != "" {
+			limits += " " + header + "=" + value
+		}
+	}
+
+	t.logger.Printf(
+		"%s %s -> %d in %v%s",
+		r.Method, redact(r.URL), resp.StatusCode, elapsed, limits,
+	)
+	return resp, nil
+}
+
+// redact returns the url with the values of credential parameters hidden.
+func redact(u *url.URL) string {
+	if u == nil {
+		return ""
+	}
+
+	query := u.Query()
+	for key := range query {
+		if redacted[key] {
+			query.Set(key, "REDACTED")
+		}
+	}
+
+	clean := *u
+	clean.User = nil
+	clean.RawQuery = query.Encode()
+	return clean.String()
+}
+
+// traced returns the client with its requests traced to the logger.
+func traced(cli *http.Client, logger *log.Logger) *http.Client {
+	next := cli.Transport
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	return &http.Client{
+		Transport: &tracer{next: next, logger: logger},
+		Timeout:   cli.Timeout,
+	}
+}
+
+// spanner records a span for every request made through it.
+type spanner struct {
+	next   http.RoundTripper
+	tracer trace.Tracer
+}
+
+func (s *spanner) RoundTrip(r *http.Request) (*http.Response, error) {
+	ctx, span := s.tracer.Start(r.Context(), "reddit.request")
+	defer span.End()
+
+	span.SetAttribute("http.method", r.Method)
+	span.SetAttribute("http.url", redact(r.URL))
+
+	resp, err := s.next.RoundTrip(r.WithContext(ctx))
+	if err != nil {
+		span.RecordError(err)
+		return resp, err
+	}
+
+	span.SetAttribute("http.status_code", resp.StatusCode)
+	if remaining := resp.Header.Get("X-Ratelimit-Remaining"); remaining != "" {
+		if n, err := strconv.ParseFloat(remaining, 64); err == nil {
+			span.SetAttribute("reddit.ratelimit_remaining", n)
+		}
+	}
+	return resp, nil
+}
+
+// spanned returns the client with a span recorded for each of its requests.
+func spanned(cli *http.Client, tracer trace.Tracer) *http.Client {
+	next := cli.Transport
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	return &http.Client{
+		Transport: &spanner{next: next, tracer: tracer},
+		Timeout:   cli.Timeout,
+	}
+}
@@ -0,0 +1,128 @@
+package reddit
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTransportDefaults(t *testing.T) {
+	tr := TransportConfig{}.transport()
+	if tr.MaxIdleConns != defaultMaxIdleConns ||
+		tr.MaxIdleConnsPerHost != defaultMaxIdleConnsPerHost ||
+		tr.IdleConnTimeout != defaultIdleConnTimeout ||
+		!tr.ForceAttemptHTTP2 {
+		t.Errorf("got %+v; wanted defaults", tr)
+	}
+
+	tr = TransportConfig{
+		MaxIdleConnsPerHost: 3,
+		IdleConnTimeout:     time.Minute,
+		DisableHTTP2:        true,
+	}.transport()
+	if tr.MaxIdleConnsPerHost != 3 || tr.IdleConnTimeout != time.Minute {
+		t.Errorf("got %+v; wanted configured values", tr)
+	}
+	if tr.ForceAttemptHTTP2 || tr.TLSNextProto == nil {
+		t.Errorf("HTTP/2 was not disabled")
+	}
+}
+
+func TestTransportReusesConnections(t *testing.T) {
+	var mu sync.Mutex
+	conns := 0
+	serv := httptest.NewUnstartedServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}),
+	)
+	serv.Config.ConnState = func(c net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			mu.Lock()
+			conns++
+			mu.Unlock()
+		}
+	}
+	serv.Start()
+	defer serv.Close()
+
+	cli := &baseClient{clientWithAgent("graw:test:1 (by /u/roxven)", TransportConfig{})}
+	for i := 0; i < 5; i++ {
+		req, _ := http.NewRequest("GET", serv.URL, nil)
+		if _, err := cli.Do(req); err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if conns != 1 {
+		t.Errorf("got %d connections for 5 requests; wanted 1", conns)
+	}
+}
+
+func TestTransportDecompresses(t *testing.T) {
+	encoders := map[string]func(io.Writer) io.WriteCloser{
+		"gzip":    func(w io.Writer) io.WriteCloser { return gzip.NewWriter(w) },
+		"deflate": func(w io.Writer) io.WriteCloser { return zlib.NewWriter(w) },
+	}
+
+	var accepted string
+	serv := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			accepted = r.Header.Get("Accept-Encoding")
+			encoding := r.URL.Query().Get("encoding")
+			encode, ok := encoders[encoding]
+			if !ok {
+				w.Write([]byte("listing"))
+				return
+			}
+
+			var buf bytes.Buffer
+			enc := encode(&buf)
+			enc.Write([]byte("listing"))
+			enc.Close()
+			w.Header().Set("Content-Encoding", encoding)
+			w.Write(buf.Bytes())
+		}),
+	)
+	defer serv.Close()
+
+	for _, test := range []struct {
+		encoding string
+		disabled bool
+		accepted string
+	}{
+		{"gzip", false, "gzip, deflate"},
+		{"deflate", false, "gzip, deflate"},
+		{"", true, ""},
+	} {
+		cli := &baseClient{
+			clientWithAgent(
+				"graw:test:1 (by /u/roxven)",
+				TransportConfig{DisableCompression: test.disabled},
+			),
+		}
+
+		req, _ := http.NewRequest("GET", serv.URL+"?encoding="+test.encoding, nil)
+		body, err := cli.Do(req)
+		if err != nil {
+			t.Fatalf("%s: request failed: %v", test.encoding, err)
+		}
+
+		if string(body) != "listing" {
+			t.Errorf("%s: got body %q; wanted listing", test.encoding, body)
+		}
+		if accepted != test.accepted {
+			t.Errorf(
+				"%s: sent Accept-Encoding %q; wanted %q",
+				test.encoding, accepted, test.accepted,
+			)
+		}
+	}
+}
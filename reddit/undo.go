@@ -0,0 +1,65 @@
+package reddit
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/turnage/graw/store"
+)
+
+var (
+	UnknownActionErr      = fmt.Errorf("The audit log has no such action.")
+	IrreversibleActionErr = fmt.Errorf("The action cannot be undone.")
+)
+
+// Undo reverses an action in the audit log, so the effects of a misfiring bot
+// can be reverted quickly. Comments and posts the bot made are deleted, inbox
+// items it marked read are marked unread, and vice versa, things it approved
+// are removed, and vice versa, and users it banned are unbanned. Failed
+// actions, and actions Reddit offers no reversal for, such as sending a
+// message, cannot be undone; nor can unbans, whose ban's terms are not known.
+//
+// The account should be the bot which made the action. If it records to the
+// audit log, the reversal is recorded too.
+func Undo(acct Account, audit store.Audit, actionID int64) error {
+	a, err := audit.Action(actionID)
+	if err != nil {
+		return err
+	} else if a == nil {
+		return UnknownActionErr
+	} else if a.Err != "" {
+		return IrreversibleActionErr
+	}
+
+	switch a.Path {
+	case "/api/comment", "/api/submit":
+		if a.Result == "" {
+			return IrreversibleActionErr
+		}
+		return acct.Delete(a.Result)
+	case "/api/read_message":
+		return acct.MarkUnread(strings.Split(a.Values["id"], ",")...)
+	case "/api/unread_message":
+		return acct.MarkRead(strings.Split(a.Values["id"], ",")...)
+	case "/api/approve":
+		return acct.Remove(a.Values["id"], false)
+	case "/api/remove":
+		return acct.Approve(a.Values["id"])
+	}
+
+	if subreddit, ok := banSubreddit(a); ok {
+		return acct.Unban(subreddit, a.Values["name"])
+	}
+	return IrreversibleActionErr
+}
+
+// banSubreddit returns the subreddit a user was banned from, if the action is
+// a ban.
+func banSubreddit(a *store.Action) (string, bool) {
+	if a.Values["type"] != "banned" || !strings.HasPrefix(a.Path, "/r/") ||
+		!strings.HasSuffix(a.Path, "/api/friend") {
+		return "", false
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(a.Path, "/r/"), "/api/friend"),
+		true
+}
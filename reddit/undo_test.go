@@ -0,0 +1,60 @@
+package reddit
+
+import (
+	"io/ioutil"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/turnage/graw/store"
+)
+
+func TestUndo(t *testing.T) {
+	r := reaperWhich(Harvest{}, nil)
+	audit := store.NewMemory()
+	a := newAccount(
+		newAuditReaper(r, audit, log.New(ioutil.Discard, "", 0)),
+		SplitConfig{},
+	)
+
+	a.Reply("t1_parent", "oops")
+	a.MarkRead("t4_a", "t4_b")
+	a.SendMessage("spez", "hello", "hi")
+	a.Approve("t3_a")
+	a.Remove("t3_b", true)
+	a.Ban("golang", "spammer", 7, "spam")
+
+	for i, test := range []struct {
+		id     int64
+		err    error
+		path   string
+		target string
+	}{
+		{1, nil, "/api/del", "t1_b"},
+		{2, nil, "/api/unread_message", "t4_a,t4_b"},
+		{3, IrreversibleActionErr, "", ""},
+		{4, nil, "/api/remove", "t3_a"},
+		{5, nil, "/api/approve", "t3_b"},
+		{6, nil, "/r/golang/api/unfriend", "spammer"},
+		{100, UnknownActionErr, "", ""},
+	} {
+		r.path = ""
+		if err := Undo(a, audit, test.id); err != test.err {
+			t.Errorf("%d: got error %v; wanted %v", i, err, test.err)
+		}
+		if r.path != test.path {
+			t.Errorf("%d: undone with %q; wanted %q", i, r.path, test.path)
+		}
+		if test.path == "" {
+			continue
+		}
+
+		actions, _ := audit.Actions(time.Time{})
+		if last := actions[len(actions)-1]; last.Target != test.target {
+			t.Errorf(
+				"%d: reversal recorded with target %q; wanted %q",
+				i, last.Target, test.target,
+			)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package reddit
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+)
+
+var errMissingUserAgent = fmt.Errorf(
+	"a user agent is required; build one with reddit.UserAgent",
+)
+
+// userAgentPattern matches user agents in the format Reddit's API rules ask
+// for: <platform>:<app ID>:<version string> (by /u/<reddit username>)
+var userAgentPattern = regexp.MustCompile(
+	`^[^:\s]+:[^:\s]+:[^:\s]+ \(by /u/[A-Za-z0-9_-]{3,20}\)$`,
+)
+
+// genericAgents are fragments of user agents sent by default by HTTP libraries
+// and browsers, which Reddit throttles heavily.
+var genericAgents = []string{
+	"Go-http-client",
+	"python-requests",
+	"curl/",
+	"Mozilla/",
+}
+
+// UserAgent builds a user agent in the format Reddit's API rules ask for. Bots
+// with user agents Reddit does not like are throttled, often silently.
+//
+//	agent := reddit.UserAgent{
+//		Platform: "linux",
+//		AppID:    "mybot",
+//		Version:  "1.0.0",
+//		Username: "mybotsauthor",
+//	}.String() // linux:mybot:1.0.0 (by /u/mybotsauthor)
+type UserAgent struct {
+	// Platform the bot runs on, e.g. "linux" or "server".
+	Platform string
+	// AppID is a unique name for the bot.
+	AppID string
+	// Version of the bot.
+	Version string
+	// Username of the bot's author on Reddit, without "/u/".
+	Username string
+}
+
+func (u UserAgent) String() string {
+	return fmt.Sprintf(
+		"%s:%s:%s (by /u/%s)",
+		u.Platform, u.AppID, u.Version,
+		strings.TrimPrefix(strings.TrimPrefix(u.Username, "/u/"), "u/"),
+	)
+}
+
+// Validate returns an error if the user agent is incomplete or malformed.
+func (u UserAgent) Validate() error {
+	if warnings := UserAgentWarnings(u.String()); len(warnings) > 0 {
+		return fmt.Errorf("invalid user agent: %s", strings.Join(warnings, "; "))
+	}
+	return nil
+}
+
+// UserAgentWarnings returns the ways a user agent departs from the format
+// Reddit's API rules ask for, or nothing if it is compliant.
+func UserAgentWarnings(agent string) []string {
+	if agent == "" {
+		return []string{"user agent is empty"}
+	}
+
+	var warnings []string
+	for _, generic := range genericAgents {
+		if strings.Contains(agent, generic) {
+			warnings = append(
+				warnings,
+				fmt.Sprintf(
+					"user agent looks like a default (%s); "+
+						"Reddit throttles these heavily",
+					generic,
+				),
+			)
+		}
+	}
+
+	if !userAgentPattern.MatchString(agent) {
+		warnings = append(
+			warnings,
+			fmt.Sprintf(
+				"user agent %q does not match "+
+					"<platform>:<app ID>:<version> (by /u/<username>)",
+				agent,
+			),
+		)
+	}
+
+	return warnings
+}
+
+// checkUserAgent returns an error if the agent is missing and logs warnings
+// to the logger, if there is one, if it is not compliant.
+func checkUserAgent(agent string, logger *log.Logger) error {
+	if agent == "" {
+		return errMissingUserAgent
+	}
+
+	if logger == nil {
+		return nil
+	}
+	for _, warning := range UserAgentWarnings(agent) {
+		logger.Printf("warning: %s", warning)
+	}
+	return nil
+}
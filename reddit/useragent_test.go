@@ -0,0 +1,46 @@
+package reddit
+
+import (
+	"testing"
+)
+
+func TestUserAgent(t *testing.T) {
+	agent := UserAgent{
+		Platform: "linux",
+		AppID:    "mybot",
+		Version:  "1.0.0",
+		Username: "/u/roxven",
+	}
+
+	if agent.String() != "linux:mybot:1.0.0 (by /u/roxven)" {
+		t.Errorf("got %q", agent.String())
+	}
+
+	if err := agent.Validate(); err != nil {
+		t.Errorf("valid agent rejected: %v", err)
+	}
+
+	if err := (UserAgent{Platform: "linux"}).Validate(); err == nil {
+		t.Errorf("incomplete agent accepted")
+	}
+}
+
+func TestUserAgentWarnings(t *testing.T) {
+	for _, test := range []struct {
+		agent    string
+		warnings int
+	}{
+		{"linux:mybot:1.0.0 (by /u/roxven)", 0},
+		{"android:com.example.app:v1.2.3 (by /u/kemitche)", 0},
+		{"", 1},
+		{"mybot by roxven", 1},
+		{"Go-http-client/1.1", 2},
+	} {
+		if warnings := UserAgentWarnings(test.agent); len(warnings) != test.warnings {
+			t.Errorf(
+				"%q: got warnings %v; wanted %d",
+				test.agent, warnings, test.warnings,
+			)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package reddit
+
+import (
+	"encoding/json"
+)
+
+// WikiPage is a page of a subreddit's wiki.
+type WikiPage struct {
+	// Content is the page's markdown.
+	Content string `json:"content_md"`
+	// RevisionID identifies the page's current revision; it changes with
+	// each edit. RevisionDate is when the revision was made, in seconds
+	// since the epoch.
+	RevisionID   string  `json:"revision_id"`
+	RevisionDate float64 `json:"revision_date"`
+	// RevisionBy is who made the revision, if Reddit says.
+	RevisionBy string `json:"-"`
+	// MayRevise is whether the bot can edit the page.
+	MayRevise bool `json:"may_revise"`
+}
+
+func (s *lurker) WikiPage(subreddit, page string) (*WikiPage, error) {
+	blob, err := s.r.reapRaw(
+		"/r/"+subreddit+"/wiki/"+page,
+		map[string]string{"raw_json": "1"},
+	)
+	if err != nil {
+		return nil, err
+	}
+	return parseWikiPage(blob)
+}
+
+// parseWikiPage parses a wiki page response.
+func parseWikiPage(blob []byte) (*WikiPage, error) {
+	var resp struct {
+		Data struct {
+			WikiPage
+			RevisionBy *struct {
+				Data struct {
+					Name string `json:"name"`
+				} `json:"data"`
+			} `json:"revision_by"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(blob, &resp); err != nil {
+		return nil, err
+	}
+
+	page := resp.Data.WikiPage
+	if resp.Data.RevisionBy != nil {
+		page.RevisionBy = resp.Data.RevisionBy.Data.Name
+	}
+	return &page, nil
+}
@@ -0,0 +1,133 @@
+package router
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/turnage/graw/botfaces"
+	"github.com/turnage/graw/sink"
+)
+
+// deliver calls the handler's method for the kind of the event, if it
+// implements one.
+func deliver(handler interface{}, ev sink.Event) error {
+	switch ev.Kind {
+	case sink.PostKind:
+		if h, ok := handler.(botfaces.PostHandler); ok {
+			return h.Post(ev.Post)
+		}
+	case sink.CommentKind:
+		if h, ok := handler.(botfaces.CommentHandler); ok {
+			return h.Comment(ev.Comment)
+		}
+	case sink.UserPostKind:
+		if h, ok := handler.(botfaces.UserHandler); ok {
+			return h.UserPost(ev.Post)
+		}
+	case sink.UserCommentKind:
+		if h, ok := handler.(botfaces.UserHandler); ok {
+			return h.UserComment(ev.Comment)
+		}
+	case sink.PostReplyKind:
+		if h, ok := handler.(botfaces.PostReplyHandler); ok {
+			return h.PostReply(ev.Message)
+		}
+	case sink.CommentReplyKind:
+		if h, ok := handler.(botfaces.CommentReplyHandler); ok {
+			return h.CommentReply(ev.Message)
+		}
+	case sink.MentionKind:
+		if h, ok := handler.(botfaces.MentionHandler); ok {
+			return h.Mention(ev.Message)
+		}
+	case sink.MessageKind:
+		if h, ok := handler.(botfaces.MessageHandler); ok {
+			return h.Message(ev.Message)
+		}
+	case sink.CommentContextKind:
+		if h, ok := handler.(botfaces.CommentContextHandler); ok {
+			return h.CommentContext(ev.Comment, ev.Ancestors, ev.Post)
+		}
+	case sink.PostBatchKind:
+		if h, ok := handler.(botfaces.PostBatchHandler); ok {
+			return h.PostBatch(ev.Posts)
+		}
+	case sink.CommentBatchKind:
+		if h, ok := handler.(botfaces.CommentBatchHandler); ok {
+			return h.CommentBatch(ev.Comments)
+		}
+	case sink.ThreadKind:
+		if h, ok := handler.(botfaces.ThreadHandler); ok {
+			return h.ThreadComment(ev.Comment)
+		}
+	case sink.PostAgeKind:
+		if h, ok := handler.(botfaces.PostAgeHandler); ok {
+			return h.PostAge(ev.Post, time.Duration(ev.Age)*time.Second)
+		}
+	case sink.RankKind:
+		if h, ok := handler.(botfaces.RankHandler); ok {
+			return h.Rank(ev.Listing, ev.Post, ev.Rank, ev.PreviousRank)
+		}
+	case sink.DigestKind:
+		if h, ok := handler.(botfaces.DigestHandler); ok {
+			return h.Digest(ev.Listing, ev.Posts)
+		}
+	case sink.PostGildedKind:
+		if h, ok := handler.(botfaces.GildingHandler); ok {
+			return h.PostGilded(ev.Post)
+		}
+	case sink.CommentGildedKind:
+		if h, ok := handler.(botfaces.GildingHandler); ok {
+			return h.CommentGilded(ev.Comment)
+		}
+	case sink.FlairChangedKind:
+		if h, ok := handler.(botfaces.FlairHandler); ok {
+			return h.FlairChanged(ev.Post, ev.OldFlair)
+		}
+	case sink.OPReplyKind:
+		if h, ok := handler.(botfaces.OPReplyHandler); ok {
+			return h.OPReply(ev.Post, ev.Comment)
+		}
+	case sink.PostDeletedKind:
+		if h, ok := handler.(botfaces.DeletionHandler); ok {
+			return h.PostDeleted(ev.Post, ev.Removal)
+		}
+	case sink.CommentDeletedKind:
+		if h, ok := handler.(botfaces.DeletionHandler); ok {
+			return h.CommentDeleted(ev.Comment, ev.Removal)
+		}
+	case sink.PostPageKind:
+		if h, ok := handler.(botfaces.PageHandler); ok {
+			return h.PostPage(ev.Post, ev.Page)
+		}
+	case sink.PostFilteredKind:
+		if h, ok := handler.(botfaces.SubmissionFilteredHandler); ok {
+			return h.PostFiltered(ev.Post)
+		}
+	case sink.CommentFilteredKind:
+		if h, ok := handler.(botfaces.SubmissionFilteredHandler); ok {
+			return h.CommentFiltered(ev.Comment)
+		}
+	case sink.HandlerPausedKind:
+		if h, ok := handler.(botfaces.AlertHandler); ok {
+			return h.HandlerPaused(ev.Handler, fmt.Errorf("%s", ev.Error))
+		}
+	case sink.HandlerResumedKind:
+		if h, ok := handler.(botfaces.AlertHandler); ok {
+			return h.HandlerResumed(ev.Handler)
+		}
+	case sink.SourceDegradedKind:
+		if h, ok := handler.(botfaces.SourceAlertHandler); ok {
+			return h.SourceDegraded(ev.Feed, fmt.Errorf("%s", ev.Error))
+		}
+	case sink.SourceSuspendedKind:
+		if h, ok := handler.(botfaces.SourceAlertHandler); ok {
+			return h.SourceSuspended(ev.Feed, fmt.Errorf("%s", ev.Error))
+		}
+	case sink.SourceRecoveredKind:
+		if h, ok := handler.(botfaces.SourceAlertHandler); ok {
+			return h.SourceRecovered(ev.Feed)
+		}
+	}
+	return nil
+}
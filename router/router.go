@@ -0,0 +1,216 @@
+// Package router hosts several independent behaviors in one bot process by
+// routing each event to the handlers registered for it:
+//
+//	r := router.New(
+//		router.Route{Subreddits: []string{"golang"}, Handler: releaseBot},
+//		router.Route{
+//			Kinds:   []string{sink.MentionKind},
+//			Handler: helpBot,
+//		},
+//	)
+//	stop, wait, err := graw.Run(r, bot, cfg)
+//
+// Handlers are ordinary bots implementing the interfaces in graw/botfaces. The
+// router implements all of them, so graw forwards every event subscribed to in
+// the graw.Config; events routed to a handler which does not implement the
+// interface for them are skipped.
+//
+// Multilingual communities can route each language to its own responder:
+//
+//	router.Route{Languages: []string{"es"}, Handler: spanishBot}
+//
+// A new route can be tried on live traffic before it acts by making it a
+// shadow route: the events it matches are reported rather than delivered.
+//
+//	router.Route{
+//		Name:    "spam remover",
+//		Filter:  filter.MustCompile("title ~= 'free crypto'"),
+//		Handler: remover,
+//		Shadow:  true,
+//	}
+package router
+
+import (
+	"io/ioutil"
+	"log"
+	"strings"
+
+	"github.com/turnage/graw/botfaces"
+	"github.com/turnage/graw/filter"
+	"github.com/turnage/graw/reddit"
+	"github.com/turnage/graw/sink"
+)
+
+// Route selects events for a handler. An event is routed to the handler if it
+// matches every criterion set; a route with none set receives every event.
+type Route struct {
+	// Kinds are the kinds of events routed, named by the kind constants
+	// in graw/sink (e.g. sink.PostKind).
+	Kinds []string
+	// Subreddits are the subreddits events are routed from. Events which
+	// did not happen in a subreddit, such as private messages, never
+	// match.
+	Subreddits []string
+	// Languages are the languages of the events routed, by ISO 639-1
+	// code (e.g. "es"), as graw/lang guesses them. Include "" to route
+	// events whose language cannot be told.
+	Languages []string
+	// Filter must match the post, comment, or message the event is about.
+	Filter *filter.Filter
+	// Handler is the bot events are routed to.
+	Handler interface{}
+	// Name names the route in reports.
+	Name string
+	// Shadow, if true, makes the route report-only: events it matches are
+	// reported instead of delivered to its handler, so it can be tuned on
+	// live traffic before it acts.
+	Shadow bool
+	// Report receives the events a shadow route matches. If nil, they are
+	// written to the router's logger: the graw.Config's Logger, if the
+	// router is run with one.
+	Report func(ev sink.Event)
+}
+
+// matches returns true if the event should be routed to the handler.
+func (r Route) matches(ev sink.Event) bool {
+	if len(r.Kinds) > 0 && !contains(r.Kinds, ev.Kind, false) {
+		return false
+	}
+
+	if len(r.Subreddits) > 0 &&
+		!contains(r.Subreddits, ev.Subreddit(), true) {
+		return false
+	}
+
+	if len(r.Languages) > 0 && !contains(r.Languages, ev.Language(), true) {
+		return false
+	}
+
+	if r.Filter != nil && !r.Filter.Match(thingOf(ev)) {
+		return false
+	}
+	return true
+}
+
+// report records that the shadow route matched the event.
+func (r Route) report(ev sink.Event, logger *log.Logger) {
+	if r.Report != nil {
+		r.Report(ev)
+		return
+	}
+
+	name := ""
+	switch thing := thingOf(ev).(type) {
+	case *reddit.Post:
+		name = thing.Name
+	case *reddit.Comment:
+		name = thing.Name
+	case *reddit.Message:
+		name = thing.Name
+	}
+	logger.Printf(
+		"router: shadow route %q would handle %s %s",
+		r.Name, ev.Kind, name,
+	)
+}
+
+func contains(set []string, value string, fold bool) bool {
+	for _, s := range set {
+		if s == value || (fold && strings.EqualFold(s, value)) {
+			return true
+		}
+	}
+	return false
+}
+
+// thingOf returns what the event is about. OP reply events carry their post
+// for context, but are about the comment.
+func thingOf(ev sink.Event) interface{} {
+	switch {
+	case ev.Comment != nil:
+		return ev.Comment
+	case ev.Message != nil:
+		return ev.Message
+	case ev.Post != nil:
+		return ev.Post
+	}
+	return nil
+}
+
+// Router is a bot which routes the events it receives to the handlers of the
+// routes they match, in the order the routes were given. An event matching
+// several routes is delivered to each of them, except shadow routes, which
+// only report it.
+type Router struct {
+	*sink.Handler
+	routes []Route
+	logger *log.Logger
+}
+
+// New returns a Router for the routes.
+func New(routes ...Route) *Router {
+	r := &Router{
+		routes: routes,
+		logger: log.New(ioutil.Discard, "", 0),
+	}
+	r.Handler = sink.NewHandler(r.route)
+	return r
+}
+
+// SetLogger makes the router report shadow routes' events to the logger, and
+// gives it to every routed handler which takes one. graw calls it with the
+// config's Logger. A nil logger discards reports.
+func (r *Router) SetLogger(logger *log.Logger) {
+	if logger == nil {
+		logger = log.New(ioutil.Discard, "", 0)
+	}
+	r.logger = logger
+	for _, route := range r.routes {
+		if l, ok := route.Handler.(botfaces.Logged); ok {
+			l.SetLogger(logger)
+		}
+	}
+}
+
+// SetUp sets up every routed handler which needs it, failing on the first
+// which cannot be set up.
+func (r *Router) SetUp() error {
+	for _, route := range r.routes {
+		if l, ok := route.Handler.(botfaces.Loader); ok {
+			if err := l.SetUp(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// TearDown tears down every routed handler which needs it.
+func (r *Router) TearDown() {
+	for _, route := range r.routes {
+		if t, ok := route.Handler.(botfaces.Tearer); ok {
+			t.TearDown()
+		}
+	}
+}
+
+// route delivers the event to every route it matches. It returns the first
+// error a handler returns, after every handler has had the event.
+func (r *Router) route(ev sink.Event) error {
+	var first error
+	for _, route := range r.routes {
+		if !route.matches(ev) {
+			continue
+		}
+
+		if route.Shadow {
+			route.report(ev, r.logger)
+			continue
+		}
+
+		if err := deliver(route.Handler, ev); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
@@ -0,0 +1,210 @@
+package router
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"reflect"
+	"testing"
+
+	"github.com/turnage/graw/filter"
+	"github.com/turnage/graw/reddit"
+	"github.com/turnage/graw/sink"
+)
+
+// postBot records posts it is handed.
+type postBot struct {
+	posts []string
+	err   error
+}
+
+func (p *postBot) Post(post *reddit.Post) error {
+	p.posts = append(p.posts, post.Name)
+	return p.err
+}
+
+// mentionBot records mentions it is handed.
+type mentionBot struct {
+	mentions []string
+	setUp    bool
+}
+
+func (m *mentionBot) SetUp() error {
+	m.setUp = true
+	return nil
+}
+
+func (m *mentionBot) Mention(msg *reddit.Message) error {
+	m.mentions = append(m.mentions, msg.Name)
+	return nil
+}
+
+func TestRouter(t *testing.T) {
+	golang := &postBot{}
+	popular := &postBot{}
+	mentions := &mentionBot{}
+	r := New(
+		Route{Subreddits: []string{"golang"}, Handler: golang},
+		Route{Filter: filter.MustCompile("score > 100"), Handler: popular},
+		Route{Kinds: []string{sink.MentionKind}, Handler: mentions},
+	)
+
+	if err := r.SetUp(); err != nil {
+		t.Fatalf("error setting up: %v", err)
+	}
+	if !mentions.setUp {
+		t.Errorf("routed handler was not set up")
+	}
+
+	r.Post(&reddit.Post{Name: "t3_a", Subreddit: "GoLang"})
+	r.Post(&reddit.Post{Name: "t3_b", Subreddit: "golang", Score: 500})
+	r.Post(&reddit.Post{Name: "t3_c", Subreddit: "rust", Score: 500})
+	r.Mention(&reddit.Message{Name: "t1_d", Subreddit: "golang"})
+
+	if expected := []string{"t3_a", "t3_b"}; !reflect.DeepEqual(golang.posts, expected) {
+		t.Errorf("golang got %v; wanted %v", golang.posts, expected)
+	}
+	if expected := []string{"t3_b", "t3_c"}; !reflect.DeepEqual(popular.posts, expected) {
+		t.Errorf("popular got %v; wanted %v", popular.posts, expected)
+	}
+	if expected := []string{"t1_d"}; !reflect.DeepEqual(mentions.mentions, expected) {
+		t.Errorf("mentions got %v; wanted %v", mentions.mentions, expected)
+	}
+}
+
+// batchBot records the batches and digests it is handed.
+type batchBot struct {
+	batches [][]string
+	digests []string
+}
+
+func (b *batchBot) PostBatch(posts []*reddit.Post) error {
+	var names []string
+	for _, p := range posts {
+		names = append(names, p.Name)
+	}
+	b.batches = append(b.batches, names)
+	return nil
+}
+
+func (b *batchBot) Digest(listing string, posts []*reddit.Post) error {
+	b.digests = append(b.digests, listing)
+	return nil
+}
+
+func TestRouterBatches(t *testing.T) {
+	batches := &batchBot{}
+	r := New(Route{Handler: batches})
+
+	r.PostBatch([]*reddit.Post{{Name: "t3_a"}, {Name: "t3_b"}})
+	r.Digest("/r/golang/top", []*reddit.Post{{Name: "t3_c"}})
+	r.CommentBatch([]*reddit.Comment{{Name: "t1_d"}})
+
+	if expected := [][]string{{"t3_a", "t3_b"}}; !reflect.DeepEqual(batches.batches, expected) {
+		t.Errorf("got batches %v; wanted %v", batches.batches, expected)
+	}
+	if expected := []string{"/r/golang/top"}; !reflect.DeepEqual(batches.digests, expected) {
+		t.Errorf("got digests %v; wanted %v", batches.digests, expected)
+	}
+}
+
+func TestRouterErrors(t *testing.T) {
+	failing := &postBot{err: fmt.Errorf("failed")}
+	after := &postBot{}
+	r := New(Route{Handler: failing}, Route{Handler: after})
+
+	if err := r.Post(&reddit.Post{Name: "t3_a"}); err != failing.err {
+		t.Errorf("got %v; wanted %v", err, failing.err)
+	}
+	if len(after.posts) != 1 {
+		t.Errorf("later route did not get the event after an error")
+	}
+}
+
+func TestRouterShadow(t *testing.T) {
+	live := &postBot{}
+	shadow := &postBot{}
+	var reported []string
+	r := New(
+		Route{Handler: live},
+		Route{
+			Name:    "popular",
+			Filter:  filter.MustCompile("score > 100"),
+			Handler: shadow,
+			Shadow:  true,
+			Report: func(ev sink.Event) {
+				reported = append(reported, ev.Post.Name)
+			},
+		},
+	)
+
+	r.Post(&reddit.Post{Name: "t3_a", Score: 500})
+	r.Post(&reddit.Post{Name: "t3_b"})
+
+	if len(live.posts) != 2 {
+		t.Errorf("live route got %v; wanted both posts", live.posts)
+	}
+	if len(shadow.posts) != 0 {
+		t.Errorf("shadow route acted on %v", shadow.posts)
+	}
+	if expected := []string{"t3_a"}; !reflect.DeepEqual(reported, expected) {
+		t.Errorf("shadow route reported %v; wanted %v", reported, expected)
+	}
+}
+
+func TestRouterShadowLogger(t *testing.T) {
+	logged := &loggedBot{}
+	r := New(
+		Route{Name: "popular", Handler: &postBot{}, Shadow: true},
+		Route{Handler: logged},
+	)
+
+	var buf bytes.Buffer
+	logger := log.New(&buf, "", 0)
+	r.SetLogger(logger)
+	r.Post(&reddit.Post{Name: "t3_a"})
+
+	want := "router: shadow route \"popular\" would handle post t3_a\n"
+	if buf.String() != want {
+		t.Errorf("logged %q; wanted %q", buf.String(), want)
+	}
+	if logged.logger != logger {
+		t.Errorf("routed handler was not given the logger")
+	}
+}
+
+// loggedBot takes a logger.
+type loggedBot struct {
+	postBot
+	logger *log.Logger
+}
+
+func (l *loggedBot) SetLogger(logger *log.Logger) {
+	l.logger = logger
+}
+
+func TestRouterLanguages(t *testing.T) {
+	spanish := &postBot{}
+	unknown := &postBot{}
+	r := New(
+		Route{Languages: []string{"es"}, Handler: spanish},
+		Route{Languages: []string{""}, Handler: unknown},
+	)
+
+	r.Post(&reddit.Post{
+		Name:  "t3_a",
+		Title: "¿Alguien sabe por qué mi código no compila con los módulos?",
+	})
+	r.Post(&reddit.Post{
+		Name:  "t3_b",
+		Title: "Does anyone know why my code is not compiling with the modules?",
+	})
+	r.Post(&reddit.Post{Name: "t3_c", Title: "Go 1.20"})
+
+	if expected := []string{"t3_a"}; !reflect.DeepEqual(spanish.posts, expected) {
+		t.Errorf("spanish got %v; wanted %v", spanish.posts, expected)
+	}
+	if expected := []string{"t3_c"}; !reflect.DeepEqual(unknown.posts, expected) {
+		t.Errorf("unknown got %v; wanted %v", unknown.posts, expected)
+	}
+}
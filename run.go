@@ -8,6 +8,15 @@ import (
 	"github.com/turnage/graw/streams"
 )
 
+// The types Reddit gives inbox items. Private messages have none; they are
+// typed messageType here.
+const (
+	postReplyType    = "post_reply"
+	commentReplyType = "comment_reply"
+	mentionType      = "username_mention"
+	messageType      = "private_message"
+)
+
 var (
 	postReplyHandlerErr = fmt.Errorf(
 		"You must implement PostReplHandler to take post reply feeds.",
@@ -35,6 +44,7 @@ func Run(handler interface{}, bot reddit.Bot, cfg Config) (
 	kill := make(chan bool)
 	errs := make(chan error)
 
+	lendLogger(handler, cfg.Logger)
 	if err := connectAllStreams(
 		handler,
 		bot,
@@ -55,89 +65,278 @@ func connectAllStreams(
 	kill <-chan bool,
 	errs chan<- error,
 ) error {
+	if err := checkScopes(c, bot.Scopes()); err != nil {
+		return err
+	}
+
+	self := ""
+	if c.LoopGuard.IgnoreSelf || c.SpamCheck.Script != nil {
+		me, err := bot.Me()
+		if err != nil {
+			return err
+		}
+		self = me.Name
+	}
+
+	d := newDispatcher(c, self, errs)
 	if err := connectScanStreams(
 		handler,
 		bot,
 		c,
+		d,
+		kill,
+		errs,
+	); err != nil {
+		return err
+	}
+
+	if err := connectInbox(
+		handler,
+		bot,
+		bot,
+		c,
+		d,
+		kill,
+		errs,
+	); err != nil {
+		return err
+	}
+
+	if err := watchSpam(
+		handler,
+		bot,
+		c.SpamCheck,
+		self,
+		d,
+		kill,
+		errs,
+	); err != nil {
+		return err
+	}
+
+	return serveHealth(c.Health, bot, d, kill)
+}
+
+// inboxFeed is a kind of inbox item the bot can ask to be forwarded.
+type inboxFeed struct {
+	// path is the listing of only this kind of item.
+	path string
+	// kind is the message type of this kind of item.
+	kind string
+	// dispatch forwards an item of this kind to the bot, delivered as its
+	// feed's Delivery says.
+	dispatch func(m *reddit.Message, delivery Delivery) bool
+}
+
+// connectInbox follows the parts of the bot's inbox it asked for. A bot which
+// takes one kind of inbox item polls only the listing of that kind; a bot which
+// takes several polls the whole inbox once and sorts the items by kind, rather
+// than poll each listing separately.
+func connectInbox(
+	handler interface{},
+	sc reddit.Scanner,
+	acct reddit.Account,
+	c Config,
+	d *dispatcher,
+	kill <-chan bool,
+	errs chan<- error,
+) error {
+	feeds, err := inboxFeeds(handler, c, d)
+	if err != nil || len(feeds) == 0 {
+		return err
+	}
+
+	var marker *readMarker
+	if c.MarkRead {
+		marker = newReadMarker(acct, d.clock, d.logger)
+		go marker.run(kill, readInterval)
+	}
+
+	path := "/message/inbox"
+	if len(feeds) == 1 {
+		path = feeds[0].path
+	}
+	delivery := c.deliveryOf(path)
+
+	route := func(m *reddit.Message) bool {
+		kind := messageType
+		if m.WasComment {
+			kind = m.Type
+		}
+
+		for _, feed := range feeds {
+			if feed.kind != kind {
+				continue
+			}
+
+			// Under AtMostOnce, items are marked read whether or
+			// not they were handled, so they are not redelivered.
+			done := feed.dispatch(m, delivery)
+			if marker != nil && (done || delivery == AtMostOnce) {
+				marker.add(m.Name)
+			}
+			return done
+		}
+		// Items of kinds the bot did not ask for are skipped, and
+		// left unread.
+		return true
+	}
+
+	// Items left unread are redelivered before the feed forwards any, and
+	// the feed skips those it finds again.
+	feed := route
+	redelivered := make(map[string]bool)
+	done := make(chan bool)
+	if marker != nil {
+		feed = func(m *reddit.Message) bool {
+			<-done
+			if redelivered[m.Name] {
+				delete(redelivered, m.Name)
+				return true
+			}
+			return route(m)
+		}
+	}
+
+	if err := followListing(
+		feedScanner(sc, path, c, d, kill),
+		c.Cursors,
+		path,
+		delivery,
 		kill,
 		errs,
+		func(e streams.Event) bool { return feed(e.Message) },
 	); err != nil {
 		return err
 	}
 
+	if marker != nil {
+		go func() {
+			redeliverUnread(sc, route, redelivered, errs)
+			close(done)
+		}()
+	}
+	return nil
+}
+
+// redeliverUnread forwards the items left unread in the bot's inbox, e.g.
+// because the bot stopped before it handled them, oldest first, and records
+// their names in redelivered.
+func redeliverUnread(
+	sc reddit.Scanner,
+	route func(m *reddit.Message) bool,
+	redelivered map[string]bool,
+	errs chan<- error,
+) {
+	// The listing is newest first; each page is of the items older than
+	// the last page's oldest.
+	var unread []*reddit.Message
+	after := ""
+	for {
+		h, err := sc.ListingWithParams(
+			"/message/unread",
+			map[string]string{"after": after},
+		)
+		if err != nil {
+			errs <- err
+			break
+		}
+		if len(h.Messages) == 0 ||
+			h.Messages[len(h.Messages)-1].Name == after {
+			break
+		}
+
+		unread = append(unread, h.Messages...)
+		after = h.Messages[len(h.Messages)-1].Name
+	}
+
+	for i := len(unread) - 1; i >= 0; i-- {
+		redelivered[unread[i].Name] = true
+		route(unread[i])
+	}
+}
+
+// inboxFeeds returns the inbox feeds the config asks for.
+func inboxFeeds(
+	handler interface{},
+	c Config,
+	d *dispatcher,
+) ([]inboxFeed, error) {
+	var feeds []inboxFeed
+
 	// lol no generics:
 
 	if c.PostReplies {
-		if prh, ok := handler.(botfaces.PostReplyHandler); !ok {
-			return postReplyHandlerErr
-		} else if prs, err := streams.PostReplies(
-			bot,
-			kill,
-			errs,
-		); err != nil {
-			return err
-		} else {
-			go func() {
-				for pr := range prs {
-					errs <- prh.PostReply(pr)
-				}
-			}()
+		prh, ok := handler.(botfaces.PostReplyHandler)
+		if !ok {
+			return nil, postReplyHandlerErr
 		}
+
+		feeds = append(feeds, inboxFeed{
+			path: "/message/selfreply",
+			kind: postReplyType,
+			dispatch: func(m *reddit.Message, delivery Delivery) bool {
+				return d.dispatch(
+					deliveredAs(messageEv(postReplyEvent, m), delivery),
+					func() error { return prh.PostReply(m) },
+				)
+			},
+		})
 	}
 
 	if c.CommentReplies {
-		if crh, ok := handler.(botfaces.CommentReplyHandler); !ok {
-			return commentReplyHandlerErr
-		} else if crs, err := streams.CommentReplies(
-			bot,
-			kill,
-			errs,
-		); err != nil {
-			return err
-		} else {
-			go func() {
-				for cr := range crs {
-					errs <- crh.CommentReply(cr)
-				}
-			}()
+		crh, ok := handler.(botfaces.CommentReplyHandler)
+		if !ok {
+			return nil, commentReplyHandlerErr
 		}
+
+		feeds = append(feeds, inboxFeed{
+			path: "/message/comments",
+			kind: commentReplyType,
+			dispatch: func(m *reddit.Message, delivery Delivery) bool {
+				return d.dispatch(
+					deliveredAs(messageEv(commentReplyEvent, m), delivery),
+					func() error { return crh.CommentReply(m) },
+				)
+			},
+		})
 	}
 
 	if c.Mentions {
-		if mh, ok := handler.(botfaces.MentionHandler); !ok {
-			return mentionHandlerErr
-		} else if ms, err := streams.Mentions(
-			bot,
-			kill,
-			errs,
-		); err != nil {
-			return err
-		} else {
-			go func() {
-				for m := range ms {
-					errs <- mh.Mention(m)
-				}
-			}()
+		mh, ok := handler.(botfaces.MentionHandler)
+		if !ok {
+			return nil, mentionHandlerErr
 		}
+
+		feeds = append(feeds, inboxFeed{
+			path: "/message/mentions",
+			kind: mentionType,
+			dispatch: func(m *reddit.Message, delivery Delivery) bool {
+				return d.dispatch(
+					deliveredAs(messageEv(mentionEvent, m), delivery),
+					func() error { return mh.Mention(m) },
+				)
+			},
+		})
 	}
 
 	if c.Messages {
-		if mh, ok := handler.(botfaces.MessageHandler); !ok {
-			return messageHandlerErr
-		} else if ms, err := streams.Messages(
-			bot,
-			kill,
-			errs,
-		); err != nil {
-			return err
-		} else {
-			go func() {
-				for m := range ms {
-					errs <- mh.Message(m)
-				}
-			}()
+		mh, ok := handler.(botfaces.MessageHandler)
+		if !ok {
+			return nil, messageHandlerErr
 		}
+
+		feeds = append(feeds, inboxFeed{
+			path: "/message/messages",
+			kind: messageType,
+			dispatch: func(m *reddit.Message, delivery Delivery) bool {
+				return d.dispatch(
+					deliveredAs(messageEv(messageEvent, m), delivery),
+					func() error { return mh.Message(m) },
+				)
+			},
+		})
 	}
 
-	return nil
+	return feeds, nil
 }
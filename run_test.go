@@ -0,0 +1,207 @@
+package graw
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/turnage/graw/reddit"
+)
+
+// inboxListing answers every listing with its messages once, and the unread
+// listing with its unread messages, two to a page. It records the paths
+// requested.
+type inboxListing struct {
+	reddit.Scanner
+	messages []*reddit.Message
+	unread   []*reddit.Message
+	paths    map[string]bool
+	mu       sync.Mutex
+}
+
+func (i *inboxListing) Listing(path, before string) (reddit.Harvest, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.paths[path] = true
+	h := reddit.Harvest{Messages: i.messages}
+	i.messages = nil
+	return h, nil
+}
+
+func (i *inboxListing) ListingWithParams(
+	path string,
+	params map[string]string,
+) (reddit.Harvest, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.paths[path] = true
+	unread := i.unread
+	for j, m := range unread {
+		if m.Name == params["after"] {
+			unread = unread[j+1:]
+			break
+		}
+	}
+	if len(unread) > 2 {
+		unread = unread[:2]
+	}
+	return reddit.Harvest{Messages: unread}, nil
+}
+
+type inboxRecorder struct {
+	handled chan string
+}
+
+func (i *inboxRecorder) Message(m *reddit.Message) error {
+	i.handled <- "message " + m.Name
+	return nil
+}
+
+func (i *inboxRecorder) Mention(m *reddit.Message) error {
+	i.handled <- "mention " + m.Name
+	return nil
+}
+
+func TestConnectInbox(t *testing.T) {
+	for _, test := range []struct {
+		c    Config
+		path string
+		want []string
+	}{
+		{
+			Config{Mentions: true},
+			"/message/mentions",
+			[]string{"mention t1_b"},
+		},
+		{
+			Config{Messages: true, Mentions: true},
+			"/message/inbox",
+			[]string{"message t4_c", "mention t1_b"},
+		},
+	} {
+		sc := &inboxListing{paths: make(map[string]bool)}
+		rec := &inboxRecorder{handled: make(chan string, 3)}
+		kill := make(chan bool)
+		errs := make(chan error, 10)
+
+		if err := connectInbox(
+			rec,
+			sc,
+			nil,
+			test.c,
+			newDispatcher(test.c, "", errs),
+			kill,
+			errs,
+		); err != nil {
+			t.Fatalf("error connecting inbox: %v", err)
+		}
+
+		// The first poll syncs the monitor; the next finds these.
+		sc.mu.Lock()
+		sc.messages = []*reddit.Message{
+			{Name: "t4_c"},
+			{Name: "t1_b", WasComment: true, Type: "username_mention"},
+			{Name: "t1_a", WasComment: true, Type: "comment_reply"},
+		}
+		sc.mu.Unlock()
+
+		for _, want := range test.want {
+			if got := <-rec.handled; got != want {
+				t.Errorf("got %s; wanted %s", got, want)
+			}
+		}
+		close(kill)
+
+		sc.mu.Lock()
+		if len(sc.paths) != 1 || !sc.paths[test.path] {
+			t.Errorf("polled %v; wanted only %s", sc.paths, test.path)
+		}
+		sc.mu.Unlock()
+	}
+}
+
+func TestConnectInboxRedeliversUnread(t *testing.T) {
+	c := Config{Mentions: true, MarkRead: true}
+	sc := &inboxListing{
+		paths: make(map[string]bool),
+		unread: []*reddit.Message{
+			{Name: "t1_c", WasComment: true, Type: "username_mention"},
+			{Name: "t1_b", WasComment: true, Type: "username_mention"},
+			{Name: "t1_a", WasComment: true, Type: "username_mention"},
+		},
+	}
+	rec := &inboxRecorder{handled: make(chan string, 5)}
+	kill := make(chan bool)
+	defer close(kill)
+	errs := make(chan error, 10)
+
+	if err := connectInbox(
+		rec,
+		sc,
+		&readRecorder{},
+		c,
+		newDispatcher(c, "", errs),
+		kill,
+		errs,
+	); err != nil {
+		t.Fatalf("error connecting inbox: %v", err)
+	}
+
+	// The feed finds an unread item again, which is not forwarded twice.
+	sc.mu.Lock()
+	sc.messages = []*reddit.Message{
+		{Name: "t1_d", WasComment: true, Type: "username_mention"},
+		{Name: "t1_c", WasComment: true, Type: "username_mention"},
+	}
+	sc.mu.Unlock()
+
+	for _, want := range []string{
+		"mention t1_a",
+		"mention t1_b",
+		"mention t1_c",
+		"mention t1_d",
+	} {
+		if got := <-rec.handled; got != want {
+			t.Errorf("got %s; wanted %s", got, want)
+		}
+	}
+	select {
+	case got := <-rec.handled:
+		t.Errorf("got %s again", got)
+	default:
+	}
+
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	if !sc.paths["/message/unread"] {
+		t.Errorf("unread items were not fetched; polled %v", sc.paths)
+	}
+}
+
+func TestSubredditNames(t *testing.T) {
+	names := subredditNames([]string{"R/Golang", "golang", "/r/rust/", ""})
+	if len(names) != 2 || names[0] != "golang" || names[1] != "rust" {
+		t.Errorf("got subreddit names %v; wanted [golang rust]", names)
+	}
+}
+
+func TestExceptFromAll(t *testing.T) {
+	names := exceptFromAll(
+		[]string{"all", "golang", "popular"},
+		[]string{"pics", "funny"},
+	)
+	want := []string{"all-pics-funny", "golang", "popular-pics-funny"}
+	if len(names) != len(want) {
+		t.Fatalf("got %v; wanted %v", names, want)
+	}
+	for i := range want {
+		if names[i] != want[i] {
+			t.Errorf("got %v; wanted %v", names, want)
+		}
+	}
+
+	if names := exceptFromAll([]string{"all"}, nil); names[0] != "all" {
+		t.Errorf("got %v without exclusions; wanted [all]", names)
+	}
+}
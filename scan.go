@@ -2,10 +2,14 @@ package graw
 
 import (
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/turnage/graw/botfaces"
+	"github.com/turnage/graw/link"
 	"github.com/turnage/graw/reddit"
 	"github.com/turnage/graw/streams"
+	"github.com/turnage/graw/unfurl"
 )
 
 var (
@@ -16,12 +20,33 @@ var (
 		"You must implement CommentHandler to handle subreddit " +
 			"comment feeds.",
 	)
+	commentContextHandlerErr = fmt.Errorf(
+		"You must implement CommentContextHandler to receive comment " +
+			"context.",
+	)
 	userHandlerErr = fmt.Errorf(
 		"You must implement UserHandler to handle user feeds.",
 	)
+	postAgeHandlerErr = fmt.Errorf(
+		"You must implement PostAgeHandler to follow posts as they age.",
+	)
+	rankHandlerErr = fmt.Errorf(
+		"You must implement RankHandler to watch ranked listings.",
+	)
+	pageHandlerErr = fmt.Errorf(
+		"You must implement PageHandler to receive linked pages.",
+	)
+	threadHandlerErr = fmt.Errorf(
+		"You must implement a thread handler (ThreadHandler, " +
+			"GildingHandler, FlairHandler, OPReplyHandler, or " +
+			"DeletionHandler) to watch threads.",
+	)
 	loggedOutErr = fmt.Errorf(
 		"You must be running as a logged in bot to get inbox feeds.",
 	)
+	ignoreSelfErr = fmt.Errorf(
+		"You must be running as a logged in bot to ignore your own events.",
+	)
 )
 
 // Scan connects any requested logged-out event sources to the given handler,
@@ -40,16 +65,27 @@ func Scan(handler interface{}, script reddit.Script, cfg Config) (
 		return nil, nil, loggedOutErr
 	}
 
+	if cfg.LoopGuard.IgnoreSelf {
+		return nil, nil, ignoreSelfErr
+	}
+
+	lendLogger(handler, cfg.Logger)
+	d := newDispatcher(cfg, "", errs)
 	if err := connectScanStreams(
 		handler,
 		script,
 		cfg,
+		d,
 		kill,
 		errs,
 	); err != nil {
 		return nil, nil, err
 	}
 
+	if err := serveHealth(cfg.Health, script, d, kill); err != nil {
+		return nil, nil, err
+	}
+
 	return launch(handler, kill, errs, logger(cfg.Logger))
 }
 
@@ -57,52 +93,180 @@ func Scan(handler interface{}, script reddit.Script, cfg Config) (
 // handler.
 func connectScanStreams(
 	handler interface{},
-	sc reddit.Scanner,
+	sc reddit.Script,
 	c Config,
+	d *dispatcher,
 	kill <-chan bool,
 	errs chan<- error,
 ) error {
+	d.alerts, _ = handler.(botfaces.AlertHandler)
+	d.sources, _ = handler.(botfaces.SourceAlertHandler)
+
+	c.Subreddits = subredditNames(c.Subreddits)
+	c.SubredditComments = subredditNames(c.SubredditComments)
+
+	var err error
+	if c.Subreddits, err = c.Shard.assign(c.Subreddits); err != nil {
+		return err
+	}
+	if c.SubredditComments, err = c.Shard.assign(
+		c.SubredditComments,
+	); err != nil {
+		return err
+	}
+
+	if c.Threads, err = threadPermalinks(c.Threads); err != nil {
+		return err
+	}
+	if c.PriorityThreads, err = threadPermalinks(
+		c.PriorityThreads,
+	); err != nil {
+		return err
+	}
+
+	if c.CheckSources {
+		if err := checkSources(sc, c); err != nil {
+			return err
+		}
+	}
+
+	if d.lists != nil {
+		keywords, err := newKeywordLoader(
+			c.KeywordLists,
+			c.Monitor.lurker(sc, "", kill),
+			d.lists,
+			d.clock,
+			d.logger,
+		)
+		if err != nil {
+			return err
+		}
+		if err := keywords.load(); err != nil {
+			return err
+		}
+		go keywords.run(kill)
+	}
+
+	excluded := subredditNames(c.AllExcept)
+	c.Subreddits = exceptFromAll(c.Subreddits, excluded)
+	c.SubredditComments = exceptFromAll(c.SubredditComments, excluded)
+
 	if len(c.Subreddits) > 0 {
-		ph, ok := handler.(botfaces.PostHandler)
-		if !ok {
-			return postHandlerErr
+		handle, err := postHandling(handler, c, d, kill)
+		if err != nil {
+			return err
 		}
 
-		if posts, err := streams.Subreddits(
-			sc,
+		var aging *ager
+		if len(c.PostAges) > 0 {
+			pah, ok := handler.(botfaces.PostAgeHandler)
+			if !ok {
+				return postAgeHandlerErr
+			}
+
+			aging = newAger(
+				c.Monitor.lurker(sc, "", kill),
+				c.PostAges,
+				d.clock,
+			)
+			go aging.run(
+				kill,
+				errs,
+				func(p *reddit.Post, age time.Duration) {
+					logCredit(d, d.leaderboards.postAwards(
+						p,
+						d.clock.Now(),
+					))
+					d.dispatch(
+						agedPostEv(p, age),
+						func() error { return pah.PostAge(p, age) },
+					)
+				},
+			)
+		}
+
+		var pages *pageFetcher
+		if c.Unfurler != nil {
+			pgh, ok := handler.(botfaces.PageHandler)
+			if !ok {
+				return pageHandlerErr
+			}
+
+			pages = newPageFetcher(c.Unfurler, d.logger)
+			pages.run(kill, func(p *reddit.Post, page *unfurl.Page) {
+				d.dispatch(
+					repeatable(postEv(postPageEvent, p)),
+					func() error { return pgh.PostPage(p, page) },
+				)
+			})
+		}
+
+		path := "/r/" + strings.Join(c.Subreddits, "+") + "/new"
+		delivery := c.deliveryOf(path)
+		if err := followListing(
+			feedScanner(sc, path, c, d, kill),
+			c.Cursors,
+			path,
+			delivery,
 			kill,
 			errs,
-			c.Subreddits...,
+			func(e streams.Event) bool {
+				p := e.Post
+				d.subreddits.post(p, d.clock.Now())
+				logCredit(d, d.leaderboards.post(p, d.clock.Now()))
+				if aging != nil {
+					aging.track(p, d.clock.Now())
+				}
+				if c.Links != nil {
+					if err := indexLink(c.Links, p); err != nil {
+						errs <- err
+					}
+				}
+				done := d.dispatch(
+					deliveredAs(postEv(postEvent, p), delivery),
+					func() error { return handle(p) },
+				)
+				if pages != nil && done {
+					pages.fetch(p)
+				}
+				return done
+			},
 		); err != nil {
 			return err
-		} else {
-			go func() {
-				for p := range posts {
-					errs <- ph.Post(p)
-				}
-			}()
 		}
 	}
 
 	if len(c.SubredditComments) > 0 {
-		ch, ok := handler.(botfaces.CommentHandler)
-		if !ok {
-			return commentHandlerErr
+		handle, err := commentHandling(handler, sc, c, d, kill)
+		if err != nil {
+			return err
 		}
 
-		if comments, err := streams.SubredditComments(
-			sc,
+		path := "/r/" + strings.Join(c.SubredditComments, "+") + "/comments"
+		delivery := c.deliveryOf(path)
+		if err := followListing(
+			feedScanner(sc, path, c, d, kill),
+			c.Cursors,
+			path,
+			delivery,
 			kill,
 			errs,
-			c.SubredditComments...,
+			func(e streams.Event) bool {
+				d.subreddits.comment(e.Comment, d.clock.Now())
+				logCredit(
+					d,
+					d.leaderboards.comment(e.Comment, d.clock.Now()),
+				)
+				return d.dispatch(
+					deliveredAs(
+						commentEv(commentEvent, e.Comment),
+						delivery,
+					),
+					func() error { return handle(e.Comment) },
+				)
+			},
 		); err != nil {
 			return err
-		} else {
-			go func() {
-				for c := range comments {
-					errs <- ch.Comment(c)
-				}
-			}()
 		}
 	}
 
@@ -113,22 +277,168 @@ func connectScanStreams(
 		}
 
 		for _, user := range c.Users {
-			if posts, comments, err := streams.User(
-				sc,
+			path := "/u/" + user
+			delivery := c.deliveryOf(path)
+			if err := followListing(
+				feedScanner(sc, path, c, d, kill),
+				c.Cursors,
+				path,
+				delivery,
 				kill,
 				errs,
-				user,
+				func(e streams.Event) bool {
+					if e.Post != nil {
+						return d.dispatch(
+							deliveredAs(
+								postEv(userPostEvent, e.Post),
+								delivery,
+							),
+							func() error { return uh.UserPost(e.Post) },
+						)
+					}
+					return d.dispatch(
+						deliveredAs(
+							commentEv(userCommentEvent, e.Comment),
+							delivery,
+						),
+						func() error { return uh.UserComment(e.Comment) },
+					)
+				},
+			); err != nil {
+				return err
+			}
+		}
+	}
+
+	if len(c.Rankings) > 0 {
+		rh, ok := handler.(botfaces.RankHandler)
+		if !ok {
+			return rankHandlerErr
+		}
+
+		for _, ranking := range c.Rankings {
+			if changes, err := streams.RanksOver(
+				c.Monitor.scanner(
+					c.Degradation.scanner(
+						c.Pacing.scanner(sc, d.clock, kill),
+						ranking.Path,
+						d,
+						kill,
+					),
+					ranking.Path,
+					kill,
+				),
+				kill,
+				errs,
+				ranking.Path,
+				ranking.Time,
+				ranking.Top,
+				ranking.Moves,
 			); err != nil {
 				return err
 			} else {
-				go func() {
-					for p := range posts {
-						errs <- uh.UserPost(p)
+				go func(ranking Ranking) {
+					for change := range changes {
+						if !ranking.wants(change.Post.Name) {
+							continue
+						}
+
+						change := change
+						d.dispatch(
+							repeatable(
+								postEv(rankEvent, change.Post),
+							),
+							func() error {
+								return rh.Rank(
+									ranking.Path,
+									change.Post,
+									change.Rank,
+									change.Previous,
+								)
+							},
+						)
 					}
-				}()
+				}(ranking)
+			}
+		}
+	}
+
+	if err := connectDigests(handler, sc, c, d, kill, errs); err != nil {
+		return err
+	}
+
+	// Priority threads follow the others, so a thread is a priority
+	// thread if its index is past the others'.
+	threads := append(append([]string{}, c.Threads...), c.PriorityThreads...)
+	threadKills := make([]<-chan bool, len(threads))
+	for i, thread := range threads {
+		threadKills[i] = expireThread(
+			sc,
+			thread,
+			c.ThreadExpiry,
+			d.clock,
+			c.Archiver,
+			kill,
+			errs,
+		)
+	}
+
+	if c.History != nil {
+		for i, thread := range threads {
+			go sampleHistory(
+				sc,
+				thread,
+				c.History,
+				c.HistoryInterval,
+				d.clock,
+				threadKills[i],
+				errs,
+			)
+		}
+	}
+
+	if len(threads) > 0 {
+		th, err := newThreadHandlers(handler)
+		if err != nil {
+			return err
+		}
+
+		turns := newThreadTurns(c)
+		for i, thread := range threads {
+			pacing, priority := c.Pacing, i >= len(c.Threads)
+			if priority {
+				pacing = priorityPacing(c)
+			}
+
+			if events, err := streams.Thread(
+				c.Monitor.lurker(
+					c.Degradation.lurker(
+						turns.lurker(
+							pacing.lurker(
+								sc,
+								d.clock,
+								threadKills[i],
+							),
+							priority,
+						),
+						thread,
+						d,
+						threadKills[i],
+					),
+					thread,
+					threadKills[i],
+				),
+				threadKills[i],
+				errs,
+				thread,
+				c.ThreadOptions,
+			); err != nil {
+				return err
+			} else {
 				go func() {
-					for c := range comments {
-						errs <- uh.UserComment(c)
+					for e := range events {
+						leaderboardAwards(d, e)
+						th.dispatch(d, e)
 					}
 				}()
 			}
@@ -137,3 +447,100 @@ func connectScanStreams(
 
 	return nil
 }
+
+// commentHandling returns the function which hands subreddit comments to the
+// handler, in batches or with their context if the config asks for either.
+func commentHandling(
+	handler interface{},
+	sc reddit.Script,
+	c Config,
+	d *dispatcher,
+	kill <-chan bool,
+) (func(*reddit.Comment) error, error) {
+	if c.Batching.Size > 0 {
+		return batchedComments(handler, c, d, kill)
+	}
+
+	if c.CommentContext <= 0 {
+		ch, ok := handler.(botfaces.CommentHandler)
+		if !ok {
+			return nil, commentHandlerErr
+		}
+		return ch.Comment, nil
+	}
+
+	cch, ok := handler.(botfaces.CommentContextHandler)
+	if !ok {
+		return nil, commentContextHandlerErr
+	}
+
+	contexts := &commentContexts{
+		lurker: c.Monitor.lurker(sc, "", kill),
+		depth:  c.CommentContext,
+		logger: d.logger,
+	}
+	return func(comment *reddit.Comment) error {
+		ancestors, post := contexts.fetch(comment)
+		return cch.CommentContext(comment, ancestors, post)
+	}, nil
+}
+
+// feedScanner returns the scanner the listing feed at path polls through:
+// traced, with parse warnings logged, paced, degraded while failing, and held
+// while paused, as the config asks.
+func feedScanner(
+	sc reddit.Scanner,
+	path string,
+	c Config,
+	d *dispatcher,
+	kill <-chan bool,
+) reddit.Scanner {
+	return c.Monitor.scanner(
+		c.Degradation.scanner(
+			c.Pacing.scanner(
+				warned(d.tracing.scanner(sc), d.logger),
+				d.clock,
+				kill,
+			),
+			path,
+			d,
+			kill,
+		),
+		path,
+		kill,
+	)
+}
+
+// subredditNames returns the subreddits named in the config in the form
+// Reddit matches names in, without duplicates, so "R/Golang" and "golang" are
+// one subreddit rather than two in a feed's listing.
+func subredditNames(names []string) []string {
+	var normal []string
+	seen := make(map[string]bool)
+	for _, name := range names {
+		name = link.SubredditName(name)
+		if name != "" && !seen[name] {
+			seen[name] = true
+			normal = append(normal, name)
+		}
+	}
+	return normal
+}
+
+// exceptFromAll returns the subreddits with the excluded subreddits left out of
+// r/all and r/popular, in Reddit's syntax: r/all-pics-funny is r/all without
+// r/pics or r/funny.
+func exceptFromAll(names, excluded []string) []string {
+	if len(excluded) == 0 {
+		return names
+	}
+
+	except := make([]string, len(names))
+	for i, name := range names {
+		except[i] = name
+		if name == "all" || name == "popular" {
+			except[i] = name + "-" + strings.Join(excluded, "-")
+		}
+	}
+	return except
+}
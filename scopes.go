@@ -0,0 +1,63 @@
+package graw
+
+import (
+	"fmt"
+)
+
+// RequiredScopes returns the OAuth scopes a bot needs for the event sources in
+// the config. Request at least these (see reddit.BotConfig.Scopes), plus any
+// the bot's own actions need, such as "submit" to reply.
+func RequiredScopes(c Config) []string {
+	scopes := []string{"read"}
+	if len(c.Users) > 0 {
+		scopes = append(scopes, "history")
+	}
+	if c.PostReplies || c.CommentReplies || c.Mentions || c.Messages {
+		scopes = append(scopes, "privatemessages")
+	}
+	if c.LoopGuard.IgnoreSelf || c.SpamCheck.Script != nil {
+		scopes = append(scopes, "identity")
+	}
+	if c.SpamCheck.Script != nil && len(c.Users) == 0 {
+		// The bot's own submissions are followed on its user page.
+		scopes = append(scopes, "history")
+	}
+	if len(c.KeywordLists.Pages) > 0 {
+		scopes = append(scopes, "wikiread")
+	}
+	for _, digest := range c.Digests {
+		if digest.PostTo != "" {
+			scopes = append(scopes, "submit")
+			break
+		}
+	}
+	return scopes
+}
+
+// checkScopes returns an error naming the first scope the config needs which
+// is not among those granted. If granted is empty, the grant is unknown and
+// nothing is checked.
+func checkScopes(c Config, granted []string) error {
+	if len(granted) == 0 {
+		return nil
+	}
+
+	has := make(map[string]bool)
+	for _, scope := range granted {
+		has[scope] = true
+	}
+	if has["*"] {
+		return nil
+	}
+
+	for _, scope := range RequiredScopes(c) {
+		if !has[scope] {
+			return fmt.Errorf(
+				"The config needs the %q oauth scope, which the "+
+					"bot was not granted.",
+				scope,
+			)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package graw
+
+import (
+	"testing"
+)
+
+func TestCheckScopes(t *testing.T) {
+	for i, test := range []struct {
+		cfg     Config
+		granted []string
+		ok      bool
+	}{
+		{Config{Subreddits: []string{"self"}}, nil, true},
+		{Config{Subreddits: []string{"self"}}, []string{"read"}, true},
+		{Config{Messages: true}, []string{"read"}, false},
+		{Config{Messages: true}, []string{"read", "privatemessages"}, true},
+		{Config{Users: []string{"spez"}}, []string{"read"}, false},
+		{
+			Config{LoopGuard: LoopGuard{IgnoreSelf: true}},
+			[]string{"read"},
+			false,
+		},
+		{
+			Config{KeywordLists: KeywordLists{
+				Pages: map[string]string{"spam": "spam"},
+			}},
+			[]string{"read"},
+			false,
+		},
+		{
+			Config{Digests: []Digest{{Path: "/r/golang/top"}}},
+			[]string{"read"},
+			true,
+		},
+		{
+			Config{Digests: []Digest{{PostTo: "golang"}}},
+			[]string{"read"},
+			false,
+		},
+		{Config{Messages: true}, []string{"*"}, true},
+	} {
+		if err := checkScopes(test.cfg, test.granted); (err == nil) != test.ok {
+			t.Errorf("%d: got %v; wanted ok: %v", i, err, test.ok)
+		}
+	}
+}
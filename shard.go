@@ -0,0 +1,67 @@
+package graw
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strings"
+)
+
+var shardErr = fmt.Errorf("Shard.Index must be at least 0 and less than Count")
+
+// Shard splits the subreddits a bot monitors among several processes, so a
+// set of subreddits too large for one process, or for one account's rate
+// limit, can be monitored by many. Every process is given the same config,
+// except for its Index; each monitors only its share of the Subreddits and
+// SubredditComments.
+//
+// Subreddits are assigned to shards by consistent hashing of their names, so
+// when the number of shards changes, only the subreddits moving to or from
+// the new shards change hands. The processes should share a store (see
+// graw/store/redis) for their Seen set and Cursors, so a subreddit which moves
+// is picked up where its last shard left it, and so they share the rate limit
+// if they share an account.
+type Shard struct {
+	// Index is this process's shard, from 0 to Count-1.
+	Index int
+	// Count is the number of shards. If zero, the subreddits are not
+	// sharded.
+	Count int
+}
+
+// ShardOf returns the shard, from 0 to shards-1, which monitors the subreddit.
+// Names are not case sensitive.
+func ShardOf(subreddit string, shards int) int {
+	h := fnv.New64a()
+	h.Write([]byte(strings.ToLower(subreddit)))
+	return jump(h.Sum64(), shards)
+}
+
+// jump is Lamping and Veach's jump consistent hash: it maps the key to one of
+// the buckets, and when the number of buckets grows from n to n+1, only 1/(n+1)
+// of the keys move, all to the new bucket.
+func jump(key uint64, buckets int) int {
+	var b, j int64 = -1, 0
+	for j < int64(buckets) {
+		b = j
+		key = key*2862933555777941757 + 1
+		j = int64(float64(b+1) * (float64(int64(1)<<31) / float64((key>>33)+1)))
+	}
+	return int(b)
+}
+
+// assign returns the subreddits in this shard's share.
+func (s Shard) assign(subreddits []string) ([]string, error) {
+	if s.Count == 0 {
+		return subreddits, nil
+	} else if s.Index < 0 || s.Index >= s.Count {
+		return nil, shardErr
+	}
+
+	var share []string
+	for _, sub := range subreddits {
+		if ShardOf(sub, s.Count) == s.Index {
+			share = append(share, sub)
+		}
+	}
+	return share, nil
+}
@@ -0,0 +1,68 @@
+package graw
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestShardAssignsEachSubredditOnce(t *testing.T) {
+	var subs []string
+	for i := 0; i < 1000; i++ {
+		subs = append(subs, fmt.Sprintf("sub%d", i))
+	}
+
+	assigned := make(map[string]int)
+	for i := 0; i < 4; i++ {
+		share, err := Shard{Index: i, Count: 4}.assign(subs)
+		if err != nil {
+			t.Fatalf("error assigning shard %d: %v", i, err)
+		}
+		if len(share) < 200 || len(share) > 300 {
+			t.Errorf("shard %d got %d of 1000 subreddits", i, len(share))
+		}
+		for _, sub := range share {
+			assigned[sub]++
+		}
+	}
+
+	for _, sub := range subs {
+		if assigned[sub] != 1 {
+			t.Errorf("%s assigned to %d shards", sub, assigned[sub])
+		}
+	}
+}
+
+func TestShardOfIsConsistent(t *testing.T) {
+	moved := 0
+	for i := 0; i < 1000; i++ {
+		sub := fmt.Sprintf("sub%d", i)
+		before, after := ShardOf(sub, 4), ShardOf(sub, 5)
+		if before != after {
+			moved++
+			if after != 4 {
+				t.Errorf("%s moved from %d to old shard %d", sub, before, after)
+			}
+		}
+	}
+
+	if moved < 150 || moved > 250 {
+		t.Errorf("%d of 1000 subreddits moved to a fifth shard", moved)
+	}
+
+	if ShardOf("golang", 7) != ShardOf("GoLang", 7) {
+		t.Errorf("shards are case sensitive")
+	}
+}
+
+func TestShardErrors(t *testing.T) {
+	for _, s := range []Shard{{Index: 4, Count: 4}, {Index: -1, Count: 2}} {
+		if _, err := s.assign([]string{"golang"}); err != shardErr {
+			t.Errorf("got error %v for %+v; wanted %v", err, s, shardErr)
+		}
+	}
+
+	subs := []string{"golang", "rust"}
+	if share, _ := (Shard{}).assign(subs); len(share) != 2 {
+		t.Errorf("unsharded config got %v; wanted all subreddits", share)
+	}
+}
@@ -0,0 +1,108 @@
+package sink
+
+import (
+	"fmt"
+	"html"
+	"strings"
+)
+
+const (
+	// redditURL prefixes permalinks in bridged messages and event sources.
+	redditURL = "https://www.reddit.com"
+	// bridgeBodyLength is the longest comment or message body bridged
+	// whole; longer ones are cut short, since the link leads to the rest.
+	bridgeBodyLength = 500
+)
+
+// Room is a chat room which events are mirrored into. See NewMatrix and
+// NewTelegram for rooms on those services.
+type Room interface {
+	// Say posts a message to the room. text is the message in plain
+	// text; html is the same message marked up, for rooms which render
+	// it.
+	Say(text, html string) error
+}
+
+// BridgeConfig configures a bridge sink.
+type BridgeConfig struct {
+	// Format returns the message posted for an event, in plain text and
+	// HTML, and false if the event should not be posted. The default,
+	// BridgeFormat, posts posts, comments, and messages, with links back
+	// to Reddit.
+	Format func(ev Event) (text, html string, ok bool)
+}
+
+// NewBridge returns a sink which mirrors every event it receives into the
+// room, e.g. a subreddit's new posts (graw.Config's Subreddits) or the
+// discussion in a thread (its Threads) into a community's chat room.
+func NewBridge(r Room, c BridgeConfig) *Handler {
+	if c.Format == nil {
+		c.Format = BridgeFormat
+	}
+
+	return NewHandler(
+		func(ev Event) error {
+			text, html, ok := c.Format(ev)
+			if !ok {
+				return nil
+			}
+			return r.Say(text, html)
+		},
+	)
+}
+
+// BridgeFormat is the default message format of bridges. Posts are posted
+// with their titles; comments and messages with their bodies, cut short if
+// they are long. Alerts and events without a thing are not posted.
+func BridgeFormat(ev Event) (string, string, bool) {
+	switch {
+	case ev.Comment != nil:
+		c := ev.Comment
+		return bridgeMessage(
+			fmt.Sprintf("/u/%s in /r/%s", c.Author, c.Subreddit),
+			clip(c.Body),
+			redditURL+c.Permalink,
+		)
+	case ev.Post != nil:
+		p := ev.Post
+		return bridgeMessage(
+			fmt.Sprintf("/u/%s posted in /r/%s", p.Author, p.Subreddit),
+			p.Title,
+			redditURL+p.Permalink,
+		)
+	case ev.Message != nil:
+		m := ev.Message
+		link := ""
+		if m.Context != "" {
+			link = redditURL + m.Context
+		}
+		return bridgeMessage(
+			fmt.Sprintf("/u/%s: %s", m.Author, m.Subject),
+			clip(m.Body),
+			link,
+		)
+	}
+	return "", "", false
+}
+
+// bridgeMessage formats a message from a header line, a body, and a link.
+func bridgeMessage(header, body, link string) (string, string, bool) {
+	text := header + "\n" + body
+	markup := "<b>" + html.EscapeString(header) + "</b><br>" +
+		strings.Replace(html.EscapeString(body), "\n", "<br>", -1)
+	if link != "" {
+		text += "\n" + link
+		markup += "<br><a href=\"" + html.EscapeString(link) + "\">" +
+			html.EscapeString(link) + "</a>"
+	}
+	return text, markup, true
+}
+
+// clip cuts text longer than the bridge body length short.
+func clip(text string) string {
+	runes := []rune(text)
+	if len(runes) <= bridgeBodyLength {
+		return text
+	}
+	return string(runes[:bridgeBodyLength]) + "…"
+}
@@ -0,0 +1,140 @@
+package sink
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/turnage/graw/reddit"
+)
+
+// roomRecorder is a Room which records what is said in it.
+type roomRecorder struct {
+	said []string
+}
+
+func (r *roomRecorder) Say(text, html string) error {
+	r.said = append(r.said, text)
+	return nil
+}
+
+func TestBridge(t *testing.T) {
+	room := &roomRecorder{}
+	b := NewBridge(room, BridgeConfig{})
+
+	if err := b.Post(&reddit.Post{
+		Author:    "alice",
+		Subreddit: "golang",
+		Title:     "Go 2 & you",
+		Permalink: "/r/golang/comments/a/go_2/",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.HandlerResumed("post"); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.ThreadComment(&reddit.Comment{
+		Author:    "bob",
+		Subreddit: "golang",
+		Body:      strings.Repeat("a", 600),
+		Permalink: "/r/golang/comments/a/go_2/b/",
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(room.said) != 2 {
+		t.Fatalf("got %d messages; wanted 2: %v", len(room.said), room.said)
+	}
+	if room.said[0] != "/u/alice posted in /r/golang\nGo 2 & you\n"+
+		"https://www.reddit.com/r/golang/comments/a/go_2/" {
+		t.Errorf("post message incorrect: %q", room.said[0])
+	}
+	if !strings.Contains(room.said[1], strings.Repeat("a", 500)+"…\n") {
+		t.Errorf("long comment was not cut short: %q", room.said[1])
+	}
+}
+
+func TestBridgeFormatHTML(t *testing.T) {
+	_, markup, ok := BridgeFormat(Event{
+		Kind:    MessageKind,
+		Message: &reddit.Message{Author: "carol", Subject: "<hi>", Body: "a\nb"},
+	})
+	if !ok || markup != "<b>/u/carol: &lt;hi&gt;</b><br>a<br>b" {
+		t.Errorf("got %q; wanted escaped markup", markup)
+	}
+}
+
+func TestMatrix(t *testing.T) {
+	requests := make(chan *http.Request, 1)
+	bodies := make(chan map[string]string, 1)
+	srv := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			var body map[string]string
+			json.NewDecoder(r.Body).Decode(&body)
+			requests <- r
+			bodies <- body
+			w.Write([]byte(`{"event_id": "$abc"}`))
+		},
+	))
+	defer srv.Close()
+
+	m := NewMatrix(MatrixConfig{
+		Homeserver: srv.URL,
+		Token:      "token",
+		Room:       "!room:example.org",
+	})
+	if err := m.Say("hello", "<b>hello</b>"); err != nil {
+		t.Fatalf("error sending: %v", err)
+	}
+
+	r, body := <-requests, <-bodies
+	if r.Method != "PUT" ||
+		!strings.HasPrefix(
+			r.URL.EscapedPath(),
+			"/_matrix/client/v3/rooms/%21room:example.org/send/m.room.message/graw.",
+		) {
+		t.Errorf("got %s %s; wanted a message sent to the room", r.Method, r.URL.EscapedPath())
+	}
+	if r.Header.Get("Authorization") != "Bearer token" {
+		t.Errorf("request was not authorized with the token")
+	}
+	if body["body"] != "hello" || body["formatted_body"] != "<b>hello</b>" {
+		t.Errorf("message incorrect: %v", body)
+	}
+}
+
+func TestTelegram(t *testing.T) {
+	bodies := make(chan map[string]interface{}, 2)
+	srv := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/bottoken/sendMessage" {
+				t.Errorf("unexpected request to %s", r.URL.Path)
+			}
+			var body map[string]interface{}
+			json.NewDecoder(r.Body).Decode(&body)
+			bodies <- body
+			if body["chat_id"] == "@closed" {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"ok": false, "description": "chat not found"}`))
+				return
+			}
+			w.Write([]byte(`{"ok": true, "result": {}}`))
+		},
+	))
+	defer srv.Close()
+
+	tg := NewTelegram(TelegramConfig{API: srv.URL, Token: "token", Chat: "@golang"})
+	if err := tg.Say("a\nb", "<b>a</b><br>b"); err != nil {
+		t.Fatalf("error sending: %v", err)
+	}
+	if body := <-bodies; body["text"] != "<b>a</b>\nb" || body["parse_mode"] != "HTML" {
+		t.Errorf("message incorrect: %v", body)
+	}
+
+	tg = NewTelegram(TelegramConfig{API: srv.URL, Token: "token", Chat: "@closed"})
+	if err := tg.Say("a", "a"); err == nil || !strings.Contains(err.Error(), "chat not found") {
+		t.Errorf("got %v; wanted Telegram's description of the failure", err)
+	}
+}
@@ -0,0 +1,61 @@
+package sink
+
+// defaultTopicPrefix prefixes the kind of an event to form its topic, if a bus
+// has no topic function.
+const defaultTopicPrefix = "graw."
+
+// Publisher publishes messages to topics on a message bus such as Kafka or
+// NATS. See DialNATS for a NATS publisher.
+//
+// graw does not depend on a Kafka client; adapting one takes a few lines. For
+// example, with github.com/segmentio/kafka-go:
+//
+//	w := &kafka.Writer{Addr: kafka.TCP("localhost:9092")}
+//	p := sink.PublisherFunc(func(topic string, payload []byte) error {
+//		return w.WriteMessages(
+//			context.Background(),
+//			kafka.Message{Topic: topic, Value: payload},
+//		)
+//	})
+type Publisher interface {
+	Publish(topic string, payload []byte) error
+}
+
+// PublisherFunc adapts a function to the Publisher interface.
+type PublisherFunc func(topic string, payload []byte) error
+
+// Publish calls f.
+func (f PublisherFunc) Publish(topic string, payload []byte) error {
+	return f(topic, payload)
+}
+
+// BusConfig configures a bus sink.
+type BusConfig struct {
+	// Encoding is the serialization of published events. The default is
+	// JSON.
+	Encoding Encoding
+	// Topic returns the topic events of a kind are published to. The
+	// default publishes to "graw.<kind>", e.g. graw.post and
+	// graw.comment.
+	Topic func(kind string) string
+}
+
+// NewBus returns a sink which publishes every event it receives with the
+// publisher. Events the encoding has nothing to encode for are not published.
+func NewBus(p Publisher, c BusConfig) *Handler {
+	if c.Topic == nil {
+		c.Topic = func(kind string) string {
+			return defaultTopicPrefix + kind
+		}
+	}
+
+	return NewHandler(
+		func(ev Event) error {
+			payload, err := c.Encoding.Encode(ev)
+			if err != nil || payload == nil {
+				return err
+			}
+			return p.Publish(c.Topic(ev.Kind), payload)
+		},
+	)
+}
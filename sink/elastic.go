@@ -0,0 +1,347 @@
+package sink
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultElasticIndex     = "graw-events"
+	defaultElasticBatch     = 100
+	defaultElasticFlush     = 5 * time.Second
+	defaultElasticTimeout   = 30 * time.Second
+	elasticIndexExistsError = "resource_already_exists_exception"
+)
+
+// elasticMapping is the mapping of the index events are written to. Names
+// and other identifiers are keywords, for exact matches and aggregations;
+// titles and bodies are full text. The whole event is kept in event, but not
+// indexed.
+const elasticMapping = `{
+	"mappings": {
+		"properties": {
+			"kind": {"type": "keyword"},
+			"name": {"type": "keyword"},
+			"subreddit": {"type": "keyword"},
+			"author": {"type": "keyword"},
+			"title": {"type": "text"},
+			"body": {"type": "text"},
+			"url": {"type": "keyword"},
+			"domain": {"type": "keyword"},
+			"link_id": {"type": "keyword"},
+			"parent_id": {"type": "keyword"},
+			"score": {"type": "long"},
+			"created": {"type": "date", "format": "epoch_second"},
+			"received": {"type": "date", "format": "epoch_second"},
+			"event": {"type": "object", "enabled": false}
+		}
+	}
+}`
+
+// ElasticConfig configures an Elasticsearch sink. OpenSearch speaks the same
+// API, so the sink works with it too.
+type ElasticConfig struct {
+	// URL is the address of the cluster, e.g. http://localhost:9200.
+	URL string
+	// Index is the index events are written to. It is created with the
+	// sink's mapping if it does not exist. The default is graw-events.
+	Index string
+	// Username and Password, if set, authenticate requests with basic
+	// auth.
+	Username string
+	Password string
+	// BatchSize is the number of events sent in each bulk request. The
+	// default is 100.
+	BatchSize int
+	// FlushInterval is the longest events wait to be sent when fewer than
+	// BatchSize are waiting. The default is five seconds.
+	FlushInterval time.Duration
+	// Client makes the requests. The default is a client with a thirty
+	// second timeout.
+	Client *http.Client
+}
+
+// Elastic is a sink which indexes every event it receives into Elasticsearch,
+// in batches. Like any sink, it is a bot handler; hand it to graw.Run or
+// graw.Scan in place of a bot.
+//
+// Each event is a document in the index, identified by its kind and the
+// fullname of the thing it is about (e.g. post:t3_5du939), so events which
+// repeat replace their earlier documents rather than piling up.
+type Elastic struct {
+	*Handler
+	c ElasticConfig
+	// pending is the bulk request body of the events waiting to be sent.
+	pending bytes.Buffer
+	count   int
+	// err is the last failure of a flush in the background, returned
+	// from the next event.
+	err  error
+	mu   *sync.Mutex
+	kill chan bool
+	done chan bool
+}
+
+// NewElastic returns a sink which indexes events into the configured cluster,
+// creating the index if it does not exist. Close it when the run is over to
+// send the events still waiting.
+func NewElastic(c ElasticConfig) (*Elastic, error) {
+	if c.Index == "" {
+		c.Index = defaultElasticIndex
+	}
+	if c.BatchSize <= 0 {
+		c.BatchSize = defaultElasticBatch
+	}
+	if c.FlushInterval <= 0 {
+		c.FlushInterval = defaultElasticFlush
+	}
+	if c.Client == nil {
+		c.Client = &http.Client{Timeout: defaultElasticTimeout}
+	}
+	c.URL = strings.TrimSuffix(c.URL, "/")
+
+	e := &Elastic{
+		c:    c,
+		mu:   &sync.Mutex{},
+		kill: make(chan bool),
+		done: make(chan bool),
+	}
+	if err := e.createIndex(); err != nil {
+		return nil, err
+	}
+
+	e.Handler = NewHandler(e.add)
+	go e.run()
+	return e, nil
+}
+
+// Close sends the events still waiting and stops the sink.
+func (e *Elastic) Close() error {
+	close(e.kill)
+	<-e.done
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.flush()
+}
+
+// createIndex creates the index with the sink's mapping, unless it exists.
+func (e *Elastic) createIndex() error {
+	resp, body, err := e.do(
+		"PUT",
+		"/"+e.c.Index,
+		"application/json",
+		[]byte(elasticMapping),
+	)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode == http.StatusBadRequest &&
+		bytes.Contains(body, []byte(elasticIndexExistsError)) {
+		return nil
+	} else if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf(
+			"creating index %s: elasticsearch responded %s: %s",
+			e.c.Index, resp.Status, body,
+		)
+	}
+	return nil
+}
+
+// add queues the event, and sends the batch if it is full.
+func (e *Elastic) add(ev Event) error {
+	doc, id, err := elasticDoc(ev)
+	if err != nil {
+		return err
+	}
+
+	action := map[string]interface{}{"_index": e.c.Index}
+	if id != "" {
+		action["_id"] = id
+	}
+	meta, err := json.Marshal(map[string]interface{}{"index": action})
+	if err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if err := e.err; err != nil {
+		e.err = nil
+		return err
+	}
+
+	e.pending.Write(meta)
+	e.pending.WriteByte('\n')
+	e.pending.Write(doc)
+	e.pending.WriteByte('\n')
+	e.count++
+
+	if e.count >= e.c.BatchSize {
+		return e.flush()
+	}
+	return nil
+}
+
+// run sends waiting events every flush interval until the sink is closed.
+func (e *Elastic) run() {
+	defer close(e.done)
+
+	ticker := time.NewTicker(e.c.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-e.kill:
+			return
+		case <-ticker.C:
+			e.mu.Lock()
+			if err := e.flush(); err != nil {
+				e.err = err
+			}
+			e.mu.Unlock()
+		}
+	}
+}
+
+// flush sends the waiting events in a bulk request. Callers hold mu. Events
+// are not resent if the request fails; the error says how many were lost.
+func (e *Elastic) flush() error {
+	if e.count == 0 {
+		return nil
+	}
+
+	body := append([]byte{}, e.pending.Bytes()...)
+	count := e.count
+	e.pending.Reset()
+	e.count = 0
+
+	resp, respBody, err := e.do(
+		"POST",
+		"/_bulk",
+		"application/x-ndjson",
+		body,
+	)
+	if err != nil {
+		return fmt.Errorf("indexing %d events: %v", count, err)
+	} else if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf(
+			"indexing %d events: elasticsearch responded %s",
+			count, resp.Status,
+		)
+	}
+
+	var result struct {
+		Errors bool `json:"errors"`
+		Items  []map[string]struct {
+			Status int             `json:"status"`
+			Error  json.RawMessage `json:"error"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return err
+	} else if !result.Errors {
+		return nil
+	}
+
+	failed := 0
+	var first json.RawMessage
+	for _, item := range result.Items {
+		for _, op := range item {
+			if op.Status >= 300 {
+				if failed == 0 {
+					first = op.Error
+				}
+				failed++
+			}
+		}
+	}
+	return fmt.Errorf(
+		"elasticsearch failed to index %d of %d events; first error: %s",
+		failed, count, first,
+	)
+}
+
+// do makes a request to the cluster and reads its response.
+func (e *Elastic) do(
+	method, path, contentType string,
+	body []byte,
+) (*http.Response, []byte, error) {
+	req, err := http.NewRequest(method, e.c.URL+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	req.Header.Set("Content-Type", contentType)
+	if e.c.Username != "" || e.c.Password != "" {
+		req.SetBasicAuth(e.c.Username, e.c.Password)
+	}
+
+	resp, err := e.c.Client.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	return resp, respBody, err
+}
+
+// elasticDoc returns the document indexed for the event, and its id. Alerts,
+// which are not about a thing, have no id.
+func elasticDoc(ev Event) ([]byte, string, error) {
+	doc := map[string]interface{}{
+		"kind":     ev.Kind,
+		"received": time.Now().Unix(),
+		"event":    ev,
+	}
+
+	name := ""
+	if p := ev.Post; p != nil {
+		name = p.Name
+		doc["subreddit"] = p.Subreddit
+		doc["author"] = p.Author
+		doc["title"] = p.Title
+		doc["body"] = p.SelfText
+		doc["url"] = p.URL
+		doc["domain"] = p.Domain
+		doc["score"] = p.Score
+		doc["created"] = p.CreatedUTC
+	}
+	if c := ev.Comment; c != nil {
+		name = c.Name
+		doc["subreddit"] = c.Subreddit
+		doc["author"] = c.Author
+		doc["body"] = c.Body
+		doc["link_id"] = c.LinkID
+		doc["parent_id"] = c.ParentID
+		doc["score"] = c.Ups - c.Downs
+		doc["created"] = c.CreatedUTC
+	}
+	if m := ev.Message; m != nil {
+		name = m.Name
+		doc["subreddit"] = m.Subreddit
+		doc["author"] = m.Author
+		doc["title"] = m.Subject
+		doc["body"] = m.Body
+		doc["parent_id"] = m.ParentID
+		doc["created"] = m.CreatedUTC
+	}
+
+	id := ""
+	if name != "" {
+		doc["name"] = name
+		id = ev.Kind + ":" + name
+	}
+
+	blob, err := json.Marshal(doc)
+	return blob, id, err
+}
@@ -0,0 +1,140 @@
+package sink
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/turnage/graw/reddit"
+)
+
+// elasticServer answers index creation and bulk requests, sending the bodies
+// of bulk requests down the channel. If failing, it reports every bulk item
+// failed.
+func elasticServer(
+	t *testing.T,
+	bulks chan<- string,
+	failing bool,
+) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			body, _ := ioutil.ReadAll(r.Body)
+			switch {
+			case r.Method == "PUT" && r.URL.Path == "/events":
+				if !strings.Contains(string(body), `"mappings"`) {
+					t.Errorf("index created without mapping: %s", body)
+				}
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"error": {"type": "resource_already_exists_exception"}}`))
+			case r.Method == "POST" && r.URL.Path == "/_bulk":
+				bulks <- string(body)
+				if failing {
+					w.Write([]byte(`{"errors": true, "items": [
+						{"index": {"status": 400, "error": {"type": "mapper_parsing_exception"}}}
+					]}`))
+				} else {
+					w.Write([]byte(`{"errors": false, "items": []}`))
+				}
+			default:
+				t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+				w.WriteHeader(http.StatusNotFound)
+			}
+		},
+	))
+}
+
+func TestElastic(t *testing.T) {
+	bulks := make(chan string, 10)
+	srv := elasticServer(t, bulks, false)
+	defer srv.Close()
+
+	e, err := NewElastic(ElasticConfig{
+		URL:           srv.URL,
+		Index:         "events",
+		BatchSize:     2,
+		FlushInterval: time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("error creating sink: %v", err)
+	}
+
+	if err := e.Post(&reddit.Post{Name: "t3_a", Title: "hello"}); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case body := <-bulks:
+		t.Fatalf("batch sent before it was full: %s", body)
+	default:
+	}
+
+	if err := e.Comment(&reddit.Comment{Name: "t1_b", Body: "hi"}); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(<-bulks), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("got %d lines in bulk request; wanted 4: %v", len(lines), lines)
+	}
+
+	var meta struct {
+		Index struct {
+			Index string `json:"_index"`
+			ID    string `json:"_id"`
+		} `json:"index"`
+	}
+	if err := json.Unmarshal([]byte(lines[0]), &meta); err != nil {
+		t.Fatal(err)
+	}
+	if meta.Index.Index != "events" || meta.Index.ID != "post:t3_a" {
+		t.Errorf("got action %s; wanted post:t3_a in events", lines[0])
+	}
+
+	var doc map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[3]), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc["kind"] != CommentKind || doc["name"] != "t1_b" || doc["body"] != "hi" {
+		t.Errorf("comment document incorrect: %s", lines[3])
+	}
+
+	// Closing the sink sends events still waiting.
+	if err := e.Message(&reddit.Message{Name: "t4_c"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.Close(); err != nil {
+		t.Fatalf("error closing sink: %v", err)
+	}
+	select {
+	case body := <-bulks:
+		if !strings.Contains(body, `"message:t4_c"`) {
+			t.Errorf("got final batch %s; wanted the message", body)
+		}
+	default:
+		t.Errorf("waiting events were not sent on close")
+	}
+}
+
+func TestElasticBulkFailure(t *testing.T) {
+	bulks := make(chan string, 10)
+	srv := elasticServer(t, bulks, true)
+	defer srv.Close()
+
+	e, err := NewElastic(ElasticConfig{
+		URL:           srv.URL,
+		Index:         "events",
+		BatchSize:     1,
+		FlushInterval: time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("error creating sink: %v", err)
+	}
+	defer e.Close()
+
+	err = e.Post(&reddit.Post{Name: "t3_a"})
+	if err == nil || !strings.Contains(err.Error(), "mapper_parsing_exception") {
+		t.Errorf("got %v; wanted the bulk item's failure", err)
+	}
+}
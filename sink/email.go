@@ -0,0 +1,227 @@
+package sink
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/smtp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/turnage/graw/reddit"
+)
+
+// EmailConfig configures an email sink.
+type EmailConfig struct {
+	// Addr is the address of the SMTP server, e.g. smtp.example.com:587.
+	// The connection is upgraded with STARTTLS if the server offers it.
+	Addr string
+	// Auth, if set, authenticates with the server, e.g. with
+	// smtp.PlainAuth.
+	Auth smtp.Auth
+	// From is the address mail is sent from, and To the addresses it is
+	// sent to, unless a rule says otherwise.
+	From string
+	To   []string
+	// Rules select which events are mailed, by kind (e.g.
+	// sink.HandlerPausedKind). Events of kinds without a rule are
+	// dropped; email is for the few events someone should hear about.
+	Rules map[string]EmailRule
+}
+
+// EmailRule says when events of a kind are mailed.
+type EmailRule struct {
+	// Match, if set, selects the events of the kind which count toward
+	// the rule; others are dropped.
+	Match func(Event) bool
+	// Count and Window make the rule mail only once Count matching
+	// events arrive within Window, e.g. 10 comments mentioning the bot's
+	// product in an hour. By default every matching event is mailed.
+	Count  int
+	Window time.Duration
+	// Quiet is the least time between mails for the rule, so a burst of
+	// events sends one mail rather than a flood. The default is no limit.
+	Quiet time.Duration
+	// To, if set, replaces the config's recipients for the rule.
+	To []string
+}
+
+// emailRuleState is the recent history of a rule.
+type emailRuleState struct {
+	// matches are when the events counting toward the rule arrived.
+	matches []time.Time
+	// last is when the rule last sent mail.
+	last time.Time
+}
+
+// Email is a sink which mails events worth someone's attention. Like any sink,
+// it is a bot handler; hand it to graw.Run or graw.Scan in place of a bot.
+// Mail which cannot be sent stops the run as any handler error would.
+//
+// Some things worth a mail are not events: the bot being banned, or its
+// credentials being revoked, surface as the errors which end its run. Mail
+// those with Failure.
+type Email struct {
+	*Handler
+	c      EmailConfig
+	states map[string]*emailRuleState
+	mu     *sync.Mutex
+	// send sends mail. It is smtp.SendMail but in tests.
+	send func(string, smtp.Auth, string, []string, []byte) error
+}
+
+// NewEmail returns a sink which mails events as its rules say.
+func NewEmail(c EmailConfig) *Email {
+	e := &Email{
+		c:      c,
+		states: make(map[string]*emailRuleState),
+		mu:     &sync.Mutex{},
+		send:   smtp.SendMail,
+	}
+	e.Handler = NewHandler(e.receive)
+	return e
+}
+
+// Failure mails the error which ended the bot's run, explaining the errors
+// Reddit gives banned bots and bots with revoked credentials:
+//
+//	if err := wait(); err != nil {
+//		mail.Failure(err)
+//	}
+func (e *Email) Failure(err error) error {
+	var subject string
+	switch err {
+	case nil:
+		return nil
+	case reddit.UnauthorizedErr:
+		subject = "Reddit rejected the bot's credentials"
+	case reddit.PermissionDeniedErr:
+		subject = "The bot was denied access; it may be banned"
+	default:
+		subject = "The bot stopped"
+	}
+	return e.Alert(subject, err.Error())
+}
+
+// Alert mails a message to the config's recipients.
+func (e *Email) Alert(subject, body string) error {
+	return e.mail(e.c.To, subject, body)
+}
+
+// receive mails the event if its kind's rule says to.
+func (e *Email) receive(ev Event) error {
+	rule, ok := e.c.Rules[ev.Kind]
+	if !ok || (rule.Match != nil && !rule.Match(ev)) {
+		return nil
+	}
+
+	now := time.Now()
+	count, due := e.count(ev.Kind, rule, now)
+	if !due {
+		return nil
+	}
+
+	to := rule.To
+	if len(to) == 0 {
+		to = e.c.To
+	}
+
+	body, err := json.MarshalIndent(ev, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	text := string(body)
+	if count > 1 {
+		text = fmt.Sprintf(
+			"%d %s events arrived in the last %s. The latest:\n\n%s",
+			count, ev.Kind, rule.Window, body,
+		)
+	}
+	return e.mail(to, summary(ev), text)
+}
+
+// count records a matching event for the rule of the kind, and returns how
+// many have arrived within the rule's window and whether a mail is due.
+func (e *Email) count(
+	kind string,
+	rule EmailRule,
+	now time.Time,
+) (int, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	s, ok := e.states[kind]
+	if !ok {
+		s = &emailRuleState{}
+		e.states[kind] = s
+	}
+
+	s.matches = append(s.matches, now)
+	if rule.Window > 0 {
+		recent := s.matches[:0]
+		for _, t := range s.matches {
+			if now.Sub(t) <= rule.Window {
+				recent = append(recent, t)
+			}
+		}
+		s.matches = recent
+	}
+
+	count := len(s.matches)
+	if count < rule.Count {
+		return count, false
+	}
+	if rule.Quiet > 0 && !s.last.IsZero() && now.Sub(s.last) < rule.Quiet {
+		return count, false
+	}
+
+	s.matches = nil
+	s.last = now
+	return count, true
+}
+
+// mail sends a plain text message.
+func (e *Email) mail(to []string, subject, body string) error {
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", e.c.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(
+		&msg,
+		"Subject: %s\r\n",
+		mime.QEncoding.Encode("utf-8", "[graw] "+subject),
+	)
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	msg.WriteString(strings.Replace(body, "\n", "\r\n", -1))
+	msg.WriteString("\r\n")
+
+	return e.send(e.c.Addr, e.c.Auth, e.c.From, to, msg.Bytes())
+}
+
+// summary returns a subject line describing the event.
+func summary(ev Event) string {
+	switch {
+	case ev.Handler != "":
+		return ev.Kind + ": " + ev.Handler
+	case ev.Feed != "":
+		return ev.Kind + ": " + ev.Feed
+	case ev.Post != nil:
+		return fmt.Sprintf(
+			"%s: %q in /r/%s", ev.Kind, ev.Post.Title, ev.Post.Subreddit,
+		)
+	case ev.Comment != nil:
+		return fmt.Sprintf(
+			"%s: /u/%s in /r/%s",
+			ev.Kind, ev.Comment.Author, ev.Comment.Subreddit,
+		)
+	case ev.Message != nil:
+		return fmt.Sprintf(
+			"%s: %q from /u/%s",
+			ev.Kind, ev.Message.Subject, ev.Message.Author,
+		)
+	}
+	return ev.Kind
+}
@@ -0,0 +1,116 @@
+package sink
+
+import (
+	"fmt"
+	"net/smtp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/turnage/graw/reddit"
+)
+
+// mail is a message an email sink sent.
+type mail struct {
+	to  []string
+	msg string
+}
+
+// recordMail replaces the sink's sender with one which records the mail.
+func recordMail(e *Email) *[]mail {
+	var sent []mail
+	e.send = func(
+		addr string,
+		a smtp.Auth,
+		from string,
+		to []string,
+		msg []byte,
+	) error {
+		sent = append(sent, mail{to: to, msg: string(msg)})
+		return nil
+	}
+	return &sent
+}
+
+func TestEmailRules(t *testing.T) {
+	e := NewEmail(EmailConfig{
+		From: "bot@example.com",
+		To:   []string{"ops@example.com"},
+		Rules: map[string]EmailRule{
+			HandlerPausedKind: {},
+			CommentKind: {
+				Match: func(ev Event) bool {
+					return strings.Contains(ev.Comment.Body, "graw")
+				},
+				Count:  2,
+				Window: time.Hour,
+				To:     []string{"dev@example.com"},
+			},
+		},
+	})
+	sent := recordMail(e)
+
+	// Posts have no rule, so they are not mailed.
+	if err := e.Post(&reddit.Post{Title: "hi"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.HandlerPaused("comment", fmt.Errorf("boom")); err != nil {
+		t.Fatal(err)
+	}
+	for _, body := range []string{"graw is neat", "unrelated", "graw again"} {
+		if err := e.Comment(&reddit.Comment{Body: body}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if len(*sent) != 2 {
+		t.Fatalf("got %d mails; wanted 2: %v", len(*sent), *sent)
+	}
+
+	paused := (*sent)[0]
+	if len(paused.to) != 1 || paused.to[0] != "ops@example.com" ||
+		!strings.Contains(paused.msg, "Subject: [graw] handler_paused: comment\r\n") {
+		t.Errorf("pause alert incorrect: %+v", paused)
+	}
+
+	comments := (*sent)[1]
+	if len(comments.to) != 1 || comments.to[0] != "dev@example.com" ||
+		!strings.Contains(comments.msg, "2 comment events arrived") ||
+		!strings.Contains(comments.msg, "graw again") {
+		t.Errorf("comment alert incorrect: %+v", comments)
+	}
+}
+
+func TestEmailQuiet(t *testing.T) {
+	e := NewEmail(EmailConfig{
+		Rules: map[string]EmailRule{
+			PostFilteredKind: {Quiet: time.Hour},
+		},
+	})
+	sent := recordMail(e)
+
+	for i := 0; i < 3; i++ {
+		if err := e.PostFiltered(&reddit.Post{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(*sent) != 1 {
+		t.Errorf("got %d mails; wanted 1 within the quiet period", len(*sent))
+	}
+}
+
+func TestEmailFailure(t *testing.T) {
+	e := NewEmail(EmailConfig{To: []string{"ops@example.com"}})
+	sent := recordMail(e)
+
+	if err := e.Failure(nil); err != nil || len(*sent) != 0 {
+		t.Errorf("a run which ended cleanly should not be mailed")
+	}
+	if err := e.Failure(reddit.UnauthorizedErr); err != nil {
+		t.Fatal(err)
+	}
+	if len(*sent) != 1 ||
+		!strings.Contains((*sent)[0].msg, "rejected the bot's credentials") {
+		t.Errorf("got %v; wanted a mail about credentials", *sent)
+	}
+}
@@ -0,0 +1,96 @@
+package sink
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultMatrixTimeout = 10 * time.Second
+
+// MatrixConfig configures a Matrix room.
+type MatrixConfig struct {
+	// Homeserver is the address of the bridge account's homeserver, e.g.
+	// https://matrix.org.
+	Homeserver string
+	// Token is the access token of the account messages are sent as.
+	Token string
+	// Room is the ID of the room messages are sent to, e.g.
+	// !abcdefg:matrix.org. The account must have joined it.
+	Room string
+	// Client makes the requests. The default is a client with a ten second
+	// timeout.
+	Client *http.Client
+}
+
+// Matrix is a Room in Matrix, which messages are sent to with the client-server
+// API.
+type Matrix struct {
+	c MatrixConfig
+	// prefix and sent make the transaction ID of each message, which
+	// keeps the homeserver from posting a retried message twice.
+	prefix string
+	sent   int64
+	mu     *sync.Mutex
+}
+
+// NewMatrix returns the Matrix room.
+func NewMatrix(c MatrixConfig) *Matrix {
+	if c.Client == nil {
+		c.Client = &http.Client{Timeout: defaultMatrixTimeout}
+	}
+	c.Homeserver = strings.TrimSuffix(c.Homeserver, "/")
+
+	return &Matrix{
+		c:      c,
+		prefix: "graw." + strconv.FormatInt(time.Now().UnixNano(), 36),
+		mu:     &sync.Mutex{},
+	}
+}
+
+// Say sends the message to the room as text, with an HTML rendering.
+func (m *Matrix) Say(text, html string) error {
+	m.mu.Lock()
+	m.sent++
+	txn := m.prefix + "." + strconv.FormatInt(m.sent, 10)
+	m.mu.Unlock()
+
+	body, err := json.Marshal(map[string]string{
+		"msgtype":        "m.text",
+		"body":           text,
+		"format":         "org.matrix.custom.html",
+		"formatted_body": html,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(
+		"PUT",
+		m.c.Homeserver+"/_matrix/client/v3/rooms/"+
+			url.PathEscape(m.c.Room)+"/send/m.room.message/"+txn,
+		bytes.NewReader(body),
+	)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+m.c.Token)
+
+	resp, err := m.c.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("matrix responded %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package sink
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// natsTimeout bounds dialing and writes to a NATS server.
+const natsTimeout = 5 * time.Second
+
+// NATS is a Publisher for a NATS server, speaking the core NATS protocol. It
+// publishes without acknowledgement, which is NATS' at-most-once delivery.
+type NATS struct {
+	conn net.Conn
+	// err is the last error the server reported, returned from the next
+	// Publish.
+	err error
+	mu  *sync.Mutex
+}
+
+// DialNATS connects to a NATS server at the address (host:port).
+func DialNATS(addr string) (*NATS, error) {
+	conn, err := net.DialTimeout("tcp", addr, natsTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	rd := bufio.NewReader(conn)
+	conn.SetReadDeadline(time.Now().Add(natsTimeout))
+	info, err := rd.ReadString('\n')
+	if err != nil {
+		conn.Close()
+		return nil, err
+	} else if !strings.HasPrefix(info, "INFO") {
+		conn.Close()
+		return nil, fmt.Errorf("unexpected greeting from NATS: %q", info)
+	}
+	conn.SetReadDeadline(time.Time{})
+
+	n := &NATS{conn: conn, mu: &sync.Mutex{}}
+	if err := n.write(
+		"CONNECT {\"verbose\":false,\"pedantic\":false," +
+			"\"name\":\"graw\"}\r\n",
+	); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	go n.read(rd)
+	return n, nil
+}
+
+// Publish publishes the payload to the subject.
+func (n *NATS) Publish(subject string, payload []byte) error {
+	n.mu.Lock()
+	err := n.err
+	n.err = nil
+	n.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	msg := "PUB " + subject + " " + strconv.Itoa(len(payload)) + "\r\n" +
+		string(payload) + "\r\n"
+	return n.write(msg)
+}
+
+// Close closes the connection to the server.
+func (n *NATS) Close() error {
+	return n.conn.Close()
+}
+
+func (n *NATS) write(msg string) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.conn.SetWriteDeadline(time.Now().Add(natsTimeout))
+	_, err := n.conn.Write([]byte(msg))
+	return err
+}
+
+// read answers the server's keepalive pings and records errors it reports.
+func (n *NATS) read(rd *bufio.Reader) {
+	for {
+		line, err := rd.ReadString('\n')
+		if err != nil {
+			n.mu.Lock()
+			n.err = err
+			n.mu.Unlock()
+			return
+		}
+
+		switch {
+		case strings.HasPrefix(line, "PING"):
+			n.write("PONG\r\n")
+		case strings.HasPrefix(line, "-ERR"):
+			n.mu.Lock()
+			n.err = fmt.Errorf("NATS error: %s", strings.TrimSpace(line[4:]))
+			n.mu.Unlock()
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package sink
+
+import (
+	"bufio"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNATS(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer ln.Close()
+
+	received := make(chan string)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		conn.Write([]byte("INFO {\"server_id\":\"test\"}\r\n"))
+		rd := bufio.NewReader(conn)
+		for i := 0; i < 3; i++ {
+			line, err := rd.ReadString('\n')
+			if err != nil {
+				return
+			}
+			received <- line
+		}
+	}()
+
+	n, err := DialNATS(ln.Addr().String())
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+	defer n.Close()
+
+	if err := n.Publish("graw.post", []byte("hello")); err != nil {
+		t.Fatalf("failed to publish: %v", err)
+	}
+
+	for _, prefix := range []string{"CONNECT ", "PUB graw.post 5", "hello"} {
+		select {
+		case line := <-received:
+			if !strings.HasPrefix(line, prefix) {
+				t.Errorf("server got %q; wanted prefix %q", line, prefix)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("server did not receive %q", prefix)
+		}
+	}
+}
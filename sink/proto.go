@@ -0,0 +1,251 @@
+package sink
+
+import (
+	"fmt"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/turnage/graw/reddit"
+	"github.com/turnage/redditproto"
+)
+
+// The redditproto messages events are encoded as.
+const (
+	linkProto = iota + 1
+	commentProto
+	messageProto
+)
+
+// protoKinds maps the kinds of events which can be encoded as protocol buffers
+// to the redditproto message they are encoded as: the post, comment, or
+// message the event is about. OP replies are about the reply, and comment
+// context events about the comment. Digests and batches, which are about many,
+// are not encoded.
+var protoKinds = map[string]int{
+	PostKind:            linkProto,
+	UserPostKind:        linkProto,
+	PostAgeKind:         linkProto,
+	RankKind:            linkProto,
+	PostGildedKind:      linkProto,
+	FlairChangedKind:    linkProto,
+	PostPageKind:        linkProto,
+	PostDeletedKind:     linkProto,
+	PostFilteredKind:    linkProto,
+	CommentKind:         commentProto,
+	UserCommentKind:     commentProto,
+	ThreadKind:          commentProto,
+	CommentGildedKind:   commentProto,
+	OPReplyKind:         commentProto,
+	CommentDeletedKind:  commentProto,
+	CommentFilteredKind: commentProto,
+	CommentContextKind:  commentProto,
+	PostReplyKind:       messageProto,
+	CommentReplyKind:    messageProto,
+	MentionKind:         messageProto,
+	MessageKind:         messageProto,
+}
+
+var protoMalformedErr = fmt.Errorf("malformed protocol buffer event")
+
+// encodeProto encodes the post, comment, or message the event is about as a
+// redditproto Link, Comment, or Message. Events about none of them, such as
+// alerts and batches, encode to nil.
+func encodeProto(ev Event) ([]byte, error) {
+	switch protoKinds[ev.Kind] {
+	case linkProto:
+		if ev.Post != nil {
+			return proto.Marshal(linkOf(ev.Post))
+		}
+	case commentProto:
+		if ev.Comment != nil {
+			return proto.Marshal(commentOf(ev.Comment))
+		}
+	case messageProto:
+		if ev.Message != nil {
+			return proto.Marshal(messageOf(ev.Message))
+		}
+	}
+	return nil, nil
+}
+
+// decodeProto decodes an event of the kind encoded by encodeProto. Only the
+// fields redditproto has survive encoding.
+func decodeProto(kind string, buf []byte) (Event, error) {
+	ev := Event{Kind: kind}
+	switch protoKinds[kind] {
+	case linkProto:
+		l := &redditproto.Link{}
+		if err := proto.Unmarshal(buf, l); err != nil {
+			return Event{}, err
+		}
+		ev.Post = postOf(l)
+	case commentProto:
+		c := &redditproto.Comment{}
+		if err := proto.Unmarshal(buf, c); err != nil {
+			return Event{}, err
+		}
+		ev.Comment = commentFrom(c)
+	case messageProto:
+		m := &redditproto.Message{}
+		if err := proto.Unmarshal(buf, m); err != nil {
+			return Event{}, err
+		}
+		ev.Message = messageFrom(m)
+	default:
+		return Event{}, protoMalformedErr
+	}
+	return ev, nil
+}
+
+func linkOf(p *reddit.Post) *redditproto.Link {
+	return &redditproto.Link{
+		Id:                  proto.String(p.ID),
+		Name:                proto.String(p.Name),
+		Permalink:           proto.String(p.Permalink),
+		CreatedUtc:          proto.Uint64(p.CreatedUTC),
+		Deleted:             proto.Bool(p.Deleted),
+		Ups:                 proto.Int32(p.Ups),
+		Downs:               proto.Int32(p.Downs),
+		Likes:               proto.Bool(p.Likes),
+		Author:              proto.String(p.Author),
+		AuthorFlairCssClass: proto.String(p.AuthorFlairCSSClass),
+		AuthorFlairText:     proto.String(p.AuthorFlairText),
+		Title:               proto.String(p.Title),
+		Score:               proto.Int32(p.Score),
+		Url:                 proto.String(p.URL),
+		Domain:              proto.String(p.Domain),
+		Over_18:             proto.Bool(p.NSFW),
+		Subreddit:           proto.String(p.Subreddit),
+		SubredditId:         proto.String(p.SubredditID),
+		IsSelf:              proto.Bool(p.IsSelf),
+		Selftext:            proto.String(p.SelfText),
+		SelftextHtml:        proto.String(p.SelfTextHTML),
+		Hidden:              proto.Bool(p.Hidden),
+		LinkFlairCssClass:   proto.String(p.LinkFlairCSSClass),
+		LinkFlairText:       proto.String(p.LinkFlairText),
+		NumComments:         proto.Int32(p.NumComments),
+	}
+}
+
+func postOf(l *redditproto.Link) *reddit.Post {
+	return &reddit.Post{
+		ID:                  l.GetId(),
+		Name:                l.GetName(),
+		Permalink:           l.GetPermalink(),
+		CreatedUTC:          l.GetCreatedUtc(),
+		Deleted:             l.GetDeleted(),
+		Ups:                 l.GetUps(),
+		Downs:               l.GetDowns(),
+		Likes:               l.GetLikes(),
+		Author:              l.GetAuthor(),
+		AuthorFlairCSSClass: l.GetAuthorFlairCssClass(),
+		AuthorFlairText:     l.GetAuthorFlairText(),
+		Title:               l.GetTitle(),
+		Score:               l.GetScore(),
+		URL:                 l.GetUrl(),
+		Domain:              l.GetDomain(),
+		NSFW:                l.GetOver_18(),
+		Subreddit:           l.GetSubreddit(),
+		SubredditID:         l.GetSubredditId(),
+		IsSelf:              l.GetIsSelf(),
+		SelfText:            l.GetSelftext(),
+		SelfTextHTML:        l.GetSelftextHtml(),
+		Hidden:              l.GetHidden(),
+		LinkFlairCSSClass:   l.GetLinkFlairCssClass(),
+		LinkFlairText:       l.GetLinkFlairText(),
+		NumComments:         l.GetNumComments(),
+	}
+}
+
+func commentOf(c *reddit.Comment) *redditproto.Comment {
+	return &redditproto.Comment{
+		Id:                  proto.String(c.ID),
+		Name:                proto.String(c.Name),
+		Permalink:           proto.String(c.Permalink),
+		CreatedUtc:          proto.Uint64(c.CreatedUTC),
+		Deleted:             proto.Bool(c.Deleted),
+		Ups:                 proto.Int32(c.Ups),
+		Downs:               proto.Int32(c.Downs),
+		Likes:               proto.Bool(c.Likes),
+		Author:              proto.String(c.Author),
+		AuthorFlairCssClass: proto.String(c.AuthorFlairCSSClass),
+		AuthorFlairText:     proto.String(c.AuthorFlairText),
+		LinkAuthor:          proto.String(c.LinkAuthor),
+		LinkUrl:             proto.String(c.LinkURL),
+		LinkTitle:           proto.String(c.LinkTitle),
+		LinkId:              proto.String(c.LinkID),
+		Subreddit:           proto.String(c.Subreddit),
+		SubredditId:         proto.String(c.SubredditID),
+		ParentId:            proto.String(c.ParentID),
+		Body:                proto.String(c.Body),
+		BodyHtml:            proto.String(c.BodyHTML),
+		Gilded:              proto.Int32(c.Gilded),
+		Distinguished:       proto.String(c.Distinguished),
+	}
+}
+
+func commentFrom(c *redditproto.Comment) *reddit.Comment {
+	return &reddit.Comment{
+		ID:                  c.GetId(),
+		Name:                c.GetName(),
+		Permalink:           c.GetPermalink(),
+		CreatedUTC:          c.GetCreatedUtc(),
+		Deleted:             c.GetDeleted(),
+		Ups:                 c.GetUps(),
+		Downs:               c.GetDowns(),
+		Likes:               c.GetLikes(),
+		Author:              c.GetAuthor(),
+		AuthorFlairCSSClass: c.GetAuthorFlairCssClass(),
+		AuthorFlairText:     c.GetAuthorFlairText(),
+		LinkAuthor:          c.GetLinkAuthor(),
+		LinkURL:             c.GetLinkUrl(),
+		LinkTitle:           c.GetLinkTitle(),
+		LinkID:              c.GetLinkId(),
+		Subreddit:           c.GetSubreddit(),
+		SubredditID:         c.GetSubredditId(),
+		ParentID:            c.GetParentId(),
+		Body:                c.GetBody(),
+		BodyHTML:            c.GetBodyHtml(),
+		Gilded:              c.GetGilded(),
+		Distinguished:       c.GetDistinguished(),
+	}
+}
+
+func messageOf(m *reddit.Message) *redditproto.Message {
+	return &redditproto.Message{
+		Id:               proto.String(m.ID),
+		Name:             proto.String(m.Name),
+		CreatedUtc:       proto.Uint64(m.CreatedUTC),
+		Author:           proto.String(m.Author),
+		Subject:          proto.String(m.Subject),
+		Body:             proto.String(m.Body),
+		BodyHtml:         proto.String(m.BodyHTML),
+		Context:          proto.String(m.Context),
+		FirstMessageName: proto.String(m.FirstMessageName),
+		Likes:            proto.Bool(m.Likes),
+		LinkTitle:        proto.String(m.LinkTitle),
+		New:              proto.Bool(m.New),
+		ParentId:         proto.String(m.ParentID),
+		Subreddit:        proto.String(m.Subreddit),
+		WasComment:       proto.Bool(m.WasComment),
+	}
+}
+
+func messageFrom(m *redditproto.Message) *reddit.Message {
+	return &reddit.Message{
+		ID:               m.GetId(),
+		Name:             m.GetName(),
+		CreatedUTC:       m.GetCreatedUtc(),
+		Author:           m.GetAuthor(),
+		Subject:          m.GetSubject(),
+		Body:             m.GetBody(),
+		BodyHTML:         m.GetBodyHtml(),
+		Context:          m.GetContext(),
+		FirstMessageName: m.GetFirstMessageName(),
+		Likes:            m.GetLikes(),
+		LinkTitle:        m.GetLinkTitle(),
+		New:              m.GetNew(),
+		ParentID:         m.GetParentId(),
+		Subreddit:        m.GetSubreddit(),
+		WasComment:       m.GetWasComment(),
+	}
+}
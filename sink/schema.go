@@ -0,0 +1,83 @@
+package sink
+
+import (
+	"encoding/json"
+)
+
+// SchemaVersion is the version of the JSON schema events are encoded in. It is
+// raised only by changes which could break consumers, such as a field being
+// renamed, removed, or changing meaning; fields are added without raising it.
+//
+// An event encodes as an object with
+//
+//	schema_version, the version of its schema.
+//	kind, one of the Kind constants.
+//	source, where the event came from: producer (always "graw"), and the
+//	  subreddit, fullname, and permalink of the thing the event is about,
+//	  when it has them.
+//	post, comment, and message, the things the event is about, with the
+//	  field names of Reddit's API (e.g. created_utc, over_18); see the json
+//	  tags of graw/reddit's types.
+//	posts and comments, the batches in digest and batch events, and
+//	  ancestors, the comments above the comment in comment context events.
+//	page, what the page a post links to says about itself; see the json
+//	  tags of graw/unfurl's Page.
+//	age, listing, rank, previous_rank, old_flair, removal, handler, feed,
+//	  and error, as the kind of event has them.
+//
+// Fields an event does not have are left out. Consumers should ignore fields
+// they do not know, as later versions of graw may add them.
+const SchemaVersion = 1
+
+// producer names graw as the source of events.
+const producer = "graw"
+
+// Source describes where an event came from, in its JSON encoding.
+type Source struct {
+	Producer  string `json:"producer"`
+	Subreddit string `json:"subreddit,omitempty"`
+	// Fullname and Permalink identify the thing the event is about,
+	// e.g. t3_5du939 and https://www.reddit.com/r/golang/comments/5du939.
+	Fullname  string `json:"fullname,omitempty"`
+	Permalink string `json:"permalink,omitempty"`
+}
+
+// Source returns where the event came from.
+func (e Event) Source() Source {
+	s := Source{Producer: producer, Subreddit: e.Subreddit()}
+	switch {
+	case e.Comment != nil:
+		s.Fullname = e.Comment.Name
+		s.Permalink = permalinkURL(e.Comment.Permalink)
+	case e.Post != nil:
+		s.Fullname = e.Post.Name
+		s.Permalink = permalinkURL(e.Post.Permalink)
+	case e.Message != nil:
+		s.Fullname = e.Message.Name
+		s.Permalink = permalinkURL(e.Message.Context)
+	}
+	return s
+}
+
+// MarshalJSON encodes the event in the schema of SchemaVersion.
+func (e Event) MarshalJSON() ([]byte, error) {
+	// event has Event's fields but not its methods, so encoding it does
+	// not recurse.
+	type event Event
+	return json.Marshal(
+		struct {
+			SchemaVersion int `json:"schema_version"`
+			event
+			Source Source `json:"source"`
+		}{SchemaVersion, event(e), e.Source()},
+	)
+}
+
+// permalinkURL returns the address of a permalink (e.g.
+// /r/golang/comments/5du939), or "" if there is none.
+func permalinkURL(permalink string) string {
+	if permalink == "" {
+		return ""
+	}
+	return redditURL + permalink
+}
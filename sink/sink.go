@@ -0,0 +1,313 @@
+// Package sink forwards graw's event streams to systems outside the bot.
+//
+// Every sink is a bot handler. Hand one to graw.Run or graw.Scan in place of a
+// bot, and every event subscribed to in the graw.Config is forwarded:
+//
+//	nats, err := sink.DialNATS("localhost:4222")
+//	...
+//	bus := sink.NewBus(nats, sink.BusConfig{Encoding: sink.JSON})
+//	stop, wait, err := graw.Scan(bus, script, cfg)
+package sink
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/turnage/graw/lang"
+	"github.com/turnage/graw/reddit"
+	"github.com/turnage/graw/unfurl"
+)
+
+// Kinds of events, named after the handler that receives them in
+// graw/botfaces.
+const (
+	PostKind          = "post"
+	CommentKind       = "comment"
+	UserPostKind      = "user_post"
+	UserCommentKind   = "user_comment"
+	PostReplyKind     = "post_reply"
+	CommentReplyKind  = "comment_reply"
+	MentionKind       = "mention"
+	MessageKind       = "message"
+	ThreadKind        = "thread_comment"
+	PostAgeKind       = "post_age"
+	RankKind          = "rank"
+	PostGildedKind    = "post_gilded"
+	CommentGildedKind = "comment_gilded"
+	FlairChangedKind  = "flair_changed"
+	OPReplyKind       = "op_reply"
+	PostPageKind      = "post_page"
+	// Posts and comments forwarded together, with Config.Digests,
+	// Config.Batching, or Config.CommentContext; see
+	// botfaces.DigestHandler, botfaces.PostBatchHandler,
+	// botfaces.CommentBatchHandler, and botfaces.CommentContextHandler.
+	DigestKind         = "digest"
+	PostBatchKind      = "post_batch"
+	CommentBatchKind   = "comment_batch"
+	CommentContextKind = "comment_context"
+	// Posts and comments taken down in watched threads; see
+	// botfaces.DeletionHandler.
+	PostDeletedKind    = "post_deleted"
+	CommentDeletedKind = "comment_deleted"
+	// The bot's own submissions, when they are filtered; see
+	// botfaces.SubmissionFilteredHandler.
+	PostFilteredKind    = "post_filtered"
+	CommentFilteredKind = "comment_filtered"
+	// Alerts about the run itself rather than Reddit; see
+	// botfaces.AlertHandler.
+	HandlerPausedKind  = "handler_paused"
+	HandlerResumedKind = "handler_resumed"
+	// Alerts about the run's feeds; see botfaces.SourceAlertHandler.
+	SourceDegradedKind  = "source_degraded"
+	SourceSuspendedKind = "source_suspended"
+	SourceRecoveredKind = "source_recovered"
+)
+
+// Event is the envelope sinks serialize. Post, Comment, or Message is set,
+// depending on the kind; alerts set Handler or Feed, and Error, instead. See
+// SchemaVersion for its JSON encoding.
+type Event struct {
+	Kind    string          `json:"kind"`
+	Post    *reddit.Post    `json:"post,omitempty"`
+	Comment *reddit.Comment `json:"comment,omitempty"`
+	Message *reddit.Message `json:"message,omitempty"`
+	// Age is the age in seconds of the post in post age events.
+	Age int64 `json:"age,omitempty"`
+	// Listing, Rank, and PreviousRank describe the change in rank events;
+	// see botfaces.RankHandler.
+	Listing      string `json:"listing,omitempty"`
+	Rank         int    `json:"rank,omitempty"`
+	PreviousRank int    `json:"previous_rank,omitempty"`
+	// Posts and Comments are the batch in digest and batch events.
+	Posts    []*reddit.Post    `json:"posts,omitempty"`
+	Comments []*reddit.Comment `json:"comments,omitempty"`
+	// Ancestors are the comment's nearest ancestors, parent first, in
+	// comment context events; Post is the post they are in, if it could
+	// be fetched.
+	Ancestors []*reddit.Comment `json:"ancestors,omitempty"`
+	// OldFlair is the post's link flair text before a flair change.
+	OldFlair string `json:"old_flair,omitempty"`
+	// Removal is how the post or comment was taken down, in deletion
+	// events.
+	Removal reddit.Removal `json:"removal,omitempty"`
+	// Page is what the page the post links to says about itself, in post
+	// page events.
+	Page *unfurl.Page `json:"page,omitempty"`
+	// Handler names the handler an alert is about, and Error is the
+	// failure which paused it.
+	Handler string `json:"handler,omitempty"`
+	Error   string `json:"error,omitempty"`
+	// Feed names the feed a source alert is about, and Error is the
+	// failure which degraded or suspended it.
+	Feed string `json:"feed,omitempty"`
+}
+
+// Subreddit returns the subreddit the event happened in, if it has one.
+func (e Event) Subreddit() string {
+	switch {
+	case e.Post != nil:
+		return e.Post.Subreddit
+	case e.Comment != nil:
+		return e.Comment.Subreddit
+	case e.Message != nil:
+		return e.Message.Subreddit
+	}
+	return ""
+}
+
+// Language returns the ISO 639-1 code of the language of the text the event is
+// about (e.g. "en"), as graw/lang guesses it, or "" if it cannot tell.
+func (e Event) Language() string {
+	switch {
+	case e.Comment != nil:
+		return lang.Detect(e.Comment.Body)
+	case e.Message != nil:
+		return lang.Detect(e.Message.Subject + "\n" + e.Message.Body)
+	case e.Post != nil:
+		return lang.Detect(e.Post.Title + "\n" + e.Post.SelfText)
+	}
+	return ""
+}
+
+// Encoding is a serialization format for events.
+type Encoding int
+
+const (
+	// JSON encodes events as JSON objects.
+	JSON Encoding = iota
+	// Proto encodes the post, comment, or message an event is about as a
+	// redditproto Link, Comment, or Message. Events about none of them,
+	// such as alerts and batches, encode to nil.
+	Proto
+)
+
+// Encode serializes the event.
+func (e Encoding) Encode(ev Event) ([]byte, error) {
+	switch e {
+	case JSON:
+		return json.Marshal(ev)
+	case Proto:
+		return encodeProto(ev)
+	}
+	return nil, fmt.Errorf("unknown encoding %d", e)
+}
+
+// Handler is a bot which implements every handler in graw/botfaces, and passes
+// every event it receives to a function.
+type Handler struct {
+	f func(Event) error
+}
+
+// NewHandler returns a Handler which passes every event to f. If f returns an
+// error, the graw run stops, as it would for any bot.
+func NewHandler(f func(Event) error) *Handler {
+	return &Handler{f: f}
+}
+
+func (h *Handler) Post(p *reddit.Post) error {
+	return h.f(Event{Kind: PostKind, Post: p})
+}
+
+func (h *Handler) Comment(c *reddit.Comment) error {
+	return h.f(Event{Kind: CommentKind, Comment: c})
+}
+
+func (h *Handler) UserPost(p *reddit.Post) error {
+	return h.f(Event{Kind: UserPostKind, Post: p})
+}
+
+func (h *Handler) UserComment(c *reddit.Comment) error {
+	return h.f(Event{Kind: UserCommentKind, Comment: c})
+}
+
+func (h *Handler) PostReply(m *reddit.Message) error {
+	return h.f(Event{Kind: PostReplyKind, Message: m})
+}
+
+func (h *Handler) CommentReply(m *reddit.Message) error {
+	return h.f(Event{Kind: CommentReplyKind, Message: m})
+}
+
+func (h *Handler) Mention(m *reddit.Message) error {
+	return h.f(Event{Kind: MentionKind, Message: m})
+}
+
+func (h *Handler) Message(m *reddit.Message) error {
+	return h.f(Event{Kind: MessageKind, Message: m})
+}
+
+func (h *Handler) CommentContext(
+	c *reddit.Comment,
+	ancestors []*reddit.Comment,
+	p *reddit.Post,
+) error {
+	return h.f(
+		Event{
+			Kind:      CommentContextKind,
+			Comment:   c,
+			Ancestors: ancestors,
+			Post:      p,
+		},
+	)
+}
+
+func (h *Handler) PostBatch(posts []*reddit.Post) error {
+	return h.f(Event{Kind: PostBatchKind, Posts: posts})
+}
+
+func (h *Handler) CommentBatch(comments []*reddit.Comment) error {
+	return h.f(Event{Kind: CommentBatchKind, Comments: comments})
+}
+
+func (h *Handler) ThreadComment(c *reddit.Comment) error {
+	return h.f(Event{Kind: ThreadKind, Comment: c})
+}
+
+func (h *Handler) Rank(
+	listing string,
+	p *reddit.Post,
+	rank, previous int,
+) error {
+	return h.f(
+		Event{
+			Kind:         RankKind,
+			Post:         p,
+			Listing:      listing,
+			Rank:         rank,
+			PreviousRank: previous,
+		},
+	)
+}
+
+func (h *Handler) Digest(listing string, posts []*reddit.Post) error {
+	return h.f(Event{Kind: DigestKind, Listing: listing, Posts: posts})
+}
+
+func (h *Handler) PostGilded(p *reddit.Post) error {
+	return h.f(Event{Kind: PostGildedKind, Post: p})
+}
+
+func (h *Handler) CommentGilded(c *reddit.Comment) error {
+	return h.f(Event{Kind: CommentGildedKind, Comment: c})
+}
+
+func (h *Handler) FlairChanged(p *reddit.Post, old string) error {
+	return h.f(Event{Kind: FlairChangedKind, Post: p, OldFlair: old})
+}
+
+func (h *Handler) OPReply(p *reddit.Post, c *reddit.Comment) error {
+	return h.f(Event{Kind: OPReplyKind, Post: p, Comment: c})
+}
+
+func (h *Handler) PostDeleted(p *reddit.Post, r reddit.Removal) error {
+	return h.f(Event{Kind: PostDeletedKind, Post: p, Removal: r})
+}
+
+func (h *Handler) CommentDeleted(c *reddit.Comment, r reddit.Removal) error {
+	return h.f(Event{Kind: CommentDeletedKind, Comment: c, Removal: r})
+}
+
+func (h *Handler) PostPage(p *reddit.Post, page *unfurl.Page) error {
+	return h.f(Event{Kind: PostPageKind, Post: p, Page: page})
+}
+
+func (h *Handler) PostFiltered(p *reddit.Post) error {
+	return h.f(Event{Kind: PostFilteredKind, Post: p})
+}
+
+func (h *Handler) CommentFiltered(c *reddit.Comment) error {
+	return h.f(Event{Kind: CommentFilteredKind, Comment: c})
+}
+
+func (h *Handler) HandlerPaused(handler string, err error) error {
+	return h.f(
+		Event{Kind: HandlerPausedKind, Handler: handler, Error: err.Error()},
+	)
+}
+
+func (h *Handler) HandlerResumed(handler string) error {
+	return h.f(Event{Kind: HandlerResumedKind, Handler: handler})
+}
+
+func (h *Handler) SourceDegraded(source string, err error) error {
+	return h.f(
+		Event{Kind: SourceDegradedKind, Feed: source, Error: err.Error()},
+	)
+}
+
+func (h *Handler) SourceSuspended(source string, err error) error {
+	return h.f(
+		Event{Kind: SourceSuspendedKind, Feed: source, Error: err.Error()},
+	)
+}
+
+func (h *Handler) SourceRecovered(source string) error {
+	return h.f(Event{Kind: SourceRecoveredKind, Feed: source})
+}
+
+func (h *Handler) PostAge(p *reddit.Post, age time.Duration) error {
+	return h.f(
+		Event{Kind: PostAgeKind, Post: p, Age: int64(age / time.Second)},
+	)
+}
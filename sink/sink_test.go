@@ -0,0 +1,163 @@
+package sink
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/turnage/graw/reddit"
+	"github.com/turnage/redditproto"
+)
+
+func TestHandlerKinds(t *testing.T) {
+	var events []Event
+	h := NewHandler(func(ev Event) error {
+		events = append(events, ev)
+		return nil
+	})
+
+	h.Post(&reddit.Post{})
+	h.UserComment(&reddit.Comment{})
+	h.Mention(&reddit.Message{})
+	h.Digest("/r/golang/top", nil)
+	h.PostBatch(nil)
+	h.CommentBatch(nil)
+	h.CommentContext(&reddit.Comment{}, nil, nil)
+
+	for i, kind := range []string{
+		PostKind,
+		UserCommentKind,
+		MentionKind,
+		DigestKind,
+		PostBatchKind,
+		CommentBatchKind,
+		CommentContextKind,
+	} {
+		if events[i].Kind != kind {
+			t.Errorf("event %d has kind %s; wanted %s", i, events[i].Kind, kind)
+		}
+	}
+}
+
+func TestEncodeJSON(t *testing.T) {
+	payload, err := JSON.Encode(
+		Event{Kind: PostKind, Post: &reddit.Post{Title: "hello"}},
+	)
+	if err != nil {
+		t.Fatalf("error encoding: %v", err)
+	}
+
+	var ev Event
+	if err := json.Unmarshal(payload, &ev); err != nil {
+		t.Fatalf("error decoding: %v", err)
+	}
+
+	if ev.Kind != PostKind || ev.Post.Title != "hello" || ev.Comment != nil {
+		t.Errorf("event did not survive encoding: %+v", ev)
+	}
+}
+
+func TestEncodeJSONSchema(t *testing.T) {
+	payload, err := JSON.Encode(
+		Event{
+			Kind: PostKind,
+			Post: &reddit.Post{
+				Name:       "t3_5du939",
+				Title:      "hello",
+				Subreddit:  "golang",
+				Permalink:  "/r/golang/comments/5du939",
+				CreatedUTC: 1500000000,
+			},
+		},
+	)
+	if err != nil {
+		t.Fatalf("error encoding: %v", err)
+	}
+
+	var ev struct {
+		SchemaVersion int                    `json:"schema_version"`
+		Kind          string                 `json:"kind"`
+		Source        Source                 `json:"source"`
+		Post          map[string]interface{} `json:"post"`
+	}
+	if err := json.Unmarshal(payload, &ev); err != nil {
+		t.Fatalf("error decoding: %v", err)
+	}
+
+	if ev.SchemaVersion != SchemaVersion || ev.Kind != PostKind {
+		t.Errorf("got version %d, kind %s", ev.SchemaVersion, ev.Kind)
+	}
+	expected := Source{
+		Producer:  "graw",
+		Subreddit: "golang",
+		Fullname:  "t3_5du939",
+		Permalink: "https://www.reddit.com/r/golang/comments/5du939",
+	}
+	if ev.Source != expected {
+		t.Errorf("got source %+v; wanted %+v", ev.Source, expected)
+	}
+	if ev.Post["title"] != "hello" || ev.Post["created_utc"] != 1500000000.0 {
+		t.Errorf("post fields not named as Reddit names them: %v", ev.Post)
+	}
+}
+
+func TestEncodeProto(t *testing.T) {
+	payload, err := Proto.Encode(
+		Event{Kind: "post", Post: &reddit.Post{Title: "hi"}},
+	)
+	if err != nil {
+		t.Fatalf("error encoding: %v", err)
+	}
+
+	link := &redditproto.Link{}
+	if err := proto.Unmarshal(payload, link); err != nil {
+		t.Fatalf("error decoding: %v", err)
+	}
+	if link.GetTitle() != "hi" {
+		t.Errorf("got link %v; wanted title hi", link)
+	}
+
+	payload, err = Proto.Encode(Event{Kind: HandlerPausedKind})
+	if err != nil || payload != nil {
+		t.Errorf("got %v, %v for an alert; wanted nothing", payload, err)
+	}
+}
+
+func TestProtoMessage(t *testing.T) {
+	payload, err := Proto.Encode(
+		Event{
+			Kind:    MentionKind,
+			Message: &reddit.Message{Name: "t1_a", WasComment: true},
+		},
+	)
+	if err != nil {
+		t.Fatalf("error encoding: %v", err)
+	}
+
+	ev, err := decodeProto(MentionKind, payload)
+	if err != nil {
+		t.Fatalf("error decoding: %v", err)
+	}
+	if ev.Kind != MentionKind || ev.Message.Name != "t1_a" ||
+		!ev.Message.WasComment {
+		t.Errorf("event did not survive encoding: %+v", ev)
+	}
+}
+
+func TestBusTopics(t *testing.T) {
+	var topics []string
+	bus := NewBus(
+		PublisherFunc(func(topic string, _ []byte) error {
+			topics = append(topics, topic)
+			return nil
+		}),
+		BusConfig{},
+	)
+
+	bus.Post(&reddit.Post{})
+	bus.Message(&reddit.Message{})
+
+	if len(topics) != 2 || topics[0] != "graw.post" || topics[1] != "graw.message" {
+		t.Errorf("published to %v; wanted graw.post and graw.message", topics)
+	}
+}
@@ -0,0 +1,312 @@
+package sink
+
+import (
+	"database/sql"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/turnage/graw/reddit"
+)
+
+// Dialect is the flavor of SQL a database speaks.
+type Dialect int
+
+const (
+	// SQLite databases, version 3.24 or later.
+	SQLite Dialect = iota
+	// PostgreSQL databases, version 9.5 or later.
+	Postgres
+)
+
+// rebind rewrites the ? placeholders in a query into the dialect's.
+func (d Dialect) rebind(query string) string {
+	if d != Postgres {
+		return query
+	}
+
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+		} else {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// sqlMigrations are the changes to the sink's schema, in order. Each is
+// applied once, in a transaction, and its number (its index plus one) is
+// recorded in graw_sink_version. Migrations are only ever appended, so the
+// schema of existing databases is brought up to date rather than rebuilt.
+var sqlMigrations = [][]string{
+	{
+		`CREATE TABLE graw_posts (
+			name TEXT PRIMARY KEY,
+			id TEXT NOT NULL,
+			subreddit TEXT NOT NULL,
+			author TEXT NOT NULL,
+			title TEXT NOT NULL,
+			url TEXT NOT NULL,
+			domain TEXT NOT NULL,
+			selftext TEXT NOT NULL,
+			permalink TEXT NOT NULL,
+			score BIGINT NOT NULL,
+			upvote_ratio REAL NOT NULL,
+			num_comments BIGINT NOT NULL,
+			nsfw BOOLEAN NOT NULL,
+			created_utc BIGINT NOT NULL,
+			edited_utc BIGINT NOT NULL,
+			data TEXT NOT NULL
+		)`,
+		`CREATE INDEX graw_posts_subreddit
+			ON graw_posts (subreddit, created_utc)`,
+		`CREATE TABLE graw_comments (
+			name TEXT PRIMARY KEY,
+			id TEXT NOT NULL,
+			link_id TEXT NOT NULL,
+			parent_id TEXT NOT NULL,
+			subreddit TEXT NOT NULL,
+			author TEXT NOT NULL,
+			body TEXT NOT NULL,
+			permalink TEXT NOT NULL,
+			score BIGINT NOT NULL,
+			created_utc BIGINT NOT NULL,
+			edited_utc BIGINT NOT NULL,
+			data TEXT NOT NULL
+		)`,
+		`CREATE INDEX graw_comments_link ON graw_comments (link_id)`,
+		`CREATE TABLE graw_messages (
+			name TEXT PRIMARY KEY,
+			id TEXT NOT NULL,
+			author TEXT NOT NULL,
+			subject TEXT NOT NULL,
+			body TEXT NOT NULL,
+			subreddit TEXT NOT NULL,
+			context TEXT NOT NULL,
+			parent_id TEXT NOT NULL,
+			was_comment BOOLEAN NOT NULL,
+			created_utc BIGINT NOT NULL,
+			data TEXT NOT NULL
+		)`,
+		`CREATE TABLE graw_events (
+			kind TEXT NOT NULL,
+			name TEXT NOT NULL,
+			received BIGINT NOT NULL,
+			event TEXT NOT NULL
+		)`,
+		`CREATE INDEX graw_events_name ON graw_events (name, received)`,
+	},
+}
+
+// Columns of the sink's tables, in the order rows are written. The first is
+// the key things are upserted on.
+var (
+	postColumns = []string{
+		"name", "id", "subreddit", "author", "title", "url", "domain",
+		"selftext", "permalink", "score", "upvote_ratio", "num_comments",
+		"nsfw", "created_utc", "edited_utc", "data",
+	}
+	commentColumns = []string{
+		"name", "id", "link_id", "parent_id", "subreddit", "author",
+		"body", "permalink", "score", "created_utc", "edited_utc", "data",
+	}
+	messageColumns = []string{
+		"name", "id", "author", "subject", "body", "subreddit", "context",
+		"parent_id", "was_comment", "created_utc", "data",
+	}
+)
+
+// SQLConfig configures a SQL sink.
+type SQLConfig struct {
+	// Dialect is the flavor of SQL the database speaks. The default is
+	// SQLite.
+	Dialect Dialect
+}
+
+// sqlSink writes events to a database.
+type sqlSink struct {
+	db      *sql.DB
+	dialect Dialect
+}
+
+// NewSQL returns a sink which writes every event it receives into tables in
+// the database, bringing their schema up to date first.
+//
+// Posts, comments, and messages are written to graw_posts, graw_comments, and
+// graw_messages, keyed by fullname; a thing seen again (e.g. in a post age
+// event) updates its row, so the tables hold the latest copy of each thing.
+// Each row keeps the thing's commonly queried fields in columns, and the whole
+// thing as JSON in data. Every event is also appended to graw_events, with
+// the fullname of the thing it is about and its full envelope as JSON.
+//
+// Like graw/store/sqlite, this package does not import a database driver.
+// Import one yourself and hand the opened database to NewSQL:
+//
+//	import _ "github.com/lib/pq"
+//
+//	db, err := sql.Open("postgres", "dbname=reddit sslmode=disable")
+//	...
+//	s, err := sink.NewSQL(db, sink.SQLConfig{Dialect: sink.Postgres})
+//	stop, wait, err := graw.Scan(s, script, cfg)
+func NewSQL(db *sql.DB, c SQLConfig) (*Handler, error) {
+	s := &sqlSink{db: db, dialect: c.Dialect}
+	if err := s.migrate(); err != nil {
+		return nil, err
+	}
+
+	return NewHandler(s.write), nil
+}
+
+// migrate applies the migrations the database has not had yet.
+func (s *sqlSink) migrate() error {
+	if _, err := s.db.Exec(
+		`CREATE TABLE IF NOT EXISTS graw_sink_version (
+			version INTEGER NOT NULL
+		)`,
+	); err != nil {
+		return err
+	}
+
+	var version int
+	if err := s.db.QueryRow(
+		`SELECT COALESCE(MAX(version), 0) FROM graw_sink_version`,
+	).Scan(&version); err != nil {
+		return err
+	}
+
+	for i := version; i < len(sqlMigrations); i++ {
+		tx, err := s.db.Begin()
+		if err != nil {
+			return err
+		}
+
+		for _, stmt := range sqlMigrations[i] {
+			if _, err := tx.Exec(stmt); err != nil {
+				tx.Rollback()
+				return err
+			}
+		}
+
+		if _, err := tx.Exec(
+			s.dialect.rebind(
+				`INSERT INTO graw_sink_version (version) VALUES (?)`,
+			),
+			i+1,
+		); err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// write records the event and the things in it in one transaction.
+func (s *sqlSink) write(ev Event) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	if err := s.writeEvent(tx, ev); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *sqlSink) writeEvent(tx *sql.Tx, ev Event) error {
+	name := ""
+	var err error
+	if p := ev.Post; p != nil {
+		name = p.Name
+		err = s.upsert(tx, "graw_posts", postColumns, postRow(p))
+	}
+	if c := ev.Comment; c != nil && err == nil {
+		name = c.Name
+		err = s.upsert(tx, "graw_comments", commentColumns, commentRow(c))
+	}
+	if m := ev.Message; m != nil && err == nil {
+		name = m.Name
+		err = s.upsert(tx, "graw_messages", messageColumns, messageRow(m))
+	}
+	if err != nil {
+		return err
+	}
+
+	blob, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(
+		s.dialect.rebind(
+			`INSERT INTO graw_events (kind, name, received, event)
+			VALUES (?, ?, ?, ?)`,
+		),
+		ev.Kind, name, time.Now().Unix(), string(blob),
+	)
+	return err
+}
+
+// upsert writes the row into the table, replacing the row with the same key.
+func (s *sqlSink) upsert(
+	tx *sql.Tx,
+	table string,
+	columns []string,
+	row []interface{},
+) error {
+	updates := make([]string, len(columns)-1)
+	for i, column := range columns[1:] {
+		updates[i] = column + " = excluded." + column
+	}
+
+	query := "INSERT INTO " + table +
+		" (" + strings.Join(columns, ", ") + ")" +
+		" VALUES (?" + strings.Repeat(", ?", len(columns)-1) + ")" +
+		" ON CONFLICT (" + columns[0] + ") DO UPDATE SET " +
+		strings.Join(updates, ", ")
+
+	_, err := tx.Exec(s.dialect.rebind(query), row...)
+	return err
+}
+
+func postRow(p *reddit.Post) []interface{} {
+	return []interface{}{
+		p.Name, p.ID, p.Subreddit, p.Author, p.Title, p.URL, p.Domain,
+		p.SelfText, p.Permalink, int64(p.Score), p.UpvoteRatio,
+		int64(p.NumComments), p.NSFW, int64(p.CreatedUTC),
+		int64(p.EditedUTC), jsonOf(p),
+	}
+}
+
+func commentRow(c *reddit.Comment) []interface{} {
+	return []interface{}{
+		c.Name, c.ID, c.LinkID, c.ParentID, c.Subreddit, c.Author, c.Body,
+		c.Permalink, int64(c.Ups - c.Downs), int64(c.CreatedUTC),
+		int64(c.EditedUTC), jsonOf(c),
+	}
+}
+
+func messageRow(m *reddit.Message) []interface{} {
+	return []interface{}{
+		m.Name, m.ID, m.Author, m.Subject, m.Body, m.Subreddit, m.Context,
+		m.ParentID, m.WasComment, int64(m.CreatedUTC), jsonOf(m),
+	}
+}
+
+// jsonOf returns the thing encoded as JSON. Things are plain data, so they
+// always encode.
+func jsonOf(thing interface{}) string {
+	blob, _ := json.Marshal(thing)
+	return string(blob)
+}
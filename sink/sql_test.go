@@ -0,0 +1,171 @@
+package sink
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/turnage/graw/reddit"
+)
+
+// fakeDB is a database/sql driver which records the statements executed on
+// it. It remembers the schema version the sink records, and answers every
+// query with it. Each test opens its own, with sql.OpenDB, so tests do not
+// share what was executed.
+type fakeDB struct {
+	mu      *sync.Mutex
+	stmts   []string
+	args    [][]driver.Value
+	version int64
+}
+
+func newFakeDB() *fakeDB {
+	return &fakeDB{mu: &sync.Mutex{}}
+}
+
+func (f *fakeDB) Open(name string) (driver.Conn, error) { return f, nil }
+func (f *fakeDB) Driver() driver.Driver                 { return f }
+func (f *fakeDB) Close() error                          { return nil }
+func (f *fakeDB) Begin() (driver.Tx, error)             { return f, nil }
+func (f *fakeDB) Commit() error                         { return nil }
+func (f *fakeDB) Rollback() error                       { return nil }
+
+func (f *fakeDB) Connect(ctx context.Context) (driver.Conn, error) {
+	return f, nil
+}
+
+func (f *fakeDB) Prepare(query string) (driver.Stmt, error) {
+	return &fakeStmt{db: f, query: query}, nil
+}
+
+type fakeStmt struct {
+	db    *fakeDB
+	query string
+}
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return -1 }
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	s.db.stmts = append(s.db.stmts, s.query)
+	s.db.args = append(s.db.args, args)
+	if strings.HasPrefix(s.query, "INSERT INTO graw_sink_version") {
+		s.db.version = args[0].(int64)
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+	return &fakeRows{value: s.db.version}, nil
+}
+
+type fakeRows struct {
+	value int64
+	done  bool
+}
+
+func (r *fakeRows) Columns() []string { return []string{"version"} }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	dest[0] = r.value
+	return nil
+}
+
+// executed returns the statements executed since the last call which start
+// with the prefix, and their arguments.
+func (f *fakeDB) executed(prefix string) ([]string, [][]driver.Value) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var stmts []string
+	var args [][]driver.Value
+	for i, stmt := range f.stmts {
+		if strings.HasPrefix(stmt, prefix) {
+			stmts = append(stmts, stmt)
+			args = append(args, f.args[i])
+		}
+	}
+	f.stmts, f.args = nil, nil
+	return stmts, args
+}
+
+func TestSQLRebind(t *testing.T) {
+	query := "INSERT INTO t (a, b) VALUES (?, ?)"
+	if got := SQLite.rebind(query); got != query {
+		t.Errorf("sqlite: got %q; wanted %q", got, query)
+	}
+	if got := Postgres.rebind(query); got != "INSERT INTO t (a, b) VALUES ($1, $2)" {
+		t.Errorf("postgres: got %q", got)
+	}
+}
+
+func TestSQL(t *testing.T) {
+	fake := newFakeDB()
+	db := sql.OpenDB(fake)
+	defer db.Close()
+
+	s, err := NewSQL(db, SQLConfig{Dialect: Postgres})
+	if err != nil {
+		t.Fatalf("error creating sink: %v", err)
+	}
+
+	creates, _ := fake.executed("CREATE TABLE graw_")
+	if len(creates) != 4 {
+		t.Errorf("got %d tables created; wanted 4", len(creates))
+	}
+	if fake.version != int64(len(sqlMigrations)) {
+		t.Errorf("got version %d; wanted %d", fake.version, len(sqlMigrations))
+	}
+
+	// Opening the sink again does not migrate again.
+	if _, err := NewSQL(db, SQLConfig{Dialect: Postgres}); err != nil {
+		t.Fatalf("error reopening sink: %v", err)
+	}
+	if creates, _ := fake.executed("CREATE TABLE graw_"); len(creates) != 0 {
+		t.Errorf("migrations were applied twice: %v", creates)
+	}
+
+	if err := s.OPReply(
+		&reddit.Post{Name: "t3_a", Title: "title", CreatedUTC: 10},
+		&reddit.Comment{Name: "t1_b", LinkID: "t3_a", Ups: 3},
+	); err != nil {
+		t.Fatalf("error writing event: %v", err)
+	}
+
+	inserts, args := fake.executed("INSERT INTO ")
+	if len(inserts) != 3 ||
+		!strings.HasPrefix(inserts[0], "INSERT INTO graw_posts") ||
+		!strings.HasPrefix(inserts[1], "INSERT INTO graw_comments") ||
+		!strings.HasPrefix(inserts[2], "INSERT INTO graw_events") {
+		t.Fatalf("got inserts %v; wanted a post, comment, and event", inserts)
+	}
+
+	if !strings.Contains(inserts[0], "$16") ||
+		!strings.Contains(inserts[0], "ON CONFLICT (name) DO UPDATE SET") ||
+		!strings.Contains(inserts[0], "score = excluded.score") {
+		t.Errorf("post upsert incorrect: %s", inserts[0])
+	}
+	if args[0][0] != "t3_a" || args[0][4] != "title" || args[0][13] != int64(10) {
+		t.Errorf("post row incorrect: %v", args[0])
+	}
+	if args[1][0] != "t1_b" || args[1][2] != "t3_a" || args[1][8] != int64(3) {
+		t.Errorf("comment row incorrect: %v", args[1])
+	}
+	if args[2][0] != OPReplyKind || args[2][1] != "t1_b" {
+		t.Errorf("event row incorrect: %v", args[2])
+	}
+}
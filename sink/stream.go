@@ -0,0 +1,100 @@
+package sink
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/golang/protobuf/proto"
+)
+
+// maxStreamEvent is the largest event ReadStream reads; anything larger is
+// taken for a corrupt stream rather than allocated.
+const maxStreamEvent = 64 << 20
+
+var streamEventTooLargeErr = fmt.Errorf(
+	"stream event larger than %d bytes; the stream is corrupt",
+	maxStreamEvent,
+)
+
+// NewStream returns a sink which writes every event it receives to w, e.g. a
+// file or a socket, as its kind followed by the redditproto Link, Comment, or
+// Message it is about, each prefixed with its length as a varint. This is the
+// delimited format protocol buffer libraries read and write streams of
+// messages in (e.g. Java's parseDelimitedFrom), and is compact enough for long
+// term archives. Read it back with ReadStream. Events about none of those,
+// such as alerts and batches, are not written.
+//
+// Events are written as they arrive, one write each; buffer w, and flush it
+// when the run is over, if writes are expensive.
+func NewStream(w io.Writer) *Handler {
+	mu := &sync.Mutex{}
+	return NewHandler(
+		func(ev Event) error {
+			body, err := encodeProto(ev)
+			if err != nil || body == nil {
+				return err
+			}
+			msg := proto.EncodeVarint(uint64(len(ev.Kind)))
+			msg = append(msg, ev.Kind...)
+			msg = append(msg, proto.EncodeVarint(uint64(len(body)))...)
+			msg = append(msg, body...)
+
+			mu.Lock()
+			defer mu.Unlock()
+			_, err = w.Write(msg)
+			return err
+		},
+	)
+}
+
+// ReadStream reads the events NewStream wrote to r, e.g. to replay an archive,
+// passing each to f in order. It stops at the end of the stream, or at the
+// first error f returns. Only the fields redditproto has survive the trip.
+func ReadStream(r io.Reader, f func(Event) error) error {
+	br := bufio.NewReader(r)
+	for {
+		kind, err := readDelimited(br)
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		body, err := readDelimited(br)
+		if err == io.EOF {
+			return io.ErrUnexpectedEOF
+		} else if err != nil {
+			return err
+		}
+
+		ev, err := decodeProto(string(kind), body)
+		if err != nil {
+			return err
+		}
+		if err := f(ev); err != nil {
+			return err
+		}
+	}
+}
+
+// readDelimited reads one length prefixed record from br. It returns io.EOF
+// only if the stream ends before the record starts.
+func readDelimited(br *bufio.Reader) ([]byte, error) {
+	size, err := binary.ReadUvarint(br)
+	if err != nil {
+		return nil, err
+	} else if size > maxStreamEvent {
+		return nil, streamEventTooLargeErr
+	}
+
+	buf := make([]byte, size)
+	if _, err := io.ReadFull(br, buf); err == io.EOF {
+		return nil, io.ErrUnexpectedEOF
+	} else if err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
@@ -0,0 +1,78 @@
+package sink
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/kylelemons/godebug/pretty"
+	"github.com/turnage/graw/reddit"
+)
+
+func TestStream(t *testing.T) {
+	events := []Event{
+		{
+			Kind: RankKind,
+			Post: &reddit.Post{
+				Name:       "t3_a",
+				Title:      "hello",
+				Score:      -3,
+				CreatedUTC: 1500000000,
+				NSFW:       true,
+			},
+		},
+		{
+			Kind:    OPReplyKind,
+			Comment: &reddit.Comment{Name: "t1_b", Body: "hi"},
+		},
+		{
+			Kind:    MentionKind,
+			Message: &reddit.Message{Name: "t1_c", WasComment: true},
+		},
+		{
+			Kind: PostPageKind,
+			Post: &reddit.Post{Name: "t3_d", URL: "https://blog.golang.org"},
+		},
+	}
+
+	var buf bytes.Buffer
+	h := NewStream(&buf)
+	for _, ev := range events {
+		if err := h.f(ev); err != nil {
+			t.Fatalf("error writing event: %v", err)
+		}
+	}
+	if err := h.f(
+		Event{
+			Kind:  SourceSuspendedKind,
+			Feed:  "/r/golang/new",
+			Error: "503 service unavailable",
+		},
+	); err != nil {
+		t.Fatalf("error writing alert: %v", err)
+	}
+
+	var read []Event
+	if err := ReadStream(
+		bytes.NewReader(buf.Bytes()),
+		func(ev Event) error {
+			read = append(read, ev)
+			return nil
+		},
+	); err != nil {
+		t.Fatalf("error reading stream: %v", err)
+	}
+
+	if diff := pretty.Compare(read, events); diff != "" {
+		t.Errorf("events did not survive the stream; diff: %s", diff)
+	}
+
+	truncated := buf.Bytes()[:buf.Len()-1]
+	if err := ReadStream(
+		bytes.NewReader(truncated),
+		func(Event) error { return nil },
+	); err != io.ErrUnexpectedEOF {
+		t.Errorf("got %v reading a truncated stream; wanted %v",
+			err, io.ErrUnexpectedEOF)
+	}
+}
@@ -0,0 +1,87 @@
+package sink
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	defaultTelegramAPI     = "https://api.telegram.org"
+	defaultTelegramTimeout = 10 * time.Second
+)
+
+// telegramBreaks replaces line breaks in markup with newlines; Telegram's HTML
+// does not accept <br>.
+var telegramBreaks = strings.NewReplacer("<br>", "\n")
+
+// TelegramConfig configures a Telegram chat.
+type TelegramConfig struct {
+	// Token is the token BotFather issued the bridge's Telegram bot.
+	Token string
+	// Chat is the chat messages are sent to: a channel's username (e.g.
+	// @golang_reddit) or a chat's numeric ID. The bot must be allowed to
+	// post in it.
+	Chat string
+	// API is the address of the Bot API. The default is
+	// https://api.telegram.org.
+	API string
+	// Client makes the requests. The default is a client with a ten second
+	// timeout.
+	Client *http.Client
+}
+
+// Telegram is a Room in Telegram, which messages are sent to with the Bot API.
+type Telegram struct {
+	c TelegramConfig
+}
+
+// NewTelegram returns the Telegram chat.
+func NewTelegram(c TelegramConfig) *Telegram {
+	if c.API == "" {
+		c.API = defaultTelegramAPI
+	}
+	if c.Client == nil {
+		c.Client = &http.Client{Timeout: defaultTelegramTimeout}
+	}
+	c.API = strings.TrimSuffix(c.API, "/")
+
+	return &Telegram{c: c}
+}
+
+// Say sends the message to the chat, rendered from its HTML.
+func (t *Telegram) Say(text, html string) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"chat_id":                  t.c.Chat,
+		"text":                     telegramBreaks.Replace(html),
+		"parse_mode":               "HTML",
+		"disable_web_page_preview": true,
+	})
+	if err != nil {
+		return err
+	}
+
+	resp, err := t.c.Client.Post(
+		t.c.API+"/bot"+t.c.Token+"/sendMessage",
+		"application/json",
+		bytes.NewReader(body),
+	)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		OK          bool   `json:"ok"`
+		Description string `json:"description"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("telegram responded %s", resp.Status)
+	} else if !result.OK {
+		return fmt.Errorf("telegram: %s", result.Description)
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+package sink
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// SignatureHeader carries the HMAC-SHA256 signature of webhook
+	// requests, in the form "sha256=<hex digest>"; see Sign.
+	SignatureHeader = "X-Graw-Signature"
+	// TimestampHeader carries the time a webhook request was sent, in
+	// seconds since the epoch. It is covered by the signature.
+	TimestampHeader = "X-Graw-Timestamp"
+	// KindHeader carries the kind of the event in webhook requests.
+	KindHeader = "X-Graw-Event"
+
+	defaultWebhookRetries = 3
+	defaultWebhookBackoff = time.Second
+	defaultWebhookTimeout = 10 * time.Second
+)
+
+// WebhookConfig configures a webhook sink.
+type WebhookConfig struct {
+	// URL is the endpoint every event is POSTed to as JSON.
+	URL string
+	// Secret, if set, is the key used to sign each request's timestamp
+	// and body with HMAC-SHA256. The signature is sent in the
+	// X-Graw-Signature header, so the receiver can verify the request came
+	// from the bot, and the timestamp lets it refuse replayed requests.
+	Secret string
+	// Retries is the number of times a failed delivery is retried. Network
+	// errors, 429s, and 5xx responses are retried; other responses are
+	// not. The default is 3; negative values disable retries.
+	Retries int
+	// Backoff is how long to wait before the first retry. It doubles with
+	// every retry after. The default is a second.
+	Backoff time.Duration
+	// Client makes the requests. The default is a client with a ten second
+	// timeout.
+	Client *http.Client
+	// Context, if set, bounds deliveries: once it is done, requests in
+	// flight are cancelled and retries are abandoned, e.g. so a bot can
+	// shut down without waiting out the backoff.
+	Context context.Context
+}
+
+// NewWebhook returns a sink which POSTs every event it receives as JSON to the
+// configured URL. If an event cannot be delivered after all retries, the error
+// stops the graw run as any handler error would.
+func NewWebhook(c WebhookConfig) *Handler {
+	if c.Retries == 0 {
+		c.Retries = defaultWebhookRetries
+	} else if c.Retries < 0 {
+		c.Retries = 0
+	}
+
+	if c.Backoff <= 0 {
+		c.Backoff = defaultWebhookBackoff
+	}
+
+	if c.Client == nil {
+		c.Client = &http.Client{Timeout: defaultWebhookTimeout}
+	}
+
+	if c.Context == nil {
+		c.Context = context.Background()
+	}
+
+	return NewHandler(
+		func(ev Event) error {
+			body, err := JSON.Encode(ev)
+			if err != nil {
+				return err
+			}
+			return c.deliver(ev.Kind, body)
+		},
+	)
+}
+
+func (c WebhookConfig) deliver(kind string, body []byte) error {
+	backoff := c.Backoff
+	var err error
+	for attempt := 0; attempt <= c.Retries; attempt++ {
+		if attempt > 0 {
+			wait := time.NewTimer(backoff)
+			select {
+			case <-c.Context.Done():
+				wait.Stop()
+				return c.Context.Err()
+			case <-wait.C:
+			}
+			backoff *= 2
+		}
+
+		var retry bool
+		if retry, err = c.post(kind, body); err == nil || !retry {
+			return err
+		}
+	}
+	return err
+}
+
+// post makes one delivery attempt, and returns whether a failure is worth
+// retrying.
+func (c WebhookConfig) post(kind string, body []byte) (bool, error) {
+	req, err := http.NewRequest("POST", c.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req = req.WithContext(c.Context)
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(KindHeader, kind)
+	req.Header.Set(TimestampHeader, timestamp)
+	if c.Secret != "" {
+		req.Header.Set(SignatureHeader, Sign(c.Secret, timestamp, body))
+	}
+
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		if c.Context.Err() != nil {
+			return false, err
+		}
+		return true, err
+	}
+	resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return false, nil
+	case resp.StatusCode == http.StatusTooManyRequests,
+		resp.StatusCode >= 500:
+		return true, fmt.Errorf("webhook responded %s", resp.Status)
+	}
+	return false, fmt.Errorf("webhook responded %s", resp.Status)
+}
+
+// Sign returns the value of the X-Graw-Signature header for a request with
+// the X-Graw-Timestamp header and body, signed with the secret: the HMAC of
+// the timestamp, a ".", and the body. Receivers can compare it to the header
+// with hmac.Equal, and should refuse timestamps far from their own clock so
+// captured requests cannot be replayed.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
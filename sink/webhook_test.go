@@ -0,0 +1,114 @@
+package sink
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/turnage/graw/reddit"
+)
+
+func TestWebhookSignsAndRetries(t *testing.T) {
+	attempts := 0
+	serv := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			attempts++
+			body, _ := ioutil.ReadAll(r.Body)
+			timestamp := r.Header.Get(TimestampHeader)
+			if sent, err := strconv.ParseInt(timestamp, 10, 64); err != nil ||
+				time.Since(time.Unix(sent, 0)) > time.Minute {
+				t.Errorf("bad timestamp %q", timestamp)
+			}
+			if sig := r.Header.Get(SignatureHeader); sig != Sign(
+				"key",
+				timestamp,
+				body,
+			) {
+				t.Errorf("bad signature %q", sig)
+			}
+
+			if kind := r.Header.Get(KindHeader); kind != PostKind {
+				t.Errorf("event kind header %q; wanted post", kind)
+			}
+
+			if attempts < 3 {
+				w.WriteHeader(http.StatusBadGateway)
+			}
+		},
+	))
+	defer serv.Close()
+
+	hook := NewWebhook(WebhookConfig{
+		URL:     serv.URL,
+		Secret:  "key",
+		Backoff: time.Millisecond,
+	})
+
+	if err := hook.Post(&reddit.Post{Title: "hello"}); err != nil {
+		t.Errorf("delivery failed: %v", err)
+	}
+
+	if attempts != 3 {
+		t.Errorf("made %d attempts; wanted 3", attempts)
+	}
+}
+
+func TestWebhookDoesNotRetryClientErrors(t *testing.T) {
+	attempts := 0
+	serv := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			attempts++
+			w.WriteHeader(http.StatusBadRequest)
+		},
+	))
+	defer serv.Close()
+
+	hook := NewWebhook(WebhookConfig{URL: serv.URL, Backoff: time.Millisecond})
+	if err := hook.Comment(&reddit.Comment{}); err == nil {
+		t.Errorf("wanted error for rejected delivery")
+	}
+
+	if attempts != 1 {
+		t.Errorf("made %d attempts; wanted 1", attempts)
+	}
+}
+
+func TestWebhookRetriesStopWithContext(t *testing.T) {
+	attempts := make(chan bool, 10)
+	serv := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			attempts <- true
+		},
+	))
+	defer serv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	hook := NewWebhook(WebhookConfig{
+		URL:     serv.URL,
+		Backoff: time.Hour,
+		Context: ctx,
+	})
+
+	done := make(chan error)
+	go func() { done <- hook.Post(&reddit.Post{}) }()
+	<-attempts
+	cancel()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("got %v; wanted the context's error", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("delivery waited out the backoff after the context ended")
+	}
+	if len(attempts) > 0 {
+		t.Errorf("made another attempt after the context ended")
+	}
+}
@@ -0,0 +1,226 @@
+// Package snapshot saves whole threads, a post and its comment tree, for bots
+// which archive discussions. Snapshots are written as JSON, which can be read
+// back, or rendered as Markdown or HTML for people to read.
+//
+//	a := snapshot.Archiver{Dir: "threads", Format: snapshot.Markdown}
+//	file, err := a.Snapshot(bot, "/r/golang/comments/5du939")
+//
+// graw takes a snapshot of each watched thread when it expires if its config
+// names an Archiver (see graw.Config's ThreadExpiry).
+package snapshot
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/turnage/graw/reddit"
+)
+
+// Format is the form a snapshot is written in.
+type Format int
+
+const (
+	// JSON writes a Thread, which can be decoded again with encoding/json.
+	JSON Format = iota
+	// Markdown renders the thread as a Markdown document, with replies
+	// quoted under their parents.
+	Markdown
+	// HTML renders the thread as a standalone HTML page, using the HTML
+	// Reddit rendered for the post and its comments.
+	HTML
+)
+
+// timeLayout is how times are written in rendered snapshots.
+const timeLayout = "2006-01-02 15:04 UTC"
+
+var unknownFormatErr = fmt.Errorf("unknown snapshot format")
+
+// Thread is a snapshot of a post and its comment tree, as written in JSON.
+type Thread struct {
+	// Taken is when the snapshot was taken.
+	Taken time.Time
+	Post  *reddit.Post
+}
+
+// extension returns the file extension for snapshots in the format.
+func (f Format) extension() (string, error) {
+	switch f {
+	case JSON:
+		return ".json", nil
+	case Markdown:
+		return ".md", nil
+	case HTML:
+		return ".html", nil
+	}
+	return "", unknownFormatErr
+}
+
+// Write writes a snapshot of the post and its comment tree, taken at the
+// given time, in the format.
+func Write(w io.Writer, post *reddit.Post, taken time.Time, f Format) error {
+	switch f {
+	case JSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(Thread{Taken: taken.UTC(), Post: post})
+	case Markdown:
+		return writeMarkdown(w, post, taken)
+	case HTML:
+		return page.Execute(w, Thread{Taken: taken.UTC(), Post: post})
+	}
+	return unknownFormatErr
+}
+
+// Archiver fetches threads and writes snapshots of them to files.
+type Archiver struct {
+	// Dir is the directory snapshots are written to, named for the
+	// post's fullname (e.g. t3_5du939.md). It is created if it does not
+	// exist. The default is the working directory.
+	Dir string
+	// Format is the form snapshots are written in. The default is JSON.
+	Format Format
+	// Options select the slice of each thread which is saved. By default
+	// as much of the thread as Reddit will return in one request is.
+	Options reddit.ThreadOptions
+}
+
+// Snapshot fetches the thread with the permalink and writes a snapshot of it,
+// returning the path of the file written. A snapshot of the same post taken
+// earlier is replaced.
+func (a Archiver) Snapshot(
+	lurker reddit.Lurker,
+	permalink string,
+) (string, error) {
+	post, err := lurker.ThreadWithOptions(permalink, a.Options)
+	if err != nil {
+		return "", err
+	}
+	return a.Save(post, time.Now())
+}
+
+// Save writes a snapshot of a post already fetched, returning the path of the
+// file written.
+func (a Archiver) Save(post *reddit.Post, taken time.Time) (string, error) {
+	ext, err := a.Format.extension()
+	if err != nil {
+		return "", err
+	}
+
+	if a.Dir != "" {
+		if err := os.MkdirAll(a.Dir, 0755); err != nil {
+			return "", err
+		}
+	}
+
+	path := filepath.Join(a.Dir, post.Name+ext)
+	f, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+
+	if err := Write(f, post, taken, a.Format); err != nil {
+		f.Close()
+		return "", err
+	}
+	return path, f.Close()
+}
+
+// writeMarkdown renders the thread as Markdown.
+func writeMarkdown(w io.Writer, post *reddit.Post, taken time.Time) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# [%s](%s)\n\n", post.Title, post.URL)
+	fmt.Fprintf(
+		&b,
+		"*Posted by /u/%s to /r/%s on %s, %d points. "+
+			"Snapshot taken %s.*\n\n",
+		post.Author,
+		post.Subreddit,
+		post.Created().Format(timeLayout),
+		post.Score,
+		taken.UTC().Format(timeLayout),
+	)
+	if post.SelfText != "" {
+		b.WriteString(post.SelfText)
+		b.WriteString("\n\n")
+	}
+	b.WriteString("---\n")
+
+	for _, c := range post.Replies {
+		b.WriteString("\n")
+		b.WriteString(markdownComment(c))
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// markdownComment renders a comment and its replies, which are quoted under
+// it.
+func markdownComment(c *reddit.Comment) string {
+	var b strings.Builder
+	fmt.Fprintf(
+		&b,
+		"**/u/%s** · %d points · %s\n\n%s\n",
+		c.Author,
+		points(c),
+		c.Created().Format(timeLayout),
+		c.Body,
+	)
+	for _, r := range c.Replies {
+		b.WriteString("\n")
+		b.WriteString(quote(markdownComment(r)))
+	}
+	return b.String()
+}
+
+// points returns the comment's score.
+func points(c *reddit.Comment) int32 {
+	return c.Ups - c.Downs
+}
+
+// quote prefixes each line of the text with a Markdown quote marker.
+func quote(text string) string {
+	lines := strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+	for i, line := range lines {
+		if line == "" {
+			lines[i] = ">"
+		} else {
+			lines[i] = "> " + line
+		}
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// page renders a thread as an HTML page.
+var page = template.Must(template.New("page").Funcs(template.FuncMap{
+	"points":   points,
+	"rendered": func(s string) template.HTML { return template.HTML(s) },
+	"when":     func(t time.Time) string { return t.UTC().Format(timeLayout) },
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Post.Title}}</title>
+</head>
+<body>
+{{with .Post}}<h1><a href="{{.URL}}">{{.Title}}</a></h1>
+<p><em>Posted by /u/{{.Author}} to /r/{{.Subreddit}} on {{when .Created}}, {{.Score}} points.</em></p>
+{{rendered .HTML}}{{end}}
+<p><em>Snapshot taken {{when .Taken}}.</em></p>
+<hr>
+{{template "comments" .Post.Replies}}
+</body>
+</html>
+{{define "comments"}}{{if .}}<ul>
+{{range .}}<li id="{{.Name}}">
+<p><strong>/u/{{.Author}}</strong> · {{points .}} points · {{when .Created}}</p>
+{{rendered .HTML}}
+{{template "comments" .Replies}}</li>
+{{end}}</ul>
+{{end}}{{end}}`))
@@ -0,0 +1,172 @@
+package snapshot
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/turnage/graw/reddit"
+)
+
+// thread returns a post with a reply, which has a reply of its own.
+func thread() *reddit.Post {
+	return &reddit.Post{
+		Name:         "t3_a",
+		Title:        "Fish & chips",
+		URL:          "https://reddit.com/r/golang/comments/a",
+		Author:       "op",
+		Subreddit:    "golang",
+		Score:        12,
+		SelfText:     "which are best?",
+		SelfTextHTML: "&lt;p&gt;which are best?&lt;/p&gt;",
+		CreatedUTC:   1500000000,
+		Replies: []*reddit.Comment{{
+			Name:       "t1_b",
+			Author:     "alice",
+			Ups:        5,
+			Body:       "mine",
+			BodyHTML:   "&lt;p&gt;mine&lt;/p&gt;",
+			CreatedUTC: 1500000060,
+			Replies: []*reddit.Comment{{
+				Name:       "t1_c",
+				Author:     "bob",
+				Ups:        2,
+				Body:       "no,\n\nmine",
+				BodyHTML:   "&lt;p&gt;no, mine&lt;/p&gt;",
+				CreatedUTC: 1500000120,
+			}},
+		}},
+	}
+}
+
+var taken = time.Unix(1500003600, 0)
+
+func TestWriteJSON(t *testing.T) {
+	var b bytes.Buffer
+	if err := Write(&b, thread(), taken, JSON); err != nil {
+		t.Fatalf("error writing snapshot: %v", err)
+	}
+
+	var th Thread
+	if err := json.Unmarshal(b.Bytes(), &th); err != nil {
+		t.Fatalf("error reading snapshot back: %v", err)
+	}
+	if !th.Taken.Equal(taken) ||
+		th.Post.Title != "Fish & chips" ||
+		len(th.Post.Replies) != 1 ||
+		len(th.Post.Replies[0].Replies) != 1 ||
+		th.Post.Replies[0].Replies[0].Body != "no,\n\nmine" {
+		t.Errorf("snapshot read back incorrectly: %+v", th)
+	}
+}
+
+func TestWriteMarkdown(t *testing.T) {
+	var b bytes.Buffer
+	if err := Write(&b, thread(), taken, Markdown); err != nil {
+		t.Fatalf("error writing snapshot: %v", err)
+	}
+
+	expected := `# [Fish & chips](https://reddit.com/r/golang/comments/a)
+
+*Posted by /u/op to /r/golang on 2017-07-14 02:40 UTC, 12 points. Snapshot taken 2017-07-14 03:40 UTC.*
+
+which are best?
+
+---
+
+**/u/alice** · 5 points · 2017-07-14 02:41 UTC
+
+mine
+
+> **/u/bob** · 2 points · 2017-07-14 02:42 UTC
+>
+> no,
+>
+> mine
+`
+	if b.String() != expected {
+		t.Errorf("got\n%s\nwanted\n%s", b.String(), expected)
+	}
+}
+
+func TestWriteHTML(t *testing.T) {
+	var b bytes.Buffer
+	if err := Write(&b, thread(), taken, HTML); err != nil {
+		t.Fatalf("error writing snapshot: %v", err)
+	}
+
+	page := b.String()
+	for _, want := range []string{
+		"<title>Fish &amp; chips</title>",
+		"<p>which are best?</p>",
+		`<li id="t1_b">`,
+		"<strong>/u/bob</strong> · 2 points",
+		"<p>no, mine</p>",
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("page does not contain %q:\n%s", want, page)
+		}
+	}
+	// bob's reply is nested in alice's comment.
+	if strings.Index(page, `<li id="t1_c">`) > strings.Index(page, "</li>") {
+		t.Errorf("reply is not nested under its parent:\n%s", page)
+	}
+}
+
+func TestWriteUnknownFormat(t *testing.T) {
+	if err := Write(&bytes.Buffer{}, thread(), taken, Format(-1)); err != unknownFormatErr {
+		t.Errorf("got %v; wanted %v", err, unknownFormatErr)
+	}
+}
+
+// threadLurker returns the same post for every thread, and records the
+// permalinks asked for. Its other methods are unimplemented.
+type threadLurker struct {
+	reddit.Lurker
+	post       *reddit.Post
+	permalinks []string
+}
+
+func (l *threadLurker) ThreadWithOptions(
+	permalink string,
+	opts reddit.ThreadOptions,
+) (*reddit.Post, error) {
+	l.permalinks = append(l.permalinks, permalink)
+	return l.post, nil
+}
+
+func TestArchiverSnapshot(t *testing.T) {
+	dir, err := ioutil.TempDir("", "snapshot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	lurker := &threadLurker{post: thread()}
+	a := Archiver{Dir: filepath.Join(dir, "threads"), Format: Markdown}
+	path, err := a.Snapshot(lurker, "/r/golang/comments/a")
+	if err != nil {
+		t.Fatalf("error taking snapshot: %v", err)
+	}
+
+	if path != filepath.Join(dir, "threads", "t3_a.md") {
+		t.Errorf("got path %s; wanted t3_a.md in the archive dir", path)
+	}
+	if len(lurker.permalinks) != 1 ||
+		lurker.permalinks[0] != "/r/golang/comments/a" {
+		t.Errorf("got fetches %v; wanted the thread", lurker.permalinks)
+	}
+
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("error reading snapshot: %v", err)
+	}
+	if !strings.HasPrefix(string(contents), "# [Fish & chips]") {
+		t.Errorf("snapshot incorrect:\n%s", contents)
+	}
+}
@@ -0,0 +1,182 @@
+package graw
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/turnage/graw/link"
+	"github.com/turnage/graw/reddit"
+)
+
+var (
+	subredditMissingErr = fmt.Errorf(
+		"subreddit does not exist, or is banned",
+	)
+	subredditPrivateErr = fmt.Errorf(
+		"subreddit is private, and the bot is not an approved user",
+	)
+	threadLinkErr = fmt.Errorf("not a permalink to a thread")
+)
+
+// pseudoSubreddits are the listings Reddit serves under /r/ which are not
+// subreddits, so cannot be looked up.
+var pseudoSubreddits = map[string]bool{
+	"all":     true,
+	"popular": true,
+	"friends": true,
+	"mod":     true,
+}
+
+// SourceErrors is returned by Run and Scan when Config.CheckSources finds
+// subreddits or threads the bot cannot watch. It holds what is wrong with
+// each, by its name in the config.
+type SourceErrors map[string]error
+
+func (s SourceErrors) Error() string {
+	names := make([]string, 0, len(s))
+	for name := range s {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	problems := make([]string, len(names))
+	for i, name := range names {
+		problems[i] = name + ": " + s[name].Error()
+	}
+	return "cannot watch sources: " + strings.Join(problems, "; ")
+}
+
+// checkSources looks up the subreddits and threads the config watches, and
+// returns SourceErrors naming those which do not exist or cannot be read.
+// Subreddits and threads are looked up at the same time, each in batches.
+func checkSources(l reddit.Lurker, c Config) error {
+	problems := SourceErrors{}
+	mu := &sync.Mutex{}
+	report := func(name string, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		problems[name] = err
+	}
+
+	var subErr, threadErr error
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		subErr = checkSubreddits(
+			l,
+			append(
+				append([]string{}, c.Subreddits...),
+				c.SubredditComments...,
+			),
+			report,
+		)
+	}()
+	go func() {
+		defer wg.Done()
+		threadErr = checkThreads(
+			l,
+			append(append([]string{}, c.Threads...), c.PriorityThreads...),
+			report,
+		)
+	}()
+	wg.Wait()
+
+	if subErr != nil {
+		return subErr
+	} else if threadErr != nil {
+		return threadErr
+	} else if len(problems) > 0 {
+		return problems
+	}
+	return nil
+}
+
+// checkSubreddits reports the subreddits which do not exist or cannot be read.
+func checkSubreddits(
+	l reddit.Lurker,
+	names []string,
+	report func(string, error),
+) error {
+	// wanted are the names looked up, by the lowercase name Reddit
+	// matches them by.
+	wanted := make(map[string]string)
+	var lookups []string
+	for _, name := range names {
+		key := strings.ToLower(name)
+		if _, ok := wanted[key]; ok || pseudoSubreddits[key] {
+			continue
+		}
+		wanted[key] = name
+		lookups = append(lookups, name)
+	}
+	if len(lookups) == 0 {
+		return nil
+	}
+
+	subreddits, err := l.Subreddits(lookups...)
+	if err != nil {
+		return err
+	}
+
+	for _, sr := range subreddits {
+		key := strings.ToLower(sr.DisplayName)
+		name, ok := wanted[key]
+		if !ok {
+			continue
+		}
+		delete(wanted, key)
+
+		if !sr.Readable() {
+			report(name, subredditPrivateErr)
+		}
+	}
+
+	for _, name := range wanted {
+		report(name, subredditMissingErr)
+	}
+	return nil
+}
+
+// checkThreads reports the threads which do not exist, or whose permalinks do
+// not parse.
+func checkThreads(
+	l reddit.Lurker,
+	permalinks []string,
+	report func(string, error),
+) error {
+	// wanted are the permalinks looked up, by their post's fullname.
+	wanted := make(map[string]string)
+	var lookups []string
+	for _, permalink := range permalinks {
+		lnk, err := link.Parse(permalink)
+		if err != nil {
+			report(permalink, threadLinkErr)
+			continue
+		}
+
+		name := lnk.PostFullname()
+		if _, ok := wanted[name]; !ok {
+			lookups = append(lookups, name)
+		}
+		wanted[name] = permalink
+	}
+	if len(lookups) == 0 {
+		return nil
+	}
+
+	found, err := l.Info(lookups...)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range found.Posts {
+		delete(wanted, p.Name)
+	}
+	for _, permalink := range wanted {
+		report(permalink, reddit.ThreadDoesNotExistErr)
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package graw
+
+import (
+	"testing"
+
+	"github.com/turnage/graw/reddit"
+)
+
+// sourceLurker knows a fixed set of subreddits and posts.
+type sourceLurker struct {
+	reddit.Lurker
+	subreddits []*reddit.Subreddit
+	posts      map[string]bool
+}
+
+func (s *sourceLurker) Subreddits(
+	names ...string,
+) ([]*reddit.Subreddit, error) {
+	return s.subreddits, nil
+}
+
+func (s *sourceLurker) Info(fullnames ...string) (reddit.Harvest, error) {
+	var h reddit.Harvest
+	for _, name := range fullnames {
+		if s.posts[name] {
+			h.Posts = append(h.Posts, &reddit.Post{Name: name})
+		}
+	}
+	return h, nil
+}
+
+func TestCheckSources(t *testing.T) {
+	l := &sourceLurker{
+		subreddits: []*reddit.Subreddit{
+			{DisplayName: "golang", Type: reddit.SubredditPublic},
+			{DisplayName: "secret", Type: reddit.SubredditPrivate},
+			{
+				DisplayName:       "club",
+				Type:              reddit.SubredditPrivate,
+				UserIsContributor: true,
+			},
+		},
+		posts: map[string]bool{"t3_5du939": true},
+	}
+
+	err := checkSources(l, Config{
+		Subreddits:        []string{"GoLang", "all", "secret", "gloang"},
+		SubredditComments: []string{"club"},
+		Threads:           []string{"/r/golang/comments/5du939"},
+		PriorityThreads: []string{
+			"/r/golang/comments/zzzzzz",
+			"not a link",
+		},
+	})
+
+	problems, ok := err.(SourceErrors)
+	if !ok {
+		t.Fatalf("got error %v; wanted SourceErrors", err)
+	}
+	want := SourceErrors{
+		"secret":                    subredditPrivateErr,
+		"gloang":                    subredditMissingErr,
+		"/r/golang/comments/zzzzzz": reddit.ThreadDoesNotExistErr,
+		"not a link":                threadLinkErr,
+	}
+	if len(problems) != len(want) {
+		t.Errorf("got source errors %v; wanted %v", problems, want)
+	}
+	for name, err := range want {
+		if problems[name] != err {
+			t.Errorf(
+				"got error %v for %s; wanted %v",
+				problems[name], name, err,
+			)
+		}
+	}
+
+	if err := checkSources(l, Config{
+		Subreddits: []string{"golang"},
+		Threads:    []string{"https://redd.it/5du939"},
+	}); err != nil {
+		t.Errorf("error checking good sources: %v", err)
+	}
+}
@@ -0,0 +1,220 @@
+package graw
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/turnage/graw/botfaces"
+	"github.com/turnage/graw/clock"
+	"github.com/turnage/graw/reddit"
+	"github.com/turnage/graw/streams"
+)
+
+// defaultSpamCheckDelay is how long after the bot submits something it is
+// checked, if a SpamCheck does not say.
+const defaultSpamCheckDelay = time.Minute
+
+var submissionFilteredHandlerErr = fmt.Errorf(
+	"You must implement SubmissionFilteredHandler to check for spam filtering.",
+)
+
+// SpamCheck watches the bot's own posts and comments, and checks whether other
+// users can see them. Reddit's spam filter removes things silently: the bot
+// still sees them as posted, but nobody else does. Submissions logged out
+// users cannot see are forwarded to the bot's SubmissionFilteredHandler, so
+// the bot can message the moderators or try again later.
+type SpamCheck struct {
+	// Script is the logged out handle submissions are checked with. The
+	// bot's own handle cannot be used, since it can see its filtered
+	// submissions. If nil, submissions are not checked.
+	Script reddit.Script
+	// Delay is how long after a submission is made it is checked, which
+	// gives the spam filter time to act. The default is a minute.
+	Delay time.Duration
+}
+
+// spamChecker checks the visibility of the bot's submissions.
+type spamChecker struct {
+	script  reddit.Lurker
+	delay   time.Duration
+	clock   clock.Clock
+	handler botfaces.SubmissionFilteredHandler
+	d       *dispatcher
+	errs    chan<- error
+	// pending are the submissions waiting to be checked, in the order
+	// they are due, and added is signaled when one is added.
+	pending []pendingCheck
+	added   chan bool
+	mu      *sync.Mutex
+}
+
+// pendingCheck is a submission waiting to be checked.
+type pendingCheck struct {
+	due time.Time
+	e   streams.Event
+}
+
+func newSpamChecker(
+	script reddit.Lurker,
+	delay time.Duration,
+	fh botfaces.SubmissionFilteredHandler,
+	d *dispatcher,
+	errs chan<- error,
+) *spamChecker {
+	s := &spamChecker{
+		script:  script,
+		delay:   delay,
+		clock:   d.clock,
+		handler: fh,
+		d:       d,
+		errs:    errs,
+		added:   make(chan bool, 1),
+		mu:      &sync.Mutex{},
+	}
+	if s.delay <= 0 {
+		s.delay = defaultSpamCheckDelay
+	}
+	return s
+}
+
+// watchSpam checks each new submission in the bot's user listing after the
+// configured delay.
+func watchSpam(
+	handler interface{},
+	bot reddit.Bot,
+	c SpamCheck,
+	self string,
+	d *dispatcher,
+	kill <-chan bool,
+	errs chan<- error,
+) error {
+	if c.Script == nil {
+		return nil
+	}
+
+	fh, ok := handler.(botfaces.SubmissionFilteredHandler)
+	if !ok {
+		return submissionFilteredHandlerErr
+	}
+
+	s := newSpamChecker(c.Script, c.Delay, fh, d, errs)
+	go s.run(kill)
+
+	return followListing(
+		bot,
+		nil,
+		"/u/"+self,
+		AtMostOnce,
+		kill,
+		errs,
+		func(e streams.Event) bool {
+			s.enqueue(e)
+			return true
+		},
+	)
+}
+
+// enqueue queues the submission to be checked once the delay has passed.
+func (s *spamChecker) enqueue(e streams.Event) {
+	s.mu.Lock()
+	s.pending = append(
+		s.pending,
+		pendingCheck{due: s.clock.Now().Add(s.delay), e: e},
+	)
+	s.mu.Unlock()
+
+	select {
+	case s.added <- true:
+	default:
+	}
+}
+
+// run checks the queued submissions as they come due, until killed. The delay
+// is the same for every submission, so they come due in the order they were
+// queued, and only the first is waited on.
+func (s *spamChecker) run(kill <-chan bool) {
+	var wait <-chan time.Time
+	for {
+		if wait == nil {
+			s.mu.Lock()
+			if len(s.pending) > 0 {
+				wait = s.clock.After(s.pending[0].due.Sub(s.clock.Now()))
+			}
+			s.mu.Unlock()
+		}
+
+		select {
+		case <-kill:
+			return
+		case <-s.added:
+		case <-wait:
+			wait = nil
+			for _, e := range s.due() {
+				s.check(e)
+			}
+		}
+	}
+}
+
+// due removes the submissions which have come due from the queue and returns
+// them.
+func (s *spamChecker) due() []streams.Event {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.clock.Now()
+	var due []streams.Event
+	for len(s.pending) > 0 && !s.pending[0].due.After(now) {
+		due = append(due, s.pending[0].e)
+		s.pending = s.pending[1:]
+	}
+	return due
+}
+
+// check dispatches the submission to the bot if logged out users cannot see
+// it.
+func (s *spamChecker) check(e streams.Event) {
+	name := ""
+	if e.Post != nil {
+		name = e.Post.Name
+	} else if e.Comment != nil {
+		name = e.Comment.Name
+	}
+
+	if visible, err := s.visible(name); err != nil {
+		s.errs <- err
+		return
+	} else if visible {
+		return
+	}
+
+	if e.Post != nil {
+		s.d.dispatch(
+			filteredEv(postEv(postFilteredEvent, e.Post)),
+			func() error { return s.handler.PostFiltered(e.Post) },
+		)
+	} else if e.Comment != nil {
+		s.d.dispatch(
+			filteredEv(commentEv(commentFilteredEvent, e.Comment)),
+			func() error { return s.handler.CommentFiltered(e.Comment) },
+		)
+	}
+}
+
+// visible returns whether the thing is visible to logged out users: it can be
+// found, and it has not been removed.
+func (s *spamChecker) visible(name string) (bool, error) {
+	h, err := s.script.Info(name)
+	if err != nil {
+		return false, err
+	}
+
+	for _, p := range h.Posts {
+		return !p.Removed(), nil
+	}
+	for _, c := range h.Comments {
+		return !c.Removed(), nil
+	}
+	return false, nil
+}
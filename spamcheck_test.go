@@ -0,0 +1,123 @@
+package graw
+
+import (
+	"testing"
+	"time"
+
+	"github.com/turnage/graw/clock"
+	"github.com/turnage/graw/reddit"
+	"github.com/turnage/graw/streams"
+)
+
+// infoLurker answers info requests from a fixed harvest per name. Its other
+// methods are unimplemented.
+type infoLurker struct {
+	reddit.Lurker
+	things map[string]reddit.Harvest
+}
+
+func (i *infoLurker) Info(fullnames ...string) (reddit.Harvest, error) {
+	return i.things[fullnames[0]], nil
+}
+
+type filteredRecorder struct {
+	posts    []string
+	comments []string
+}
+
+func (f *filteredRecorder) PostFiltered(p *reddit.Post) error {
+	f.posts = append(f.posts, p.Name)
+	return nil
+}
+
+func (f *filteredRecorder) CommentFiltered(c *reddit.Comment) error {
+	f.comments = append(f.comments, c.Name)
+	return nil
+}
+
+func TestSpamCheck(t *testing.T) {
+	errs := make(chan error, 10)
+	rec := &filteredRecorder{}
+	s := &spamChecker{
+		script: &infoLurker{things: map[string]reddit.Harvest{
+			"t3_fine": {Posts: []*reddit.Post{{Name: "t3_fine"}}},
+			"t3_spam": {Posts: []*reddit.Post{{
+				Name:              "t3_spam",
+				RemovedByCategory: "reddit",
+			}}},
+			"t1_fine": {Comments: []*reddit.Comment{{Name: "t1_fine"}}},
+			"t1_spam": {Comments: []*reddit.Comment{{
+				Name: "t1_spam",
+				Body: "[removed]",
+			}}},
+		}},
+		handler: rec,
+		d:       newDispatcher(Config{}, "", errs),
+		errs:    errs,
+	}
+
+	for _, name := range []string{"t3_fine", "t3_spam", "t3_gone"} {
+		s.check(streams.Event{Post: &reddit.Post{Name: name}})
+	}
+	for _, name := range []string{"t1_fine", "t1_spam"} {
+		s.check(streams.Event{Comment: &reddit.Comment{Name: name}})
+	}
+
+	if len(rec.posts) != 2 || rec.posts[0] != "t3_spam" ||
+		rec.posts[1] != "t3_gone" {
+		t.Errorf("got filtered posts %v; wanted [t3_spam t3_gone]", rec.posts)
+	}
+	if len(rec.comments) != 1 || rec.comments[0] != "t1_spam" {
+		t.Errorf("got filtered comments %v; wanted [t1_spam]", rec.comments)
+	}
+	for len(errs) > 0 {
+		if err := <-errs; err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	}
+}
+
+func TestSpamCheckDelay(t *testing.T) {
+	v := clock.NewVirtual(time.Unix(1500000000, 0))
+	errs := make(chan error, 10)
+	rec := &filteredRecorder{}
+	s := newSpamChecker(
+		&infoLurker{},
+		time.Minute,
+		rec,
+		newDispatcher(Config{Clock: v}, "", errs),
+		errs,
+	)
+	kill := make(chan bool)
+	done := make(chan bool)
+	go func() {
+		s.run(kill)
+		close(done)
+	}()
+
+	s.enqueue(streams.Event{Post: &reddit.Post{Name: "t3_a"}})
+	v.BlockUntil(1)
+	v.Advance(30 * time.Second)
+	s.enqueue(streams.Event{Post: &reddit.Post{Name: "t3_b"}})
+	if len(rec.posts) != 0 {
+		t.Errorf("got filtered posts %v before the delay passed", rec.posts)
+	}
+
+	// Once the first submission is checked, the checker waits on the
+	// second.
+	v.Advance(30 * time.Second)
+	v.BlockUntil(1)
+	if len(rec.posts) != 1 || rec.posts[0] != "t3_a" {
+		t.Errorf("got filtered posts %v; wanted [t3_a]", rec.posts)
+	}
+
+	s.enqueue(streams.Event{Post: &reddit.Post{Name: "t3_c"}})
+	v.Advance(30 * time.Second)
+	v.BlockUntil(1)
+	if len(rec.posts) != 2 || rec.posts[1] != "t3_b" {
+		t.Errorf("got filtered posts %v; wanted [t3_a t3_b]", rec.posts)
+	}
+
+	close(kill)
+	<-done
+}
@@ -0,0 +1,137 @@
+package graw
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/turnage/graw/store"
+)
+
+// stateVersion is the version of the state format ExportState writes.
+const stateVersion = 1
+
+var (
+	seenUnlistableErr = fmt.Errorf(
+		"the seen set cannot list its names; it must be a store.SeenLister",
+	)
+	tipsUnlistableErr = fmt.Errorf(
+		"the tips store cannot list its paths; it must be a store.TipsLister",
+	)
+)
+
+// StateStores are the stores whose contents make up a bot's state: where its
+// feeds are (see Config's Cursors), what it has handled (Config's Seen), and
+// the writes it has queued (see graw/outbox). Nil stores are skipped.
+type StateStores struct {
+	Tips   store.Tips
+	Seen   store.SeenSet
+	Outbox store.Outbox
+}
+
+// state is a bot's state, as ExportState writes it.
+type state struct {
+	Version int                 `json:"version"`
+	Tips    map[string][]string `json:"tips,omitempty"`
+	Seen    []string            `json:"seen,omitempty"`
+	Outbox  []store.Item        `json:"outbox,omitempty"`
+}
+
+// ExportState returns the contents of the stores as one blob, which
+// ImportState loads into other stores. Together they hand a bot's state from
+// one deployment to the next, e.g. from in memory stores to the stores of a
+// new host, so the new deployment neither misses events nor handles any twice:
+//
+//	stop()                      // stop the old deployment
+//	blob, err := graw.ExportState(old)
+//	...
+//	err = graw.ImportState(blob, fresh)
+//	...
+//	stop, wait, err := graw.Run(handler, bot, cfg) // the new deployment
+//
+// Export after the old deployment stops, or its feeds may move on after their
+// tips are exported. The tips and seen stores must be able to list what they
+// hold; the stores in graw/store can.
+func ExportState(s StateStores) ([]byte, error) {
+	st := state{Version: stateVersion}
+
+	if s.Tips != nil {
+		lister, ok := s.Tips.(store.TipsLister)
+		if !ok {
+			return nil, tipsUnlistableErr
+		}
+
+		paths, err := lister.TipPaths()
+		if err != nil {
+			return nil, err
+		}
+
+		st.Tips = make(map[string][]string)
+		for _, path := range paths {
+			tips, err := s.Tips.Tips(path)
+			if err != nil {
+				return nil, err
+			}
+			st.Tips[path] = tips
+		}
+	}
+
+	if s.Seen != nil {
+		lister, ok := s.Seen.(store.SeenLister)
+		if !ok {
+			return nil, seenUnlistableErr
+		}
+
+		var err error
+		if st.Seen, err = lister.SeenNames(); err != nil {
+			return nil, err
+		}
+	}
+
+	if s.Outbox != nil {
+		var err error
+		if st.Outbox, err = s.Outbox.Pending(); err != nil {
+			return nil, err
+		}
+	}
+
+	return json.Marshal(st)
+}
+
+// ImportState loads a blob written by ExportState into the stores. Tips replace
+// any the stores have for the same paths, and seen names are added to theirs.
+// Queued writes are pushed to the outbox anew, with new IDs, so a blob must
+// only be imported once, or its writes are sent twice.
+func ImportState(blob []byte, s StateStores) error {
+	var st state
+	if err := json.Unmarshal(blob, &st); err != nil {
+		return err
+	} else if st.Version != stateVersion {
+		return fmt.Errorf("unknown state version %d", st.Version)
+	}
+
+	if s.Tips != nil {
+		for path, tips := range st.Tips {
+			if err := s.Tips.SetTips(path, tips); err != nil {
+				return err
+			}
+		}
+	}
+
+	if s.Seen != nil {
+		for _, name := range st.Seen {
+			if err := s.Seen.MarkSeen(name); err != nil {
+				return err
+			}
+		}
+	}
+
+	if s.Outbox != nil {
+		for _, item := range st.Outbox {
+			if _, err := s.Outbox.Push(item.Path, item.Values); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,67 @@
+package graw
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/turnage/graw/store"
+)
+
+func TestStateRoundTrip(t *testing.T) {
+	old := store.NewMemory()
+	old.SetTips("/r/golang/new", []string{"t3_b", "t3_a"})
+	old.SetTips("/message/inbox", []string{"t4_a"})
+	old.MarkSeen("t3_a")
+	old.MarkSeen("t1_a")
+	old.Push("/api/comment", map[string]string{"thing_id": "t3_a"})
+	acked, _ := old.Push("/api/comment", map[string]string{"thing_id": "t3_b"})
+	old.Ack(acked)
+
+	blob, err := ExportState(StateStores{Tips: old, Seen: old, Outbox: old})
+	if err != nil {
+		t.Fatalf("error exporting state: %v", err)
+	}
+
+	fresh := store.NewMemory()
+	if err := ImportState(
+		blob,
+		StateStores{Tips: fresh, Seen: fresh, Outbox: fresh},
+	); err != nil {
+		t.Fatalf("error importing state: %v", err)
+	}
+
+	for _, path := range []string{"/r/golang/new", "/message/inbox"} {
+		want, _ := old.Tips(path)
+		if got, _ := fresh.Tips(path); !reflect.DeepEqual(got, want) {
+			t.Errorf("got tips %v for %s; wanted %v", got, path, want)
+		}
+	}
+
+	for _, name := range []string{"t3_a", "t1_a"} {
+		if seen, _ := fresh.Seen(name); !seen {
+			t.Errorf("%s was not imported as seen", name)
+		}
+	}
+
+	pending, _ := fresh.Pending()
+	if len(pending) != 1 || pending[0].Values["thing_id"] != "t3_a" {
+		t.Errorf("got pending writes %+v; wanted the unacknowledged one", pending)
+	}
+}
+
+// unlistableSeenSet is a seen set which cannot list its names.
+type unlistableSeenSet struct {
+	store.SeenSet
+}
+
+func TestExportStateNeedsListers(t *testing.T) {
+	if _, err := ExportState(StateStores{
+		Seen: unlistableSeenSet{store.NewMemory()},
+	}); err != seenUnlistableErr {
+		t.Errorf("got error %v; wanted %v", err, seenUnlistableErr)
+	}
+
+	if err := ImportState([]byte(`{"version": 99}`), StateStores{}); err == nil {
+		t.Errorf("wanted error importing an unknown version")
+	}
+}
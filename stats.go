@@ -0,0 +1,112 @@
+package graw
+
+import (
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// meterWindow is the number of seconds event rates are averaged over.
+const meterWindow = 60
+
+// meter counts events in one second buckets, to report the recent event rate.
+type meter struct {
+	buckets [meterWindow]int
+	// seconds are the unix seconds each bucket is counting.
+	seconds [meterWindow]int64
+	mu      *sync.Mutex
+}
+
+func newMeter() *meter {
+	return &meter{mu: &sync.Mutex{}}
+}
+
+// mark counts an event at now.
+func (m *meter) mark(now time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	sec := now.Unix()
+	i := sec % meterWindow
+	if m.seconds[i] != sec {
+		m.seconds[i] = sec
+		m.buckets[i] = 0
+	}
+	m.buckets[i]++
+}
+
+// rate returns the average events per second over the window ending at now.
+func (m *meter) rate(now time.Time) float64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	sec, total := now.Unix(), 0
+	for i := range m.buckets {
+		if sec-m.seconds[i] < meterWindow {
+			total += m.buckets[i]
+		}
+	}
+	return float64(total) / meterWindow
+}
+
+// stats is the body of stats responses.
+type stats struct {
+	Goroutines int    `json:"goroutines"`
+	HeapAlloc  uint64 `json:"heap_alloc"`
+	// Backlog is the number of events each handler is working through.
+	Backlog map[string]int `json:"backlog"`
+	// Events is the number of events each handler has handled.
+	Events map[string]int `json:"events"`
+	// EventsPerSecond is the rate events have been handled at over the
+	// last minute.
+	EventsPerSecond float64 `json:"events_per_second"`
+	// Subreddits is the recent activity in each monitored subreddit, if
+	// the run keeps SubredditStats.
+	Subreddits map[string]SubredditStat `json:"subreddits,omitempty"`
+	// Cooldowns is the number of seconds until the author asked about
+	// with the author parameter may reach each cooled down handler
+	// again.
+	Cooldowns map[string]float64 `json:"cooldowns,omitempty"`
+}
+
+func (h *healthServer) stats(now time.Time, author string) stats {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	s := stats{
+		Goroutines:      runtime.NumGoroutine(),
+		HeapAlloc:       mem.HeapAlloc,
+		Backlog:         h.d.backlog(),
+		Events:          h.d.handled(),
+		EventsPerSecond: h.d.rate.rate(now),
+	}
+	if h.d.subreddits != nil {
+		s.Subreddits = h.d.subreddits.stats(now)
+	}
+	if author != "" {
+		s.Cooldowns = h.d.cooldowns(strings.ToLower(author), now)
+	}
+	return s
+}
+
+// serveDebug adds the stats and pprof endpoints to the mux.
+func (h *healthServer) serveDebug(mux *http.ServeMux) {
+	mux.HandleFunc(
+		"/stats",
+		func(w http.ResponseWriter, r *http.Request) {
+			h.respond(
+				w,
+				h.stats(h.d.clock.Now(), r.URL.Query().Get("author")),
+				true,
+			)
+		},
+	)
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+}
@@ -0,0 +1,104 @@
+package graw
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/turnage/graw/clock"
+	"github.com/turnage/graw/reddit"
+)
+
+func TestMeter(t *testing.T) {
+	m := newMeter()
+	start := time.Unix(1000, 0)
+	for i := 0; i < 60; i++ {
+		m.mark(start.Add(time.Duration(i) * time.Second))
+		m.mark(start.Add(time.Duration(i) * time.Second))
+	}
+
+	if rate := m.rate(start.Add(59 * time.Second)); rate != 2 {
+		t.Errorf("got rate %v; wanted 2", rate)
+	}
+
+	// Half the window has passed since the last events.
+	if rate := m.rate(start.Add(89 * time.Second)); rate != 1 {
+		t.Errorf("got rate %v; wanted 1", rate)
+	}
+
+	if rate := m.rate(start.Add(time.Hour)); rate != 0 {
+		t.Errorf("got rate %v; wanted 0", rate)
+	}
+}
+
+func TestDebugEndpoints(t *testing.T) {
+	errs := make(chan error, 10)
+	d := newDispatcher(Config{}, "", errs)
+	d.dispatch(event{kind: postEvent}, func() error { return nil })
+	d.subreddits = NewSubredditStats(time.Hour)
+	d.subreddits.post(&reddit.Post{Subreddit: "golang"}, time.Now())
+
+	for _, debug := range []bool{false, true} {
+		h := &healthServer{monitor: &fakeMonitor{}, d: d, debug: debug}
+		serv := httptest.NewServer(h.mux())
+
+		resp, err := http.Get(serv.URL + "/stats")
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+
+		if !debug {
+			if resp.StatusCode != http.StatusNotFound {
+				t.Errorf("got %d for stats without debug; wanted 404", resp.StatusCode)
+			}
+		} else {
+			var s stats
+			if err := json.NewDecoder(resp.Body).Decode(&s); err != nil {
+				t.Fatalf("error decoding stats: %v", err)
+			}
+			if s.Goroutines == 0 || s.Events["post"] != 1 {
+				t.Errorf("got stats %+v; wanted goroutines and one post", s)
+			}
+			if _, ok := s.Subreddits["golang"]; !ok {
+				t.Errorf("got stats %+v; wanted golang's activity", s)
+			}
+
+			pprof, err := http.Get(serv.URL + "/debug/pprof/")
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			pprof.Body.Close()
+			if pprof.StatusCode != http.StatusOK {
+				t.Errorf("got %d for pprof index; wanted 200", pprof.StatusCode)
+			}
+		}
+
+		resp.Body.Close()
+		serv.Close()
+	}
+}
+
+func TestStatsCooldowns(t *testing.T) {
+	clk := clock.NewVirtual(time.Unix(1000, 0))
+	d := newDispatcher(
+		Config{Clock: clk, Cooldowns: Cooldowns{Post: time.Hour}},
+		"",
+		make(chan error, 10),
+	)
+	d.dispatch(
+		event{kind: postEvent, author: "roxven"},
+		func() error { return nil },
+	)
+	clk.Advance(20 * time.Minute)
+
+	h := &healthServer{monitor: &fakeMonitor{}, d: d}
+	s := h.stats(clk.Now(), "Roxven")
+	if left := s.Cooldowns["post"]; left != (40 * time.Minute).Seconds() {
+		t.Errorf("got %v seconds of cooldown left; wanted 2400", left)
+	}
+	if s := h.stats(clk.Now(), ""); s.Cooldowns != nil {
+		t.Errorf("got cooldowns %v without an author", s.Cooldowns)
+	}
+}
@@ -0,0 +1,299 @@
+package store
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+type memory struct {
+	seen     map[string]bool
+	tips     map[string][]string
+	outbox   map[int64]Item
+	lastID   int64
+	sessions map[string][]byte
+	history  map[string][]Sample
+	audit    []Action
+	links    map[string][]Sighting
+	credits  map[string]map[string]Credit
+	mu       *sync.Mutex
+}
+
+// NewMemory returns a Store which keeps its state in memory. Its state is lost
+// when the program exits, so it is best suited to tests and bots which do not
+// need to resume.
+func NewMemory() Store {
+	return &memory{
+		seen:     make(map[string]bool),
+		tips:     make(map[string][]string),
+		outbox:   make(map[int64]Item),
+		sessions: make(map[string][]byte),
+		history:  make(map[string][]Sample),
+		links:    make(map[string][]Sighting),
+		credits:  make(map[string]map[string]Credit),
+		mu:       &sync.Mutex{},
+	}
+}
+
+func (m *memory) Seen(name string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.seen[name], nil
+}
+
+func (m *memory) MarkSeen(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.seen[name] = true
+	return nil
+}
+
+func (m *memory) SeenNames() ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	names := make([]string, 0, len(m.seen))
+	for name := range m.seen {
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+func (m *memory) Tips(path string) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return copyStrings(m.tips[path]), nil
+}
+
+func (m *memory) SetTips(path string, tips []string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.tips[path] = copyStrings(tips)
+	return nil
+}
+
+func (m *memory) TipPaths() ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	paths := make([]string, 0, len(m.tips))
+	for path := range m.tips {
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+func (m *memory) Push(path string, values map[string]string) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.lastID++
+	item := Item{
+		ID:      m.lastID,
+		Path:    path,
+		Values:  make(map[string]string),
+		Created: time.Now(),
+	}
+	for key, value := range values {
+		item.Values[key] = value
+	}
+
+	m.outbox[item.ID] = item
+	return item.ID, nil
+}
+
+func (m *memory) Pending() ([]Item, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	items := make([]Item, 0, len(m.outbox))
+	for _, item := range m.outbox {
+		items = append(items, item)
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].ID < items[j].ID })
+	return items, nil
+}
+
+func (m *memory) Ack(id int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.outbox, id)
+	return nil
+}
+
+func (m *memory) Session(key string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return copyBytes(m.sessions[key]), nil
+}
+
+func (m *memory) SetSession(key string, data []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.sessions[key] = copyBytes(data)
+	return nil
+}
+
+func (m *memory) AddSample(name string, s Sample) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.history[name] = append(m.history[name], s)
+	return nil
+}
+
+func (m *memory) Samples(name string) ([]Sample, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.history[name] == nil {
+		return nil, nil
+	}
+	return append([]Sample{}, m.history[name]...), nil
+}
+
+func (m *memory) Record(a Action) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	a.ID = int64(len(m.audit) + 1)
+	a.Values = copyValues(a.Values)
+	m.audit = append(m.audit, a)
+	return a.ID, nil
+}
+
+func (m *memory) Action(id int64) (*Action, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if id < 1 || id > int64(len(m.audit)) {
+		return nil, nil
+	}
+
+	a := m.audit[id-1]
+	a.Values = copyValues(a.Values)
+	return &a, nil
+}
+
+func (m *memory) Actions(since time.Time) ([]Action, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var actions []Action
+	for _, a := range m.audit {
+		if !a.Time.Before(since) {
+			a.Values = copyValues(a.Values)
+			actions = append(actions, a)
+		}
+	}
+	return actions, nil
+}
+
+func (m *memory) AddSighting(link string, s Sighting) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, seen := range m.links[link] {
+		if seen.Post == s.Post {
+			return nil
+		}
+	}
+	m.links[link] = append(m.links[link], s)
+	return nil
+}
+
+func (m *memory) Sightings(link string) ([]Sighting, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.links[link] == nil {
+		return nil, nil
+	}
+
+	sightings := append([]Sighting{}, m.links[link]...)
+	sort.SliceStable(sightings, func(i, j int) bool {
+		return sightings[i].Time.Before(sightings[j].Time)
+	})
+	return sightings, nil
+}
+
+func (m *memory) AddCredit(board string, c Credit) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.credits[board] == nil {
+		m.credits[board] = make(map[string]Credit)
+	}
+	m.credits[board][c.Thing] = c
+	return nil
+}
+
+func (m *memory) Credits(
+	board, subreddit string,
+	since time.Time,
+) ([]Credit, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var credits []Credit
+	for _, c := range m.credits[board] {
+		if c.Subreddit == subreddit && !c.Time.Before(since) {
+			credits = append(credits, c)
+		}
+	}
+
+	sort.Slice(credits, func(i, j int) bool {
+		if !credits[i].Time.Equal(credits[j].Time) {
+			return credits[i].Time.Before(credits[j].Time)
+		}
+		return credits[i].Thing < credits[j].Thing
+	})
+	return credits, nil
+}
+
+func (m *memory) ForgetCredits(before time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, credits := range m.credits {
+		for thing, c := range credits {
+			if c.Time.Before(before) {
+				delete(credits, thing)
+			}
+		}
+	}
+	return nil
+}
+
+func copyValues(values map[string]string) map[string]string {
+	if values == nil {
+		return nil
+	}
+
+	c := make(map[string]string, len(values))
+	for key, value := range values {
+		c[key] = value
+	}
+	return c
+}
+
+func copyStrings(s []string) []string {
+	if s == nil {
+		return nil
+	}
+	return append([]string{}, s...)
+}
+
+func copyBytes(b []byte) []byte {
+	if b == nil {
+		return nil
+	}
+	return append([]byte{}, b...)
+}
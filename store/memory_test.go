@@ -0,0 +1,12 @@
+package store_test
+
+import (
+	"testing"
+
+	"github.com/turnage/graw/store"
+	"github.com/turnage/graw/store/storetest"
+)
+
+func TestMemory(t *testing.T) {
+	storetest.Run(t, store.NewMemory())
+}
@@ -0,0 +1,120 @@
+package redis
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/turnage/graw/internal/resp"
+)
+
+const (
+	// defaultLimiterRate is the time between requests if a limiter is not
+	// given one; Reddit allows OAuth clients 60 requests per minute.
+	defaultLimiterRate = time.Second
+	// maxSlotsAhead is how far past the present a limiter looks for a free
+	// slot before giving up.
+	maxSlotsAhead = 1000
+)
+
+var limiterFullErr = fmt.Errorf(
+	"redis: no free request slot; too many requests are waiting",
+)
+
+// Limiter is a rate limiter shared through Redis, for processes which make
+// requests to Reddit with the same account. Give it to each process's bot as
+// reddit.BotConfig.Limiter:
+//
+//	limiter := redis.NewLimiter(redis.Config{Addr: "localhost:6379"}, "mybot", 0)
+//	bot, err := reddit.NewBot(reddit.BotConfig{Limiter: limiter, ...})
+//
+// Time is divided into slots one rate long, and each request claims a slot in
+// Redis, waiting for the slot to start if it is in the future. Together the
+// processes make at most one request per slot, like a token bucket refilled
+// once per rate. Clocks of the processes should be synchronized.
+type Limiter struct {
+	cli  *resp.Client
+	key  string
+	rate time.Duration
+	// next is the earliest slot this process has not seen claimed.
+	next int64
+	mu   *sync.Mutex
+}
+
+// NewLimiter returns a limiter for the account, allowing one request per rate
+// (one per second if rate is zero).
+func NewLimiter(c Config, account string, rate time.Duration) *Limiter {
+	if c.Prefix == "" {
+		c.Prefix = defaultPrefix
+	}
+	if rate <= 0 {
+		rate = defaultLimiterRate
+	}
+
+	return &Limiter{
+		cli: resp.New(
+			resp.Config{
+				Addr:     c.Addr,
+				Password: c.Password,
+				DB:       c.DB,
+				Timeout:  c.Timeout,
+			},
+		),
+		key:  c.Prefix + "ratelimit:" + account + ":",
+		rate: rate,
+		mu:   &sync.Mutex{},
+	}
+}
+
+// Close closes the connection to Redis.
+func (l *Limiter) Close() error {
+	return l.cli.Close()
+}
+
+// Wait blocks until the next free slot starts.
+func (l *Limiter) Wait() error {
+	start, err := l.claim(time.Now())
+	if err != nil {
+		return err
+	}
+
+	if wait := start.Sub(time.Now()); wait > 0 {
+		<-time.After(wait)
+	}
+	return nil
+}
+
+// claim claims the earliest free slot from now on, and returns when it starts.
+func (l *Limiter) claim(now time.Time) (time.Time, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	slot := now.UnixNano() / int64(l.rate)
+	if l.next > slot {
+		slot = l.next
+	}
+
+	for end := slot + maxSlotsAhead; slot < end; slot++ {
+		// Claims expire once their slot is long past.
+		ttl := time.Unix(0, (slot+2)*int64(l.rate)).Sub(now)
+		_, err := l.cli.Do(
+			"SET",
+			l.key+strconv.FormatInt(slot, 10),
+			"1",
+			"NX",
+			"PX",
+			strconv.FormatInt(int64(ttl/time.Millisecond)+1, 10),
+		)
+		if err == resp.Nil {
+			continue
+		} else if err != nil {
+			return time.Time{}, err
+		}
+
+		l.next = slot + 1
+		return time.Unix(0, slot*int64(l.rate)), nil
+	}
+
+	return time.Time{}, limiterFullErr
+}
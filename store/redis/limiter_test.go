@@ -0,0 +1,41 @@
+package redis
+
+import (
+	"testing"
+	"time"
+
+	"github.com/turnage/graw/internal/resp/resptest"
+)
+
+func TestLimiterSharesSlots(t *testing.T) {
+	s, err := resptest.NewServer()
+	if err != nil {
+		t.Fatalf("failed to start server: %v", err)
+	}
+	defer s.Close()
+
+	c := Config{Addr: s.Addr}
+	a := NewLimiter(c, "mybot", time.Minute)
+	defer a.Close()
+	b := NewLimiter(c, "mybot", time.Minute)
+	defer b.Close()
+	other := NewLimiter(c, "otherbot", time.Minute)
+	defer other.Close()
+
+	now := time.Now()
+	starts := make(map[time.Time]bool)
+	for _, l := range []*Limiter{a, b, a, b} {
+		start, err := l.claim(now)
+		if err != nil {
+			t.Fatalf("error claiming slot: %v", err)
+		}
+		if starts[start] {
+			t.Errorf("slot starting %v claimed twice", start)
+		}
+		starts[start] = true
+	}
+
+	if first, err := other.claim(now); err != nil || first.After(now) {
+		t.Errorf("other account waits until %v, %v; wanted no wait", first, err)
+	}
+}
@@ -0,0 +1,150 @@
+package redis
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/turnage/graw/internal/resp"
+)
+
+// defaultLockTerm is the length of a lock's terms if it is not given one.
+const defaultLockTerm = 15 * time.Second
+
+// Lock is a leader lock shared through Redis, for electing one of several
+// instances of a bot to be active with graw/leader:
+//
+//	lock := redis.NewLock(redis.Config{Addr: "localhost:6379"}, "mybot", 0)
+//	e := leader.New(leader.Config{Lock: lock, ...})
+//
+// Like the Limiter, time is divided into terms, and the instance which claims
+// a term in Redis leads for it. The leader claims the next term during the
+// current one, so it keeps leading while it lives; other instances only claim
+// the current term, so they take over within a term of the leader dying.
+// Clocks of the instances should be synchronized to well within a term.
+type Lock struct {
+	cli  *resp.Client
+	key  string
+	id   string
+	term time.Duration
+	// held are the terms this instance has claimed.
+	held map[int64]bool
+	mu   *sync.Mutex
+}
+
+// NewLock returns a lock for the bot, with terms of the given length (15
+// seconds if zero). An elector using it should acquire it several times a
+// term.
+func NewLock(c Config, bot string, term time.Duration) *Lock {
+	if c.Prefix == "" {
+		c.Prefix = defaultPrefix
+	}
+	if term <= 0 {
+		term = defaultLockTerm
+	}
+
+	host, _ := os.Hostname()
+	return &Lock{
+		cli: resp.New(
+			resp.Config{
+				Addr:     c.Addr,
+				Password: c.Password,
+				DB:       c.DB,
+				Timeout:  c.Timeout,
+			},
+		),
+		key:  c.Prefix + "leader:" + bot + ":",
+		id:   fmt.Sprintf("%s:%d", host, os.Getpid()),
+		term: term,
+		held: make(map[int64]bool),
+		mu:   &sync.Mutex{},
+	}
+}
+
+// Close closes the connection to Redis.
+func (l *Lock) Close() error {
+	return l.cli.Close()
+}
+
+// Acquire claims the current term if it is free, and the next term if this
+// instance holds the current one, returning when the last term it holds ends.
+func (l *Lock) Acquire() (time.Time, error) {
+	return l.acquire(time.Now())
+}
+
+func (l *Lock) acquire(now time.Time) (time.Time, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	term := now.UnixNano() / int64(l.term)
+	for held := range l.held {
+		if held < term {
+			delete(l.held, held)
+		}
+	}
+
+	if !l.held[term] {
+		claimed, err := l.claim(term, now)
+		if err != nil || !claimed {
+			return time.Time{}, err
+		}
+	}
+
+	if !l.held[term+1] {
+		if _, err := l.claim(term+1, now); err != nil {
+			return l.end(term), err
+		}
+	}
+
+	if l.held[term+1] {
+		return l.end(term + 1), nil
+	}
+	return l.end(term), nil
+}
+
+// claim claims the term for this instance if no instance has claimed it.
+func (l *Lock) claim(term int64, now time.Time) (bool, error) {
+	// Claims expire once their term is long past.
+	ttl := l.end(term + 1).Sub(now)
+	_, err := l.cli.Do(
+		"SET",
+		l.key+strconv.FormatInt(term, 10),
+		l.id,
+		"NX",
+		"PX",
+		strconv.FormatInt(int64(ttl/time.Millisecond)+1, 10),
+	)
+	if err == resp.Nil {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	l.held[term] = true
+	return true, nil
+}
+
+// end returns when the term ends.
+func (l *Lock) end(term int64) time.Time {
+	return time.Unix(0, (term+1)*int64(l.term))
+}
+
+// Release gives up the terms this instance holds, so another instance can
+// take over at once.
+func (l *Lock) Release() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for term := range l.held {
+		if _, err := l.cli.Do(
+			"DEL",
+			l.key+strconv.FormatInt(term, 10),
+		); err != nil {
+			return err
+		}
+		delete(l.held, term)
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package redis
+
+import (
+	"testing"
+	"time"
+
+	"github.com/turnage/graw/internal/resp/resptest"
+)
+
+func TestLockElectsOneLeader(t *testing.T) {
+	s, err := resptest.NewServer()
+	if err != nil {
+		t.Fatalf("failed to start server: %v", err)
+	}
+	defer s.Close()
+
+	c := Config{Addr: s.Addr}
+	a := NewLock(c, "mybot", time.Minute)
+	defer a.Close()
+	b := NewLock(c, "mybot", time.Minute)
+	defer b.Close()
+
+	now := time.Unix(600, 0)
+	leads := func(l *Lock, at time.Time) bool {
+		until, err := l.acquire(at)
+		if err != nil {
+			t.Fatalf("error acquiring lock: %v", err)
+		}
+		return until.After(at)
+	}
+
+	if !leads(a, now) {
+		t.Fatalf("first instance was not elected")
+	}
+	if leads(b, now) {
+		t.Errorf("second instance was elected while the first leads")
+	}
+
+	// The leader claimed the next term in advance, so it keeps leading.
+	if !leads(a, now.Add(time.Minute)) || leads(b, now.Add(time.Minute)) {
+		t.Errorf("leader lost its lead at the turn of a term")
+	}
+
+	// The leader stops renewing, as if it died; once its terms are over,
+	// the other instance takes over.
+	if !leads(b, now.Add(3*time.Minute)) {
+		t.Errorf("standby did not take over from a dead leader")
+	}
+
+	if err := b.Release(); err != nil {
+		t.Fatalf("error releasing lock: %v", err)
+	}
+	if !leads(a, now.Add(3*time.Minute)) {
+		t.Errorf("lock was not free after its leader released it")
+	}
+}
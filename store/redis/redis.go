@@ -0,0 +1,440 @@
+// Package redis implements graw/store on Redis, so horizontally scaled or
+// containerized deployments of a bot can share their seen sets, tips, outbox,
+// sessions, history, audit log, link index, and leaderboards.
+//
+//	st := redis.New(redis.Config{Addr: "localhost:6379", Prefix: "mybot:"})
+//
+// Such deployments should also share Reddit's rate limit; see Limiter. Active
+// and standby instances of a bot can elect their leader with Lock.
+//
+// The seen set grows with every thing a bot handles. Bound it with ForgetSeen,
+// e.g. once a day, keeping names longer than any feed could deliver them again.
+package redis
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/turnage/graw/internal/resp"
+	"github.com/turnage/graw/store"
+)
+
+// defaultPrefix prefixes all keys the store uses if no prefix is configured.
+const defaultPrefix = "graw:"
+
+// Config configures a connection to Redis.
+type Config struct {
+	// Addr is the host:port of the Redis server.
+	Addr string
+	// Password, if set, is used to authenticate with the server.
+	Password string
+	// DB selects a database other than the default.
+	DB int
+	// Timeout bounds dialing and each command. The default is 5 seconds.
+	Timeout time.Duration
+	// Prefix is prepended to every key the store uses. Bots sharing a
+	// Redis server but not state should use different prefixes. The
+	// default is "graw:".
+	Prefix string
+}
+
+// Store is a graw/store.Store backed by Redis.
+type Store struct {
+	cli    *resp.Client
+	prefix string
+}
+
+// New returns a Store which keeps its state in Redis. It does not connect
+// until it is first used.
+func New(c Config) *Store {
+	if c.Prefix == "" {
+		c.Prefix = defaultPrefix
+	}
+
+	return &Store{
+		cli: resp.New(
+			resp.Config{
+				Addr:     c.Addr,
+				Password: c.Password,
+				DB:       c.DB,
+				Timeout:  c.Timeout,
+			},
+		),
+		prefix: c.Prefix,
+	}
+}
+
+// Close closes the connection to Redis.
+func (s *Store) Close() error {
+	return s.cli.Close()
+}
+
+func (s *Store) Seen(name string) (bool, error) {
+	_, err := s.cli.Do("ZSCORE", s.key("seen:times"), name)
+	if err == resp.Nil {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (s *Store) MarkSeen(name string) error {
+	// Names are kept in a sorted set, scored by the time they were first
+	// marked seen, so ForgetSeen can find the old ones.
+	_, err := s.cli.Do(
+		"ZADD", s.key("seen:times"), "NX", score(time.Now()), name,
+	)
+	return err
+}
+
+// ForgetSeen removes the names marked seen before the time, so the seen set
+// does not grow without limit. Names forgotten are handled again if a feed
+// delivers them again.
+func (s *Store) ForgetSeen(before time.Time) error {
+	_, err := s.cli.Do(
+		"ZREMRANGEBYSCORE", s.key("seen:times"), "-inf", "("+score(before),
+	)
+	return err
+}
+
+func (s *Store) SeenNames() ([]string, error) {
+	return resp.Strings(
+		s.cli.Do("ZRANGEBYSCORE", s.key("seen:times"), "-inf", "+inf"),
+	)
+}
+
+func (s *Store) Tips(path string) ([]string, error) {
+	blob, err := resp.String(s.cli.Do("HGET", s.key("tips"), path))
+	if err == resp.Nil {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var tips []string
+	return tips, json.Unmarshal([]byte(blob), &tips)
+}
+
+func (s *Store) SetTips(path string, tips []string) error {
+	blob, err := json.Marshal(tips)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.cli.Do("HSET", s.key("tips"), path, string(blob))
+	return err
+}
+
+func (s *Store) TipPaths() ([]string, error) {
+	return resp.Strings(s.cli.Do("HKEYS", s.key("tips")))
+}
+
+func (s *Store) Push(path string, values map[string]string) (int64, error) {
+	id, err := resp.Int(s.cli.Do("INCR", s.key("outbox:id")))
+	if err != nil {
+		return 0, err
+	}
+
+	blob, err := json.Marshal(
+		store.Item{
+			ID:      id,
+			Path:    path,
+			Values:  values,
+			Created: time.Now(),
+		},
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = s.cli.Do(
+		"HSET", s.key("outbox"), strconv.FormatInt(id, 10), string(blob),
+	)
+	return id, err
+}
+
+func (s *Store) Pending() ([]store.Item, error) {
+	fields, err := resp.Strings(s.cli.Do("HGETALL", s.key("outbox")))
+	if err != nil {
+		return nil, err
+	}
+
+	var items []store.Item
+	for i := 1; i < len(fields); i += 2 {
+		var item store.Item
+		if err := json.Unmarshal([]byte(fields[i]), &item); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+
+	sort.Slice(items, func(i, j int) bool { return items[i].ID < items[j].ID })
+	return items, nil
+}
+
+func (s *Store) Ack(id int64) error {
+	_, err := s.cli.Do("HDEL", s.key("outbox"), strconv.FormatInt(id, 10))
+	return err
+}
+
+func (s *Store) Session(key string) ([]byte, error) {
+	data, err := resp.String(s.cli.Do("HGET", s.key("sessions"), key))
+	if err == resp.Nil {
+		return nil, nil
+	}
+	return []byte(data), err
+}
+
+func (s *Store) SetSession(key string, data []byte) error {
+	_, err := s.cli.Do("HSET", s.key("sessions"), key, string(data))
+	return err
+}
+
+func (s *Store) AddSample(name string, sample store.Sample) error {
+	blob, err := json.Marshal(sample)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.cli.Do("RPUSH", s.key("history:"+name), string(blob))
+	return err
+}
+
+func (s *Store) Samples(name string) ([]store.Sample, error) {
+	blobs, err := resp.Strings(
+		s.cli.Do("LRANGE", s.key("history:"+name), "0", "-1"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var samples []store.Sample
+	for _, blob := range blobs {
+		var sample store.Sample
+		if err := json.Unmarshal([]byte(blob), &sample); err != nil {
+			return nil, err
+		}
+		samples = append(samples, sample)
+	}
+	return samples, nil
+}
+
+func (s *Store) Record(a store.Action) (int64, error) {
+	id, err := resp.Int(s.cli.Do("INCR", s.key("audit:id")))
+	if err != nil {
+		return 0, err
+	}
+
+	a.ID = id
+	blob, err := json.Marshal(a)
+	if err != nil {
+		return 0, err
+	}
+
+	// Actions are kept in a hash by ID, indexed by time in a sorted set so
+	// Actions reads only those it needs.
+	if _, err := s.cli.Do(
+		"HSET", s.key("audit"), strconv.FormatInt(id, 10), string(blob),
+	); err != nil {
+		return 0, err
+	}
+	_, err = s.cli.Do(
+		"ZADD", s.key("audit:times"), score(a.Time), strconv.FormatInt(id, 10),
+	)
+	return id, err
+}
+
+func (s *Store) Action(id int64) (*store.Action, error) {
+	blob, err := resp.String(
+		s.cli.Do("HGET", s.key("audit"), strconv.FormatInt(id, 10)),
+	)
+	if err == resp.Nil {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	a := &store.Action{}
+	return a, json.Unmarshal([]byte(blob), a)
+}
+
+func (s *Store) Actions(since time.Time) ([]store.Action, error) {
+	ids, err := resp.Strings(
+		s.cli.Do("ZRANGEBYSCORE", s.key("audit:times"), score(since), "+inf"),
+	)
+	if err != nil || len(ids) == 0 {
+		return nil, err
+	}
+
+	blobs, err := resp.Strings(
+		s.cli.Do(append([]string{"HMGET", s.key("audit")}, ids...)...),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var actions []store.Action
+	for _, blob := range blobs {
+		if blob == "" {
+			continue
+		}
+		var a store.Action
+		if err := json.Unmarshal([]byte(blob), &a); err != nil {
+			return nil, err
+		}
+		if !a.Time.Before(since) {
+			actions = append(actions, a)
+		}
+	}
+
+	sort.Slice(actions, func(i, j int) bool {
+		return actions[i].ID < actions[j].ID
+	})
+	return actions, nil
+}
+
+func (s *Store) AddSighting(link string, sighting store.Sighting) error {
+	blob, err := json.Marshal(sighting)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.cli.Do(
+		"HSET", s.key("links:"+link), sighting.Post, string(blob),
+	)
+	return err
+}
+
+func (s *Store) Sightings(link string) ([]store.Sighting, error) {
+	fields, err := resp.Strings(s.cli.Do("HGETALL", s.key("links:"+link)))
+	if err != nil {
+		return nil, err
+	}
+
+	var sightings []store.Sighting
+	for i := 1; i < len(fields); i += 2 {
+		var sighting store.Sighting
+		if err := json.Unmarshal([]byte(fields[i]), &sighting); err != nil {
+			return nil, err
+		}
+		sightings = append(sightings, sighting)
+	}
+
+	sort.Slice(sightings, func(i, j int) bool {
+		if !sightings[i].Time.Equal(sightings[j].Time) {
+			return sightings[i].Time.Before(sightings[j].Time)
+		}
+		return sightings[i].Post < sightings[j].Post
+	})
+	return sightings, nil
+}
+
+func (s *Store) AddCredit(board string, c store.Credit) error {
+	blob, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+
+	// Credits are kept in a hash for each board and subreddit, indexed by
+	// time in a sorted set. The hashes are listed so ForgetCredits can find
+	// them.
+	hash := "credits:" + board + ":" + c.Subreddit
+	if _, err := s.cli.Do("SADD", s.key("credits"), hash); err != nil {
+		return err
+	}
+	if _, err := s.cli.Do(
+		"HSET",
+		s.key(hash),
+		c.Thing,
+		string(blob),
+	); err != nil {
+		return err
+	}
+	_, err = s.cli.Do("ZADD", s.key(hash+":times"), score(c.Time), c.Thing)
+	return err
+}
+
+func (s *Store) Credits(
+	board, subreddit string,
+	since time.Time,
+) ([]store.Credit, error) {
+	hash := "credits:" + board + ":" + subreddit
+	things, err := resp.Strings(
+		s.cli.Do("ZRANGEBYSCORE", s.key(hash+":times"), score(since), "+inf"),
+	)
+	if err != nil || len(things) == 0 {
+		return nil, err
+	}
+
+	blobs, err := resp.Strings(
+		s.cli.Do(append([]string{"HMGET", s.key(hash)}, things...)...),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var credits []store.Credit
+	for _, blob := range blobs {
+		if blob == "" {
+			continue
+		}
+		var c store.Credit
+		if err := json.Unmarshal([]byte(blob), &c); err != nil {
+			return nil, err
+		}
+		credits = append(credits, c)
+	}
+
+	sort.Slice(credits, func(i, j int) bool {
+		if !credits[i].Time.Equal(credits[j].Time) {
+			return credits[i].Time.Before(credits[j].Time)
+		}
+		return credits[i].Thing < credits[j].Thing
+	})
+	return credits, nil
+}
+
+func (s *Store) ForgetCredits(before time.Time) error {
+	hashes, err := resp.Strings(s.cli.Do("SMEMBERS", s.key("credits")))
+	if err != nil {
+		return err
+	}
+
+	max := "(" + score(before)
+	for _, hash := range hashes {
+		things, err := resp.Strings(
+			s.cli.Do("ZRANGEBYSCORE", s.key(hash+":times"), "-inf", max),
+		)
+		if err != nil {
+			return err
+		}
+		if len(things) == 0 {
+			continue
+		}
+
+		if _, err := s.cli.Do(
+			append([]string{"HDEL", s.key(hash)}, things...)...,
+		); err != nil {
+			return err
+		}
+		if _, err := s.cli.Do(
+			"ZREMRANGEBYSCORE",
+			s.key(hash+":times"),
+			"-inf",
+			max,
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// score returns the sorted set score of a time: milliseconds since the epoch.
+func score(t time.Time) string {
+	return strconv.FormatInt(t.UnixMilli(), 10)
+}
+
+func (s *Store) key(name string) string {
+	return s.prefix + name
+}
@@ -0,0 +1,60 @@
+package redis
+
+import (
+	"testing"
+	"time"
+
+	"github.com/turnage/graw/internal/resp/resptest"
+	"github.com/turnage/graw/store/storetest"
+)
+
+func TestStore(t *testing.T) {
+	s, err := resptest.NewServer()
+	if err != nil {
+		t.Fatalf("failed to start server: %v", err)
+	}
+	defer s.Close()
+
+	st := New(Config{Addr: s.Addr})
+	defer st.Close()
+
+	storetest.Run(t, st)
+}
+
+func TestForgetSeen(t *testing.T) {
+	s, err := resptest.NewServer()
+	if err != nil {
+		t.Fatalf("failed to start server: %v", err)
+	}
+	defer s.Close()
+
+	st := New(Config{Addr: s.Addr})
+	defer st.Close()
+
+	if _, err := st.cli.Do(
+		"ZADD",
+		st.key("seen:times"),
+		score(time.Now().Add(-48*time.Hour)),
+		"t3_old",
+	); err != nil {
+		t.Fatalf("failed to insert old name: %v", err)
+	}
+	if err := st.MarkSeen("t3_new"); err != nil {
+		t.Fatalf("failed to mark seen: %v", err)
+	}
+
+	if err := st.ForgetSeen(time.Now().Add(-24 * time.Hour)); err != nil {
+		t.Fatalf("failed to forget seen names: %v", err)
+	}
+
+	names, err := st.SeenNames()
+	if err != nil {
+		t.Fatalf("failed to list seen names: %v", err)
+	}
+	if len(names) != 1 || names[0] != "t3_new" {
+		t.Errorf("got seen names %v; wanted only t3_new", names)
+	}
+	if seen, err := st.Seen("t3_old"); seen || err != nil {
+		t.Errorf("got %v, %v for a forgotten name; wanted false", seen, err)
+	}
+}
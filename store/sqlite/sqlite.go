@@ -0,0 +1,487 @@
+// Package sqlite implements graw/store on a SQLite database, giving small bots
+// durable state without any external services.
+//
+// This package does not import a SQLite driver, so graw does not force cgo on
+// anyone. Import one yourself and hand the opened database to New:
+//
+//	import _ "github.com/mattn/go-sqlite3"
+//
+//	db, err := sql.Open("sqlite3", "bot.db")
+//	...
+//	st, err := sqlite.New(db)
+//
+// The seen set grows with every thing a bot handles. Bound it with ForgetSeen,
+// e.g. once a day, keeping names longer than any feed could deliver them again.
+package sqlite
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/turnage/graw/store"
+)
+
+// schema creates the tables the store uses if they do not exist.
+var schema = []string{
+	`CREATE TABLE IF NOT EXISTS graw_seen (
+		name TEXT PRIMARY KEY,
+		seen_at INTEGER NOT NULL
+	)`,
+	`CREATE INDEX IF NOT EXISTS graw_seen_at ON graw_seen (seen_at)`,
+	`CREATE TABLE IF NOT EXISTS graw_tips (
+		path TEXT PRIMARY KEY,
+		tips TEXT NOT NULL
+	)`,
+	`CREATE TABLE IF NOT EXISTS graw_outbox (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		path TEXT NOT NULL,
+		params TEXT NOT NULL,
+		created INTEGER NOT NULL
+	)`,
+	`CREATE TABLE IF NOT EXISTS graw_sessions (
+		key TEXT PRIMARY KEY,
+		data BLOB NOT NULL
+	)`,
+	`CREATE TABLE IF NOT EXISTS graw_history (
+		name TEXT NOT NULL,
+		time INTEGER NOT NULL,
+		score INTEGER NOT NULL,
+		comments INTEGER NOT NULL,
+		upvote_ratio REAL NOT NULL
+	)`,
+	`CREATE INDEX IF NOT EXISTS graw_history_name
+		ON graw_history (name, time)`,
+	`CREATE TABLE IF NOT EXISTS graw_audit (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		time INTEGER NOT NULL,
+		path TEXT NOT NULL,
+		target TEXT NOT NULL,
+		params TEXT NOT NULL,
+		trigger TEXT NOT NULL,
+		result TEXT NOT NULL,
+		err TEXT NOT NULL
+	)`,
+	`CREATE INDEX IF NOT EXISTS graw_audit_time ON graw_audit (time)`,
+	`CREATE TABLE IF NOT EXISTS graw_links (
+		link TEXT NOT NULL,
+		post TEXT NOT NULL,
+		subreddit TEXT NOT NULL,
+		time INTEGER NOT NULL,
+		PRIMARY KEY (link, post)
+	)`,
+	`CREATE TABLE IF NOT EXISTS graw_credits (
+		board TEXT NOT NULL,
+		thing TEXT NOT NULL,
+		author TEXT NOT NULL,
+		subreddit TEXT NOT NULL,
+		time INTEGER NOT NULL,
+		points INTEGER NOT NULL,
+		PRIMARY KEY (board, thing)
+	)`,
+	`CREATE INDEX IF NOT EXISTS graw_credits_subreddit_time
+		ON graw_credits (board, subreddit, time)`,
+}
+
+// Store is a graw/store.Store backed by a SQLite database.
+type Store struct {
+	db *sql.DB
+}
+
+// New returns a Store which keeps its state in the database, creating its
+// tables if they do not exist. All tables are prefixed with "graw_", so the
+// database can be shared with the bot's own tables.
+func New(db *sql.DB) (*Store, error) {
+	for _, stmt := range schema {
+		if _, err := db.Exec(stmt); err != nil {
+			return nil, err
+		}
+	}
+
+	return &Store{db: db}, nil
+}
+
+func (s *Store) Seen(name string) (bool, error) {
+	var n int
+	err := s.db.QueryRow(
+		`SELECT COUNT(*) FROM graw_seen WHERE name = ?`, name,
+	).Scan(&n)
+	return n > 0, err
+}
+
+func (s *Store) MarkSeen(name string) error {
+	_, err := s.db.Exec(
+		`INSERT OR IGNORE INTO graw_seen (name, seen_at) VALUES (?, ?)`,
+		name, time.Now().Unix(),
+	)
+	return err
+}
+
+// ForgetSeen removes the names marked seen before the time, so the seen set
+// does not grow without limit. Names forgotten are handled again if a feed
+// delivers them again.
+func (s *Store) ForgetSeen(before time.Time) error {
+	_, err := s.db.Exec(
+		`DELETE FROM graw_seen WHERE seen_at < ?`,
+		before.Unix(),
+	)
+	return err
+}
+
+func (s *Store) SeenNames() ([]string, error) {
+	return s.column(`SELECT name FROM graw_seen`)
+}
+
+func (s *Store) Tips(path string) ([]string, error) {
+	var blob string
+	err := s.db.QueryRow(
+		`SELECT tips FROM graw_tips WHERE path = ?`, path,
+	).Scan(&blob)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var tips []string
+	return tips, json.Unmarshal([]byte(blob), &tips)
+}
+
+func (s *Store) SetTips(path string, tips []string) error {
+	blob, err := json.Marshal(tips)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.Exec(
+		`INSERT OR REPLACE INTO graw_tips (path, tips) VALUES (?, ?)`,
+		path, string(blob),
+	)
+	return err
+}
+
+func (s *Store) TipPaths() ([]string, error) {
+	return s.column(`SELECT path FROM graw_tips`)
+}
+
+func (s *Store) Push(path string, values map[string]string) (int64, error) {
+	params, err := json.Marshal(values)
+	if err != nil {
+		return 0, err
+	}
+
+	result, err := s.db.Exec(
+		`INSERT INTO graw_outbox (path, params, created) VALUES (?, ?, ?)`,
+		path, string(params), time.Now().UnixNano(),
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.LastInsertId()
+}
+
+func (s *Store) Pending() ([]store.Item, error) {
+	rows, err := s.db.Query(
+		`SELECT id, path, params, created FROM graw_outbox ORDER BY id`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []store.Item
+	for rows.Next() {
+		var item store.Item
+		var params string
+		var created int64
+		if err := rows.Scan(&item.ID, &item.Path, &params, &created); err != nil {
+			return nil, err
+		}
+
+		if err := json.Unmarshal([]byte(params), &item.Values); err != nil {
+			return nil, err
+		}
+
+		item.Created = time.Unix(0, created)
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+func (s *Store) Ack(id int64) error {
+	_, err := s.db.Exec(`DELETE FROM graw_outbox WHERE id = ?`, id)
+	return err
+}
+
+func (s *Store) Session(key string) ([]byte, error) {
+	var data []byte
+	err := s.db.QueryRow(
+		`SELECT data FROM graw_sessions WHERE key = ?`, key,
+	).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return data, err
+}
+
+func (s *Store) SetSession(key string, data []byte) error {
+	_, err := s.db.Exec(
+		`INSERT OR REPLACE INTO graw_sessions (key, data) VALUES (?, ?)`,
+		key, data,
+	)
+	return err
+}
+
+func (s *Store) AddSample(name string, sample store.Sample) error {
+	_, err := s.db.Exec(
+		`INSERT INTO graw_history (name, time, score, comments, upvote_ratio)
+		VALUES (?, ?, ?, ?, ?)`,
+		name,
+		sample.Time.UnixNano(),
+		sample.Score,
+		sample.Comments,
+		sample.UpvoteRatio,
+	)
+	return err
+}
+
+func (s *Store) Samples(name string) ([]store.Sample, error) {
+	rows, err := s.db.Query(
+		`SELECT time, score, comments, upvote_ratio FROM graw_history
+		WHERE name = ? ORDER BY time`,
+		name,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var samples []store.Sample
+	for rows.Next() {
+		var sample store.Sample
+		var at int64
+		if err := rows.Scan(
+			&at,
+			&sample.Score,
+			&sample.Comments,
+			&sample.UpvoteRatio,
+		); err != nil {
+			return nil, err
+		}
+
+		sample.Time = time.Unix(0, at)
+		samples = append(samples, sample)
+	}
+
+	return samples, rows.Err()
+}
+
+func (s *Store) Record(a store.Action) (int64, error) {
+	params, err := json.Marshal(a.Values)
+	if err != nil {
+		return 0, err
+	}
+
+	result, err := s.db.Exec(
+		`INSERT INTO graw_audit
+		(time, path, target, params, trigger, result, err)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		a.Time.UnixNano(),
+		a.Path,
+		a.Target,
+		string(params),
+		a.Trigger,
+		a.Result,
+		a.Err,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.LastInsertId()
+}
+
+func (s *Store) Action(id int64) (*store.Action, error) {
+	actions, err := s.queryActions(
+		`SELECT id, time, path, target, params, trigger, result, err
+		FROM graw_audit WHERE id = ?`,
+		id,
+	)
+	if err != nil || len(actions) == 0 {
+		return nil, err
+	}
+	return &actions[0], nil
+}
+
+func (s *Store) Actions(since time.Time) ([]store.Action, error) {
+	return s.queryActions(
+		`SELECT id, time, path, target, params, trigger, result, err
+		FROM graw_audit WHERE time >= ? ORDER BY time, id`,
+		since.UnixNano(),
+	)
+}
+
+// queryActions returns the actions selected by the query, which must select
+// every column of graw_audit.
+func (s *Store) queryActions(
+	query string,
+	args ...interface{},
+) ([]store.Action, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var actions []store.Action
+	for rows.Next() {
+		var a store.Action
+		var at int64
+		var params string
+		if err := rows.Scan(
+			&a.ID,
+			&at,
+			&a.Path,
+			&a.Target,
+			&params,
+			&a.Trigger,
+			&a.Result,
+			&a.Err,
+		); err != nil {
+			return nil, err
+		}
+
+		if err := json.Unmarshal([]byte(params), &a.Values); err != nil {
+			return nil, err
+		}
+
+		a.Time = time.Unix(0, at)
+		actions = append(actions, a)
+	}
+
+	return actions, rows.Err()
+}
+
+func (s *Store) AddSighting(link string, sighting store.Sighting) error {
+	_, err := s.db.Exec(
+		`INSERT OR IGNORE INTO graw_links (link, post, subreddit, time)
+		VALUES (?, ?, ?, ?)`,
+		link,
+		sighting.Post,
+		sighting.Subreddit,
+		sighting.Time.UnixNano(),
+	)
+	return err
+}
+
+func (s *Store) Sightings(link string) ([]store.Sighting, error) {
+	rows, err := s.db.Query(
+		`SELECT post, subreddit, time FROM graw_links
+		WHERE link = ? ORDER BY time, post`,
+		link,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sightings []store.Sighting
+	for rows.Next() {
+		var sighting store.Sighting
+		var at int64
+		if err := rows.Scan(
+			&sighting.Post,
+			&sighting.Subreddit,
+			&at,
+		); err != nil {
+			return nil, err
+		}
+
+		sighting.Time = time.Unix(0, at)
+		sightings = append(sightings, sighting)
+	}
+
+	return sightings, rows.Err()
+}
+
+// column returns the values of the one column the query selects.
+func (s *Store) column(query string) ([]string, error) {
+	rows, err := s.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var values []string
+	for rows.Next() {
+		var v string
+		if err := rows.Scan(&v); err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+	}
+	return values, rows.Err()
+}
+
+func (s *Store) AddCredit(board string, c store.Credit) error {
+	_, err := s.db.Exec(
+		`INSERT OR REPLACE INTO graw_credits
+		(board, thing, author, subreddit, time, points)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		board,
+		c.Thing,
+		c.Author,
+		c.Subreddit,
+		c.Time.UnixNano(),
+		c.Points,
+	)
+	return err
+}
+
+func (s *Store) Credits(
+	board, subreddit string,
+	since time.Time,
+) ([]store.Credit, error) {
+	rows, err := s.db.Query(
+		`SELECT thing, author, subreddit, time, points FROM graw_credits
+		WHERE board = ? AND subreddit = ? AND time >= ?
+		ORDER BY time, thing`,
+		board,
+		subreddit,
+		since.UnixNano(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var credits []store.Credit
+	for rows.Next() {
+		var c store.Credit
+		var at int64
+		if err := rows.Scan(
+			&c.Thing,
+			&c.Author,
+			&c.Subreddit,
+			&at,
+			&c.Points,
+		); err != nil {
+			return nil, err
+		}
+
+		c.Time = time.Unix(0, at)
+		credits = append(credits, c)
+	}
+
+	return credits, rows.Err()
+}
+
+func (s *Store) ForgetCredits(before time.Time) error {
+	_, err := s.db.Exec(
+		`DELETE FROM graw_credits WHERE time < ?`,
+		before.UnixNano(),
+	)
+	return err
+}
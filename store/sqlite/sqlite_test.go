@@ -0,0 +1,68 @@
+//go:build sqlite
+
+// These tests need a SQLite driver, which needs cgo, so they only build with
+// the sqlite tag:
+//
+//	go test -tags sqlite ./store/sqlite
+
+package sqlite
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/turnage/graw/store/storetest"
+)
+
+// open returns a store in a fresh in memory database.
+func open(t *testing.T) (*Store, *sql.DB) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	// Each connection to :memory: is a database of its own.
+	db.SetMaxOpenConns(1)
+
+	st, err := New(db)
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	return st, db
+}
+
+func TestStore(t *testing.T) {
+	st, db := open(t)
+	defer db.Close()
+
+	storetest.Run(t, st)
+}
+
+func TestForgetSeen(t *testing.T) {
+	st, db := open(t)
+	defer db.Close()
+
+	if _, err := db.Exec(
+		`INSERT INTO graw_seen (name, seen_at) VALUES (?, ?)`,
+		"t3_old",
+		time.Now().Add(-48*time.Hour).Unix(),
+	); err != nil {
+		t.Fatalf("failed to insert old name: %v", err)
+	}
+	if err := st.MarkSeen("t3_new"); err != nil {
+		t.Fatalf("failed to mark seen: %v", err)
+	}
+
+	if err := st.ForgetSeen(time.Now().Add(-24 * time.Hour)); err != nil {
+		t.Fatalf("failed to forget seen names: %v", err)
+	}
+
+	names, err := st.SeenNames()
+	if err != nil {
+		t.Fatalf("failed to list seen names: %v", err)
+	}
+	if len(names) != 1 || names[0] != "t3_new" {
+		t.Errorf("got seen names %v; wanted only t3_new", names)
+	}
+}
@@ -0,0 +1,191 @@
+// Package store defines the state graw persists so bots can pick up where they
+// left off after a restart, and provides an in memory implementation.
+//
+// Durable implementations live in subpackages, such as graw/store/sqlite. All
+// implementations are goroutine safe.
+package store
+
+import (
+	"time"
+)
+
+// SeenSet records which Reddit things, by fullname (e.g. t3_5du939), a bot has
+// already handled.
+type SeenSet interface {
+	// Seen returns true if the name has been marked seen.
+	Seen(name string) (bool, error)
+	// MarkSeen marks the name seen.
+	MarkSeen(name string) error
+}
+
+// Tips records the reference points monitors use to track their place in
+// Reddit listings, by listing path.
+type Tips interface {
+	// Tips returns the tips saved for the listing path, youngest first, or
+	// nil if there are none.
+	Tips(path string) ([]string, error)
+	// SetTips replaces the tips saved for the listing path.
+	SetTips(path string, tips []string) error
+}
+
+// SeenLister is a SeenSet which can list its names, so its contents can be
+// copied to another store (see graw.ExportState). The stores in this package
+// and its subpackages are SeenListers.
+type SeenLister interface {
+	// SeenNames returns every name marked seen, in no particular order.
+	SeenNames() ([]string, error)
+}
+
+// TipsLister is a Tips store which can list the paths it has tips for.
+type TipsLister interface {
+	// TipPaths returns every listing path with saved tips, in no
+	// particular order.
+	TipPaths() ([]string, error)
+}
+
+// Item is a write to Reddit waiting in an outbox.
+type Item struct {
+	// ID is assigned by the outbox when the item is pushed.
+	ID int64
+	// Path is the API endpoint the write is made to, e.g. /api/comment.
+	Path string
+	// Values are the parameters of the write.
+	Values map[string]string
+	// Created is when the item was pushed.
+	Created time.Time
+}
+
+// Outbox queues writes to Reddit until they are acknowledged as sent, so that
+// writes are not lost if the bot stops before sending them.
+type Outbox interface {
+	// Push queues a write and returns its ID.
+	Push(path string, values map[string]string) (int64, error)
+	// Pending returns all writes which have not been acknowledged, oldest
+	// first.
+	Pending() ([]Item, error)
+	// Ack removes a write from the outbox.
+	Ack(id int64) error
+}
+
+// Sessions saves opaque session data, such as OAuth2 tokens, by key.
+type Sessions interface {
+	// Session returns the data saved under the key, or nil if there is
+	// none.
+	Session(key string) ([]byte, error)
+	// SetSession replaces the data saved under the key.
+	SetSession(key string, data []byte) error
+}
+
+// Action is a write a bot made to Reddit, recorded so its behavior can be
+// reviewed.
+type Action struct {
+	// ID is assigned by the audit log when the action is recorded.
+	ID int64
+	// Time is when the write was made.
+	Time time.Time
+	// Path is the API endpoint written to, e.g. /api/comment.
+	Path string
+	// Target is what the write acted on: the parent of a reply, the
+	// recipient of a message, the subreddit of a post, or the things
+	// marked read.
+	Target string
+	// Values are the parameters of the write.
+	Values map[string]string
+	// Trigger is the name of the thing whose event prompted the write, if
+	// it is known; see reddit.Account's Triggered.
+	Trigger string
+	// Result is the name of the thing the write created, if Reddit
+	// reported one.
+	Result string
+	// Err is why the write failed, or "" if it succeeded.
+	Err string
+}
+
+// Audit records the writes a bot makes, so moderators can review its behavior
+// and reconstruct incidents.
+type Audit interface {
+	// Record appends an action to the log and returns its ID.
+	Record(a Action) (int64, error)
+	// Action returns the action with the ID, or nil if there is none.
+	Action(id int64) (*Action, error)
+	// Actions returns the actions made at or after since, oldest first.
+	Actions(since time.Time) ([]Action, error)
+}
+
+// Sample is a snapshot of a post's standing at a point in time.
+type Sample struct {
+	Time        time.Time
+	Score       int32
+	Comments    int32
+	UpvoteRatio float64
+}
+
+// History records time series of samples of posts, by fullname, so analyses
+// like how fast a post rose can be run on them later.
+type History interface {
+	// AddSample appends a sample to the post's history.
+	AddSample(name string, s Sample) error
+	// Samples returns the post's history, oldest first, or nil if it has
+	// none.
+	Samples(name string) ([]Sample, error)
+}
+
+// Sighting is a post of a link.
+type Sighting struct {
+	// Post is the fullname of the post, e.g. t3_5du939.
+	Post      string
+	Subreddit string
+	// Time is when the post was made.
+	Time time.Time
+}
+
+// Links indexes posts by the links they share, so bots can find where else a
+// link was posted.
+type Links interface {
+	// AddSighting records a post of the link. Recording the same post of
+	// the link again has no effect.
+	AddSighting(link string, s Sighting) error
+	// Sightings returns the posts of the link, oldest first, or nil if it
+	// has none.
+	Sightings(link string) ([]Sighting, error)
+}
+
+// Credit is a post or comment counted for its author on a leaderboard.
+type Credit struct {
+	// Thing is the fullname of the post or comment, e.g. t3_5du939.
+	Thing     string
+	Author    string
+	Subreddit string
+	// Time is when the thing was made.
+	Time time.Time
+	// Points are what the thing counts for on the board, e.g. one for a
+	// post on a board of posters, or its awards on a board of awards.
+	Points int
+}
+
+// Leaderboards records the credits of authors on named boards, so they can be
+// ranked over any span of time later.
+type Leaderboards interface {
+	// AddCredit records the credit on the board, replacing the credit for
+	// the same thing on it, e.g. when a post is seen with more awards.
+	AddCredit(board string, c Credit) error
+	// Credits returns the credits on the board for things in the
+	// subreddit made at or after since, oldest first, or nil if there are
+	// none.
+	Credits(board, subreddit string, since time.Time) ([]Credit, error)
+	// ForgetCredits removes the credits on every board for things made
+	// before the time.
+	ForgetCredits(before time.Time) error
+}
+
+// Store is the complete set of state graw persists.
+type Store interface {
+	SeenSet
+	Tips
+	Outbox
+	Sessions
+	History
+	Audit
+	Links
+	Leaderboards
+}
@@ -0,0 +1,361 @@
+// Package storetest checks that implementations of graw/store behave as graw
+// expects.
+package storetest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/turnage/graw/store"
+)
+
+// Run checks the behavior of an empty store.
+func Run(t *testing.T, s store.Store) {
+	t.Run("SeenSet", func(t *testing.T) { testSeenSet(t, s) })
+	t.Run("Tips", func(t *testing.T) { testTips(t, s) })
+	t.Run("Outbox", func(t *testing.T) { testOutbox(t, s) })
+	t.Run("Sessions", func(t *testing.T) { testSessions(t, s) })
+	t.Run("History", func(t *testing.T) { testHistory(t, s) })
+	t.Run("Audit", func(t *testing.T) { testAudit(t, s) })
+	t.Run("Links", func(t *testing.T) { testLinks(t, s) })
+	t.Run("Leaderboards", func(t *testing.T) { testLeaderboards(t, s) })
+	t.Run("Listers", func(t *testing.T) { testListers(t, s) })
+}
+
+// testListers checks the store lists what the SeenSet and Tips tests left in
+// it, if it can list them.
+func testListers(t *testing.T, s store.Store) {
+	if l, ok := s.(store.SeenLister); ok {
+		names, err := l.SeenNames()
+		if err != nil {
+			t.Fatalf("error listing seen names: %v", err)
+		}
+		if len(names) != 1 || names[0] != "t3_a" {
+			t.Errorf("got seen names %v; wanted [t3_a]", names)
+		}
+	}
+
+	if l, ok := s.(store.TipsLister); ok {
+		paths, err := l.TipPaths()
+		if err != nil {
+			t.Fatalf("error listing tip paths: %v", err)
+		}
+		if len(paths) != 1 || paths[0] != "/r/golang/new" {
+			t.Errorf("got tip paths %v; wanted [/r/golang/new]", paths)
+		}
+	}
+}
+
+func testSeenSet(t *testing.T, s store.SeenSet) {
+	if seen, err := s.Seen("t3_a"); err != nil || seen {
+		t.Errorf("new name seen: %v, %v", seen, err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := s.MarkSeen("t3_a"); err != nil {
+			t.Fatalf("error marking seen: %v", err)
+		}
+	}
+
+	if seen, err := s.Seen("t3_a"); err != nil || !seen {
+		t.Errorf("marked name not seen: %v, %v", seen, err)
+	}
+}
+
+func testTips(t *testing.T, s store.Tips) {
+	if tips, err := s.Tips("/r/golang/new"); err != nil || len(tips) != 0 {
+		t.Errorf("unexpected tips for new path: %v, %v", tips, err)
+	}
+
+	for _, want := range [][]string{{"t3_b", "t3_a"}, {"t3_c"}} {
+		if err := s.SetTips("/r/golang/new", want); err != nil {
+			t.Fatalf("error setting tips: %v", err)
+		}
+
+		tips, err := s.Tips("/r/golang/new")
+		if err != nil {
+			t.Fatalf("error getting tips: %v", err)
+		}
+
+		if len(tips) != len(want) {
+			t.Fatalf("got tips %v; wanted %v", tips, want)
+		}
+		for i := range want {
+			if tips[i] != want[i] {
+				t.Errorf("got tips %v; wanted %v", tips, want)
+			}
+		}
+	}
+}
+
+func testOutbox(t *testing.T, s store.Outbox) {
+	first, err := s.Push("/api/comment", map[string]string{"text": "1"})
+	if err != nil {
+		t.Fatalf("error pushing: %v", err)
+	}
+
+	second, err := s.Push("/api/compose", map[string]string{"text": "2"})
+	if err != nil {
+		t.Fatalf("error pushing: %v", err)
+	}
+
+	items, err := s.Pending()
+	if err != nil {
+		t.Fatalf("error listing pending: %v", err)
+	}
+
+	if len(items) != 2 || items[0].ID != first || items[1].ID != second {
+		t.Fatalf("pending items incorrect: %+v", items)
+	}
+
+	if items[0].Path != "/api/comment" || items[0].Values["text"] != "1" {
+		t.Errorf("first item incorrect: %+v", items[0])
+	}
+
+	if err := s.Ack(first); err != nil {
+		t.Fatalf("error acknowledging: %v", err)
+	}
+
+	if items, err := s.Pending(); err != nil || len(items) != 1 {
+		t.Errorf("acknowledged item still pending: %+v, %v", items, err)
+	}
+}
+
+func testSessions(t *testing.T, s store.Sessions) {
+	if data, err := s.Session("bot"); err != nil || data != nil {
+		t.Errorf("unexpected data for new key: %s, %v", data, err)
+	}
+
+	if err := s.SetSession("bot", []byte("token")); err != nil {
+		t.Fatalf("error saving session: %v", err)
+	}
+
+	if data, err := s.Session("bot"); err != nil || string(data) != "token" {
+		t.Errorf("got %s, %v; wanted token", data, err)
+	}
+}
+
+func testHistory(t *testing.T, s store.History) {
+	if samples, err := s.Samples("t3_a"); err != nil || len(samples) != 0 {
+		t.Errorf("unexpected samples for new post: %v, %v", samples, err)
+	}
+
+	start := time.Unix(1500000000, 0)
+	want := []store.Sample{
+		{Time: start, Score: 1, Comments: 0, UpvoteRatio: 1},
+		{Time: start.Add(time.Minute), Score: 10, Comments: 3, UpvoteRatio: 0.9},
+	}
+	for _, sample := range want {
+		if err := s.AddSample("t3_a", sample); err != nil {
+			t.Fatalf("error adding sample: %v", err)
+		}
+	}
+
+	samples, err := s.Samples("t3_a")
+	if err != nil {
+		t.Fatalf("error getting samples: %v", err)
+	}
+
+	if len(samples) != len(want) {
+		t.Fatalf("got samples %+v; wanted %+v", samples, want)
+	}
+	for i := range want {
+		if !samples[i].Time.Equal(want[i].Time) ||
+			samples[i].Score != want[i].Score ||
+			samples[i].Comments != want[i].Comments ||
+			samples[i].UpvoteRatio != want[i].UpvoteRatio {
+			t.Errorf("got sample %+v; wanted %+v", samples[i], want[i])
+		}
+	}
+}
+
+func testAudit(t *testing.T, s store.Audit) {
+	start := time.Unix(1500000000, 0)
+	if actions, err := s.Actions(start); err != nil || len(actions) != 0 {
+		t.Errorf("unexpected actions in new log: %v, %v", actions, err)
+	}
+
+	want := []store.Action{
+		{
+			Time:    start,
+			Path:    "/api/comment",
+			Target:  "t1_a",
+			Values:  map[string]string{"thing_id": "t1_a", "text": "hi"},
+			Trigger: "t1_a",
+			Result:  "t1_b",
+		},
+		{
+			Time:   start.Add(time.Minute),
+			Path:   "/api/compose",
+			Target: "spez",
+			Values: map[string]string{"to": "spez"},
+			Err:    "forbidden",
+		},
+	}
+	var ids []int64
+	for _, a := range want {
+		id, err := s.Record(a)
+		if err != nil {
+			t.Fatalf("error recording action: %v", err)
+		}
+		ids = append(ids, id)
+	}
+
+	actions, err := s.Actions(start)
+	if err != nil {
+		t.Fatalf("error getting actions: %v", err)
+	}
+
+	if len(actions) != len(want) {
+		t.Fatalf("got actions %+v; wanted %+v", actions, want)
+	}
+	for i := range want {
+		if !actions[i].Time.Equal(want[i].Time) ||
+			actions[i].Path != want[i].Path ||
+			actions[i].Target != want[i].Target ||
+			actions[i].Values["text"] != want[i].Values["text"] ||
+			actions[i].ID != ids[i] ||
+			actions[i].Trigger != want[i].Trigger ||
+			actions[i].Result != want[i].Result ||
+			actions[i].Err != want[i].Err {
+			t.Errorf("got action %+v; wanted %+v", actions[i], want[i])
+		}
+	}
+
+	if actions, err := s.Actions(start.Add(time.Second)); err != nil ||
+		len(actions) != 1 {
+		t.Errorf("got %+v, %v; wanted only the later action", actions, err)
+	}
+
+	if a, err := s.Action(ids[1]); err != nil || a == nil ||
+		a.Path != "/api/compose" {
+		t.Errorf("got %+v, %v; wanted the second action", a, err)
+	}
+	if a, err := s.Action(ids[1] + 100); err != nil || a != nil {
+		t.Errorf("got %+v, %v for unknown action; wanted nil", a, err)
+	}
+}
+
+func testLinks(t *testing.T, s store.Links) {
+	link := "example.com/a"
+	if sightings, err := s.Sightings(link); err != nil || len(sightings) != 0 {
+		t.Errorf("unexpected sightings of new link: %v, %v", sightings, err)
+	}
+
+	start := time.Unix(1500000000, 0)
+	for _, sighting := range []store.Sighting{
+		{Post: "t3_b", Subreddit: "golang", Time: start.Add(time.Minute)},
+		{Post: "t3_a", Subreddit: "programming", Time: start},
+		// Posts seen again are not recorded twice.
+		{Post: "t3_b", Subreddit: "golang", Time: start.Add(time.Minute)},
+	} {
+		if err := s.AddSighting(link, sighting); err != nil {
+			t.Fatalf("error adding sighting: %v", err)
+		}
+	}
+	if err := s.AddSighting("example.com/b", store.Sighting{
+		Post: "t3_c",
+		Time: start,
+	}); err != nil {
+		t.Fatalf("error adding sighting: %v", err)
+	}
+
+	sightings, err := s.Sightings(link)
+	if err != nil {
+		t.Fatalf("error getting sightings: %v", err)
+	}
+	if len(sightings) != 2 ||
+		sightings[0].Post != "t3_a" ||
+		sightings[0].Subreddit != "programming" ||
+		!sightings[0].Time.Equal(start) ||
+		sightings[1].Post != "t3_b" {
+		t.Errorf("got sightings %+v; wanted t3_a then t3_b", sightings)
+	}
+}
+
+func testLeaderboards(t *testing.T, s store.Leaderboards) {
+	start := time.Unix(1500000000, 0)
+	credits, err := s.Credits("posters", "golang", start)
+	if err != nil || len(credits) != 0 {
+		t.Errorf("unexpected credits on new board: %v, %v", credits, err)
+	}
+
+	for _, add := range []struct {
+		board string
+		c     store.Credit
+	}{
+		{"awarded", store.Credit{
+			Thing:     "t3_b",
+			Author:    "gopher",
+			Subreddit: "golang",
+			Time:      start.Add(time.Minute),
+			Points:    1,
+		}},
+		{"awarded", store.Credit{
+			Thing:     "t3_a",
+			Author:    "rustacean",
+			Subreddit: "golang",
+			Time:      start,
+			Points:    2,
+		}},
+		// Credits for the same thing replace the last.
+		{"awarded", store.Credit{
+			Thing:     "t3_b",
+			Author:    "gopher",
+			Subreddit: "golang",
+			Time:      start.Add(time.Minute),
+			Points:    3,
+		}},
+		{"awarded", store.Credit{
+			Thing:     "t3_old",
+			Subreddit: "golang",
+			Time:      start.Add(-time.Hour),
+			Points:    1,
+		}},
+		{"awarded", store.Credit{
+			Thing:     "t3_c",
+			Subreddit: "programming",
+			Time:      start,
+			Points:    1,
+		}},
+		{"posters", store.Credit{
+			Thing:     "t3_b",
+			Subreddit: "golang",
+			Time:      start.Add(time.Minute),
+			Points:    1,
+		}},
+	} {
+		if err := s.AddCredit(add.board, add.c); err != nil {
+			t.Fatalf("error adding credit: %v", err)
+		}
+	}
+
+	credits, err = s.Credits("awarded", "golang", start)
+	if err != nil {
+		t.Fatalf("error getting credits: %v", err)
+	}
+	if len(credits) != 2 ||
+		credits[0].Thing != "t3_a" ||
+		credits[0].Author != "rustacean" ||
+		credits[0].Points != 2 ||
+		!credits[0].Time.Equal(start) ||
+		credits[1].Thing != "t3_b" ||
+		credits[1].Points != 3 {
+		t.Errorf("got credits %+v; wanted t3_a then t3_b", credits)
+	}
+
+	if err := s.ForgetCredits(start); err != nil {
+		t.Fatalf("error forgetting credits: %v", err)
+	}
+	credits, err = s.Credits("awarded", "golang", time.Time{})
+	if err != nil {
+		t.Fatalf("error getting credits: %v", err)
+	}
+	if len(credits) != 2 {
+		t.Errorf("got credits %+v after forgetting old credits", credits)
+	}
+	credits, err = s.Credits("posters", "golang", time.Time{})
+	if err != nil || len(credits) != 1 {
+		t.Errorf("got credits %+v, %v on another board", credits, err)
+	}
+}
@@ -0,0 +1,219 @@
+package streams
+
+import (
+	"sync"
+
+	"github.com/turnage/graw/reddit"
+)
+
+// DropPolicy is what a subscription does with an event when its buffer is
+// full.
+type DropPolicy int
+
+const (
+	// Block waits for the subscriber to make room. A subscriber which
+	// falls behind holds back every other subscriber of the broadcaster.
+	Block DropPolicy = iota
+	// DropNewest discards the new event.
+	DropNewest
+	// DropOldest discards the oldest buffered event to make room for the
+	// new one.
+	DropOldest
+)
+
+// Subscription is one consumer's copy of a broadcast stream.
+type Subscription struct {
+	// C carries the subscriber's events. It is closed when the
+	// subscription is cancelled or the broadcast stream ends.
+	C <-chan Event
+
+	c       chan Event
+	policy  DropPolicy
+	dropped uint64
+	closed  bool
+	done    chan struct{}
+	once    *sync.Once
+	mu      *sync.Mutex
+}
+
+// Dropped returns how many events the subscription has discarded because its
+// buffer was full.
+func (s *Subscription) Dropped() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.dropped
+}
+
+// Cancel stops the subscription and closes its channel.
+func (s *Subscription) Cancel() {
+	s.once.Do(func() {
+		close(s.done)
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.closed = true
+		close(s.c)
+	})
+}
+
+// send delivers the event according to the subscription's policy. It holds
+// the lock while it blocks so Cancel cannot close the channel under it;
+// Cancel closes done first to unblock it.
+func (s *Subscription) send(e Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+
+	switch s.policy {
+	case Block:
+		select {
+		case s.c <- e:
+		case <-s.done:
+		}
+		return
+	case DropOldest:
+		select {
+		case s.c <- e:
+			return
+		default:
+		}
+		// Only the broadcaster sends, so once one event is taken
+		// there is room for this one.
+		select {
+		case <-s.c:
+			s.dropped++
+		default:
+		}
+	}
+
+	select {
+	case s.c <- e:
+	default:
+		s.dropped++
+	}
+}
+
+// Broadcaster duplicates one stream of events to any number of subscribers,
+// each with its own buffer and drop policy, so e.g. a logger, a responder,
+// and an archiver can all consume the same stream without coordinating.
+type Broadcaster struct {
+	subs  map[*Subscription]bool
+	ended bool
+	mu    *sync.Mutex
+}
+
+// Broadcast starts duplicating the events to subscribers. Events sent before
+// a subscriber subscribes are not sent to it. When the events channel is
+// closed, so are the channels of all subscriptions.
+func Broadcast(events <-chan Event) *Broadcaster {
+	b := &Broadcaster{
+		subs: make(map[*Subscription]bool),
+		mu:   &sync.Mutex{},
+	}
+	go b.run(events)
+	return b
+}
+
+// Subscribe returns a new subscription which buffers up to buffer events,
+// applying the policy when its buffer is full.
+func (b *Broadcaster) Subscribe(buffer int, policy DropPolicy) *Subscription {
+	c := make(chan Event, buffer)
+	s := &Subscription{
+		C:      c,
+		c:      c,
+		policy: policy,
+		done:   make(chan struct{}),
+		once:   &sync.Once{},
+		mu:     &sync.Mutex{},
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.ended {
+		s.Cancel()
+	} else {
+		b.subs[s] = true
+	}
+	return s
+}
+
+func (b *Broadcaster) run(events <-chan Event) {
+	for e := range events {
+		for _, s := range b.subscribers() {
+			s.send(e)
+		}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.ended = true
+	for s := range b.subs {
+		s.Cancel()
+	}
+}
+
+// subscribers returns the live subscriptions, forgetting cancelled ones.
+func (b *Broadcaster) subscribers() []*Subscription {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var live []*Subscription
+	for s := range b.subs {
+		select {
+		case <-s.done:
+			delete(b.subs, s)
+		default:
+			live = append(live, s)
+		}
+	}
+	return live
+}
+
+// Events merges streams of posts, comments, and messages into one stream of
+// events, e.g. for a broadcaster. Any of the streams may be nil. The events
+// channel is closed when all of the streams are.
+func Events(
+	posts <-chan *reddit.Post,
+	comments <-chan *reddit.Comment,
+	messages <-chan *reddit.Message,
+) <-chan Event {
+	events := make(chan Event)
+	wg := &sync.WaitGroup{}
+
+	if posts != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for p := range posts {
+				events <- Event{Post: p}
+			}
+		}()
+	}
+	if comments != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for c := range comments {
+				events <- Event{Comment: c}
+			}
+		}()
+	}
+	if messages != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for m := range messages {
+				events <- Event{Message: m}
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(events)
+	}()
+
+	return events
+}
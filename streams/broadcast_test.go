@@ -0,0 +1,89 @@
+package streams
+
+import (
+	"testing"
+	"time"
+
+	"github.com/turnage/graw/reddit"
+)
+
+func TestBroadcast(t *testing.T) {
+	events := make(chan Event)
+	b := Broadcast(events)
+
+	all := b.Subscribe(0, Block)
+	newest := b.Subscribe(2, DropNewest)
+	oldest := b.Subscribe(2, DropOldest)
+	cancelled := b.Subscribe(0, Block)
+	cancelled.Cancel()
+
+	received := make(chan []string)
+	go func() {
+		var names []string
+		for e := range all.C {
+			names = append(names, e.Post.Name)
+		}
+		received <- names
+	}()
+
+	for _, name := range []string{"a", "b", "c", "d"} {
+		events <- Event{Post: &reddit.Post{Name: name}}
+	}
+	close(events)
+
+	select {
+	case names := <-received:
+		if len(names) != 4 {
+			t.Errorf("blocking subscriber got %v; wanted all four", names)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("blocking subscriber's channel was not closed")
+	}
+
+	for _, test := range []struct {
+		name  string
+		sub   *Subscription
+		names []string
+	}{
+		{"newest", newest, []string{"a", "b"}},
+		{"oldest", oldest, []string{"c", "d"}},
+	} {
+		var names []string
+		for e := range test.sub.C {
+			names = append(names, e.Post.Name)
+		}
+
+		if len(names) != 2 ||
+			names[0] != test.names[0] ||
+			names[1] != test.names[1] {
+			t.Errorf("%s: got %v; wanted %v", test.name, names, test.names)
+		}
+		if test.sub.Dropped() != 2 {
+			t.Errorf("%s: dropped %d; wanted 2", test.name, test.sub.Dropped())
+		}
+	}
+
+	if _, ok := <-cancelled.C; ok {
+		t.Errorf("cancelled subscription received an event")
+	}
+	if _, ok := <-b.Subscribe(1, Block).C; ok {
+		t.Errorf("subscription after the end received an event")
+	}
+}
+
+func TestEvents(t *testing.T) {
+	posts := make(chan *reddit.Post, 1)
+	messages := make(chan *reddit.Message, 1)
+	posts <- &reddit.Post{}
+	messages <- &reddit.Message{}
+	close(posts)
+	close(messages)
+
+	count := 0
+	for range Events(posts, nil, messages) {
+		count++
+	}
+	if count != 2 {
+		t.Errorf("got %d events; wanted 2", count)
+	}
+}
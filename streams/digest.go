@@ -0,0 +1,73 @@
+package streams
+
+import (
+	"time"
+
+	"github.com/turnage/graw/reddit"
+)
+
+// digestTop is the number of posts in a digest if Digest is not told.
+const digestTop = 10
+
+// Digest returns a stream of the top posts of a listing, sent once every
+// interval, e.g. the top 10 of /r/golang/top over the past day, every day, for
+// bots which post digests. t is the time range top and controversial listings
+// are ranked over, one of the reddit.Time constants. Stickied posts are pinned
+// rather than ranked, so they are skipped.
+//
+// top is 10 by default and capped at 1000. Each digest costs a request per 100 posts in it.
+//
+// The first digest is sent one interval after the stream starts.
+func Digest(
+	scanner reddit.Scanner,
+	kill <-chan bool,
+	errs chan<- error,
+	path string,
+	t string,
+	top int,
+	every time.Duration,
+) <-chan []*reddit.Post {
+	if top <= 0 {
+		top = digestTop
+	} else if top > maxRankTop {
+		top = maxRankTop
+	}
+
+	digests := make(chan []*reddit.Post)
+	go func() {
+		for {
+			select {
+			case <-kill:
+				close(digests)
+				return
+			case <-time.After(every):
+				if posts, err := topPosts(
+					scanner,
+					path,
+					t,
+					top,
+				); err != nil {
+					errs <- err
+				} else {
+					digests <- unstickied(posts, top)
+				}
+			}
+		}
+	}()
+
+	return digests
+}
+
+// unstickied returns the first top posts which are not stickied.
+func unstickied(posts []*reddit.Post, top int) []*reddit.Post {
+	var ranked []*reddit.Post
+	for _, p := range posts {
+		if len(ranked) == top {
+			break
+		}
+		if !p.Stickied {
+			ranked = append(ranked, p)
+		}
+	}
+	return ranked
+}
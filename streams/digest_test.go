@@ -0,0 +1,55 @@
+package streams
+
+import (
+	"testing"
+	"time"
+
+	"github.com/turnage/graw/reddit"
+)
+
+func TestDigest(t *testing.T) {
+	posts := append(
+		[]*reddit.Post{{Name: "s", Stickied: true}},
+		postsNamed("a", "b", "c")...,
+	)
+	s := &pagedScanner{posts: posts}
+	kill := make(chan bool)
+	errs := make(chan error)
+	digests := Digest(
+		s,
+		kill,
+		errs,
+		"/r/golang/top",
+		reddit.TimeWeek,
+		2,
+		time.Millisecond,
+	)
+
+	select {
+	case digest := <-digests:
+		if len(digest) != 2 ||
+			digest[0].Name != "a" ||
+			digest[1].Name != "b" {
+			t.Errorf("got digest %v; wanted a and b", postNames(digest))
+		}
+	case err := <-errs:
+		t.Fatalf("error making digest: %v", err)
+	case <-time.After(time.Second):
+		t.Fatalf("no digest was sent")
+	}
+	close(kill)
+	for range digests {
+	}
+
+	if s.time != reddit.TimeWeek {
+		t.Errorf("got time range %q; wanted %q", s.time, reddit.TimeWeek)
+	}
+}
+
+func postNames(posts []*reddit.Post) []string {
+	var names []string
+	for _, p := range posts {
+		names = append(names, p.Name)
+	}
+	return names
+}
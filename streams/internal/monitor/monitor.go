@@ -27,6 +27,9 @@ type Monitor interface {
 	// Update will check for new events, and send them to the Monitor's
 	// handlers.
 	Update() (reddit.Harvest, error)
+	// Tips returns the monitor's place in the listing, youngest first. A
+	// monitor configured with them resumes from there.
+	Tips() []string
 }
 
 // Config configures a monitor.
@@ -39,6 +42,11 @@ type Config struct {
 
 	// Sorter sorts the monitor's new listing elements.
 	Sorter rsort.Sorter
+
+	// Tips, if set, are the place in the listing to resume from, as
+	// returned by an earlier monitor's Tips. Otherwise the monitor starts
+	// at the current tip of the listing.
+	Tips []string
 }
 
 type monitor struct {
@@ -66,6 +74,11 @@ func New(c Config) (Monitor, error) {
 		sorter:         c.Sorter,
 	}
 
+	if len(c.Tips) > 0 {
+		m.tip = append([]string{}, c.Tips...)
+		return m, nil
+	}
+
 	if err := m.sync(); err != nil {
 		return nil, err
 	}
@@ -73,6 +86,11 @@ func New(c Config) (Monitor, error) {
 	return m, nil
 }
 
+// Tips returns the monitor's place in the listing, youngest first.
+func (m *monitor) Tips() []string {
+	return append([]string{}, m.tip...)
+}
+
 // Update checks for new content at the monitored listing endpoint and forwards
 // new content to the bot for processing.
 func (m *monitor) Update() (reddit.Harvest, error) {
@@ -18,6 +18,10 @@ func (m *mockScanner) ListingWithParams(_ string, _ map[string]string) (reddit.H
 	return reddit.Harvest{}, nil
 }
 
+func (m *mockScanner) ListingWithOptions(_ string, _ reddit.ListingOptions) (reddit.Harvest, error) {
+	return reddit.Harvest{}, nil
+}
+
 type mockSorter struct {
 	names []string
 }
@@ -43,6 +47,21 @@ func TestNew(t *testing.T) {
 	if len(impl.tip) != len(names) || !reflect.DeepEqual(names, impl.tip) {
 		t.Errorf("tip wrongly filled; got %v", impl.tip)
 	}
+
+	saved := []string{"3", "4"}
+	m, err = New(
+		Config{
+			Scanner: &mockScanner{},
+			Sorter:  &mockSorter{names},
+			Tips:    saved,
+		},
+	)
+	if err != nil {
+		t.Errorf("error creating monitor: %v", err)
+	}
+	if !reflect.DeepEqual(m.Tips(), saved) {
+		t.Errorf("tip not resumed; got %v", m.Tips())
+	}
 }
 
 func TestShaveTip(t *testing.T) {
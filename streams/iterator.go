@@ -0,0 +1,241 @@
+package streams
+
+import (
+	"context"
+	"strings"
+
+	"github.com/turnage/graw/reddit"
+
+	"github.com/turnage/graw/streams/internal/monitor"
+)
+
+// Event is one new thing from a listing. Exactly one of its fields is set.
+type Event struct {
+	Post    *reddit.Post
+	Comment *reddit.Comment
+	Message *reddit.Message
+}
+
+// update is the result of one poll of a listing.
+type update struct {
+	h   reddit.Harvest
+	err error
+}
+
+// Iterator is a pull-based alternative to the channel streams: rather than
+// the stream pushing new things to a channel as fast as it finds them, the
+// caller asks for each one with Next, and the listing is only polled when the
+// caller has taken everything found so far. A slow caller holds the stream
+// back instead of piling things up.
+//
+// An Iterator can save its place in the listing as it goes, so a restarted
+// program resumes where it left off; see Checkpoint.
+//
+// An Iterator is not safe for concurrent use.
+type Iterator struct {
+	mon  monitor.Monitor
+	keep func(Event) bool
+
+	queue []Event
+	// polling carries the result of a poll still in flight when the
+	// context of the Next call which started it was done, so the next
+	// call picks it up rather than losing its things.
+	polling chan update
+
+	save func([]string) error
+	// tips is the place in the listing after the queued events, saved
+	// once they are all acknowledged.
+	tips []string
+	// last is the event last returned by Next, and owed is set while it
+	// is unacknowledged. Events left unacknowledged wait in retry to be
+	// returned again after the next poll; no checkpoint is saved while
+	// any do, since it would skip them.
+	last  Event
+	owed  bool
+	retry []Event
+}
+
+// Checkpoint makes the iterator save its place in the listing with save each
+// time every event from a poll has been acknowledged with Ack. Passing the
+// saved tips to ListingIterator resumes the listing from there, delivering
+// any events which were not acknowledged again: at least once.
+//
+// If Next is called again without acknowledging the event it last returned,
+// the event is returned again after the next poll, and no checkpoint is saved
+// until it is acknowledged, so it is redelivered after a restart too.
+func (it *Iterator) Checkpoint(save func(tips []string) error) {
+	it.save = save
+}
+
+// Ack acknowledges that the event last returned by Next has been handled. It
+// returns any error saving a checkpoint.
+func (it *Iterator) Ack() error {
+	it.owed = false
+	return it.checkpoint()
+}
+
+// checkpoint saves the iterator's place if everything before it has been
+// acknowledged.
+func (it *Iterator) checkpoint() error {
+	if it.save == nil || it.owed || len(it.retry) > 0 ||
+		len(it.queue) > 0 || it.tips == nil {
+		return nil
+	}
+
+	tips := it.tips
+	it.tips = nil
+	return it.save(tips)
+}
+
+// Next returns the next new thing in the listing, polling for more when none
+// are waiting. It returns the context's error if the context is done first.
+//
+// Like the errors on a stream's error channel, the errors Next returns may be
+// intermittent; the iterator can be used again after them.
+func (it *Iterator) Next(ctx context.Context) (Event, error) {
+	if it.owed {
+		it.owed = false
+		it.retry = append(it.retry, it.last)
+	}
+
+	for len(it.queue) == 0 {
+		if it.polling == nil {
+			it.polling = make(chan update, 1)
+			go func(polling chan<- update) {
+				h, err := it.mon.Update()
+				polling <- update{h, err}
+			}(it.polling)
+		}
+
+		select {
+		case <-ctx.Done():
+			return Event{}, ctx.Err()
+		case u := <-it.polling:
+			it.polling = nil
+			if u.err != nil {
+				return Event{}, u.err
+			}
+			if found := it.enqueue(u.h); found > 0 && it.save != nil {
+				it.tips = it.mon.Tips()
+			}
+			it.queue = append(it.retry, it.queue...)
+			it.retry = nil
+
+			// A poll whose events were all left out is done with.
+			if err := it.checkpoint(); err != nil {
+				return Event{}, err
+			}
+		}
+	}
+
+	e := it.queue[0]
+	it.queue = it.queue[1:]
+	it.last, it.owed = e, true
+	return e, nil
+}
+
+// enqueue queues the events the iterator keeps from the harvest, and returns
+// how many things the harvest held.
+func (it *Iterator) enqueue(h reddit.Harvest) int {
+	var events []Event
+	for _, p := range h.Posts {
+		events = append(events, Event{Post: p})
+	}
+	for _, c := range h.Comments {
+		events = append(events, Event{Comment: c})
+	}
+	for _, m := range h.Messages {
+		events = append(events, Event{Message: m})
+	}
+
+	for _, e := range events {
+		if it.keep == nil || it.keep(e) {
+			it.queue = append(it.queue, e)
+		}
+	}
+	return len(events)
+}
+
+// SubredditsIterator returns an iterator over new posts from the requested
+// subreddits. It is the pull-based equivalent of Subreddits.
+func SubredditsIterator(
+	scanner reddit.Scanner,
+	subreddits ...string,
+) (*Iterator, error) {
+	path := "/r/" + strings.Join(subreddits, "+") + "/new"
+	return iteratorFromPath(scanner, path, nil)
+}
+
+// SubredditCommentsIterator returns an iterator over new comments from the
+// requested subreddits. It is the pull-based equivalent of SubredditComments.
+func SubredditCommentsIterator(
+	scanner reddit.Scanner,
+	subreddits ...string,
+) (*Iterator, error) {
+	path := "/r/" + strings.Join(subreddits, "+") + "/comments"
+	return iteratorFromPath(scanner, path, nil)
+}
+
+// UserIterator returns an iterator over new posts and comments made by a
+// user. It is the pull-based equivalent of User.
+func UserIterator(scanner reddit.Scanner, user string) (*Iterator, error) {
+	return iteratorFromPath(scanner, "/u/"+user, nil)
+}
+
+// PostRepliesIterator returns an iterator over top level replies to posts
+// made by the bot's account. It is the pull-based equivalent of PostReplies.
+func PostRepliesIterator(bot reddit.Bot) (*Iterator, error) {
+	return iteratorFromPath(bot, "/message/selfreply", nil)
+}
+
+// CommentRepliesIterator returns an iterator over replies to comments made by
+// the bot's account. It is the pull-based equivalent of CommentReplies.
+func CommentRepliesIterator(bot reddit.Bot) (*Iterator, error) {
+	return iteratorFromPath(bot, "/message/comments", nil)
+}
+
+// MentionsIterator returns an iterator over mentions of the bot's username.
+// It is the pull-based equivalent of Mentions.
+func MentionsIterator(bot reddit.Bot) (*Iterator, error) {
+	return iteratorFromPath(bot, "/message/mentions", nil)
+}
+
+// MessagesIterator returns an iterator over messages sent to the bot's inbox.
+// It is the pull-based equivalent of Messages.
+func MessagesIterator(bot reddit.Bot) (*Iterator, error) {
+	return iteratorFromPath(
+		bot, "/message/inbox",
+		func(e Event) bool { return e.Message != nil && !e.Message.WasComment },
+	)
+}
+
+// ListingIterator returns an iterator over new things in any listing, by path
+// (e.g. /r/golang/new or /message/inbox). If tips saved by an earlier
+// iterator's Checkpoint are given, it resumes from there, so things which
+// arrived in between are delivered too. Otherwise it starts at the listing's
+// current tip.
+func ListingIterator(
+	scanner reddit.Scanner,
+	path string,
+	tips []string,
+) (*Iterator, error) {
+	mon, err := monitorFromPath(path, scanner, tips)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Iterator{mon: mon}, nil
+}
+
+func iteratorFromPath(
+	scanner reddit.Scanner,
+	path string,
+	keep func(Event) bool,
+) (*Iterator, error) {
+	mon, err := monitorFromPath(path, scanner, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Iterator{mon: mon, keep: keep}, nil
+}
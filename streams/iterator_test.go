@@ -0,0 +1,150 @@
+package streams
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/turnage/graw/reddit"
+)
+
+// gatedMonitor returns its harvests one update at a time, each only once it
+// is let through the gate.
+type gatedMonitor struct {
+	gate     chan bool
+	harvests []reddit.Harvest
+	errs     []error
+}
+
+func (g *gatedMonitor) Update() (reddit.Harvest, error) {
+	<-g.gate
+	h, err := g.harvests[0], g.errs[0]
+	g.harvests, g.errs = g.harvests[1:], g.errs[1:]
+	return h, err
+}
+
+func (g *gatedMonitor) Tips() []string {
+	return []string{fmt.Sprintf("tip%d", len(g.harvests))}
+}
+
+func TestIterator(t *testing.T) {
+	mon := &gatedMonitor{
+		gate: make(chan bool, 3),
+		harvests: []reddit.Harvest{
+			{},
+			{
+				Posts:    []*reddit.Post{{Name: "t3_a"}},
+				Comments: []*reddit.Comment{{Name: "t1_b"}},
+			},
+			{},
+		},
+		errs: []error{fmt.Errorf("busy"), nil, nil},
+	}
+	it := &Iterator{mon: mon}
+
+	// The first poll fails; the iterator reports it and can be used again.
+	mon.gate <- true
+	if _, err := it.Next(context.Background()); err == nil {
+		t.Fatalf("wanted the poll's error")
+	}
+
+	// The second poll is held back past the deadline. Its things are not
+	// lost; the next call picks them up.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := it.Next(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("got %v; wanted the deadline", err)
+	}
+
+	mon.gate <- true
+	e, err := it.Next(context.Background())
+	if err != nil || e.Post == nil || e.Post.Name != "t3_a" {
+		t.Fatalf("got %+v, %v; wanted t3_a", e, err)
+	}
+
+	// The comment was found by the same poll, so no poll is needed for it.
+	e, err = it.Next(context.Background())
+	if err != nil || e.Comment == nil || e.Comment.Name != "t1_b" {
+		t.Fatalf("got %+v, %v; wanted t1_b", e, err)
+	}
+	if len(mon.harvests) != 1 {
+		t.Errorf("iterator polled before its queue was empty")
+	}
+}
+
+func TestIteratorKeep(t *testing.T) {
+	mon := &gatedMonitor{
+		gate: make(chan bool, 1),
+		harvests: []reddit.Harvest{{
+			Messages: []*reddit.Message{
+				{Name: "t1_reply", WasComment: true},
+				{Name: "t4_pm"},
+			},
+		}},
+		errs: []error{nil},
+	}
+	it := &Iterator{
+		mon:  mon,
+		keep: func(e Event) bool { return !e.Message.WasComment },
+	}
+
+	mon.gate <- true
+	e, err := it.Next(context.Background())
+	if err != nil || e.Message.Name != "t4_pm" {
+		t.Errorf("got %+v, %v; wanted t4_pm", e, err)
+	}
+}
+
+func TestIteratorCheckpoint(t *testing.T) {
+	mon := &gatedMonitor{
+		gate: make(chan bool, 3),
+		harvests: []reddit.Harvest{
+			{Posts: []*reddit.Post{{Name: "t3_a"}, {Name: "t3_b"}}},
+			{Posts: []*reddit.Post{{Name: "t3_c"}}},
+			{Posts: []*reddit.Post{{Name: "t3_d"}}},
+		},
+		errs: []error{nil, nil, nil},
+	}
+	it := &Iterator{mon: mon}
+
+	var saved []string
+	it.Checkpoint(func(tips []string) error {
+		saved = append(saved, tips[0])
+		return nil
+	})
+
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		mon.gate <- true
+	}
+
+	// The first poll's checkpoint waits for both of its events.
+	it.Next(ctx)
+	it.Ack()
+	if len(saved) != 0 {
+		t.Errorf("saved %v before the poll was handled", saved)
+	}
+	it.Next(ctx)
+	it.Ack()
+	if len(saved) != 1 || saved[0] != "tip2" {
+		t.Errorf("saved %v; wanted tip2", saved)
+	}
+
+	// An event left unacknowledged is returned again after the next poll,
+	// and holds back checkpoints until it is acknowledged.
+	it.Next(ctx)
+	e, _ := it.Next(ctx)
+	if e.Post == nil || e.Post.Name != "t3_c" {
+		t.Errorf("got %+v; wanted t3_c again", e)
+	}
+	it.Ack()
+	if len(saved) != 1 {
+		t.Errorf("saved %v past an unacknowledged event", saved)
+	}
+	it.Next(ctx)
+	it.Ack()
+	if len(saved) != 2 || saved[1] != "tip0" {
+		t.Errorf("saved %v; wanted tip0 once t3_c was acknowledged", saved)
+	}
+}
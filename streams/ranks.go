@@ -0,0 +1,221 @@
+package streams
+
+import (
+	"github.com/turnage/graw/reddit"
+)
+
+const (
+	// rankPage is the most positions one listing request covers.
+	rankPage = 100
+	// maxRankTop is the most positions Reddit lists.
+	maxRankTop = 1000
+)
+
+// RankChange is a change in a post's position in a ranked listing, such as
+// /r/golang/hot. Positions count from 1.
+type RankChange struct {
+	Post *reddit.Post
+	// Rank is the post's new position, or 0 if it left the top positions.
+	Rank int
+	// Previous is the post's old position, or 0 if it entered the top
+	// positions.
+	Previous int
+}
+
+// Ranks returns a stream of posts entering and leaving the top positions of a
+// ranked listing, e.g. the top 10 of /r/golang/hot. If moves is true, posts
+// moving within the top positions are sent too. Stickied posts are pinned
+// rather than ranked, so they are skipped.
+//
+// top is 100 by default and capped at 1000. Each 100 positions watched consume one interval of
+// the handle.
+//
+// Posts already in the top positions when the stream starts are not sent.
+func Ranks(
+	scanner reddit.Scanner,
+	kill <-chan bool,
+	errs chan<- error,
+	path string,
+	top int,
+	moves bool,
+) (
+	<-chan RankChange,
+	error,
+) {
+	return RanksOver(scanner, kill, errs, path, "", top, moves)
+}
+
+// RanksOver is Ranks for a top or controversial listing ranked over a time
+// range, one of the reddit.Time constants, e.g. the top 10 of /r/golang/top
+// over the past week.
+func RanksOver(
+	scanner reddit.Scanner,
+	kill <-chan bool,
+	errs chan<- error,
+	path string,
+	t string,
+	top int,
+	moves bool,
+) (
+	<-chan RankChange,
+	error,
+) {
+	r := newRanker(scanner, path, t, top, moves)
+	if _, err := r.poll(); err != nil {
+		return nil, err
+	}
+
+	changes := make(chan RankChange)
+	go func() {
+		for {
+			select {
+			case <-kill:
+				close(changes)
+				return
+			default:
+				if fresh, err := r.poll(); err != nil {
+					errs <- err
+				} else {
+					for _, c := range fresh {
+						changes <- c
+					}
+				}
+			}
+		}
+	}()
+
+	return changes, nil
+}
+
+// ranker tracks the top positions of a ranked listing.
+type ranker struct {
+	scanner reddit.Scanner
+	path    string
+	top     int
+	moves   bool
+	ranks   map[string]int
+	posts   map[string]*reddit.Post
+	// t is the time range the listing is ranked over, if any.
+	t string
+}
+
+func newRanker(
+	scanner reddit.Scanner,
+	path string,
+	t string,
+	top int,
+	moves bool,
+) *ranker {
+	if top <= 0 {
+		top = rankPage
+	} else if top > maxRankTop {
+		top = maxRankTop
+	}
+
+	return &ranker{
+		scanner: scanner,
+		path:    path,
+		t:       t,
+		top:     top,
+		moves:   moves,
+		ranks:   make(map[string]int),
+		posts:   make(map[string]*reddit.Post),
+	}
+}
+
+// poll fetches the listing, a page at a time, and returns the changes since
+// the last poll.
+func (r *ranker) poll() ([]RankChange, error) {
+	posts, err := topPosts(r.scanner, r.path, r.t, r.top)
+	if err != nil {
+		return nil, err
+	}
+	return r.update(posts), nil
+}
+
+// topPosts fetches the first top posts of the listing, ranked over the time
+// range t if it is set, a page at a time.
+func topPosts(
+	scanner reddit.Scanner,
+	path string,
+	t string,
+	top int,
+) ([]*reddit.Post, error) {
+	var posts []*reddit.Post
+	after := ""
+	for len(posts) < top {
+		harvest, err := scanner.ListingWithOptions(
+			path,
+			reddit.ListingOptions{
+				After: after,
+				Limit: minInt(top-len(posts), rankPage),
+				Time:  t,
+			},
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		posts = append(posts, harvest.Posts...)
+		if len(harvest.Posts) < rankPage || top <= len(posts) {
+			break
+		}
+		after = harvest.Posts[len(harvest.Posts)-1].Name
+	}
+	return posts, nil
+}
+
+// update ranks the posts, in listing order, and returns the posts which
+// entered, left, or (if the ranker tracks moves) moved within the top
+// positions.
+func (r *ranker) update(posts []*reddit.Post) []RankChange {
+	ranks := make(map[string]int)
+	current := make(map[string]*reddit.Post)
+	for _, p := range posts {
+		if len(ranks) == r.top {
+			break
+		}
+
+		if p.Stickied {
+			continue
+		}
+
+		ranks[p.Name] = len(ranks) + 1
+		current[p.Name] = p
+	}
+
+	var changes []RankChange
+	for _, p := range posts {
+		rank, ok := ranks[p.Name]
+		if !ok {
+			continue
+		}
+
+		previous, ok := r.ranks[p.Name]
+		if !ok || (r.moves && previous != rank) {
+			changes = append(
+				changes,
+				RankChange{Post: p, Rank: rank, Previous: previous},
+			)
+		}
+	}
+
+	for name, previous := range r.ranks {
+		if _, ok := ranks[name]; !ok {
+			changes = append(
+				changes,
+				RankChange{Post: r.posts[name], Previous: previous},
+			)
+		}
+	}
+
+	r.ranks, r.posts = ranks, current
+	return changes
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
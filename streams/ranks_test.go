@@ -0,0 +1,118 @@
+package streams
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/turnage/graw/reddit"
+)
+
+func postsNamed(names ...string) []*reddit.Post {
+	var posts []*reddit.Post
+	for _, name := range names {
+		posts = append(posts, &reddit.Post{Name: name})
+	}
+	return posts
+}
+
+func TestRankerUpdate(t *testing.T) {
+	r := newRanker(nil, "/r/golang/hot", "", 2, false)
+	if changes := r.update(postsNamed("a", "b", "c")); len(changes) != 2 {
+		t.Errorf("got %d changes priming; wanted 2", len(changes))
+	}
+
+	sticky := &reddit.Post{Name: "s", Stickied: true}
+	changes := r.update(append([]*reddit.Post{sticky}, postsNamed("c", "a")...))
+
+	expected := map[string]RankChange{
+		"c": {Rank: 1},
+		"b": {Previous: 2},
+	}
+	if len(changes) != len(expected) {
+		t.Fatalf("got changes %+v; wanted %+v", changes, expected)
+	}
+
+	for _, c := range changes {
+		want, ok := expected[c.Post.Name]
+		if !ok || c.Rank != want.Rank || c.Previous != want.Previous {
+			t.Errorf("unexpected change %s: %+v", c.Post.Name, c)
+		}
+	}
+}
+
+func TestRankerMoves(t *testing.T) {
+	r := newRanker(nil, "/r/all", "", 3, true)
+	r.update(postsNamed("a", "b", "c"))
+
+	changes := r.update(postsNamed("b", "a", "c"))
+	if len(changes) != 2 {
+		t.Fatalf("got changes %+v; wanted a and b to swap", changes)
+	}
+
+	for _, c := range changes {
+		if c.Rank == c.Previous || c.Rank == 0 || c.Previous == 0 {
+			t.Errorf("unexpected change %s: %+v", c.Post.Name, c)
+		}
+	}
+}
+
+// pagedScanner serves a listing of numbered posts a page at a time.
+type pagedScanner struct {
+	posts []*reddit.Post
+	pages int
+	// time is the time range of the last page requested.
+	time string
+}
+
+func (p *pagedScanner) Listing(path, after string) (reddit.Harvest, error) {
+	return reddit.Harvest{}, nil
+}
+
+func (p *pagedScanner) ListingWithParams(
+	path string,
+	params map[string]string,
+) (reddit.Harvest, error) {
+	return reddit.Harvest{}, nil
+}
+
+func (p *pagedScanner) ListingWithOptions(
+	path string,
+	opts reddit.ListingOptions,
+) (reddit.Harvest, error) {
+	p.pages++
+	p.time = opts.Time
+	start := 0
+	for i, post := range p.posts {
+		if post.Name == opts.After {
+			start = i + 1
+		}
+	}
+
+	end := start + rankPage
+	if end > len(p.posts) {
+		end = len(p.posts)
+	}
+	return reddit.Harvest{Posts: p.posts[start:end]}, nil
+}
+
+func TestRankerPages(t *testing.T) {
+	var names []string
+	for i := 0; i < 300; i++ {
+		names = append(names, "t3_"+strconv.Itoa(i))
+	}
+
+	s := &pagedScanner{posts: postsNamed(names...)}
+	r := newRanker(s, "/r/all", "", 250, false)
+	changes, err := r.poll()
+	if err != nil {
+		t.Fatalf("error polling: %v", err)
+	}
+
+	if s.pages != 3 {
+		t.Errorf("fetched %d pages; wanted 3", s.pages)
+	}
+
+	if len(changes) != 250 || changes[249].Rank != 250 {
+		t.Errorf("got %d changes; wanted 250 ranked in order", len(changes))
+	}
+}
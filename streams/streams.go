@@ -25,6 +25,11 @@
 // invalid, that will be caught in the initial construction of the stream; you
 // don't need to worry about that on the error channel.
 //
+// Each listing stream also has an Iterator equivalent (e.g. SubredditsIterator
+// for Subreddits), which needs no control channels: the caller pulls each new
+// thing with Next, whose context bounds the wait and whose error reports what
+// the error channel would have.
+//
 // These streams will consume "intervals" of the Reddit handle given to them.
 // Since the reddit handlers are rate limited and do not allow bursts, there is
 // essentially a schedule on which they execute requests, and the executions
@@ -195,7 +200,7 @@ func streamFromPath(
 	<-chan *reddit.Message,
 	error,
 ) {
-	mon, err := monitorFromPath(path, scanner)
+	mon, err := monitorFromPath(path, scanner, nil)
 	if err != nil {
 		return nil, nil, nil, err
 	}
@@ -204,12 +209,17 @@ func streamFromPath(
 	return posts, comments, messages, nil
 }
 
-func monitorFromPath(path string, sc reddit.Scanner) (monitor.Monitor, error) {
+func monitorFromPath(
+	path string,
+	sc reddit.Scanner,
+	tips []string,
+) (monitor.Monitor, error) {
 	return monitor.New(
 		monitor.Config{
 			Path:    path,
 			Scanner: sc,
 			Sorter:  rsort.New(),
+			Tips:    tips,
 		},
 	)
 }
@@ -267,3 +277,81 @@ func flow(
 		}
 	}
 }
+
+// Thread returns a stream of changes in a thread, identified by its permalink
+// (e.g. /r/golang/comments/5du939): new comments, replies by its post's author,
+// awards given to its post or comments, and changes to its post's flair. It
+// consumes one interval of the handle. The options select which slice of the
+// thread is polled; comments outside it are not seen.
+//
+// Changes made before the stream starts are not sent.
+func Thread(
+	lurker reddit.Lurker,
+	kill <-chan bool,
+	errs chan<- error,
+	permalink string,
+	opts reddit.ThreadOptions,
+) (
+	<-chan ThreadEvent,
+	error,
+) {
+	t, err := newThreadWatch(lurker, permalink, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make(chan ThreadEvent)
+	go func() {
+		for {
+			select {
+			case <-kill:
+				close(events)
+				return
+			default:
+				if fresh, err := t.update(); err != nil {
+					errs <- err
+				} else {
+					for _, e := range fresh {
+						events <- e
+					}
+				}
+			}
+		}
+	}()
+
+	return events, nil
+}
+
+// ThreadComments returns a stream of new comments in a thread, identified by
+// its permalink (e.g. /r/golang/comments/5du939). It consumes one interval of
+// the handle. The options select which slice of the thread is polled; comments
+// outside it are not sent.
+//
+// Comments already in the thread when the stream starts are not sent.
+func ThreadComments(
+	lurker reddit.Lurker,
+	kill <-chan bool,
+	errs chan<- error,
+	permalink string,
+	opts reddit.ThreadOptions,
+) (
+	<-chan *reddit.Comment,
+	error,
+) {
+	events, err := Thread(lurker, kill, errs, permalink, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	comments := make(chan *reddit.Comment)
+	go func() {
+		defer close(comments)
+		for e := range events {
+			if e.Kind == ThreadComment {
+				comments <- e.Comment
+			}
+		}
+	}()
+
+	return comments, nil
+}
@@ -18,6 +18,8 @@ func (m *mockMonitor) Update() (reddit.Harvest, error) {
 	return m.h, m.err
 }
 
+func (m *mockMonitor) Tips() []string { return nil }
+
 func TestStream(t *testing.T) {
 	kill := make(chan bool)
 	errs := make(chan error)
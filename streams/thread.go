@@ -0,0 +1,268 @@
+package streams
+
+import (
+	"sort"
+
+	"github.com/turnage/graw/reddit"
+)
+
+// ThreadEventKind is the kind of a change in a watched thread.
+type ThreadEventKind int
+
+const (
+	// ThreadComment is a new comment in the thread.
+	ThreadComment ThreadEventKind = iota
+	// ThreadPostGilded is an award given to the thread's post.
+	ThreadPostGilded
+	// ThreadCommentGilded is an award given to a comment in the thread.
+	ThreadCommentGilded
+	// ThreadFlairChanged is a change to the link flair of the thread's
+	// post.
+	ThreadFlairChanged
+	// ThreadOPReply is a new comment in the thread by the author of its
+	// post. It is sent after the ThreadComment event for the comment.
+	ThreadOPReply
+	// ThreadPostDeleted is the thread's post being deleted by its author
+	// or removed.
+	ThreadPostDeleted
+	// ThreadCommentDeleted is a comment in the thread being deleted by its
+	// author or removed.
+	ThreadCommentDeleted
+)
+
+// ThreadEvent is a change found between polls of a watched thread.
+type ThreadEvent struct {
+	Kind ThreadEventKind
+	// Post is the thread's post as of the poll which found the change.
+	Post *reddit.Post
+	// Comment is the comment the change is about, if any.
+	Comment *reddit.Comment
+	// OldFlair is the post's link flair text before a flair change.
+	OldFlair string
+	// Removal is how the post or comment was taken down, in deletion
+	// events.
+	Removal reddit.Removal
+}
+
+// threadWatch tails a thread, tracking the newest comment seen in it as a
+// cursor. Only comments newer than the cursor are new, so the watch does not
+// need to remember every comment in the thread, and the thread can be polled
+// sorted by new with a small limit rather than downloaded whole.
+type threadWatch struct {
+	lurker    reddit.Lurker
+	permalink string
+	opts      reddit.ThreadOptions
+	// tip is the creation time of the newest comment seen.
+	tip uint64
+	// atTip are the names of comments seen created at the tip, since
+	// creation times only have a resolution of one second.
+	atTip map[string]bool
+	// post is the thread's post as of the last poll.
+	post *reddit.Post
+	// awards are the award counts of comments seen with awards.
+	awards map[string]int32
+	// gone are the names of comments seen deleted or removed.
+	gone map[string]bool
+}
+
+// newThreadWatch returns a watch on the thread with its cursor at the newest
+// comment currently in it. Unless the options set a sort, the thread is polled
+// sorted by new.
+func newThreadWatch(
+	lurker reddit.Lurker,
+	permalink string,
+	opts reddit.ThreadOptions,
+) (*threadWatch, error) {
+	if opts.Sort == "" {
+		opts.Sort = reddit.CommentSortNew
+	}
+
+	t := &threadWatch{
+		lurker:    lurker,
+		permalink: permalink,
+		opts:      opts,
+		atTip:     make(map[string]bool),
+		awards:    make(map[string]int32),
+		gone:      make(map[string]bool),
+	}
+
+	_, err := t.update()
+	return t, err
+}
+
+// update fetches the thread and returns the changes since the last poll.
+func (t *threadWatch) update() ([]ThreadEvent, error) {
+	post, err := t.lurker.ThreadWithOptions(t.permalink, t.opts)
+	if err != nil {
+		return nil, err
+	}
+
+	comments := flatten(post.Replies)
+	events := t.postChanges(post)
+	events = append(events, t.gildedComments(post, comments)...)
+	events = append(events, t.deletedComments(post, comments)...)
+	events = append(events, t.newComments(post, comments)...)
+	t.post = post
+	return events, nil
+}
+
+// postChanges returns changes to the post since the last poll.
+func (t *threadWatch) postChanges(post *reddit.Post) []ThreadEvent {
+	if t.post == nil {
+		return nil
+	}
+
+	var events []ThreadEvent
+	if post.Awards() > t.post.Awards() {
+		events = append(events, ThreadEvent{Kind: ThreadPostGilded, Post: post})
+	}
+	if post.LinkFlairText != t.post.LinkFlairText {
+		events = append(
+			events,
+			ThreadEvent{
+				Kind:     ThreadFlairChanged,
+				Post:     post,
+				OldFlair: t.post.LinkFlairText,
+			},
+		)
+	}
+	if removal := post.Removal(); removal != reddit.NotRemoved &&
+		t.post.Removal() == reddit.NotRemoved {
+		events = append(
+			events,
+			ThreadEvent{
+				Kind:    ThreadPostDeleted,
+				Post:    post,
+				Removal: removal,
+			},
+		)
+	}
+	return events
+}
+
+// gildedComments returns comments which received awards since the last poll.
+// Only comments in the polled slice of the thread are seen, and comments
+// without awards are not remembered, so an awarded comment the watch has no
+// record of is assumed to have had none if it is older than the cursor.
+func (t *threadWatch) gildedComments(
+	post *reddit.Post,
+	comments []*reddit.Comment,
+) []ThreadEvent {
+	var events []ThreadEvent
+	for _, c := range comments {
+		awards := c.Awards()
+		if awards == 0 {
+			continue
+		}
+
+		previous, ok := t.awards[c.Name]
+		if !ok && t.post != nil && t.seen(c) {
+			previous, ok = 0, true
+		}
+
+		if ok && awards > previous {
+			events = append(
+				events,
+				ThreadEvent{Kind: ThreadCommentGilded, Post: post, Comment: c},
+			)
+		}
+		t.awards[c.Name] = awards
+	}
+	return events
+}
+
+// deletedComments returns comments which were deleted or removed since the
+// last poll. As with awards, only comments in the polled slice of the thread
+// are seen, so a comment taken down which the watch has no record of is
+// assumed to have been up if it is older than the cursor. New comments which
+// are already gone are remembered, but only sent as new comments.
+func (t *threadWatch) deletedComments(
+	post *reddit.Post,
+	comments []*reddit.Comment,
+) []ThreadEvent {
+	var events []ThreadEvent
+	for _, c := range comments {
+		removal := c.Removal()
+		if removal == reddit.NotRemoved || t.gone[c.Name] {
+			continue
+		}
+
+		if t.post != nil && t.seen(c) {
+			events = append(
+				events,
+				ThreadEvent{
+					Kind:    ThreadCommentDeleted,
+					Post:    post,
+					Comment: c,
+					Removal: removal,
+				},
+			)
+		}
+		t.gone[c.Name] = true
+	}
+	return events
+}
+
+// seen returns true if the comment is at or behind the cursor.
+func (t *threadWatch) seen(c *reddit.Comment) bool {
+	return c.CreatedUTC < t.tip || (c.CreatedUTC == t.tip && t.atTip[c.Name])
+}
+
+// newComments returns comments newer than the cursor, oldest first, and moves
+// the cursor past them.
+func (t *threadWatch) newComments(
+	post *reddit.Post,
+	comments []*reddit.Comment,
+) []ThreadEvent {
+	var fresh []*reddit.Comment
+	for _, c := range comments {
+		if !t.seen(c) {
+			fresh = append(fresh, c)
+		}
+	}
+
+	sort.SliceStable(fresh, func(i, j int) bool {
+		return fresh[i].CreatedUTC < fresh[j].CreatedUTC
+	})
+
+	var events []ThreadEvent
+	for _, c := range fresh {
+		if c.CreatedUTC > t.tip {
+			t.tip = c.CreatedUTC
+			t.atTip = make(map[string]bool)
+		}
+		t.atTip[c.Name] = true
+
+		// Comments seen before the watch started are only cursor
+		// material; the first poll sends nothing.
+		if t.post != nil {
+			events = append(
+				events,
+				ThreadEvent{Kind: ThreadComment, Post: post, Comment: c},
+			)
+			if isOP(post, c) {
+				events = append(
+					events,
+					ThreadEvent{Kind: ThreadOPReply, Post: post, Comment: c},
+				)
+			}
+		}
+	}
+	return events
+}
+
+// isOP returns true if the comment was written by the author of the post.
+// Deleted accounts all share one placeholder name, so they never match.
+func isOP(post *reddit.Post, c *reddit.Comment) bool {
+	return c.Author != "" && c.Author != "[deleted]" && c.Author == post.Author
+}
+
+// flatten returns every comment in a comment tree.
+func flatten(tree []*reddit.Comment) []*reddit.Comment {
+	var comments []*reddit.Comment
+	for _, c := range tree {
+		comments = append(comments, c)
+		comments = append(comments, flatten(c.Replies)...)
+	}
+	return comments
+}
@@ -0,0 +1,291 @@
+package streams
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/turnage/graw/reddit"
+)
+
+// mockLurker returns its threads in order, repeating the last, and records
+// the options it was called with.
+type mockLurker struct {
+	reddit.Lurker
+	threads []*reddit.Post
+	opts    reddit.ThreadOptions
+}
+
+func (m *mockLurker) Thread(permalink string) (*reddit.Post, error) {
+	return m.ThreadWithOptions(permalink, reddit.ThreadOptions{})
+}
+
+func (m *mockLurker) ThreadWithOptions(
+	permalink string,
+	opts reddit.ThreadOptions,
+) (*reddit.Post, error) {
+	m.opts = opts
+	post := m.threads[0]
+	if len(m.threads) > 1 {
+		m.threads = m.threads[1:]
+	}
+	return post, nil
+}
+
+func TestThreadWatch(t *testing.T) {
+	l := &mockLurker{
+		threads: []*reddit.Post{
+			{Replies: []*reddit.Comment{
+				{Name: "t1_old", CreatedUTC: 1},
+			}},
+			{Replies: []*reddit.Comment{
+				{
+					Name:       "t1_old",
+					CreatedUTC: 1,
+					Replies: []*reddit.Comment{
+						{Name: "t1_newer", CreatedUTC: 3},
+					},
+				},
+				{Name: "t1_new", CreatedUTC: 2},
+			}},
+			{Replies: []*reddit.Comment{
+				{Name: "t1_newer", CreatedUTC: 3},
+				{Name: "t1_tied", CreatedUTC: 3},
+				// Older comments which fall back into the
+				// polled slice are not new.
+				{Name: "t1_older", CreatedUTC: 0},
+			}},
+		},
+	}
+
+	w, err := newThreadWatch(
+		l,
+		"/r/golang/comments/abc",
+		reddit.ThreadOptions{Limit: 10},
+	)
+	if err != nil {
+		t.Fatalf("error starting watch: %v", err)
+	}
+
+	if l.opts.Sort != reddit.CommentSortNew || l.opts.Limit != 10 {
+		t.Errorf("thread polled with %+v; wanted sort new, limit 10", l.opts)
+	}
+
+	for i, expected := range [][]string{
+		{"t1_new", "t1_newer"},
+		{"t1_tied"},
+		{},
+	} {
+		fresh, err := w.update()
+		if err != nil {
+			t.Fatalf("error updating watch: %v", err)
+		}
+
+		if len(fresh) != len(expected) {
+			t.Errorf("%d: got %d comments; wanted %v", i, len(fresh), expected)
+			continue
+		}
+
+		for j := range fresh {
+			if fresh[j].Kind != ThreadComment ||
+				fresh[j].Comment.Name != expected[j] {
+				t.Errorf("%d: got %+v; wanted %s", i, fresh[j], expected[j])
+			}
+		}
+	}
+}
+
+func TestThreadWatchGildings(t *testing.T) {
+	l := &mockLurker{
+		threads: []*reddit.Post{
+			{Replies: []*reddit.Comment{
+				{Name: "t1_a", CreatedUTC: 1, Gilded: 1},
+				{Name: "t1_b", CreatedUTC: 1},
+			}},
+			{
+				TotalAwardsReceived: 2,
+				Replies: []*reddit.Comment{
+					{Name: "t1_a", CreatedUTC: 1, TotalAwardsReceived: 2},
+					{Name: "t1_b", CreatedUTC: 1, Gilded: 1},
+				},
+			},
+		},
+	}
+
+	w, err := newThreadWatch(l, "/r/golang/comments/abc", reddit.ThreadOptions{})
+	if err != nil {
+		t.Fatalf("error starting watch: %v", err)
+	}
+
+	events, err := w.update()
+	if err != nil {
+		t.Fatalf("error updating watch: %v", err)
+	}
+
+	// t1_b had no awards before, so its count was never recorded, but a
+	// comment going from none to one is a gilding all the same.
+	gilded := make(map[string]bool)
+	postGilded := false
+	for _, e := range events {
+		switch e.Kind {
+		case ThreadPostGilded:
+			postGilded = true
+		case ThreadCommentGilded:
+			gilded[e.Comment.Name] = true
+		}
+	}
+
+	if !postGilded {
+		t.Errorf("post gilding not found in %+v", events)
+	}
+
+	if !gilded["t1_a"] || !gilded["t1_b"] {
+		t.Errorf("comment gildings not found in %+v", events)
+	}
+}
+
+func TestThreadWatchFlair(t *testing.T) {
+	l := &mockLurker{
+		threads: []*reddit.Post{
+			{LinkFlairText: "Unsolved"},
+			{LinkFlairText: "Unsolved"},
+			{LinkFlairText: "Solved"},
+		},
+	}
+
+	w, err := newThreadWatch(l, "/r/golang/comments/abc", reddit.ThreadOptions{})
+	if err != nil {
+		t.Fatalf("error starting watch: %v", err)
+	}
+
+	if events, _ := w.update(); len(events) != 0 {
+		t.Errorf("unexpected events with flair unchanged: %+v", events)
+	}
+
+	events, err := w.update()
+	if err != nil {
+		t.Fatalf("error updating watch: %v", err)
+	}
+
+	if len(events) != 1 ||
+		events[0].Kind != ThreadFlairChanged ||
+		events[0].OldFlair != "Unsolved" ||
+		events[0].Post.LinkFlairText != "Solved" {
+		t.Errorf("got %+v; wanted a change from Unsolved to Solved", events)
+	}
+}
+
+func TestThreadWatchDeletions(t *testing.T) {
+	l := &mockLurker{
+		threads: []*reddit.Post{
+			{Replies: []*reddit.Comment{
+				{Name: "t1_a", CreatedUTC: 1},
+				{Name: "t1_b", CreatedUTC: 1},
+				{Name: "t1_c", CreatedUTC: 1, Body: "[removed]"},
+			}},
+			{
+				RemovedByCategory: "moderator",
+				Replies: []*reddit.Comment{
+					{Name: "t1_a", CreatedUTC: 1, Body: "[deleted]"},
+					{Name: "t1_b", CreatedUTC: 1, BannedBy: "mod"},
+					{Name: "t1_c", CreatedUTC: 1, Body: "[removed]"},
+					{Name: "t1_d", CreatedUTC: 2, Body: "[deleted]"},
+				},
+			},
+			{
+				RemovedByCategory: "moderator",
+				Replies: []*reddit.Comment{
+					{Name: "t1_a", CreatedUTC: 1, Body: "[deleted]"},
+					{Name: "t1_d", CreatedUTC: 2, Body: "[deleted]"},
+				},
+			},
+		},
+	}
+
+	w, err := newThreadWatch(l, "/r/golang/comments/abc", reddit.ThreadOptions{})
+	if err != nil {
+		t.Fatalf("error starting watch: %v", err)
+	}
+
+	events, err := w.update()
+	if err != nil {
+		t.Fatalf("error updating watch: %v", err)
+	}
+
+	// t1_c was removed before the watch began, and t1_d was gone when it
+	// was first seen, so neither was seen taken down.
+	expected := []ThreadEvent{
+		{Kind: ThreadPostDeleted, Removal: reddit.RemovedByModerator},
+		{
+			Kind:    ThreadCommentDeleted,
+			Comment: &reddit.Comment{Name: "t1_a"},
+			Removal: reddit.DeletedByAuthor,
+		},
+		{
+			Kind:    ThreadCommentDeleted,
+			Comment: &reddit.Comment{Name: "t1_b"},
+			Removal: reddit.RemovedByModerator,
+		},
+		{Kind: ThreadComment, Comment: &reddit.Comment{Name: "t1_d"}},
+	}
+	if len(events) != len(expected) {
+		t.Fatalf("got %+v; wanted %+v", events, expected)
+	}
+	for i, e := range events {
+		want := expected[i]
+		if e.Kind != want.Kind || e.Removal != want.Removal ||
+			(want.Comment != nil && e.Comment.Name != want.Comment.Name) {
+			t.Errorf("%d: got %+v; wanted %+v", i, e, want)
+		}
+	}
+
+	if events, _ := w.update(); len(events) != 0 {
+		t.Errorf("got %+v for things already gone", events)
+	}
+}
+
+func TestThreadWatchOPReplies(t *testing.T) {
+	l := &mockLurker{
+		threads: []*reddit.Post{
+			{Author: "op"},
+			{
+				Author: "op",
+				Replies: []*reddit.Comment{
+					{Name: "a", Author: "other", CreatedUTC: 1},
+					{Name: "b", Author: "op", CreatedUTC: 2},
+				},
+			},
+			{
+				Author: "[deleted]",
+				Replies: []*reddit.Comment{
+					{Name: "c", Author: "[deleted]", CreatedUTC: 3},
+				},
+			},
+		},
+	}
+
+	w, err := newThreadWatch(l, "/r/golang/comments/abc", reddit.ThreadOptions{})
+	if err != nil {
+		t.Fatalf("error starting watch: %v", err)
+	}
+
+	events, err := w.update()
+	if err != nil {
+		t.Fatalf("error updating watch: %v", err)
+	}
+
+	var kinds []ThreadEventKind
+	for _, e := range events {
+		kinds = append(kinds, e.Kind)
+	}
+	expected := []ThreadEventKind{ThreadComment, ThreadComment, ThreadOPReply}
+	if !reflect.DeepEqual(kinds, expected) {
+		t.Errorf("got kinds %v; wanted %v", kinds, expected)
+	}
+	if last := events[len(events)-1]; last.Comment.Name != "b" {
+		t.Errorf("got OP reply %s; wanted b", last.Comment.Name)
+	}
+
+	if events, _ := w.update(); len(events) != 1 {
+		t.Errorf("got %+v; wanted only the deleted comment", events)
+	}
+}
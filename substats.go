@@ -0,0 +1,216 @@
+package graw
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/turnage/graw/clock"
+	"github.com/turnage/graw/reddit"
+)
+
+const (
+	defaultStatsWindow = time.Hour
+	// statsTop is the number of top authors and domains reported.
+	statsTop = 10
+)
+
+// SubredditStats keeps rolling statistics of the activity in the subreddits a
+// bot monitors (see Config's Subreddits and SubredditComments), for dashboards
+// which need no pipeline of their own. Read them with Stats, or from the
+// /stats endpoint of the health server. Its methods are goroutine safe.
+//
+// Every post and comment the feeds deliver is counted, before the config's
+// policies choose which are forwarded to the bot.
+type SubredditStats struct {
+	window time.Duration
+	// clock is the time the statistics keep, which is the run's (see
+	// Config's Clock) once one is started with them.
+	clock clock.Clock
+	start time.Time
+	// arrivals are the recent posts and comments of each subreddit, oldest
+	// first.
+	arrivals map[string][]arrival
+	mu       *sync.Mutex
+}
+
+// arrival is a post or comment counted in the statistics.
+type arrival struct {
+	at     time.Time
+	post   bool
+	author string
+	domain string
+}
+
+// SubredditStat is the recent activity of a subreddit.
+type SubredditStat struct {
+	PostsPerHour    float64 `json:"posts_per_hour"`
+	CommentsPerHour float64 `json:"comments_per_hour"`
+	// TopAuthors are the authors of the most posts and comments, and
+	// TopDomains the domains of the most link posts, most first.
+	TopAuthors []Tally `json:"top_authors"`
+	TopDomains []Tally `json:"top_domains"`
+}
+
+// Tally is a count of the posts or comments with a common property.
+type Tally struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// NewSubredditStats returns statistics kept over a rolling window, e.g. the
+// last hour, which is the default.
+func NewSubredditStats(window time.Duration) *SubredditStats {
+	if window <= 0 {
+		window = defaultStatsWindow
+	}
+
+	return &SubredditStats{
+		window:   window,
+		clock:    clock.Real,
+		start:    clock.Real.Now(),
+		arrivals: make(map[string][]arrival),
+		mu:       &sync.Mutex{},
+	}
+}
+
+// Stats returns the activity of each subreddit with posts or comments in the
+// window, by name.
+func (s *SubredditStats) Stats() map[string]SubredditStat {
+	s.mu.Lock()
+	now := s.clock.Now()
+	s.mu.Unlock()
+
+	return s.stats(now)
+}
+
+// keepTime makes the statistics keep the clock's time, and starts their window
+// over at its time.
+func (s *SubredditStats) keepTime(clk clock.Clock) {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.clock = clk
+	s.start = clk.Now()
+}
+
+func (s *SubredditStats) stats(now time.Time) map[string]SubredditStat {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// A young run has not seen a whole window yet, and its rates are
+	// over the time it has seen.
+	hours := s.window.Hours()
+	if age := now.Sub(s.start); age < s.window {
+		hours = age.Hours()
+	}
+
+	stats := make(map[string]SubredditStat)
+	for sub := range s.arrivals {
+		s.prune(sub, now)
+		recent := s.arrivals[sub]
+		if len(recent) == 0 {
+			continue
+		}
+
+		posts := 0
+		authors := make(map[string]int)
+		domains := make(map[string]int)
+		for _, a := range recent {
+			if a.post {
+				posts++
+			}
+			if a.author != "" && a.author != "[deleted]" {
+				authors[a.author]++
+			}
+			if a.domain != "" {
+				domains[a.domain]++
+			}
+		}
+
+		stat := SubredditStat{
+			TopAuthors: top(authors),
+			TopDomains: top(domains),
+		}
+		if hours > 0 {
+			stat.PostsPerHour = float64(posts) / hours
+			stat.CommentsPerHour = float64(len(recent)-posts) / hours
+		}
+		stats[sub] = stat
+	}
+	return stats
+}
+
+// post counts a post.
+func (s *SubredditStats) post(p *reddit.Post, now time.Time) {
+	domain := ""
+	if !p.IsSelf {
+		domain = p.Domain
+	}
+	s.add(
+		p.Subreddit,
+		arrival{at: now, post: true, author: p.Author, domain: domain},
+	)
+}
+
+// comment counts a comment.
+func (s *SubredditStats) comment(c *reddit.Comment, now time.Time) {
+	s.add(c.Subreddit, arrival{at: now, author: c.Author})
+}
+
+func (s *SubredditStats) add(sub string, a arrival) {
+	if s == nil || sub == "" {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.arrivals[sub] = append(s.arrivals[sub], a)
+	s.prune(sub, a.at)
+}
+
+// prune forgets the arrivals in the subreddit older than the window, and the
+// subreddit if none are left. Callers hold mu.
+func (s *SubredditStats) prune(sub string, now time.Time) {
+	recent := s.arrivals[sub]
+	i := sort.Search(len(recent), func(i int) bool {
+		return now.Sub(recent[i].at) < s.window
+	})
+
+	if i == len(recent) {
+		delete(s.arrivals, sub)
+	} else if i > 0 {
+		s.arrivals[sub] = append([]arrival{}, recent[i:]...)
+	}
+}
+
+// top returns the names with the highest counts, highest first.
+func top(counts map[string]int) []Tally {
+	tallies := ranked(counts)
+	if len(tallies) > statsTop {
+		tallies = tallies[:statsTop]
+	}
+	return tallies
+}
+
+// ranked returns every name by its count, highest first, and by name among
+// equal counts.
+func ranked(counts map[string]int) []Tally {
+	tallies := make([]Tally, 0, len(counts))
+	for name, count := range counts {
+		tallies = append(tallies, Tally{Name: name, Count: count})
+	}
+
+	sort.Slice(tallies, func(i, j int) bool {
+		if tallies[i].Count != tallies[j].Count {
+			return tallies[i].Count > tallies[j].Count
+		}
+		return tallies[i].Name < tallies[j].Name
+	})
+	return tallies
+}
@@ -0,0 +1,106 @@
+package graw
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/turnage/graw/clock"
+	"github.com/turnage/graw/reddit"
+)
+
+func TestSubredditStats(t *testing.T) {
+	s := NewSubredditStats(time.Hour)
+	start := time.Now()
+	s.start = start
+
+	for i := 0; i < 4; i++ {
+		s.post(&reddit.Post{
+			Subreddit: "golang",
+			Author:    fmt.Sprintf("user%d", i%2),
+			Domain:    "github.com",
+		}, start.Add(time.Duration(i)*time.Minute))
+	}
+	s.post(&reddit.Post{
+		Subreddit: "golang",
+		Author:    "user0",
+		Domain:    "self.golang",
+		IsSelf:    true,
+	}, start.Add(5*time.Minute))
+	for i := 0; i < 6; i++ {
+		s.comment(&reddit.Comment{
+			Subreddit: "golang",
+			Author:    "[deleted]",
+		}, start.Add(10*time.Minute))
+		s.comment(&reddit.Comment{
+			Subreddit: "rust",
+			Author:    "crab",
+		}, start.Add(50*time.Minute))
+	}
+
+	stats := s.stats(start.Add(30 * time.Minute))
+	golang := stats["golang"]
+	if golang.PostsPerHour != 10 || golang.CommentsPerHour != 12 {
+		t.Errorf(
+			"got %v posts and %v comments per hour; wanted 10 and 12",
+			golang.PostsPerHour, golang.CommentsPerHour,
+		)
+	}
+	want := []Tally{{"user0", 3}, {"user1", 2}}
+	if !reflect.DeepEqual(golang.TopAuthors, want) {
+		t.Errorf("got top authors %v; wanted %v", golang.TopAuthors, want)
+	}
+	want = []Tally{{"github.com", 4}}
+	if !reflect.DeepEqual(golang.TopDomains, want) {
+		t.Errorf("got top domains %v; wanted %v", golang.TopDomains, want)
+	}
+
+	// An hour and a half in, only the rust comments are in the window, and rates
+	// are over the whole window.
+	stats = s.stats(start.Add(90 * time.Minute))
+	if _, ok := stats["golang"]; ok {
+		t.Errorf("got stats for golang after its activity left the window")
+	}
+	if rust := stats["rust"]; rust.CommentsPerHour != 6 {
+		t.Errorf(
+			"got %v rust comments per hour; wanted 6",
+			rust.CommentsPerHour,
+		)
+	}
+}
+
+func TestTop(t *testing.T) {
+	counts := make(map[string]int)
+	for i := 0; i < statsTop+5; i++ {
+		counts[fmt.Sprintf("name%02d", i)] = i
+	}
+
+	tallies := top(counts)
+	if len(tallies) != statsTop {
+		t.Fatalf("got %d tallies; wanted %d", len(tallies), statsTop)
+	}
+	if tallies[0].Name != fmt.Sprintf("name%02d", statsTop+4) {
+		t.Errorf("got %v first; wanted the highest count", tallies[0])
+	}
+}
+
+func TestSubredditStatsKeepRunTime(t *testing.T) {
+	v := clock.NewVirtual(time.Unix(1500000000, 0))
+	s := NewSubredditStats(time.Hour)
+	newDispatcher(Config{SubredditStats: s, Clock: v}, "", nil)
+
+	s.post(&reddit.Post{Subreddit: "golang", Author: "gopher"}, v.Now())
+	v.Advance(30 * time.Minute)
+
+	// The run is half an hour old by its clock, however long the test
+	// took.
+	if rate := s.Stats()["golang"].PostsPerHour; rate != 2 {
+		t.Errorf("got %v posts per hour; wanted 2", rate)
+	}
+
+	v.Advance(2 * time.Hour)
+	if stats := s.Stats(); len(stats) != 0 {
+		t.Errorf("got stats %v after the window passed", stats)
+	}
+}
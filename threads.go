@@ -0,0 +1,173 @@
+package graw
+
+import (
+	"strings"
+	"time"
+
+	"github.com/turnage/graw/botfaces"
+	"github.com/turnage/graw/clock"
+	"github.com/turnage/graw/link"
+	"github.com/turnage/graw/reddit"
+	"github.com/turnage/graw/snapshot"
+	"github.com/turnage/graw/streams"
+)
+
+// threadHandlers are the handlers a bot implements for events in watched
+// threads. Events for handlers the bot does not implement are dropped.
+type threadHandlers struct {
+	comments  botfaces.ThreadHandler
+	gildings  botfaces.GildingHandler
+	flairs    botfaces.FlairHandler
+	op        botfaces.OPReplyHandler
+	deletions botfaces.DeletionHandler
+}
+
+// newThreadHandlers returns the bot's thread handlers, or an error if it
+// implements none of them.
+func newThreadHandlers(handler interface{}) (threadHandlers, error) {
+	var t threadHandlers
+	t.comments, _ = handler.(botfaces.ThreadHandler)
+	t.gildings, _ = handler.(botfaces.GildingHandler)
+	t.flairs, _ = handler.(botfaces.FlairHandler)
+	t.op, _ = handler.(botfaces.OPReplyHandler)
+	t.deletions, _ = handler.(botfaces.DeletionHandler)
+
+	if t.comments == nil && t.gildings == nil && t.flairs == nil &&
+		t.op == nil && t.deletions == nil {
+		return t, threadHandlerErr
+	}
+	return t, nil
+}
+
+// dispatch forwards a thread event to the handler for it, if the bot
+// implements one.
+func (t threadHandlers) dispatch(d *dispatcher, e streams.ThreadEvent) {
+	switch {
+	case e.Kind == streams.ThreadComment && t.comments != nil:
+		d.dispatch(
+			commentEv(threadEvent, e.Comment),
+			func() error { return t.comments.ThreadComment(e.Comment) },
+		)
+	case e.Kind == streams.ThreadPostGilded && t.gildings != nil:
+		d.dispatch(
+			repeatable(postEv(postGildedEvent, e.Post)),
+			func() error { return t.gildings.PostGilded(e.Post) },
+		)
+	case e.Kind == streams.ThreadCommentGilded && t.gildings != nil:
+		d.dispatch(
+			repeatable(commentEv(commentGildedEvent, e.Comment)),
+			func() error { return t.gildings.CommentGilded(e.Comment) },
+		)
+	case e.Kind == streams.ThreadFlairChanged && t.flairs != nil:
+		d.dispatch(
+			repeatable(postEv(flairEvent, e.Post)),
+			func() error { return t.flairs.FlairChanged(e.Post, e.OldFlair) },
+		)
+	case e.Kind == streams.ThreadOPReply && t.op != nil:
+		d.dispatch(
+			opReplyEv(e.Comment),
+			func() error { return t.op.OPReply(e.Post, e.Comment) },
+		)
+	case e.Kind == streams.ThreadPostDeleted && t.deletions != nil:
+		d.dispatch(
+			deletedEv(postEv(postDeletedEvent, e.Post)),
+			func() error {
+				return t.deletions.PostDeleted(e.Post, e.Removal)
+			},
+		)
+	case e.Kind == streams.ThreadCommentDeleted && t.deletions != nil:
+		d.dispatch(
+			deletedEv(commentEv(commentDeletedEvent, e.Comment)),
+			func() error {
+				return t.deletions.CommentDeleted(e.Comment, e.Removal)
+			},
+		)
+	}
+}
+
+// expireThread returns the kill signal for a watched thread, which is closed
+// when the run is killed or once the thread has been watched for the expiry.
+// When the thread expires, a snapshot of it is saved if the archiver is set.
+// Threads never expire if the expiry is not set.
+func expireThread(
+	lurker reddit.Lurker,
+	permalink string,
+	expiry time.Duration,
+	clk clock.Clock,
+	archiver *snapshot.Archiver,
+	kill <-chan bool,
+	errs chan<- error,
+) <-chan bool {
+	if expiry <= 0 {
+		return kill
+	}
+
+	threadKill := make(chan bool)
+	go func() {
+		defer close(threadKill)
+		select {
+		case <-kill:
+		case <-clk.After(expiry):
+			if archiver == nil {
+				return
+			}
+			if _, err := archiver.Snapshot(lurker, permalink); err != nil {
+				errs <- err
+			}
+		}
+	}()
+	return threadKill
+}
+
+// threadPermalinks returns the threads named in the config by their
+// permalinks, without duplicates. Threads may be named by a link to them or to
+// a comment in them (e.g. https://www.reddit.com/r/golang/comments/5du939/title/
+// or https://redd.it/5du939), their id (5du939), or their fullname
+// (t3_5du939). Names which are none of these are returned in SourceErrors.
+func threadPermalinks(threads []string) ([]string, error) {
+	var permalinks []string
+	seen := make(map[string]bool)
+	problems := SourceErrors{}
+	for _, thread := range threads {
+		permalink, ok := threadPermalink(thread)
+		if !ok {
+			problems[thread] = threadLinkErr
+		} else if !seen[permalink] {
+			seen[permalink] = true
+			permalinks = append(permalinks, permalink)
+		}
+	}
+
+	if len(problems) > 0 {
+		return nil, problems
+	}
+	return permalinks, nil
+}
+
+// idChars are the characters of Reddit's base 36 ids.
+const idChars = "0123456789abcdefghijklmnopqrstuvwxyz"
+
+// threadPermalink returns the permalink of the thread named by a link, id, or
+// fullname, and false if it names none.
+func threadPermalink(thread string) (string, bool) {
+	thread = strings.TrimSpace(thread)
+	l, err := link.Parse(thread)
+	if err != nil {
+		typ, id := link.Split(thread)
+		if typ == "" {
+			id = thread
+		} else if typ != link.PostType {
+			return "", false
+		}
+
+		if id == "" || strings.TrimLeft(id, idChars) != "" {
+			return "", false
+		}
+		l = link.Link{Post: id}
+	}
+
+	// The permalink of a comment is of the thread under it; watch the
+	// whole thread.
+	l.Comment = ""
+	return strings.TrimSuffix(l.Permalink(), "/"), true
+}
@@ -0,0 +1,263 @@
+package graw
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/turnage/graw/clock"
+	"github.com/turnage/graw/reddit"
+	"github.com/turnage/graw/snapshot"
+	"github.com/turnage/graw/store"
+	"github.com/turnage/graw/streams"
+)
+
+// gildingBot records gildings it is handed.
+type gildingBot struct {
+	gilded []string
+}
+
+func (g *gildingBot) PostGilded(p *reddit.Post) error {
+	g.gilded = append(g.gilded, p.Name)
+	return nil
+}
+
+func (g *gildingBot) CommentGilded(c *reddit.Comment) error {
+	g.gilded = append(g.gilded, c.Name)
+	return nil
+}
+
+func TestThreadHandlers(t *testing.T) {
+	if _, err := newThreadHandlers(struct{}{}); err != threadHandlerErr {
+		t.Errorf("got %v; wanted %v", err, threadHandlerErr)
+	}
+
+	bot := &gildingBot{}
+	th, err := newThreadHandlers(bot)
+	if err != nil {
+		t.Fatalf("error getting handlers: %v", err)
+	}
+
+	errs := make(chan error, 10)
+	d := newDispatcher(Config{}, "", errs)
+	post := &reddit.Post{Name: "t3_a"}
+	for _, e := range []streams.ThreadEvent{
+		{Kind: streams.ThreadPostGilded, Post: post},
+		// The bot does not handle comments, so this is dropped.
+		{Kind: streams.ThreadComment, Post: post, Comment: &reddit.Comment{Name: "t1_a"}},
+		{Kind: streams.ThreadCommentGilded, Post: post, Comment: &reddit.Comment{Name: "t1_b"}},
+		// Gildings repeat.
+		{Kind: streams.ThreadPostGilded, Post: post},
+	} {
+		th.dispatch(d, e)
+	}
+
+	if len(bot.gilded) != 3 ||
+		bot.gilded[0] != "t3_a" ||
+		bot.gilded[1] != "t1_b" ||
+		bot.gilded[2] != "t3_a" {
+		t.Errorf("got gildings %v; wanted t3_a, t1_b, t3_a", bot.gilded)
+	}
+}
+
+// opBot records thread comments and OP replies it is handed.
+type opBot struct {
+	comments []string
+	replies  []string
+}
+
+func (o *opBot) ThreadComment(c *reddit.Comment) error {
+	o.comments = append(o.comments, c.Name)
+	return nil
+}
+
+func (o *opBot) OPReply(p *reddit.Post, c *reddit.Comment) error {
+	o.replies = append(o.replies, c.Name)
+	return nil
+}
+
+func TestThreadHandlersOPReply(t *testing.T) {
+	bot := &opBot{}
+	th, err := newThreadHandlers(bot)
+	if err != nil {
+		t.Fatalf("error getting handlers: %v", err)
+	}
+
+	errs := make(chan error, 10)
+	d := newDispatcher(Config{Seen: store.NewMemory()}, "", errs)
+	post := &reddit.Post{Name: "t3_a", Author: "op"}
+	reply := &reddit.Comment{Name: "t1_a", Author: "op"}
+	for _, e := range []streams.ThreadEvent{
+		{Kind: streams.ThreadComment, Post: post, Comment: reply},
+		// The reply is seen as a thread comment, but not as an OP reply.
+		{Kind: streams.ThreadOPReply, Post: post, Comment: reply},
+		{Kind: streams.ThreadOPReply, Post: post, Comment: reply},
+	} {
+		th.dispatch(d, e)
+	}
+
+	if len(bot.comments) != 1 || len(bot.replies) != 1 {
+		t.Errorf(
+			"got comments %v and replies %v; wanted t1_a once each",
+			bot.comments, bot.replies,
+		)
+	}
+}
+
+// deletionBot records thread comments and the removals it is handed.
+type deletionBot struct {
+	comments []string
+	removals []string
+}
+
+func (b *deletionBot) ThreadComment(c *reddit.Comment) error {
+	b.comments = append(b.comments, c.Name)
+	return nil
+}
+
+func (b *deletionBot) PostDeleted(p *reddit.Post, r reddit.Removal) error {
+	b.removals = append(b.removals, p.Name+" "+string(r))
+	return nil
+}
+
+func (b *deletionBot) CommentDeleted(
+	c *reddit.Comment,
+	r reddit.Removal,
+) error {
+	b.removals = append(b.removals, c.Name+" "+string(r))
+	return nil
+}
+
+func TestThreadHandlersDeletion(t *testing.T) {
+	bot := &deletionBot{}
+	th, err := newThreadHandlers(bot)
+	if err != nil {
+		t.Fatalf("error getting handlers: %v", err)
+	}
+
+	errs := make(chan error, 10)
+	d := newDispatcher(Config{Seen: store.NewMemory()}, "", errs)
+	post := &reddit.Post{Name: "t3_a", RemovedByCategory: "moderator"}
+	comment := &reddit.Comment{Name: "t1_a", Body: "[deleted]"}
+	for _, e := range []streams.ThreadEvent{
+		{Kind: streams.ThreadComment, Post: post, Comment: comment},
+		// The comment is seen as a thread comment, but not as deleted.
+		{
+			Kind:    streams.ThreadCommentDeleted,
+			Post:    post,
+			Comment: comment,
+			Removal: reddit.DeletedByAuthor,
+		},
+		{
+			Kind:    streams.ThreadPostDeleted,
+			Post:    post,
+			Removal: reddit.RemovedByModerator,
+		},
+	} {
+		th.dispatch(d, e)
+	}
+
+	want := []string{"t1_a deleted", "t3_a moderator"}
+	if len(bot.comments) != 1 ||
+		strings.Join(bot.removals, ",") != strings.Join(want, ",") {
+		t.Errorf(
+			"got comments %v and removals %v; wanted t1_a and %v",
+			bot.comments, bot.removals, want,
+		)
+	}
+}
+
+func TestExpireThread(t *testing.T) {
+	kill := make(chan bool)
+	threadKill := expireThread(nil, "", 0, clock.Real, nil, kill, nil)
+	if threadKill != kill {
+		t.Errorf("threads without an expiry should share the run's kill")
+	}
+
+	dir, err := ioutil.TempDir("", "graw")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	errs := make(chan error, 1)
+	v := clock.NewVirtual(time.Unix(1486847000, 0))
+	threadKill = expireThread(
+		&threadLurker{post: &reddit.Post{Name: "t3_a", Title: "title"}},
+		"/r/golang/comments/a",
+		time.Hour,
+		v,
+		&snapshot.Archiver{Dir: dir},
+		kill,
+		errs,
+	)
+
+	v.BlockUntil(1)
+	v.Advance(59 * time.Minute)
+	select {
+	case <-threadKill:
+		t.Fatalf("thread expired early")
+	default:
+	}
+
+	v.Advance(time.Minute)
+	select {
+	case <-threadKill:
+	case err := <-errs:
+		t.Fatalf("error expiring thread: %v", err)
+	case <-time.After(time.Second):
+		t.Fatalf("thread did not expire")
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "t3_a.json")); err != nil {
+		t.Errorf("snapshot was not saved when the thread expired: %v", err)
+	}
+
+	// Killing the run stops threads before they expire.
+	threadKill = expireThread(nil, "", time.Hour, v, nil, kill, errs)
+	close(kill)
+	select {
+	case <-threadKill:
+	case <-time.After(time.Second):
+		t.Errorf("thread was not stopped with the run")
+	}
+}
+
+func TestThreadPermalinks(t *testing.T) {
+	permalinks, err := threadPermalinks([]string{
+		"/r/golang/comments/5du939",
+		"https://www.reddit.com/r/golang/comments/5du939/title/d8s9dfa/",
+		"https://redd.it/5du939",
+		"5du939",
+		"t3_5du939",
+		"t3_abc",
+	})
+	if err != nil {
+		t.Fatalf("error normalizing threads: %v", err)
+	}
+
+	want := []string{
+		"/r/golang/comments/5du939",
+		"/comments/5du939",
+		"/comments/abc",
+	}
+	if len(permalinks) != len(want) {
+		t.Fatalf("got permalinks %v; wanted %v", permalinks, want)
+	}
+	for i := range want {
+		if permalinks[i] != want[i] {
+			t.Errorf("got permalinks %v; wanted %v", permalinks, want)
+		}
+	}
+
+	_, err = threadPermalinks([]string{"t1_d8s9dfa", "/r/golang/new", "5du939"})
+	problems, ok := err.(SourceErrors)
+	if !ok || len(problems) != 2 ||
+		problems["t1_d8s9dfa"] != threadLinkErr ||
+		problems["/r/golang/new"] != threadLinkErr {
+		t.Errorf("got error %v; wanted SourceErrors for two names", err)
+	}
+}
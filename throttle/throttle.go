@@ -0,0 +1,116 @@
+// Package throttle limits how often a bot acts on any one key, such as the
+// username of the author it is responding to.
+//
+// A throttle is a cheap guard against reply loops and spam:
+//
+//	// Respond to each user at most once every ten minutes.
+//	t := throttle.New(10 * time.Minute)
+//
+//	func (b *bot) Comment(c *reddit.Comment) error {
+//	  if !t.Allow(c.Author) {
+//	    return nil
+//	  }
+//	  return b.Reply(c.Name, "hello!")
+//	}
+//
+// graw applies throttles itself if they are configured in graw.Config.
+package throttle
+
+import (
+	"sync"
+	"time"
+)
+
+// pruneThreshold is the least number of tracked keys past which a throttle
+// forgets keys whose cooldown has passed.
+const pruneThreshold = 1024
+
+// Throttle tracks a cooldown per key. Its methods are goroutine safe.
+type Throttle struct {
+	cooldown time.Duration
+	last     map[string]time.Time
+	// limit is the number of tracked keys past which the throttle next
+	// forgets keys whose cooldown has passed.
+	limit int
+	mu    *sync.Mutex
+}
+
+// New returns a throttle which allows each key once per cooldown.
+func New(cooldown time.Duration) *Throttle {
+	return &Throttle{
+		cooldown: cooldown,
+		last:     make(map[string]time.Time),
+		limit:    pruneThreshold,
+		mu:       &sync.Mutex{},
+	}
+}
+
+// Allow returns true if the key is not cooling down, and starts a new cooldown
+// for it if so.
+func (t *Throttle) Allow(key string) bool {
+	return t.AllowAt(key, time.Now())
+}
+
+// Remaining returns how long the key will be cooling down for.
+func (t *Throttle) Remaining(key string) time.Duration {
+	return t.RemainingAt(key, time.Now())
+}
+
+// RemainingAt is Remaining at the given time, for callers which keep their own
+// clock, such as a virtual one.
+func (t *Throttle) RemainingAt(key string, now time.Time) time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	last, ok := t.last[key]
+	if !ok {
+		return 0
+	}
+
+	if left := t.cooldown - now.Sub(last); left > 0 {
+		return left
+	}
+	return 0
+}
+
+// Reset ends the cooldown of the key.
+func (t *Throttle) Reset(key string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	delete(t.last, key)
+}
+
+// AllowAt is Allow at the given time, for callers which keep their own clock,
+// such as a virtual one.
+func (t *Throttle) AllowAt(key string, now time.Time) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if last, ok := t.last[key]; ok && now.Sub(last) < t.cooldown {
+		return false
+	}
+
+	if len(t.last) >= t.limit {
+		t.prune(now)
+	}
+
+	t.last[key] = now
+	return true
+}
+
+// prune forgets all keys which are no longer cooling down. The next prune
+// waits until twice as many keys as are left are tracked, so a throttle with
+// many keys cooling down does not sweep them all on every call.
+func (t *Throttle) prune(now time.Time) {
+	for key, last := range t.last {
+		if now.Sub(last) >= t.cooldown {
+			delete(t.last, key)
+		}
+	}
+
+	t.limit = 2 * len(t.last)
+	if t.limit < pruneThreshold {
+		t.limit = pruneThreshold
+	}
+}
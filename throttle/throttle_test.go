@@ -0,0 +1,95 @@
+package throttle
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestAllow(t *testing.T) {
+	th := New(time.Minute)
+	start := time.Now()
+
+	for i, test := range []struct {
+		key   string
+		at    time.Duration
+		allow bool
+	}{
+		{"roxven", 0, true},
+		{"roxven", time.Second, false},
+		{"other", time.Second, true},
+		{"roxven", time.Minute - time.Second, false},
+		{"roxven", time.Minute, true},
+		{"roxven", time.Minute + time.Second, false},
+	} {
+		if allow := th.AllowAt(test.key, start.Add(test.at)); allow != test.allow {
+			t.Errorf("%d: got %v; wanted %v", i, allow, test.allow)
+		}
+	}
+}
+
+func TestReset(t *testing.T) {
+	th := New(time.Hour)
+	th.Allow("roxven")
+	th.Reset("roxven")
+
+	if !th.Allow("roxven") {
+		t.Errorf("key was not allowed after reset")
+	}
+
+	if left := th.Remaining("roxven"); left <= 0 || left > time.Hour {
+		t.Errorf("remaining cooldown %v; wanted in (0, 1h]", left)
+	}
+}
+
+func TestRemainingAt(t *testing.T) {
+	th := New(time.Hour)
+	start := time.Unix(1000, 0)
+	th.AllowAt("roxven", start)
+
+	for i, test := range []struct {
+		at   time.Duration
+		left time.Duration
+	}{
+		{0, time.Hour},
+		{20 * time.Minute, 40 * time.Minute},
+		{time.Hour, 0},
+		{2 * time.Hour, 0},
+	} {
+		if left := th.RemainingAt("roxven", start.Add(test.at)); left != test.left {
+			t.Errorf("%d: got %v; wanted %v", i, left, test.left)
+		}
+	}
+}
+
+func TestPrune(t *testing.T) {
+	th := New(time.Minute)
+	start := time.Now()
+	for i := 0; i < pruneThreshold; i++ {
+		th.AllowAt(fmt.Sprintf("user%d", i), start)
+	}
+
+	th.AllowAt("late", start.Add(time.Hour))
+	if len(th.last) != 1 {
+		t.Errorf("tracking %d keys; wanted expired keys pruned", len(th.last))
+	}
+}
+
+func TestPruneAmortized(t *testing.T) {
+	th := New(time.Hour)
+	start := time.Now()
+	for i := 0; i <= pruneThreshold; i++ {
+		th.AllowAt(fmt.Sprintf("user%d", i), start)
+	}
+
+	// None of the keys had cooled down, so the next sweep waits until
+	// twice as many are tracked.
+	if th.limit != 2*pruneThreshold {
+		t.Errorf("next prune at %d keys; wanted %d", th.limit, 2*pruneThreshold)
+	}
+
+	th.AllowAt("late", start.Add(2*time.Hour))
+	if len(th.last) != pruneThreshold+2 {
+		t.Errorf("tracking %d keys; wanted none pruned yet", len(th.last))
+	}
+}
@@ -0,0 +1,142 @@
+// Package trace defines the spans graw and the reddit package record, so the
+// latency of a bot, from its requests to Reddit through its handlers, can be
+// traced in production.
+//
+// The interfaces mirror OpenTelemetry's, and an adapter around an OpenTelemetry
+// tracer is a few lines; the exporter (OTLP, Jaeger, stdout, ...) is whatever
+// the tracer provider is configured with:
+//
+//	type otelTracer struct{ t oteltrace.Tracer }
+//
+//	func (o otelTracer) Start(
+//	  ctx context.Context,
+//	  name string,
+//	) (context.Context, trace.Span) {
+//	  ctx, span := o.t.Start(ctx, name)
+//	  return ctx, otelSpan{span}
+//	}
+//
+//	type otelSpan struct{ oteltrace.Span }
+//
+//	func (s otelSpan) SetAttribute(key string, value interface{}) {
+//	  s.SetAttributes(attribute.String(key, fmt.Sprint(value)))
+//	}
+//
+//	exporter, _ := otlptracehttp.New(ctx)
+//	provider := sdktrace.NewTracerProvider(sdktrace.WithBatcher(exporter))
+//	cfg := graw.Config{Tracer: otelTracer{provider.Tracer("graw")}, ...}
+//
+// Without OpenTelemetry, Log exports spans as log lines.
+package trace
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Tracer starts spans.
+type Tracer interface {
+	// Start starts a span, a child of the span in the context if there is
+	// one, and returns a context carrying the new span.
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// Span is a timed operation.
+type Span interface {
+	// SetAttribute records a property of the operation, e.g. the path of
+	// a request.
+	SetAttribute(key string, value interface{})
+	// RecordError records that the operation failed.
+	RecordError(err error)
+	// End ends the span. It is not used after.
+	End()
+}
+
+// spanKey is the context key of the span a log tracer started.
+type spanKey struct{}
+
+// Log returns a tracer which writes each span to the logger when it ends, with
+// its duration, attributes, and the ids linking it to its trace and parent.
+func Log(logger *log.Logger) Tracer {
+	return &logTracer{logger: logger}
+}
+
+type logTracer struct {
+	logger *log.Logger
+}
+
+func (t *logTracer) Start(
+	ctx context.Context,
+	name string,
+) (context.Context, Span) {
+	s := &logSpan{
+		logger: t.logger,
+		name:   name,
+		id:     newID(),
+		start:  time.Now(),
+		attrs:  make(map[string]interface{}),
+	}
+	if parent, ok := ctx.Value(spanKey{}).(*logSpan); ok {
+		s.trace, s.parent = parent.trace, parent.id
+	} else {
+		s.trace = newID()
+	}
+	return context.WithValue(ctx, spanKey{}, s), s
+}
+
+type logSpan struct {
+	logger *log.Logger
+	name   string
+	trace  string
+	id     string
+	parent string
+	start  time.Time
+	attrs  map[string]interface{}
+	err    error
+}
+
+func (s *logSpan) SetAttribute(key string, value interface{}) {
+	s.attrs[key] = value
+}
+
+func (s *logSpan) RecordError(err error) {
+	s.err = err
+}
+
+func (s *logSpan) End() {
+	keys := make([]string, 0, len(s.attrs))
+	for key := range s.attrs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	fmt.Fprintf(
+		&b,
+		"span %s %v trace=%s id=%s",
+		s.name, time.Since(s.start), s.trace, s.id,
+	)
+	if s.parent != "" {
+		fmt.Fprintf(&b, " parent=%s", s.parent)
+	}
+	for _, key := range keys {
+		fmt.Fprintf(&b, " %s=%v", key, s.attrs[key])
+	}
+	if s.err != nil {
+		fmt.Fprintf(&b, " error=%q", s.err.Error())
+	}
+	s.logger.Print(b.String())
+}
+
+// newID returns a random span or trace id.
+func newID() string {
+	id := make([]byte, 8)
+	rand.Read(id)
+	return hex.EncodeToString(id)
+}
@@ -0,0 +1,53 @@
+package trace
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestLog(t *testing.T) {
+	var buf bytes.Buffer
+	tracer := Log(log.New(&buf, "", 0))
+
+	ctx, parent := tracer.Start(context.Background(), "poll")
+	_, child := tracer.Start(ctx, "dispatch")
+	child.SetAttribute("kind", "post")
+	child.SetAttribute("done", false)
+	child.RecordError(fmt.Errorf("handler failed"))
+	child.End()
+	parent.End()
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines; wanted 2: %q", len(lines), buf.String())
+	}
+
+	line := regexp.MustCompile(
+		`^span (\S+) \S+ trace=(\w+) id=(\w+)(?: parent=(\w+))?(.*)$`,
+	)
+	c, p := line.FindStringSubmatch(lines[0]), line.FindStringSubmatch(lines[1])
+	if c == nil || p == nil {
+		t.Fatalf("lines do not describe spans: %q", lines)
+	}
+
+	if c[1] != "dispatch" || p[1] != "poll" {
+		t.Errorf("got spans %s and %s; wanted dispatch and poll", c[1], p[1])
+	}
+	if c[2] != p[2] {
+		t.Errorf("child is in trace %s; wanted its parent's %s", c[2], p[2])
+	}
+	if c[4] != p[3] {
+		t.Errorf("child's parent is %s; wanted %s", c[4], p[3])
+	}
+	if p[4] != "" {
+		t.Errorf("root span has parent %s", p[4])
+	}
+	if want := ` done=false kind=post error="handler failed"`; c[5] != want {
+		t.Errorf("got attributes %q; wanted %q", c[5], want)
+	}
+}
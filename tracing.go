@@ -0,0 +1,183 @@
+package graw
+
+import (
+	"context"
+	"sync"
+
+	"github.com/turnage/graw/reddit"
+	"github.com/turnage/graw/trace"
+)
+
+// maxPolls is the number of things whose poll is remembered, so their events
+// can be traced back to it.
+const maxPolls = 10000
+
+// tracing records spans for the listing polls and event dispatches of a run.
+// Each dispatch span is a child of the span of the poll which fetched its
+// thing, so one trace follows a thing from Reddit to the bot's handler.
+type tracing struct {
+	tracer trace.Tracer
+	// polls holds the context of the poll which first fetched each thing,
+	// by fullname; order holds the fullnames, oldest first, so the oldest
+	// can be forgotten.
+	polls map[string]context.Context
+	order []string
+	mu    *sync.Mutex
+}
+
+// newTracing returns tracing for the tracer, or nil if there is no tracer.
+func newTracing(tracer trace.Tracer) *tracing {
+	if tracer == nil {
+		return nil
+	}
+
+	return &tracing{
+		tracer: tracer,
+		polls:  make(map[string]context.Context),
+		mu:     &sync.Mutex{},
+	}
+}
+
+// scanner returns the scanner with its listing polls traced.
+func (t *tracing) scanner(sc reddit.Scanner) reddit.Scanner {
+	if t == nil {
+		return sc
+	}
+	return &tracedScanner{Scanner: sc, t: t}
+}
+
+// remember records the poll which fetched the things in the harvest, unless
+// an earlier poll already fetched them.
+func (t *tracing) remember(ctx context.Context, h reddit.Harvest) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	add := func(name string) {
+		if _, ok := t.polls[name]; ok {
+			return
+		}
+
+		t.polls[name] = ctx
+		t.order = append(t.order, name)
+		if len(t.order) > maxPolls {
+			delete(t.polls, t.order[0])
+			t.order = t.order[1:]
+		}
+	}
+
+	for _, p := range h.Posts {
+		add(p.Name)
+	}
+	for _, c := range h.Comments {
+		add(c.Name)
+	}
+	for _, m := range h.Messages {
+		add(m.Name)
+	}
+}
+
+// poll returns the context of the poll which fetched the thing, or the
+// background context if it is not known.
+func (t *tracing) poll(name string) context.Context {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if ctx, ok := t.polls[name]; ok {
+		return ctx
+	}
+	return context.Background()
+}
+
+// dispatch traces the dispatch of an event, with a child span for the
+// handler if the event gets to it. It returns whether the event is done with,
+// as forward does.
+func (t *tracing) dispatch(
+	e event,
+	handle func() error,
+	forward func(func() error) bool,
+) bool {
+	name := ""
+	switch thing := e.thing.(type) {
+	case *reddit.Post:
+		name = thing.Name
+	case *reddit.Comment:
+		name = thing.Name
+	case *reddit.Message:
+		name = thing.Name
+	}
+
+	ctx, span := t.tracer.Start(t.poll(name), "graw.dispatch")
+	defer span.End()
+	span.SetAttribute("graw.event.kind", string(e.kind))
+	span.SetAttribute("graw.event.name", name)
+
+	handled := false
+	done := forward(func() error {
+		handled = true
+		_, hspan := t.tracer.Start(ctx, "graw.handle")
+		defer hspan.End()
+
+		err := handle()
+		if err != nil {
+			hspan.RecordError(err)
+			span.RecordError(err)
+		}
+		return err
+	})
+
+	span.SetAttribute("graw.event.handled", handled)
+	span.SetAttribute("graw.event.done", done)
+	return done
+}
+
+// tracedScanner records a span for each listing poll.
+type tracedScanner struct {
+	reddit.Scanner
+	t *tracing
+}
+
+func (s *tracedScanner) Listing(path, after string) (reddit.Harvest, error) {
+	return s.trace(path, func() (reddit.Harvest, error) {
+		return s.Scanner.Listing(path, after)
+	})
+}
+
+func (s *tracedScanner) ListingWithParams(
+	path string,
+	params map[string]string,
+) (reddit.Harvest, error) {
+	return s.trace(path, func() (reddit.Harvest, error) {
+		return s.Scanner.ListingWithParams(path, params)
+	})
+}
+
+func (s *tracedScanner) ListingWithOptions(
+	path string,
+	opts reddit.ListingOptions,
+) (reddit.Harvest, error) {
+	return s.trace(path, func() (reddit.Harvest, error) {
+		return s.Scanner.ListingWithOptions(path, opts)
+	})
+}
+
+func (s *tracedScanner) trace(
+	path string,
+	poll func() (reddit.Harvest, error),
+) (reddit.Harvest, error) {
+	ctx, span := s.t.tracer.Start(context.Background(), "graw.poll")
+	defer span.End()
+	span.SetAttribute("reddit.path", path)
+
+	h, err := poll()
+	if err != nil {
+		span.RecordError(err)
+		return h, err
+	}
+
+	span.SetAttribute(
+		"graw.poll.things",
+		len(h.Posts)+len(h.Comments)+len(h.Messages),
+	)
+	s.t.remember(ctx, h)
+	return h, nil
+}
@@ -0,0 +1,141 @@
+package graw
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/turnage/graw/reddit"
+	"github.com/turnage/graw/trace"
+)
+
+// testSpan is a span recorded by testTracer.
+type testSpan struct {
+	name   string
+	parent *testSpan
+	attrs  map[string]interface{}
+	err    error
+	ended  bool
+}
+
+func (s *testSpan) SetAttribute(key string, value interface{}) {
+	s.attrs[key] = value
+}
+
+func (s *testSpan) RecordError(err error) { s.err = err }
+
+func (s *testSpan) End() { s.ended = true }
+
+type testSpanKey struct{}
+
+// testTracer keeps the spans it starts, linked to their parents.
+type testTracer struct {
+	spans []*testSpan
+	mu    sync.Mutex
+}
+
+func (t *testTracer) Start(
+	ctx context.Context,
+	name string,
+) (context.Context, trace.Span) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	s := &testSpan{name: name, attrs: make(map[string]interface{})}
+	s.parent, _ = ctx.Value(testSpanKey{}).(*testSpan)
+	t.spans = append(t.spans, s)
+	return context.WithValue(ctx, testSpanKey{}, s), s
+}
+
+// named returns the spans with the name, in the order they started.
+func (t *testTracer) named(name string) []*testSpan {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var spans []*testSpan
+	for _, s := range t.spans {
+		if s.name == name {
+			spans = append(spans, s)
+		}
+	}
+	return spans
+}
+
+func TestTracingLinksDispatchToPoll(t *testing.T) {
+	tracer := &testTracer{}
+	errs := make(chan error, 10)
+	d := newDispatcher(Config{Tracer: tracer}, "", errs)
+
+	listing := &growingListing{}
+	listing.add("t3_a")
+	h, err := d.tracing.scanner(listing).Listing("/r/golang/new", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := h.Posts[0]
+	d.dispatch(postEv(postEvent, p), func() error { return nil })
+	d.dispatch(
+		postEv(postEvent, &reddit.Post{Name: "t3_b"}),
+		func() error { return fmt.Errorf("handler failed") },
+	)
+
+	polls := tracer.named("graw.poll")
+	dispatches := tracer.named("graw.dispatch")
+	handles := tracer.named("graw.handle")
+	if len(polls) != 1 || len(dispatches) != 2 || len(handles) != 2 {
+		t.Fatalf(
+			"got %d polls, %d dispatches, and %d handles; wanted 1, 2, 2",
+			len(polls), len(dispatches), len(handles),
+		)
+	}
+
+	if polls[0].attrs["reddit.path"] != "/r/golang/new" ||
+		polls[0].attrs["graw.poll.things"] != 1 {
+		t.Errorf("got poll attributes %v", polls[0].attrs)
+	}
+	if dispatches[0].parent != polls[0] {
+		t.Errorf("dispatch of a polled post is not a child of its poll")
+	}
+	if handles[0].parent != dispatches[0] {
+		t.Errorf("handle is not a child of its dispatch")
+	}
+	if dispatches[0].attrs["graw.event.done"] != true {
+		t.Errorf("got dispatch attributes %v", dispatches[0].attrs)
+	}
+
+	if dispatches[1].parent != nil {
+		t.Errorf("dispatch of an unpolled post has a parent")
+	}
+	if dispatches[1].err == nil || handles[1].err == nil {
+		t.Errorf("failed handler's spans have no error")
+	}
+	if dispatches[1].attrs["graw.event.done"] != false {
+		t.Errorf("got dispatch attributes %v", dispatches[1].attrs)
+	}
+
+	for _, s := range tracer.named("graw.dispatch") {
+		if !s.ended {
+			t.Errorf("span %s was not ended", s.name)
+		}
+	}
+}
+
+func TestTracingForgetsOldPolls(t *testing.T) {
+	tr := newTracing(&testTracer{})
+	ctx := context.WithValue(context.Background(), testSpanKey{}, "poll")
+
+	var h reddit.Harvest
+	for i := 0; i <= maxPolls; i++ {
+		h.Posts = append(h.Posts, &reddit.Post{Name: fmt.Sprintf("t3_%d", i)})
+	}
+	tr.remember(ctx, h)
+
+	if tr.poll("t3_0") != context.Background() {
+		t.Errorf("oldest poll was not forgotten")
+	}
+	if tr.poll(fmt.Sprintf("t3_%d", maxPolls)) != ctx {
+		t.Errorf("newest poll was forgotten")
+	}
+}
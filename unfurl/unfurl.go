@@ -0,0 +1,501 @@
+// Package unfurl fetches the pages link posts point to and reads their titles
+// and Open Graph metadata, for bots which check links or catch posts whose
+// titles misrepresent them:
+//
+//	f := unfurl.NewFetcher(unfurl.FetcherConfig{Agent: agent})
+//
+//	func (b *bot) Post(p *reddit.Post) error {
+//	  page, err := f.FetchPost(p)
+//	  if err == unfurl.NoLinkErr {
+//	    return nil
+//	  } else if err != nil {
+//	    return err
+//	  }
+//	  if !similar(p.Title, page.Title) {
+//	    return b.report(p, "title does not match the article")
+//	  }
+//	  ...
+//	}
+//
+// Fetches are made with care for the sites fetched and for the bot: pages are
+// fetched no more often than once a second per host, robots.txt is obeyed,
+// bodies are read only up to a size limit, and links to private addresses
+// (e.g. localhost, or the bot's own network) are refused.
+package unfurl
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/turnage/graw/reddit"
+)
+
+const (
+	defaultMaxBytes     = 1 << 20
+	defaultTimeout      = 10 * time.Second
+	defaultHostInterval = time.Second
+	// maxRedirects is the most redirects followed to reach a page.
+	maxRedirects = 5
+	// maxRobotsBytes is the most of a robots.txt file read.
+	maxRobotsBytes = 512 << 10
+	// maxSites is the most hosts whose turns and robots.txt rules are
+	// remembered; past it, they are forgotten and fetched again.
+	maxSites = 1000
+)
+
+var (
+	// NoLinkErr is returned for posts which do not link off Reddit.
+	NoLinkErr = fmt.Errorf("post does not link off Reddit")
+	// NotPageErr is returned for links which are not to HTML pages, such
+	// as images and downloads.
+	NotPageErr = fmt.Errorf("link is not to an HTML page")
+	// DisallowedErr is returned for links the site's robots.txt asks
+	// bots not to fetch.
+	DisallowedErr  = fmt.Errorf("robots.txt disallows fetching the link")
+	privateAddrErr = fmt.Errorf("link is to a private address")
+	schemeErr      = fmt.Errorf("link is not http or https")
+	redirectsErr   = fmt.Errorf("link redirects too many times")
+)
+
+var (
+	titlePattern = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+	metaPattern  = regexp.MustCompile(`(?is)<meta\s([^>]*)>`)
+	attrPattern  = regexp.MustCompile(
+		`([a-zA-Z:_-]+)\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s"'>]+))`,
+	)
+	// headEnd marks where a page's metadata ends.
+	headEnd = regexp.MustCompile(`(?i)</head>|<body[\s>]`)
+)
+
+// redditHosts serve Reddit's own pages, which are not unfurled.
+var redditHosts = []string{"reddit.com", "redd.it", "redditmedia.com"}
+
+// Page is what a page says about itself.
+type Page struct {
+	// URL is where the page was found, after redirects.
+	URL string `json:"url"`
+	// Title is the text of the page's title element, and Description
+	// that of its description meta tag.
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	// OGTitle, OGDescription, OGSiteName, OGImage, and OGType are the
+	// page's Open Graph metadata (og:title etc.), which sites set for
+	// link previews.
+	OGTitle       string `json:"og_title,omitempty"`
+	OGDescription string `json:"og_description,omitempty"`
+	OGSiteName    string `json:"og_site_name,omitempty"`
+	OGImage       string `json:"og_image,omitempty"`
+	OGType        string `json:"og_type,omitempty"`
+}
+
+// FetcherConfig configures a Fetcher.
+type FetcherConfig struct {
+	// Agent is the user agent pages are requested with, and the one
+	// robots.txt rules are matched against; build one with
+	// reddit.UserAgent.
+	Agent string
+	// MaxBytes is the most of a page read. Titles and metadata are at the
+	// top of pages, so the rest is not downloaded. The default is 1 MiB.
+	MaxBytes int64
+	// HostInterval is the least time between requests to each host. The
+	// default is a second.
+	HostInterval time.Duration
+	// IgnoreRobots fetches pages robots.txt asks bots not to fetch.
+	IgnoreRobots bool
+	// Client makes the requests. The default is a client with a ten
+	// second timeout which refuses to connect to private addresses.
+	Client *http.Client
+}
+
+// Fetcher fetches pages. Its methods are goroutine safe.
+type Fetcher struct {
+	c FetcherConfig
+	// next is when each host may next be sent a request, and robots the
+	// rules of each host's robots.txt, by scheme and host.
+	next   map[string]time.Time
+	robots map[string]*robots
+	mu     *sync.Mutex
+}
+
+// NewFetcher returns a Fetcher for the config.
+func NewFetcher(c FetcherConfig) *Fetcher {
+	if c.MaxBytes <= 0 {
+		c.MaxBytes = defaultMaxBytes
+	}
+	if c.HostInterval <= 0 {
+		c.HostInterval = defaultHostInterval
+	}
+	if c.Client == nil {
+		c.Client = publicClient()
+	}
+
+	client := *c.Client
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if len(via) >= maxRedirects {
+			return redirectsErr
+		}
+		return checkScheme(req.URL)
+	}
+	c.Client = &client
+
+	return &Fetcher{
+		c:      c,
+		next:   make(map[string]time.Time),
+		robots: make(map[string]*robots),
+		mu:     &sync.Mutex{},
+	}
+}
+
+// FetchPost fetches the page the post links to. It returns NoLinkErr if the
+// post is a self post, or links to Reddit.
+func (f *Fetcher) FetchPost(p *reddit.Post) (*Page, error) {
+	if p.IsSelf || p.URL == "" || isReddit(p.URL) {
+		return nil, NoLinkErr
+	}
+	return f.Fetch(p.URL)
+}
+
+// Fetch fetches the page at the link and reads what it says about itself.
+func (f *Fetcher) Fetch(link string) (*Page, error) {
+	u, err := url.Parse(link)
+	if err != nil {
+		return nil, err
+	} else if err := checkScheme(u); err != nil {
+		return nil, err
+	}
+
+	if !f.c.IgnoreRobots {
+		rules, err := f.robotsOf(u)
+		if err != nil {
+			return nil, err
+		} else if !rules.allows(u.RequestURI()) {
+			return nil, DisallowedErr
+		}
+	}
+
+	resp, err := f.get(u)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching %s: %s", link, resp.Status)
+	}
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+		return nil, NotPageErr
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, f.c.MaxBytes))
+	if err != nil {
+		return nil, err
+	}
+
+	page := parsePage(string(body))
+	page.URL = resp.Request.URL.String()
+	return page, nil
+}
+
+// get requests the link, once the host's interval since its last request has
+// passed.
+func (f *Fetcher) get(u *url.URL) (*http.Response, error) {
+	f.wait(u.Host)
+
+	req, err := http.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if f.c.Agent != "" {
+		req.Header.Set("User-Agent", f.c.Agent)
+	}
+	req.Header.Set("Accept", "text/html,application/xhtml+xml")
+	return f.c.Client.Do(req)
+}
+
+// wait blocks until the host may be sent a request, and reserves its turn.
+func (f *Fetcher) wait(host string) {
+	host = strings.ToLower(host)
+
+	f.mu.Lock()
+	now := time.Now()
+	if len(f.next) >= maxSites {
+		for h, turn := range f.next {
+			if turn.Before(now) {
+				delete(f.next, h)
+			}
+		}
+	}
+	turn := f.next[host]
+	if turn.Before(now) {
+		turn = now
+	}
+	f.next[host] = turn.Add(f.c.HostInterval)
+	f.mu.Unlock()
+
+	time.Sleep(turn.Sub(now))
+}
+
+// robotsOf returns the rules of the robots.txt of the link's site, fetching
+// it the first time the site is linked. Sites without one allow everything.
+func (f *Fetcher) robotsOf(u *url.URL) (*robots, error) {
+	site := u.Scheme + "://" + strings.ToLower(u.Host)
+
+	f.mu.Lock()
+	rules, ok := f.robots[site]
+	f.mu.Unlock()
+	if ok {
+		return rules, nil
+	}
+
+	robotsURL, err := url.Parse(site + "/robots.txt")
+	if err != nil {
+		return nil, err
+	}
+	resp, err := f.get(robotsURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusOK:
+		rules = parseRobots(
+			io.LimitReader(resp.Body, maxRobotsBytes),
+			f.c.Agent,
+		)
+	case resp.StatusCode >= 500:
+		// A site which cannot serve its robots.txt may be struggling;
+		// fetch nothing from it until it can.
+		return nil, fmt.Errorf(
+			"fetching %s: %s", robotsURL, resp.Status,
+		)
+	default:
+		rules = &robots{}
+	}
+
+	f.mu.Lock()
+	if len(f.robots) >= maxSites {
+		f.robots = make(map[string]*robots)
+	}
+	f.robots[site] = rules
+	f.mu.Unlock()
+	return rules, nil
+}
+
+// parsePage reads the title and metadata in the head of a page.
+func parsePage(body string) *Page {
+	if loc := headEnd.FindStringIndex(body); loc != nil {
+		body = body[:loc[0]]
+	}
+
+	page := &Page{}
+	if m := titlePattern.FindStringSubmatch(body); m != nil {
+		page.Title = clean(m[1])
+	}
+
+	for _, m := range metaPattern.FindAllStringSubmatch(body, -1) {
+		attrs := make(map[string]string)
+		for _, a := range attrPattern.FindAllStringSubmatch(m[1], -1) {
+			attrs[strings.ToLower(a[1])] = a[2] + a[3] + a[4]
+		}
+
+		key := strings.ToLower(attrs["property"])
+		if key == "" {
+			key = strings.ToLower(attrs["name"])
+		}
+		value := clean(attrs["content"])
+
+		switch key {
+		case "description":
+			page.Description = value
+		case "og:title":
+			page.OGTitle = value
+		case "og:description":
+			page.OGDescription = value
+		case "og:site_name":
+			page.OGSiteName = value
+		case "og:image", "og:image:url":
+			if page.OGImage == "" {
+				page.OGImage = value
+			}
+		case "og:type":
+			page.OGType = value
+		}
+	}
+	return page
+}
+
+// clean unescapes text and collapses its whitespace.
+func clean(text string) string {
+	return strings.Join(strings.Fields(html.UnescapeString(text)), " ")
+}
+
+// robots are the rules of a robots.txt which apply to an agent.
+type robots struct {
+	allow    []string
+	disallow []string
+}
+
+// parseRobots parses the rules of a robots.txt which apply to the agent: those
+// of the groups whose name is in the agent, or if there are none, those of
+// the groups for every agent (*).
+func parseRobots(r io.Reader, agent string) *robots {
+	agent = strings.ToLower(agent)
+
+	named, every := &robots{}, &robots{}
+	var groups []*robots
+	// inRules is true once the current group's rules have begun, so the
+	// next user-agent line starts a new group.
+	inRules := false
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		field := strings.ToLower(strings.TrimSpace(parts[0]))
+		value := strings.TrimSpace(parts[1])
+
+		switch field {
+		case "user-agent":
+			if inRules {
+				groups, inRules = nil, false
+			}
+			switch name := strings.ToLower(value); {
+			case name == "*":
+				groups = append(groups, every)
+			case name != "" && strings.Contains(agent, name):
+				groups = append(groups, named)
+			}
+		case "allow", "disallow":
+			inRules = true
+			if value == "" {
+				continue
+			}
+			for _, g := range groups {
+				if field == "allow" {
+					g.allow = append(g.allow, value)
+				} else {
+					g.disallow = append(g.disallow, value)
+				}
+			}
+		}
+	}
+
+	if len(named.allow)+len(named.disallow) > 0 {
+		return named
+	}
+	return every
+}
+
+// allows returns true if the rules allow fetching the path. The longest rule
+// matching the path decides; allow rules win ties.
+func (r *robots) allows(path string) bool {
+	allowed, longest := true, -1
+	for _, rule := range r.disallow {
+		if matchRobots(rule, path) && len(rule) > longest {
+			allowed, longest = false, len(rule)
+		}
+	}
+	for _, rule := range r.allow {
+		if matchRobots(rule, path) && len(rule) >= longest {
+			allowed, longest = true, len(rule)
+		}
+	}
+	return allowed
+}
+
+// matchRobots returns true if the path matches the rule: a path prefix, in
+// which * matches anything and a trailing $ anchors the end.
+func matchRobots(rule, path string) bool {
+	anchored := strings.HasSuffix(rule, "$")
+	parts := strings.Split(strings.TrimSuffix(rule, "$"), "*")
+	if !strings.HasPrefix(path, parts[0]) {
+		return false
+	}
+
+	rest := path[len(parts[0]):]
+	for _, part := range parts[1:] {
+		i := strings.Index(rest, part)
+		if i < 0 {
+			return false
+		}
+		rest = rest[i+len(part):]
+	}
+	if !anchored {
+		return true
+	}
+	last := parts[len(parts)-1]
+	return rest == "" || (len(parts) > 1 && strings.HasSuffix(path, last))
+}
+
+// isReddit returns true if the link is to one of Reddit's own hosts.
+func isReddit(link string) bool {
+	u, err := url.Parse(link)
+	if err != nil {
+		return false
+	}
+
+	host := strings.ToLower(u.Hostname())
+	for _, h := range redditHosts {
+		if host == h || strings.HasSuffix(host, "."+h) {
+			return true
+		}
+	}
+	return false
+}
+
+func checkScheme(u *url.URL) error {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return schemeErr
+	}
+	return nil
+}
+
+// publicClient returns a client which only connects to public addresses, so
+// a link cannot make the bot fetch from its own machine or network.
+func publicClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: defaultTimeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !public(ip) {
+				return privateAddrErr
+			}
+			return nil
+		},
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{Timeout: defaultTimeout, Transport: transport}
+}
+
+// public returns true if the address is reachable on the public internet.
+func public(ip net.IP) bool {
+	return !ip.IsLoopback() && !ip.IsPrivate() && !ip.IsUnspecified() &&
+		!ip.IsLinkLocalUnicast() && !ip.IsLinkLocalMulticast() &&
+		!ip.IsInterfaceLocalMulticast() && !ip.IsMulticast()
+}
@@ -0,0 +1,161 @@
+package unfurl
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kylelemons/godebug/pretty"
+	"github.com/turnage/graw/reddit"
+)
+
+const article = `<!DOCTYPE html>
+<html><head>
+<title>
+  Go 1.8 is released &amp; fast
+</title>
+<meta name="description" content="The latest Go release">
+<meta property="og:title" content="Go 1.8 is released">
+<meta property='og:site_name' content='The Go Blog'>
+<meta content="https://blog.golang.org/gopher.png" property="og:image">
+<meta property="og:type" content=article>
+</head><body>
+<meta property="og:title" content="not metadata">
+</body></html>`
+
+func TestFetch(t *testing.T) {
+	var agents []string
+	srv := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			agents = append(agents, r.UserAgent())
+			switch r.URL.Path {
+			case "/robots.txt":
+				fmt.Fprint(w, "User-agent: *\nDisallow: /private\n")
+			case "/moved":
+				http.Redirect(w, r, "/go1.8", http.StatusFound)
+			case "/go1.8":
+				w.Header().Set("Content-Type", "text/html; charset=utf-8")
+				fmt.Fprint(w, article)
+			case "/gopher.png":
+				w.Header().Set("Content-Type", "image/png")
+			}
+		},
+	))
+	defer srv.Close()
+
+	f := NewFetcher(FetcherConfig{
+		Agent:        "graw-test",
+		HostInterval: time.Millisecond,
+		Client:       srv.Client(),
+	})
+
+	page, err := f.FetchPost(&reddit.Post{URL: srv.URL + "/moved"})
+	if err != nil {
+		t.Fatalf("error fetching page: %v", err)
+	}
+	if diff := pretty.Compare(page, &Page{
+		URL:         srv.URL + "/go1.8",
+		Title:       "Go 1.8 is released & fast",
+		Description: "The latest Go release",
+		OGTitle:     "Go 1.8 is released",
+		OGSiteName:  "The Go Blog",
+		OGImage:     "https://blog.golang.org/gopher.png",
+		OGType:      "article",
+	}); diff != "" {
+		t.Errorf("page incorrect; diff: %s", diff)
+	}
+
+	if _, err := f.Fetch(srv.URL + "/private/page"); err != DisallowedErr {
+		t.Errorf("got error %v for disallowed page; wanted DisallowedErr", err)
+	}
+	if _, err := f.Fetch(srv.URL + "/gopher.png"); err != NotPageErr {
+		t.Errorf("got error %v for an image; wanted NotPageErr", err)
+	}
+
+	// robots.txt is fetched once, and every request names the agent.
+	if len(agents) != 4 {
+		t.Errorf("got %d requests; wanted 4", len(agents))
+	}
+	for _, agent := range agents {
+		if agent != "graw-test" {
+			t.Errorf("got user agent %q; wanted graw-test", agent)
+		}
+	}
+}
+
+func TestFetchPostNoLink(t *testing.T) {
+	f := NewFetcher(FetcherConfig{})
+	for _, p := range []*reddit.Post{
+		{IsSelf: true, URL: "https://www.reddit.com/r/golang/comments/a"},
+		{URL: "https://i.redd.it/a.png"},
+		{URL: "https://old.reddit.com/r/golang"},
+	} {
+		if _, err := f.FetchPost(p); err != NoLinkErr {
+			t.Errorf("got error %v for %s; wanted NoLinkErr", err, p.URL)
+		}
+	}
+}
+
+func TestFetchRefusesPrivateAddresses(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			t.Errorf("private address was fetched")
+		},
+	))
+	defer srv.Close()
+
+	f := NewFetcher(FetcherConfig{IgnoreRobots: true})
+	_, err := f.Fetch(srv.URL)
+	if err == nil || !strings.Contains(err.Error(), privateAddrErr.Error()) {
+		t.Errorf("got error %v; wanted %v", err, privateAddrErr)
+	}
+
+	if _, err := f.Fetch("file:///etc/passwd"); err != schemeErr {
+		t.Errorf("got error %v for a file link; wanted %v", err, schemeErr)
+	}
+}
+
+func TestRobots(t *testing.T) {
+	rules := parseRobots(strings.NewReader(`
+# Everyone else
+User-agent: *
+Disallow: /
+
+User-agent: OtherBot
+User-agent: GrawBot
+Disallow: /private
+Allow: /private/press
+Disallow: /*.pdf$
+`), "linux:grawbot:1.0 (by /u/turnage)")
+
+	for _, test := range []struct {
+		path    string
+		allowed bool
+	}{
+		{"/", true},
+		{"/news", true},
+		{"/private", false},
+		{"/private/notes", false},
+		{"/private/press/release", true},
+		{"/paper.pdf", false},
+		{"/paper.pdf.html", true},
+	} {
+		if allowed := rules.allows(test.path); allowed != test.allowed {
+			t.Errorf(
+				"%s: got allowed %v; wanted %v",
+				test.path, allowed, test.allowed,
+			)
+		}
+	}
+
+	everyone := parseRobots(
+		strings.NewReader("User-agent: *\nDisallow: /\n"),
+		"grawbot",
+	)
+	if everyone.allows("/news") {
+		t.Errorf("rules for every agent were not applied")
+	}
+}
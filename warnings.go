@@ -0,0 +1,50 @@
+package graw
+
+import (
+	"log"
+
+	"github.com/turnage/graw/reddit"
+)
+
+// warned returns the scanner with the parse warnings of the listings it
+// fetches logged, so things Reddit has changed and the parser left out are
+// noticed rather than missed.
+func warned(sc reddit.Scanner, logger *log.Logger) reddit.Scanner {
+	return &warnedScanner{Scanner: sc, logger: logger}
+}
+
+type warnedScanner struct {
+	reddit.Scanner
+	logger *log.Logger
+}
+
+func (s *warnedScanner) Listing(path, after string) (reddit.Harvest, error) {
+	return s.log(path)(s.Scanner.Listing(path, after))
+}
+
+func (s *warnedScanner) ListingWithParams(
+	path string,
+	params map[string]string,
+) (reddit.Harvest, error) {
+	return s.log(path)(s.Scanner.ListingWithParams(path, params))
+}
+
+func (s *warnedScanner) ListingWithOptions(
+	path string,
+	opts reddit.ListingOptions,
+) (reddit.Harvest, error) {
+	return s.log(path)(s.Scanner.ListingWithOptions(path, opts))
+}
+
+// log returns a function which logs the warnings of a harvest from the path
+// and passes the harvest on.
+func (s *warnedScanner) log(
+	path string,
+) func(reddit.Harvest, error) (reddit.Harvest, error) {
+	return func(h reddit.Harvest, err error) (reddit.Harvest, error) {
+		for _, w := range h.Warnings {
+			s.logger.Printf("Parsing %s: %v", path, w)
+		}
+		return h, err
+	}
+}
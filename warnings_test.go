@@ -0,0 +1,41 @@
+package graw
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"strings"
+	"testing"
+
+	"github.com/turnage/graw/reddit"
+)
+
+// driftScanner returns a harvest with a warning from every listing.
+type driftScanner struct {
+	reddit.Scanner
+}
+
+func (s *driftScanner) Listing(path, after string) (reddit.Harvest, error) {
+	return reddit.Harvest{
+		Posts: []*reddit.Post{{Name: "t3_a"}},
+		Warnings: []reddit.ParseWarning{{
+			Kind:  "t3",
+			Name:  "t3_a",
+			Field: "score",
+			Err:   errors.New("expected number"),
+		}},
+	}, nil
+}
+
+func TestWarnedScanner(t *testing.T) {
+	var buf bytes.Buffer
+	sc := warned(&driftScanner{}, log.New(&buf, "", 0))
+
+	h, err := sc.Listing("/r/golang/new", "")
+	if err != nil || len(h.Posts) != 1 {
+		t.Fatalf("got harvest %v, error %v; wanted the post", h, err)
+	}
+	if !strings.Contains(buf.String(), `/r/golang/new: t3_a field "score"`) {
+		t.Errorf("got log %q; wanted the warning", buf.String())
+	}
+}